@@ -0,0 +1,262 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+// Package detect_lang_ngram_analyzer provides an analyzer
+// which detects the language of its input and analyzes it
+// with the analyzer of that language.
+//
+// The language is detected by comparing the character
+// trigrams of the input to those of the stop words of each
+// language, which make up most of any running text.  No
+// other data is needed, but the detection is only reliable
+// for text of more than a few words.
+package detect_lang_ngram_analyzer
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/blevesearch/bleve/analysis"
+	"github.com/blevesearch/bleve/analysis/analyzers/standard_analyzer"
+	"github.com/blevesearch/bleve/registry"
+)
+
+const Name = "detect_lang_ngram"
+
+// LanguageTermPrefix prefixes the language code in the
+// term added to the tokens of text in a detected language,
+// so documents can be filtered by language with a term
+// query for "lang:fr" on the field.
+const LanguageTermPrefix = "lang:"
+
+// DefaultLanguages are the languages detected when none are
+// configured, those without a registered analyzer or stop
+// word list are skipped.
+var DefaultLanguages = []string{"da", "de", "en", "es", "fi", "fr", "hu", "it", "nl", "no", "pt", "ro", "sv"}
+
+// DefaultConfidence is the confidence below which the
+// fallback analyzer is used.
+const DefaultConfidence = 0.3
+
+// inputs with fewer trigrams than this have their
+// confidence reduced in proportion, as a handful of
+// trigrams is too little to tell the languages apart
+const minTrigrams = 20
+
+// a profile is the unit vector of trigram frequencies
+type profile map[string]float64
+
+// DetectLangAnalyzer routes its input to the analyzer of
+// the detected language.  It is used as the tokenizer of
+// an analysis.Analyzer, the tokens it returns have already
+// been through the whole language analyzer.
+type DetectLangAnalyzer struct {
+	languages  []string
+	profiles   map[string]profile
+	analyzers  map[string]*analysis.Analyzer
+	fallback   *analysis.Analyzer
+	confidence float64
+}
+
+// NewDetectLangAnalyzer creates an analyzer detecting the
+// languages of the analyzers, using stop words to build
+// their profiles.  Input detected with less than
+// confidence is analyzed by fallback, and gets no
+// language term.
+func NewDetectLangAnalyzer(analyzers map[string]*analysis.Analyzer, stopWords map[string]analysis.TokenMap, fallback *analysis.Analyzer, confidence float64) *DetectLangAnalyzer {
+	rv := DetectLangAnalyzer{
+		profiles:   make(map[string]profile, len(analyzers)),
+		analyzers:  analyzers,
+		fallback:   fallback,
+		confidence: confidence,
+	}
+	for language := range analyzers {
+		rv.languages = append(rv.languages, language)
+		counts := make(map[string]float64)
+		for word := range stopWords[language] {
+			addTrigrams(counts, word)
+		}
+		rv.profiles[language] = newProfile(counts)
+	}
+	sort.Strings(rv.languages)
+	return &rv
+}
+
+// Detect returns the most likely language of the input,
+// and a confidence from 0 to 1, the relative margin of its
+// similarity over that of the next most likely language,
+// reduced for short inputs.
+func (a *DetectLangAnalyzer) Detect(input []byte) (string, float64) {
+	counts := make(map[string]float64)
+	for _, word := range words(input) {
+		addTrigrams(counts, word)
+	}
+	trigrams := 0.0
+	for _, count := range counts {
+		trigrams += count
+	}
+	text := newProfile(counts)
+
+	best, next := 0.0, 0.0
+	rv := ""
+	for _, language := range a.languages {
+		similarity := 0.0
+		for trigram, weight := range text {
+			similarity += weight * a.profiles[language][trigram]
+		}
+		if similarity > best {
+			best, next = similarity, best
+			rv = language
+		} else if similarity > next {
+			next = similarity
+		}
+	}
+	if best == 0 {
+		return "", 0
+	}
+	return rv, (best - next) / best * math.Min(1, trigrams/minTrigrams)
+}
+
+func (a *DetectLangAnalyzer) Tokenize(input []byte) analysis.TokenStream {
+	language, confidence := a.Detect(input)
+	if language == "" || confidence < a.confidence {
+		return a.fallback.Analyze(input)
+	}
+	rv := a.analyzers[language].Analyze(input)
+	position := 0
+	for _, token := range rv {
+		if token.Position > position {
+			position = token.Position
+		}
+	}
+	return append(rv, &analysis.Token{
+		Term:     []byte(LanguageTermPrefix + language),
+		Position: position + 1,
+		Type:     analysis.AlphaNumeric,
+		KeyWord:  true,
+	})
+}
+
+// words returns the lower cased runs of letters in the
+// input
+func words(input []byte) []string {
+	var rv []string
+	var word []rune
+	for len(input) > 0 {
+		r, size := utf8.DecodeRune(input)
+		input = input[size:]
+		if unicode.IsLetter(r) {
+			word = append(word, unicode.ToLower(r))
+			continue
+		}
+		if len(word) > 0 {
+			rv = append(rv, string(word))
+			word = word[:0]
+		}
+	}
+	if len(word) > 0 {
+		rv = append(rv, string(word))
+	}
+	return rv
+}
+
+// addTrigrams counts the trigrams of the word, padded with
+// a space at each end so short words have some
+func addTrigrams(counts map[string]float64, word string) {
+	runes := []rune(" " + word + " ")
+	for i := 0; i+3 <= len(runes); i++ {
+		counts[string(runes[i:i+3])]++
+	}
+}
+
+func newProfile(counts map[string]float64) profile {
+	norm := 0.0
+	for _, count := range counts {
+		norm += count * count
+	}
+	norm = math.Sqrt(norm)
+	rv := make(profile, len(counts))
+	for trigram, count := range counts {
+		rv[trigram] = count / norm
+	}
+	return rv
+}
+
+// AnalyzerConstructor builds a DetectLangAnalyzer.  The
+// "languages" config maps language codes to the names of
+// their analyzers, and defaults to DefaultLanguages.  The
+// stop words of a language are the token map "stop_" +
+// code.  "fallback" names the analyzer used when the
+// language is not detected, standard by default, and
+// "confidence" overrides DefaultConfidence.
+func AnalyzerConstructor(config map[string]interface{}, cache *registry.Cache) (*analysis.Analyzer, error) {
+	analyzers := make(map[string]*analysis.Analyzer)
+	stopWords := make(map[string]analysis.TokenMap)
+	languages, ok := config["languages"].(map[string]interface{})
+	if ok {
+		for language, analyzerName := range languages {
+			name, ok := analyzerName.(string)
+			if !ok {
+				return nil, fmt.Errorf("analyzer name for language '%s' must be a string", language)
+			}
+			analyzer, err := cache.AnalyzerNamed(name)
+			if err != nil {
+				return nil, err
+			}
+			stop, err := cache.TokenMapNamed("stop_" + language)
+			if err != nil {
+				return nil, err
+			}
+			analyzers[language] = analyzer
+			stopWords[language] = stop
+		}
+	} else {
+		for _, language := range DefaultLanguages {
+			analyzer, err := cache.AnalyzerNamed(language)
+			if err != nil {
+				continue
+			}
+			stop, err := cache.TokenMapNamed("stop_" + language)
+			if err != nil {
+				continue
+			}
+			analyzers[language] = analyzer
+			stopWords[language] = stop
+		}
+	}
+	if len(analyzers) == 0 {
+		return nil, fmt.Errorf("no languages to detect")
+	}
+
+	fallbackName := standard_analyzer.Name
+	if name, ok := config["fallback"].(string); ok {
+		fallbackName = name
+	}
+	fallback, err := cache.AnalyzerNamed(fallbackName)
+	if err != nil {
+		return nil, err
+	}
+
+	confidence := DefaultConfidence
+	if c, ok := config["confidence"].(float64); ok {
+		confidence = c
+	}
+
+	rv := analysis.Analyzer{
+		Tokenizer: NewDetectLangAnalyzer(analyzers, stopWords, fallback, confidence),
+	}
+	return &rv, nil
+}
+
+func init() {
+	registry.RegisterAnalyzer(Name, AnalyzerConstructor)
+}
@@ -0,0 +1,115 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package detect_lang_ngram_analyzer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/blevesearch/bleve/analysis"
+	_ "github.com/blevesearch/bleve/analysis/analyzers/custom_analyzer"
+	_ "github.com/blevesearch/bleve/analysis/language/en"
+	_ "github.com/blevesearch/bleve/analysis/language/fr"
+	_ "github.com/blevesearch/bleve/analysis/tokenizers/unicode"
+	"github.com/blevesearch/bleve/registry"
+)
+
+func testAnalyzer(t *testing.T, config map[string]interface{}) *analysis.Analyzer {
+	cache := registry.NewCache()
+	// the french analyzer needs a stemmer from libstemmer
+	_, err := cache.DefineAnalyzer("fr_simple", map[string]interface{}{
+		"type":          "custom",
+		"tokenizer":     "unicode",
+		"token_filters": []interface{}{"elision_fr", "to_lower", "stop_fr"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	config["type"] = Name
+	config["languages"] = map[string]interface{}{
+		"en": "en",
+		"fr": "fr_simple",
+	}
+	analyzer, err := cache.DefineAnalyzer("detect", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return analyzer
+}
+
+func terms(tokens analysis.TokenStream) []string {
+	rv := make([]string, len(tokens))
+	for i, token := range tokens {
+		rv[i] = string(token.Term)
+	}
+	return rv
+}
+
+func TestDetectLangAnalyzer(t *testing.T) {
+	analyzer := testAnalyzer(t, map[string]interface{}{})
+
+	tests := []struct {
+		input    []byte
+		language string
+		output   []string
+	}{
+		{
+			input:    []byte("The cats are sleeping on the warm roof of the house"),
+			language: "en",
+			output:   []string{"cat", "sleep", "warm", "roof", "hous", "lang:en"},
+		},
+		{
+			input:    []byte("Les chats dorment sur le toit chaud de l'ancienne maison"),
+			language: "fr",
+			output:   []string{"chats", "dorment", "toit", "chaud", "ancienne", "maison", "lang:fr"},
+		},
+		// too short to tell, so the standard analyzer is used
+		{
+			input:    []byte("le chat"),
+			language: "",
+			output:   []string{"le", "chat"},
+		},
+	}
+
+	detector := analyzer.Tokenizer.(*DetectLangAnalyzer)
+	for _, test := range tests {
+		language, confidence := detector.Detect(test.input)
+		if test.language != "" && language != test.language {
+			t.Errorf("expected %s detected for %q, got %s", test.language, test.input, language)
+		}
+		if test.language == "" && confidence >= DefaultConfidence {
+			t.Errorf("expected low confidence for %q, got %s with %f", test.input, language, confidence)
+		}
+		actual := terms(analyzer.Analyze(test.input))
+		if !reflect.DeepEqual(actual, test.output) {
+			t.Errorf("expected %v for %q, got %v", test.output, test.input, actual)
+		}
+	}
+
+	// the language term follows the other tokens
+	tokens := analyzer.Analyze([]byte("The cats are sleeping on the warm roof of the house"))
+	last := tokens[len(tokens)-1]
+	if last.Position != tokens[len(tokens)-2].Position+1 {
+		t.Errorf("expected language term at position %d, got %d", tokens[len(tokens)-2].Position+1, last.Position)
+	}
+}
+
+func TestDetectLangAnalyzerConfidence(t *testing.T) {
+	// nothing is confident enough, so everything falls back
+	analyzer := testAnalyzer(t, map[string]interface{}{
+		"confidence": 1.0,
+		"fallback":   "en",
+	})
+	actual := terms(analyzer.Analyze([]byte("Les chats dorment sur le toit")))
+	expected := []string{"le", "chat", "dorment", "sur", "le", "toit"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
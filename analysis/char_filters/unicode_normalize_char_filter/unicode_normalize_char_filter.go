@@ -0,0 +1,82 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package unicode_normalize_char_filter
+
+import (
+	"fmt"
+
+	"github.com/blevesearch/bleve/analysis"
+	"github.com/blevesearch/bleve/analysis/token_filters/unicode_normalize"
+	"github.com/blevesearch/bleve/registry"
+	"golang.org/x/text/unicode/norm"
+)
+
+const Name = "normalize_unicode"
+
+var forms = map[string]norm.Form{
+	unicode_normalize.NFC:  norm.NFC,
+	unicode_normalize.NFD:  norm.NFD,
+	unicode_normalize.NFKC: norm.NFKC,
+	unicode_normalize.NFKD: norm.NFKD,
+}
+
+// NormalizeCharFilter applies a unicode normalization
+// form to the input before it is tokenized, so that
+// differently encoded text produces the same tokens.
+type NormalizeCharFilter struct {
+	form norm.Form
+}
+
+func NewNormalizeCharFilter(formName string) (*NormalizeCharFilter, error) {
+	form, ok := forms[formName]
+	if !ok {
+		return nil, fmt.Errorf("no form named %s", formName)
+	}
+	return &NormalizeCharFilter{
+		form: form,
+	}, nil
+}
+
+func (s *NormalizeCharFilter) Filter(input []byte) []byte {
+	return s.form.Bytes(input)
+}
+
+// FilterOffsets normalizes the input one segment at a time,
+// mapping the bytes of each output segment to the start
+// of the input segment it came from.
+func (s *NormalizeCharFilter) FilterOffsets(input []byte) ([]byte, analysis.OffsetMap) {
+	rv := make([]byte, 0, len(input))
+	offsets := make(analysis.OffsetMap, 0, len(input)+1)
+	var iter norm.Iter
+	iter.Init(s.form, input)
+	start := 0
+	for !iter.Done() {
+		segment := iter.Next()
+		for range segment {
+			offsets = append(offsets, start)
+		}
+		rv = append(rv, segment...)
+		start = iter.Pos()
+	}
+	offsets = append(offsets, len(input))
+	return rv, offsets
+}
+
+func NormalizeCharFilterConstructor(config map[string]interface{}, cache *registry.Cache) (analysis.CharFilter, error) {
+	formVal, ok := config["form"].(string)
+	if !ok {
+		return nil, fmt.Errorf("must specify form")
+	}
+	return NewNormalizeCharFilter(formVal)
+}
+
+func init() {
+	registry.RegisterCharFilter(Name, NormalizeCharFilterConstructor)
+}
@@ -0,0 +1,101 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package unicode_normalize_char_filter
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/blevesearch/bleve/analysis"
+	"github.com/blevesearch/bleve/analysis/token_filters/unicode_normalize"
+	"github.com/blevesearch/bleve/analysis/tokenizers/unicode"
+)
+
+func TestNormalizeCharFilter(t *testing.T) {
+	composed := []byte("un café noir")
+	decomposed := []byte("un café noir")
+
+	tests := []struct {
+		form   string
+		input  []byte
+		output []byte
+	}{
+		{form: unicode_normalize.NFC, input: composed, output: composed},
+		{form: unicode_normalize.NFC, input: decomposed, output: composed},
+		{form: unicode_normalize.NFD, input: composed, output: decomposed},
+		{form: unicode_normalize.NFKC, input: []byte("ﬁ"), output: []byte("fi")},
+		{form: unicode_normalize.NFKD, input: []byte("ﬁé"), output: []byte("fié")},
+	}
+
+	for _, test := range tests {
+		filter, err := NewNormalizeCharFilter(test.form)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual := filter.Filter(test.input)
+		if !reflect.DeepEqual(actual, test.output) {
+			t.Errorf("expected %q, got %q for %q", test.output, actual, test.input)
+		}
+		actual, offsets := filter.FilterOffsets(test.input)
+		if !reflect.DeepEqual(actual, test.output) {
+			t.Errorf("expected %q, got %q for %q", test.output, actual, test.input)
+		}
+		if len(offsets) != len(actual)+1 || offsets[len(actual)] != len(test.input) {
+			t.Errorf("expected %d offsets ending at %d, got %v", len(actual)+1, len(test.input), offsets)
+		}
+	}
+
+	_, err := NewNormalizeCharFilter("bogus")
+	if err == nil {
+		t.Errorf("expected error for unknown form")
+	}
+}
+
+func TestNormalizeCharFilterAnalyzer(t *testing.T) {
+	filter, err := NewNormalizeCharFilter(unicode_normalize.NFC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	analyzer := analysis.Analyzer{
+		CharFilters: []analysis.CharFilter{filter},
+		Tokenizer:   unicode.NewUnicodeTokenizer(),
+	}
+
+	composed := []byte("café noir")
+	decomposed := []byte("café noir")
+
+	composedTokens := analyzer.Analyze(composed)
+	decomposedTokens := analyzer.Analyze(decomposed)
+	if len(composedTokens) != 2 || len(decomposedTokens) != 2 {
+		t.Fatalf("expected 2 tokens each, got %v and %v", composedTokens, decomposedTokens)
+	}
+	for i := range composedTokens {
+		if string(composedTokens[i].Term) != string(decomposedTokens[i].Term) {
+			t.Errorf("expected identical terms, got %q and %q", composedTokens[i].Term, decomposedTokens[i].Term)
+		}
+		if composedTokens[i].Position != decomposedTokens[i].Position {
+			t.Errorf("expected identical positions, got %d and %d", composedTokens[i].Position, decomposedTokens[i].Position)
+		}
+	}
+
+	// offsets refer to the original input
+	expectedOffsets := [][2]int{{0, 6}, {7, 11}}
+	for i, token := range decomposedTokens {
+		if token.Start != expectedOffsets[i][0] || token.End != expectedOffsets[i][1] {
+			t.Errorf("expected offsets %v, got %d-%d for %s", expectedOffsets[i], token.Start, token.End, token.Term)
+		}
+	}
+	expectedOffsets = [][2]int{{0, 5}, {6, 10}}
+	for i, token := range composedTokens {
+		if token.Start != expectedOffsets[i][0] || token.End != expectedOffsets[i][1] {
+			t.Errorf("expected offsets %v, got %d-%d for %s", expectedOffsets[i], token.Start, token.End, token.Term)
+		}
+	}
+}
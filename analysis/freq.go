@@ -10,10 +10,11 @@
 package analysis
 
 type TokenLocation struct {
-	Field    string
-	Start    int
-	End      int
-	Position int
+	Field          string
+	ArrayPositions []uint64
+	Start          int
+	End            int
+	Position       int
 }
 
 type TokenFreq struct {
@@ -39,7 +40,11 @@ func (tfs TokenFrequencies) MergeAll(remoteField string, other TokenFrequencies)
 		if exists {
 			existingTf.Locations = append(existingTf.Locations, tf.Locations...)
 		} else {
-			index[string(tf.Term)] = tf
+			// copy, so that later merges don't modify other's entries
+			index[string(tf.Term)] = &TokenFreq{
+				Term:      tf.Term,
+				Locations: append([]*TokenLocation(nil), tf.Locations...),
+			}
 		}
 	}
 	// flatten map back to array
@@ -52,25 +57,36 @@ func (tfs TokenFrequencies) MergeAll(remoteField string, other TokenFrequencies)
 	return rv
 }
 
+// TokenFrequency counts the occurrences of each term in
+// the token stream.
 func TokenFrequency(tokens TokenStream) TokenFrequencies {
+	return TokenFrequencyWithArrayPositions(tokens, nil)
+}
+
+// TokenFrequencyWithArrayPositions is like TokenFrequency,
+// but records with each location the arrayPositions of the
+// field value the tokens came from.
+func TokenFrequencyWithArrayPositions(tokens TokenStream, arrayPositions []uint64) TokenFrequencies {
 	index := make(map[string]*TokenFreq)
 
 	for _, token := range tokens {
 		curr, ok := index[string(token.Term)]
 		if ok {
 			curr.Locations = append(curr.Locations, &TokenLocation{
-				Start:    token.Start,
-				End:      token.End,
-				Position: token.Position,
+				ArrayPositions: arrayPositions,
+				Start:          token.Start,
+				End:            token.End,
+				Position:       token.Position,
 			})
 		} else {
 			index[string(token.Term)] = &TokenFreq{
 				Term: token.Term,
 				Locations: []*TokenLocation{
 					&TokenLocation{
-						Start:    token.Start,
-						End:      token.End,
-						Position: token.Position,
+						ArrayPositions: arrayPositions,
+						Start:          token.Start,
+						End:            token.End,
+						Position:       token.Position,
 					},
 				},
 			}
@@ -52,6 +52,34 @@ func TestTokenFrequency(t *testing.T) {
 	}
 }
 
+func TestTokenFrequencyArrayPositions(t *testing.T) {
+	tokens := TokenStream{
+		&Token{
+			Term:     []byte("water"),
+			Position: 1,
+			Start:    0,
+			End:      5,
+		},
+	}
+	expectedResult := TokenFrequencies{
+		&TokenFreq{
+			Term: []byte("water"),
+			Locations: []*TokenLocation{
+				&TokenLocation{
+					ArrayPositions: []uint64{3, 1},
+					Position:       1,
+					Start:          0,
+					End:            5,
+				},
+			},
+		},
+	}
+	result := TokenFrequencyWithArrayPositions(tokens, []uint64{3, 1})
+	if !reflect.DeepEqual(result, expectedResult) {
+		t.Errorf("expected %#v, got %#v", expectedResult, result)
+	}
+}
+
 func TestTokenFrequenciesMergeAll(t *testing.T) {
 	tf1 := TokenFrequencies{
 		&TokenFreq{
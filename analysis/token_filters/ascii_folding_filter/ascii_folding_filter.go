@@ -0,0 +1,199 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+// Package ascii_folding_filter implements a TokenFilter replacing
+// accented and other non-ASCII Latin letters with their ASCII
+// equivalents, so "José" is indexed as "Jose" and "Straße" as
+// "Strasse".  Letters of other scripts are left untouched.
+package ascii_folding_filter
+
+import (
+	"bytes"
+	"unicode/utf8"
+
+	"github.com/blevesearch/bleve/analysis"
+	"github.com/blevesearch/bleve/registry"
+)
+
+const Name = "ascii_folding"
+
+// foldingGroups maps each rune of a source string to the
+// ASCII replacement.
+var foldingGroups = []struct {
+	from string
+	to   string
+}{
+	{"ÀÁÂÃÄÅĀĂĄǍǞǠǺȀȂȦȺḀẠẢẤẦẨẪẬẮẰẲẴẶ", "A"},
+	{"àáâãäåāăąǎǟǡǻȁȃȧḁạảấầẩẫậắằẳẵặ", "a"},
+	{"ƁƂḂḄḆ", "B"},
+	{"ƀƃɓḃḅḇ", "b"},
+	{"ÇĆĈĊČƇȻḈ", "C"},
+	{"çćĉċčƈȼḉ", "c"},
+	{"ÐĎĐƉƊḊḌḎḐḒ", "D"},
+	{"ðďđɖɗḋḍḏḑḓ", "d"},
+	{"ÈÉÊËĒĔĖĘĚȄȆȨɆḔḖḘḚḜẸẺẼẾỀỂỄỆ", "E"},
+	{"èéêëēĕėęěȅȇȩɇḕḗḙḛḝẹẻẽếềểễệ", "e"},
+	{"ƑḞ", "F"},
+	{"ƒḟ", "f"},
+	{"ĜĞĠĢƓǤǦǴḠ", "G"},
+	{"ĝğġģɠǥǧǵḡ", "g"},
+	{"ĤĦȞḢḤḦḨḪ", "H"},
+	{"ĥħȟḣḥḧḩḫẖ", "h"},
+	{"ÌÍÎÏĨĪĬĮİƗǏȈȊḬḮỈỊ", "I"},
+	{"ìíîïĩīĭįıɨǐȉȋḭḯỉị", "i"},
+	{"ĴɈ", "J"},
+	{"ĵǰȷɉ", "j"},
+	{"ĶƘǨḰḲḴ", "K"},
+	{"ķƙǩḱḳḵĸ", "k"},
+	{"ĹĻĽĿŁȽḶḸḺḼ", "L"},
+	{"ĺļľŀłƚḷḹḻḽ", "l"},
+	{"ḾṀṂ", "M"},
+	{"ḿṁṃ", "m"},
+	{"ÑŃŅŇŊƝǸȠṄṆṈṊ", "N"},
+	{"ñńņňŋɲǹƞṅṇṉṋŉ", "n"},
+	{"ÒÓÔÕÖØŌŎŐƟƠǑǪǬǾȌȎȪȬȮȰṌṎṐṒỌỎỐỒỔỖỘỚỜỞỠỢ", "O"},
+	{"òóôõöøōŏőɵơǒǫǭǿȍȏȫȭȯȱṍṏṑṓọỏốồổỗộớờởỡợ", "o"},
+	{"ƤṔṖ", "P"},
+	{"ƥṕṗ", "p"},
+	{"ɊŔŖŘȐȒɌṘṚṜṞ", "R"},
+	{"ɋŕŗřȑȓɍṙṛṝṟ", "r"},
+	{"ŚŜŞŠȘṠṢṤṦṨ", "S"},
+	{"śŝşšșṡṣṥṧṩſ", "s"},
+	{"ŢŤŦƬƮȚȾṪṬṮṰ", "T"},
+	{"ţťŧƫƭțʈṫṭṯṱẗ", "t"},
+	{"ÙÚÛÜŨŪŬŮŰŲƯǓǕǗǙǛȔȖɄṲṴṶṸṺỤỦỨỪỬỮỰ", "U"},
+	{"ùúûüũūŭůűųưǔǖǘǚǜȕȗʉṳṵṷṹṻụủứừửữự", "u"},
+	{"ƲṼṾ", "V"},
+	{"ʋṽṿ", "v"},
+	{"ŴẀẂẄẆẈ", "W"},
+	{"ŵẁẃẅẇẉẘ", "w"},
+	{"ẊẌ", "X"},
+	{"ẋẍ", "x"},
+	{"ÝŶŸƳȲɎẎỲỴỶỸ", "Y"},
+	{"ýÿŷƴȳɏẏẙỳỵỷỹ", "y"},
+	{"ŹŻŽƵȤẐẒẔ", "Z"},
+	{"źżžƶȥẑẓẕ", "z"},
+	{"Æ", "AE"},
+	{"æ", "ae"},
+	{"Œ", "OE"},
+	{"œ", "oe"},
+	{"Ĳ", "IJ"},
+	{"ĳ", "ij"},
+	{"Þ", "TH"},
+	{"þ", "th"},
+	{"ẞ", "SS"},
+	{"ß", "ss"},
+	{"ﬀ", "ff"},
+	{"ﬁ", "fi"},
+	{"ﬂ", "fl"},
+	{"ﬃ", "ffi"},
+	{"ﬄ", "ffl"},
+	{"ﬅﬆ", "st"},
+}
+
+var folding = map[rune]string{}
+
+func init() {
+	for _, group := range foldingGroups {
+		for _, r := range group.from {
+			folding[r] = group.to
+		}
+	}
+}
+
+type ASCIIFoldingFilter struct {
+	preserveOriginal bool
+}
+
+// NewASCIIFoldingFilter returns a filter folding the terms of
+// tokens to ASCII.  With preserveOriginal, a token changed by
+// folding is kept too, followed by its folded copy at the same
+// position, so the original spelling can still match exactly.
+func NewASCIIFoldingFilter(preserveOriginal bool) *ASCIIFoldingFilter {
+	return &ASCIIFoldingFilter{
+		preserveOriginal: preserveOriginal,
+	}
+}
+
+func (s *ASCIIFoldingFilter) Filter(input analysis.TokenStream) analysis.TokenStream {
+	if !s.preserveOriginal {
+		for _, token := range input {
+			token.Term = fold(token.Term)
+		}
+		return input
+	}
+
+	rv := make(analysis.TokenStream, 0, len(input))
+	for _, token := range input {
+		rv = append(rv, token)
+		folded := fold(token.Term)
+		if !bytes.Equal(folded, token.Term) {
+			rv = append(rv, &analysis.Token{
+				Start:    token.Start,
+				End:      token.End,
+				Term:     folded,
+				Position: token.Position,
+				Type:     token.Type,
+				KeyWord:  token.KeyWord,
+			})
+		}
+	}
+	return rv
+}
+
+// fold returns term with its Latin letters folded to ASCII,
+// dropping the combining diacritical marks which follow ASCII
+// or folded runes.  A term all ASCII is returned as is.
+func fold(term []byte) []byte {
+	i := 0
+	for i < len(term) && term[i] < utf8.RuneSelf {
+		i++
+	}
+	if i == len(term) {
+		return term
+	}
+
+	rv := make([]byte, i, len(term))
+	copy(rv, term[:i])
+	afterLatin := i > 0
+	for i < len(term) {
+		r, size := utf8.DecodeRune(term[i:])
+		i += size
+		if r < utf8.RuneSelf {
+			rv = append(rv, byte(r))
+			afterLatin = true
+			continue
+		}
+		if afterLatin && r >= 0x0300 && r <= 0x036F {
+			continue
+		}
+		replacement, ok := folding[r]
+		if ok {
+			rv = append(rv, replacement...)
+			afterLatin = true
+			continue
+		}
+		rv = append(rv, term[i-size:i]...)
+		afterLatin = false
+	}
+	return rv
+}
+
+func ASCIIFoldingFilterConstructor(config map[string]interface{}, cache *registry.Cache) (analysis.TokenFilter, error) {
+	preserveOriginal := false
+	preserveVal, ok := config["preserve_original"].(bool)
+	if ok {
+		preserveOriginal = preserveVal
+	}
+	return NewASCIIFoldingFilter(preserveOriginal), nil
+}
+
+func init() {
+	registry.RegisterTokenFilter(Name, ASCIIFoldingFilterConstructor)
+}
@@ -0,0 +1,120 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package ascii_folding_filter
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/blevesearch/bleve/analysis"
+	"github.com/blevesearch/bleve/analysis/token_filters/lower_case_filter"
+	"github.com/blevesearch/bleve/registry"
+)
+
+func TestASCIIFoldingFilter(t *testing.T) {
+	tests := []struct {
+		input  string
+		output string
+	}{
+		{input: "José", output: "Jose"},
+		{input: "naïve", output: "naive"},
+		{input: "Straße", output: "Strasse"},
+		{input: "Ærøskøbing", output: "AEroskobing"},
+		{input: "ﬁnancière", output: "financiere"},
+		{input: "Łódź", output: "Lodz"},
+		// decomposed e and combining acute accent
+		{input: "cafe\u0301", output: "cafe"},
+		{input: "plain", output: "plain"},
+		{input: "Москва", output: "Москва"},
+		{input: "東京", output: "東京"},
+		{input: "ά", output: "ά"},
+		{input: "Zürich-東京", output: "Zurich-東京"},
+	}
+
+	filter := NewASCIIFoldingFilter(false)
+	for _, test := range tests {
+		input := analysis.TokenStream{
+			&analysis.Token{
+				Term: []byte(test.input),
+			},
+		}
+		actual := filter.Filter(input)
+		if string(actual[0].Term) != test.output {
+			t.Errorf("expected %s to fold to %s, got %s", test.input, test.output, actual[0].Term)
+		}
+	}
+}
+
+func TestASCIIFoldingFilterLowerCase(t *testing.T) {
+	input := analysis.TokenStream{
+		&analysis.Token{
+			Term: []byte("José"),
+		},
+	}
+	output := NewASCIIFoldingFilter(false).Filter(lower_case_filter.NewLowerCaseFilter().Filter(input))
+	if string(output[0].Term) != "jose" {
+		t.Errorf("expected jose, got %s", output[0].Term)
+	}
+}
+
+func TestASCIIFoldingFilterPreserveOriginal(t *testing.T) {
+	cache := registry.NewCache()
+	filter, err := cache.DefineTokenFilter("folding_test", map[string]interface{}{
+		"type":              Name,
+		"preserve_original": true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := analysis.TokenStream{
+		&analysis.Token{
+			Term:     []byte("José"),
+			Start:    0,
+			End:      5,
+			Position: 1,
+			Type:     analysis.AlphaNumeric,
+		},
+		&analysis.Token{
+			Term:     []byte("Garcia"),
+			Start:    6,
+			End:      12,
+			Position: 2,
+			Type:     analysis.AlphaNumeric,
+		},
+	}
+	expected := analysis.TokenStream{
+		&analysis.Token{
+			Term:     []byte("José"),
+			Start:    0,
+			End:      5,
+			Position: 1,
+			Type:     analysis.AlphaNumeric,
+		},
+		&analysis.Token{
+			Term:     []byte("Jose"),
+			Start:    0,
+			End:      5,
+			Position: 1,
+			Type:     analysis.AlphaNumeric,
+		},
+		&analysis.Token{
+			Term:     []byte("Garcia"),
+			Start:    6,
+			End:      12,
+			Position: 2,
+			Type:     analysis.AlphaNumeric,
+		},
+	}
+	actual := filter.Filter(input)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
@@ -24,6 +24,7 @@ const defaultMinWordSize = 5
 const defaultMinSubWordSize = 2
 const defaultMaxSubWordSize = 15
 const defaultOnlyLongestMatch = false
+const defaultMaxSubWords = 0
 
 type DictionaryCompoundFilter struct {
 	dict             analysis.TokenMap
@@ -31,15 +32,27 @@ type DictionaryCompoundFilter struct {
 	minSubWordSize   int
 	maxSubWordSize   int
 	onlyLongestMatch bool
+	maxSubWords      int
 }
 
+// NewDictionaryCompoundFilter creates a filter emitting, after
+// each token of at least minWordSize runes, all the words of
+// dict found inside it.
 func NewDictionaryCompoundFilter(dict analysis.TokenMap, minWordSize, minSubWordSize, maxSubWordSize int, onlyLongestMatch bool) *DictionaryCompoundFilter {
+	return NewBoundedDictionaryCompoundFilter(dict, minWordSize, minSubWordSize, maxSubWordSize, onlyLongestMatch, defaultMaxSubWords)
+}
+
+// NewBoundedDictionaryCompoundFilter is like
+// NewDictionaryCompoundFilter, but emits at most maxSubWords
+// subwords per token, or all of them if maxSubWords is 0.
+func NewBoundedDictionaryCompoundFilter(dict analysis.TokenMap, minWordSize, minSubWordSize, maxSubWordSize int, onlyLongestMatch bool, maxSubWords int) *DictionaryCompoundFilter {
 	return &DictionaryCompoundFilter{
 		dict:             dict,
 		minWordSize:      minWordSize,
 		minSubWordSize:   minSubWordSize,
 		maxSubWordSize:   maxSubWordSize,
 		onlyLongestMatch: onlyLongestMatch,
+		maxSubWords:      maxSubWords,
 	}
 }
 
@@ -64,19 +77,28 @@ func (f *DictionaryCompoundFilter) decompose(token *analysis.Token) []*analysis.
 	runes := bytes.Runes(token.Term)
 	rv := make([]*analysis.Token, 0)
 	rlen := len(runes)
+	// byte offset of rune i within the term
+	offset := 0
 	for i := 0; i <= (rlen - f.minSubWordSize); i++ {
+		if f.maxSubWords > 0 && len(rv) >= f.maxSubWords {
+			break
+		}
 		var longestMatchToken *analysis.Token
 		for j := f.minSubWordSize; j <= f.maxSubWordSize; j++ {
 			if i+j > rlen {
 				break
 			}
-			_, inDict := f.dict[string(runes[i:i+j])]
+			if f.maxSubWords > 0 && len(rv) >= f.maxSubWords {
+				break
+			}
+			subword := string(runes[i : i+j])
+			_, inDict := f.dict[subword]
 			if inDict {
 				newtoken := analysis.Token{
-					Term:     []byte(string(runes[i : i+j])),
+					Term:     []byte(subword),
 					Position: token.Position,
-					Start:    token.Start + i,
-					End:      token.Start + i + j,
+					Start:    token.Start + offset,
+					End:      token.Start + offset + len(subword),
 					Type:     token.Type,
 					KeyWord:  token.KeyWord,
 				}
@@ -92,6 +114,7 @@ func (f *DictionaryCompoundFilter) decompose(token *analysis.Token) []*analysis.
 		if f.onlyLongestMatch && longestMatchToken != nil {
 			rv = append(rv, longestMatchToken)
 		}
+		offset += utf8.RuneLen(runes[i])
 	}
 	return rv
 }
@@ -102,6 +125,7 @@ func DictionaryCompoundFilterConstructor(config map[string]interface{}, cache *r
 	minSubWordSize := defaultMinSubWordSize
 	maxSubWordSize := defaultMaxSubWordSize
 	onlyLongestMatch := defaultOnlyLongestMatch
+	maxSubWords := defaultMaxSubWords
 
 	minVal, ok := config["min_word_size"].(float64)
 	if ok {
@@ -119,6 +143,10 @@ func DictionaryCompoundFilterConstructor(config map[string]interface{}, cache *r
 	if ok {
 		onlyLongestMatch = onlyVal
 	}
+	maxSubWordsVal, ok := config["max_subwords"].(float64)
+	if ok {
+		maxSubWords = int(maxSubWordsVal)
+	}
 
 	dictTokenMapName, ok := config["dict_token_map"].(string)
 	if !ok {
@@ -128,7 +156,7 @@ func DictionaryCompoundFilterConstructor(config map[string]interface{}, cache *r
 	if err != nil {
 		return nil, fmt.Errorf("error building dict compound words filter: %v", err)
 	}
-	return NewDictionaryCompoundFilter(dictTokenMap, minWordSize, minSubWordSize, maxSubWordSize, onlyLongestMatch), nil
+	return NewBoundedDictionaryCompoundFilter(dictTokenMap, minWordSize, minSubWordSize, maxSubWordSize, onlyLongestMatch, maxSubWords), nil
 }
 
 func init() {
@@ -10,6 +10,7 @@
 package compound
 
 import (
+	"fmt"
 	"reflect"
 	"testing"
 
@@ -180,3 +181,141 @@ func TestStopWordsFilterLongestMatch(t *testing.T) {
 		t.Errorf("expected %#v got %#v", expectedTokenStream, ouputTokenStream)
 	}
 }
+
+func TestDictionaryCompoundFilterGerman(t *testing.T) {
+
+	cache := registry.NewCache()
+	dictListConfig := map[string]interface{}{
+		"type":   token_map.Name,
+		"tokens": []interface{}{"leben", "versicherung", "sicherung", "fuß", "ball"},
+	}
+	_, err := cache.DefineTokenMap("dict_de", dictListConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		config map[string]interface{}
+		input  analysis.TokenStream
+		output analysis.TokenStream
+	}{
+		{
+			config: map[string]interface{}{
+				"type":           "dict_compound",
+				"dict_token_map": "dict_de",
+			},
+			input: analysis.TokenStream{
+				&analysis.Token{
+					Term:     []byte("lebensversicherung"),
+					Start:    0,
+					End:      18,
+					Position: 1,
+				},
+			},
+			output: analysis.TokenStream{
+				&analysis.Token{
+					Term:     []byte("lebensversicherung"),
+					Start:    0,
+					End:      18,
+					Position: 1,
+				},
+				&analysis.Token{
+					Term:     []byte("leben"),
+					Start:    0,
+					End:      5,
+					Position: 1,
+				},
+				&analysis.Token{
+					Term:     []byte("versicherung"),
+					Start:    6,
+					End:      18,
+					Position: 1,
+				},
+				&analysis.Token{
+					Term:     []byte("sicherung"),
+					Start:    9,
+					End:      18,
+					Position: 1,
+				},
+			},
+		},
+		// fan-out bounded by max_subwords
+		{
+			config: map[string]interface{}{
+				"type":           "dict_compound",
+				"dict_token_map": "dict_de",
+				"max_subwords":   2.0,
+			},
+			input: analysis.TokenStream{
+				&analysis.Token{
+					Term:     []byte("lebensversicherung"),
+					Start:    0,
+					End:      18,
+					Position: 1,
+				},
+			},
+			output: analysis.TokenStream{
+				&analysis.Token{
+					Term:     []byte("lebensversicherung"),
+					Start:    0,
+					End:      18,
+					Position: 1,
+				},
+				&analysis.Token{
+					Term:     []byte("leben"),
+					Start:    0,
+					End:      5,
+					Position: 1,
+				},
+				&analysis.Token{
+					Term:     []byte("versicherung"),
+					Start:    6,
+					End:      18,
+					Position: 1,
+				},
+			},
+		},
+		// subwords shorter than min_subword_size are skipped,
+		// offsets are in bytes
+		{
+			config: map[string]interface{}{
+				"type":             "dict_compound",
+				"dict_token_map":   "dict_de",
+				"min_subword_size": 4.0,
+			},
+			input: analysis.TokenStream{
+				&analysis.Token{
+					Term:     []byte("fußball"),
+					Start:    4,
+					End:      12,
+					Position: 2,
+				},
+			},
+			output: analysis.TokenStream{
+				&analysis.Token{
+					Term:     []byte("fußball"),
+					Start:    4,
+					End:      12,
+					Position: 2,
+				},
+				&analysis.Token{
+					Term:     []byte("ball"),
+					Start:    8,
+					End:      12,
+					Position: 2,
+				},
+			},
+		},
+	}
+
+	for i, test := range tests {
+		dictFilter, err := cache.DefineTokenFilter(fmt.Sprintf("dict_de_%d", i), test.config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ouputTokenStream := dictFilter.Filter(test.input)
+		if !reflect.DeepEqual(ouputTokenStream, test.output) {
+			t.Errorf("expected %s got %s", test.output, ouputTokenStream)
+		}
+	}
+}
@@ -42,43 +42,38 @@ func NewEdgeNgramFilter(side Side, minLength, maxLength int) *EdgeNgramFilter {
 func (s *EdgeNgramFilter) Filter(input analysis.TokenStream) analysis.TokenStream {
 	rv := make(analysis.TokenStream, 0, len(input))
 
+	// grams already emitted at the current position, tokens
+	// sharing a position (such as synonyms) often share grams
+	var seen map[string]struct{}
+	lastPosition := -1
+
 	for _, token := range input {
+		if seen == nil || token.Position != lastPosition {
+			seen = make(map[string]struct{})
+			lastPosition = token.Position
+		}
 		runeCount := utf8.RuneCount(token.Term)
 		runes := bytes.Runes(token.Term)
-		if s.back {
-			i := runeCount
-			// index of the starting rune for this token
-			for ngramSize := s.minLength; ngramSize <= s.maxLength; ngramSize++ {
-				// build an ngram of this size starting at i
-				if i-ngramSize > 0 {
-					ngramTerm := buildTermFromRunes(runes[i-ngramSize : i])
-					token := analysis.Token{
-						Position: token.Position,
-						Start:    token.Start,
-						End:      token.End,
-						Type:     token.Type,
-						Term:     ngramTerm,
-					}
-					rv = append(rv, &token)
-				}
+		for ngramSize := s.minLength; ngramSize <= s.maxLength && ngramSize <= runeCount; ngramSize++ {
+			var ngramTerm []byte
+			if s.back {
+				// build an ngram of this size ending at the last rune
+				ngramTerm = buildTermFromRunes(runes[runeCount-ngramSize:])
+			} else {
+				// build an ngram of this size starting at the first rune
+				ngramTerm = buildTermFromRunes(runes[:ngramSize])
 			}
-		} else {
-			i := 0
-			// index of the starting rune for this token
-			for ngramSize := s.minLength; ngramSize <= s.maxLength; ngramSize++ {
-				// build an ngram of this size starting at i
-				if i+ngramSize <= runeCount {
-					ngramTerm := buildTermFromRunes(runes[i : i+ngramSize])
-					token := analysis.Token{
-						Position: token.Position,
-						Start:    token.Start,
-						End:      token.End,
-						Type:     token.Type,
-						Term:     ngramTerm,
-					}
-					rv = append(rv, &token)
-				}
+			if _, dup := seen[string(ngramTerm)]; dup {
+				continue
 			}
+			seen[string(ngramTerm)] = struct{}{}
+			rv = append(rv, &analysis.Token{
+				Position: token.Position,
+				Start:    token.Start,
+				End:      token.End,
+				Type:     token.Type,
+				Term:     ngramTerm,
+			})
 		}
 	}
 
@@ -101,6 +96,17 @@ func EdgeNgramFilterConstructor(config map[string]interface{}, cache *registry.C
 	if ok && back {
 		side = BACK
 	}
+	sideName, ok := config["side"].(string)
+	if ok {
+		switch sideName {
+		case "front":
+			side = FRONT
+		case "back":
+			side = BACK
+		default:
+			return nil, fmt.Errorf("invalid side '%s', must be front or back", sideName)
+		}
+	}
 	minVal, ok := config["min"].(float64)
 	if !ok {
 		return nil, fmt.Errorf("must specify min")
@@ -111,6 +117,9 @@ func EdgeNgramFilterConstructor(config map[string]interface{}, cache *registry.C
 		return nil, fmt.Errorf("must specify max")
 	}
 	max := int(maxVal)
+	if min < 1 || max < min {
+		return nil, fmt.Errorf("invalid min %d and max %d", min, max)
+	}
 
 	return NewEdgeNgramFilter(side, min, max), nil
 }
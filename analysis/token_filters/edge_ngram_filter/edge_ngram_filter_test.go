@@ -130,6 +130,98 @@ func TestEdgeNgramFilter(t *testing.T) {
 				},
 			},
 		},
+		{
+			side: BACK,
+			min:  3,
+			max:  6,
+			input: analysis.TokenStream{
+				&analysis.Token{
+					Term: []byte("abcde"),
+				},
+			},
+			output: analysis.TokenStream{
+				&analysis.Token{
+					Term: []byte("cde"),
+				},
+				&analysis.Token{
+					Term: []byte("bcde"),
+				},
+				&analysis.Token{
+					Term: []byte("abcde"),
+				},
+			},
+		},
+		{
+			side: FRONT,
+			min:  2,
+			max:  4,
+			input: analysis.TokenStream{
+				&analysis.Token{
+					Term:     []byte("search"),
+					Position: 1,
+					Start:    0,
+					End:      6,
+				},
+			},
+			output: analysis.TokenStream{
+				&analysis.Token{
+					Term:     []byte("se"),
+					Position: 1,
+					Start:    0,
+					End:      6,
+				},
+				&analysis.Token{
+					Term:     []byte("sea"),
+					Position: 1,
+					Start:    0,
+					End:      6,
+				},
+				&analysis.Token{
+					Term:     []byte("sear"),
+					Position: 1,
+					Start:    0,
+					End:      6,
+				},
+			},
+		},
+		// grams shared by tokens at the same position are emitted once
+		{
+			side: FRONT,
+			min:  2,
+			max:  3,
+			input: analysis.TokenStream{
+				&analysis.Token{
+					Term:     []byte("television"),
+					Position: 1,
+				},
+				&analysis.Token{
+					Term:     []byte("telly"),
+					Position: 1,
+				},
+				&analysis.Token{
+					Term:     []byte("tea"),
+					Position: 2,
+				},
+			},
+			output: analysis.TokenStream{
+				&analysis.Token{
+					Term:     []byte("te"),
+					Position: 1,
+				},
+				&analysis.Token{
+					Term:     []byte("tel"),
+					Position: 1,
+				},
+				&analysis.Token{
+					Term:     []byte("te"),
+					Position: 2,
+				},
+				&analysis.Token{
+					Term:     []byte("tea"),
+					Position: 2,
+				},
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -140,3 +232,31 @@ func TestEdgeNgramFilter(t *testing.T) {
 		}
 	}
 }
+
+func TestEdgeNgramFilterConstructor(t *testing.T) {
+	tests := []struct {
+		config map[string]interface{}
+		err    bool
+		side   Side
+	}{
+		{config: map[string]interface{}{"min": 2.0, "max": 4.0}, side: FRONT},
+		{config: map[string]interface{}{"min": 2.0, "max": 4.0, "back": true}, side: BACK},
+		{config: map[string]interface{}{"min": 2.0, "max": 4.0, "side": "back"}, side: BACK},
+		{config: map[string]interface{}{"min": 2.0, "max": 4.0, "side": "front"}, side: FRONT},
+		{config: map[string]interface{}{"min": 2.0, "max": 4.0, "side": "middle"}, err: true},
+		{config: map[string]interface{}{"max": 4.0}, err: true},
+		{config: map[string]interface{}{"min": 0.0, "max": 4.0}, err: true},
+		{config: map[string]interface{}{"min": 4.0, "max": 2.0}, err: true},
+	}
+
+	for _, test := range tests {
+		filter, err := EdgeNgramFilterConstructor(test.config, nil)
+		if (err != nil) != test.err {
+			t.Errorf("expected error %t, got %v for %v", test.err, err, test.config)
+			continue
+		}
+		if err == nil && filter.(*EdgeNgramFilter).back != test.side {
+			t.Errorf("expected side %t, got %t for %v", test.side, filter.(*EdgeNgramFilter).back, test.config)
+		}
+	}
+}
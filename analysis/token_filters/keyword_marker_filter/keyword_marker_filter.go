@@ -39,16 +39,31 @@ func (f *KeyWordMarkerFilter) Filter(input analysis.TokenStream) analysis.TokenS
 	return input
 }
 
+// KeyWordMarkerFilterConstructor builds the filter from
+// either a named token map (keywords_token_map) or an
+// inline list of words (keywords).
 func KeyWordMarkerFilterConstructor(config map[string]interface{}, cache *registry.Cache) (analysis.TokenFilter, error) {
 	keywordsTokenMapName, ok := config["keywords_token_map"].(string)
-	if !ok {
-		return nil, fmt.Errorf("must specify keywords_token_map")
+	if ok {
+		keywordsTokenMap, err := cache.TokenMapNamed(keywordsTokenMapName)
+		if err != nil {
+			return nil, fmt.Errorf("error building keyword marker filter: %v", err)
+		}
+		return NewKeyWordMarkerFilter(keywordsTokenMap), nil
 	}
-	keywordsTokenMap, err := cache.TokenMapNamed(keywordsTokenMapName)
-	if err != nil {
-		return nil, fmt.Errorf("error building keyword marker filter: %v", err)
+	keywords, ok := config["keywords"].([]interface{})
+	if ok {
+		keywordsTokenMap := analysis.NewTokenMap()
+		for _, keyword := range keywords {
+			keywordStr, ok := keyword.(string)
+			if !ok {
+				return nil, fmt.Errorf("error building keyword marker filter: invalid keyword %v", keyword)
+			}
+			keywordsTokenMap.AddToken(keywordStr)
+		}
+		return NewKeyWordMarkerFilter(keywordsTokenMap), nil
 	}
-	return NewKeyWordMarkerFilter(keywordsTokenMap), nil
+	return nil, fmt.Errorf("must specify keywords_token_map or keywords")
 }
 
 func init() {
@@ -10,10 +10,14 @@
 package keyword_filter
 
 import (
+	"fmt"
 	"reflect"
 	"testing"
 
 	"github.com/blevesearch/bleve/analysis"
+	"github.com/blevesearch/bleve/analysis/token_filters/porter"
+	"github.com/blevesearch/bleve/analysis/token_map"
+	"github.com/blevesearch/bleve/registry"
 )
 
 func TestKeyWordMarkerFilter(t *testing.T) {
@@ -66,3 +70,68 @@ func TestKeyWordMarkerFilter(t *testing.T) {
 		t.Errorf("expected %#v got %#v", expectedTokenStream[0].KeyWord, ouputTokenStream[0].KeyWord)
 	}
 }
+
+func TestKeyWordMarkerFilterProtectsFromStemming(t *testing.T) {
+	cache := registry.NewCache()
+	_, err := cache.DefineTokenMap("brands", map[string]interface{}{
+		"type":   token_map.Name,
+		"tokens": []interface{}{"windows"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	configs := []map[string]interface{}{
+		{
+			"type":               Name,
+			"keywords_token_map": "brands",
+		},
+		{
+			"type":     Name,
+			"keywords": []interface{}{"windows"},
+		},
+	}
+
+	stemmer := porter.NewPorterStemmer()
+	for i, config := range configs {
+		marker, err := cache.DefineTokenFilter(fmt.Sprintf("marker_%d", i), config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		input := analysis.TokenStream{
+			&analysis.Token{
+				Term: []byte("windows"),
+			},
+			&analysis.Token{
+				Term: []byte("doors"),
+			},
+		}
+		expected := analysis.TokenStream{
+			&analysis.Token{
+				Term:    []byte("windows"),
+				KeyWord: true,
+			},
+			&analysis.Token{
+				Term: []byte("door"),
+			},
+		}
+		actual := stemmer.Filter(marker.Filter(input))
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("expected %s, got %s for %v", expected, actual, config)
+		}
+	}
+}
+
+func TestKeyWordMarkerFilterInvalidConfig(t *testing.T) {
+	configs := []map[string]interface{}{
+		{},
+		{"keywords_token_map": "missing"},
+		{"keywords": []interface{}{"windows", 7}},
+	}
+	for _, config := range configs {
+		_, err := KeyWordMarkerFilterConstructor(config, registry.NewCache())
+		if err == nil {
+			t.Errorf("expected error for config %v", config)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+// Package limit_token_count_filter caps the number of
+// tokens of a field, like Lucene's LimitTokenCountFilter,
+// so the filters after it and the index only see the
+// first tokens of giant fields.
+package limit_token_count_filter
+
+import (
+	"fmt"
+
+	"github.com/blevesearch/bleve/analysis"
+	"github.com/blevesearch/bleve/registry"
+)
+
+const Name = "limit_token_count"
+
+type LimitTokenCountFilter struct {
+	maxTokenCount    int
+	consumeAllTokens bool
+}
+
+// NewLimitTokenCountFilter creates a filter keeping the
+// first maxTokenCount tokens of the stream.  The token
+// stream is built in full by the tokenizer before it is
+// filtered, so consumeAllTokens, kept for configurations
+// written for Lucene, does not change the output.
+func NewLimitTokenCountFilter(maxTokenCount int, consumeAllTokens bool) *LimitTokenCountFilter {
+	return &LimitTokenCountFilter{
+		maxTokenCount:    maxTokenCount,
+		consumeAllTokens: consumeAllTokens,
+	}
+}
+
+func (f *LimitTokenCountFilter) Filter(input analysis.TokenStream) analysis.TokenStream {
+	if len(input) > f.maxTokenCount {
+		return input[:f.maxTokenCount]
+	}
+	return input
+}
+
+func LimitTokenCountFilterConstructor(config map[string]interface{}, cache *registry.Cache) (analysis.TokenFilter, error) {
+	maxTokenCount, ok := config["max_token_count"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("must specify max_token_count")
+	}
+	if maxTokenCount < 1 {
+		return nil, fmt.Errorf("max_token_count must be at least 1")
+	}
+	consumeAllTokens, _ := config["consume_all_tokens"].(bool)
+	return NewLimitTokenCountFilter(int(maxTokenCount), consumeAllTokens), nil
+}
+
+func init() {
+	registry.RegisterTokenFilter(Name, LimitTokenCountFilterConstructor)
+}
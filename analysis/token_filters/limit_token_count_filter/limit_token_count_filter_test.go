@@ -0,0 +1,111 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package limit_token_count_filter
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/blevesearch/bleve/analysis"
+	_ "github.com/blevesearch/bleve/analysis/analyzers/custom_analyzer"
+	_ "github.com/blevesearch/bleve/analysis/token_filters/lower_case_filter"
+	"github.com/blevesearch/bleve/analysis/tokenizers/regexp_tokenizer"
+	"github.com/blevesearch/bleve/analysis/tokenizers/whitespace_tokenizer"
+	"github.com/blevesearch/bleve/registry"
+)
+
+// recordingFilter remembers the length of the stream it
+// is given.
+type recordingFilter struct {
+	seen int
+}
+
+func (f *recordingFilter) Filter(input analysis.TokenStream) analysis.TokenStream {
+	f.seen = len(input)
+	return input
+}
+
+func thousandWords() []byte {
+	words := make([]string, 1000)
+	for i := range words {
+		words[i] = "Word"
+	}
+	return []byte(strings.Join(words, " "))
+}
+
+func TestLimitTokenCountFilter(t *testing.T) {
+	recorder := &recordingFilter{}
+	analyzer := &analysis.Analyzer{
+		Tokenizer: regexp_tokenizer.NewRegexpTokenizer(regexp.MustCompile(`\w+`)),
+		TokenFilters: []analysis.TokenFilter{
+			NewLimitTokenCountFilter(10, false),
+			recorder,
+		},
+	}
+	tokens := analyzer.Analyze(thousandWords())
+	if len(tokens) != 10 {
+		t.Errorf("expected 10 tokens, got %d", len(tokens))
+	}
+	if recorder.seen != 10 {
+		t.Errorf("expected the next filter to see 10 tokens, got %d", recorder.seen)
+	}
+	if tokens[9].Position != 10 {
+		t.Errorf("expected the last token at position 10, got %d", tokens[9].Position)
+	}
+
+	// shorter streams are left as they are
+	input := analysis.TokenStream{
+		&analysis.Token{Term: []byte("a"), Position: 1},
+		&analysis.Token{Term: []byte("b"), Position: 2},
+	}
+	output := NewLimitTokenCountFilter(10, true).Filter(input)
+	if !reflect.DeepEqual(output, input) {
+		t.Errorf("expected %v, got %v", input, output)
+	}
+}
+
+func TestLimitTokenCountFilterInAnalyzer(t *testing.T) {
+	cache := registry.NewCache()
+
+	_, err := cache.DefineTokenFilter("limit_5", map[string]interface{}{
+		"type": Name,
+	})
+	if err == nil {
+		t.Errorf("expected error without max_token_count")
+	}
+
+	_, err = cache.DefineTokenFilter("limit_5", map[string]interface{}{
+		"type":               Name,
+		"max_token_count":    5.0,
+		"consume_all_tokens": true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	analyzer, err := cache.DefineAnalyzer("limited", map[string]interface{}{
+		"type":          "custom",
+		"tokenizer":     whitespace_tokenizer.Name,
+		"token_filters": []interface{}{"limit_5", "to_lower"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokens := analyzer.Analyze(thousandWords())
+	terms := make([]string, len(tokens))
+	for i, token := range tokens {
+		terms[i] = string(token.Term)
+	}
+	expected := []string{"word", "word", "word", "word", "word"}
+	if !reflect.DeepEqual(terms, expected) {
+		t.Errorf("expected %v, got %v", expected, terms)
+	}
+}
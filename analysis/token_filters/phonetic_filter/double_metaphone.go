@@ -0,0 +1,645 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package phonetic_filter
+
+import (
+	"strings"
+)
+
+// doubleMetaphoneMaxLength is the length the codes
+// are truncated to
+const doubleMetaphoneMaxLength = 4
+
+// DoubleMetaphoneCodes returns the primary and alternate
+// Double Metaphone codes of s, following the rules of
+// Lawrence Philips' original algorithm.  Both are empty
+// if s has nothing to encode.
+func DoubleMetaphoneCodes(s string) (string, string) {
+	value := []rune(strings.ToUpper(strings.TrimSpace(s)))
+	if len(value) == 0 {
+		return "", ""
+	}
+	e := &doubleMetaphoneEncoder{
+		value:         value,
+		slavoGermanic: isSlavoGermanic(string(value)),
+	}
+
+	index := 0
+	if e.isSilentStart() {
+		index = 1
+	}
+	for !e.isComplete() && index < len(value) {
+		switch value[index] {
+		case 'A', 'E', 'I', 'O', 'U', 'Y':
+			if index == 0 {
+				e.add('A')
+			}
+			index++
+		case 'B':
+			e.add('P')
+			index = e.skipDouble(index, 'B')
+		case 'Ç':
+			e.add('S')
+			index++
+		case 'C':
+			index = e.handleC(index)
+		case 'D':
+			index = e.handleD(index)
+		case 'F':
+			e.add('F')
+			index = e.skipDouble(index, 'F')
+		case 'G':
+			index = e.handleG(index)
+		case 'H':
+			index = e.handleH(index)
+		case 'J':
+			index = e.handleJ(index)
+		case 'K':
+			e.add('K')
+			index = e.skipDouble(index, 'K')
+		case 'L':
+			index = e.handleL(index)
+		case 'M':
+			e.add('M')
+			if e.conditionM0(index) {
+				index += 2
+			} else {
+				index++
+			}
+		case 'N':
+			e.add('N')
+			index = e.skipDouble(index, 'N')
+		case 'Ñ':
+			e.add('N')
+			index++
+		case 'P':
+			index = e.handleP(index)
+		case 'Q':
+			e.add('K')
+			index = e.skipDouble(index, 'Q')
+		case 'R':
+			index = e.handleR(index)
+		case 'S':
+			index = e.handleS(index)
+		case 'T':
+			index = e.handleT(index)
+		case 'V':
+			e.add('F')
+			index = e.skipDouble(index, 'V')
+		case 'W':
+			index = e.handleW(index)
+		case 'X':
+			index = e.handleX(index)
+		case 'Z':
+			index = e.handleZ(index)
+		default:
+			index++
+		}
+	}
+	return string(e.primary), string(e.alternate)
+}
+
+type doubleMetaphoneEncoder struct {
+	value         []rune
+	slavoGermanic bool
+	primary       []rune
+	alternate     []rune
+}
+
+func isSlavoGermanic(value string) bool {
+	return strings.ContainsAny(value, "WK") || strings.Contains(value, "CZ")
+}
+
+func isVowel(r rune) bool {
+	return strings.ContainsRune("AEIOUY", r)
+}
+
+func (e *doubleMetaphoneEncoder) isSilentStart() bool {
+	return e.contains(0, 2, "GN", "KN", "PN", "WR", "PS")
+}
+
+func (e *doubleMetaphoneEncoder) isComplete() bool {
+	return len(e.primary) >= doubleMetaphoneMaxLength &&
+		len(e.alternate) >= doubleMetaphoneMaxLength
+}
+
+func appendCode(code []rune, s string) []rune {
+	for _, r := range s {
+		if len(code) >= doubleMetaphoneMaxLength {
+			break
+		}
+		code = append(code, r)
+	}
+	return code
+}
+
+// add appends to both codes
+func (e *doubleMetaphoneEncoder) add(r rune) {
+	e.addBoth(string(r), string(r))
+}
+
+func (e *doubleMetaphoneEncoder) addString(s string) {
+	e.addBoth(s, s)
+}
+
+func (e *doubleMetaphoneEncoder) addBoth(primary, alternate string) {
+	e.primary = appendCode(e.primary, primary)
+	e.alternate = appendCode(e.alternate, alternate)
+}
+
+func (e *doubleMetaphoneEncoder) addPrimary(r rune) {
+	e.primary = appendCode(e.primary, string(r))
+}
+
+func (e *doubleMetaphoneEncoder) addAlternate(r rune) {
+	e.alternate = appendCode(e.alternate, string(r))
+}
+
+// charAt returns the rune at index, or 0 out of range
+func (e *doubleMetaphoneEncoder) charAt(index int) rune {
+	if index < 0 || index >= len(e.value) {
+		return 0
+	}
+	return e.value[index]
+}
+
+// contains reports whether the length runes from start
+// are one of the criteria
+func (e *doubleMetaphoneEncoder) contains(start, length int, criteria ...string) bool {
+	if start < 0 || start+length > len(e.value) {
+		return false
+	}
+	target := string(e.value[start : start+length])
+	for _, c := range criteria {
+		if target == c {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *doubleMetaphoneEncoder) skipDouble(index int, r rune) int {
+	if e.charAt(index+1) == r {
+		return index + 2
+	}
+	return index + 1
+}
+
+func (e *doubleMetaphoneEncoder) last() int {
+	return len(e.value) - 1
+}
+
+func (e *doubleMetaphoneEncoder) handleC(index int) int {
+	switch {
+	case e.conditionC0(index):
+		e.add('K')
+		index += 2
+	case index == 0 && e.contains(index, 6, "CAESAR"):
+		e.add('S')
+		index += 2
+	case e.contains(index, 2, "CH"):
+		index = e.handleCH(index)
+	case e.contains(index, 2, "CZ") && !e.contains(index-2, 4, "WICZ"):
+		// "Czerny"
+		e.addBoth("S", "X")
+		index += 2
+	case e.contains(index+1, 3, "CIA"):
+		// "focaccia"
+		e.add('X')
+		index += 3
+	case e.contains(index, 2, "CC") && !(index == 1 && e.charAt(0) == 'M'):
+		// double "cc" but not "McClelland"
+		return e.handleCC(index)
+	case e.contains(index, 2, "CK", "CG", "CQ"):
+		e.add('K')
+		index += 2
+	case e.contains(index, 2, "CI", "CE", "CY"):
+		// Italian vs. English
+		if e.contains(index, 3, "CIO", "CIE", "CIA") {
+			e.addBoth("S", "X")
+		} else {
+			e.add('S')
+		}
+		index += 2
+	default:
+		e.add('K')
+		if e.contains(index+1, 2, " C", " Q", " G") {
+			// "Mac Caffrey", "Mac Gregor"
+			index += 3
+		} else if e.contains(index+1, 1, "C", "K", "Q") && !e.contains(index+1, 2, "CE", "CI") {
+			index += 2
+		} else {
+			index++
+		}
+	}
+	return index
+}
+
+func (e *doubleMetaphoneEncoder) handleCC(index int) int {
+	if e.contains(index+2, 1, "I", "E", "H") && !e.contains(index+2, 2, "HU") {
+		// "bellocchio" but not "bacchus"
+		if (index == 1 && e.charAt(index-1) == 'A') || e.contains(index-1, 5, "UCCEE", "UCCES") {
+			// "accident", "accede", "succeed"
+			e.addString("KS")
+		} else {
+			// "bacci", "bertucci", other Italian
+			e.add('X')
+		}
+		return index + 3
+	}
+	// Pierce's rule
+	e.add('K')
+	return index + 2
+}
+
+func (e *doubleMetaphoneEncoder) handleCH(index int) int {
+	switch {
+	case index > 0 && e.contains(index, 4, "CHAE"):
+		// "Michael"
+		e.addBoth("K", "X")
+	case e.conditionCH0(index):
+		// Greek roots, "chemistry", "chorus"
+		e.add('K')
+	case e.conditionCH1(index):
+		// Germanic, Greek, or otherwise "ch" for "kh" sound
+		e.add('K')
+	case index > 0:
+		if e.contains(0, 2, "MC") {
+			e.add('K')
+		} else {
+			e.addBoth("X", "K")
+		}
+	default:
+		e.add('X')
+	}
+	return index + 2
+}
+
+func (e *doubleMetaphoneEncoder) handleD(index int) int {
+	switch {
+	case e.contains(index, 2, "DG"):
+		if e.contains(index+2, 1, "I", "E", "Y") {
+			// "edge"
+			e.add('J')
+			return index + 3
+		}
+		// "Edgar"
+		e.addString("TK")
+		return index + 2
+	case e.contains(index, 2, "DT", "DD"):
+		e.add('T')
+		return index + 2
+	}
+	e.add('T')
+	return index + 1
+}
+
+func (e *doubleMetaphoneEncoder) handleG(index int) int {
+	switch {
+	case e.charAt(index+1) == 'H':
+		return e.handleGH(index)
+	case e.charAt(index+1) == 'N':
+		if index == 1 && isVowel(e.charAt(0)) && !e.slavoGermanic {
+			e.addBoth("KN", "N")
+		} else if !e.contains(index+2, 2, "EY") && e.charAt(index+1) != 'Y' && !e.slavoGermanic {
+			e.addBoth("N", "KN")
+		} else {
+			e.addString("KN")
+		}
+		return index + 2
+	case e.contains(index+1, 2, "LI") && !e.slavoGermanic:
+		e.addBoth("KL", "L")
+		return index + 2
+	case index == 0 && (e.charAt(index+1) == 'Y' ||
+		e.contains(index+1, 2, "ES", "EP", "EB", "EL", "EY", "IB", "IL", "IN", "IE", "EI", "ER")):
+		// -ges-, -gep-, -gel-, -gie- at the beginning
+		e.addBoth("K", "J")
+		return index + 2
+	case (e.contains(index+1, 2, "ER") || e.charAt(index+1) == 'Y') &&
+		!e.contains(0, 6, "DANGER", "RANGER", "MANGER") &&
+		!e.contains(index-1, 1, "E", "I") &&
+		!e.contains(index-1, 3, "RGY", "OGY"):
+		// -ger-, -gy-
+		e.addBoth("K", "J")
+		return index + 2
+	case e.contains(index+1, 1, "E", "I", "Y") || e.contains(index-1, 4, "AGGI", "OGGI"):
+		// Italian "biaggi"
+		if e.contains(0, 4, "VAN ", "VON ") || e.contains(0, 3, "SCH") || e.contains(index+1, 2, "ET") {
+			// obviously Germanic
+			e.add('K')
+		} else if e.contains(index+1, 3, "IER") {
+			e.add('J')
+		} else {
+			e.addBoth("J", "K")
+		}
+		return index + 2
+	case e.charAt(index+1) == 'G':
+		e.add('K')
+		return index + 2
+	}
+	e.add('K')
+	return index + 1
+}
+
+func (e *doubleMetaphoneEncoder) handleGH(index int) int {
+	switch {
+	case index > 0 && !isVowel(e.charAt(index-1)):
+		e.add('K')
+	case index == 0:
+		if e.charAt(index+2) == 'I' {
+			e.add('J')
+		} else {
+			e.add('K')
+		}
+	case (index > 1 && e.contains(index-2, 1, "B", "H", "D")) ||
+		(index > 2 && e.contains(index-3, 1, "B", "H", "D")) ||
+		(index > 3 && e.contains(index-4, 1, "B", "H")):
+		// Parker's rule, "hugh"
+	default:
+		if index > 2 && e.charAt(index-1) == 'U' && e.contains(index-3, 1, "C", "G", "L", "R", "T") {
+			// "laugh", "McLaughlin", "cough", "gough", "rough", "tough"
+			e.add('F')
+		} else if index > 0 && e.charAt(index-1) != 'I' {
+			e.add('K')
+		}
+	}
+	return index + 2
+}
+
+func (e *doubleMetaphoneEncoder) handleH(index int) int {
+	// only kept first or between vowels, before a vowel
+	if (index == 0 || isVowel(e.charAt(index-1))) && isVowel(e.charAt(index+1)) {
+		e.add('H')
+		return index + 2
+	}
+	return index + 1
+}
+
+func (e *doubleMetaphoneEncoder) handleJ(index int) int {
+	if e.contains(index, 4, "JOSE") || e.contains(0, 4, "SAN ") {
+		// obviously Spanish, "Jose", "San Jacinto"
+		if (index == 0 && e.charAt(index+4) == ' ') || len(e.value) == 4 || e.contains(0, 4, "SAN ") {
+			e.add('H')
+		} else {
+			e.addBoth("J", "H")
+		}
+		return index + 1
+	}
+
+	if index == 0 && !e.contains(index, 4, "JOSE") {
+		e.addBoth("J", "A")
+	} else if isVowel(e.charAt(index-1)) && !e.slavoGermanic &&
+		(e.charAt(index+1) == 'A' || e.charAt(index+1) == 'O') {
+		e.addBoth("J", "H")
+	} else if index == e.last() {
+		e.addPrimary('J')
+	} else if !e.contains(index+1, 1, "L", "T", "K", "S", "N", "M", "B", "Z") &&
+		!e.contains(index-1, 1, "S", "K", "L") {
+		e.add('J')
+	}
+	return e.skipDouble(index, 'J')
+}
+
+func (e *doubleMetaphoneEncoder) handleL(index int) int {
+	if e.charAt(index+1) == 'L' {
+		if e.conditionL0(index) {
+			e.addPrimary('L')
+		} else {
+			e.add('L')
+		}
+		return index + 2
+	}
+	e.add('L')
+	return index + 1
+}
+
+func (e *doubleMetaphoneEncoder) handleP(index int) int {
+	if e.charAt(index+1) == 'H' {
+		e.add('F')
+		return index + 2
+	}
+	e.add('P')
+	if e.contains(index+1, 1, "P", "B") {
+		return index + 2
+	}
+	return index + 1
+}
+
+func (e *doubleMetaphoneEncoder) handleR(index int) int {
+	if index == e.last() && !e.slavoGermanic &&
+		e.contains(index-2, 2, "IE") && !e.contains(index-4, 2, "ME", "MA") {
+		// French, "Rogier"
+		e.addAlternate('R')
+	} else {
+		e.add('R')
+	}
+	return e.skipDouble(index, 'R')
+}
+
+func (e *doubleMetaphoneEncoder) handleS(index int) int {
+	switch {
+	case e.contains(index-1, 3, "ISL", "YSL"):
+		// "island", "isle", "carlisle", "carlysle"
+		return index + 1
+	case index == 0 && e.contains(index, 5, "SUGAR"):
+		e.addBoth("X", "S")
+		return index + 1
+	case e.contains(index, 2, "SH"):
+		if e.contains(index+1, 4, "HEIM", "HOEK", "HOLM", "HOLZ") {
+			// Germanic
+			e.add('S')
+		} else {
+			e.add('X')
+		}
+		return index + 2
+	case e.contains(index, 3, "SIO", "SIA") || e.contains(index, 4, "SIAN"):
+		// Italian and Armenian
+		if e.slavoGermanic {
+			e.add('S')
+		} else {
+			e.addBoth("S", "X")
+		}
+		return index + 3
+	case (index == 0 && e.contains(index+1, 1, "M", "N", "L", "W")) || e.contains(index+1, 1, "Z"):
+		// German and anglicisations, "smith" matches "schmidt",
+		// "snider" matches "schneider", and Slavic -sz-
+		e.addBoth("S", "X")
+		if e.contains(index+1, 1, "Z") {
+			return index + 2
+		}
+		return index + 1
+	case e.contains(index, 2, "SC"):
+		return e.handleSC(index)
+	}
+	if index == e.last() && e.contains(index-2, 2, "AI", "OI") {
+		// French, "resnais", "artois"
+		e.addAlternate('S')
+	} else {
+		e.add('S')
+	}
+	if e.contains(index+1, 1, "S", "Z") {
+		return index + 2
+	}
+	return index + 1
+}
+
+func (e *doubleMetaphoneEncoder) handleSC(index int) int {
+	switch {
+	case e.charAt(index+2) == 'H':
+		// Schlesinger's rule
+		if e.contains(index+3, 2, "OO", "ER", "EN", "UY", "ED", "EM") {
+			// Dutch origin, "school", "schooner"
+			if e.contains(index+3, 2, "ER", "EN") {
+				// "schermerhorn", "schenker"
+				e.addBoth("X", "SK")
+			} else {
+				e.addString("SK")
+			}
+		} else if index == 0 && !isVowel(e.charAt(3)) && e.charAt(3) != 'W' {
+			e.addBoth("X", "S")
+		} else {
+			e.add('X')
+		}
+	case e.contains(index+2, 1, "I", "E", "Y"):
+		e.add('S')
+	default:
+		e.addString("SK")
+	}
+	return index + 3
+}
+
+func (e *doubleMetaphoneEncoder) handleT(index int) int {
+	switch {
+	case e.contains(index, 4, "TION"), e.contains(index, 3, "TIA", "TCH"):
+		e.add('X')
+		return index + 3
+	case e.contains(index, 2, "TH") || e.contains(index, 3, "TTH"):
+		if e.contains(index+2, 2, "OM", "AM") ||
+			e.contains(0, 4, "VAN ", "VON ") || e.contains(0, 3, "SCH") {
+			// "thomas", "thames" or Germanic
+			e.add('T')
+		} else {
+			e.addBoth("0", "T")
+		}
+		return index + 2
+	}
+	e.add('T')
+	if e.contains(index+1, 1, "T", "D") {
+		return index + 2
+	}
+	return index + 1
+}
+
+func (e *doubleMetaphoneEncoder) handleW(index int) int {
+	if e.contains(index, 2, "WR") {
+		// can also be in the middle of a word
+		e.add('R')
+		return index + 2
+	}
+	switch {
+	case index == 0 && (isVowel(e.charAt(index+1)) || e.contains(index, 2, "WH")):
+		if isVowel(e.charAt(index + 1)) {
+			// "Wasserman" matches "Vasserman"
+			e.addBoth("A", "F")
+		} else {
+			// "Uomo" matches "Womo"
+			e.add('A')
+		}
+		return index + 1
+	case (index == e.last() && isVowel(e.charAt(index-1))) ||
+		e.contains(index-1, 5, "EWSKI", "EWSKY", "OWSKI", "OWSKY") ||
+		e.contains(0, 3, "SCH"):
+		// "Arnow" matches "Arnoff"
+		e.addAlternate('F')
+		return index + 1
+	case e.contains(index, 4, "WICZ", "WITZ"):
+		// Polish, "filipowicz"
+		e.addBoth("TS", "FX")
+		return index + 4
+	}
+	return index + 1
+}
+
+func (e *doubleMetaphoneEncoder) handleX(index int) int {
+	if index == 0 {
+		e.add('S')
+		return index + 1
+	}
+	if !(index == e.last() &&
+		(e.contains(index-3, 3, "IAU", "EAU") || e.contains(index-2, 2, "AU", "OU"))) {
+		// not French, "breaux"
+		e.addString("KS")
+	}
+	if e.contains(index+1, 1, "C", "X") {
+		return index + 2
+	}
+	return index + 1
+}
+
+func (e *doubleMetaphoneEncoder) handleZ(index int) int {
+	if e.charAt(index+1) == 'H' {
+		// Chinese pinyin, "zhao"
+		e.add('J')
+		return index + 2
+	}
+	if e.contains(index+1, 2, "ZO", "ZI", "ZA") ||
+		(e.slavoGermanic && index > 0 && e.charAt(index-1) != 'T') {
+		e.addBoth("S", "TS")
+	} else {
+		e.add('S')
+	}
+	return e.skipDouble(index, 'Z')
+}
+
+func (e *doubleMetaphoneEncoder) conditionC0(index int) bool {
+	if e.contains(index, 4, "CHIA") {
+		return true
+	}
+	if index <= 1 || isVowel(e.charAt(index-2)) || !e.contains(index-1, 3, "ACH") {
+		return false
+	}
+	c := e.charAt(index + 2)
+	return (c != 'I' && c != 'E') || e.contains(index-2, 6, "BACHER", "MACHER")
+}
+
+func (e *doubleMetaphoneEncoder) conditionCH0(index int) bool {
+	if index != 0 {
+		return false
+	}
+	if !e.contains(index+1, 5, "HARAC", "HARIS") &&
+		!e.contains(index+1, 3, "HOR", "HYM", "HIA", "HEM") {
+		return false
+	}
+	return !e.contains(0, 5, "CHORE")
+}
+
+func (e *doubleMetaphoneEncoder) conditionCH1(index int) bool {
+	return e.contains(0, 4, "VAN ", "VON ") || e.contains(0, 3, "SCH") ||
+		e.contains(index-2, 6, "ORCHES", "ARCHIT", "ORCHID") ||
+		e.contains(index+2, 1, "T", "S") ||
+		((e.contains(index-1, 1, "A", "O", "U", "E") || index == 0) &&
+			(e.contains(index+2, 1, "L", "R", "N", "M", "B", "H", "F", "V", "W", " ") || index+1 == e.last()))
+}
+
+func (e *doubleMetaphoneEncoder) conditionL0(index int) bool {
+	if index == len(e.value)-3 && e.contains(index-1, 4, "ILLO", "ILLA", "ALLE") {
+		return true
+	}
+	return (e.contains(len(e.value)-2, 2, "AS", "OS") || e.contains(len(e.value)-1, 1, "A", "O")) &&
+		e.contains(index-1, 4, "ALLE")
+}
+
+func (e *doubleMetaphoneEncoder) conditionM0(index int) bool {
+	if e.charAt(index+1) == 'M' {
+		return true
+	}
+	return e.contains(index-1, 3, "UMB") &&
+		(index+1 == e.last() || e.contains(index+2, 2, "ER"))
+}
@@ -0,0 +1,118 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+// Package phonetic_filter implements a TokenFilter replacing
+// each token with codes for how it sounds, so names spelled
+// differently but pronounced alike, such as "Smith" and
+// "Smyth", produce the same terms.
+package phonetic_filter
+
+import (
+	"fmt"
+
+	"github.com/blevesearch/bleve/analysis"
+	"github.com/blevesearch/bleve/registry"
+)
+
+const Name = "phonetic"
+
+const (
+	// Soundex encodes a token as its American Soundex code.
+	Soundex = "soundex"
+	// DoubleMetaphone encodes a token as its primary Double
+	// Metaphone code, and its alternate code when different.
+	DoubleMetaphone = "double_metaphone"
+)
+
+// DefaultAlgorithm is used when the filter is not
+// configured with an algorithm.
+const DefaultAlgorithm = DoubleMetaphone
+
+var encoders = map[string]func(string) []string{
+	Soundex: func(s string) []string {
+		code := SoundexCode(s)
+		if code == "" {
+			return nil
+		}
+		return []string{code}
+	},
+	DoubleMetaphone: func(s string) []string {
+		primary, alternate := DoubleMetaphoneCodes(s)
+		if primary == "" {
+			return nil
+		}
+		if alternate == "" || alternate == primary {
+			return []string{primary}
+		}
+		return []string{primary, alternate}
+	},
+}
+
+type PhoneticFilter struct {
+	encode         func(string) []string
+	outputOriginal bool
+}
+
+// NewPhoneticFilter returns a filter encoding tokens with
+// the named algorithm.  With outputOriginal the original
+// token is kept, followed by the codes at the same
+// position.  Keywords, and tokens without letters to
+// encode, are left as they are.
+func NewPhoneticFilter(algorithm string, outputOriginal bool) (*PhoneticFilter, error) {
+	encode, ok := encoders[algorithm]
+	if !ok {
+		return nil, fmt.Errorf("unknown phonetic algorithm '%s'", algorithm)
+	}
+	return &PhoneticFilter{
+		encode:         encode,
+		outputOriginal: outputOriginal,
+	}, nil
+}
+
+func (f *PhoneticFilter) Filter(input analysis.TokenStream) analysis.TokenStream {
+	rv := make(analysis.TokenStream, 0, len(input))
+	for _, token := range input {
+		if token.KeyWord {
+			rv = append(rv, token)
+			continue
+		}
+		codes := f.encode(string(token.Term))
+		if len(codes) == 0 || f.outputOriginal {
+			rv = append(rv, token)
+		}
+		for _, code := range codes {
+			rv = append(rv, &analysis.Token{
+				Start:    token.Start,
+				End:      token.End,
+				Term:     []byte(code),
+				Position: token.Position,
+				Type:     token.Type,
+			})
+		}
+	}
+	return rv
+}
+
+func PhoneticFilterConstructor(config map[string]interface{}, cache *registry.Cache) (analysis.TokenFilter, error) {
+	algorithm := DefaultAlgorithm
+	algorithmVal, ok := config["algorithm"].(string)
+	if ok {
+		algorithm = algorithmVal
+	}
+	outputOriginal := false
+	outVal, ok := config["output_original"].(bool)
+	if ok {
+		outputOriginal = outVal
+	}
+	return NewPhoneticFilter(algorithm, outputOriginal)
+}
+
+func init() {
+	registry.RegisterTokenFilter(Name, PhoneticFilterConstructor)
+}
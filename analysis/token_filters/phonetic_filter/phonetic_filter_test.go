@@ -0,0 +1,172 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package phonetic_filter
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/blevesearch/bleve/analysis"
+	"github.com/blevesearch/bleve/registry"
+)
+
+func TestSoundexCode(t *testing.T) {
+	tests := []struct {
+		input  string
+		output string
+	}{
+		{input: "Smith", output: "S530"},
+		{input: "smyth", output: "S530"},
+		{input: "Schmidt", output: "S530"},
+		{input: "Robert", output: "R163"},
+		{input: "Rupert", output: "R163"},
+		{input: "Ashcraft", output: "A261"},
+		{input: "Tymczak", output: "T522"},
+		{input: "Pfister", output: "P236"},
+		{input: "Lee", output: "L000"},
+		{input: "O'Hara", output: "O600"},
+		{input: "42", output: ""},
+	}
+	for _, test := range tests {
+		actual := SoundexCode(test.input)
+		if actual != test.output {
+			t.Errorf("expected %s for %s, got %s", test.output, test.input, actual)
+		}
+	}
+}
+
+func TestDoubleMetaphoneCodes(t *testing.T) {
+	tests := []struct {
+		input     string
+		primary   string
+		alternate string
+	}{
+		{input: "Smith", primary: "SM0", alternate: "XMT"},
+		{input: "smyth", primary: "SM0", alternate: "XMT"},
+		{input: "Schmidt", primary: "XMT", alternate: "SMT"},
+		{input: "Catherine", primary: "K0RN", alternate: "KTRN"},
+		{input: "Kathryn", primary: "K0RN", alternate: "KTRN"},
+		{input: "Stephen", primary: "STFN", alternate: "STFN"},
+		{input: "Steven", primary: "STFN", alternate: "STFN"},
+		{input: "Michael", primary: "MKL", alternate: "MXL"},
+		{input: "Jose", primary: "HS", alternate: "HS"},
+		{input: "Xavier", primary: "SF", alternate: "SFR"},
+		{input: "Knight", primary: "NT", alternate: "NT"},
+		{input: "laugh", primary: "LF", alternate: "LF"},
+		{input: "Arnow", primary: "ARN", alternate: "ARNF"},
+		{input: "Filipowicz", primary: "FLPT", alternate: "FLPF"},
+		{input: "Cabrillo", primary: "KPRL", alternate: "KPR"},
+		{input: "", primary: "", alternate: ""},
+	}
+	for _, test := range tests {
+		primary, alternate := DoubleMetaphoneCodes(test.input)
+		if primary != test.primary || alternate != test.alternate {
+			t.Errorf("expected %s/%s for %s, got %s/%s", test.primary, test.alternate, test.input, primary, alternate)
+		}
+	}
+}
+
+func TestPhoneticFilter(t *testing.T) {
+	inputTokenStream := func() analysis.TokenStream {
+		return analysis.TokenStream{
+			&analysis.Token{
+				Start:    0,
+				End:      4,
+				Term:     []byte("john"),
+				Position: 1,
+			},
+			&analysis.Token{
+				Start:    5,
+				End:      10,
+				Term:     []byte("smith"),
+				Position: 2,
+			},
+			&analysis.Token{
+				Start:    11,
+				End:      13,
+				Term:     []byte("42"),
+				Position: 3,
+			},
+		}
+	}
+
+	tests := []struct {
+		algorithm      string
+		outputOriginal bool
+		output         []string
+	}{
+		{
+			algorithm: Soundex,
+			output:    []string{"J500", "S530", "42"},
+		},
+		{
+			algorithm: DoubleMetaphone,
+			output:    []string{"JN", "AN", "SM0", "XMT", "42"},
+		},
+		{
+			algorithm:      Soundex,
+			outputOriginal: true,
+			output:         []string{"john", "J500", "smith", "S530", "42"},
+		},
+	}
+	for _, test := range tests {
+		filter, err := NewPhoneticFilter(test.algorithm, test.outputOriginal)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ouputTokenStream := filter.Filter(inputTokenStream())
+		terms := make([]string, len(ouputTokenStream))
+		for i, token := range ouputTokenStream {
+			terms[i] = string(token.Term)
+		}
+		if !reflect.DeepEqual(terms, test.output) {
+			t.Errorf("expected %v for %s, got %v", test.output, test.algorithm, terms)
+		}
+		// the codes take the place of the original token
+		for _, token := range ouputTokenStream {
+			if string(token.Term) == "S530" && (token.Position != 2 || token.Start != 5 || token.End != 10) {
+				t.Errorf("expected code at position 2, 5-10, got %v", token)
+			}
+		}
+	}
+}
+
+func TestPhoneticFilterConstructor(t *testing.T) {
+	cache := registry.NewCache()
+
+	_, err := PhoneticFilterConstructor(map[string]interface{}{
+		"algorithm": "nysiis",
+	}, cache)
+	if err == nil {
+		t.Errorf("expected error for unknown algorithm")
+	}
+
+	filter, err := PhoneticFilterConstructor(map[string]interface{}{}, cache)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ouputTokenStream := filter.Filter(analysis.TokenStream{
+		&analysis.Token{
+			Term: []byte("schmidt"),
+		},
+		&analysis.Token{
+			Term:    []byte("smith"),
+			KeyWord: true,
+		},
+	})
+	expected := []string{"XMT", "SMT", "smith"}
+	terms := make([]string, len(ouputTokenStream))
+	for i, token := range ouputTokenStream {
+		terms[i] = string(token.Term)
+	}
+	if !reflect.DeepEqual(terms, expected) {
+		t.Errorf("expected %v, got %v", expected, terms)
+	}
+}
@@ -0,0 +1,54 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package phonetic_filter
+
+import (
+	"unicode"
+)
+
+// soundex digits of the letters A to Z, 0 for the
+// vowels which separate letters of the same digit
+const soundexDigits = "01230120022455012623010202"
+
+// SoundexCode returns the American Soundex code of s, its
+// first letter followed by three digits, or the empty
+// string if s has no letters from A to Z.
+func SoundexCode(s string) string {
+	letters := make([]byte, 0, len(s))
+	for _, r := range s {
+		r = unicode.ToUpper(r)
+		if r >= 'A' && r <= 'Z' {
+			letters = append(letters, byte(r))
+		}
+	}
+	if len(letters) == 0 {
+		return ""
+	}
+
+	rv := []byte{letters[0], '0', '0', '0'}
+	n := 1
+	last := soundexDigits[letters[0]-'A']
+	for _, c := range letters[1:] {
+		if n == len(rv) {
+			break
+		}
+		// H and W do not separate letters of the same digit
+		if c == 'H' || c == 'W' {
+			continue
+		}
+		digit := soundexDigits[c-'A']
+		if digit != '0' && digit != last {
+			rv[n] = digit
+			n++
+		}
+		last = digit
+	}
+	return string(rv)
+}
@@ -16,8 +16,6 @@ type ShingleFilter struct {
 	outputOriginal bool
 	tokenSeparator string
 	fill           string
-	ring           *ring.Ring
-	itemsInRing    int
 }
 
 func NewShingleFilter(min, max int, outputOriginal bool, sep, fill string) *ShingleFilter {
@@ -27,13 +25,16 @@ func NewShingleFilter(min, max int, outputOriginal bool, sep, fill string) *Shin
 		outputOriginal: outputOriginal,
 		tokenSeparator: sep,
 		fill:           fill,
-		ring:           ring.New(max),
 	}
 }
 
 func (s *ShingleFilter) Filter(input analysis.TokenStream) analysis.TokenStream {
 	rv := make(analysis.TokenStream, 0, len(input))
 
+	// the ring holds the last max tokens of this input only, so
+	// shingles never span inputs and the filter may be shared
+	r := ring.New(s.max)
+	itemsInRing := 0
 	currentPosition := 0
 	for _, token := range input {
 		if s.outputOriginal {
@@ -50,35 +51,35 @@ func (s *ShingleFilter) Filter(input analysis.TokenStream) analysis.TokenStream
 				Type:     analysis.AlphaNumeric,
 				Term:     []byte(s.fill),
 			}
-			s.ring.Value = &fillerToken
-			if s.itemsInRing < s.max {
-				s.itemsInRing++
+			r.Value = &fillerToken
+			if itemsInRing < s.max {
+				itemsInRing++
 			}
-			rv = append(rv, s.shingleCurrentRingState()...)
-			s.ring = s.ring.Next()
+			rv = append(rv, s.shingleCurrentRingState(r, itemsInRing)...)
+			r = r.Next()
 			offset--
 		}
 		currentPosition = token.Position
 
-		s.ring.Value = token
-		if s.itemsInRing < s.max {
-			s.itemsInRing++
+		r.Value = token
+		if itemsInRing < s.max {
+			itemsInRing++
 		}
-		rv = append(rv, s.shingleCurrentRingState()...)
-		s.ring = s.ring.Next()
+		rv = append(rv, s.shingleCurrentRingState(r, itemsInRing)...)
+		r = r.Next()
 
 	}
 
 	return rv
 }
 
-func (s *ShingleFilter) shingleCurrentRingState() analysis.TokenStream {
+func (s *ShingleFilter) shingleCurrentRingState(r *ring.Ring, itemsInRing int) analysis.TokenStream {
 	rv := make(analysis.TokenStream, 0)
 	for shingleN := s.min; shingleN <= s.max; shingleN++ {
 		// if there are enough items in the ring
 		// to produce a shingle of this size
-		if s.itemsInRing >= shingleN {
-			thisShingleRing := s.ring.Move(-(shingleN - 1))
+		if itemsInRing >= shingleN {
+			thisShingleRing := r.Move(-(shingleN - 1))
 			shingledBytes := make([]byte, 0)
 			pos := 0
 			start := -1
@@ -130,6 +131,12 @@ func ShingleFilterConstructor(config map[string]interface{}, cache *registry.Cac
 		return nil, fmt.Errorf("must specify max")
 	}
 	max := int(maxVal)
+	if min < 1 {
+		return nil, fmt.Errorf("min must be at least 1")
+	}
+	if max < min {
+		return nil, fmt.Errorf("max must not be less than min")
+	}
 
 	outputOriginal := false
 	outVal, ok := config["output_original"].(bool)
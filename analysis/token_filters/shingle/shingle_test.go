@@ -318,6 +318,20 @@ func TestShingleFilter(t *testing.T) {
 				},
 			},
 		},
+		{
+			min:            2,
+			max:            3,
+			outputOriginal: false,
+			separator:      " ",
+			filler:         "_",
+			input: analysis.TokenStream{
+				&analysis.Token{
+					Term:     []byte("fox"),
+					Position: 1,
+				},
+			},
+			output: analysis.TokenStream{},
+		},
 	}
 
 	for _, test := range tests {
@@ -328,3 +342,74 @@ func TestShingleFilter(t *testing.T) {
 		}
 	}
 }
+
+func TestShingleFilterReused(t *testing.T) {
+	shingleFilter := NewShingleFilter(2, 2, false, " ", "_")
+	shingleFilter.Filter(analysis.TokenStream{
+		&analysis.Token{
+			Term:     []byte("the"),
+			Position: 1,
+		},
+		&analysis.Token{
+			Term:     []byte("quick"),
+			Position: 2,
+		},
+		&analysis.Token{
+			Term:     []byte("brown"),
+			Position: 3,
+		},
+	})
+
+	// no shingle joins the last token of the previous input
+	actual := shingleFilter.Filter(analysis.TokenStream{
+		&analysis.Token{
+			Term:     []byte("fox"),
+			Position: 1,
+		},
+		&analysis.Token{
+			Term:     []byte("jumps"),
+			Position: 2,
+		},
+	})
+	expected := analysis.TokenStream{
+		&analysis.Token{
+			Term:     []byte("fox jumps"),
+			Type:     analysis.Shingle,
+			Position: 1,
+		},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %s, got %s", expected, actual)
+	}
+}
+
+func TestShingleFilterConstructor(t *testing.T) {
+	tests := []struct {
+		config map[string]interface{}
+		valid  bool
+	}{
+		{
+			config: map[string]interface{}{"min": 2.0, "max": 3.0},
+			valid:  true,
+		},
+		{
+			config: map[string]interface{}{"max": 3.0},
+			valid:  false,
+		},
+		{
+			config: map[string]interface{}{"min": 0.0, "max": 3.0},
+			valid:  false,
+		},
+		{
+			config: map[string]interface{}{"min": 3.0, "max": 2.0},
+			valid:  false,
+		},
+	}
+
+	for _, test := range tests {
+		_, err := ShingleFilterConstructor(test.config, nil)
+		if (err == nil) != test.valid {
+			t.Errorf("expected valid %t for %v, got error %v", test.valid, test.config, err)
+		}
+	}
+}
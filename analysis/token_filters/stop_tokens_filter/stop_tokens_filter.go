@@ -41,16 +41,47 @@ func (f *StopTokensFilter) Filter(input analysis.TokenStream) analysis.TokenStre
 	return rv
 }
 
+// StopTokensFilterConstructor builds the filter from a named
+// token map (stop_token_map), the built-in stop word list of a
+// language registered by the analysis/language packages (lang),
+// or an inline list of stop words (words), in that order of
+// precedence.
 func StopTokensFilterConstructor(config map[string]interface{}, cache *registry.Cache) (analysis.TokenFilter, error) {
 	stopTokenMapName, ok := config["stop_token_map"].(string)
-	if !ok {
-		return nil, fmt.Errorf("must specify stop_token_map")
+	if ok {
+		stopTokenMap, err := cache.TokenMapNamed(stopTokenMapName)
+		if err != nil {
+			return nil, fmt.Errorf("error building stop words filter: %v", err)
+		}
+		return NewStopTokensFilter(stopTokenMap), nil
+	}
+	lang, ok := config["lang"].(string)
+	if ok {
+		stopTokenMap, err := cache.TokenMapNamed(languageStopTokenMapName(lang))
+		if err != nil {
+			return nil, fmt.Errorf("error building stop words filter: unknown language '%s'", lang)
+		}
+		return NewStopTokensFilter(stopTokenMap), nil
 	}
-	stopTokenMap, err := cache.TokenMapNamed(stopTokenMapName)
-	if err != nil {
-		return nil, fmt.Errorf("error building stop words filter: %v", err)
+	words, ok := config["words"].([]interface{})
+	if ok {
+		stopTokenMap := analysis.NewTokenMap()
+		for _, word := range words {
+			wordStr, ok := word.(string)
+			if !ok {
+				return nil, fmt.Errorf("error building stop words filter: invalid word %v", word)
+			}
+			stopTokenMap.AddToken(wordStr)
+		}
+		return NewStopTokensFilter(stopTokenMap), nil
 	}
-	return NewStopTokensFilter(stopTokenMap), nil
+	return nil, fmt.Errorf("must specify stop_token_map, lang or words")
+}
+
+// the language packages register their stop word
+// lists as token maps named stop_<lang>
+func languageStopTokenMapName(lang string) string {
+	return "stop_" + lang
 }
 
 func init() {
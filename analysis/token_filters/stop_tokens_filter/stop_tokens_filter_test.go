@@ -71,3 +71,53 @@ func TestStopWordsFilter(t *testing.T) {
 		t.Errorf("expected %#v got %#v", expectedTokenStream, ouputTokenStream)
 	}
 }
+
+func TestStopWordsFilterInlineWords(t *testing.T) {
+
+	inputTokenStream := analysis.TokenStream{
+		&analysis.Token{
+			Term: []byte("a"),
+		},
+		&analysis.Token{
+			Term: []byte("walk"),
+		},
+		&analysis.Token{
+			Term: []byte("in"),
+		},
+	}
+
+	expectedTokenStream := analysis.TokenStream{
+		&analysis.Token{
+			Term: []byte("walk"),
+		},
+	}
+
+	cache := registry.NewCache()
+	stopConfig := map[string]interface{}{
+		"type":  "stop_tokens",
+		"words": []interface{}{"a", "in"},
+	}
+	stopFilter, err := cache.DefineTokenFilter("stop_test", stopConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ouputTokenStream := stopFilter.Filter(inputTokenStream)
+	if !reflect.DeepEqual(ouputTokenStream, expectedTokenStream) {
+		t.Errorf("expected %#v got %#v", expectedTokenStream, ouputTokenStream)
+	}
+}
+
+func TestStopWordsFilterInvalidConfig(t *testing.T) {
+	configs := []map[string]interface{}{
+		{},
+		{"lang": "xx"},
+		{"words": []interface{}{"a", 7}},
+	}
+	for _, config := range configs {
+		_, err := StopTokensFilterConstructor(config, registry.NewCache())
+		if err == nil {
+			t.Errorf("expected error for config %v", config)
+		}
+	}
+}
@@ -0,0 +1,262 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+// Package synonym_filter implements a token filter which injects
+// synonyms into the token stream.
+//
+// Synonyms are described with rules, one per line when loaded from
+// a file:
+//
+//	# multi-way, each term expands to all the others
+//	tv, television, telly
+//	# one-way, car is replaced by automobile and vehicle
+//	car => automobile, vehicle
+//
+// A one-way rule replaces the original token, so to keep it
+// list it on the right hand side as well ("car => car, vehicle").
+// Synonyms are injected at the position of the original token,
+// so phrase queries continue to work.  Only single token
+// synonyms are supported.
+package synonym_filter
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/blevesearch/bleve/analysis"
+	"github.com/blevesearch/bleve/registry"
+)
+
+const Name = "synonym"
+
+type synonyms struct {
+	terms   []string
+	replace bool
+}
+
+// SynonymMap maps a term to its synonyms
+type SynonymMap map[string]*synonyms
+
+func NewSynonymMap() SynonymMap {
+	return make(SynonymMap, 0)
+}
+
+func (s SynonymMap) LoadFile(filename string) error {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	return s.LoadBytes(data)
+}
+
+func (s SynonymMap) LoadBytes(data []byte) error {
+	bytesReader := bytes.NewReader(data)
+	bufioReader := bufio.NewReader(bytesReader)
+	line, err := bufioReader.ReadString('\n')
+	for err == nil {
+		err = s.LoadLine(line)
+		if err != nil {
+			return err
+		}
+		line, err = bufioReader.ReadString('\n')
+	}
+	// if the err was EOF we still need to process the last value
+	if err == io.EOF {
+		return s.LoadLine(line)
+	}
+	return err
+}
+
+// LoadLine parses a single synonym rule, blank
+// lines and comments are ignored
+func (s SynonymMap) LoadLine(line string) error {
+	startComment := strings.Index(line, "#")
+	if startComment >= 0 {
+		line = line[:startComment]
+	}
+	if strings.TrimSpace(line) == "" {
+		return nil
+	}
+
+	sides := strings.Split(line, "=>")
+	switch len(sides) {
+	case 1:
+		terms, err := parseTerms(sides[0])
+		if err != nil {
+			return err
+		}
+		return s.AddEquivalent(terms)
+	case 2:
+		from, err := parseTerms(sides[0])
+		if err != nil {
+			return err
+		}
+		to, err := parseTerms(sides[1])
+		if err != nil {
+			return err
+		}
+		if len(to) == 0 {
+			return fmt.Errorf("invalid synonym rule '%s'", strings.TrimSpace(line))
+		}
+		return s.AddMapping(from, to)
+	}
+	return fmt.Errorf("invalid synonym rule '%s'", strings.TrimSpace(line))
+}
+
+func parseTerms(list string) ([]string, error) {
+	rv := make([]string, 0)
+	for _, term := range strings.Split(list, ",") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+		if strings.IndexAny(term, " \t") >= 0 {
+			return nil, fmt.Errorf("multi-token synonym '%s' not supported", term)
+		}
+		rv = append(rv, term)
+	}
+	return rv, nil
+}
+
+// AddEquivalent makes each of the terms expand
+// to all of the others
+func (s SynonymMap) AddEquivalent(terms []string) error {
+	if len(terms) < 2 {
+		return fmt.Errorf("synonym group must have at least 2 terms, got %v", terms)
+	}
+	for _, term := range terms {
+		s.add(term, terms, false)
+	}
+	return nil
+}
+
+// AddMapping makes each of the from terms be
+// replaced by the to terms
+func (s SynonymMap) AddMapping(from, to []string) error {
+	if len(from) < 1 || len(to) < 1 {
+		return fmt.Errorf("synonym mapping must have terms on both sides")
+	}
+	for _, term := range from {
+		s.add(term, to, true)
+	}
+	return nil
+}
+
+func (s SynonymMap) add(term string, terms []string, replace bool) {
+	syns := s[term]
+	if syns == nil {
+		syns = &synonyms{}
+		s[term] = syns
+	}
+	if replace {
+		syns.replace = true
+	}
+TERMS:
+	for _, t := range terms {
+		if !replace && t == term {
+			continue
+		}
+		for _, existing := range syns.terms {
+			if existing == t {
+				continue TERMS
+			}
+		}
+		syns.terms = append(syns.terms, t)
+	}
+}
+
+type SynonymFilter struct {
+	synonyms SynonymMap
+}
+
+func NewSynonymFilter(synonyms SynonymMap) *SynonymFilter {
+	return &SynonymFilter{
+		synonyms: synonyms,
+	}
+}
+
+func (f *SynonymFilter) Filter(input analysis.TokenStream) analysis.TokenStream {
+	rv := make(analysis.TokenStream, 0, len(input))
+	for _, token := range input {
+		syns := f.synonyms[string(token.Term)]
+		if syns == nil {
+			rv = append(rv, token)
+			continue
+		}
+		keepOriginal := !syns.replace
+		if keepOriginal {
+			rv = append(rv, token)
+		}
+		for _, term := range syns.terms {
+			if keepOriginal && term == string(token.Term) {
+				continue
+			}
+			rv = append(rv, &analysis.Token{
+				Term:     []byte(term),
+				Start:    token.Start,
+				End:      token.End,
+				Position: token.Position,
+				Type:     token.Type,
+				KeyWord:  token.KeyWord,
+			})
+		}
+	}
+	return rv
+}
+
+func SynonymFilterConstructor(config map[string]interface{}, cache *registry.Cache) (analysis.TokenFilter, error) {
+	rv := NewSynonymMap()
+
+	// first: try to load by filename
+	filename, ok := config["filename"].(string)
+	if ok {
+		err := rv.LoadFile(filename)
+		if err != nil {
+			return nil, fmt.Errorf("error building synonym filter: %v", err)
+		}
+		return NewSynonymFilter(rv), nil
+	}
+	// next: look for inline rules, either rule strings
+	// or lists of equivalent terms
+	rules, ok := config["synonyms"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("must specify filename or list of synonyms")
+	}
+	for _, rule := range rules {
+		var err error
+		switch rule := rule.(type) {
+		case string:
+			err = rv.LoadLine(rule)
+		case []interface{}:
+			terms := make([]string, 0, len(rule))
+			for _, term := range rule {
+				termStr, ok := term.(string)
+				if !ok {
+					return nil, fmt.Errorf("invalid synonym %v", term)
+				}
+				terms = append(terms, termStr)
+			}
+			err = rv.AddEquivalent(terms)
+		default:
+			err = fmt.Errorf("invalid synonym rule %v", rule)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error building synonym filter: %v", err)
+		}
+	}
+	return NewSynonymFilter(rv), nil
+}
+
+func init() {
+	registry.RegisterTokenFilter(Name, SynonymFilterConstructor)
+}
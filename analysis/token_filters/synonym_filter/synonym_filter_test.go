@@ -0,0 +1,220 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package synonym_filter
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/blevesearch/bleve/analysis"
+	"github.com/blevesearch/bleve/registry"
+)
+
+func TestSynonymFilter(t *testing.T) {
+
+	tests := []struct {
+		config map[string]interface{}
+		input  analysis.TokenStream
+		output analysis.TokenStream
+	}{
+		// multi-way, inline rule
+		{
+			config: map[string]interface{}{
+				"type":     Name,
+				"synonyms": []interface{}{"tv, television"},
+			},
+			input: analysis.TokenStream{
+				&analysis.Token{
+					Term:     []byte("the"),
+					Position: 1,
+					Start:    0,
+					End:      3,
+				},
+				&analysis.Token{
+					Term:     []byte("television"),
+					Position: 2,
+					Start:    4,
+					End:      14,
+				},
+			},
+			output: analysis.TokenStream{
+				&analysis.Token{
+					Term:     []byte("the"),
+					Position: 1,
+					Start:    0,
+					End:      3,
+				},
+				&analysis.Token{
+					Term:     []byte("television"),
+					Position: 2,
+					Start:    4,
+					End:      14,
+				},
+				&analysis.Token{
+					Term:     []byte("tv"),
+					Position: 2,
+					Start:    4,
+					End:      14,
+				},
+			},
+		},
+		// multi-way, inline array
+		{
+			config: map[string]interface{}{
+				"type":     Name,
+				"synonyms": []interface{}{[]interface{}{"tv", "television", "telly"}},
+			},
+			input: analysis.TokenStream{
+				&analysis.Token{
+					Term:     []byte("tv"),
+					Position: 1,
+				},
+			},
+			output: analysis.TokenStream{
+				&analysis.Token{
+					Term:     []byte("tv"),
+					Position: 1,
+				},
+				&analysis.Token{
+					Term:     []byte("television"),
+					Position: 1,
+				},
+				&analysis.Token{
+					Term:     []byte("telly"),
+					Position: 1,
+				},
+			},
+		},
+		// one-way replaces the original token
+		{
+			config: map[string]interface{}{
+				"type":     Name,
+				"synonyms": []interface{}{"car => automobile, vehicle"},
+			},
+			input: analysis.TokenStream{
+				&analysis.Token{
+					Term:     []byte("car"),
+					Position: 1,
+				},
+				&analysis.Token{
+					Term:     []byte("vehicle"),
+					Position: 2,
+				},
+			},
+			output: analysis.TokenStream{
+				&analysis.Token{
+					Term:     []byte("automobile"),
+					Position: 1,
+				},
+				&analysis.Token{
+					Term:     []byte("vehicle"),
+					Position: 1,
+				},
+				&analysis.Token{
+					Term:     []byte("vehicle"),
+					Position: 2,
+				},
+			},
+		},
+		// one-way keeping the original token
+		{
+			config: map[string]interface{}{
+				"type":     Name,
+				"synonyms": []interface{}{"car => car, automobile"},
+			},
+			input: analysis.TokenStream{
+				&analysis.Token{
+					Term:     []byte("car"),
+					Position: 1,
+				},
+			},
+			output: analysis.TokenStream{
+				&analysis.Token{
+					Term:     []byte("car"),
+					Position: 1,
+				},
+				&analysis.Token{
+					Term:     []byte("automobile"),
+					Position: 1,
+				},
+			},
+		},
+		// rules loaded from a file
+		{
+			config: map[string]interface{}{
+				"type":     Name,
+				"filename": "test_synonyms.txt",
+			},
+			input: analysis.TokenStream{
+				&analysis.Token{
+					Term:     []byte("telly"),
+					Position: 1,
+				},
+				&analysis.Token{
+					Term:     []byte("car"),
+					Position: 2,
+				},
+			},
+			output: analysis.TokenStream{
+				&analysis.Token{
+					Term:     []byte("telly"),
+					Position: 1,
+				},
+				&analysis.Token{
+					Term:     []byte("tv"),
+					Position: 1,
+				},
+				&analysis.Token{
+					Term:     []byte("television"),
+					Position: 1,
+				},
+				&analysis.Token{
+					Term:     []byte("automobile"),
+					Position: 2,
+				},
+				&analysis.Token{
+					Term:     []byte("vehicle"),
+					Position: 2,
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		cache := registry.NewCache()
+		filter, err := cache.DefineTokenFilter("synonym_test", test.config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual := filter.Filter(test.input)
+		if !reflect.DeepEqual(actual, test.output) {
+			t.Errorf("expected %v, got %v", test.output, actual)
+		}
+	}
+}
+
+func TestSynonymFilterInvalidConfig(t *testing.T) {
+	configs := []map[string]interface{}{
+		{"type": Name},
+		{"type": Name, "synonyms": []interface{}{"tv"}},
+		{"type": Name, "synonyms": []interface{}{"car =>"}},
+		{"type": Name, "synonyms": []interface{}{"a => b => c"}},
+		{"type": Name, "synonyms": []interface{}{"tv, television set"}},
+		{"type": Name, "synonyms": []interface{}{[]interface{}{"tv", 7}}},
+		{"type": Name, "filename": "does_not_exist.txt"},
+	}
+	for _, config := range configs {
+		cache := registry.NewCache()
+		_, err := cache.DefineTokenFilter("synonym_test", config)
+		if err == nil {
+			t.Errorf("expected error for config %v", config)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+// Package token_type_filter implements a TokenFilter making the
+// type of tokens searchable.  Each token of one of the selected
+// types is followed by a copy at the same position whose term is
+// prefixed with the name of the type, so "bob@example.com" typed
+// analysis.Email is also indexed as "<EMAIL>bob@example.com".
+// A search for that term only matches the text as an email
+// address, and a prefix search for "<EMAIL>" matches any.
+package token_type_filter
+
+import (
+	"fmt"
+
+	"github.com/blevesearch/bleve/analysis"
+	"github.com/blevesearch/bleve/registry"
+)
+
+const Name = "token_type"
+
+// TypeNames maps the names of the token types used in the
+// prefix of the typed terms, and in the configuration of the
+// filter, to the types.
+var TypeNames = map[string]analysis.TokenType{
+	"WORD":        analysis.AlphaNumeric,
+	"IDEOGRAPHIC": analysis.Ideographic,
+	"NUM":         analysis.Numeric,
+	"URL":         analysis.URL,
+	"EMAIL":       analysis.Email,
+}
+
+// DefaultTypes are the types made searchable when the filter
+// is not configured with a list of types.
+var DefaultTypes = []analysis.TokenType{
+	analysis.URL,
+	analysis.Email,
+	analysis.Numeric,
+}
+
+// TypePrefix returns the prefix of the typed terms of tokens
+// of type typ, or nil for a type without a name.
+func TypePrefix(typ analysis.TokenType) []byte {
+	for name, t := range TypeNames {
+		if t == typ {
+			return []byte("<" + name + ">")
+		}
+	}
+	return nil
+}
+
+type TokenTypeFilter struct {
+	prefixes map[analysis.TokenType][]byte
+}
+
+func NewTokenTypeFilter(types []analysis.TokenType) *TokenTypeFilter {
+	prefixes := make(map[analysis.TokenType][]byte, len(types))
+	for _, typ := range types {
+		prefix := TypePrefix(typ)
+		if prefix != nil {
+			prefixes[typ] = prefix
+		}
+	}
+	return &TokenTypeFilter{
+		prefixes: prefixes,
+	}
+}
+
+func (f *TokenTypeFilter) Filter(input analysis.TokenStream) analysis.TokenStream {
+	rv := make(analysis.TokenStream, 0, len(input))
+	for _, token := range input {
+		rv = append(rv, token)
+		prefix, ok := f.prefixes[token.Type]
+		if !ok {
+			continue
+		}
+		term := make([]byte, 0, len(prefix)+len(token.Term))
+		term = append(term, prefix...)
+		term = append(term, token.Term...)
+		rv = append(rv, &analysis.Token{
+			Start:    token.Start,
+			End:      token.End,
+			Term:     term,
+			Position: token.Position,
+			Type:     token.Type,
+			KeyWord:  true,
+		})
+	}
+	return rv
+}
+
+func TokenTypeFilterConstructor(config map[string]interface{}, cache *registry.Cache) (analysis.TokenFilter, error) {
+	types := DefaultTypes
+	names, ok := config["types"].([]interface{})
+	if ok {
+		types = make([]analysis.TokenType, 0, len(names))
+		for _, name := range names {
+			nameStr, ok := name.(string)
+			if !ok {
+				return nil, fmt.Errorf("error building token type filter: invalid type %v", name)
+			}
+			typ, ok := TypeNames[nameStr]
+			if !ok {
+				return nil, fmt.Errorf("error building token type filter: unknown type '%s'", nameStr)
+			}
+			types = append(types, typ)
+		}
+	}
+	return NewTokenTypeFilter(types), nil
+}
+
+func init() {
+	registry.RegisterTokenFilter(Name, TokenTypeFilterConstructor)
+}
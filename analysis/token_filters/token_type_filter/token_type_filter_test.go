@@ -0,0 +1,117 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package token_type_filter
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/blevesearch/bleve/analysis"
+	"github.com/blevesearch/bleve/registry"
+)
+
+func TestTokenTypeFilter(t *testing.T) {
+
+	inputTokenStream := analysis.TokenStream{
+		&analysis.Token{
+			Start:    0,
+			End:      4,
+			Term:     []byte("mail"),
+			Position: 1,
+			Type:     analysis.AlphaNumeric,
+		},
+		&analysis.Token{
+			Start:    5,
+			End:      20,
+			Term:     []byte("bob@example.com"),
+			Position: 2,
+			Type:     analysis.Email,
+		},
+		&analysis.Token{
+			Start:    21,
+			End:      23,
+			Term:     []byte("42"),
+			Position: 3,
+			Type:     analysis.Numeric,
+		},
+	}
+
+	expectedTokenStream := analysis.TokenStream{
+		&analysis.Token{
+			Start:    0,
+			End:      4,
+			Term:     []byte("mail"),
+			Position: 1,
+			Type:     analysis.AlphaNumeric,
+		},
+		&analysis.Token{
+			Start:    5,
+			End:      20,
+			Term:     []byte("bob@example.com"),
+			Position: 2,
+			Type:     analysis.Email,
+		},
+		&analysis.Token{
+			Start:    5,
+			End:      20,
+			Term:     []byte("<EMAIL>bob@example.com"),
+			Position: 2,
+			Type:     analysis.Email,
+			KeyWord:  true,
+		},
+		&analysis.Token{
+			Start:    21,
+			End:      23,
+			Term:     []byte("42"),
+			Position: 3,
+			Type:     analysis.Numeric,
+		},
+	}
+
+	filter := NewTokenTypeFilter([]analysis.TokenType{analysis.Email, analysis.URL})
+	ouputTokenStream := filter.Filter(inputTokenStream)
+	if !reflect.DeepEqual(ouputTokenStream, expectedTokenStream) {
+		t.Errorf("expected %v got %v", expectedTokenStream, ouputTokenStream)
+	}
+}
+
+func TestTokenTypeFilterConstructor(t *testing.T) {
+	cache := registry.NewCache()
+
+	_, err := TokenTypeFilterConstructor(map[string]interface{}{
+		"types": []interface{}{"EMAIL", "PHONE"},
+	}, cache)
+	if err == nil {
+		t.Errorf("expected error for unknown type")
+	}
+
+	filter, err := TokenTypeFilterConstructor(map[string]interface{}{
+		"types": []interface{}{"NUM"},
+	}, cache)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ouputTokenStream := filter.Filter(analysis.TokenStream{
+		&analysis.Token{
+			Term: []byte("bob@example.com"),
+			Type: analysis.Email,
+		},
+		&analysis.Token{
+			Term: []byte("42"),
+			Type: analysis.Numeric,
+		},
+	})
+	if len(ouputTokenStream) != 3 {
+		t.Fatalf("expected 3 tokens, got %d", len(ouputTokenStream))
+	}
+	if string(ouputTokenStream[2].Term) != "<NUM>42" {
+		t.Errorf("expected <NUM>42, got %s", ouputTokenStream[2].Term)
+	}
+}
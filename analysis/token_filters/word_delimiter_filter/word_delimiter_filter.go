@@ -0,0 +1,289 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+// Package word_delimiter_filter splits tokens into subwords,
+// like Lucene's WordDelimiterGraphFilter, so "PowerShot2000"
+// can be found by "power", "shot" or "2000", and "wi-fi" by
+// "wi fi" or "wifi".
+//
+// Tokens are split at the characters which are neither letters
+// nor digits, at the changes from lower to upper case
+// ("PowerShot"), before the last of a run of upper case letters
+// followed by a lower case one ("XMLParser"), and between letters
+// and digits ("Shot2000").  The subwords take consecutive
+// positions, and the tokens after them are moved along, so a
+// phrase query over the subwords matches.  The catenated subwords
+// and the original token are put at the position of their first
+// subword.
+package word_delimiter_filter
+
+import (
+	"bytes"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/blevesearch/bleve/analysis"
+	"github.com/blevesearch/bleve/registry"
+)
+
+const Name = "word_delimiter"
+
+// The flags choosing how tokens are split, and which tokens
+// are emitted.
+const (
+	// GenerateWordParts emits the subwords made of letters.
+	GenerateWordParts = 1 << iota
+	// GenerateNumberParts emits the subwords made of digits.
+	GenerateNumberParts
+	// CatenateWords emits each run of consecutive letter
+	// subwords joined, "wi-fi" gives "wifi".
+	CatenateWords
+	// CatenateNumbers emits each run of consecutive digit
+	// subwords joined, "500-42" gives "50042".
+	CatenateNumbers
+	// CatenateAll emits all the subwords joined,
+	// "wi-fi-4000" gives "wifi4000".
+	CatenateAll
+	// PreserveOriginal emits the token as it was too.
+	PreserveOriginal
+	// SplitOnCaseChange splits at the changes of case.
+	SplitOnCaseChange
+	// SplitOnNumerics splits between letters and digits.
+	SplitOnNumerics
+)
+
+// DefaultFlags emits the letter and digit subwords of the
+// tokens split at delimiters, changes of case and between
+// letters and digits.
+const DefaultFlags = GenerateWordParts | GenerateNumberParts | SplitOnCaseChange | SplitOnNumerics
+
+type WordDelimiterFilter struct {
+	flags int
+}
+
+// NewWordDelimiterFilter creates a filter splitting tokens
+// by flags, a combination of the flags above.
+func NewWordDelimiterFilter(flags int) *WordDelimiterFilter {
+	return &WordDelimiterFilter{
+		flags: flags,
+	}
+}
+
+func (f *WordDelimiterFilter) has(flag int) bool {
+	return f.flags&flag != 0
+}
+
+// the kinds of the runes of a token
+const (
+	runeDelimiter = iota
+	runeLower
+	runeUpper
+	runeDigit
+)
+
+func runeKind(r rune) int {
+	switch {
+	case unicode.IsDigit(r):
+		return runeDigit
+	case unicode.IsUpper(r):
+		return runeUpper
+	case unicode.IsLetter(r):
+		return runeLower
+	}
+	return runeDelimiter
+}
+
+func isLetter(kind int) bool {
+	return kind == runeLower || kind == runeUpper
+}
+
+// a part is a subword, from start to end in the term,
+// numeric when it has no letters
+type part struct {
+	start   int
+	end     int
+	numeric bool
+}
+
+// split returns the subwords of term.
+func (f *WordDelimiterFilter) split(term []byte) []part {
+	var rv []part
+	partStart := -1
+	letters := false
+	lastKind := runeDelimiter
+	for i := 0; i < len(term); {
+		r, size := utf8.DecodeRune(term[i:])
+		kind := runeKind(r)
+		brk := false
+		switch {
+		case kind == runeDelimiter || lastKind == runeDelimiter:
+			brk = true
+		case isLetter(lastKind) != isLetter(kind):
+			brk = f.has(SplitOnNumerics)
+		case lastKind == runeLower && kind == runeUpper:
+			brk = f.has(SplitOnCaseChange)
+		case lastKind == runeUpper && kind == runeLower && f.has(SplitOnCaseChange):
+			// "XMLParser" splits before the "P"
+			prev, prevSize := utf8.DecodeLastRune(term[:i])
+			if runeKind(prev) == runeUpper && i-prevSize > partStart {
+				before, _ := utf8.DecodeLastRune(term[:i-prevSize])
+				if runeKind(before) == runeUpper {
+					rv = append(rv, part{start: partStart, end: i - prevSize})
+					partStart = i - prevSize
+				}
+			}
+		}
+		if brk {
+			if partStart >= 0 {
+				rv = append(rv, part{start: partStart, end: i, numeric: !letters})
+				partStart = -1
+			}
+			if kind != runeDelimiter {
+				partStart = i
+				letters = false
+			}
+		}
+		letters = letters || isLetter(kind)
+		lastKind = kind
+		i += size
+	}
+	if partStart >= 0 {
+		rv = append(rv, part{start: partStart, end: len(term), numeric: !letters})
+	}
+	return rv
+}
+
+func (f *WordDelimiterFilter) Filter(input analysis.TokenStream) analysis.TokenStream {
+	rv := make(analysis.TokenStream, 0, len(input))
+	shift := 0
+	for _, token := range input {
+		token.Position += shift
+		if token.KeyWord {
+			rv = append(rv, token)
+			continue
+		}
+		parts := f.split(token.Term)
+		if len(parts) == 1 && parts[0].start == 0 && parts[0].end == len(token.Term) {
+			rv = append(rv, token)
+			continue
+		}
+		var positions int
+		rv, positions = f.subwords(rv, token, parts)
+		if positions > 1 {
+			shift += positions - 1
+		}
+	}
+	return rv
+}
+
+// subwords appends the tokens for the parts of token to rv,
+// and returns the number of positions they take.
+func (f *WordDelimiterFilter) subwords(rv analysis.TokenStream, token *analysis.Token, parts []part) (analysis.TokenStream, int) {
+	group := len(rv)
+	emit := func(term []byte, start, end, position int) {
+		// the same term at the same position once
+		for _, t := range rv[group:] {
+			if t.Position == position && bytes.Equal(t.Term, term) {
+				return
+			}
+		}
+		rv = append(rv, &analysis.Token{
+			Term:     term,
+			Start:    token.Start + start,
+			End:      token.Start + end,
+			Position: position,
+			Type:     token.Type,
+		})
+	}
+	generated := func(p part) bool {
+		if p.numeric {
+			return f.has(GenerateNumberParts)
+		}
+		return f.has(GenerateWordParts)
+	}
+	catenated := func(p part) bool {
+		if p.numeric {
+			return f.has(CatenateNumbers)
+		}
+		return f.has(CatenateWords)
+	}
+	join := func(parts []part) []byte {
+		var rv []byte
+		for _, p := range parts {
+			rv = append(rv, token.Term[p.start:p.end]...)
+		}
+		return rv
+	}
+
+	if f.has(PreserveOriginal) {
+		emit(token.Term, 0, len(token.Term), token.Position)
+	}
+	if len(parts) == 0 {
+		if f.has(PreserveOriginal) {
+			return rv, 1
+		}
+		return rv, 0
+	}
+	if f.has(CatenateAll) && len(parts) > 1 {
+		emit(join(parts), parts[0].start, parts[len(parts)-1].end, token.Position)
+	}
+	position := token.Position
+	for n, p := range parts {
+		// a run of parts of the same kind starts here
+		if catenated(p) && (n == 0 || parts[n-1].numeric != p.numeric) {
+			run := n + 1
+			for run < len(parts) && parts[run].numeric == p.numeric {
+				run++
+			}
+			if run-n > 1 {
+				emit(join(parts[n:run]), p.start, parts[run-1].end, position)
+			}
+		}
+		if generated(p) {
+			emit(token.Term[p.start:p.end], p.start, p.end, position)
+			position++
+		}
+	}
+	positions := position - token.Position
+	if positions == 0 && len(rv) > group {
+		positions = 1
+	}
+	return rv, positions
+}
+
+func WordDelimiterFilterConstructor(config map[string]interface{}, cache *registry.Cache) (analysis.TokenFilter, error) {
+	flags := 0
+	options := []struct {
+		key  string
+		flag int
+	}{
+		{"generate_word_parts", GenerateWordParts},
+		{"generate_number_parts", GenerateNumberParts},
+		{"catenate_words", CatenateWords},
+		{"catenate_numbers", CatenateNumbers},
+		{"catenate_all", CatenateAll},
+		{"preserve_original", PreserveOriginal},
+		{"split_on_case_change", SplitOnCaseChange},
+		{"split_on_numerics", SplitOnNumerics},
+	}
+	for _, option := range options {
+		on, ok := config[option.key].(bool)
+		if !ok {
+			on = DefaultFlags&option.flag != 0
+		}
+		if on {
+			flags |= option.flag
+		}
+	}
+	return NewWordDelimiterFilter(flags), nil
+}
+
+func init() {
+	registry.RegisterTokenFilter(Name, WordDelimiterFilterConstructor)
+}
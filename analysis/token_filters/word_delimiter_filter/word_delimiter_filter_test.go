@@ -0,0 +1,170 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package word_delimiter_filter
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/blevesearch/bleve/analysis"
+	"github.com/blevesearch/bleve/registry"
+)
+
+// termPositions lists the tokens of a stream as term@position
+func termPositions(ts analysis.TokenStream) []string {
+	rv := make([]string, len(ts))
+	for i, token := range ts {
+		rv[i] = string(token.Term) + "@" + strconv.Itoa(token.Position)
+	}
+	return rv
+}
+
+func tokens(terms ...string) analysis.TokenStream {
+	rv := make(analysis.TokenStream, len(terms))
+	start := 0
+	for i, term := range terms {
+		rv[i] = &analysis.Token{
+			Term:     []byte(term),
+			Start:    start,
+			End:      start + len(term),
+			Position: i + 1,
+		}
+		start += len(term) + 1
+	}
+	return rv
+}
+
+func TestWordDelimiterFilter(t *testing.T) {
+	tests := []struct {
+		flags    int
+		input    []string
+		expected []string
+	}{
+		{
+			flags:    DefaultFlags,
+			input:    []string{"PowerShot2000"},
+			expected: []string{"Power@1", "Shot@2", "2000@3"},
+		},
+		{
+			flags:    DefaultFlags,
+			input:    []string{"wi-fi"},
+			expected: []string{"wi@1", "fi@2"},
+		},
+		// the tokens after the subwords are moved along
+		{
+			flags:    DefaultFlags,
+			input:    []string{"buy", "PowerShot2000", "now"},
+			expected: []string{"buy@1", "Power@2", "Shot@3", "2000@4", "now@5"},
+		},
+		{
+			flags:    DefaultFlags | PreserveOriginal,
+			input:    []string{"PowerShot2000"},
+			expected: []string{"PowerShot2000@1", "Power@1", "Shot@2", "2000@3"},
+		},
+		{
+			flags:    DefaultFlags | CatenateWords,
+			input:    []string{"PowerShot2000"},
+			expected: []string{"PowerShot@1", "Power@1", "Shot@2", "2000@3"},
+		},
+		{
+			flags:    DefaultFlags | CatenateWords,
+			input:    []string{"wi-fi"},
+			expected: []string{"wifi@1", "wi@1", "fi@2"},
+		},
+		{
+			flags:    DefaultFlags | CatenateAll,
+			input:    []string{"wi-fi", "4000"},
+			expected: []string{"wifi@1", "wi@1", "fi@2", "4000@3"},
+		},
+		// only the catenation takes one position
+		{
+			flags:    CatenateWords | SplitOnCaseChange,
+			input:    []string{"wi-fi", "router"},
+			expected: []string{"wifi@1", "router@2"},
+		},
+		{
+			flags:    GenerateWordParts | SplitOnCaseChange,
+			input:    []string{"PowerShot2000"},
+			expected: []string{"Power@1", "Shot2000@2"},
+		},
+		{
+			flags:    GenerateWordParts | GenerateNumberParts | SplitOnNumerics,
+			input:    []string{"PowerShot2000"},
+			expected: []string{"PowerShot@1", "2000@2"},
+		},
+		{
+			flags:    GenerateWordParts | SplitOnNumerics,
+			input:    []string{"PowerShot2000"},
+			expected: []string{"PowerShot@1"},
+		},
+		{
+			flags:    DefaultFlags | CatenateNumbers,
+			input:    []string{"555-1234"},
+			expected: []string{"5551234@1", "555@1", "1234@2"},
+		},
+		{
+			flags:    DefaultFlags,
+			input:    []string{"XMLParser"},
+			expected: []string{"XML@1", "Parser@2"},
+		},
+		// tokens without delimiters are left alone
+		{
+			flags:    DefaultFlags,
+			input:    []string{"power", "2000"},
+			expected: []string{"power@1", "2000@2"},
+		},
+		// tokens of delimiters only are dropped
+		{
+			flags:    DefaultFlags,
+			input:    []string{"a", "--", "b"},
+			expected: []string{"a@1", "b@3"},
+		},
+	}
+
+	for _, test := range tests {
+		actual := termPositions(NewWordDelimiterFilter(test.flags).Filter(tokens(test.input...)))
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("flags %b on %v: expected %v, got %v", test.flags, test.input, test.expected, actual)
+		}
+	}
+}
+
+func TestWordDelimiterFilterOffsets(t *testing.T) {
+	output := NewWordDelimiterFilter(DefaultFlags).Filter(tokens("x", "wi-fi"))
+	expected := [][2]int{{0, 1}, {2, 4}, {5, 7}}
+	if len(output) != len(expected) {
+		t.Fatalf("expected %d tokens, got %d", len(expected), len(output))
+	}
+	for i, token := range output {
+		if token.Start != expected[i][0] || token.End != expected[i][1] {
+			t.Errorf("expected token %s at %v, got %d-%d", token.Term, expected[i], token.Start, token.End)
+		}
+	}
+}
+
+func TestWordDelimiterFilterConstructor(t *testing.T) {
+	cache := registry.NewCache()
+	filter, err := cache.DefineTokenFilter("product_codes", map[string]interface{}{
+		"type":              Name,
+		"catenate_words":    true,
+		"preserve_original": true,
+		"split_on_numerics": false,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual := termPositions(filter.Filter(tokens("PowerShot2000")))
+	// the catenated words are the original token
+	expected := []string{"PowerShot2000@1", "Power@1", "Shot2000@2"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
@@ -0,0 +1,86 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+// Package url_email_tokenizer implements a Tokenizer keeping
+// URLs and email addresses whole, typed as analysis.URL and
+// analysis.Email, instead of splitting them at punctuation.
+// Numbers, with their decimal and grouping separators, are
+// typed analysis.Numeric, runs of ideographs
+// analysis.Ideographic and other words analysis.AlphaNumeric.
+package url_email_tokenizer
+
+import (
+	"bytes"
+	"regexp"
+
+	"github.com/blevesearch/bleve/analysis"
+	"github.com/blevesearch/bleve/registry"
+)
+
+const Name = "url_email"
+
+// the alternatives are tried left to right, so a URL or an
+// email address is preferred over the words it contains
+var tokenRegexp = regexp.MustCompile(
+	`(?i)((?:https?|ftp)://[^\s<>"'()\[\]{}]+|www\.[^\s<>"'()\[\]{}]+)` +
+		`|([\w.%+-]+@[\w-]+(?:\.[\w-]+)+)` +
+		`|(\d+(?:[.,]\d+)*\b)` +
+		`|(\p{Han}+|\p{Hangul}+|\p{Hiragana}+|\p{Katakana}+)` +
+		`|([\p{L}\p{M}\p{N}_]+)`)
+
+var groupTypes = []analysis.TokenType{
+	analysis.URL,
+	analysis.Email,
+	analysis.Numeric,
+	analysis.Ideographic,
+	analysis.AlphaNumeric,
+}
+
+// trailing punctuation ending a sentence rather than a URL
+const urlTrailingPunctuation = ".,;:!?"
+
+type URLEmailTokenizer struct{}
+
+func NewURLEmailTokenizer() *URLEmailTokenizer {
+	return &URLEmailTokenizer{}
+}
+
+func (t *URLEmailTokenizer) Tokenize(input []byte) analysis.TokenStream {
+	matches := tokenRegexp.FindAllSubmatchIndex(input, -1)
+	rv := make(analysis.TokenStream, 0, len(matches))
+	for _, match := range matches {
+		start, end := match[0], match[1]
+		typ := analysis.AlphaNumeric
+		for g, gt := range groupTypes {
+			if match[2+2*g] >= 0 {
+				typ = gt
+				break
+			}
+		}
+		if typ == analysis.URL {
+			end = start + len(bytes.TrimRight(input[start:end], urlTrailingPunctuation))
+		}
+		rv = append(rv, &analysis.Token{
+			Term:     input[start:end],
+			Start:    start,
+			End:      end,
+			Position: len(rv) + 1,
+			Type:     typ,
+		})
+	}
+	return rv
+}
+
+func URLEmailTokenizerConstructor(config map[string]interface{}, cache *registry.Cache) (analysis.Tokenizer, error) {
+	return NewURLEmailTokenizer(), nil
+}
+
+func init() {
+	registry.RegisterTokenizer(Name, URLEmailTokenizerConstructor)
+}
@@ -0,0 +1,119 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package url_email_tokenizer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/blevesearch/bleve/analysis"
+)
+
+func TestURLEmailTokenizer(t *testing.T) {
+
+	tests := []struct {
+		input  []byte
+		output analysis.TokenStream
+	}{
+		{
+			[]byte("Mail bob@example.com or see https://example.com/a?b=1, version 2.5"),
+			analysis.TokenStream{
+				{
+					Start:    0,
+					End:      4,
+					Term:     []byte("Mail"),
+					Position: 1,
+					Type:     analysis.AlphaNumeric,
+				},
+				{
+					Start:    5,
+					End:      20,
+					Term:     []byte("bob@example.com"),
+					Position: 2,
+					Type:     analysis.Email,
+				},
+				{
+					Start:    21,
+					End:      23,
+					Term:     []byte("or"),
+					Position: 3,
+					Type:     analysis.AlphaNumeric,
+				},
+				{
+					Start:    24,
+					End:      27,
+					Term:     []byte("see"),
+					Position: 4,
+					Type:     analysis.AlphaNumeric,
+				},
+				{
+					Start:    28,
+					End:      53,
+					Term:     []byte("https://example.com/a?b=1"),
+					Position: 5,
+					Type:     analysis.URL,
+				},
+				{
+					Start:    55,
+					End:      62,
+					Term:     []byte("version"),
+					Position: 6,
+					Type:     analysis.AlphaNumeric,
+				},
+				{
+					Start:    63,
+					End:      66,
+					Term:     []byte("2.5"),
+					Position: 7,
+					Type:     analysis.Numeric,
+				},
+			},
+		},
+		{
+			[]byte("visit www.example.org. 123abc"),
+			analysis.TokenStream{
+				{
+					Start:    0,
+					End:      5,
+					Term:     []byte("visit"),
+					Position: 1,
+					Type:     analysis.AlphaNumeric,
+				},
+				{
+					Start:    6,
+					End:      21,
+					Term:     []byte("www.example.org"),
+					Position: 2,
+					Type:     analysis.URL,
+				},
+				{
+					Start:    23,
+					End:      29,
+					Term:     []byte("123abc"),
+					Position: 3,
+					Type:     analysis.AlphaNumeric,
+				},
+			},
+		},
+		{
+			[]byte(""),
+			analysis.TokenStream{},
+		},
+	}
+
+	for _, test := range tests {
+		tokenizer := NewURLEmailTokenizer()
+		actual := tokenizer.Tokenize(test.input)
+
+		if !reflect.DeepEqual(actual, test.output) {
+			t.Errorf("Expected %v, got %v for %s", test.output, actual, string(test.input))
+		}
+	}
+}
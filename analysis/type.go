@@ -18,6 +18,21 @@ type CharFilter interface {
 	Filter([]byte) []byte
 }
 
+// An OffsetMap maps each byte offset in the output of a
+// char filter, up to and including its length, to the
+// corresponding byte offset in the input.
+type OffsetMap []int
+
+// OffsetMappingCharFilter is implemented by char filters
+// which change the length of their input.  The returned
+// OffsetMap lets token offsets be reported relative to the
+// original input, other char filters are expected to
+// preserve offsets.
+type OffsetMappingCharFilter interface {
+	CharFilter
+	FilterOffsets([]byte) ([]byte, OffsetMap)
+}
+
 type TokenType int
 
 const (
@@ -28,6 +43,10 @@ const (
 	Shingle
 	Single
 	Double
+	IP
+	URL
+	Email
+	Boolean
 )
 
 type Token struct {
@@ -60,12 +79,31 @@ type Analyzer struct {
 }
 
 func (a *Analyzer) Analyze(input []byte) TokenStream {
+	var offsets OffsetMap
 	if a.CharFilters != nil {
 		for _, cf := range a.CharFilters {
-			input = cf.Filter(input)
+			ocf, ok := cf.(OffsetMappingCharFilter)
+			if !ok {
+				input = cf.Filter(input)
+				continue
+			}
+			var cfOffsets OffsetMap
+			input, cfOffsets = ocf.FilterOffsets(input)
+			if offsets != nil {
+				for i, offset := range cfOffsets {
+					cfOffsets[i] = offsets.Offset(offset)
+				}
+			}
+			offsets = cfOffsets
 		}
 	}
 	tokens := a.Tokenizer.Tokenize(input)
+	if offsets != nil {
+		for _, token := range tokens {
+			token.Start = offsets.Offset(token.Start)
+			token.End = offsets.Offset(token.End)
+		}
+	}
 	if a.TokenFilters != nil {
 		for _, tf := range a.TokenFilters {
 			tokens = tf.Filter(tokens)
@@ -74,6 +112,16 @@ func (a *Analyzer) Analyze(input []byte) TokenStream {
 	return tokens
 }
 
+// Offset returns the input offset corresponding to the
+// output offset, offsets outside the map are returned
+// unchanged.
+func (m OffsetMap) Offset(offset int) int {
+	if offset >= 0 && offset < len(m) {
+		return m[offset]
+	}
+	return offset
+}
+
 var ErrInvalidDateTime = fmt.Errorf("unable to parse datetime with any of the layouts")
 
 type DateTimeParser interface {
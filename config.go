@@ -24,6 +24,7 @@ import (
 	// fragment formatters
 	_ "github.com/blevesearch/bleve/search/highlight/fragment_formatters/ansi"
 	_ "github.com/blevesearch/bleve/search/highlight/fragment_formatters/html"
+	_ "github.com/blevesearch/bleve/search/highlight/fragment_formatters/plain"
 
 	// fragmenters
 	_ "github.com/blevesearch/bleve/search/highlight/fragmenters/simple"
@@ -34,32 +35,42 @@ import (
 	// char filters
 	_ "github.com/blevesearch/bleve/analysis/char_filters/html_char_filter"
 	_ "github.com/blevesearch/bleve/analysis/char_filters/regexp_char_filter"
+	_ "github.com/blevesearch/bleve/analysis/char_filters/unicode_normalize_char_filter"
 	_ "github.com/blevesearch/bleve/analysis/char_filters/zero_width_non_joiner"
 
 	// analyzers
 	_ "github.com/blevesearch/bleve/analysis/analyzers/custom_analyzer"
+	_ "github.com/blevesearch/bleve/analysis/analyzers/detect_lang_ngram_analyzer"
 	_ "github.com/blevesearch/bleve/analysis/analyzers/keyword_analyzer"
 	_ "github.com/blevesearch/bleve/analysis/analyzers/simple_analyzer"
 	_ "github.com/blevesearch/bleve/analysis/analyzers/standard_analyzer"
+	_ "github.com/blevesearch/bleve/analysis/analyzers/url_email_analyzer"
 
 	// token filters
 	_ "github.com/blevesearch/bleve/analysis/token_filters/apostrophe_filter"
+	_ "github.com/blevesearch/bleve/analysis/token_filters/ascii_folding_filter"
 	_ "github.com/blevesearch/bleve/analysis/token_filters/compound"
 	_ "github.com/blevesearch/bleve/analysis/token_filters/edge_ngram_filter"
 	_ "github.com/blevesearch/bleve/analysis/token_filters/elision_filter"
 	_ "github.com/blevesearch/bleve/analysis/token_filters/keyword_marker_filter"
 	_ "github.com/blevesearch/bleve/analysis/token_filters/length_filter"
+	_ "github.com/blevesearch/bleve/analysis/token_filters/limit_token_count_filter"
 	_ "github.com/blevesearch/bleve/analysis/token_filters/lower_case_filter"
 	_ "github.com/blevesearch/bleve/analysis/token_filters/ngram_filter"
+	_ "github.com/blevesearch/bleve/analysis/token_filters/phonetic_filter"
 	_ "github.com/blevesearch/bleve/analysis/token_filters/shingle"
 	_ "github.com/blevesearch/bleve/analysis/token_filters/stop_tokens_filter"
+	_ "github.com/blevesearch/bleve/analysis/token_filters/synonym_filter"
+	_ "github.com/blevesearch/bleve/analysis/token_filters/token_type_filter"
 	_ "github.com/blevesearch/bleve/analysis/token_filters/truncate_token_filter"
 	_ "github.com/blevesearch/bleve/analysis/token_filters/unicode_normalize"
+	_ "github.com/blevesearch/bleve/analysis/token_filters/word_delimiter_filter"
 
 	// tokenizers
 	_ "github.com/blevesearch/bleve/analysis/tokenizers/regexp_tokenizer"
 	_ "github.com/blevesearch/bleve/analysis/tokenizers/single_token"
 	_ "github.com/blevesearch/bleve/analysis/tokenizers/unicode"
+	_ "github.com/blevesearch/bleve/analysis/tokenizers/url_email_tokenizer"
 	_ "github.com/blevesearch/bleve/analysis/tokenizers/whitespace_tokenizer"
 
 	// date time parsers
@@ -100,9 +111,13 @@ import (
 
 	// kv stores
 	_ "github.com/blevesearch/bleve/index/store/boltdb"
+	_ "github.com/blevesearch/bleve/index/store/cache"
+	_ "github.com/blevesearch/bleve/index/store/compress"
 	_ "github.com/blevesearch/bleve/index/store/cznicb"
 	_ "github.com/blevesearch/bleve/index/store/gtreap"
 	_ "github.com/blevesearch/bleve/index/store/inmem"
+	_ "github.com/blevesearch/bleve/index/store/metrics"
+	_ "github.com/blevesearch/bleve/index/store/wal"
 
 	// byte array converters
 	_ "github.com/blevesearch/bleve/analysis/byte_array_converters/ignore"
@@ -117,7 +132,13 @@ type configuration struct {
 	DefaultHighlighter     string
 	DefaultKVStore         string
 	SlowSearchLogThreshold time.Duration
-	analysisQueue          upside_down.AnalysisQueue
+	// MaxClauseCount, when above 0, limits the number of
+	// terms prefix, fuzzy, wildcard and regexp queries may
+	// expand to.  Searches expanding more fail with
+	// searchers.ErrTooManyClauses, unless their request
+	// sets a limit of its own.
+	MaxClauseCount int
+	analysisQueue  upside_down.AnalysisQueue
 }
 
 func newConfiguration() *configuration {
@@ -157,6 +178,13 @@ func init() {
 			"formatter":  "ansi",
 		})
 
+	Config.Cache.DefineHighlighter("plain",
+		map[string]interface{}{
+			"type":       "simple",
+			"fragmenter": "simple",
+			"formatter":  "plain",
+		})
+
 	// set the default highlighter
 	Config.DefaultHighlighter = "html"
 
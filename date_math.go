@@ -0,0 +1,130 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package bleve
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const dateMathNow = "now"
+
+// isDateMath reports whether s is a date math expression
+// rather than a date to be parsed by a datetime parser.
+func isDateMath(s string) bool {
+	return strings.HasPrefix(s, dateMathNow)
+}
+
+// ParseDateMath evaluates a date math expression relative
+// to now, in the time zone loc (UTC if nil).  Expressions
+// start with "now", followed by any number of offsets, like
+// "+1d" or "-12h", and roundings down, like "/d", applied
+// left to right.  The units are y (years), M (months),
+// w (weeks), d (days), h (hours), m (minutes) and
+// s (seconds).  Rounding to a week goes back to Monday.
+// For example "now-7d/d" is the start of the day a week ago.
+func ParseDateMath(expr string, now time.Time, loc *time.Location) (time.Time, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	if !isDateMath(expr) {
+		return time.Time{}, fmt.Errorf("date math expression '%s' must start with '%s'", expr, dateMathNow)
+	}
+	rv := now.In(loc)
+
+	rest := expr[len(dateMathNow):]
+	for len(rest) > 0 {
+		op := rest[0]
+		rest = rest[1:]
+		switch op {
+		case '+', '-':
+			digits := 0
+			for digits < len(rest) && rest[digits] >= '0' && rest[digits] <= '9' {
+				digits++
+			}
+			if digits == 0 {
+				return time.Time{}, fmt.Errorf("date math expression '%s' has no amount after '%c'", expr, op)
+			}
+			n, err := strconv.Atoi(rest[:digits])
+			if err != nil {
+				return time.Time{}, fmt.Errorf("date math expression '%s' has invalid amount: %v", expr, err)
+			}
+			rest = rest[digits:]
+			if len(rest) == 0 {
+				return time.Time{}, fmt.Errorf("date math expression '%s' has no unit after '%c%d'", expr, op, n)
+			}
+			if op == '-' {
+				n = -n
+			}
+			rv, err = addDateMathUnit(rv, n, rest[0])
+			if err != nil {
+				return time.Time{}, fmt.Errorf("date math expression '%s': %v", expr, err)
+			}
+			rest = rest[1:]
+		case '/':
+			if len(rest) == 0 {
+				return time.Time{}, fmt.Errorf("date math expression '%s' has no unit after '/'", expr)
+			}
+			var err error
+			rv, err = roundDateMathUnit(rv, rest[0])
+			if err != nil {
+				return time.Time{}, fmt.Errorf("date math expression '%s': %v", expr, err)
+			}
+			rest = rest[1:]
+		default:
+			return time.Time{}, fmt.Errorf("date math expression '%s' has unexpected '%c', expected '+', '-' or '/'", expr, op)
+		}
+	}
+	return rv, nil
+}
+
+func addDateMathUnit(t time.Time, n int, unit byte) (time.Time, error) {
+	switch unit {
+	case 'y':
+		return t.AddDate(n, 0, 0), nil
+	case 'M':
+		return t.AddDate(0, n, 0), nil
+	case 'w':
+		return t.AddDate(0, 0, 7*n), nil
+	case 'd':
+		return t.AddDate(0, 0, n), nil
+	case 'h':
+		return t.Add(time.Duration(n) * time.Hour), nil
+	case 'm':
+		return t.Add(time.Duration(n) * time.Minute), nil
+	case 's':
+		return t.Add(time.Duration(n) * time.Second), nil
+	}
+	return t, fmt.Errorf("unknown unit '%c'", unit)
+}
+
+func roundDateMathUnit(t time.Time, unit byte) (time.Time, error) {
+	loc := t.Location()
+	switch unit {
+	case 'y':
+		return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, loc), nil
+	case 'M':
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc), nil
+	case 'w':
+		daysSinceMonday := (int(t.Weekday()) + 6) % 7
+		return time.Date(t.Year(), t.Month(), t.Day()-daysSinceMonday, 0, 0, 0, 0, loc), nil
+	case 'd':
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc), nil
+	case 'h':
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, loc), nil
+	case 'm':
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, loc), nil
+	case 's':
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, loc), nil
+	}
+	return t, fmt.Errorf("unknown unit '%c'", unit)
+}
@@ -0,0 +1,144 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package bleve
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDateMath(t *testing.T) {
+	// a Wednesday
+	now := time.Date(2016, 3, 9, 14, 35, 27, 500, time.UTC)
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skipf("no time zone database: %v", err)
+	}
+
+	tests := []struct {
+		expr     string
+		loc      *time.Location
+		expected time.Time
+	}{
+		{
+			expr:     "now",
+			expected: now,
+		},
+		{
+			expr:     "now-7d",
+			expected: time.Date(2016, 3, 2, 14, 35, 27, 500, time.UTC),
+		},
+		{
+			expr:     "now-7d/d",
+			expected: time.Date(2016, 3, 2, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			expr:     "now+1h/h",
+			expected: time.Date(2016, 3, 9, 15, 0, 0, 0, time.UTC),
+		},
+		{
+			expr:     "now-90m-30s",
+			expected: time.Date(2016, 3, 9, 13, 4, 57, 500, time.UTC),
+		},
+		{
+			expr:     "now/d+1d",
+			expected: time.Date(2016, 3, 10, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			expr:     "now/w",
+			expected: time.Date(2016, 3, 7, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			expr:     "now-1M/M",
+			expected: time.Date(2016, 2, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			expr:     "now+1y/y",
+			expected: time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			// 15:35 in Paris, the day starts at 23:00 UTC the day before
+			expr:     "now/d",
+			loc:      paris,
+			expected: time.Date(2016, 3, 8, 23, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, test := range tests {
+		actual, err := ParseDateMath(test.expr, now, test.loc)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.expr, err)
+			continue
+		}
+		if !actual.Equal(test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.expr, test.expected, actual)
+		}
+	}
+}
+
+func TestParseDateMathInvalid(t *testing.T) {
+	now := time.Date(2016, 3, 9, 14, 35, 27, 0, time.UTC)
+	for _, expr := range []string{
+		"",
+		"yesterday",
+		"now-d",
+		"now-7",
+		"now-7x",
+		"now/",
+		"now/q",
+		"now*2d",
+	} {
+		_, err := ParseDateMath(expr, now, nil)
+		if err == nil {
+			t.Errorf("%s: expected error", expr)
+		}
+	}
+}
+
+func TestDateRangeQueryDateMath(t *testing.T) {
+	index, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	now := time.Now().UTC()
+	docs := map[string]time.Time{
+		"recent": now.Add(-time.Hour),
+		"old":    now.AddDate(0, 0, -30),
+	}
+	for id, created := range docs {
+		err = index.Index(id, map[string]interface{}{"created": created})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	start := "now-7d"
+	end := "now"
+	q := NewDateRangeQuery(&start, &end).SetField("created")
+	res, err := index.Search(NewSearchRequest(q))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 1 || res.Hits[0].ID != "recent" {
+		t.Errorf("expected only recent to match, got %v", res.Hits)
+	}
+
+	invalid := "now-7x"
+	q = NewDateRangeQuery(&invalid, nil).SetField("created")
+	if q.Validate() == nil {
+		t.Errorf("expected invalid date math to fail validation")
+	}
+	q = NewDateRangeQuery(&start, nil).SetTimeZone("Nowhere/Special").SetField("created")
+	if q.Validate() == nil {
+		t.Errorf("expected unknown time zone to fail validation")
+	}
+}
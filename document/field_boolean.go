@@ -0,0 +1,97 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package document
+
+import (
+	"fmt"
+
+	"github.com/blevesearch/bleve/analysis"
+)
+
+const DefaultBooleanIndexingOptions = StoreField | IndexField
+
+// BooleanField holds true or false, indexed and stored as
+// the single term "T" or "F".
+type BooleanField struct {
+	name           string
+	arrayPositions []uint64
+	options        IndexingOptions
+	value          []byte
+}
+
+func (b *BooleanField) Name() string {
+	return b.name
+}
+
+func (b *BooleanField) ArrayPositions() []uint64 {
+	return b.arrayPositions
+}
+
+func (b *BooleanField) Options() IndexingOptions {
+	return b.options
+}
+
+func (b *BooleanField) Analyze() (int, analysis.TokenFrequencies) {
+	tokens := analysis.TokenStream{
+		&analysis.Token{
+			Start:    0,
+			End:      len(b.value),
+			Term:     b.value,
+			Position: 1,
+			Type:     analysis.Boolean,
+		},
+	}
+	tokenFreqs := analysis.TokenFrequencyWithArrayPositions(tokens, b.arrayPositions)
+	return len(tokens), tokenFreqs
+}
+
+func (b *BooleanField) Value() []byte {
+	return b.value
+}
+
+func (b *BooleanField) Boolean() (bool, error) {
+	switch string(b.value) {
+	case "T":
+		return true, nil
+	case "F":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid boolean value '%s'", b.value)
+}
+
+func (b *BooleanField) GoString() string {
+	return fmt.Sprintf("&document.BooleanField{Name:%s, Options: %s, Value: %s}", b.name, b.options, b.value)
+}
+
+func NewBooleanFieldFromBytes(name string, arrayPositions []uint64, value []byte) *BooleanField {
+	return &BooleanField{
+		name:           name,
+		arrayPositions: arrayPositions,
+		value:          value,
+		options:        DefaultBooleanIndexingOptions,
+	}
+}
+
+func NewBooleanField(name string, arrayPositions []uint64, b bool) *BooleanField {
+	return NewBooleanFieldWithIndexingOptions(name, arrayPositions, b, DefaultBooleanIndexingOptions)
+}
+
+func NewBooleanFieldWithIndexingOptions(name string, arrayPositions []uint64, b bool, options IndexingOptions) *BooleanField {
+	value := []byte("F")
+	if b {
+		value = []byte("T")
+	}
+	return &BooleanField{
+		name:           name,
+		arrayPositions: arrayPositions,
+		value:          value,
+		options:        options,
+	}
+}
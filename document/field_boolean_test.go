@@ -0,0 +1,39 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package document
+
+import (
+	"testing"
+)
+
+func TestBooleanField(t *testing.T) {
+	for _, b := range []bool{true, false} {
+		bf := NewBooleanField("active", []uint64{}, b)
+		numTokens, tokenFreqs := bf.Analyze()
+		if numTokens != 1 {
+			t.Errorf("expected 1 token, got %d", numTokens)
+		}
+		if len(tokenFreqs) != 1 {
+			t.Errorf("expected 1 token freq, got %d", len(tokenFreqs))
+		}
+		decoded, err := NewBooleanFieldFromBytes("active", nil, bf.Value()).Boolean()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decoded != b {
+			t.Errorf("expected %t, got %t", b, decoded)
+		}
+	}
+
+	_, err := NewBooleanFieldFromBytes("active", nil, []byte("x")).Boolean()
+	if err == nil {
+		t.Errorf("expected error decoding invalid boolean")
+	}
+}
@@ -0,0 +1,110 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package document
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/blevesearch/bleve/analysis"
+	"github.com/blevesearch/bleve/numeric_util"
+)
+
+const DefaultIPIndexingOptions = StoreField | IndexField
+
+// IPField holds an IPv4 or IPv6 address.  Its terms are
+// the address shifted by each multiple of
+// numeric_util.IPPrecisionStep bits, as numbers are, so
+// ranges of addresses can be searched for.
+type IPField struct {
+	name           string
+	arrayPositions []uint64
+	options        IndexingOptions
+	value          numeric_util.PrefixCoded
+}
+
+func (n *IPField) Name() string {
+	return n.name
+}
+
+func (n *IPField) ArrayPositions() []uint64 {
+	return n.arrayPositions
+}
+
+func (n *IPField) Options() IndexingOptions {
+	return n.options
+}
+
+func (n *IPField) Analyze() (int, analysis.TokenFrequencies) {
+	tokens := make(analysis.TokenStream, 0)
+	tokens = append(tokens, &analysis.Token{
+		Start:    0,
+		End:      len(n.value),
+		Term:     n.value,
+		Position: 1,
+		Type:     analysis.IP,
+	})
+
+	original, err := n.value.IP()
+	if err == nil {
+		for shift := numeric_util.IPPrecisionStep; shift < 128; shift += numeric_util.IPPrecisionStep {
+			shiftEncoded := numeric_util.MustNewPrefixCodedIP(original, shift)
+			tokens = append(tokens, &analysis.Token{
+				Start:    0,
+				End:      len(shiftEncoded),
+				Term:     shiftEncoded,
+				Position: 1,
+				Type:     analysis.IP,
+			})
+		}
+	}
+
+	fieldLength := len(tokens)
+	tokenFreqs := analysis.TokenFrequencyWithArrayPositions(tokens, n.arrayPositions)
+	return fieldLength, tokenFreqs
+}
+
+func (n *IPField) Value() []byte {
+	return n.value
+}
+
+func (n *IPField) IP() (net.IP, error) {
+	return n.value.IP()
+}
+
+func (n *IPField) GoString() string {
+	return fmt.Sprintf("&document.IPField{Name:%s, Options: %s, Value: %s}", n.name, n.options, n.value)
+}
+
+func NewIPFieldFromBytes(name string, arrayPositions []uint64, value []byte) *IPField {
+	return &IPField{
+		name:           name,
+		arrayPositions: arrayPositions,
+		value:          value,
+		options:        DefaultIPIndexingOptions,
+	}
+}
+
+func NewIPField(name string, arrayPositions []uint64, ip net.IP) (*IPField, error) {
+	return NewIPFieldWithIndexingOptions(name, arrayPositions, ip, DefaultIPIndexingOptions)
+}
+
+func NewIPFieldWithIndexingOptions(name string, arrayPositions []uint64, ip net.IP, options IndexingOptions) (*IPField, error) {
+	prefixCoded, err := numeric_util.NewPrefixCodedIP(ip, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &IPField{
+		name:           name,
+		arrayPositions: arrayPositions,
+		value:          prefixCoded,
+		options:        options,
+	}, nil
+}
@@ -0,0 +1,43 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package document
+
+import (
+	"net"
+	"testing"
+)
+
+func TestIPField(t *testing.T) {
+	for _, addr := range []string{"192.168.1.10", "2001:db8::1"} {
+		f, err := NewIPField("client", []uint64{}, net.ParseIP(addr))
+		if err != nil {
+			t.Fatal(err)
+		}
+		numTokens, tokenFreqs := f.Analyze()
+		if numTokens != 16 {
+			t.Errorf("expected 16 tokens, got %d", numTokens)
+		}
+		if len(tokenFreqs) != 16 {
+			t.Errorf("expected 16 token freqs, got %d", len(tokenFreqs))
+		}
+		ip, err := NewIPFieldFromBytes("client", nil, f.Value()).IP()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ip.Equal(net.ParseIP(addr)) {
+			t.Errorf("expected %s, got %s", addr, ip)
+		}
+	}
+
+	_, err := NewIPField("client", []uint64{}, nil)
+	if err == nil {
+		t.Errorf("expected error for invalid address")
+	}
+}
@@ -71,7 +71,7 @@ func (n *NumericField) Analyze() (int, analysis.TokenFrequencies) {
 	}
 
 	fieldLength := len(tokens)
-	tokenFreqs := analysis.TokenFrequency(tokens)
+	tokenFreqs := analysis.TokenFrequencyWithArrayPositions(tokens, n.arrayPositions)
 	return fieldLength, tokenFreqs
 }
 
@@ -23,6 +23,8 @@ type TextField struct {
 	options        IndexingOptions
 	analyzer       *analysis.Analyzer
 	value          []byte
+
+	positionIncrementGap int
 }
 
 func (t *TextField) Name() string {
@@ -40,7 +42,11 @@ func (t *TextField) Options() IndexingOptions {
 func (t *TextField) Analyze() (int, analysis.TokenFrequencies) {
 	var tokens analysis.TokenStream
 	if t.analyzer != nil {
-		tokens = t.analyzer.Analyze(t.Value())
+		// token filters may change the bytes of the tokens in
+		// place, which must not change the value to be stored
+		value := make([]byte, len(t.value))
+		copy(value, t.value)
+		tokens = t.analyzer.Analyze(value)
 	} else {
 		tokens = analysis.TokenStream{
 			&analysis.Token{
@@ -53,10 +59,25 @@ func (t *TextField) Analyze() (int, analysis.TokenFrequencies) {
 		}
 	}
 	fieldLength := len(tokens) // number of tokens in this doc field
-	tokenFreqs := analysis.TokenFrequency(tokens)
+	tokenFreqs := analysis.TokenFrequencyWithArrayPositions(tokens, t.arrayPositions)
 	return fieldLength, tokenFreqs
 }
 
+// PositionIncrementGap returns the gap left between the last
+// token position of the previous value of the field and the
+// first one of this value, when both are indexed from the
+// same document.
+func (t *TextField) PositionIncrementGap() int {
+	return t.positionIncrementGap
+}
+
+// SetPositionIncrementGap sets the gap left before the token
+// positions of this value.  With 0, the default, they start
+// over from 1.
+func (t *TextField) SetPositionIncrementGap(gap int) {
+	t.positionIncrementGap = gap
+}
+
 func (t *TextField) Value() []byte {
 	return t.value
 }
@@ -15,6 +15,7 @@ const (
 	IndexField IndexingOptions = 1 << iota
 	StoreField
 	IncludeTermVectors
+	CompressStoredField
 )
 
 func (o IndexingOptions) IsIndexed() bool {
@@ -29,6 +30,13 @@ func (o IndexingOptions) IncludeTermVectors() bool {
 	return o&IncludeTermVectors != 0
 }
 
+// IsStoredCompressed reports whether the stored value of the
+// field is compressed in the index.  It has no effect on
+// fields which are not stored.
+func (o IndexingOptions) IsStoredCompressed() bool {
+	return o&CompressStoredField != 0
+}
+
 func (o IndexingOptions) String() string {
 	rv := ""
 	if o.IsIndexed() {
@@ -46,5 +54,11 @@ func (o IndexingOptions) String() string {
 		}
 		rv += "TV"
 	}
+	if o.IsStoredCompressed() {
+		if rv != "" {
+			rv += ", "
+		}
+		rv += "COMPRESSED"
+	}
 	return rv
 }
@@ -19,6 +19,7 @@ func TestIndexingOptions(t *testing.T) {
 		isIndexed          bool
 		isStored           bool
 		includeTermVectors bool
+		isStoredCompressed bool
 	}{
 		{
 			options:            IndexField | StoreField | IncludeTermVectors,
@@ -50,6 +51,13 @@ func TestIndexingOptions(t *testing.T) {
 			isStored:           true,
 			includeTermVectors: false,
 		},
+		{
+			options:            IndexField | StoreField | CompressStoredField,
+			isIndexed:          true,
+			isStored:           true,
+			includeTermVectors: false,
+			isStoredCompressed: true,
+		},
 	}
 
 	for _, test := range tests {
@@ -65,5 +73,9 @@ func TestIndexingOptions(t *testing.T) {
 		if actuallyIncludeTermVectors != test.includeTermVectors {
 			t.Errorf("expected includeTermVectors to be %v, got %v for %d", test.includeTermVectors, actuallyIncludeTermVectors, test.options)
 		}
+		actuallyStoredCompressed := test.options.IsStoredCompressed()
+		if actuallyStoredCompressed != test.isStoredCompressed {
+			t.Errorf("expected isStoredCompressed to be %v, got %v for %d", test.isStoredCompressed, actuallyStoredCompressed, test.options)
+		}
 	}
 }
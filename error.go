@@ -24,6 +24,36 @@ const (
 	ErrorIndexClosed
 	ErrorAliasMulti
 	ErrorAliasEmpty
+	ErrorConstantScoreQueryNoFilter
+	ErrorGeoPolygonTooFewPoints
+	ErrorSearchAfterNeedsSort
+	ErrorSearchAfterSortMismatch
+	ErrorSearchAfterNoTiebreaker
+	ErrorUnknownScoringModel
+	ErrorDocumentNotFound
+	ErrorSearchIteratorUnsupported
+	ErrorBackupCorrupt
+	ErrorBackupVersion
+	ErrorFieldExistsQueryNoField
+	ErrorNestedQueryNoPath
+	ErrorNestedQueryPathNotNested
+	ErrorUnknownScoreNormalization
+	ErrorPhraseQueryNegativeSlop
+	ErrorMoreLikeThisQueryNoLike
+	ErrorUnknownAggregationType
+	ErrorCIDRQueryInvalid
+	ErrorDisjunctionMaxTieBreakerRange
+	ErrorUnknownFacetOrder
+	ErrorUnknownTokenType
+	ErrorMultiMatchQueryNoFields
+	ErrorUnknownMultiMatchType
+	ErrorPostFilterNoQuery
+	ErrorUnknownDateHistogramInterval
+	ErrorUnknownTimeZone
+	ErrorFunctionScoreQueryNoFunction
+	ErrorUnknownFunctionScoreMode
+	ErrorIndexReadOnly
+	ErrorSearchTemplateParamType
 )
 
 // Error represents a more strongly typed bleve error for detecting
@@ -40,7 +70,7 @@ var errorMessages = map[int]string{
 	int(ErrorIndexMetaMissing):               "cannot open index, metadata missing",
 	int(ErrorIndexMetaCorrupt):               "cannot open index, metadata corrupt",
 	int(ErrorDisjunctionFewerThanMinClauses): "disjunction query has fewer than the minimum number of clauses to satisfy",
-	int(ErrorBooleanQueryNeedsMustOrShould):  "boolean query must contain at least one must or should clause",
+	int(ErrorBooleanQueryNeedsMustOrShould):  "boolean query must contain at least one must, should or filter clause",
 	int(ErrorNumericQueryNoBounds):           "numeric range query must specify min or max",
 	int(ErrorPhraseQueryNoTerms):             "phrase query must contain at least one term",
 	int(ErrorUnknownQueryType):               "unknown query type",
@@ -48,4 +78,34 @@ var errorMessages = map[int]string{
 	int(ErrorIndexClosed):                    "index is closed",
 	int(ErrorAliasMulti):                     "cannot perform single index operation on multiple index alias",
 	int(ErrorAliasEmpty):                     "cannot perform operation on empty alias",
+	int(ErrorConstantScoreQueryNoFilter):     "constant score query must contain a filter query",
+	int(ErrorGeoPolygonTooFewPoints):         "geo polygon query must have at least three distinct points",
+	int(ErrorSearchAfterNeedsSort):           "search after requires a sort and cannot be combined with collapse",
+	int(ErrorSearchAfterSortMismatch):        "search after must have one value for each sort",
+	int(ErrorSearchAfterNoTiebreaker):        "search after requires the sort to include the document id",
+	int(ErrorUnknownScoringModel):            "unknown scoring model",
+	int(ErrorDocumentNotFound):               "document not found",
+	int(ErrorSearchIteratorUnsupported):      "search iterator does not support sort, search after, collapse, facets, aggregations or post filters",
+	int(ErrorBackupCorrupt):                  "backup stream is truncated or corrupt",
+	int(ErrorBackupVersion):                  "unsupported backup format version",
+	int(ErrorFieldExistsQueryNoField):        "field exists and field missing queries must specify a field",
+	int(ErrorNestedQueryNoPath):              "nested query must specify a path",
+	int(ErrorNestedQueryPathNotNested):       "nested query path is not mapped as nested",
+	int(ErrorUnknownScoreNormalization):      "unknown score normalization",
+	int(ErrorPhraseQueryNegativeSlop):        "phrase query slop must not be negative",
+	int(ErrorMoreLikeThisQueryNoLike):        "more like this query must specify one of like id or like text",
+	int(ErrorUnknownAggregationType):         "unknown aggregation type",
+	int(ErrorCIDRQueryInvalid):               "cidr query must specify an IP address or CIDR subnet",
+	int(ErrorDisjunctionMaxTieBreakerRange):  "disjunction max query tie breaker must be between 0 and 1",
+	int(ErrorUnknownFacetOrder):              "unknown facet order",
+	int(ErrorUnknownTokenType):               "unknown token type",
+	int(ErrorMultiMatchQueryNoFields):        "multi match query must specify at least one field",
+	int(ErrorUnknownMultiMatchType):          "unknown multi match type",
+	int(ErrorPostFilterNoQuery):              "post filter must specify a query",
+	int(ErrorUnknownDateHistogramInterval):   "unknown date histogram interval",
+	int(ErrorUnknownTimeZone):                "unknown time zone",
+	int(ErrorFunctionScoreQueryNoFunction):   "function score query must specify a query and a function",
+	int(ErrorUnknownFunctionScoreMode):       "unknown function score mode",
+	int(ErrorIndexReadOnly):                  "index is read-only",
+	int(ErrorSearchTemplateParamType):        "search template parameters must be strings, numbers or booleans",
 }
@@ -0,0 +1,54 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package geo
+
+import (
+	"fmt"
+	"math"
+)
+
+// EarthRadius is the mean radius of the earth in meters.
+const EarthRadius = 6371008.8
+
+// distanceUnits maps unit names to their length in meters
+var distanceUnits = map[string]float64{
+	"m":          1,
+	"meters":     1,
+	"km":         1000,
+	"kilometers": 1000,
+	"mi":         1609.344,
+	"miles":      1609.344,
+}
+
+// DistanceUnit returns the length in meters of the named
+// unit, one of m, km or mi.  The empty name means meters.
+func DistanceUnit(name string) (float64, error) {
+	if name == "" {
+		return 1, nil
+	}
+	rv, ok := distanceUnits[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown distance unit '%s'", name)
+	}
+	return rv, nil
+}
+
+// Haversine returns the great circle distance
+// between a and b in meters.
+func Haversine(a, b Point) float64 {
+	lat1 := a.Lat * math.Pi / 180
+	lat2 := b.Lat * math.Pi / 180
+	dLat := lat2 - lat1
+	dLon := (b.Lon - a.Lon) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * EarthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
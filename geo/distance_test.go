@@ -0,0 +1,64 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHaversine(t *testing.T) {
+	tests := []struct {
+		a, b Point
+		dist float64 // meters
+	}{
+		{Point{Lon: 0, Lat: 0}, Point{Lon: 0, Lat: 0}, 0},
+		// one degree of latitude
+		{Point{Lon: 0, Lat: 0}, Point{Lon: 0, Lat: 1}, 111195},
+		// san francisco to new york
+		{Point{Lon: -122.4194, Lat: 37.7749}, Point{Lon: -74.0060, Lat: 40.7128}, 4129000},
+		// across the antimeridian
+		{Point{Lon: 179.5, Lat: 0}, Point{Lon: -179.5, Lat: 0}, 111195},
+		// antipodes
+		{Point{Lon: 0, Lat: 0}, Point{Lon: 180, Lat: 0}, math.Pi * EarthRadius},
+	}
+
+	for _, test := range tests {
+		actual := Haversine(test.a, test.b)
+		// within 0.1%, or a meter
+		if math.Abs(actual-test.dist) > math.Max(1, test.dist*0.001) {
+			t.Errorf("expected %f, got %f for %v to %v", test.dist, actual, test.a, test.b)
+		}
+	}
+}
+
+func TestDistanceUnit(t *testing.T) {
+	tests := []struct {
+		name   string
+		meters float64
+		err    bool
+	}{
+		{name: "", meters: 1},
+		{name: "m", meters: 1},
+		{name: "km", meters: 1000},
+		{name: "mi", meters: 1609.344},
+		{name: "furlongs", err: true},
+	}
+
+	for _, test := range tests {
+		actual, err := DistanceUnit(test.name)
+		if (err != nil) != test.err {
+			t.Errorf("expected error %t, got %v for %s", test.err, err, test.name)
+		}
+		if actual != test.meters {
+			t.Errorf("expected %f, got %f for %s", test.meters, actual, test.name)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+// Package geo provides the geometry used by bleve's
+// geo queries.  Coordinates are degrees, with
+// longitudes in [-180, 180] and latitudes in [-90, 90].
+package geo
+
+import (
+	"math"
+)
+
+// epsilon is the tolerance used when deciding if
+// a point lies on a polygon edge
+const epsilon = 1e-9
+
+// Point is a location on the globe.
+type Point struct {
+	Lon float64 `json:"lon"`
+	Lat float64 `json:"lat"`
+}
+
+// normalizePolygon drops the closing vertex of a closed
+// polygon, and reports whether any edge crosses the
+// antimeridian.  Polygons are assumed to take the shorter
+// way around, so an edge spanning more than 180 degrees
+// of longitude is taken to cross.
+func normalizePolygon(polygon []Point) ([]Point, bool) {
+	if len(polygon) > 1 && polygon[0] == polygon[len(polygon)-1] {
+		polygon = polygon[:len(polygon)-1]
+	}
+	for i := range polygon {
+		j := (i + 1) % len(polygon)
+		if math.Abs(polygon[i].Lon-polygon[j].Lon) > 180 {
+			return polygon, true
+		}
+	}
+	return polygon, false
+}
+
+// shiftLon moves negative longitudes up by 360 degrees,
+// making an antimeridian crossing polygon contiguous
+func shiftLon(lon float64) float64 {
+	if lon < 0 {
+		return lon + 360
+	}
+	return lon
+}
+
+// ValidPolygon reports whether the polygon has at
+// least three distinct vertices.  The closing vertex
+// may be repeated or omitted.
+func ValidPolygon(polygon []Point) bool {
+	polygon, _ = normalizePolygon(polygon)
+	return len(polygon) >= 3
+}
+
+// PolygonBoundingBox returns the corners of the smallest
+// box containing the polygon.  When the polygon crosses
+// the antimeridian, the longitude of topLeft is greater
+// than that of bottomRight.
+func PolygonBoundingBox(polygon []Point) (topLeft, bottomRight Point) {
+	polygon, crosses := normalizePolygon(polygon)
+	if len(polygon) == 0 {
+		return
+	}
+	minLon, maxLon := math.Inf(1), math.Inf(-1)
+	minLat, maxLat := math.Inf(1), math.Inf(-1)
+	for _, p := range polygon {
+		lon := p.Lon
+		if crosses {
+			lon = shiftLon(lon)
+		}
+		minLon = math.Min(minLon, lon)
+		maxLon = math.Max(maxLon, lon)
+		minLat = math.Min(minLat, p.Lat)
+		maxLat = math.Max(maxLat, p.Lat)
+	}
+	if maxLon > 180 {
+		maxLon -= 360
+	}
+	if minLon > 180 {
+		minLon -= 360
+	}
+	return Point{Lon: minLon, Lat: maxLat}, Point{Lon: maxLon, Lat: minLat}
+}
+
+// PolygonContains reports whether the point lies
+// inside the polygon, or on one of its edges, using
+// ray casting.  The closing vertex may be repeated
+// or omitted.
+func PolygonContains(polygon []Point, p Point) bool {
+	polygon, crosses := normalizePolygon(polygon)
+	if len(polygon) < 3 {
+		return false
+	}
+	px, py := p.Lon, p.Lat
+	if crosses {
+		px = shiftLon(px)
+	}
+
+	inside := false
+	for i := range polygon {
+		j := (i + 1) % len(polygon)
+		ax, ay := polygon[i].Lon, polygon[i].Lat
+		bx, by := polygon[j].Lon, polygon[j].Lat
+		if crosses {
+			ax, bx = shiftLon(ax), shiftLon(bx)
+		}
+		if onSegment(ax, ay, bx, by, px, py) {
+			return true
+		}
+		if (ay > py) != (by > py) &&
+			px < (bx-ax)*(py-ay)/(by-ay)+ax {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+func onSegment(ax, ay, bx, by, px, py float64) bool {
+	cross := (bx-ax)*(py-ay) - (by-ay)*(px-ax)
+	if math.Abs(cross) > epsilon {
+		return false
+	}
+	return px >= math.Min(ax, bx)-epsilon && px <= math.Max(ax, bx)+epsilon &&
+		py >= math.Min(ay, by)-epsilon && py <= math.Max(ay, by)+epsilon
+}
@@ -0,0 +1,115 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package geo
+
+import (
+	"testing"
+)
+
+var square = []Point{
+	{Lon: 0, Lat: 0},
+	{Lon: 10, Lat: 0},
+	{Lon: 10, Lat: 10},
+	{Lon: 0, Lat: 10},
+}
+
+var closedSquare = append(append([]Point{}, square...), square[0])
+
+// concave "C" shape open to the east
+var concave = []Point{
+	{Lon: 0, Lat: 0},
+	{Lon: 10, Lat: 0},
+	{Lon: 10, Lat: 3},
+	{Lon: 3, Lat: 3},
+	{Lon: 3, Lat: 7},
+	{Lon: 10, Lat: 7},
+	{Lon: 10, Lat: 10},
+	{Lon: 0, Lat: 10},
+}
+
+// straddles the antimeridian around Fiji
+var antimeridian = []Point{
+	{Lon: 175, Lat: -15},
+	{Lon: -175, Lat: -15},
+	{Lon: -175, Lat: -20},
+	{Lon: 175, Lat: -20},
+}
+
+func TestPolygonContains(t *testing.T) {
+	tests := []struct {
+		polygon []Point
+		point   Point
+		result  bool
+	}{
+		// inside
+		{square, Point{Lon: 5, Lat: 5}, true},
+		{closedSquare, Point{Lon: 5, Lat: 5}, true},
+		{concave, Point{Lon: 1, Lat: 5}, true},
+		{antimeridian, Point{Lon: 179, Lat: -17}, true},
+		{antimeridian, Point{Lon: -179, Lat: -17}, true},
+		{antimeridian, Point{Lon: 180, Lat: -17}, true},
+		// outside
+		{square, Point{Lon: 15, Lat: 5}, false},
+		{square, Point{Lon: -1, Lat: 5}, false},
+		{closedSquare, Point{Lon: 5, Lat: 11}, false},
+		{concave, Point{Lon: 5, Lat: 5}, false},
+		{antimeridian, Point{Lon: 0, Lat: -17}, false},
+		{antimeridian, Point{Lon: 170, Lat: -17}, false},
+		{antimeridian, Point{Lon: -170, Lat: -17}, false},
+		// on edges and vertices
+		{square, Point{Lon: 5, Lat: 0}, true},
+		{square, Point{Lon: 10, Lat: 5}, true},
+		{square, Point{Lon: 0, Lat: 10}, true},
+		{closedSquare, Point{Lon: 0, Lat: 5}, true},
+		{concave, Point{Lon: 3, Lat: 5}, true},
+		{antimeridian, Point{Lon: -175, Lat: -17}, true},
+		// degenerate
+		{square[:2], Point{Lon: 5, Lat: 0}, false},
+		{[]Point{}, Point{Lon: 5, Lat: 0}, false},
+	}
+
+	for i, test := range tests {
+		actual := PolygonContains(test.polygon, test.point)
+		if actual != test.result {
+			t.Errorf("expected %t for %v in polygon %d, got %t", test.result, test.point, i, actual)
+		}
+	}
+}
+
+func TestPolygonBoundingBox(t *testing.T) {
+	tests := []struct {
+		polygon     []Point
+		topLeft     Point
+		bottomRight Point
+	}{
+		{square, Point{Lon: 0, Lat: 10}, Point{Lon: 10, Lat: 0}},
+		{closedSquare, Point{Lon: 0, Lat: 10}, Point{Lon: 10, Lat: 0}},
+		{antimeridian, Point{Lon: 175, Lat: -15}, Point{Lon: -175, Lat: -20}},
+	}
+
+	for i, test := range tests {
+		topLeft, bottomRight := PolygonBoundingBox(test.polygon)
+		if topLeft != test.topLeft || bottomRight != test.bottomRight {
+			t.Errorf("expected box %v %v for polygon %d, got %v %v", test.topLeft, test.bottomRight, i, topLeft, bottomRight)
+		}
+	}
+}
+
+func TestValidPolygon(t *testing.T) {
+	if !ValidPolygon(square) {
+		t.Errorf("expected square to be valid")
+	}
+	if !ValidPolygon(closedSquare) {
+		t.Errorf("expected closed square to be valid")
+	}
+	if ValidPolygon([]Point{square[0], square[1], square[0]}) {
+		t.Errorf("expected closed polygon of two vertices to be invalid")
+	}
+}
@@ -86,6 +86,16 @@ func (h *DocGetHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 			if err == nil {
 				newval = d.Format(time.RFC3339Nano)
 			}
+		case *document.IPField:
+			ip, err := field.IP()
+			if err == nil {
+				newval = ip.String()
+			}
+		case *document.BooleanField:
+			b, err := field.Boolean()
+			if err == nil {
+				newval = b
+			}
 		}
 		existing, existed := rv.Fields[field.Name()]
 		if existed {
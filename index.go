@@ -10,9 +10,14 @@
 package bleve
 
 import (
+	"context"
+	"io"
+	"time"
+
 	"github.com/blevesearch/bleve/document"
 	"github.com/blevesearch/bleve/index"
 	"github.com/blevesearch/bleve/index/store"
+	"github.com/blevesearch/bleve/search"
 )
 
 // A Batch groups together multiple Index and Delete
@@ -68,14 +73,25 @@ func (b Batch) Size() int {
 // using the New() and Open() methods.
 type Index interface {
 	Index(id string, data interface{}) error
+	IndexWithTTL(id string, data interface{}, ttl time.Duration) error
 	Delete(id string) error
+	DeleteByQuery(q Query) (uint64, error)
+	Update(id string, fields map[string]interface{}) error
+	UpdateByQuery(q Query, fields map[string]interface{}) (uint64, error)
 
 	Batch(b *Batch) error
 
 	Document(id string) (*document.Document, error)
+	Documents(ids []string) (map[string]*document.Document, error)
+	DocumentField(id, field string) (interface{}, error)
 	DocCount() (uint64, error)
 
 	Search(req *SearchRequest) (*SearchResult, error)
+	SearchInContext(ctx context.Context, req *SearchRequest) (*SearchResult, error)
+	SearchIter(req *SearchRequest) (ResultIterator, error)
+	AnalyzeQuery(q Query) (QueryAnalysis, error)
+	Explain(q Query, id string) (*search.Explanation, error)
+	SuggestTerms(field, term string, maxEdits, maxSuggestions int) ([]Suggestion, error)
 
 	Fields() ([]string, error)
 
@@ -88,12 +104,30 @@ type Index interface {
 	Mapping() *IndexMapping
 
 	Stats() *IndexStat
+	StatsMap() map[string]interface{}
 
 	GetInternal(key []byte) ([]byte, error)
 	SetInternal(key, val []byte) error
 	DeleteInternal(key []byte) error
 
 	Advanced() (index.Index, store.KVStore, error)
+
+	Backup(w io.Writer) error
+
+	Warmup(ctx context.Context, maxBytes uint64) error
+
+	RegisterListener(l IndexListener)
+}
+
+// A ResultIterator yields the hits of a search one at
+// a time, as they are found, instead of collecting them
+// into a SearchResult.  Next returns nil once all the
+// hits have been returned.  The iterator reads from a
+// snapshot of the index taken when it was created, and
+// must be closed to release it.
+type ResultIterator interface {
+	Next() (*search.DocumentMatch, error)
+	Close() error
 }
 
 // A Classifier is an interface describing any object
@@ -126,6 +160,18 @@ func Open(path string) (Index, error) {
 	return openIndexUsing(path, nil)
 }
 
+// OpenReadOnly opens the index at the specified path,
+// which must exist, for reading only.  The store is opened
+// with the "read_only" runtime config, which lets stores
+// such as boltdb be opened by several processes at once,
+// and the writes to the index fail with ErrorIndexReadOnly.
+// OpenUsing with "read_only" set to true does the same.
+func OpenReadOnly(path string) (Index, error) {
+	return openIndexUsing(path, map[string]interface{}{
+		"read_only": true,
+	})
+}
+
 // OpenUsing opens index at the specified path, must exist.
 // The mapping used when it was created will be used for all Index/Search operations.
 // The provided runtimeConfig can override settings
@@ -10,11 +10,24 @@
 package index
 
 import (
+	"context"
 	"encoding/json"
 
 	"github.com/blevesearch/bleve/document"
 )
 
+// FieldNamesField is the reserved field under which an
+// index records, as terms, the names of the fields which
+// produced at least one term for each document.
+const FieldNamesField = "_field_names"
+
+// ArrayPathsField is the reserved field under which an
+// index mapping stores the paths of the array values of
+// each document, once for each level of arrays, so the
+// array positions of the other fields can be matched to
+// their paths.  Indexes leave it out of Document.
+const ArrayPathsField = "_array_paths"
+
 type Index interface {
 	Open() error
 	Close() error
@@ -34,7 +47,24 @@ type Index interface {
 
 	Reader() (IndexReader, error)
 
+	// Warmup reads the term dictionary into memory, up to
+	// maxBytes (no limit if 0), to speed up the searches
+	// which follow.  It stops when ctx is done.
+	Warmup(ctx context.Context, maxBytes uint64) error
+
 	Stats() json.Marshaler
+
+	// StatsMap returns the sizes of the index: its
+	// document count, the number of distinct terms of
+	// each field, and the entries of its store.
+	StatsMap() (map[string]interface{}, error)
+}
+
+// IndexMultiReader is an optional extension of Index for
+// indexes able to open several readers at once, one for
+// each goroutine of a search, see store.KVMultiReader.
+type IndexMultiReader interface {
+	Readers(n int) ([]IndexReader, error)
 }
 
 type IndexReader interface {
@@ -44,6 +74,7 @@ type IndexReader interface {
 	FieldReader(field string, startTerm []byte, endTerm []byte) (FieldReader, error)
 
 	Document(id string) (*document.Document, error)
+	DocumentField(id, field string) ([]document.Field, error)
 	DocumentFieldTerms(id string) (FieldTerms, error)
 
 	Fields() ([]string, error)
@@ -52,16 +83,35 @@ type IndexReader interface {
 
 	DocCount() uint64
 
+	FieldStats(field string) FieldStats
+
 	Close()
 }
 
 type FieldTerms map[string][]string
 
+// FieldStats describes the lengths, in tokens, of a
+// field over all the documents indexing it.
+type FieldStats struct {
+	DocCount    uint64
+	TotalLength uint64
+}
+
+// AverageLength returns the average length of the
+// field, or 0 if no documents index it.
+func (fs FieldStats) AverageLength() float64 {
+	if fs.DocCount == 0 {
+		return 0
+	}
+	return float64(fs.TotalLength) / float64(fs.DocCount)
+}
+
 type TermFieldVector struct {
-	Field string
-	Pos   uint64
-	Start uint64
-	End   uint64
+	Field          string
+	ArrayPositions []uint64
+	Pos            uint64
+	Start          uint64
+	End            uint64
 }
 
 type TermFieldDoc struct {
@@ -51,6 +51,36 @@ func Open(path string, bucket string) (*Store, error) {
 	return &rv, nil
 }
 
+// OpenReadOnly opens the existing database at path without
+// locking it for writing, so several processes can read it
+// at once.  Bolt still keeps it from being opened while a
+// process has it open for writing.  Writes to the store fail.
+func OpenReadOnly(path string, bucket string) (*Store, error) {
+	rv := Store{
+		path:   path,
+		bucket: bucket,
+	}
+
+	var err error
+	rv.db, err = bolt.Open(path, 0600, &bolt.Options{ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+
+	err = rv.db.View(func(tx *bolt.Tx) error {
+		if tx.Bucket([]byte(rv.bucket)) == nil {
+			return fmt.Errorf("no bucket named '%s'", rv.bucket)
+		}
+		return nil
+	})
+	if err != nil {
+		rv.db.Close()
+		return nil, err
+	}
+
+	return &rv, nil
+}
+
 func (bs *Store) get(key []byte) ([]byte, error) {
 	var rv []byte
 
@@ -101,6 +131,27 @@ func (bs *Store) Reader() (store.KVReader, error) {
 	return newReader(bs)
 }
 
+// Readers opens n readers while no write is in progress,
+// so they read the same snapshot.  A write growing the
+// file waits for the open readers to be closed, and the
+// readers opened after it starts wait for it.
+func (bs *Store) Readers(n int) ([]store.KVReader, error) {
+	bs.writer.Lock()
+	defer bs.writer.Unlock()
+	rv := make([]store.KVReader, 0, n)
+	for len(rv) < n {
+		reader, err := newReader(bs)
+		if err != nil {
+			for _, r := range rv {
+				r.Close()
+			}
+			return nil, err
+		}
+		rv = append(rv, reader)
+	}
+	return rv, nil
+}
+
 func (bs *Store) Writer() (store.KVWriter, error) {
 	return newWriter(bs)
 }
@@ -120,6 +171,11 @@ func StoreConstructor(config map[string]interface{}) (store.KVStore, error) {
 		bucket = "bleve"
 	}
 
+	readOnly, _ := config["read_only"].(bool)
+	if readOnly {
+		return OpenReadOnly(path, bucket)
+	}
+
 	return Open(path, bucket)
 }
 
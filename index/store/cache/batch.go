@@ -0,0 +1,53 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package cache
+
+import (
+	"github.com/blevesearch/bleve/index/store"
+)
+
+// Batch remembers the keys it touches, including those of merges,
+// which are computed from the existing values when the batch is
+// executed, so they can be dropped from the cache.
+type Batch struct {
+	store *Store
+	b     store.KVBatch
+	keys  [][]byte
+}
+
+func newBatch(s *Store, b store.KVBatch) *Batch {
+	return &Batch{
+		store: s,
+		b:     b,
+	}
+}
+
+func (b *Batch) Set(key, val []byte) {
+	b.keys = append(b.keys, key)
+	b.b.Set(key, val)
+}
+
+func (b *Batch) Delete(key []byte) {
+	b.keys = append(b.keys, key)
+	b.b.Delete(key)
+}
+
+func (b *Batch) Merge(key []byte, oper store.AssociativeMerge) {
+	b.keys = append(b.keys, key)
+	b.b.Merge(key, oper)
+}
+
+func (b *Batch) Execute() error {
+	return b.store.write(b.keys, b.b.Execute)
+}
+
+func (b *Batch) Close() error {
+	return b.b.Close()
+}
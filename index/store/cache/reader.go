@@ -0,0 +1,49 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package cache
+
+import (
+	"github.com/blevesearch/bleve/index/store"
+)
+
+type Reader struct {
+	store      *Store
+	r          store.KVReader
+	generation uint64
+}
+
+func newReader(s *Store, r store.KVReader, generation uint64) *Reader {
+	return &Reader{
+		store:      s,
+		r:          r,
+		generation: generation,
+	}
+}
+
+func (r *Reader) Get(key []byte) ([]byte, error) {
+	val, ok := r.store.get(r.generation, key)
+	if ok {
+		return val, nil
+	}
+	val, err := r.r.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	r.store.add(r.generation, key, val)
+	return val, nil
+}
+
+func (r *Reader) Iterator(key []byte) store.KVIterator {
+	return r.r.Iterator(key)
+}
+
+func (r *Reader) Close() error {
+	return r.r.Close()
+}
@@ -0,0 +1,219 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+// Package cache provides a KVStore which wraps another KVStore,
+// keeping the values most recently read with Get in an LRU cache.
+// Keys written through the store are dropped from the cache, so it
+// must be the only way the wrapped store is written to.
+//
+// Readers see the snapshot of the wrapped store they were opened
+// on.  The cache only serves readers opened since the last write,
+// older readers go to the wrapped store, so a write never shows up
+// in a reader opened before it, nor a stale value in one opened
+// after it.
+package cache
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+
+	"github.com/blevesearch/bleve/index/store"
+	"github.com/blevesearch/bleve/registry"
+)
+
+const Name = "cache"
+
+const DefaultMaxEntries = 10000
+
+type Store struct {
+	inner      store.KVStore
+	maxEntries int
+
+	// writes holds off opening readers while a write is
+	// applied to the wrapped store and the cache
+	writes sync.RWMutex
+
+	mutex sync.Mutex
+	// generation counts the writes, a reader may use the
+	// cache while no write happened since it was opened
+	generation uint64
+	entries    map[string]*list.Element
+	lru        *list.List
+	hits       uint64
+	misses     uint64
+}
+
+type entry struct {
+	key string
+	val []byte
+}
+
+// NewCachingStore wraps inner, caching up to maxEntries values,
+// DefaultMaxEntries if maxEntries is not positive.
+func NewCachingStore(inner store.KVStore, maxEntries int) *Store {
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+	return &Store{
+		inner:      inner,
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+	}
+}
+
+func (s *Store) Close() error {
+	return s.inner.Close()
+}
+
+func (s *Store) Reader() (store.KVReader, error) {
+	s.writes.RLock()
+	defer s.writes.RUnlock()
+	r, err := s.inner.Reader()
+	if err != nil {
+		return nil, err
+	}
+	s.mutex.Lock()
+	generation := s.generation
+	s.mutex.Unlock()
+	return newReader(s, r, generation), nil
+}
+
+func (s *Store) Writer() (store.KVWriter, error) {
+	w, err := s.inner.Writer()
+	if err != nil {
+		return nil, err
+	}
+	return newWriter(s, w), nil
+}
+
+// Hits returns the number of Get calls answered from the cache.
+func (s *Store) Hits() uint64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.hits
+}
+
+// Misses returns the number of Get calls which had to read the
+// wrapped store.
+func (s *Store) Misses() uint64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.misses
+}
+
+// Len returns the number of cached values.
+func (s *Store) Len() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.lru.Len()
+}
+
+// get returns a copy of the cached value of key, if the reader of
+// the generation may use it
+func (s *Store) get(generation uint64, key []byte) ([]byte, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if generation != s.generation {
+		return nil, false
+	}
+	e, ok := s.entries[string(key)]
+	if !ok {
+		s.misses++
+		return nil, false
+	}
+	s.hits++
+	s.lru.MoveToFront(e)
+	return copyBytes(e.Value.(*entry).val), true
+}
+
+// add caches the value of key read by the reader of the
+// generation, evicting the least recently used value when full
+func (s *Store) add(generation uint64, key, val []byte) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if generation != s.generation {
+		return
+	}
+	if e, ok := s.entries[string(key)]; ok {
+		e.Value.(*entry).val = copyBytes(val)
+		s.lru.MoveToFront(e)
+		return
+	}
+	s.entries[string(key)] = s.lru.PushFront(&entry{
+		key: string(key),
+		val: copyBytes(val),
+	})
+	if s.lru.Len() > s.maxEntries {
+		oldest := s.lru.Back()
+		s.lru.Remove(oldest)
+		delete(s.entries, oldest.Value.(*entry).key)
+	}
+}
+
+// write applies a write of keys to the wrapped store, then drops
+// keys from the cache and starts a new generation
+func (s *Store) write(keys [][]byte, apply func() error) error {
+	s.writes.Lock()
+	defer s.writes.Unlock()
+	err := apply()
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	// even a failed write may have been partly applied
+	for _, key := range keys {
+		if e, ok := s.entries[string(key)]; ok {
+			s.lru.Remove(e)
+			delete(s.entries, string(key))
+		}
+	}
+	s.generation++
+	return err
+}
+
+func copyBytes(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	rv := make([]byte, len(b))
+	copy(rv, b)
+	return rv
+}
+
+// StoreConstructor builds the store named by the "store" config
+// entry, passing it the rest of the config, and wraps it caching
+// up to the "max_entries" config entry values (default
+// DefaultMaxEntries).
+func StoreConstructor(config map[string]interface{}) (store.KVStore, error) {
+	name, ok := config["store"].(string)
+	if !ok || name == "" {
+		return nil, fmt.Errorf("must specify store")
+	}
+	innerConstructor := registry.KVStoreConstructorByName(name)
+	if innerConstructor == nil {
+		return nil, fmt.Errorf("no store named '%s' registered", name)
+	}
+	maxEntries := DefaultMaxEntries
+	if v, ok := config["max_entries"]; ok {
+		f, ok := v.(float64)
+		if !ok || f < 1 {
+			return nil, fmt.Errorf("max_entries must be a positive number")
+		}
+		maxEntries = int(f)
+	}
+	inner, err := innerConstructor(config)
+	if err != nil {
+		return nil, err
+	}
+	return NewCachingStore(inner, maxEntries), nil
+}
+
+func init() {
+	registry.RegisterKVStore(Name, StoreConstructor)
+}
@@ -0,0 +1,354 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package cache
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+
+	"github.com/blevesearch/bleve/index/store"
+	"github.com/blevesearch/bleve/index/store/cznicb"
+)
+
+func TestCacheStore(t *testing.T) {
+	s, err := StoreConstructor(map[string]interface{}{
+		"store":       cznicb.Name,
+		"max_entries": 5.0,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	CommonTestKVStore(t, s)
+}
+
+func TestCacheStoreConstructorErrors(t *testing.T) {
+	_, err := StoreConstructor(map[string]interface{}{})
+	if err == nil {
+		t.Errorf("expected error for missing store")
+	}
+	_, err = StoreConstructor(map[string]interface{}{
+		"store":       cznicb.Name,
+		"max_entries": 0.0,
+	})
+	if err == nil {
+		t.Errorf("expected error for non positive max_entries")
+	}
+}
+
+func newTestStore(t *testing.T, maxEntries int) *Store {
+	inner, err := cznicb.StoreConstructor(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewCachingStore(inner, maxEntries)
+}
+
+func set(t *testing.T, s store.KVStore, key, val string) {
+	writer, err := s.Writer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	err = writer.Set([]byte(key), []byte(val))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func get(t *testing.T, r store.KVReader, key string) []byte {
+	val, err := r.Get([]byte(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return val
+}
+
+func TestCacheStoreHits(t *testing.T) {
+	s := newTestStore(t, 10)
+	set(t, s, "a", "val-a")
+
+	reader, err := s.Reader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	for i := 0; i < 3; i++ {
+		val := get(t, reader, "a")
+		if string(val) != "val-a" {
+			t.Errorf("expected val-a, got %s", val)
+		}
+		// callers may not change the cached value
+		val[0] = 'X'
+	}
+	if get(t, reader, "missing") != nil {
+		t.Errorf("expected nil for missing key")
+	}
+	if get(t, reader, "missing") != nil {
+		t.Errorf("expected nil for missing key")
+	}
+	if s.Hits() != 3 || s.Misses() != 2 {
+		t.Errorf("expected 3 hits and 2 misses, got %d and %d", s.Hits(), s.Misses())
+	}
+	if s.Len() != 2 {
+		t.Errorf("expected 2 cached values, got %d", s.Len())
+	}
+}
+
+type addUint64Operator struct {
+	offset uint64
+}
+
+func (a *addUint64Operator) Merge(key, existing []byte) ([]byte, error) {
+	var existingUint64 uint64
+	if len(existing) > 0 {
+		existingUint64 = binary.LittleEndian.Uint64(existing)
+	}
+	result := make([]byte, 8)
+	binary.LittleEndian.PutUint64(result, existingUint64+a.offset)
+	return result, nil
+}
+
+func TestCacheStoreInvalidation(t *testing.T) {
+	s := newTestStore(t, 10)
+	set(t, s, "a", "val-a")
+	set(t, s, "b", "val-b")
+	set(t, s, "c", "val-c")
+
+	fill := func() {
+		reader, err := s.Reader()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer reader.Close()
+		for _, key := range []string{"a", "b", "c", "count"} {
+			get(t, reader, key)
+		}
+	}
+	fill()
+
+	writer, err := s.Writer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = writer.Set([]byte("a"), []byte("val-a2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = writer.Delete([]byte("b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer.Close()
+	if s.Len() != 2 {
+		t.Errorf("expected 2 cached values after set and delete, got %d", s.Len())
+	}
+
+	reader, err := s.Reader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val := get(t, reader, "a"); string(val) != "val-a2" {
+		t.Errorf("expected val-a2, got %s", val)
+	}
+	if val := get(t, reader, "b"); val != nil {
+		t.Errorf("expected nil for deleted key, got %s", val)
+	}
+	reader.Close()
+	fill()
+
+	writer, err = s.Writer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch := writer.NewBatch()
+	batch.Set([]byte("b"), []byte("val-b2"))
+	batch.Delete([]byte("c"))
+	batch.Merge([]byte("count"), &addUint64Operator{offset: 5})
+	err = batch.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch.Close()
+	writer.Close()
+	if s.Len() != 1 {
+		t.Errorf("expected 1 cached value after batch, got %d", s.Len())
+	}
+
+	reader, err = s.Reader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	if val := get(t, reader, "a"); string(val) != "val-a2" {
+		t.Errorf("expected val-a2, got %s", val)
+	}
+	if val := get(t, reader, "b"); string(val) != "val-b2" {
+		t.Errorf("expected val-b2, got %s", val)
+	}
+	if val := get(t, reader, "c"); val != nil {
+		t.Errorf("expected nil for deleted key, got %s", val)
+	}
+	if val := get(t, reader, "count"); binary.LittleEndian.Uint64(val) != 5 {
+		t.Errorf("expected 5, got %d", binary.LittleEndian.Uint64(val))
+	}
+}
+
+func TestCacheStoreReaderIsolation(t *testing.T) {
+	s := newTestStore(t, 10)
+	set(t, s, "a", "val-a")
+
+	oldReader, err := s.Reader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer oldReader.Close()
+	set(t, s, "a", "val-a2")
+
+	// the old reader must neither see nor cache its snapshot
+	if val := get(t, oldReader, "a"); string(val) != "val-a" {
+		t.Errorf("expected val-a, got %s", val)
+	}
+	newReader, err := s.Reader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer newReader.Close()
+	if val := get(t, newReader, "a"); string(val) != "val-a2" {
+		t.Errorf("expected val-a2, got %s", val)
+	}
+
+	// nor the value cached by newer readers
+	if val := get(t, oldReader, "a"); string(val) != "val-a" {
+		t.Errorf("expected val-a, got %s", val)
+	}
+}
+
+func TestCacheStoreEviction(t *testing.T) {
+	s := newTestStore(t, 2)
+	set(t, s, "a", "val-a")
+	set(t, s, "b", "val-b")
+	set(t, s, "c", "val-c")
+
+	reader, err := s.Reader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	get(t, reader, "a")
+	get(t, reader, "b")
+	// a is now more recently used than b
+	get(t, reader, "a")
+	get(t, reader, "c")
+	if s.Len() != 2 {
+		t.Errorf("expected 2 cached values, got %d", s.Len())
+	}
+
+	hits := s.Hits()
+	get(t, reader, "a")
+	get(t, reader, "c")
+	if s.Hits() != hits+2 {
+		t.Errorf("expected a and c to be cached")
+	}
+	misses := s.Misses()
+	if val := get(t, reader, "b"); !reflect.DeepEqual(val, []byte("val-b")) {
+		t.Errorf("expected val-b, got %s", val)
+	}
+	if s.Misses() != misses+1 {
+		t.Errorf("expected b to be evicted")
+	}
+}
+
+func CommonTestKVStore(t *testing.T, s store.KVStore) {
+
+	writer, err := s.Writer()
+	if err != nil {
+		t.Error(err)
+	}
+	err = writer.Set([]byte("a"), []byte("val-a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = writer.Set([]byte("z"), []byte("val-z"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = writer.Delete([]byte("z"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	batch := writer.NewBatch()
+	batch.Set([]byte("b"), []byte("val-b"))
+	batch.Set([]byte("c"), []byte("val-c"))
+	batch.Set([]byte("d"), []byte("val-d"))
+	batch.Set([]byte("e"), []byte("val-e"))
+	batch.Set([]byte("f"), []byte("val-f"))
+	batch.Set([]byte("g"), []byte("val-g"))
+	batch.Set([]byte("h"), []byte("val-h"))
+	batch.Set([]byte("i"), []byte("val-i"))
+	batch.Set([]byte("j"), []byte("val-j"))
+
+	err = batch.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer.Close()
+
+	reader, err := s.Reader()
+	if err != nil {
+		t.Error(err)
+	}
+	defer reader.Close()
+	v, err := reader.Get([]byte("z"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != nil {
+		t.Errorf("expected nil for deleted key, got %s", v)
+	}
+	it := reader.Iterator([]byte("b"))
+	key, val, valid := it.Current()
+	if !valid {
+		t.Fatalf("valid false, expected true")
+	}
+	if string(key) != "b" {
+		t.Fatalf("expected key b, got %s", key)
+	}
+	if string(val) != "val-b" {
+		t.Fatalf("expected value val-b, got %s", val)
+	}
+
+	it.Next()
+	key, val, valid = it.Current()
+	if !valid {
+		t.Fatalf("valid false, expected true")
+	}
+	if string(key) != "c" {
+		t.Fatalf("expected key c, got %s", key)
+	}
+	if string(val) != "val-c" {
+		t.Fatalf("expected value val-c, got %s", val)
+	}
+
+	it.Seek([]byte("i"))
+	key, val, valid = it.Current()
+	if !valid {
+		t.Fatalf("valid false, expected true")
+	}
+	if string(key) != "i" {
+		t.Fatalf("expected key i, got %s", key)
+	}
+	if string(val) != "val-i" {
+		t.Fatalf("expected value val-i, got %s", val)
+	}
+
+	it.Close()
+}
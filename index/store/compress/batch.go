@@ -0,0 +1,82 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package compress
+
+import (
+	"github.com/blevesearch/bleve/index/store"
+)
+
+type Batch struct {
+	store *Store
+	b     store.KVBatch
+	err   error
+}
+
+func newBatch(s *Store, b store.KVBatch) *Batch {
+	return &Batch{
+		store: s,
+		b:     b,
+	}
+}
+
+func (b *Batch) Set(key, val []byte) {
+	if val == nil {
+		b.b.Set(key, nil)
+		return
+	}
+	cval, err := b.store.codec.compress(val)
+	if err != nil {
+		// reported when the batch is executed
+		b.err = err
+		return
+	}
+	b.b.Set(key, cval)
+}
+
+func (b *Batch) Delete(key []byte) {
+	b.b.Delete(key)
+}
+
+func (b *Batch) Merge(key []byte, oper store.AssociativeMerge) {
+	b.b.Merge(key, &merge{codec: b.store.codec, oper: oper})
+}
+
+func (b *Batch) Execute() error {
+	if b.err != nil {
+		return b.err
+	}
+	return b.b.Execute()
+}
+
+func (b *Batch) Close() error {
+	return b.b.Close()
+}
+
+// merge applies an AssociativeMerge to the decompressed
+// existing value, compressing the result.
+type merge struct {
+	codec codec
+	oper  store.AssociativeMerge
+}
+
+func (m *merge) Merge(key, existing []byte) ([]byte, error) {
+	var err error
+	if existing != nil {
+		existing, err = m.codec.decompress(existing)
+		if err != nil {
+			return nil, err
+		}
+	}
+	val, err := m.oper.Merge(key, existing)
+	if err != nil || val == nil {
+		return val, err
+	}
+	return m.codec.compress(val)
+}
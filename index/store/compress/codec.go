@@ -0,0 +1,63 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+
+	"github.com/golang/snappy"
+)
+
+type codec interface {
+	compress(val []byte) ([]byte, error)
+	decompress(val []byte) ([]byte, error)
+}
+
+var codecs = map[string]codec{
+	"snappy": snappyCodec{},
+	"gzip":   gzipCodec{},
+}
+
+type snappyCodec struct{}
+
+func (snappyCodec) compress(val []byte) ([]byte, error) {
+	return snappy.Encode(nil, val), nil
+}
+
+func (snappyCodec) decompress(val []byte) ([]byte, error) {
+	return snappy.Decode(nil, val)
+}
+
+type gzipCodec struct{}
+
+func (gzipCodec) compress(val []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write(val)
+	if err != nil {
+		return nil, err
+	}
+	err = w.Close()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gzipCodec) decompress(val []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(val))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
@@ -0,0 +1,94 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package compress
+
+import (
+	"github.com/blevesearch/bleve/index/store"
+)
+
+type Iterator struct {
+	store *Store
+	it    store.KVIterator
+
+	// decompressed value at the current position
+	val     []byte
+	valDone bool
+	valErr  error
+}
+
+func newIterator(s *Store, it store.KVIterator) *Iterator {
+	return &Iterator{
+		store: s,
+		it:    it,
+	}
+}
+
+func (i *Iterator) reset() {
+	i.val = nil
+	i.valDone = false
+	i.valErr = nil
+}
+
+func (i *Iterator) SeekFirst() {
+	i.reset()
+	i.it.SeekFirst()
+}
+
+func (i *Iterator) Seek(k []byte) {
+	i.reset()
+	i.it.Seek(k)
+}
+
+func (i *Iterator) Next() {
+	i.reset()
+	i.it.Next()
+}
+
+// Current returns the current key and decompressed value, a value
+// which fails to decompress makes the iterator invalid.
+func (i *Iterator) Current() ([]byte, []byte, bool) {
+	k, v, ok := i.it.Current()
+	if !ok {
+		return nil, nil, false
+	}
+	if !i.valDone {
+		i.val, i.valErr = i.store.codec.decompress(v)
+		i.valDone = true
+	}
+	if i.valErr != nil {
+		return nil, nil, false
+	}
+	return k, i.val, true
+}
+
+func (i *Iterator) Key() []byte {
+	k, _, ok := i.Current()
+	if !ok {
+		return nil
+	}
+	return k
+}
+
+func (i *Iterator) Value() []byte {
+	_, v, ok := i.Current()
+	if !ok {
+		return nil
+	}
+	return v
+}
+
+func (i *Iterator) Valid() bool {
+	_, _, ok := i.Current()
+	return ok
+}
+
+func (i *Iterator) Close() error {
+	return i.it.Close()
+}
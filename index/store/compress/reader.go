@@ -0,0 +1,46 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package compress
+
+import (
+	"github.com/blevesearch/bleve/index/store"
+)
+
+type Reader struct {
+	store *Store
+	r     store.KVReader
+}
+
+func newReader(s *Store, r store.KVReader) *Reader {
+	return &Reader{
+		store: s,
+		r:     r,
+	}
+}
+
+func (r *Reader) Get(key []byte) ([]byte, error) {
+	return r.store.get(r.r, key)
+}
+
+func (r *Reader) Iterator(key []byte) store.KVIterator {
+	return newIterator(r.store, r.r.Iterator(key))
+}
+
+func (r *Reader) Close() error {
+	return r.r.Close()
+}
+
+func (s *Store) get(r store.KVReader, key []byte) ([]byte, error) {
+	val, err := r.Get(key)
+	if err != nil || val == nil {
+		return val, err
+	}
+	return s.codec.decompress(val)
+}
@@ -0,0 +1,88 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+// Package compress provides a KVStore which wraps another KVStore,
+// transparently compressing values as they are written and
+// decompressing them as they are read.  Keys are left untouched so
+// ordering and seeks behave exactly as in the wrapped store.
+package compress
+
+import (
+	"fmt"
+
+	"github.com/blevesearch/bleve/index/store"
+	"github.com/blevesearch/bleve/registry"
+)
+
+const Name = "compress"
+
+type Store struct {
+	inner store.KVStore
+	codec codec
+}
+
+// NewCompressingStore wraps inner, compressing values using the
+// named codec, either "snappy" or "gzip".
+func NewCompressingStore(inner store.KVStore, codecName string) (*Store, error) {
+	c, ok := codecs[codecName]
+	if !ok {
+		return nil, fmt.Errorf("unknown compression codec '%s'", codecName)
+	}
+	return &Store{
+		inner: inner,
+		codec: c,
+	}, nil
+}
+
+func (s *Store) Close() error {
+	return s.inner.Close()
+}
+
+func (s *Store) Reader() (store.KVReader, error) {
+	r, err := s.inner.Reader()
+	if err != nil {
+		return nil, err
+	}
+	return newReader(s, r), nil
+}
+
+func (s *Store) Writer() (store.KVWriter, error) {
+	w, err := s.inner.Writer()
+	if err != nil {
+		return nil, err
+	}
+	return newWriter(s, w), nil
+}
+
+// StoreConstructor builds the store named by the "store" config
+// entry, passing it the rest of the config, and wraps it using the
+// codec named by the "codec" config entry (default "snappy").
+func StoreConstructor(config map[string]interface{}) (store.KVStore, error) {
+	name, ok := config["store"].(string)
+	if !ok || name == "" {
+		return nil, fmt.Errorf("must specify store")
+	}
+	innerConstructor := registry.KVStoreConstructorByName(name)
+	if innerConstructor == nil {
+		return nil, fmt.Errorf("no store named '%s' registered", name)
+	}
+	codecName, ok := config["codec"].(string)
+	if !ok || codecName == "" {
+		codecName = "snappy"
+	}
+	inner, err := innerConstructor(config)
+	if err != nil {
+		return nil, err
+	}
+	return NewCompressingStore(inner, codecName)
+}
+
+func init() {
+	registry.RegisterKVStore(Name, StoreConstructor)
+}
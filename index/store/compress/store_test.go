@@ -0,0 +1,286 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package compress
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/blevesearch/bleve/index/store"
+	"github.com/blevesearch/bleve/index/store/cznicb"
+)
+
+var codecNames = []string{"snappy", "gzip"}
+
+func TestCompressStore(t *testing.T) {
+	for _, codecName := range codecNames {
+		s, err := StoreConstructor(map[string]interface{}{
+			"store": cznicb.Name,
+			"codec": codecName,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		CommonTestKVStore(t, s)
+	}
+}
+
+func TestCompressStoreUnknownCodec(t *testing.T) {
+	inner, err := cznicb.StoreConstructor(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = NewCompressingStore(inner, "lzma")
+	if err == nil {
+		t.Errorf("expected error for unknown codec")
+	}
+}
+
+func TestCompressStoreValuesCompressed(t *testing.T) {
+	for _, codecName := range codecNames {
+		inner, err := cznicb.StoreConstructor(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, err := NewCompressingStore(inner, codecName)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		val := bytes.Repeat([]byte("the quick brown fox "), 50)
+		writer, err := s.Writer()
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = writer.Set([]byte("k"), val)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writer.Close()
+
+		innerReader, err := inner.Reader()
+		if err != nil {
+			t.Fatal(err)
+		}
+		raw, err := innerReader.Get([]byte("k"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		innerReader.Close()
+		if len(raw) >= len(val) {
+			t.Errorf("%s: expected stored value smaller than %d, got %d", codecName, len(val), len(raw))
+		}
+
+		reader, err := s.Reader()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := reader.Get([]byte("k"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, val) {
+			t.Errorf("%s: round trip mismatch", codecName)
+		}
+		reader.Close()
+	}
+}
+
+type addUint64Operator struct {
+	offset uint64
+}
+
+func (a *addUint64Operator) Merge(key, existing []byte) ([]byte, error) {
+	var existingUint64 uint64
+	if len(existing) > 0 {
+		existingUint64 = binary.LittleEndian.Uint64(existing)
+	}
+	result := make([]byte, 8)
+	binary.LittleEndian.PutUint64(result, existingUint64+a.offset)
+	return result, nil
+}
+
+func TestCompressStoreMerge(t *testing.T) {
+	for _, codecName := range codecNames {
+		s, err := StoreConstructor(map[string]interface{}{
+			"store": cznicb.Name,
+			"codec": codecName,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		writer, err := s.Writer()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 3; i++ {
+			batch := writer.NewBatch()
+			batch.Merge([]byte("count"), &addUint64Operator{offset: 5})
+			err = batch.Execute()
+			if err != nil {
+				t.Fatal(err)
+			}
+			batch.Close()
+		}
+		val, err := writer.Get([]byte("count"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if binary.LittleEndian.Uint64(val) != 15 {
+			t.Errorf("%s: expected 15, got %d", codecName, binary.LittleEndian.Uint64(val))
+		}
+		writer.Close()
+	}
+}
+
+func CommonTestKVStore(t *testing.T, s store.KVStore) {
+
+	writer, err := s.Writer()
+	if err != nil {
+		t.Error(err)
+	}
+	err = writer.Set([]byte("a"), []byte("val-a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = writer.Set([]byte("z"), []byte("val-z"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = writer.Delete([]byte("z"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	batch := writer.NewBatch()
+	batch.Set([]byte("b"), []byte("val-b"))
+	batch.Set([]byte("c"), []byte("val-c"))
+	batch.Set([]byte("d"), []byte("val-d"))
+	batch.Set([]byte("e"), []byte("val-e"))
+	batch.Set([]byte("f"), []byte("val-f"))
+	batch.Set([]byte("g"), []byte("val-g"))
+	batch.Set([]byte("h"), []byte("val-h"))
+	batch.Set([]byte("i"), []byte("val-i"))
+	batch.Set([]byte("j"), []byte("val-j"))
+
+	err = batch.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer.Close()
+
+	reader, err := s.Reader()
+	if err != nil {
+		t.Error(err)
+	}
+	defer reader.Close()
+	v, err := reader.Get([]byte("z"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != nil {
+		t.Errorf("expected nil for deleted key, got %s", v)
+	}
+	it := reader.Iterator([]byte("b"))
+	key, val, valid := it.Current()
+	if !valid {
+		t.Fatalf("valid false, expected true")
+	}
+	if string(key) != "b" {
+		t.Fatalf("expected key b, got %s", key)
+	}
+	if string(val) != "val-b" {
+		t.Fatalf("expected value val-b, got %s", val)
+	}
+
+	it.Next()
+	key, val, valid = it.Current()
+	if !valid {
+		t.Fatalf("valid false, expected true")
+	}
+	if string(key) != "c" {
+		t.Fatalf("expected key c, got %s", key)
+	}
+	if string(val) != "val-c" {
+		t.Fatalf("expected value val-c, got %s", val)
+	}
+
+	it.Seek([]byte("i"))
+	key, val, valid = it.Current()
+	if !valid {
+		t.Fatalf("valid false, expected true")
+	}
+	if string(key) != "i" {
+		t.Fatalf("expected key i, got %s", key)
+	}
+	if string(val) != "val-i" {
+		t.Fatalf("expected value val-i, got %s", val)
+	}
+
+	it.Close()
+}
+
+// benchmarkValues returns values laid out like the upside_down term
+// frequency rows used for postings: frequency, norm and 8 term vectors.
+func benchmarkValues(n int) [][]byte {
+	rv := make([][]byte, n)
+	for i := 0; i < n; i++ {
+		buf := make([]byte, 8+4+(8*(2+8+8+8)))
+		binary.LittleEndian.PutUint64(buf[0:8], 8)
+		binary.LittleEndian.PutUint32(buf[8:12], math.Float32bits(0.25))
+		offset := 12
+		for j := 0; j < 8; j++ {
+			pos := uint64(i%13 + j*7 + 1)
+			binary.LittleEndian.PutUint16(buf[offset:offset+2], 0)
+			binary.LittleEndian.PutUint64(buf[offset+2:offset+10], pos)
+			binary.LittleEndian.PutUint64(buf[offset+10:offset+18], pos*6)
+			binary.LittleEndian.PutUint64(buf[offset+18:offset+26], pos*6+4)
+			offset += 26
+		}
+		rv[i] = buf
+	}
+	return rv
+}
+
+func benchmarkCompressStore(b *testing.B, codecName string) {
+	vals := benchmarkValues(1000)
+	raw := 0
+	for _, v := range vals {
+		raw += len(v)
+	}
+	b.SetBytes(int64(raw))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		inner, _ := cznicb.StoreConstructor(nil)
+		s, _ := NewCompressingStore(inner, codecName)
+		writer, _ := s.Writer()
+		batch := writer.NewBatch()
+		for j, v := range vals {
+			batch.Set([]byte(fmt.Sprintf("k%d", j)), v)
+		}
+		batch.Execute()
+		writer.Close()
+		if i == 0 {
+			b.Logf("%s: %d value bytes stored as %d", codecName, raw, inner.(*cznicb.Store).ApproxSize())
+		}
+	}
+}
+
+func BenchmarkCompressSnappy(b *testing.B) {
+	benchmarkCompressStore(b, "snappy")
+}
+
+func BenchmarkCompressGzip(b *testing.B) {
+	benchmarkCompressStore(b, "gzip")
+}
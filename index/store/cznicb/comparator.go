@@ -0,0 +1,57 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the
+//  License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing,
+//  software distributed under the License is distributed on an "AS
+//  IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+//  express or implied. See the License for the specific language
+//  governing permissions and limitations under the License.
+
+package cznicb
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/cznic/b"
+)
+
+// DefaultComparator is the name of the comparator used when the
+// store config does not specify one, it orders keys lexicographically.
+const DefaultComparator = "bytes"
+
+// A Comparator returns an integer comparing two keys, -1 if a < b,
+// 0 if a == b and +1 if a > b.
+type Comparator func(a, b []byte) int
+
+var comparators = map[string]Comparator{
+	DefaultComparator: bytes.Compare,
+}
+
+// RegisterComparator makes a Comparator available to stores whose
+// config sets "comparator" to name.
+func RegisterComparator(name string, cmp Comparator) {
+	_, exists := comparators[name]
+	if exists {
+		panic(fmt.Errorf("attempted to register duplicate comparator named '%s'", name))
+	}
+	comparators[name] = cmp
+}
+
+func comparatorFromConfig(config map[string]interface{}) (b.Cmp, error) {
+	name := DefaultComparator
+	if config != nil {
+		if cname, ok := config["comparator"].(string); ok && cname != "" {
+			name = cname
+		}
+	}
+	cmp, ok := comparators[name]
+	if !ok {
+		return nil, fmt.Errorf("no comparator named '%s' registered", name)
+	}
+	return func(x, y interface{}) int {
+		return cmp(x.([]byte), y.([]byte))
+	}, nil
+}
@@ -10,13 +10,17 @@
 //  governing permissions and limitations under the License.
 
 // Package cznicb provides an in-memory implementation of the KVStore
-// interfaces using the cznic/b in-memory btree.  Of note: this
-// implementation does not have reader isolation.
+// interfaces using the cznic/b in-memory btree.  Readers are isolated
+// using copy-on-write: a reader shares the btree that was current
+// when it was created, and the first mutation made while that btree
+// is shared clones it, so open readers never observe later writes.
 package cznicb
 
 import (
 	"bytes"
+	"context"
 	"errors"
+	"sort"
 	"sync"
 
 	"github.com/blevesearch/bleve/index/store"
@@ -29,33 +33,77 @@ const Name = "cznicb"
 
 var iteratorDoneErr = errors.New("iteratorDoneErr") // A sentinel value.
 
+// executeGroupSize is the number of mutations a batch applies
+// between checks of its context and progress reports.
+const executeGroupSize = 1000
+
+// ErrConcurrentModification is reported by an iterator over the live
+// store when the store was mutated after the iterator was positioned.
+var ErrConcurrentModification = errors.New("store modified during iteration")
+
 func init() {
 	registry.RegisterKVStore(Name, StoreConstructor)
 }
 
+// StoreConstructor creates an empty store, the optional "comparator"
+// config entry names a registered Comparator used to order keys.
 func StoreConstructor(config map[string]interface{}) (
 	store.KVStore, error) {
-	return &Store{t: b.TreeNew(itemCompare)}, nil
+	cmp, err := comparatorFromConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{cmp: cmp, t: b.TreeNew(cmp)}, nil
 }
 
-func itemCompare(a, b interface{}) int {
-	return bytes.Compare(a.([]byte), b.([]byte))
+// Store is safe for concurrent use.  Gets and iterators only
+// take m for reading, so any number of them proceed in
+// parallel.  Mutations, and opening or closing a reader, which
+// updates the count of readers sharing the tree, take it for
+// writing.
+type Store struct {
+	m   sync.RWMutex
+	cmp b.Cmp
+	t   *b.Tree
+
+	// number of open readers sharing t, when non-zero the
+	// next mutation must first clone t
+	shared int
+
+	// number of entries deleted from t since it was last rebuilt
+	deleted int
+
+	// approximate number of key and value bytes held in t
+	size uint64
+
+	// incremented on every mutation of the store
+	ver uint64
 }
 
-type Store struct {
-	m sync.Mutex
+type Reader struct {
+	s *Store
 	t *b.Tree
 }
 
 type Iterator struct { // Assuming that iterators are used single-threaded.
 	s *Store
+	t *b.Tree // nil when iterating the live tree of the store
 	e *b.Enumerator
 
+	reverse bool
+	ver     uint64 // store version the live iterator was positioned at
+
 	currK   interface{}
 	currV   interface{}
 	currErr error
 }
 
+// Batch flattens its mutations when executed, so each key is
+// written once.  The merges of a key are applied first, in the
+// order they were added, to the value stored before the batch;
+// a Set or Delete of the key in the same batch follows them and
+// the last one wins, leaving the merges of that key without
+// effect.
 type Batch struct {
 	s *Store
 
@@ -63,6 +111,8 @@ type Batch struct {
 	ks [][]byte
 	vs [][]byte
 	ms map[string]store.AssociativeMergeChain
+
+	progress func(applied, total int)
 }
 
 func (s *Store) Close() error {
@@ -70,7 +120,11 @@ func (s *Store) Close() error {
 }
 
 func (s *Store) Reader() (store.KVReader, error) {
-	return s, nil
+	s.m.Lock()
+	t := s.t
+	s.shared++
+	s.m.Unlock()
+	return &Reader{s: s, t: t}, nil
 }
 
 func (s *Store) Writer() (store.KVWriter, error) {
@@ -78,9 +132,9 @@ func (s *Store) Writer() (store.KVWriter, error) {
 }
 
 func (s *Store) Get(k []byte) ([]byte, error) {
-	s.m.Lock()
+	s.m.RLock()
 	v, ok := s.t.Get(k)
-	s.m.Unlock()
+	s.m.RUnlock()
 	if !ok || v == nil {
 		return nil, nil
 	}
@@ -93,17 +147,171 @@ func (s *Store) Iterator(k []byte) store.KVIterator {
 	return iter
 }
 
+// ReverseIterator returns an iterator positioned at the largest
+// key <= k, whose Next moves to the preceding key.
+func (s *Store) ReverseIterator(k []byte) store.KVIterator {
+	iter := &Iterator{s: s, reverse: true}
+	iter.Seek(k)
+	return iter
+}
+
 func (s *Store) Set(k, v []byte) (err error) {
 	s.m.Lock()
-	s.t.Set(k, v)
+	s.set(s.writableTree(), k, v)
 	s.m.Unlock()
 	return nil
 }
 
 func (s *Store) Delete(k []byte) (err error) {
 	s.m.Lock()
-	s.t.Delete(k)
+	s.delete(s.writableTree(), k)
+	s.m.Unlock()
+	return nil
+}
+
+func (s *Store) CompareAndSet(k, expectedOld, v []byte) (bool, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	old, ok := s.t.Get(k)
+	if ok != (expectedOld != nil) ||
+		(ok && !bytes.Equal(old.([]byte), expectedOld)) {
+		return false, nil
+	}
+	if v != nil {
+		s.set(s.writableTree(), k, v)
+	} else {
+		s.delete(s.writableTree(), k)
+	}
+	return true, nil
+}
+
+// set and delete mutate t, which must be the writable tree,
+// keeping the entry accounting current.  The caller must hold s.m.
+func (s *Store) set(t *b.Tree, k, v []byte) {
+	t.Put(k, func(old interface{}, exists bool) (interface{}, bool) {
+		if exists {
+			s.size -= uint64(len(old.([]byte)))
+		} else {
+			s.size += uint64(len(k))
+		}
+		s.size += uint64(len(v))
+		return v, true
+	})
+	s.ver++
+}
+
+func (s *Store) delete(t *b.Tree, k []byte) {
+	v, ok := t.Get(k)
+	if ok {
+		t.Delete(k)
+		s.deleted++
+		s.size -= uint64(len(k) + len(v.([]byte)))
+		s.ver++
+	}
+}
+
+// Len returns the number of entries in the store.
+func (s *Store) Len() int {
+	s.m.RLock()
+	rv := s.t.Len()
+	s.m.RUnlock()
+	return rv
+}
+
+// ApproxSize returns the approximate number of bytes used by the
+// keys and values in the store, excluding btree overhead.
+func (s *Store) ApproxSize() uint64 {
+	s.m.RLock()
+	rv := s.size
+	s.m.RUnlock()
+	return rv
+}
+
+// Compact rebuilds the btree from its live entries, releasing the
+// memory still held by nodes emptied through deletes.  It returns
+// the number of deleted entries reclaimed.  The rebuild happens
+// outside the store lock, so open readers keep their snapshots and
+// writers are only blocked while the new tree is swapped in.
+func (s *Store) Compact() (int, error) {
+	s.m.Lock()
+	t := s.t
+	deleted := s.deleted
+	s.shared++ // keep writers from mutating t while it is copied
 	s.m.Unlock()
+
+	nt := cloneTree(t, s.cmp)
+
+	s.m.Lock()
+	defer s.m.Unlock()
+	if s.t != t {
+		// a writer already replaced t with a fresh clone,
+		// which reclaimed the same entries
+		return deleted, nil
+	}
+	s.t = nt
+	s.shared = 0
+	s.deleted = 0
+	return deleted, nil
+}
+
+// writableTree returns a tree which may be mutated, cloning
+// the current tree if it is shared with open readers.
+// The caller must hold s.m.
+func (s *Store) writableTree() *b.Tree {
+	if s.shared > 0 {
+		s.t = cloneTree(s.t, s.cmp)
+		s.shared = 0
+		s.deleted = 0
+	}
+	return s.t
+}
+
+func cloneTree(t *b.Tree, cmp b.Cmp) *b.Tree {
+	rv := b.TreeNew(cmp)
+	e, err := t.SeekFirst()
+	if err != nil {
+		return rv
+	}
+	defer e.Close()
+	k, v, err := e.Next()
+	for err == nil {
+		rv.Set(k, v)
+		k, v, err = e.Next()
+	}
+	return rv
+}
+
+func (r *Reader) Get(k []byte) ([]byte, error) {
+	r.s.m.RLock()
+	v, ok := r.t.Get(k)
+	r.s.m.RUnlock()
+	if !ok || v == nil {
+		return nil, nil
+	}
+	return v.([]byte), nil
+}
+
+func (r *Reader) Iterator(k []byte) store.KVIterator {
+	iter := &Iterator{s: r.s, t: r.t}
+	iter.Seek(k)
+	return iter
+}
+
+// ReverseIterator returns an iterator positioned at the largest
+// key <= k, whose Next moves to the preceding key.
+func (r *Reader) ReverseIterator(k []byte) store.KVIterator {
+	iter := &Iterator{s: r.s, t: r.t, reverse: true}
+	iter.Seek(k)
+	return iter
+}
+
+func (r *Reader) Close() error {
+	r.s.m.Lock()
+	if r.t != nil && r.t == r.s.t {
+		r.s.shared--
+	}
+	r.t = nil
+	r.s.m.Unlock()
 	return nil
 }
 
@@ -111,15 +319,31 @@ func (s *Store) NewBatch() store.KVBatch {
 	return &Batch{s: s, ms: map[string]store.AssociativeMergeChain{}}
 }
 
+// NewBatchSized returns a batch with room preallocated for n
+// Set/Delete operations.
+func (s *Store) NewBatchSized(n int) *Batch {
+	return &Batch{
+		s:  s,
+		ks: make([][]byte, 0, n),
+		vs: make([][]byte, 0, n),
+		ms: map[string]store.AssociativeMergeChain{},
+	}
+}
+
 func (w *Iterator) SeekFirst() {
 	w.currK = nil
 	w.currV = nil
 	w.currErr = nil
 
 	var err error
-	w.s.m.Lock()
-	w.e, err = w.s.t.SeekFirst()
-	w.s.m.Unlock()
+	w.s.m.RLock()
+	w.ver = w.s.ver
+	if w.reverse {
+		w.e, err = w.tree().SeekLast()
+	} else {
+		w.e, err = w.tree().SeekFirst()
+	}
+	w.s.m.RUnlock()
 	if err != nil {
 		w.currK = nil
 		w.currV = nil
@@ -134,24 +358,70 @@ func (w *Iterator) Seek(k []byte) {
 	w.currV = nil
 	w.currErr = nil
 
-	w.s.m.Lock()
-	w.e, _ = w.s.t.Seek(k)
-	w.s.m.Unlock()
+	w.s.m.RLock()
+	w.ver = w.s.ver
+	var hit bool
+	w.e, hit = w.tree().Seek(k)
+	w.s.m.RUnlock()
 
 	w.Next()
+	if !w.reverse || hit {
+		return
+	}
+	// k is missing, the enumerator stepped back from the
+	// smallest key > k or from past the last key
+	if w.currK == nil {
+		// k is past the last key, so start from it
+		w.SeekFirst()
+		return
+	}
+	if bytes.Compare(w.currK.([]byte), k) > 0 {
+		w.Next()
+	}
 }
 
 func (w *Iterator) Next() {
 	if w.currErr != nil {
 		w.currK = nil
 		w.currV = nil
-		w.currErr = iteratorDoneErr
+		if w.currErr != ErrConcurrentModification {
+			w.currErr = iteratorDoneErr
+		}
 		return
 	}
 
-	w.s.m.Lock()
-	w.currK, w.currV, w.currErr = w.e.Next()
-	w.s.m.Unlock()
+	w.s.m.RLock()
+	if w.t == nil && w.ver != w.s.ver {
+		w.s.m.RUnlock()
+		w.currK = nil
+		w.currV = nil
+		w.currErr = ErrConcurrentModification
+		return
+	}
+	if w.reverse {
+		w.currK, w.currV, w.currErr = w.e.Prev()
+	} else {
+		w.currK, w.currV, w.currErr = w.e.Next()
+	}
+	w.s.m.RUnlock()
+}
+
+// tree returns the tree this iterator enumerates.
+// The caller must hold w.s.m, at least for reading.
+func (w *Iterator) tree() *b.Tree {
+	if w.t != nil {
+		return w.t
+	}
+	return w.s.t
+}
+
+// Err returns ErrConcurrentModification if the iterator was
+// invalidated by a mutation of the store, otherwise nil.
+func (w *Iterator) Err() error {
+	if w.currErr == ErrConcurrentModification {
+		return w.currErr
+	}
+	return nil
 }
 
 func (w *Iterator) Current() ([]byte, []byte, bool) {
@@ -207,6 +477,27 @@ func (w *Batch) Delete(k []byte) {
 	w.m.Unlock()
 }
 
+// SetMulti adds a Set for each key in ks with the value at the
+// same position in vs, acquiring the batch lock only once.
+// ks and vs must have the same length.
+func (w *Batch) SetMulti(ks, vs [][]byte) {
+	w.m.Lock()
+	w.ks = append(w.ks, ks...)
+	w.vs = append(w.vs, vs[:len(ks)]...)
+	w.m.Unlock()
+}
+
+// DeleteMulti adds a Delete for each key in ks, acquiring the
+// batch lock only once.
+func (w *Batch) DeleteMulti(ks [][]byte) {
+	w.m.Lock()
+	w.ks = append(w.ks, ks...)
+	for range ks {
+		w.vs = append(w.vs, nil)
+	}
+	w.m.Unlock()
+}
+
 func (w *Batch) Merge(k []byte, oper store.AssociativeMerge) {
 	key := string(k)
 	w.m.Lock()
@@ -214,7 +505,27 @@ func (w *Batch) Merge(k []byte, oper store.AssociativeMerge) {
 	w.m.Unlock()
 }
 
-func (w *Batch) Execute() (err error) {
+// SetProgress registers f to be called as the batch is
+// executed, after each group of mutations applied, with the
+// number applied so far and the total.  A mutation is the final
+// write of one key, and the merged keys are counted first.  f is
+// called with the store locked, so it must not use the store.
+func (w *Batch) SetProgress(f func(applied, total int)) {
+	w.m.Lock()
+	w.progress = f
+	w.m.Unlock()
+}
+
+func (w *Batch) Execute() error {
+	return w.ExecuteContext(context.Background())
+}
+
+// ExecuteContext applies the mutations of the batch, checking
+// ctx after each group of them.  When ctx is done it returns
+// ctx.Err(), leaving the groups already applied in the store
+// and dropping the rest.  Readers never observe a group half
+// applied, the store stays locked until ExecuteContext returns.
+func (w *Batch) ExecuteContext(ctx context.Context) (err error) {
 	w.m.Lock()
 	ks := w.ks
 	w.ks = nil
@@ -222,13 +533,50 @@ func (w *Batch) Execute() (err error) {
 	w.vs = nil
 	ms := w.ms
 	w.ms = map[string]store.AssociativeMergeChain{}
+	progress := w.progress
 	w.m.Unlock()
 
+	err = ctx.Err()
+	if err != nil {
+		return err
+	}
+
 	w.s.m.Lock()
 	defer w.s.m.Unlock()
 
-	t := w.s.t
-	for key, mc := range ms {
+	// the last Set or Delete of a key replaces whatever its
+	// merges produce, so only that one is applied
+	last := make(map[string]int, len(ks))
+	for i, k := range ks {
+		last[string(k)] = i
+	}
+	merged := make([]string, 0, len(ms))
+	for key := range ms {
+		if _, ok := last[key]; !ok {
+			merged = append(merged, key)
+		}
+	}
+	sort.Strings(merged)
+
+	total := len(merged) + len(last)
+	applied := 0
+	groupDone := func() error {
+		applied++
+		if applied%executeGroupSize != 0 && applied != total {
+			return nil
+		}
+		if progress != nil {
+			progress(applied, total)
+		}
+		if applied == total {
+			return nil
+		}
+		return ctx.Err()
+	}
+
+	t := w.s.writableTree()
+	for _, key := range merged {
+		mc := ms[key]
 		k := []byte(key)
 		b := []byte(nil)
 		v, ok := t.Get(k)
@@ -240,18 +588,29 @@ func (w *Batch) Execute() (err error) {
 			return err
 		}
 		if b != nil {
-			t.Set(k, b)
+			w.s.set(t, k, b)
 		} else {
-			t.Delete(k)
+			w.s.delete(t, k)
+		}
+		err = groupDone()
+		if err != nil {
+			return err
 		}
 	}
 
 	for i, k := range ks {
+		if last[string(k)] != i {
+			continue
+		}
 		v := vs[i]
 		if v != nil {
-			t.Set(k, v)
+			w.s.set(t, k, v)
 		} else {
-			t.Delete(k)
+			w.s.delete(t, k)
+		}
+		err = groupDone()
+		if err != nil {
+			return err
 		}
 	}
 
@@ -263,6 +622,7 @@ func (w *Batch) Close() error {
 	w.ks = nil
 	w.vs = nil
 	w.ms = nil
+	w.progress = nil
 	w.m.Unlock()
 	return nil
 }
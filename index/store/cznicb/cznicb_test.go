@@ -13,6 +13,11 @@
 package cznicb
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
 	"testing"
 
 	"github.com/blevesearch/bleve/index/store"
@@ -27,6 +32,621 @@ func TestCznicBStore(t *testing.T) {
 	CommonTestKVStore(t, s)
 }
 
+func TestReaderIsolation(t *testing.T) {
+	s, err := StoreConstructor(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	CommonTestReaderIsolation(t, s)
+}
+
+func TestReaderIsolationBatch(t *testing.T) {
+	s, err := StoreConstructor(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writer, err := s.Writer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = writer.Set([]byte("a"), []byte("val-a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := s.Reader()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	batch := writer.NewBatch()
+	batch.Set([]byte("a"), []byte("val-a2"))
+	batch.Set([]byte("b"), []byte("val-b"))
+	err = batch.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the reader still sees the pre-batch state
+	val, err := reader.Get([]byte("a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(val, []byte("val-a")) {
+		t.Errorf("expected val-a, got %s", val)
+	}
+	val, err = reader.Get([]byte("b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val != nil {
+		t.Errorf("expected nil, got %s", val)
+	}
+
+	// the writer sees the batch
+	val, err = writer.Get([]byte("a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(val, []byte("val-a2")) {
+		t.Errorf("expected val-a2, got %s", val)
+	}
+
+	// closing the reader releases its snapshot
+	newReader, err := s.Reader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = reader.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = newReader.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.(*Store).shared != 0 {
+		t.Errorf("expected no shared readers, got %d", s.(*Store).shared)
+	}
+}
+
+func TestBatchMulti(t *testing.T) {
+	s, err := StoreConstructor(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs := s.(*Store)
+
+	err = cs.Set([]byte("x"), []byte("val-x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	batch := cs.NewBatchSized(3)
+	batch.SetMulti(
+		[][]byte{[]byte("a"), []byte("b"), []byte("c")},
+		[][]byte{[]byte("val-a"), []byte("val-b"), []byte("val-c")})
+	batch.DeleteMulti([][]byte{[]byte("b"), []byte("x")})
+	batch.Merge([]byte("c"), &appendMerge{suffix: "-merged"})
+	err = batch.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"a": "val-a",
+		"b": "",
+		"c": "val-c",
+		"x": "",
+	}
+	for k, ev := range expected {
+		v, err := cs.Get([]byte(k))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(v) != ev {
+			t.Errorf("expected %s to be '%s', got '%s'", k, ev, v)
+		}
+	}
+
+	err = batch.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if batch.ks != nil || batch.vs != nil || batch.ms != nil {
+		t.Errorf("expected batch to be reset after close")
+	}
+}
+
+func TestBatchMergeAndSet(t *testing.T) {
+	s, err := StoreConstructor(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs := s.(*Store)
+
+	for _, k := range []string{"a", "b", "c", "d"} {
+		err = cs.Set([]byte(k), []byte("val-"+k))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	batch := cs.NewBatch().(*Batch)
+	// a set after a merge wins
+	batch.Merge([]byte("a"), &appendMerge{suffix: "-merged"})
+	batch.Set([]byte("a"), []byte("val-a2"))
+	// so does a set before it, merges come first
+	batch.Set([]byte("b"), []byte("val-b2"))
+	batch.Merge([]byte("b"), &appendMerge{suffix: "-merged"})
+	// and a delete
+	batch.Merge([]byte("c"), &appendMerge{suffix: "-merged"})
+	batch.Delete([]byte("c"))
+	// the merges of a key apply in order to its stored value
+	batch.Merge([]byte("d"), &appendMerge{suffix: "-1"})
+	batch.Merge([]byte("d"), &appendMerge{suffix: "-2"})
+	// the last of several sets wins
+	batch.Set([]byte("e"), []byte("val-e"))
+	batch.Delete([]byte("e"))
+	batch.Set([]byte("e"), []byte("val-e2"))
+
+	var totals []int
+	batch.SetProgress(func(applied, total int) {
+		totals = append(totals, total)
+	})
+	err = batch.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(totals, []int{5}) {
+		t.Errorf("expected one write for each of 5 keys, got totals %v", totals)
+	}
+
+	expected := map[string]string{
+		"a": "val-a2",
+		"b": "val-b2",
+		"c": "",
+		"d": "val-d-1-2",
+		"e": "val-e2",
+	}
+	for k, ev := range expected {
+		v, err := cs.Get([]byte(k))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(v) != ev {
+			t.Errorf("expected %s to be '%s', got '%s'", k, ev, v)
+		}
+	}
+	if cs.Len() != 4 {
+		t.Errorf("expected 4 entries, got %d", cs.Len())
+	}
+}
+
+func TestCompact(t *testing.T) {
+	s, err := StoreConstructor(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs := s.(*Store)
+
+	ks, vs := benchmarkBatchKeys(1000)
+	batch := cs.NewBatchSized(len(ks))
+	batch.SetMulti(ks, vs)
+	err = batch.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// hold a snapshot across the compaction
+	reader, err := s.Reader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	expected := make(map[string]string)
+	for i, k := range ks {
+		if i%2 == 0 {
+			err = cs.Delete(k)
+			if err != nil {
+				t.Fatal(err)
+			}
+		} else {
+			expected[string(k)] = string(vs[i])
+		}
+	}
+
+	reclaimed, err := cs.Compact()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reclaimed != 500 {
+		t.Errorf("expected 500 entries reclaimed, got %d", reclaimed)
+	}
+
+	count := 0
+	it := cs.Iterator([]byte{0})
+	for it.Valid() {
+		ev, ok := expected[string(it.Key())]
+		if !ok {
+			t.Errorf("unexpected key %s after compaction", it.Key())
+		} else if ev != string(it.Value()) {
+			t.Errorf("expected %s for key %s, got %s", ev, it.Key(), it.Value())
+		}
+		count++
+		it.Next()
+	}
+	it.Close()
+	if count != len(expected) {
+		t.Errorf("expected %d keys after compaction, got %d", len(expected), count)
+	}
+
+	// the snapshot still sees every key
+	count = 0
+	it = reader.Iterator([]byte{0})
+	for it.Valid() {
+		count++
+		it.Next()
+	}
+	it.Close()
+	if count != len(ks) {
+		t.Errorf("expected reader to see %d keys, saw %d", len(ks), count)
+	}
+
+	reclaimed, err = cs.Compact()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reclaimed != 0 {
+		t.Errorf("expected nothing reclaimed, got %d", reclaimed)
+	}
+}
+
+func TestReverseIterator(t *testing.T) {
+	s, err := StoreConstructor(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs := s.(*Store)
+
+	// empty store
+	it := cs.ReverseIterator([]byte("z"))
+	if it.Valid() {
+		t.Errorf("expected empty store iterator to be invalid")
+	}
+	it.SeekFirst()
+	if it.Valid() {
+		t.Errorf("expected empty store iterator to be invalid")
+	}
+	it.Close()
+
+	// single key store
+	err = cs.Set([]byte("m"), []byte("val-m"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	it = cs.ReverseIterator([]byte("m"))
+	if !it.Valid() || string(it.Key()) != "m" {
+		t.Errorf("expected key m, got %s", it.Key())
+	}
+	it.Next()
+	if it.Valid() {
+		t.Errorf("expected iterator to be exhausted, got %s", it.Key())
+	}
+	it.Seek([]byte("z"))
+	if !it.Valid() || string(it.Key()) != "m" {
+		t.Errorf("expected key m, got %s", it.Key())
+	}
+	it.Seek([]byte("a"))
+	if it.Valid() {
+		t.Errorf("expected seek before all keys to be invalid, got %s", it.Key())
+	}
+	it.Close()
+
+	// mid-range seeks
+	for _, k := range []string{"b", "d", "f", "h"} {
+		err = cs.Set([]byte(k), []byte("val-"+k))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		seek     string
+		expected []string
+	}{
+		{seek: "e", expected: []string{"d", "b"}},
+		{seek: "f", expected: []string{"f", "d", "b"}},
+		{seek: "z", expected: []string{"m", "h", "f", "d", "b"}},
+		{seek: "a", expected: []string{}},
+	}
+	for _, test := range tests {
+		it = cs.ReverseIterator([]byte(test.seek))
+		keys := []string{}
+		for it.Valid() {
+			keys = append(keys, string(it.Key()))
+			it.Next()
+		}
+		it.Close()
+		if !reflect.DeepEqual(keys, test.expected) {
+			t.Errorf("seek %s: expected %v, got %v", test.seek, test.expected, keys)
+		}
+	}
+
+	it = cs.ReverseIterator([]byte("a"))
+	it.SeekFirst()
+	if !it.Valid() || string(it.Key()) != "m" || string(it.Value()) != "val-m" {
+		t.Errorf("expected SeekFirst to position at last key m, got %s", it.Key())
+	}
+	it.Close()
+}
+
+func TestLenAndApproxSize(t *testing.T) {
+	s, err := StoreConstructor(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs := s.(*Store)
+
+	check := func(op string, expectedLen int, expectedSize uint64) {
+		if cs.Len() != expectedLen {
+			t.Errorf("after %s expected len %d, got %d", op, expectedLen, cs.Len())
+		}
+		if cs.ApproxSize() != expectedSize {
+			t.Errorf("after %s expected size %d, got %d", op, expectedSize, cs.ApproxSize())
+		}
+	}
+
+	check("open", 0, 0)
+
+	err = cs.Set([]byte("a"), []byte("val-a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("set", 1, 6)
+
+	err = cs.Set([]byte("a"), []byte("longer-val-a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("overwrite", 1, 13)
+
+	batch := cs.NewBatch()
+	batch.Set([]byte("bb"), []byte("val-b"))
+	batch.Set([]byte("c"), []byte("val-c"))
+	batch.Delete([]byte("a"))
+	err = batch.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("batch", 2, 13)
+
+	err = cs.Delete([]byte("not-there"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("missing delete", 2, 13)
+
+	err = cs.Delete([]byte("bb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("delete", 1, 6)
+
+	_, err = cs.Compact()
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("compact", 1, 6)
+}
+
+func TestCustomComparator(t *testing.T) {
+	RegisterComparator("test-reverse", func(a, b []byte) int {
+		return bytes.Compare(b, a)
+	})
+
+	s, err := StoreConstructor(map[string]interface{}{
+		"comparator": "test-reverse",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs := s.(*Store)
+
+	for _, k := range []string{"b", "a", "c"} {
+		err = cs.Set([]byte(k), []byte("val-"+k))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	keys := []string{}
+	it := cs.Iterator([]byte("z"))
+	for it.Valid() {
+		keys = append(keys, string(it.Key()))
+		it.Next()
+	}
+	it.Close()
+	expected := []string{"c", "b", "a"}
+	if !reflect.DeepEqual(keys, expected) {
+		t.Errorf("expected %v, got %v", expected, keys)
+	}
+
+	_, err = StoreConstructor(map[string]interface{}{
+		"comparator": "not-registered",
+	})
+	if err == nil {
+		t.Errorf("expected error for unknown comparator")
+	}
+}
+
+func TestIteratorConcurrentModification(t *testing.T) {
+	s, err := StoreConstructor(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs := s.(*Store)
+
+	for _, k := range []string{"a", "b", "c", "d"} {
+		err = cs.Set([]byte(k), []byte("val-"+k))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// a snapshot iterator is unaffected by mutations
+	reader, err := s.Reader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	rit := reader.Iterator([]byte("a"))
+	defer rit.Close()
+
+	it := cs.Iterator([]byte("a"))
+	defer it.Close()
+	if !it.Valid() || string(it.Key()) != "a" {
+		t.Fatalf("expected key a, got %s", it.Key())
+	}
+	it.Next()
+	if !it.Valid() || string(it.Key()) != "b" {
+		t.Fatalf("expected key b, got %s", it.Key())
+	}
+
+	batch := cs.NewBatch()
+	batch.Delete([]byte("c"))
+	err = batch.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	it.Next()
+	if it.Valid() {
+		t.Errorf("expected iterator to be invalidated, got %s", it.Key())
+	}
+	if it.(*Iterator).Err() != ErrConcurrentModification {
+		t.Errorf("expected ErrConcurrentModification, got %v", it.(*Iterator).Err())
+	}
+	it.Next()
+	if it.Valid() || it.(*Iterator).Err() != ErrConcurrentModification {
+		t.Errorf("expected iterator to stay invalidated")
+	}
+
+	// seeking repositions the iterator at the current version
+	it.Seek([]byte("b"))
+	if !it.Valid() || string(it.Key()) != "b" {
+		t.Errorf("expected key b after seek, got %s", it.Key())
+	}
+	it.Next()
+	if !it.Valid() || string(it.Key()) != "d" {
+		t.Errorf("expected key d after seek, got %s", it.Key())
+	}
+
+	count := 0
+	for rit.Valid() {
+		count++
+		rit.Next()
+	}
+	if count != 4 {
+		t.Errorf("expected snapshot iterator to see 4 keys, saw %d", count)
+	}
+}
+
+func TestCompareAndSet(t *testing.T) {
+	s, err := StoreConstructor(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer, err := s.Writer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cas := writer.(store.KVCompareAndSetter)
+
+	tests := []struct {
+		expectedOld []byte
+		val         []byte
+		swapped     bool
+		after       []byte
+	}{
+		// not present, expecting present
+		{expectedOld: []byte("v1"), val: []byte("v2"), swapped: false, after: nil},
+		// not present
+		{expectedOld: nil, val: []byte("v1"), swapped: true, after: []byte("v1")},
+		// present, expecting not present
+		{expectedOld: nil, val: []byte("v2"), swapped: false, after: []byte("v1")},
+		// successful swap
+		{expectedOld: []byte("v1"), val: []byte("v2"), swapped: true, after: []byte("v2")},
+		// failed swap
+		{expectedOld: []byte("v1"), val: []byte("v3"), swapped: false, after: []byte("v2")},
+		// swap to delete
+		{expectedOld: []byte("v2"), val: nil, swapped: true, after: nil},
+	}
+
+	for i, test := range tests {
+		swapped, err := cas.CompareAndSet([]byte("k"), test.expectedOld, test.val)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if swapped != test.swapped {
+			t.Errorf("test %d: expected swapped %t, got %t", i, test.swapped, swapped)
+		}
+		v, err := writer.Get([]byte("k"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(v, test.after) {
+			t.Errorf("test %d: expected value %s, got %s", i, test.after, v)
+		}
+	}
+}
+
+type appendMerge struct {
+	suffix string
+}
+
+func (a *appendMerge) Merge(key, existing []byte) ([]byte, error) {
+	return append(existing, []byte(a.suffix)...), nil
+}
+
+func benchmarkBatchKeys(n int) ([][]byte, [][]byte) {
+	ks := make([][]byte, n)
+	vs := make([][]byte, n)
+	for i := 0; i < n; i++ {
+		ks[i] = []byte(fmt.Sprintf("key-%d", i))
+		vs[i] = []byte(fmt.Sprintf("val-%d", i))
+	}
+	return ks, vs
+}
+
+func BenchmarkBatchSet100k(b *testing.B) {
+	ks, vs := benchmarkBatchKeys(100000)
+	s, _ := StoreConstructor(nil)
+	cs := s.(*Store)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		batch := cs.NewBatch()
+		for j := range ks {
+			batch.Set(ks[j], vs[j])
+		}
+		batch.Close()
+	}
+}
+
+func BenchmarkBatchSetMulti100k(b *testing.B) {
+	ks, vs := benchmarkBatchKeys(100000)
+	s, _ := StoreConstructor(nil)
+	cs := s.(*Store)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		batch := cs.NewBatchSized(len(ks))
+		batch.SetMulti(ks, vs)
+		batch.Close()
+	}
+}
+
 func CommonTestKVStore(t *testing.T, s store.KVStore) {
 
 	writer, err := s.Writer()
@@ -120,3 +740,302 @@ func CommonTestKVStore(t *testing.T, s store.KVStore) {
 
 	it.Close()
 }
+
+func CommonTestReaderIsolation(t *testing.T, s store.KVStore) {
+	// insert a kv pair
+	writer, err := s.Writer()
+	if err != nil {
+		t.Error(err)
+	}
+	err = writer.Set([]byte("a"), []byte("val-a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer.Close()
+
+	// create an isolated reader
+	reader, err := s.Reader()
+	if err != nil {
+		t.Error(err)
+	}
+	defer reader.Close()
+
+	// verify that we see the value already inserted
+	val, err := reader.Get([]byte("a"))
+	if err != nil {
+		t.Error(err)
+	}
+	if !reflect.DeepEqual(val, []byte("val-a")) {
+		t.Errorf("expected val-a, got nil")
+	}
+
+	// verify that an iterator sees it
+	count := 0
+	it := reader.Iterator([]byte{0})
+	defer it.Close()
+	for it.Valid() {
+		it.Next()
+		count++
+	}
+	if count != 1 {
+		t.Errorf("expected iterator to see 1, saw %d", count)
+	}
+
+	// add something after the reader was created
+	writer, err = s.Writer()
+	if err != nil {
+		t.Error(err)
+	}
+	err = writer.Set([]byte("b"), []byte("val-b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer.Close()
+
+	// ensure that a newer reader sees it
+	newReader, err := s.Reader()
+	if err != nil {
+		t.Error(err)
+	}
+	defer newReader.Close()
+	val, err = newReader.Get([]byte("b"))
+	if err != nil {
+		t.Error(err)
+	}
+	if !reflect.DeepEqual(val, []byte("val-b")) {
+		t.Errorf("expected val-b, got nil")
+	}
+
+	// ensure that the director iterator sees it
+	count = 0
+	it = newReader.Iterator([]byte{0})
+	defer it.Close()
+	for it.Valid() {
+		it.Next()
+		count++
+	}
+	if count != 2 {
+		t.Errorf("expected iterator to see 2, saw %d", count)
+	}
+
+	// but that the isolated reader does not
+	val, err = reader.Get([]byte("b"))
+	if err != nil {
+		t.Error(err)
+	}
+	if val != nil {
+		t.Errorf("expected nil, got %v", val)
+	}
+
+	// and ensure that the iterator on the isolated reader also does not
+	count = 0
+	it = reader.Iterator([]byte{0})
+	defer it.Close()
+	for it.Valid() {
+		it.Next()
+		count++
+	}
+	if count != 1 {
+		t.Errorf("expected iterator to see 1, saw %d", count)
+	}
+
+}
+
+func TestBatchExecuteContext(t *testing.T) {
+	s, err := StoreConstructor(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs := s.(*Store)
+
+	n := 2*executeGroupSize + executeGroupSize/2
+	fill := func(batch store.KVBatch) {
+		for i := 0; i < n; i++ {
+			batch.Set([]byte(fmt.Sprintf("k%05d", i)), []byte("v"))
+		}
+	}
+
+	// cancel once the first group is applied
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	batch := cs.NewBatch().(*Batch)
+	fill(batch)
+	var reports []int
+	batch.SetProgress(func(applied, total int) {
+		if total != n {
+			t.Errorf("expected total %d, got %d", n, total)
+		}
+		reports = append(reports, applied)
+		cancel()
+	})
+	err = batch.ExecuteContext(ctx)
+	if err != context.Canceled {
+		t.Errorf("expected context canceled, got %v", err)
+	}
+	if !reflect.DeepEqual(reports, []int{executeGroupSize}) {
+		t.Errorf("expected progress %v, got %v", []int{executeGroupSize}, reports)
+	}
+	if cs.Len() != executeGroupSize {
+		t.Errorf("expected %d entries applied, got %d", executeGroupSize, cs.Len())
+	}
+	for _, i := range []int{0, executeGroupSize - 1, executeGroupSize, n - 1} {
+		v, err := cs.Get([]byte(fmt.Sprintf("k%05d", i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (i < executeGroupSize) != (v != nil) {
+			t.Errorf("unexpected value %q for key %d", v, i)
+		}
+	}
+	err = batch.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cs.Len() != executeGroupSize {
+		t.Errorf("expected dropped mutations not to be applied, got %d entries", cs.Len())
+	}
+
+	// a context done before executing applies nothing
+	batch = cs.NewBatch().(*Batch)
+	batch.Set([]byte("a"), []byte("val-a"))
+	err = batch.ExecuteContext(ctx)
+	if err != context.Canceled {
+		t.Errorf("expected context canceled, got %v", err)
+	}
+	v, err := cs.Get([]byte("a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != nil {
+		t.Errorf("expected no value for a, got %q", v)
+	}
+
+	// a batch run to completion reports every group
+	batch = cs.NewBatch().(*Batch)
+	fill(batch)
+	reports = nil
+	batch.SetProgress(func(applied, total int) {
+		reports = append(reports, applied)
+	})
+	err = batch.ExecuteContext(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedReports := []int{executeGroupSize, 2 * executeGroupSize, n}
+	if !reflect.DeepEqual(reports, expectedReports) {
+		t.Errorf("expected progress %v, got %v", expectedReports, reports)
+	}
+	if cs.Len() != n {
+		t.Errorf("expected %d entries, got %d", n, cs.Len())
+	}
+
+	var _ store.KVContextBatch = batch
+}
+
+func TestConcurrentReaders(t *testing.T) {
+	kv, err := StoreConstructor(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := kv.(*Store)
+	ks, vs := benchmarkBatchKeys(1000)
+	batch := s.NewBatchSized(len(ks))
+	batch.SetMulti(ks, vs)
+	err = batch.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	var writer sync.WaitGroup
+	writer.Add(1)
+	go func() {
+		defer writer.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			k := []byte(fmt.Sprintf("other-%d", i%100))
+			if i%2 == 0 {
+				err := s.Set(k, k)
+				if err != nil {
+					t.Error(err)
+				}
+			} else {
+				batch := s.NewBatch()
+				batch.Delete(k)
+				err := batch.Execute()
+				if err != nil {
+					t.Error(err)
+				}
+			}
+		}
+	}()
+
+	var readers sync.WaitGroup
+	errs := make(chan error, 16)
+	for g := 0; g < 16; g++ {
+		readers.Add(1)
+		go func(g int) {
+			defer readers.Done()
+			for i := 0; i < 200; i++ {
+				j := (g*200 + i) % len(ks)
+				v, err := s.Get(ks[j])
+				if err != nil {
+					errs <- err
+					return
+				}
+				if !bytes.Equal(v, vs[j]) {
+					errs <- fmt.Errorf("expected %s for %s, got %s", vs[j], ks[j], v)
+					return
+				}
+				if i%50 != 0 {
+					continue
+				}
+
+				reader, err := s.Reader()
+				if err != nil {
+					errs <- err
+					return
+				}
+				count := 0
+				iter := reader.Iterator([]byte("key-"))
+				for ; iter.Valid() && bytes.HasPrefix(iter.Key(), []byte("key-")); iter.Next() {
+					count++
+				}
+				iter.Close()
+				reader.Close()
+				if count != len(ks) {
+					errs <- fmt.Errorf("expected %d keys, iterated %d", len(ks), count)
+					return
+				}
+			}
+		}(g)
+	}
+	readers.Wait()
+	close(done)
+	writer.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func BenchmarkConcurrentGet(b *testing.B) {
+	ks, vs := benchmarkBatchKeys(100000)
+	kv, _ := StoreConstructor(nil)
+	s := kv.(*Store)
+	batch := s.NewBatchSized(len(ks))
+	batch.SetMulti(ks, vs)
+	batch.Execute()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			s.Get(ks[i%len(ks)])
+			i++
+		}
+	})
+}
@@ -70,6 +70,11 @@ func (i *Store) deletelocked(key []byte) error {
 	return nil
 }
 
+// Len returns the number of entries in the store.
+func (i *Store) Len() int {
+	return i.list.Len()
+}
+
 func (i *Store) Close() error {
 	return nil
 }
@@ -9,6 +9,10 @@
 
 package store
 
+import (
+	"context"
+)
+
 type KVBatch interface {
 	Set(key, val []byte)
 	Delete(key []byte)
@@ -17,6 +21,16 @@ type KVBatch interface {
 	Close() error
 }
 
+// KVContextBatch is an optional extension of KVBatch for
+// batches able to stop executing when ctx is done.  The
+// mutations are applied in groups, and ctx is checked
+// between them: on cancellation the groups already applied
+// stay applied, the others are dropped, and ctx.Err() is
+// returned.  The batch is empty afterwards either way.
+type KVContextBatch interface {
+	ExecuteContext(ctx context.Context) error
+}
+
 type KVIterator interface {
 	SeekFirst()
 	Seek([]byte)
@@ -43,6 +57,25 @@ type KVWriter interface {
 	NewBatch() KVBatch
 }
 
+// KVCompareAndSetter is an optional extension of KVWriter for
+// stores able to atomically replace the value of a key only when
+// its current value equals expectedOld.  A nil expectedOld requires
+// the key to be absent, a nil val deletes the key.  A mismatch
+// returns false and no error.
+type KVCompareAndSetter interface {
+	CompareAndSet(key, expectedOld, val []byte) (bool, error)
+}
+
+// KVMultiReader is an optional extension of KVStore for
+// stores able to open several readers of one snapshot at
+// once, for a search reading from several goroutines.
+// Opening them together also keeps a write from coming
+// between them, when the write waits for open readers to
+// be closed.
+type KVMultiReader interface {
+	Readers(n int) ([]KVReader, error)
+}
+
 type KVReader interface {
 	Get(key []byte) ([]byte, error)
 	Iterator(key []byte) KVIterator
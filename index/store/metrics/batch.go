@@ -0,0 +1,44 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package metrics
+
+import (
+	"time"
+
+	"github.com/blevesearch/bleve/index/store"
+)
+
+type Batch struct {
+	store *Store
+	b     store.KVBatch
+}
+
+func (b *Batch) Set(key, val []byte) {
+	b.b.Set(key, val)
+}
+
+func (b *Batch) Delete(key []byte) {
+	b.b.Delete(key)
+}
+
+func (b *Batch) Merge(key []byte, oper store.AssociativeMerge) {
+	b.b.Merge(key, oper)
+}
+
+func (b *Batch) Execute() error {
+	start := time.Now()
+	err := b.b.Execute()
+	b.store.batchExecute.record(start, err)
+	return err
+}
+
+func (b *Batch) Close() error {
+	return b.b.Close()
+}
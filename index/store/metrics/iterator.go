@@ -0,0 +1,61 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package metrics
+
+import (
+	"sync/atomic"
+
+	"github.com/blevesearch/bleve/index/store"
+)
+
+type Iterator struct {
+	store *Store
+	it    store.KVIterator
+}
+
+func newIterator(s *Store, it store.KVIterator) *Iterator {
+	return &Iterator{
+		store: s,
+		it:    it,
+	}
+}
+
+func (i *Iterator) SeekFirst() {
+	i.it.SeekFirst()
+}
+
+func (i *Iterator) Seek(k []byte) {
+	i.it.Seek(k)
+}
+
+func (i *Iterator) Next() {
+	atomic.AddUint64(&i.store.iteratorNext, 1)
+	i.it.Next()
+}
+
+func (i *Iterator) Current() ([]byte, []byte, bool) {
+	return i.it.Current()
+}
+
+func (i *Iterator) Key() []byte {
+	return i.it.Key()
+}
+
+func (i *Iterator) Value() []byte {
+	return i.it.Value()
+}
+
+func (i *Iterator) Valid() bool {
+	return i.it.Valid()
+}
+
+func (i *Iterator) Close() error {
+	return i.it.Close()
+}
@@ -0,0 +1,54 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package metrics
+
+import (
+	"time"
+
+	"github.com/blevesearch/bleve/index/store"
+)
+
+type Reader struct {
+	store *Store
+	r     store.KVReader
+}
+
+func newReader(s *Store, r store.KVReader) *Reader {
+	return &Reader{
+		store: s,
+		r:     r,
+	}
+}
+
+func (r *Reader) Get(key []byte) ([]byte, error) {
+	return r.store.getFrom(r.r, key)
+}
+
+func (r *Reader) Iterator(key []byte) store.KVIterator {
+	return r.store.iteratorFrom(r.r, key)
+}
+
+func (r *Reader) Close() error {
+	return r.r.Close()
+}
+
+func (s *Store) getFrom(r store.KVReader, key []byte) ([]byte, error) {
+	start := time.Now()
+	val, err := r.Get(key)
+	s.get.record(start, err)
+	return val, err
+}
+
+func (s *Store) iteratorFrom(r store.KVReader, key []byte) store.KVIterator {
+	start := time.Now()
+	it := r.Iterator(key)
+	s.iterator.record(start, nil)
+	return newIterator(s, it)
+}
@@ -0,0 +1,97 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+// Package metrics provides a KVStore which wraps another KVStore,
+// counting and timing the operations performed on it.  The
+// accumulated stats are available as JSON through MarshalJSON.
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/blevesearch/bleve/index/store"
+	"github.com/blevesearch/bleve/registry"
+)
+
+const Name = "metrics"
+
+type Store struct {
+	inner store.KVStore
+
+	get          timer
+	set          timer
+	delete       timer
+	iterator     timer
+	batchExecute timer
+
+	iteratorNext uint64
+}
+
+// NewMetricsStore wraps inner, recording stats for every
+// operation performed through the returned store.
+func NewMetricsStore(inner store.KVStore) *Store {
+	return &Store{
+		inner: inner,
+	}
+}
+
+func (s *Store) Close() error {
+	return s.inner.Close()
+}
+
+func (s *Store) Reader() (store.KVReader, error) {
+	r, err := s.inner.Reader()
+	if err != nil {
+		return nil, err
+	}
+	return newReader(s, r), nil
+}
+
+func (s *Store) Writer() (store.KVWriter, error) {
+	w, err := s.inner.Writer()
+	if err != nil {
+		return nil, err
+	}
+	return newWriter(s, w), nil
+}
+
+func (s *Store) MarshalJSON() ([]byte, error) {
+	m := map[string]interface{}{}
+	m["get"] = &s.get
+	m["set"] = &s.set
+	m["delete"] = &s.delete
+	m["iterator"] = &s.iterator
+	m["iterator_next"] = atomic.LoadUint64(&s.iteratorNext)
+	m["batch_execute"] = &s.batchExecute
+	return json.Marshal(m)
+}
+
+// StoreConstructor builds the store named by the "store" config
+// entry, passing it the rest of the config, and wraps it.
+func StoreConstructor(config map[string]interface{}) (store.KVStore, error) {
+	name, ok := config["store"].(string)
+	if !ok || name == "" {
+		return nil, fmt.Errorf("must specify store")
+	}
+	innerConstructor := registry.KVStoreConstructorByName(name)
+	if innerConstructor == nil {
+		return nil, fmt.Errorf("no store named '%s' registered", name)
+	}
+	inner, err := innerConstructor(config)
+	if err != nil {
+		return nil, err
+	}
+	return NewMetricsStore(inner), nil
+}
+
+func init() {
+	registry.RegisterKVStore(Name, StoreConstructor)
+}
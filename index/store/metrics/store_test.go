@@ -0,0 +1,144 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package metrics
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"github.com/blevesearch/bleve/index/store/cznicb"
+)
+
+type addUint64Operator struct {
+	offset uint64
+}
+
+func (a *addUint64Operator) Merge(key, existing []byte) ([]byte, error) {
+	var existingUint64 uint64
+	if len(existing) > 0 {
+		existingUint64 = binary.LittleEndian.Uint64(existing)
+	}
+	result := make([]byte, 8)
+	binary.LittleEndian.PutUint64(result, existingUint64+a.offset)
+	return result, nil
+}
+
+func TestMetricsStore(t *testing.T) {
+	s, err := StoreConstructor(map[string]interface{}{
+		"store": cznicb.Name,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ms := s.(*Store)
+
+	writer, err := s.Writer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = writer.Set([]byte("a"), []byte("val-a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = writer.Set([]byte("b"), []byte("val-b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = writer.Delete([]byte("b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch := writer.NewBatch()
+	batch.Set([]byte("c"), []byte("val-c"))
+	batch.Merge([]byte("count"), &addUint64Operator{offset: 3})
+	err = batch.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch.Close()
+	writer.Close()
+
+	reader, err := s.Reader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	val, err := reader.Get([]byte("count"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if binary.LittleEndian.Uint64(val) != 3 {
+		t.Errorf("expected merged value 3, got %d", binary.LittleEndian.Uint64(val))
+	}
+	_, err = reader.Get([]byte("a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := 0
+	it := reader.Iterator([]byte{0})
+	for it.Valid() {
+		keys++
+		it.Next()
+	}
+	it.Close()
+	if keys != 3 {
+		t.Errorf("expected to iterate 3 keys, got %d", keys)
+	}
+	reader.Close()
+
+	expectedCounts := map[string]uint64{
+		"get":           2,
+		"set":           2,
+		"delete":        1,
+		"iterator":      1,
+		"batch_execute": 1,
+	}
+	statsBytes, err := json.Marshal(ms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stats map[string]json.RawMessage
+	err = json.Unmarshal(statsBytes, &stats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for op, expected := range expectedCounts {
+		var opStats struct {
+			Count     uint64            `json:"count"`
+			Errors    uint64            `json:"errors"`
+			Histogram map[string]uint64 `json:"histogram"`
+		}
+		err = json.Unmarshal(stats[op], &opStats)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if opStats.Count != expected {
+			t.Errorf("expected %d %s, got %d", expected, op, opStats.Count)
+		}
+		if opStats.Errors != 0 {
+			t.Errorf("expected no %s errors, got %d", op, opStats.Errors)
+		}
+		bucketed := uint64(0)
+		for _, n := range opStats.Histogram {
+			bucketed += n
+		}
+		if bucketed != expected {
+			t.Errorf("expected %d %s in histogram, got %d", expected, op, bucketed)
+		}
+	}
+	var next uint64
+	err = json.Unmarshal(stats["iterator_next"], &next)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next != 3 {
+		t.Errorf("expected 3 iterator_next, got %d", next)
+	}
+}
@@ -0,0 +1,65 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// upper bounds of the latency histogram buckets, a final
+// bucket counts everything slower
+var bucketBounds = []time.Duration{
+	time.Microsecond,
+	10 * time.Microsecond,
+	100 * time.Microsecond,
+	time.Millisecond,
+	10 * time.Millisecond,
+	100 * time.Millisecond,
+	time.Second,
+}
+
+type timer struct {
+	count     uint64
+	errors    uint64
+	totalTime uint64
+	buckets   [8]uint64
+}
+
+func (t *timer) record(start time.Time, err error) {
+	d := time.Since(start)
+	atomic.AddUint64(&t.count, 1)
+	if err != nil {
+		atomic.AddUint64(&t.errors, 1)
+	}
+	atomic.AddUint64(&t.totalTime, uint64(d))
+	i := 0
+	for i < len(bucketBounds) && d > bucketBounds[i] {
+		i++
+	}
+	atomic.AddUint64(&t.buckets[i], 1)
+}
+
+func (t *timer) MarshalJSON() ([]byte, error) {
+	histogram := map[string]uint64{}
+	for i, bound := range bucketBounds {
+		histogram[fmt.Sprintf("le_%s", bound)] = atomic.LoadUint64(&t.buckets[i])
+	}
+	histogram["gt_"+bucketBounds[len(bucketBounds)-1].String()] = atomic.LoadUint64(&t.buckets[len(bucketBounds)])
+
+	m := map[string]interface{}{}
+	m["count"] = atomic.LoadUint64(&t.count)
+	m["errors"] = atomic.LoadUint64(&t.errors)
+	m["total_time"] = atomic.LoadUint64(&t.totalTime)
+	m["histogram"] = histogram
+	return json.Marshal(m)
+}
@@ -0,0 +1,86 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package store
+
+import (
+	"bytes"
+)
+
+type prefixIterator struct {
+	it     KVIterator
+	prefix []byte
+}
+
+// PrefixIterator returns an iterator over the keys in r starting
+// with prefix.  The iterator is positioned at the first such key and
+// becomes invalid as soon as it reaches a key without the prefix.
+// An empty prefix iterates all keys.  No end key is computed, so
+// prefixes made of 0xff bytes need no special handling.
+func PrefixIterator(r KVReader, prefix []byte) KVIterator {
+	rv := &prefixIterator{
+		it:     r.Iterator(prefix),
+		prefix: prefix,
+	}
+	return rv
+}
+
+func (p *prefixIterator) SeekFirst() {
+	if len(p.prefix) == 0 {
+		p.it.SeekFirst()
+		return
+	}
+	p.it.Seek(p.prefix)
+}
+
+// Seek positions the iterator at the first key >= k, keys before
+// the prefix are never visited.
+func (p *prefixIterator) Seek(k []byte) {
+	if bytes.Compare(k, p.prefix) < 0 {
+		k = p.prefix
+	}
+	p.it.Seek(k)
+}
+
+func (p *prefixIterator) Next() {
+	p.it.Next()
+}
+
+func (p *prefixIterator) Current() ([]byte, []byte, bool) {
+	k, v, ok := p.it.Current()
+	if !ok || !bytes.HasPrefix(k, p.prefix) {
+		return nil, nil, false
+	}
+	return k, v, true
+}
+
+func (p *prefixIterator) Key() []byte {
+	k, _, ok := p.Current()
+	if !ok {
+		return nil
+	}
+	return k
+}
+
+func (p *prefixIterator) Value() []byte {
+	_, v, ok := p.Current()
+	if !ok {
+		return nil
+	}
+	return v
+}
+
+func (p *prefixIterator) Valid() bool {
+	_, _, ok := p.Current()
+	return ok
+}
+
+func (p *prefixIterator) Close() error {
+	return p.it.Close()
+}
@@ -0,0 +1,116 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package store_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/blevesearch/bleve/index/store"
+	"github.com/blevesearch/bleve/index/store/cznicb"
+	"github.com/blevesearch/bleve/index/store/gtreap"
+	"github.com/blevesearch/bleve/index/store/inmem"
+)
+
+func TestPrefixIterator(t *testing.T) {
+	constructors := map[string]func(map[string]interface{}) (store.KVStore, error){
+		"cznicb": cznicb.StoreConstructor,
+		"gtreap": gtreap.StoreConstructor,
+		"inmem":  inmem.StoreConstructor,
+	}
+
+	keys := []string{
+		"a",
+		"ba",
+		"bb",
+		"bc",
+		"c",
+		"\xff",
+		"\xff\xff",
+		"\xff\xff\x01",
+	}
+
+	tests := []struct {
+		prefix   string
+		expected []string
+	}{
+		{
+			prefix:   "b",
+			expected: []string{"ba", "bb", "bc"},
+		},
+		{
+			prefix:   "bb",
+			expected: []string{"bb"},
+		},
+		{
+			prefix:   "d",
+			expected: []string{},
+		},
+		{
+			prefix:   "",
+			expected: keys,
+		},
+		{
+			prefix:   "\xff\xff",
+			expected: []string{"\xff\xff", "\xff\xff\x01"},
+		},
+	}
+
+	for name, constructor := range constructors {
+		s, err := constructor(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writer, err := s.Writer()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, k := range keys {
+			err = writer.Set([]byte(k), []byte("val-"+k))
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		writer.Close()
+
+		reader, err := s.Reader()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, test := range tests {
+			it := store.PrefixIterator(reader, []byte(test.prefix))
+			actual := []string{}
+			for it.Valid() {
+				k, v, _ := it.Current()
+				if string(v) != "val-"+string(k) {
+					t.Errorf("%s: expected value val-%s, got %s", name, k, v)
+				}
+				actual = append(actual, string(k))
+				it.Next()
+			}
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("%s: prefix %q expected %q, got %q", name, test.prefix, test.expected, actual)
+			}
+
+			// seeking before the prefix stays within it
+			it.Seek([]byte{})
+			if len(test.expected) > 0 {
+				if string(it.Key()) != test.expected[0] {
+					t.Errorf("%s: prefix %q expected seek to %q, got %q", name, test.prefix, test.expected[0], it.Key())
+				}
+			} else if it.Valid() {
+				t.Errorf("%s: prefix %q expected seek to be invalid, got %q", name, test.prefix, it.Key())
+			}
+			it.Close()
+		}
+		reader.Close()
+		s.Close()
+	}
+}
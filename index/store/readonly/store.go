@@ -0,0 +1,81 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+// Package readonly provides a KVStore which wraps another KVStore,
+// letting it be read but failing every write with ErrReadOnly.  The
+// writers it returns read from a reader of the wrapped store, so
+// they never wait for, or hold, the write lock of the wrapped store.
+package readonly
+
+import (
+	"fmt"
+
+	"github.com/blevesearch/bleve/index/store"
+	"github.com/blevesearch/bleve/registry"
+)
+
+const Name = "readonly"
+
+// ErrReadOnly is returned by the writes to a Store.
+var ErrReadOnly = fmt.Errorf("store is read-only")
+
+type Store struct {
+	inner store.KVStore
+}
+
+// NewReadOnlyStore wraps inner, failing the writes to it.
+func NewReadOnlyStore(inner store.KVStore) *Store {
+	return &Store{
+		inner: inner,
+	}
+}
+
+func (s *Store) Close() error {
+	return s.inner.Close()
+}
+
+func (s *Store) Reader() (store.KVReader, error) {
+	return s.inner.Reader()
+}
+
+func (s *Store) Writer() (store.KVWriter, error) {
+	r, err := s.inner.Reader()
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{r: r}, nil
+}
+
+// StoreConstructor builds the store named by the "store" config
+// entry, passing it the rest of the config with "read_only" set,
+// and wraps it.
+func StoreConstructor(config map[string]interface{}) (store.KVStore, error) {
+	name, ok := config["store"].(string)
+	if !ok || name == "" {
+		return nil, fmt.Errorf("must specify store")
+	}
+	innerConstructor := registry.KVStoreConstructorByName(name)
+	if innerConstructor == nil {
+		return nil, fmt.Errorf("no store named '%s' registered", name)
+	}
+	innerConfig := make(map[string]interface{}, len(config))
+	for k, v := range config {
+		innerConfig[k] = v
+	}
+	innerConfig["read_only"] = true
+	inner, err := innerConstructor(innerConfig)
+	if err != nil {
+		return nil, err
+	}
+	return NewReadOnlyStore(inner), nil
+}
+
+func init() {
+	registry.RegisterKVStore(Name, StoreConstructor)
+}
@@ -0,0 +1,81 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package readonly
+
+import (
+	"testing"
+
+	"github.com/blevesearch/bleve/index/store/cznicb"
+)
+
+func TestReadOnlyStore(t *testing.T) {
+	inner, err := cznicb.StoreConstructor(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	innerWriter, err := inner.Writer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = innerWriter.Set([]byte("a"), []byte("val-a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	innerWriter.Close()
+
+	s := NewReadOnlyStore(inner)
+	defer s.Close()
+
+	reader, err := s.Reader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	val, err := reader.Get([]byte("a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(val) != "val-a" {
+		t.Errorf("expected val-a, got %q", val)
+	}
+	reader.Close()
+
+	writer, err := s.Writer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.Close()
+	val, err = writer.Get([]byte("a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(val) != "val-a" {
+		t.Errorf("expected writer to read val-a, got %q", val)
+	}
+	if err = writer.Set([]byte("b"), []byte("val-b")); err != ErrReadOnly {
+		t.Errorf("expected set to fail with %v, got %v", ErrReadOnly, err)
+	}
+	if err = writer.Delete([]byte("a")); err != ErrReadOnly {
+		t.Errorf("expected delete to fail with %v, got %v", ErrReadOnly, err)
+	}
+	batch := writer.NewBatch()
+	batch.Set([]byte("b"), []byte("val-b"))
+	if err = batch.Execute(); err != ErrReadOnly {
+		t.Errorf("expected batch to fail with %v, got %v", ErrReadOnly, err)
+	}
+	batch.Close()
+
+	val, err = writer.Get([]byte("b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val != nil {
+		t.Errorf("expected b not to be written, got %q", val)
+	}
+}
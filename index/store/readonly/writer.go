@@ -0,0 +1,61 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package readonly
+
+import (
+	"github.com/blevesearch/bleve/index/store"
+)
+
+// Writer reads from a reader of the wrapped store, and fails
+// the writes.
+type Writer struct {
+	r store.KVReader
+}
+
+func (w *Writer) Get(key []byte) ([]byte, error) {
+	return w.r.Get(key)
+}
+
+func (w *Writer) Iterator(key []byte) store.KVIterator {
+	return w.r.Iterator(key)
+}
+
+func (w *Writer) Set(key, val []byte) error {
+	return ErrReadOnly
+}
+
+func (w *Writer) Delete(key []byte) error {
+	return ErrReadOnly
+}
+
+func (w *Writer) NewBatch() store.KVBatch {
+	return &Batch{}
+}
+
+func (w *Writer) Close() error {
+	return w.r.Close()
+}
+
+// Batch ignores its mutations, and fails when executed.
+type Batch struct{}
+
+func (b *Batch) Set(key, val []byte) {}
+
+func (b *Batch) Delete(key []byte) {}
+
+func (b *Batch) Merge(key []byte, oper store.AssociativeMerge) {}
+
+func (b *Batch) Execute() error {
+	return ErrReadOnly
+}
+
+func (b *Batch) Close() error {
+	return nil
+}
@@ -0,0 +1,89 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package wal
+
+import (
+	"github.com/blevesearch/bleve/index/store"
+)
+
+// Batch collects the mutations of a batch, logs them as
+// one record when it is executed, and then applies them
+// with a batch of the inner store.
+type Batch struct {
+	w      *Writer
+	ops    []op
+	keys   [][]byte
+	merges map[string]store.AssociativeMergeChain
+}
+
+func newBatch(w *Writer) *Batch {
+	return &Batch{
+		w:      w,
+		merges: make(map[string]store.AssociativeMergeChain),
+	}
+}
+
+func (b *Batch) Set(key, val []byte) {
+	b.ops = append(b.ops, op{kind: opSet, key: key, val: val})
+}
+
+func (b *Batch) Delete(key []byte) {
+	b.ops = append(b.ops, op{kind: opDelete, key: key})
+}
+
+func (b *Batch) Merge(key []byte, oper store.AssociativeMerge) {
+	opers, ok := b.merges[string(key)]
+	if !ok {
+		b.keys = append(b.keys, key)
+	}
+	b.merges[string(key)] = append(opers, oper)
+}
+
+// Execute resolves the merges against the values of the
+// inner store, before the other mutations as the inmem
+// store does, so the record only holds sets and deletes.
+func (b *Batch) Execute() error {
+	ops := make([]op, 0, len(b.keys)+len(b.ops))
+	for _, key := range b.keys {
+		val, err := b.w.w.Get(key)
+		if err != nil {
+			return err
+		}
+		val, err = b.merges[string(key)].Merge(key, val)
+		if err != nil {
+			return err
+		}
+		if val == nil {
+			ops = append(ops, op{kind: opDelete, key: key})
+		} else {
+			ops = append(ops, op{kind: opSet, key: key, val: val})
+		}
+	}
+	ops = append(ops, b.ops...)
+	b.reset()
+	if len(ops) == 0 {
+		return nil
+	}
+	err := b.w.store.append(ops)
+	if err != nil {
+		return err
+	}
+	return applyOps(b.w.w, ops)
+}
+
+func (b *Batch) reset() {
+	b.ops = nil
+	b.keys = nil
+	b.merges = make(map[string]store.AssociativeMergeChain)
+}
+
+func (b *Batch) Close() error {
+	return nil
+}
@@ -0,0 +1,210 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package wal
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+
+	"github.com/blevesearch/bleve/index/store"
+)
+
+// A record of the log holds the mutations of one write:
+//
+//	length   uint32, little endian, of the payload
+//	checksum uint32, little endian, CRC-32C of the payload
+//	payload  uvarint count of mutations, then for each one
+//	         a kind byte, the uvarint length and bytes of the
+//	         key, and for a set the uvarint length and bytes
+//	         of the value
+const recordHeaderLen = 8
+
+const (
+	opSet    byte = 0
+	opDelete byte = 1
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+type op struct {
+	kind byte
+	key  []byte
+	val  []byte
+}
+
+func encodeRecord(ops []op) []byte {
+	payload := make([]byte, binary.MaxVarintLen64)
+	payload = payload[:binary.PutUvarint(payload, uint64(len(ops)))]
+	buf := make([]byte, binary.MaxVarintLen64)
+	for _, o := range ops {
+		payload = append(payload, o.kind)
+		payload = append(payload, buf[:binary.PutUvarint(buf, uint64(len(o.key)))]...)
+		payload = append(payload, o.key...)
+		if o.kind == opSet {
+			payload = append(payload, buf[:binary.PutUvarint(buf, uint64(len(o.val)))]...)
+			payload = append(payload, o.val...)
+		}
+	}
+	rv := make([]byte, recordHeaderLen, recordHeaderLen+len(payload))
+	binary.LittleEndian.PutUint32(rv[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(rv[4:8], crc32.Checksum(payload, crcTable))
+	return append(rv, payload...)
+}
+
+func decodePayload(payload []byte) ([]op, error) {
+	count, n := binary.Uvarint(payload)
+	if n <= 0 {
+		return nil, fmt.Errorf("invalid mutation count in log record")
+	}
+	payload = payload[n:]
+	readBytes := func() ([]byte, error) {
+		l, n := binary.Uvarint(payload)
+		if n <= 0 || uint64(len(payload)-n) < l {
+			return nil, fmt.Errorf("invalid length in log record")
+		}
+		rv := payload[n : n+int(l)]
+		payload = payload[n+int(l):]
+		return rv, nil
+	}
+	rv := make([]op, 0, count)
+	for uint64(len(rv)) < count {
+		if len(payload) < 1 {
+			return nil, fmt.Errorf("truncated log record")
+		}
+		o := op{kind: payload[0]}
+		payload = payload[1:]
+		var err error
+		o.key, err = readBytes()
+		if err != nil {
+			return nil, err
+		}
+		switch o.kind {
+		case opSet:
+			o.val, err = readBytes()
+			if err != nil {
+				return nil, err
+			}
+		case opDelete:
+		default:
+			return nil, fmt.Errorf("unknown mutation kind %d in log record", o.kind)
+		}
+		rv = append(rv, o)
+	}
+	return rv, nil
+}
+
+// readRecord reads the next record of the log.  It returns
+// nil ops at the end of the log, or when the rest of it is
+// not a complete record with a valid checksum.
+func readRecord(r io.Reader) ([]op, int64, error) {
+	header := make([]byte, recordHeaderLen)
+	_, err := io.ReadFull(r, header)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	payload := make([]byte, binary.LittleEndian.Uint32(header[0:4]))
+	_, err = io.ReadFull(r, payload)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	if crc32.Checksum(payload, crcTable) != binary.LittleEndian.Uint32(header[4:8]) {
+		return nil, 0, nil
+	}
+	ops, err := decodePayload(payload)
+	if err != nil {
+		return nil, 0, err
+	}
+	return ops, int64(recordHeaderLen + len(payload)), nil
+}
+
+// replay applies the records of the log to the inner
+// store, and truncates the log after the last complete
+// record.
+func (s *Store) replay() error {
+	_, err := s.log.Seek(0, os.SEEK_SET)
+	if err != nil {
+		return err
+	}
+	r := bufio.NewReader(s.log)
+	var offset int64
+	for {
+		ops, n, err := readRecord(r)
+		if err != nil {
+			return err
+		}
+		if ops == nil {
+			break
+		}
+		err = s.applyInner(ops)
+		if err != nil {
+			return err
+		}
+		offset += n
+	}
+	err = s.log.Truncate(offset)
+	if err != nil {
+		return err
+	}
+	_, err = s.log.Seek(offset, os.SEEK_SET)
+	if err != nil {
+		return err
+	}
+	s.offset = offset
+	return s.log.Sync()
+}
+
+func (s *Store) applyInner(ops []op) error {
+	w, err := s.inner.Writer()
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+	return applyOps(w, ops)
+}
+
+func applyOps(w store.KVWriter, ops []op) error {
+	batch := w.NewBatch()
+	defer batch.Close()
+	for _, o := range ops {
+		if o.kind == opDelete {
+			batch.Delete(o.key)
+		} else {
+			batch.Set(o.key, o.val)
+		}
+	}
+	return batch.Execute()
+}
+
+// append writes a record of the mutations to the log and
+// syncs it.  A failed write is truncated from the log.
+func (s *Store) append(ops []op) error {
+	record := encodeRecord(ops)
+	_, err := s.log.Write(record)
+	if err == nil {
+		err = s.log.Sync()
+	}
+	if err != nil {
+		s.log.Truncate(s.offset)
+		s.log.Seek(s.offset, os.SEEK_SET)
+		return err
+	}
+	s.offset += int64(len(record))
+	return nil
+}
@@ -0,0 +1,124 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+// Package wal provides a KVStore which wraps another KVStore,
+// appending the mutations of each write to a log file, synced to
+// disk, before applying them to the wrapped store.  When the store
+// is opened again the log is replayed into the wrapped store, which
+// gives an in-memory store, such as cznicb, the durability of the
+// log.
+//
+// Merges are resolved against the current values before they are
+// logged, so the log only holds sets and deletes, and replaying it
+// twice is harmless.  The log keeps every mutation, it is never
+// truncated except to drop an incomplete last record.
+package wal
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/blevesearch/bleve/index/store"
+	"github.com/blevesearch/bleve/registry"
+)
+
+const Name = "wal"
+
+type Store struct {
+	inner  store.KVStore
+	log    *os.File
+	offset int64
+	writer sync.Mutex
+}
+
+// NewWALStore wraps inner, logging its mutations to the file at
+// logPath.  The mutations already in the file are applied to inner
+// first.  A last record which was not fully written, or fails its
+// checksum, is the trace of a write interrupted by a crash: it was
+// never applied, and is truncated from the log.
+func NewWALStore(inner store.KVStore, logPath string) (*Store, error) {
+	log, err := os.OpenFile(logPath, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	rv := Store{
+		inner: inner,
+		log:   log,
+	}
+	err = rv.replay()
+	if err != nil {
+		log.Close()
+		return nil, err
+	}
+	return &rv, nil
+}
+
+func (s *Store) Close() error {
+	err := s.log.Close()
+	innerErr := s.inner.Close()
+	if err != nil {
+		return err
+	}
+	return innerErr
+}
+
+func (s *Store) Reader() (store.KVReader, error) {
+	return s.inner.Reader()
+}
+
+// Writer returns a writer logging its mutations.  Only
+// one writer is open at a time, so the log records the
+// mutations in the order they are applied.
+func (s *Store) Writer() (store.KVWriter, error) {
+	s.writer.Lock()
+	w, err := s.inner.Writer()
+	if err != nil {
+		s.writer.Unlock()
+		return nil, err
+	}
+	return newWriter(s, w), nil
+}
+
+// StoreConstructor builds the store named by the "store" config
+// entry, passing it the rest of the config, and wraps it logging
+// to the file named by the "log_path" config entry, by default
+// the "path" config entry with a ".wal" extension.
+func StoreConstructor(config map[string]interface{}) (store.KVStore, error) {
+	name, ok := config["store"].(string)
+	if !ok || name == "" {
+		return nil, fmt.Errorf("must specify store")
+	}
+	innerConstructor := registry.KVStoreConstructorByName(name)
+	if innerConstructor == nil {
+		return nil, fmt.Errorf("no store named '%s' registered", name)
+	}
+	logPath, ok := config["log_path"].(string)
+	if !ok || logPath == "" {
+		path, ok := config["path"].(string)
+		if !ok || path == "" {
+			return nil, fmt.Errorf("must specify log_path")
+		}
+		logPath = path + ".wal"
+	}
+	inner, err := innerConstructor(config)
+	if err != nil {
+		return nil, err
+	}
+	rv, err := NewWALStore(inner, logPath)
+	if err != nil {
+		inner.Close()
+		return nil, err
+	}
+	return rv, nil
+}
+
+func init() {
+	registry.RegisterKVStore(Name, StoreConstructor)
+}
@@ -0,0 +1,324 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package wal
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/blevesearch/bleve/index/store"
+	"github.com/blevesearch/bleve/index/store/cznicb"
+)
+
+func tempLogPath(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "wal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Join(dir, "store.wal"), func() { os.RemoveAll(dir) }
+}
+
+// openStore opens the log with an empty inner store, as a
+// process restarting with an in-memory store would.
+func openStore(t *testing.T, logPath string) (*Store, store.KVStore) {
+	inner, err := cznicb.StoreConstructor(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewWALStore(inner, logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, inner
+}
+
+func expectValues(t *testing.T, s store.KVStore, expected map[string]string) {
+	reader, err := s.Reader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	for k, v := range expected {
+		val, err := reader.Get([]byte(k))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v == "" {
+			if val != nil {
+				t.Errorf("expected %s deleted, got %q", k, val)
+			}
+		} else if string(val) != v {
+			t.Errorf("expected %s = %q, got %q", k, v, val)
+		}
+	}
+}
+
+func TestWALStore(t *testing.T) {
+	logPath, cleanup := tempLogPath(t)
+	defer cleanup()
+
+	s, err := StoreConstructor(map[string]interface{}{
+		"store":    cznicb.Name,
+		"log_path": logPath,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	CommonTestKVStore(t, s)
+	err = s.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWALStoreRecoversAfterCrash(t *testing.T) {
+	logPath, cleanup := tempLogPath(t)
+	defer cleanup()
+
+	s, _ := openStore(t, logPath)
+	writer, err := s.Writer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = writer.Set([]byte("a"), []byte("val-a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch := writer.NewBatch()
+	batch.Set([]byte("b"), []byte("val-b"))
+	batch.Set([]byte("c"), []byte("val-c"))
+	batch.Delete([]byte("a"))
+	err = batch.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch.Close()
+	writer.Close()
+
+	// the store is never closed, the process crashed
+	recovered, inner := openStore(t, logPath)
+	defer recovered.Close()
+	expected := map[string]string{"a": "", "b": "val-b", "c": "val-c"}
+	expectValues(t, recovered, expected)
+	expectValues(t, inner, expected)
+}
+
+func TestWALStoreTruncatesIncompleteRecord(t *testing.T) {
+	logPath, cleanup := tempLogPath(t)
+	defer cleanup()
+
+	s, _ := openStore(t, logPath)
+	writer, err := s.Writer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = writer.Set([]byte("a"), []byte("val-a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer.Close()
+	s.Close()
+	info, err := os.Stat(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	committed := info.Size()
+
+	// the crash came in the middle of writing a record
+	record := encodeRecord([]op{{kind: opSet, key: []byte("b"), val: []byte("val-b")}})
+	torn := func(tail []byte) {
+		f, err := os.OpenFile(logPath, os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = f.Write(tail)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+	}
+	torn(record[:len(record)-2])
+
+	s, _ = openStore(t, logPath)
+	expectValues(t, s, map[string]string{"a": "val-a", "b": ""})
+	info, err = os.Stat(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() != committed {
+		t.Errorf("expected log truncated to %d bytes, got %d", committed, info.Size())
+	}
+	writer, err = s.Writer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = writer.Set([]byte("c"), []byte("val-c"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer.Close()
+	s.Close()
+
+	// a complete record with a corrupt payload is dropped too
+	corrupt := append([]byte(nil), record...)
+	corrupt[len(corrupt)-1] ^= 0xff
+	torn(corrupt)
+
+	s, _ = openStore(t, logPath)
+	defer s.Close()
+	expectValues(t, s, map[string]string{"a": "val-a", "b": "", "c": "val-c"})
+}
+
+type addUint64Operator struct {
+	offset uint64
+}
+
+func (a *addUint64Operator) Merge(key, existing []byte) ([]byte, error) {
+	var existingUint64 uint64
+	if len(existing) > 0 {
+		existingUint64 = binary.LittleEndian.Uint64(existing)
+	}
+	result := make([]byte, 8)
+	binary.LittleEndian.PutUint64(result, existingUint64+a.offset)
+	return result, nil
+}
+
+func TestWALStoreMergeReplay(t *testing.T) {
+	logPath, cleanup := tempLogPath(t)
+	defer cleanup()
+
+	s, _ := openStore(t, logPath)
+	writer, err := s.Writer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		batch := writer.NewBatch()
+		batch.Merge([]byte("count"), &addUint64Operator{offset: 5})
+		batch.Merge([]byte("count"), &addUint64Operator{offset: 1})
+		err = batch.Execute()
+		if err != nil {
+			t.Fatal(err)
+		}
+		batch.Close()
+	}
+	writer.Close()
+
+	expectCount(t, s, 18)
+
+	recovered, _ := openStore(t, logPath)
+	defer recovered.Close()
+	expectCount(t, recovered, 18)
+}
+
+func expectCount(t *testing.T, s store.KVStore, expected uint64) {
+	reader, err := s.Reader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	val, err := reader.Get([]byte("count"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if binary.LittleEndian.Uint64(val) != expected {
+		t.Errorf("expected %d, got %d", expected, binary.LittleEndian.Uint64(val))
+	}
+}
+
+func CommonTestKVStore(t *testing.T, s store.KVStore) {
+
+	writer, err := s.Writer()
+	if err != nil {
+		t.Error(err)
+	}
+	err = writer.Set([]byte("a"), []byte("val-a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = writer.Set([]byte("z"), []byte("val-z"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = writer.Delete([]byte("z"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	batch := writer.NewBatch()
+	batch.Set([]byte("b"), []byte("val-b"))
+	batch.Set([]byte("c"), []byte("val-c"))
+	batch.Set([]byte("d"), []byte("val-d"))
+	batch.Set([]byte("e"), []byte("val-e"))
+	batch.Set([]byte("f"), []byte("val-f"))
+	batch.Set([]byte("g"), []byte("val-g"))
+	batch.Set([]byte("h"), []byte("val-h"))
+	batch.Set([]byte("i"), []byte("val-i"))
+	batch.Set([]byte("j"), []byte("val-j"))
+
+	err = batch.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer.Close()
+
+	reader, err := s.Reader()
+	if err != nil {
+		t.Error(err)
+	}
+	defer reader.Close()
+	v, err := reader.Get([]byte("z"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != nil {
+		t.Errorf("expected nil for deleted key, got %s", v)
+	}
+	it := reader.Iterator([]byte("b"))
+	key, val, valid := it.Current()
+	if !valid {
+		t.Fatalf("valid false, expected true")
+	}
+	if string(key) != "b" {
+		t.Fatalf("expected key b, got %s", key)
+	}
+	if string(val) != "val-b" {
+		t.Fatalf("expected value val-b, got %s", val)
+	}
+
+	it.Next()
+	key, val, valid = it.Current()
+	if !valid {
+		t.Fatalf("valid false, expected true")
+	}
+	if string(key) != "c" {
+		t.Fatalf("expected key c, got %s", key)
+	}
+	if string(val) != "val-c" {
+		t.Fatalf("expected value val-c, got %s", val)
+	}
+
+	it.Seek([]byte("i"))
+	key, val, valid = it.Current()
+	if !valid {
+		t.Fatalf("valid false, expected true")
+	}
+	if string(key) != "i" {
+		t.Fatalf("expected key i, got %s", key)
+	}
+	if string(val) != "val-i" {
+		t.Fatalf("expected value val-i, got %s", val)
+	}
+
+	it.Close()
+}
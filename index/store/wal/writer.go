@@ -0,0 +1,59 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package wal
+
+import (
+	"github.com/blevesearch/bleve/index/store"
+)
+
+type Writer struct {
+	store *Store
+	w     store.KVWriter
+}
+
+func newWriter(s *Store, w store.KVWriter) *Writer {
+	return &Writer{
+		store: s,
+		w:     w,
+	}
+}
+
+func (w *Writer) Get(key []byte) ([]byte, error) {
+	return w.w.Get(key)
+}
+
+func (w *Writer) Iterator(key []byte) store.KVIterator {
+	return w.w.Iterator(key)
+}
+
+func (w *Writer) Set(key, val []byte) error {
+	err := w.store.append([]op{{kind: opSet, key: key, val: val}})
+	if err != nil {
+		return err
+	}
+	return w.w.Set(key, val)
+}
+
+func (w *Writer) Delete(key []byte) error {
+	err := w.store.append([]op{{kind: opDelete, key: key}})
+	if err != nil {
+		return err
+	}
+	return w.w.Delete(key)
+}
+
+func (w *Writer) NewBatch() store.KVBatch {
+	return newBatch(w)
+}
+
+func (w *Writer) Close() error {
+	defer w.store.writer.Unlock()
+	return w.w.Close()
+}
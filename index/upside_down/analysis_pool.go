@@ -10,6 +10,7 @@
 package upside_down
 
 import (
+	"github.com/blevesearch/bleve/analysis"
 	"github.com/blevesearch/bleve/document"
 )
 
@@ -47,6 +48,16 @@ func AnalysisWorker(q AnalysisQueue) {
 		// track our back index entries
 		backIndexTermEntries := make([]*BackIndexTermEntry, 0)
 		backIndexStoredEntries := make([]*BackIndexStoreEntry, 0)
+		fieldLengths := make(map[uint16]uint64)
+		fieldNames := make(map[string]bool)
+		// values of a field in different array positions share
+		// its term frequency rows
+		fieldTokenFreqs := make(map[uint16]analysis.TokenFrequencies)
+		// last token position of each field, the positions of
+		// its next value follow it after the gap of that value
+		fieldLastPositions := make(map[uint16]int)
+		indexedFields := make(map[uint16]document.Field)
+		indexedFieldOrder := make([]uint16, 0)
 
 		for _, field := range w.d.Fields {
 			fieldIndex, newFieldRow := w.udc.fieldIndexCache.FieldIndex(field.Name())
@@ -57,16 +68,29 @@ func AnalysisWorker(q AnalysisQueue) {
 			if field.Options().IsIndexed() {
 
 				fieldLength, tokenFreqs := field.Analyze()
+				fieldLengths[fieldIndex] += uint64(fieldLength)
+				if len(tokenFreqs) > 0 {
+					fieldNames[field.Name()] = true
+				}
+				lastPosition, seen := fieldLastPositions[fieldIndex]
+				if gapper, ok := field.(positionIncrementGapper); ok && seen && gapper.PositionIncrementGap() > 0 {
+					shiftPositions(tokenFreqs, lastPosition+gapper.PositionIncrementGap())
+				}
+				fieldLastPositions[fieldIndex] = maxPosition(tokenFreqs, lastPosition)
 
 				// see if any of the composite fields need this
 				for _, compositeField := range w.d.CompositeFields {
 					compositeField.Compose(field.Name(), fieldLength, tokenFreqs)
 				}
 
-				// encode this field
-				indexRows, indexBackIndexTermEntries := w.udc.indexField(w.d.ID, field, fieldIndex, fieldLength, tokenFreqs)
-				rv.rows = append(rv.rows, indexRows...)
-				backIndexTermEntries = append(backIndexTermEntries, indexBackIndexTermEntries...)
+				existingTokenFreqs, ok := fieldTokenFreqs[fieldIndex]
+				if ok {
+					fieldTokenFreqs[fieldIndex] = existingTokenFreqs.MergeAll(field.Name(), tokenFreqs)
+				} else {
+					fieldTokenFreqs[fieldIndex] = tokenFreqs
+					indexedFields[fieldIndex] = field
+					indexedFieldOrder = append(indexedFieldOrder, fieldIndex)
+				}
 			}
 
 			if field.Options().IsStored() {
@@ -77,6 +101,13 @@ func AnalysisWorker(q AnalysisQueue) {
 
 		}
 
+		// encode the indexed fields
+		for _, fieldIndex := range indexedFieldOrder {
+			indexRows, indexBackIndexTermEntries := w.udc.indexField(w.d.ID, indexedFields[fieldIndex], fieldIndex, int(fieldLengths[fieldIndex]), fieldTokenFreqs[fieldIndex])
+			rv.rows = append(rv.rows, indexRows...)
+			backIndexTermEntries = append(backIndexTermEntries, indexBackIndexTermEntries...)
+		}
+
 		// now index the composite fields
 		for _, compositeField := range w.d.CompositeFields {
 			fieldIndex, newFieldRow := w.udc.fieldIndexCache.FieldIndex(compositeField.Name())
@@ -85,6 +116,10 @@ func AnalysisWorker(q AnalysisQueue) {
 			}
 			if compositeField.Options().IsIndexed() {
 				fieldLength, tokenFreqs := compositeField.Analyze()
+				fieldLengths[fieldIndex] += uint64(fieldLength)
+				if len(tokenFreqs) > 0 {
+					fieldNames[compositeField.Name()] = true
+				}
 				// encode this field
 				indexRows, indexBackIndexTermEntries := w.udc.indexField(w.d.ID, compositeField, fieldIndex, fieldLength, tokenFreqs)
 				rv.rows = append(rv.rows, indexRows...)
@@ -92,10 +127,45 @@ func AnalysisWorker(q AnalysisQueue) {
 			}
 		}
 
+		// record which fields produced terms
+		if len(fieldNames) > 0 {
+			indexRows, indexBackIndexTermEntries := w.udc.indexFieldNames(w.d.ID, fieldNames)
+			rv.rows = append(rv.rows, indexRows...)
+			backIndexTermEntries = append(backIndexTermEntries, indexBackIndexTermEntries...)
+		}
+
 		// build the back index row
 		backIndexRow := NewBackIndexRow(w.d.ID, backIndexTermEntries, backIndexStoredEntries)
+		backIndexRow.fieldLengths = newBackIndexFieldLengthEntries(fieldLengths)
 		rv.rows = append(rv.rows, backIndexRow)
 
 		w.rc <- rv
 	}
 }
+
+// positionIncrementGapper is implemented by the fields
+// whose token positions continue after those of the
+// previous value of the same field, past a gap.  Without
+// a gap the positions of each value start over.
+type positionIncrementGapper interface {
+	PositionIncrementGap() int
+}
+
+func shiftPositions(tokenFreqs analysis.TokenFrequencies, offset int) {
+	for _, tf := range tokenFreqs {
+		for _, l := range tf.Locations {
+			l.Position += offset
+		}
+	}
+}
+
+func maxPosition(tokenFreqs analysis.TokenFrequencies, max int) int {
+	for _, tf := range tokenFreqs {
+		for _, l := range tf.Locations {
+			if l.Position > max {
+				max = l.Position
+			}
+		}
+	}
+	return max
+}
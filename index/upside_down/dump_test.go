@@ -73,15 +73,17 @@ func TestDump(t *testing.T) {
 	for _ = range fieldsRows {
 		fieldsCount++
 	}
-	if fieldsCount != 3 {
-		t.Errorf("expected 3 fields, got %d", fieldsCount)
+	// 3 fields and the field names field
+	if fieldsCount != 4 {
+		t.Errorf("expected 4 fields, got %d", fieldsCount)
 	}
 
 	// 1 text term
 	// 16 numeric terms
 	// 16 date terms
 	// 3 stored fields
-	expectedDocRowCount := int(1 + (2 * (64 / document.DefaultPrecisionStep)) + 3)
+	// 3 field name terms
+	expectedDocRowCount := int(1 + (2 * (64 / document.DefaultPrecisionStep)) + 3 + 3)
 	docRowCount := 0
 	docRows := idx.DumpDoc("1")
 	for _ = range docRows {
@@ -107,7 +109,8 @@ func TestDump(t *testing.T) {
 	// 2 text term row count (2 different text terms)
 	// 16 numeric term row counts (shared for both docs, same numeric value)
 	// 16 date term row counts (shared for both docs, same date value)
-	expectedAllRowCount := int(1 + fieldsCount + (2 * expectedDocRowCount) + 2 + 2 + int((2 * (64 / document.DefaultPrecisionStep))))
+	// 3 field name term row counts (shared for both docs)
+	expectedAllRowCount := int(1 + fieldsCount + (2 * expectedDocRowCount) + 2 + 2 + int((2 * (64 / document.DefaultPrecisionStep))) + 3)
 	allRowCount := 0
 	allRows := idx.DumpAll()
 	for _ = range allRows {
@@ -0,0 +1,125 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package upside_down
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/blevesearch/bleve/index"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// FieldLengths tracks the field statistics of the
+// index.  The length of each field of a document is
+// recorded in its back index row, and like the doc
+// count the totals are kept in memory, rebuilt from
+// the back index rows when the index is opened.
+type FieldLengths struct {
+	// stats is replaced, never modified, so readers
+	// can keep the one they were opened with
+	stats map[uint16]index.FieldStats
+	mutex sync.RWMutex
+}
+
+func NewFieldLengths() *FieldLengths {
+	return &FieldLengths{
+		stats: make(map[uint16]index.FieldStats),
+	}
+}
+
+func (f *FieldLengths) snapshot() map[uint16]index.FieldStats {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	return f.stats
+}
+
+func (f *FieldLengths) apply(deltas fieldLengthDeltas) {
+	if len(deltas) == 0 {
+		return
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	stats := make(map[uint16]index.FieldStats, len(f.stats))
+	for field, fs := range f.stats {
+		stats[field] = fs
+	}
+	for field, delta := range deltas {
+		fs := stats[field]
+		fs.DocCount = uint64(int64(fs.DocCount) + delta.docCount)
+		fs.TotalLength = uint64(int64(fs.TotalLength) + delta.totalLength)
+		if fs.DocCount == 0 {
+			delete(stats, field)
+		} else {
+			stats[field] = fs
+		}
+	}
+	f.stats = stats
+}
+
+type fieldLengthDelta struct {
+	docCount    int64
+	totalLength int64
+}
+
+// fieldLengthDeltas accumulates the changes to the field
+// statistics made by a write
+type fieldLengthDeltas map[uint16]*fieldLengthDelta
+
+// add adds the field lengths of a back index row,
+// or removes them when sign is negative
+func (d fieldLengthDeltas) add(backIndexRow *BackIndexRow, sign int64) {
+	if backIndexRow == nil {
+		return
+	}
+	for _, entry := range backIndexRow.fieldLengths {
+		field := uint16(entry.GetField())
+		delta := d[field]
+		if delta == nil {
+			delta = &fieldLengthDelta{}
+			d[field] = delta
+		}
+		delta.docCount += sign
+		delta.totalLength += sign * int64(entry.GetLength())
+	}
+}
+
+// newBackIndexFieldLengthEntries builds the back index
+// entries for the summed lengths of each field
+func newBackIndexFieldLengthEntries(lengths map[uint16]uint64) []*BackIndexFieldLengthEntry {
+	if len(lengths) == 0 {
+		return nil
+	}
+	fields := make([]int, 0, len(lengths))
+	for field := range lengths {
+		fields = append(fields, int(field))
+	}
+	sort.Ints(fields)
+	rv := make([]*BackIndexFieldLengthEntry, len(fields))
+	for i, field := range fields {
+		rv[i] = &BackIndexFieldLengthEntry{
+			Field:  proto.Uint32(uint32(field)),
+			Length: proto.Uint64(lengths[uint16(field)]),
+		}
+	}
+	return rv
+}
+
+// backIndexRowFromRows finds the back index row among
+// the rows produced by analyzing a document
+func backIndexRowFromRows(rows []UpsideDownCouchRow) *BackIndexRow {
+	for i := len(rows) - 1; i >= 0; i-- {
+		if backIndexRow, ok := rows[i].(*BackIndexRow); ok {
+			return backIndexRow
+		}
+	}
+	return nil
+}
@@ -18,9 +18,11 @@ import (
 )
 
 type IndexReader struct {
-	index    *UpsideDownCouch
-	kvreader store.KVReader
-	docCount uint64
+	index               *UpsideDownCouch
+	kvreader            store.KVReader
+	docCount            uint64
+	fieldLengths        map[uint16]index.FieldStats
+	termCacheGeneration uint64
 }
 
 func (i *IndexReader) TermFieldReader(term []byte, fieldName string) (index.TermFieldReader, error) {
@@ -68,9 +70,15 @@ func (i *IndexReader) Document(id string) (*document.Document, error) {
 		}
 		if row != nil {
 			fieldName := i.index.fieldIndexCache.FieldName(row.field)
-			field := decodeFieldType(row.typ, fieldName, row.value)
-			if field != nil {
-				rv.AddField(field)
+			if fieldName != index.ArrayPathsField {
+				typ, value, err := decodeStoredValue(row.typ, row.value)
+				if err != nil {
+					return nil, err
+				}
+				field := decodeFieldType(typ, fieldName, row.arrayPositions, value)
+				if field != nil {
+					rv.AddField(field)
+				}
 			}
 		}
 
@@ -80,6 +88,43 @@ func (i *IndexReader) Document(id string) (*document.Document, error) {
 	return rv, nil
 }
 
+// DocumentField returns the stored values of one field of a
+// document, one per array position, scanning only the stored
+// rows of that field.  It returns nil if the document does not
+// exist or does not store the field.
+func (i *IndexReader) DocumentField(id, field string) ([]document.Field, error) {
+	fieldIndex, ok := i.index.fieldIndexCache.FieldExists(field)
+	if !ok {
+		return nil, nil
+	}
+	var rv []document.Field
+	storedRowPrefix := NewStoredRow(id, fieldIndex, []uint64{}, 'x', nil).Key()
+	it := i.kvreader.Iterator(storedRowPrefix)
+	defer it.Close()
+	key, val, valid := it.Current()
+	for valid {
+		if !bytes.HasPrefix(key, storedRowPrefix) {
+			break
+		}
+		row, err := NewStoredRowKV(key, val)
+		if err != nil {
+			return nil, err
+		}
+		typ, value, err := decodeStoredValue(row.typ, row.value)
+		if err != nil {
+			return nil, err
+		}
+		f := decodeFieldType(typ, field, row.arrayPositions, value)
+		if f != nil {
+			rv = append(rv, f)
+		}
+
+		it.Next()
+		key, val, valid = it.Current()
+	}
+	return rv, nil
+}
+
 func (i *IndexReader) DocumentFieldTerms(id string) (index.FieldTerms, error) {
 	back, err := i.index.backIndexRowForDoc(i.kvreader, id)
 	if err != nil {
@@ -88,6 +133,9 @@ func (i *IndexReader) DocumentFieldTerms(id string) (index.FieldTerms, error) {
 	rv := make(index.FieldTerms, len(back.termEntries))
 	for _, entry := range back.termEntries {
 		fieldName := i.index.fieldIndexCache.FieldName(uint16(*entry.Field))
+		if fieldName == index.FieldNamesField {
+			continue
+		}
 		terms, ok := rv[fieldName]
 		if !ok {
 			terms = make([]string, 0)
@@ -113,7 +161,7 @@ func (i *IndexReader) Fields() ([]string, error) {
 		}
 		if row != nil {
 			fieldRow, ok := row.(*FieldRow)
-			if ok {
+			if ok && fieldRow.name != index.FieldNamesField && fieldRow.name != index.ArrayPathsField {
 				rv = append(rv, fieldRow.name)
 			}
 		}
@@ -133,6 +181,14 @@ func (i *IndexReader) DocCount() uint64 {
 	return i.docCount
 }
 
+func (i *IndexReader) FieldStats(field string) index.FieldStats {
+	fieldIndex, fieldExists := i.index.fieldIndexCache.FieldExists(field)
+	if !fieldExists {
+		return index.FieldStats{}
+	}
+	return i.fieldLengths[fieldIndex]
+}
+
 func (i *IndexReader) Close() {
 	i.kvreader.Close()
 }
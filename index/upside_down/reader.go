@@ -26,7 +26,13 @@ type UpsideDownCouchTermFieldReader struct {
 
 func newUpsideDownCouchTermFieldReader(indexReader *IndexReader, term []byte, field uint16) (*UpsideDownCouchTermFieldReader, error) {
 	tfr := NewTermFrequencyRow(term, field, "", 0, 0)
-	it := indexReader.kvreader.Iterator(tfr.Key())
+	var it store.KVIterator
+	rows, cached := indexReader.index.termCache.get(indexReader.termCacheGeneration, tfr.SummaryKey())
+	if cached {
+		it = newTermCacheIterator(rows, tfr.Key())
+	} else {
+		it = indexReader.kvreader.Iterator(tfr.Key())
+	}
 
 	var count uint64
 	key, val, valid := it.Current()
@@ -171,14 +171,15 @@ func NewFieldRowKV(key, value []byte) (*FieldRow, error) {
 // TERM FIELD FREQUENCY
 
 type TermVector struct {
-	field uint16
-	pos   uint64
-	start uint64
-	end   uint64
+	field          uint16
+	arrayPositions []uint64
+	pos            uint64
+	start          uint64
+	end            uint64
 }
 
 func (tv *TermVector) String() string {
-	return fmt.Sprintf("Field: %d Pos: %d Start: %d End %d", tv.field, tv.pos, tv.start, tv.end)
+	return fmt.Sprintf("Field: %d Pos: %d Start: %d End %d ArrayPositions: %#v", tv.field, tv.pos, tv.start, tv.end, tv.arrayPositions)
 }
 
 type TermFrequencyRow struct {
@@ -234,7 +235,11 @@ func (tfr *TermFrequencyRow) SummaryKey() []byte {
 }
 
 func (tfr *TermFrequencyRow) Value() []byte {
-	buf := make([]byte, 8+4+(len(tfr.vectors)*(2+8+8+8)))
+	bufLen := 8 + 4
+	for _, vector := range tfr.vectors {
+		bufLen += 2 + 8 + 8 + 8 + binary.MaxVarintLen64*(1+len(vector.arrayPositions))
+	}
+	buf := make([]byte, bufLen)
 
 	binary.LittleEndian.PutUint64(buf[0:8], tfr.freq)
 
@@ -248,8 +253,13 @@ func (tfr *TermFrequencyRow) Value() []byte {
 		binary.LittleEndian.PutUint64(buf[offset+10:offset+18], vector.start)
 		binary.LittleEndian.PutUint64(buf[offset+18:offset+26], vector.end)
 		offset += 26
+		// followed by the array positions, count first
+		offset += binary.PutUvarint(buf[offset:], uint64(len(vector.arrayPositions)))
+		for _, arrayPosition := range vector.arrayPositions {
+			offset += binary.PutUvarint(buf[offset:], arrayPosition)
+		}
 	}
-	return buf
+	return buf[:offset]
 }
 
 func (tfr *TermFrequencyRow) String() string {
@@ -343,6 +353,20 @@ func (tfr *TermFrequencyRow) parseV(value []byte) error {
 		if err != nil {
 			return err
 		}
+		var numArrayPositions uint64
+		numArrayPositions, err = binary.ReadUvarint(buf)
+		if err != nil {
+			return err
+		}
+		if numArrayPositions > 0 {
+			tv.arrayPositions = make([]uint64, numArrayPositions)
+			for i := range tv.arrayPositions {
+				tv.arrayPositions[i], err = binary.ReadUvarint(buf)
+				if err != nil {
+					return err
+				}
+			}
+		}
 		tfr.vectors = append(tfr.vectors, &tv)
 		// try to read next record (may not exist)
 		err = binary.Read(buf, binary.LittleEndian, &field)
@@ -368,6 +392,7 @@ type BackIndexRow struct {
 	doc           []byte
 	termEntries   []*BackIndexTermEntry
 	storedEntries []*BackIndexStoreEntry
+	fieldLengths  []*BackIndexFieldLengthEntry
 }
 
 func (br *BackIndexRow) AllTermKeys() [][]byte {
@@ -405,13 +430,14 @@ func (br *BackIndexRow) Value() []byte {
 	birv := &BackIndexRowValue{
 		TermEntries:   br.termEntries,
 		StoredEntries: br.storedEntries,
+		FieldLengths:  br.fieldLengths,
 	}
 	bytes, _ := proto.Marshal(birv)
 	return bytes
 }
 
 func (br *BackIndexRow) String() string {
-	return fmt.Sprintf("Backindex DocId: `%s` Term Entries: %v, Stored Entries: %v", string(br.doc), br.termEntries, br.storedEntries)
+	return fmt.Sprintf("Backindex DocId: `%s` Term Entries: %v, Stored Entries: %v, Field Lengths: %v", string(br.doc), br.termEntries, br.storedEntries, br.fieldLengths)
 }
 
 func NewBackIndexRow(doc string, entries []*BackIndexTermEntry, storedFields []*BackIndexStoreEntry) *BackIndexRow {
@@ -444,6 +470,7 @@ func NewBackIndexRowKV(key, value []byte) (*BackIndexRow, error) {
 	}
 	rv.termEntries = birv.TermEntries
 	rv.storedEntries = birv.StoredEntries
+	rv.fieldLengths = birv.FieldLengths
 
 	return &rv, nil
 }
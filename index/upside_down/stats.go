@@ -10,8 +10,11 @@
 package upside_down
 
 import (
+	"bytes"
 	"encoding/json"
 	"sync/atomic"
+
+	"github.com/blevesearch/bleve/index"
 )
 
 type indexStat struct {
@@ -29,3 +32,64 @@ func (i *indexStat) MarshalJSON() ([]byte, error) {
 	m["index_time"] = atomic.LoadUint64(&i.indexTime)
 	return json.Marshal(m)
 }
+
+// StatsMap returns the document count, the number of
+// distinct terms of each field and their total, and the
+// number of entries of the store when it reports its Len.
+// The terms are counted from their dictionary rows, seeking
+// past the rows of the documents using them, so the cost
+// grows with the size of the dictionary only.
+func (udc *UpsideDownCouch) StatsMap() (map[string]interface{}, error) {
+	kvreader, err := udc.store.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer kvreader.Close()
+
+	cardinality := make(map[string]uint64)
+	var termCount uint64
+
+	prefix := []byte{'t'}
+	it := kvreader.Iterator(prefix)
+	defer it.Close()
+	key, val, valid := it.Current()
+	for valid && bytes.HasPrefix(key, prefix) {
+		tfr, err := NewTermFrequencyRowKV(key, val)
+		if err != nil {
+			return nil, err
+		}
+		fieldName := udc.fieldIndexCache.FieldName(tfr.field)
+		if fieldName == index.FieldNamesField {
+			// skip the field names indexed as terms
+			it.Seek(incrementBytes(key[:3]))
+			key, val, valid = it.Current()
+			continue
+		}
+		if len(tfr.doc) == 0 && tfr.freq > 0 {
+			cardinality[fieldName]++
+			termCount++
+		}
+
+		// skip the rows of the documents using the term,
+		// doc ids never hold the separator
+		it.Seek(append(tfr.ScanPrefixForFieldTerm(), ByteSeparator))
+		key, val, valid = it.Current()
+	}
+
+	docCount, _ := udc.DocCount()
+	rv := map[string]interface{}{
+		"doc_count":         docCount,
+		"term_count":        termCount,
+		"field_cardinality": cardinality,
+	}
+	if s, ok := udc.store.(storeLen); ok {
+		rv["store_entries"] = s.Len()
+	}
+	return rv, nil
+}
+
+// storeLen is implemented by the stores which can report
+// their number of entries cheaply
+type storeLen interface {
+	Len() int
+}
@@ -0,0 +1,207 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package upside_down
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/blevesearch/bleve/index/store"
+)
+
+// termCache holds the term frequency rows of the terms
+// read by Warmup, so searching for them needs no store
+// iteration.  Writes drop the terms they change before
+// they are applied, and readers opened before the cache
+// was filled never use it, so it always agrees with the
+// snapshot of a reader which does.
+type termCache struct {
+	// warming holds off writes while the cache is filled
+	warming sync.RWMutex
+
+	mutex sync.RWMutex
+	// generation counts the fills, a reader may use the
+	// cache filled at or before the generation it was
+	// opened at
+	generation uint64
+	// complete is set when every term was read, so terms
+	// which are not cached have no rows
+	complete bool
+	// rows are the rows of each term, by summary key,
+	// starting with the summary row
+	rows map[string][]termCacheRow
+}
+
+type termCacheRow struct {
+	key []byte
+	val []byte
+}
+
+func newTermCache() *termCache {
+	return &termCache{}
+}
+
+func (c *termCache) currentGeneration() uint64 {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.generation
+}
+
+// get returns the rows of the term with the summary key,
+// if the reader of the generation may use them
+func (c *termCache) get(generation uint64, summaryKey []byte) ([]termCacheRow, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	if c.generation == 0 || generation < c.generation {
+		return nil, false
+	}
+	rows, ok := c.rows[string(summaryKey)]
+	if !ok && !c.complete {
+		return nil, false
+	}
+	return rows, true
+}
+
+// invalidate drops the terms with the summary keys, and
+// any claim to hold all the terms
+func (c *termCache) invalidate(summaryKeys [][]byte) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.complete = false
+	for _, summaryKey := range summaryKeys {
+		delete(c.rows, string(summaryKey))
+	}
+}
+
+// fill replaces the cache with the terms of kvreader, up to
+// maxBytes of rows (no limit if 0).  It stops early, keeping
+// the terms read so far, when ctx is done.
+func (c *termCache) fill(ctx context.Context, kvreader store.KVReader, maxBytes uint64) error {
+	c.mutex.Lock()
+	c.generation++
+	c.complete = false
+	c.rows = make(map[string][]termCacheRow)
+	c.mutex.Unlock()
+
+	keyPrefix := []byte{'t'}
+	it := kvreader.Iterator(keyPrefix)
+	defer it.Close()
+
+	var summaryKey []byte
+	var rows []termCacheRow
+	var size uint64
+	key, val, valid := it.Current()
+	for valid && bytes.HasPrefix(key, keyPrefix) {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		if summaryKey == nil || !bytes.HasPrefix(key, summaryKey) {
+			c.add(summaryKey, rows)
+			summaryKey = termSummaryKey(key)
+			rows = nil
+		}
+		size += uint64(len(key) + len(val))
+		if maxBytes > 0 && size > maxBytes {
+			// the term being read does not fit
+			return nil
+		}
+		rows = append(rows, termCacheRow{
+			key: append([]byte(nil), key...),
+			val: append([]byte(nil), val...),
+		})
+		it.Next()
+		key, val, valid = it.Current()
+	}
+	c.add(summaryKey, rows)
+
+	c.mutex.Lock()
+	c.complete = true
+	c.mutex.Unlock()
+	return nil
+}
+
+func (c *termCache) add(summaryKey []byte, rows []termCacheRow) {
+	if summaryKey == nil {
+		return
+	}
+	c.mutex.Lock()
+	c.rows[string(summaryKey)] = rows
+	c.mutex.Unlock()
+}
+
+// termSummaryKey returns the prefix of a term frequency row
+// key shared by all the rows of its term
+func termSummaryKey(key []byte) []byte {
+	if len(key) < 3 {
+		return key
+	}
+	end := bytes.IndexByte(key[3:], ByteSeparator)
+	if end < 0 {
+		return key
+	}
+	return append([]byte(nil), key[:3+end+1]...)
+}
+
+// termCacheIterator iterates over cached rows
+type termCacheIterator struct {
+	rows []termCacheRow
+	i    int
+}
+
+func newTermCacheIterator(rows []termCacheRow, key []byte) *termCacheIterator {
+	rv := termCacheIterator{
+		rows: rows,
+	}
+	rv.Seek(key)
+	return &rv
+}
+
+func (i *termCacheIterator) SeekFirst() {
+	i.i = 0
+}
+
+func (i *termCacheIterator) Seek(key []byte) {
+	i.i = sort.Search(len(i.rows), func(n int) bool {
+		return bytes.Compare(i.rows[n].key, key) >= 0
+	})
+}
+
+func (i *termCacheIterator) Next() {
+	i.i++
+}
+
+func (i *termCacheIterator) Current() ([]byte, []byte, bool) {
+	if !i.Valid() {
+		return nil, nil, false
+	}
+	return i.rows[i.i].key, i.rows[i.i].val, true
+}
+
+func (i *termCacheIterator) Key() []byte {
+	key, _, _ := i.Current()
+	return key
+}
+
+func (i *termCacheIterator) Value() []byte {
+	_, val, _ := i.Current()
+	return val
+}
+
+func (i *termCacheIterator) Valid() bool {
+	return i.i < len(i.rows)
+}
+
+func (i *termCacheIterator) Close() error {
+	return nil
+}
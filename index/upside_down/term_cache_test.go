@@ -0,0 +1,193 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package upside_down
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/blevesearch/bleve/document"
+	"github.com/blevesearch/bleve/index"
+	"github.com/blevesearch/bleve/index/store"
+	"github.com/blevesearch/bleve/index/store/gtreap"
+)
+
+// countingStore counts the iterators opened by its readers
+type countingStore struct {
+	store.KVStore
+	iterators *uint64
+}
+
+func (s *countingStore) Reader() (store.KVReader, error) {
+	r, err := s.KVStore.Reader()
+	if err != nil {
+		return nil, err
+	}
+	return &countingReader{KVReader: r, iterators: s.iterators}, nil
+}
+
+type countingReader struct {
+	store.KVReader
+	iterators *uint64
+}
+
+func (r *countingReader) Iterator(key []byte) store.KVIterator {
+	atomic.AddUint64(r.iterators, 1)
+	return r.KVReader.Iterator(key)
+}
+
+func newWarmupTestIndex(t *testing.T) (*UpsideDownCouch, *uint64) {
+	inner, err := gtreap.StoreConstructor(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var iterators uint64
+	idx := NewUpsideDownCouch(&countingStore{KVStore: inner, iterators: &iterators}, NewAnalysisQueue(1))
+	err = idx.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id, name := range map[string]string{"1": "test", "2": "test", "3": "other"} {
+		doc := document.NewDocument(id)
+		doc.AddField(document.NewTextField("name", []uint64{}, []byte(name)))
+		err = idx.Update(doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return idx, &iterators
+}
+
+// termDocs returns the ids of the docs with the term, and
+// the number of store iterators opened to find them
+func termDocs(t *testing.T, idx *UpsideDownCouch, iterators *uint64, term string) ([]string, uint64) {
+	indexReader, err := idx.Reader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer indexReader.Close()
+	before := atomic.LoadUint64(iterators)
+	reader, err := indexReader.TermFieldReader([]byte(term), "name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	var rv []string
+	tfd, err := reader.Next()
+	for tfd != nil && err == nil {
+		rv = append(rv, tfd.ID)
+		tfd, err = reader.Next()
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reader.Count() != uint64(len(rv)) {
+		t.Errorf("expected count %d, got %d", len(rv), reader.Count())
+	}
+	return rv, atomic.LoadUint64(iterators) - before
+}
+
+func TestIndexWarmup(t *testing.T) {
+	idx, iterators := newWarmupTestIndex(t)
+	defer idx.Close()
+
+	_, opened := termDocs(t, idx, iterators, "test")
+	if opened != 1 {
+		t.Errorf("expected a cold search to iterate the store once, got %d", opened)
+	}
+
+	// a reader opened before the warmup does not use it
+	oldReader, err := idx.Reader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer oldReader.Close()
+
+	err = idx.Warmup(context.Background(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, term := range []string{"test", "other", "missing"} {
+		_, opened = termDocs(t, idx, iterators, term)
+		if opened != 0 {
+			t.Errorf("expected warm search for %s not to iterate the store, got %d", term, opened)
+		}
+	}
+	before := atomic.LoadUint64(iterators)
+	reader, err := oldReader.TermFieldReader([]byte("test"), "name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader.Close()
+	if atomic.LoadUint64(iterators) == before {
+		t.Errorf("expected reader opened before warmup to iterate the store")
+	}
+
+	// a changed term is read from the store again
+	doc := document.NewDocument("4")
+	doc.AddField(document.NewTextField("name", []uint64{}, []byte("test")))
+	err = idx.Update(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs, opened := termDocs(t, idx, iterators, "test")
+	if opened != 1 {
+		t.Errorf("expected search for changed term to iterate the store, got %d", opened)
+	}
+	if len(docs) != 3 {
+		t.Errorf("expected 3 docs, got %v", docs)
+	}
+	docs, opened = termDocs(t, idx, iterators, "other")
+	if opened != 0 || len(docs) != 1 {
+		t.Errorf("expected unchanged term to stay warm, got %v with %d iterators", docs, opened)
+	}
+
+	// after the batch deleting doc 3 its term is read again
+	batch := index.NewBatch()
+	batch.Delete("3")
+	err = idx.Batch(batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs, opened = termDocs(t, idx, iterators, "other")
+	if opened != 1 || len(docs) != 0 {
+		t.Errorf("expected deleted term to be read from the store, got %v with %d iterators", docs, opened)
+	}
+}
+
+func TestIndexWarmupBounded(t *testing.T) {
+	idx, iterators := newWarmupTestIndex(t)
+	defer idx.Close()
+
+	// too small for any term, unknown terms are then not
+	// known to be missing either
+	err := idx.Warmup(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, term := range []string{"test", "missing"} {
+		_, opened := termDocs(t, idx, iterators, term)
+		if opened != 1 {
+			t.Errorf("expected search for %s to iterate the store, got %d", term, opened)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = idx.Warmup(ctx, 0)
+	if err != context.Canceled {
+		t.Errorf("expected canceled error, got %v", err)
+	}
+	_, opened := termDocs(t, idx, iterators, "test")
+	if opened != 1 {
+		t.Errorf("expected search after canceled warmup to iterate the store, got %d", opened)
+	}
+}
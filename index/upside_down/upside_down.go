@@ -11,8 +11,12 @@ package upside_down
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
 	"math"
+	"sort"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -22,11 +26,15 @@ import (
 	"github.com/blevesearch/bleve/index/store"
 
 	"github.com/golang/protobuf/proto"
+	"github.com/golang/snappy"
 )
 
 var VersionKey = []byte{'v'}
 
-const Version uint8 = 1
+// Version 2 records the array positions of term vectors
+const Version uint8 = 2
+
+var IncompatibleVersionErr = fmt.Errorf("incompatible index version, only version %d is supported", Version)
 
 type UpsideDownCouch struct {
 	version         uint8
@@ -34,17 +42,22 @@ type UpsideDownCouch struct {
 	store           store.KVStore
 	fieldIndexCache *FieldIndexCache
 	docCount        uint64
+	docCountMutex   sync.RWMutex
+	fieldLengths    *FieldLengths
 	analysisQueue   AnalysisQueue
 	stats           *indexStat
+	termCache       *termCache
 }
 
 func NewUpsideDownCouch(s store.KVStore, analysisQueue AnalysisQueue) *UpsideDownCouch {
 	return &UpsideDownCouch{
 		version:         Version,
 		fieldIndexCache: NewFieldIndexCache(),
+		fieldLengths:    NewFieldLengths(),
 		store:           s,
 		analysisQueue:   analysisQueue,
 		stats:           &indexStat{},
+		termCache:       newTermCache(),
 	}
 }
 
@@ -87,8 +100,12 @@ func (udc *UpsideDownCouch) loadSchema(kvreader store.KVReader) (err error) {
 
 func (udc *UpsideDownCouch) batchRows(writer store.KVWriter, addRows []UpsideDownCouchRow, updateRows []UpsideDownCouchRow, deleteRows []UpsideDownCouchRow) (err error) {
 
+	udc.termCache.warming.RLock()
+	defer udc.termCache.warming.RUnlock()
+
 	// prepare batch
 	wb := writer.NewBatch()
+	var summaryKeys [][]byte
 
 	// add
 	for _, row := range addRows {
@@ -97,12 +114,17 @@ func (udc *UpsideDownCouch) batchRows(writer store.KVWriter, addRows []UpsideDow
 			// need to increment counter
 			summaryKey := tfr.SummaryKey()
 			wb.Merge(summaryKey, newTermSummaryIncr())
+			summaryKeys = append(summaryKeys, summaryKey)
 		}
 		wb.Set(row.Key(), row.Value())
 	}
 
 	// update
 	for _, row := range updateRows {
+		tfr, ok := row.(*TermFrequencyRow)
+		if ok {
+			summaryKeys = append(summaryKeys, tfr.SummaryKey())
+		}
 		wb.Set(row.Key(), row.Value())
 	}
 
@@ -113,10 +135,15 @@ func (udc *UpsideDownCouch) batchRows(writer store.KVWriter, addRows []UpsideDow
 			// need to decrement counter
 			summaryKey := tfr.SummaryKey()
 			wb.Merge(summaryKey, newTermSummaryDecr())
+			summaryKeys = append(summaryKeys, summaryKey)
 		}
 		wb.Delete(row.Key())
 	}
 
+	// drop the changed terms before any reader can see
+	// the changes
+	udc.termCache.invalidate(summaryKeys)
+
 	// write out the batch
 	err = wb.Execute()
 	if err != nil {
@@ -126,6 +153,8 @@ func (udc *UpsideDownCouch) batchRows(writer store.KVWriter, addRows []UpsideDow
 }
 
 func (udc *UpsideDownCouch) DocCount() (uint64, error) {
+	udc.docCountMutex.RLock()
+	defer udc.docCountMutex.RUnlock()
 	return udc.docCount, nil
 }
 
@@ -149,32 +178,49 @@ func (udc *UpsideDownCouch) Open() error {
 			return err
 		}
 	} else {
+		versionRow, err := NewVersionRowKV(VersionKey, value)
+		if err != nil {
+			return err
+		}
+		if versionRow.version != Version {
+			return IncompatibleVersionErr
+		}
 		err = udc.loadSchema(kvwriter)
 		if err != nil {
 			return err
 		}
 	}
-	// set doc count
-	udc.docCount = udc.countDocs(kvwriter)
-	return nil
+	// set doc count and field lengths
+	docCount, err := udc.countDocs(kvwriter)
+	udc.docCountMutex.Lock()
+	udc.docCount = docCount
+	udc.docCountMutex.Unlock()
+	return err
 }
 
-func (udc *UpsideDownCouch) countDocs(kvreader store.KVReader) uint64 {
+func (udc *UpsideDownCouch) countDocs(kvreader store.KVReader) (uint64, error) {
 	it := kvreader.Iterator([]byte{'b'})
 	defer it.Close()
 
 	var rv uint64
-	key, _, valid := it.Current()
+	deltas := make(fieldLengthDeltas)
+	key, val, valid := it.Current()
 	for valid {
 		if !bytes.HasPrefix(key, []byte{'b'}) {
 			break
 		}
 		rv++
+		backIndexRow, err := NewBackIndexRowKV(key, val)
+		if err != nil {
+			return 0, err
+		}
+		deltas.add(backIndexRow, 1)
 		it.Next()
-		key, _, valid = it.Current()
+		key, val, valid = it.Current()
 	}
+	udc.fieldLengths.apply(deltas)
 
-	return rv
+	return rv, nil
 }
 
 func (udc *UpsideDownCouch) rowCount() uint64 {
@@ -244,9 +290,18 @@ func (udc *UpsideDownCouch) Update(doc *document.Document) error {
 
 	addRows, updateRows, deleteRows = udc.mergeOldAndNew(backIndexRow, result.rows, addRows, updateRows, deleteRows)
 
+	deltas := make(fieldLengthDeltas)
+	deltas.add(backIndexRow, -1)
+	deltas.add(backIndexRowFromRows(result.rows), 1)
+
 	err = udc.batchRows(kvwriter, addRows, updateRows, deleteRows)
-	if err == nil && backIndexRow == nil {
-		udc.docCount++
+	if err == nil {
+		if backIndexRow == nil {
+			udc.docCountMutex.Lock()
+			udc.docCount++
+			udc.docCountMutex.Unlock()
+		}
+		udc.fieldLengths.apply(deltas)
 	}
 	atomic.AddUint64(&udc.stats.indexTime, uint64(time.Since(indexStart)))
 	if err == nil {
@@ -315,7 +370,16 @@ func (udc *UpsideDownCouch) storeField(docID string, field document.Field, field
 	rows := make([]UpsideDownCouchRow, 0, 100)
 	backIndexStoredEntries := make([]*BackIndexStoreEntry, 0)
 	fieldType := encodeFieldType(field)
-	storedRow := NewStoredRow(docID, fieldIndex, field.ArrayPositions(), fieldType, field.Value())
+	value := field.Value()
+	if field.Options().IsStoredCompressed() {
+		compressed := snappy.Encode(nil, value)
+		// small values may not shrink, keep those as they are
+		if len(compressed) < len(value) {
+			fieldType |= storedCompressedFlag
+			value = compressed
+		}
+	}
+	storedRow := NewStoredRow(docID, fieldIndex, field.ArrayPositions(), fieldType, value)
 
 	// record the back index entry
 	backIndexStoredEntry := BackIndexStoreEntry{Field: proto.Uint32(uint32(fieldIndex)), ArrayPositions: field.ArrayPositions()}
@@ -325,6 +389,10 @@ func (udc *UpsideDownCouch) storeField(docID string, field document.Field, field
 	return rows, backIndexStoredEntries
 }
 
+// storedCompressedFlag is set in the type of a stored row
+// whose value is snappy compressed.
+const storedCompressedFlag = 0x80
+
 func encodeFieldType(f document.Field) byte {
 	fieldType := byte('x')
 	switch f.(type) {
@@ -334,6 +402,10 @@ func encodeFieldType(f document.Field) byte {
 		fieldType = 'n'
 	case *document.DateTimeField:
 		fieldType = 'd'
+	case *document.IPField:
+		fieldType = 'i'
+	case *document.BooleanField:
+		fieldType = 'b'
 	case *document.CompositeField:
 		fieldType = 'c'
 	}
@@ -366,6 +438,30 @@ func (udc *UpsideDownCouch) indexField(docID string, field document.Field, field
 	return rows, backIndexTermEntries
 }
 
+// indexFieldNames indexes the names of the fields of a
+// document as terms of the index.FieldNamesField field
+func (udc *UpsideDownCouch) indexFieldNames(docID string, fieldNames map[string]bool) ([]UpsideDownCouchRow, []*BackIndexTermEntry) {
+	rows := make([]UpsideDownCouchRow, 0, len(fieldNames)+1)
+	backIndexTermEntries := make([]*BackIndexTermEntry, 0, len(fieldNames))
+
+	fieldIndex, newFieldRow := udc.fieldIndexCache.FieldIndex(index.FieldNamesField)
+	if newFieldRow != nil {
+		rows = append(rows, newFieldRow)
+	}
+
+	names := make([]string, 0, len(fieldNames))
+	for name := range fieldNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		rows = append(rows, NewTermFrequencyRow([]byte(name), fieldIndex, docID, 1, 1.0))
+		backIndexTermEntry := BackIndexTermEntry{Term: proto.String(name), Field: proto.Uint32(uint32(fieldIndex))}
+		backIndexTermEntries = append(backIndexTermEntries, &backIndexTermEntry)
+	}
+	return rows, backIndexTermEntries
+}
+
 func (udc *UpsideDownCouch) Delete(id string) error {
 	indexStart := time.Now()
 	// start a writer for this delete
@@ -391,7 +487,12 @@ func (udc *UpsideDownCouch) Delete(id string) error {
 
 	err = udc.batchRows(kvwriter, nil, nil, deleteRows)
 	if err == nil {
+		udc.docCountMutex.Lock()
 		udc.docCount--
+		udc.docCountMutex.Unlock()
+		deltas := make(fieldLengthDeltas)
+		deltas.add(backIndexRow, -1)
+		udc.fieldLengths.apply(deltas)
 	}
 	atomic.AddUint64(&udc.stats.indexTime, uint64(time.Since(indexStart)))
 	if err == nil {
@@ -452,14 +553,31 @@ func (udc *UpsideDownCouch) backIndexRowsForBatch(kvreader store.KVReader, batch
 	return rv, nil
 }
 
-func decodeFieldType(typ byte, name string, value []byte) document.Field {
+// decodeStoredValue returns the field type and the value of a
+// stored row, decompressing the value if needed.
+func decodeStoredValue(typ byte, value []byte) (byte, []byte, error) {
+	if typ&storedCompressedFlag == 0 {
+		return typ, value, nil
+	}
+	value, err := snappy.Decode(nil, value)
+	if err != nil {
+		return 0, nil, err
+	}
+	return typ &^ storedCompressedFlag, value, nil
+}
+
+func decodeFieldType(typ byte, name string, arrayPositions []uint64, value []byte) document.Field {
 	switch typ {
 	case 't':
-		return document.NewTextField(name, []uint64{}, value)
+		return document.NewTextField(name, arrayPositions, value)
 	case 'n':
-		return document.NewNumericFieldFromBytes(name, []uint64{}, value)
+		return document.NewNumericFieldFromBytes(name, arrayPositions, value)
 	case 'd':
-		return document.NewDateTimeFieldFromBytes(name, []uint64{}, value)
+		return document.NewDateTimeFieldFromBytes(name, arrayPositions, value)
+	case 'i':
+		return document.NewIPFieldFromBytes(name, arrayPositions, value)
+	case 'b':
+		return document.NewBooleanFieldFromBytes(name, arrayPositions, value)
 	}
 	return nil
 }
@@ -483,10 +601,11 @@ func (udc *UpsideDownCouch) termVectorsFromTokenFreq(field uint16, tf *analysis.
 			}
 		}
 		tv := TermVector{
-			field: fieldIndex,
-			pos:   uint64(l.Position),
-			start: uint64(l.Start),
-			end:   uint64(l.End),
+			field:          fieldIndex,
+			arrayPositions: l.ArrayPositions,
+			pos:            uint64(l.Position),
+			start:          uint64(l.Start),
+			end:            uint64(l.End),
 		}
 		rv[i] = &tv
 	}
@@ -500,10 +619,11 @@ func (udc *UpsideDownCouch) termFieldVectorsFromTermVectors(in []*TermVector) []
 	for i, tv := range in {
 		fieldName := udc.fieldIndexCache.FieldName(tv.field)
 		tfv := index.TermFieldVector{
-			Field: fieldName,
-			Pos:   tv.pos,
-			Start: tv.start,
-			End:   tv.end,
+			Field:          fieldName,
+			ArrayPositions: tv.arrayPositions,
+			Pos:            tv.pos,
+			Start:          tv.start,
+			End:            tv.end,
 		}
 		rv[i] = &tfv
 	}
@@ -568,14 +688,18 @@ func (udc *UpsideDownCouch) Batch(batch *index.Batch) error {
 
 	docsAdded := uint64(0)
 	docsDeleted := uint64(0)
+	deltas := make(fieldLengthDeltas)
 	for docID, doc := range batch.IndexOps {
 		backIndexRow := backIndexRows[docID]
 		if doc == nil && backIndexRow != nil {
 			// delete
 			deleteRows = udc.deleteSingle(docID, backIndexRow, deleteRows)
+			deltas.add(backIndexRow, -1)
 			docsDeleted++
 		} else if doc != nil {
 			addRows, updateRows, deleteRows = udc.mergeOldAndNew(backIndexRow, newRowsMap[docID], addRows, updateRows, deleteRows)
+			deltas.add(backIndexRow, -1)
+			deltas.add(backIndexRowFromRows(newRowsMap[docID]), 1)
 			if backIndexRow == nil {
 				docsAdded++
 			}
@@ -597,8 +721,11 @@ func (udc *UpsideDownCouch) Batch(batch *index.Batch) error {
 	err = udc.batchRows(kvwriter, addRows, updateRows, deleteRows)
 	atomic.AddUint64(&udc.stats.indexTime, uint64(time.Since(indexStart)))
 	if err == nil {
+		udc.docCountMutex.Lock()
 		udc.docCount += docsAdded
 		udc.docCount -= docsDeleted
+		udc.docCountMutex.Unlock()
+		udc.fieldLengths.apply(deltas)
 		atomic.AddUint64(&udc.stats.updates, numUpdates)
 		atomic.AddUint64(&udc.stats.deletes, docsDeleted)
 		atomic.AddUint64(&udc.stats.batches, 1)
@@ -629,17 +756,79 @@ func (udc *UpsideDownCouch) DeleteInternal(key []byte) error {
 }
 
 func (udc *UpsideDownCouch) Reader() (index.IndexReader, error) {
+	// the generation is read first, so the snapshot is at
+	// least as recent as the cache the reader may use
+	termCacheGeneration := udc.termCache.currentGeneration()
 	kvr, err := udc.store.Reader()
 	if err != nil {
 		return nil, err
 	}
+	docCount, _ := udc.DocCount()
 	return &IndexReader{
-		index:    udc,
-		kvreader: kvr,
-		docCount: udc.docCount,
+		index:               udc,
+		kvreader:            kvr,
+		docCount:            docCount,
+		fieldLengths:        udc.fieldLengths.snapshot(),
+		termCacheGeneration: termCacheGeneration,
 	}, nil
 }
 
+// Readers opens n readers, of the same snapshot when the
+// store implements store.KVMultiReader, one at a time
+// otherwise.
+func (udc *UpsideDownCouch) Readers(n int) ([]index.IndexReader, error) {
+	termCacheGeneration := udc.termCache.currentGeneration()
+	var kvrs []store.KVReader
+	if multiReader, ok := udc.store.(store.KVMultiReader); ok {
+		var err error
+		kvrs, err = multiReader.Readers(n)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		for len(kvrs) < n {
+			kvr, err := udc.store.Reader()
+			if err != nil {
+				for _, r := range kvrs {
+					r.Close()
+				}
+				return nil, err
+			}
+			kvrs = append(kvrs, kvr)
+		}
+	}
+	docCount, _ := udc.DocCount()
+	rv := make([]index.IndexReader, len(kvrs))
+	for n, kvr := range kvrs {
+		rv[n] = &IndexReader{
+			index:               udc,
+			kvreader:            kvr,
+			docCount:            docCount,
+			fieldLengths:        udc.fieldLengths.snapshot(),
+			termCacheGeneration: termCacheGeneration,
+		}
+	}
+	return rv, nil
+}
+
+// Warmup reads the term frequency rows of the index into
+// memory, up to maxBytes of them (no limit if 0), so that
+// searching the terms read needs no store iteration.
+// Writes wait for it to finish, and drop the terms they
+// change from memory.  When ctx is done it stops, keeping
+// the terms read so far, and returns the error of ctx.
+func (udc *UpsideDownCouch) Warmup(ctx context.Context, maxBytes uint64) error {
+	udc.termCache.warming.Lock()
+	defer udc.termCache.warming.Unlock()
+
+	kvreader, err := udc.store.Reader()
+	if err != nil {
+		return err
+	}
+	defer kvreader.Close()
+	return udc.termCache.fill(ctx, kvreader, maxBytes)
+}
+
 func (udc *UpsideDownCouch) Stats() json.Marshaler {
 	return udc.stats
 }
@@ -11,6 +11,7 @@ It is generated from these files:
 It has these top-level messages:
 	BackIndexTermEntry
 	BackIndexStoreEntry
+	BackIndexFieldLengthEntry
 	BackIndexRowValue
 */
 package upside_down
@@ -70,10 +71,35 @@ func (m *BackIndexStoreEntry) GetArrayPositions() []uint64 {
 	return nil
 }
 
+type BackIndexFieldLengthEntry struct {
+	Field            *uint32 `protobuf:"varint,1,req,name=field" json:"field,omitempty"`
+	Length           *uint64 `protobuf:"varint,2,req,name=length" json:"length,omitempty"`
+	XXX_unrecognized []byte  `json:"-"`
+}
+
+func (m *BackIndexFieldLengthEntry) Reset()         { *m = BackIndexFieldLengthEntry{} }
+func (m *BackIndexFieldLengthEntry) String() string { return proto.CompactTextString(m) }
+func (*BackIndexFieldLengthEntry) ProtoMessage()    {}
+
+func (m *BackIndexFieldLengthEntry) GetField() uint32 {
+	if m != nil && m.Field != nil {
+		return *m.Field
+	}
+	return 0
+}
+
+func (m *BackIndexFieldLengthEntry) GetLength() uint64 {
+	if m != nil && m.Length != nil {
+		return *m.Length
+	}
+	return 0
+}
+
 type BackIndexRowValue struct {
-	TermEntries      []*BackIndexTermEntry  `protobuf:"bytes,1,rep,name=termEntries" json:"termEntries,omitempty"`
-	StoredEntries    []*BackIndexStoreEntry `protobuf:"bytes,2,rep,name=storedEntries" json:"storedEntries,omitempty"`
-	XXX_unrecognized []byte                 `json:"-"`
+	TermEntries      []*BackIndexTermEntry        `protobuf:"bytes,1,rep,name=termEntries" json:"termEntries,omitempty"`
+	StoredEntries    []*BackIndexStoreEntry       `protobuf:"bytes,2,rep,name=storedEntries" json:"storedEntries,omitempty"`
+	FieldLengths     []*BackIndexFieldLengthEntry `protobuf:"bytes,3,rep,name=fieldLengths" json:"fieldLengths,omitempty"`
+	XXX_unrecognized []byte                       `json:"-"`
 }
 
 func (m *BackIndexRowValue) Reset()         { *m = BackIndexRowValue{} }
@@ -94,5 +120,12 @@ func (m *BackIndexRowValue) GetStoredEntries() []*BackIndexStoreEntry {
 	return nil
 }
 
+func (m *BackIndexRowValue) GetFieldLengths() []*BackIndexFieldLengthEntry {
+	if m != nil {
+		return m.FieldLengths
+	}
+	return nil
+}
+
 func init() {
 }
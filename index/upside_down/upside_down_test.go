@@ -10,9 +10,11 @@
 package upside_down
 
 import (
+	"bytes"
 	"os"
 	"reflect"
 	"regexp"
+	"strings"
 	"testing"
 	"time"
 
@@ -108,8 +110,9 @@ func TestIndexInsert(t *testing.T) {
 		t.Errorf("Expected document count to be %d got %d", expectedCount, docCount)
 	}
 
-	// should have 4 rows (1 for version, 1 for schema field, and 1 for single term, and 1 for the term count, and 1 for the back index entry)
-	expectedLength := uint64(1 + 1 + 1 + 1 + 1)
+	// should have 8 rows (1 for version, 1 for schema field, and 1 for single term, and 1 for the term count, and 1 for the back index entry)
+	// plus 1 for the field names schema field, 1 for the field name term and 1 for its term count
+	expectedLength := uint64(1 + 1 + 1 + 1 + 1 + 3)
 	rowCount := idx.rowCount()
 	if rowCount != expectedLength {
 		t.Errorf("expected %d rows, got: %d", expectedLength, rowCount)
@@ -189,8 +192,8 @@ func TestIndexInsertThenDelete(t *testing.T) {
 		t.Errorf("Expected document count to be %d got %d", expectedCount, docCount)
 	}
 
-	// should have 2 rows (1 for version, 1 for schema field)
-	expectedLength := uint64(1 + 1)
+	// should have 3 rows (1 for version, 1 for schema field, 1 for the field names schema field)
+	expectedLength := uint64(1 + 1 + 1)
 	rowCount := idx.rowCount()
 	if rowCount != expectedLength {
 		t.Errorf("expected %d rows, got: %d", expectedLength, rowCount)
@@ -224,8 +227,9 @@ func TestIndexInsertThenUpdate(t *testing.T) {
 		t.Errorf("Error deleting entry from index: %v", err)
 	}
 
-	// should have 2 rows (1 for version, 1 for schema field, and 2 for the two term, and 2 for the term counts, and 1 for the back index entry)
-	expectedLength := uint64(1 + 1 + 2 + 2 + 1)
+	// should have 10 rows (1 for version, 1 for schema field, and 2 for the two term, and 2 for the term counts, and 1 for the back index entry)
+	// plus 3 for the field names schema field, term and term count
+	expectedLength := uint64(1 + 1 + 2 + 2 + 1 + 3)
 	rowCount := idx.rowCount()
 	if rowCount != expectedLength {
 		t.Errorf("expected %d rows, got: %d", expectedLength, rowCount)
@@ -239,8 +243,9 @@ func TestIndexInsertThenUpdate(t *testing.T) {
 		t.Errorf("Error deleting entry from index: %v", err)
 	}
 
-	// should have 2 rows (1 for version, 1 for schema field, and 1 for the remaining term, and 1 for the term count, and 1 for the back index entry)
-	expectedLength = uint64(1 + 1 + 1 + 1 + 1)
+	// should have 8 rows (1 for version, 1 for schema field, and 1 for the remaining term, and 1 for the term count, and 1 for the back index entry)
+	// plus 3 for the field names schema field, term and term count
+	expectedLength = uint64(1 + 1 + 1 + 1 + 1 + 3)
 	rowCount = idx.rowCount()
 	if rowCount != expectedLength {
 		t.Errorf("expected %d rows, got: %d", expectedLength, rowCount)
@@ -276,8 +281,9 @@ func TestIndexInsertMultiple(t *testing.T) {
 	}
 	expectedCount++
 
-	// should have 4 rows (1 for version, 1 for schema field, and 2 for single term, and 1 for the term count, and 2 for the back index entries)
-	expectedLength := uint64(1 + 1 + 2 + 1 + 2)
+	// should have 11 rows (1 for version, 1 for schema field, and 2 for single term, and 1 for the term count, and 2 for the back index entries)
+	// plus 1 for the field names schema field, 2 for the field name term and 1 for its term count
+	expectedLength := uint64(1 + 1 + 2 + 1 + 2 + 4)
 	rowCount := idx.rowCount()
 	if rowCount != expectedLength {
 		t.Errorf("expected %d rows, got: %d", expectedLength, rowCount)
@@ -350,8 +356,9 @@ func TestIndexInsertWithStore(t *testing.T) {
 		t.Errorf("Expected document count to be %d got %d", expectedCount, docCount)
 	}
 
-	// should have 6 rows (1 for version, 1 for schema field, and 1 for single term, and 1 for the stored field and 1 for the term count, and 1 for the back index entry)
-	expectedLength := uint64(1 + 1 + 1 + 1 + 1 + 1)
+	// should have 9 rows (1 for version, 1 for schema field, and 1 for single term, and 1 for the stored field and 1 for the term count, and 1 for the back index entry)
+	// plus 3 for the field names schema field, term and term count
+	expectedLength := uint64(1 + 1 + 1 + 1 + 1 + 1 + 3)
 	rowCount := idx.rowCount()
 	if rowCount != expectedLength {
 		t.Errorf("expected %d rows, got: %d", expectedLength, rowCount)
@@ -380,6 +387,88 @@ func TestIndexInsertWithStore(t *testing.T) {
 	}
 }
 
+func TestIndexInsertWithStoreCompressed(t *testing.T) {
+	defer os.RemoveAll("test")
+
+	store, err := boltdb.Open("test", "bleve")
+	if err != nil {
+		t.Error(err)
+	}
+	analysisQueue := NewAnalysisQueue(1)
+	idx := NewUpsideDownCouch(store, analysisQueue)
+	err = idx.Open()
+	if err != nil {
+		t.Errorf("error opening index: %v", err)
+	}
+	defer idx.Close()
+
+	large := strings.Repeat("the quick brown fox jumps over the lazy dog ", 100)
+	doc := document.NewDocument("1")
+	doc.AddField(document.NewTextFieldWithIndexingOptions("plain", []uint64{}, []byte(large), document.IndexField|document.StoreField))
+	doc.AddField(document.NewTextFieldWithIndexingOptions("packed", []uint64{}, []byte(large), document.IndexField|document.StoreField|document.CompressStoredField))
+	doc.AddField(document.NewTextFieldWithIndexingOptions("short", []uint64{}, []byte("fox"), document.IndexField|document.StoreField|document.CompressStoredField))
+	err = idx.Update(doc)
+	if err != nil {
+		t.Errorf("Error updating index: %v", err)
+	}
+
+	// compare the size of the stored rows
+	kvreader, err := store.Reader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	storedSizes := map[string]int{}
+	prefix := NewStoredRow("1", 0, []uint64{}, 'x', nil).ScanPrefixForDoc()
+	it := kvreader.Iterator(prefix)
+	key, val, valid := it.Current()
+	for valid && bytes.HasPrefix(key, prefix) {
+		row, err := NewStoredRowKV(key, val)
+		if err != nil {
+			t.Fatal(err)
+		}
+		storedSizes[idx.fieldIndexCache.FieldName(row.field)] = len(val)
+		it.Next()
+		key, val, valid = it.Current()
+	}
+	it.Close()
+	kvreader.Close()
+	if storedSizes["packed"] >= storedSizes["plain"] {
+		t.Errorf("expected compressed field to be stored in less than %d bytes, got %d", storedSizes["plain"], storedSizes["packed"])
+	}
+	if storedSizes["short"] != len("fox")+1 {
+		t.Errorf("expected short field to be stored uncompressed in %d bytes, got %d", len("fox")+1, storedSizes["short"])
+	}
+
+	indexReader, err := idx.Reader()
+	if err != nil {
+		t.Error(err)
+	}
+	defer indexReader.Close()
+
+	storedDoc, err := indexReader.Document("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(storedDoc.Fields) != 3 {
+		t.Fatalf("expected 3 stored fields, got %d", len(storedDoc.Fields))
+	}
+	expected := map[string]string{
+		"plain":  large,
+		"packed": large,
+		"short":  "fox",
+	}
+	for _, field := range storedDoc.Fields {
+		textField, ok := field.(*document.TextField)
+		if !ok {
+			t.Errorf("expected text field for %s", field.Name())
+			continue
+		}
+		if string(textField.Value()) != expected[field.Name()] {
+			t.Errorf("expected field %s content '%s', got '%s'", field.Name(), expected[field.Name()], string(textField.Value()))
+		}
+	}
+}
+
 func TestIndexInternalCRUD(t *testing.T) {
 	defer os.RemoveAll("test")
 
@@ -577,7 +666,7 @@ func TestIndexInsertUpdateDeleteWithMultipleTypesStored(t *testing.T) {
 		t.Errorf("Expected document count to be %d got %d", expectedCount, docCount)
 	}
 
-	// should have 72 rows
+	// should have 81 rows
 	// 1 for version
 	// 3 for schema fields
 	// 1 for text term
@@ -588,7 +677,10 @@ func TestIndexInsertUpdateDeleteWithMultipleTypesStored(t *testing.T) {
 	// 16 for numeric term counts
 	// 16 for date term counts
 	// 1 for the back index entry
-	expectedLength := uint64(1 + 3 + 1 + (64 / document.DefaultPrecisionStep) + (64 / document.DefaultPrecisionStep) + 3 + 1 + (64 / document.DefaultPrecisionStep) + (64 / document.DefaultPrecisionStep) + 1)
+	// 1 for the field names schema field
+	// 3 for field name terms
+	// 3 for field name term counts
+	expectedLength := uint64(1 + 3 + 1 + (64 / document.DefaultPrecisionStep) + (64 / document.DefaultPrecisionStep) + 3 + 1 + (64 / document.DefaultPrecisionStep) + (64 / document.DefaultPrecisionStep) + 1 + 1 + 3 + 3)
 	rowCount := idx.rowCount()
 	if rowCount != expectedLength {
 		t.Errorf("expected %d rows, got: %d", expectedLength, rowCount)
@@ -774,14 +866,17 @@ func TestIndexUpdateComposites(t *testing.T) {
 		t.Errorf("Error updating index: %v", err)
 	}
 
-	// should have 72 rows
+	// should have 22 rows
 	// 1 for version
 	// 3 for schema fields
 	// 4 for text term
 	// 2 for the stored field
 	// 4 for the text term count
 	// 1 for the back index entry
-	expectedLength := uint64(1 + 3 + 4 + 2 + 4 + 1)
+	// 1 for the field names schema field
+	// 3 for field name terms
+	// 3 for field name term counts
+	expectedLength := uint64(1 + 3 + 4 + 2 + 4 + 1 + 1 + 3 + 3)
 	rowCount := idx.rowCount()
 	if rowCount != expectedLength {
 		t.Errorf("expected %d rows, got: %d", expectedLength, rowCount)
@@ -858,8 +953,12 @@ func TestIndexFieldsMisc(t *testing.T) {
 		t.Errorf("expected field named 'title', got '%s'", fieldName2)
 	}
 	fieldName3 := idx.fieldIndexCache.FieldName(3)
-	if fieldName3 != "" {
-		t.Errorf("expected field named '', got '%s'", fieldName3)
+	if fieldName3 != index.FieldNamesField {
+		t.Errorf("expected field named '%s', got '%s'", index.FieldNamesField, fieldName3)
+	}
+	fieldName4 := idx.fieldIndexCache.FieldName(4)
+	if fieldName4 != "" {
+		t.Errorf("expected field named '', got '%s'", fieldName4)
 	}
 
 }
@@ -911,6 +1010,88 @@ func TestIndexTermReaderCompositeFields(t *testing.T) {
 	}
 }
 
+func TestIndexTermVectorArrayPositions(t *testing.T) {
+	defer os.RemoveAll("test")
+
+	store, err := boltdb.Open("test", "bleve")
+	if err != nil {
+		t.Error(err)
+	}
+	analysisQueue := NewAnalysisQueue(1)
+	idx := NewUpsideDownCouch(store, analysisQueue)
+	err = idx.Open()
+	if err != nil {
+		t.Errorf("error opening index: %v", err)
+	}
+	defer idx.Close()
+
+	// the values of a field in different array positions
+	// share the term frequency row
+	doc := document.NewDocument("1")
+	doc.AddField(document.NewTextFieldWithIndexingOptions("name", []uint64{0}, []byte("test"), document.IndexField|document.IncludeTermVectors))
+	doc.AddField(document.NewTextFieldWithIndexingOptions("name", []uint64{1}, []byte("other"), document.IndexField|document.IncludeTermVectors))
+	doc.AddField(document.NewTextFieldWithIndexingOptions("name", []uint64{2}, []byte("test"), document.IndexField|document.IncludeTermVectors))
+	err = idx.Update(doc)
+	if err != nil {
+		t.Errorf("Error updating index: %v", err)
+	}
+
+	indexReader, err := idx.Reader()
+	if err != nil {
+		t.Error(err)
+	}
+	defer indexReader.Close()
+
+	termFieldReader, err := indexReader.TermFieldReader([]byte("test"), "name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer termFieldReader.Close()
+
+	tfd, err := termFieldReader.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tfd == nil || tfd.Freq != 2 || len(tfd.Vectors) != 2 {
+		t.Fatalf("expected both values in one match, got %v", tfd)
+	}
+	arrayPositions := map[uint64]bool{}
+	for _, vector := range tfd.Vectors {
+		if len(vector.ArrayPositions) != 1 {
+			t.Fatalf("expected one array position, got %v", vector.ArrayPositions)
+		}
+		arrayPositions[vector.ArrayPositions[0]] = true
+	}
+	if !arrayPositions[0] || !arrayPositions[2] {
+		t.Errorf("expected array positions 0 and 2, got %v", arrayPositions)
+	}
+}
+
+func TestIndexOpenIncompatibleVersion(t *testing.T) {
+	defer os.RemoveAll("test")
+
+	store, err := boltdb.Open("test", "bleve")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer, err := store.Writer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = writer.Set(VersionKey, NewVersionRow(Version-1).Value())
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer.Close()
+
+	idx := NewUpsideDownCouch(store, NewAnalysisQueue(1))
+	err = idx.Open()
+	if err != IncompatibleVersionErr {
+		t.Errorf("expected %v, got %v", IncompatibleVersionErr, err)
+	}
+	idx.Close()
+}
+
 func TestIndexDocumentFieldTerms(t *testing.T) {
 	defer os.RemoveAll("test")
 
@@ -952,3 +1133,89 @@ func TestIndexDocumentFieldTerms(t *testing.T) {
 		t.Errorf("expected field terms: %#v, got: %#v", expectedFieldTerms, fieldTerms)
 	}
 }
+
+func TestIndexFieldStats(t *testing.T) {
+	defer os.RemoveAll("test")
+
+	store, err := boltdb.Open("test", "bleve")
+	if err != nil {
+		t.Fatal(err)
+	}
+	analysisQueue := NewAnalysisQueue(1)
+	idx := NewUpsideDownCouch(store, analysisQueue)
+	err = idx.Open()
+	if err != nil {
+		t.Fatalf("error opening index: %v", err)
+	}
+
+	checkStats := func(idx *UpsideDownCouch, field string, expected index.FieldStats) {
+		indexReader, err := idx.Reader()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer indexReader.Close()
+		actual := indexReader.FieldStats(field)
+		if actual != expected {
+			t.Errorf("expected %v for field %s, got %v", expected, field, actual)
+		}
+	}
+
+	doc := document.NewDocument("1")
+	doc.AddField(document.NewTextFieldWithAnalyzer("name", []uint64{}, []byte("the quick fox"), testAnalyzer))
+	doc.AddField(document.NewTextFieldWithAnalyzer("desc", []uint64{}, []byte("jumps"), testAnalyzer))
+	err = idx.Update(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc = document.NewDocument("2")
+	doc.AddField(document.NewTextFieldWithAnalyzer("name", []uint64{}, []byte("lazy dog"), testAnalyzer))
+	err = idx.Update(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkStats(idx, "name", index.FieldStats{DocCount: 2, TotalLength: 5})
+	checkStats(idx, "desc", index.FieldStats{DocCount: 1, TotalLength: 1})
+	checkStats(idx, "missing", index.FieldStats{})
+
+	// updates replace the old lengths, deletes remove them
+	batch := index.NewBatch()
+	doc = document.NewDocument("2")
+	doc.AddField(document.NewTextFieldWithAnalyzer("name", []uint64{}, []byte("a very lazy dog"), testAnalyzer))
+	batch.Update(doc)
+	batch.Delete("1")
+	err = idx.Batch(batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkStats(idx, "name", index.FieldStats{DocCount: 1, TotalLength: 4})
+	checkStats(idx, "desc", index.FieldStats{})
+
+	doc = document.NewDocument("3")
+	doc.AddField(document.NewTextFieldWithAnalyzer("name", []uint64{}, []byte("six"), testAnalyzer))
+	err = idx.Update(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = idx.Delete("2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkStats(idx, "name", index.FieldStats{DocCount: 1, TotalLength: 1})
+
+	// the stats are rebuilt on reopen
+	err = idx.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err = boltdb.Open("test", "bleve")
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx = NewUpsideDownCouch(store, analysisQueue)
+	err = idx.Open()
+	if err != nil {
+		t.Fatalf("error opening index: %v", err)
+	}
+	defer idx.Close()
+	checkStats(idx, "name", index.FieldStats{DocCount: 1, TotalLength: 1})
+}
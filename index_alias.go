@@ -23,10 +23,17 @@ package bleve
 // are atomic, so you can safely change the
 // underlying Index objects while other components
 // are performing operations.
+//
+// By default a search fails if any of the underlying
+// indexes fails.  With SetPartialResults(true) the
+// results of the other indexes are returned, with the
+// errors in the Errors of the SearchResult, and the
+// search only fails if all of the indexes do.
 type IndexAlias interface {
 	Index
 
 	Add(i ...Index)
 	Remove(i ...Index)
 	Swap(in, out []Index)
+	SetPartialResults(partial bool)
 }
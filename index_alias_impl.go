@@ -10,8 +10,11 @@
 package bleve
 
 import (
+	"context"
+	"io"
 	"sort"
 	"sync"
+	"time"
 
 	"github.com/blevesearch/bleve/document"
 	"github.com/blevesearch/bleve/index"
@@ -23,6 +26,7 @@ type indexAliasImpl struct {
 	indexes []Index
 	mutex   sync.RWMutex
 	open    bool
+	partial bool
 }
 
 // NewIndexAlias creates a new IndexAlias over the provided
@@ -59,6 +63,22 @@ func (i *indexAliasImpl) Index(id string, data interface{}) error {
 	return i.indexes[0].Index(id, data)
 }
 
+func (i *indexAliasImpl) IndexWithTTL(id string, data interface{}, ttl time.Duration) error {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	if !i.open {
+		return ErrorIndexClosed
+	}
+
+	err := i.isAliasToSingleIndex()
+	if err != nil {
+		return err
+	}
+
+	return i.indexes[0].IndexWithTTL(id, data, ttl)
+}
+
 func (i *indexAliasImpl) Delete(id string) error {
 	i.mutex.RLock()
 	defer i.mutex.RUnlock()
@@ -75,6 +95,54 @@ func (i *indexAliasImpl) Delete(id string) error {
 	return i.indexes[0].Delete(id)
 }
 
+func (i *indexAliasImpl) DeleteByQuery(q Query) (uint64, error) {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	if !i.open {
+		return 0, ErrorIndexClosed
+	}
+
+	err := i.isAliasToSingleIndex()
+	if err != nil {
+		return 0, err
+	}
+
+	return i.indexes[0].DeleteByQuery(q)
+}
+
+func (i *indexAliasImpl) Update(id string, fields map[string]interface{}) error {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	if !i.open {
+		return ErrorIndexClosed
+	}
+
+	err := i.isAliasToSingleIndex()
+	if err != nil {
+		return err
+	}
+
+	return i.indexes[0].Update(id, fields)
+}
+
+func (i *indexAliasImpl) UpdateByQuery(q Query, fields map[string]interface{}) (uint64, error) {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	if !i.open {
+		return 0, ErrorIndexClosed
+	}
+
+	err := i.isAliasToSingleIndex()
+	if err != nil {
+		return 0, err
+	}
+
+	return i.indexes[0].UpdateByQuery(q, fields)
+}
+
 func (i *indexAliasImpl) Batch(b *Batch) error {
 	i.mutex.RLock()
 	defer i.mutex.RUnlock()
@@ -107,6 +175,54 @@ func (i *indexAliasImpl) Document(id string) (*document.Document, error) {
 	return i.indexes[0].Document(id)
 }
 
+func (i *indexAliasImpl) Backup(w io.Writer) error {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	if !i.open {
+		return ErrorIndexClosed
+	}
+
+	err := i.isAliasToSingleIndex()
+	if err != nil {
+		return err
+	}
+
+	return i.indexes[0].Backup(w)
+}
+
+func (i *indexAliasImpl) Documents(ids []string) (map[string]*document.Document, error) {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	if !i.open {
+		return nil, ErrorIndexClosed
+	}
+
+	err := i.isAliasToSingleIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	return i.indexes[0].Documents(ids)
+}
+
+func (i *indexAliasImpl) DocumentField(id, field string) (interface{}, error) {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	if !i.open {
+		return nil, ErrorIndexClosed
+	}
+
+	err := i.isAliasToSingleIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	return i.indexes[0].DocumentField(id, field)
+}
+
 func (i *indexAliasImpl) DocCount() (uint64, error) {
 	i.mutex.RLock()
 	defer i.mutex.RUnlock()
@@ -128,7 +244,128 @@ func (i *indexAliasImpl) DocCount() (uint64, error) {
 	return rv, nil
 }
 
+// Explain returns the explanation of the first index of
+// the alias where the document matches q, each index
+// scoring with its own statistics.
+func (i *indexAliasImpl) Explain(q Query, id string) (*search.Explanation, error) {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	if !i.open {
+		return nil, ErrorIndexClosed
+	}
+
+	if len(i.indexes) < 1 {
+		return nil, ErrorAliasEmpty
+	}
+
+	for _, index := range i.indexes {
+		expl, err := index.Explain(q, id)
+		if err != nil {
+			return nil, err
+		}
+		if expl != nil {
+			return expl, nil
+		}
+	}
+	return nil, nil
+}
+
+// AnalyzeQuery adds up the document frequencies which
+// the indexes of the alias report for the term clauses
+// of q.
+func (i *indexAliasImpl) AnalyzeQuery(q Query) (QueryAnalysis, error) {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	if !i.open {
+		return QueryAnalysis{}, ErrorIndexClosed
+	}
+
+	var rv QueryAnalysis
+	for in, index := range i.indexes {
+		qa, err := index.AnalyzeQuery(q)
+		if err != nil {
+			return QueryAnalysis{}, err
+		}
+		if in == 0 {
+			rv = qa
+			continue
+		}
+		for ti, t := range qa.Terms {
+			if ti < len(rv.Terms) {
+				rv.Terms[ti].DocFreq += t.DocFreq
+			}
+		}
+	}
+	return rv, nil
+}
+
+// SuggestTerms merges the suggestions of the indexes of
+// the alias, adding up the document frequencies of the
+// terms found in several of them.
+func (i *indexAliasImpl) SuggestTerms(field, term string, maxEdits, maxSuggestions int) ([]Suggestion, error) {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	if !i.open {
+		return nil, ErrorIndexClosed
+	}
+
+	rv := make([]Suggestion, 0)
+	positions := make(map[string]int)
+	for _, index := range i.indexes {
+		suggestions, err := index.SuggestTerms(field, term, maxEdits, 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range suggestions {
+			pos, seen := positions[s.Term]
+			if seen {
+				rv[pos].DocFreq += s.DocFreq
+				continue
+			}
+			positions[s.Term] = len(rv)
+			rv = append(rv, s)
+		}
+	}
+	return sortSuggestions(rv, maxSuggestions), nil
+}
+
+func (i *indexAliasImpl) Warmup(ctx context.Context, maxBytes uint64) error {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	if !i.open {
+		return ErrorIndexClosed
+	}
+
+	for _, index := range i.indexes {
+		err := index.Warmup(ctx, maxBytes)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RegisterListener registers the listener with each of
+// the indexes currently in the alias.  Indexes added to
+// the alias later are not listened to.
+func (i *indexAliasImpl) RegisterListener(l IndexListener) {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	for _, index := range i.indexes {
+		index.RegisterListener(l)
+	}
+}
+
 func (i *indexAliasImpl) Search(req *SearchRequest) (*SearchResult, error) {
+	return i.SearchInContext(context.Background(), req)
+}
+
+func (i *indexAliasImpl) SearchInContext(ctx context.Context, req *SearchRequest) (*SearchResult, error) {
 	i.mutex.RLock()
 	defer i.mutex.RUnlock()
 
@@ -142,10 +379,43 @@ func (i *indexAliasImpl) Search(req *SearchRequest) (*SearchResult, error) {
 
 	// short circuit the simple case
 	if len(i.indexes) == 1 {
-		return i.indexes[0].Search(req)
+		return i.indexes[0].SearchInContext(ctx, req)
 	}
 
-	return MultiSearch(req, i.indexes...)
+	return multiSearch(ctx, req, i.partial, i.indexes...)
+}
+
+// SearchIter iterates over the matches of each index
+// of the alias in turn.
+func (i *indexAliasImpl) SearchIter(req *SearchRequest) (ResultIterator, error) {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	if !i.open {
+		return nil, ErrorIndexClosed
+	}
+
+	if len(i.indexes) < 1 {
+		return nil, ErrorAliasEmpty
+	}
+
+	// short circuit the simple case
+	if len(i.indexes) == 1 {
+		return i.indexes[0].SearchIter(req)
+	}
+
+	rv := &multiResultIterator{
+		iterators: make([]ResultIterator, 0, len(i.indexes)),
+	}
+	for _, in := range i.indexes {
+		iterator, err := in.SearchIter(req)
+		if err != nil {
+			rv.Close()
+			return nil, err
+		}
+		rv.iterators = append(rv.iterators, iterator)
+	}
+	return rv, nil
 }
 
 func (i *indexAliasImpl) Fields() ([]string, error) {
@@ -252,6 +522,22 @@ func (i *indexAliasImpl) Stats() *IndexStat {
 	return i.indexes[0].Stats()
 }
 
+func (i *indexAliasImpl) StatsMap() map[string]interface{} {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	if !i.open {
+		return nil
+	}
+
+	err := i.isAliasToSingleIndex()
+	if err != nil {
+		return nil
+	}
+
+	return i.indexes[0].StatsMap()
+}
+
 func (i *indexAliasImpl) GetInternal(key []byte) ([]byte, error) {
 	i.mutex.RLock()
 	defer i.mutex.RUnlock()
@@ -323,6 +609,13 @@ func (i *indexAliasImpl) Add(indexes ...Index) {
 	i.indexes = append(i.indexes, indexes...)
 }
 
+func (i *indexAliasImpl) SetPartialResults(partial bool) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	i.partial = partial
+}
+
 func (i *indexAliasImpl) removeSingle(index Index) {
 	for pos, in := range i.indexes {
 		if in == index {
@@ -355,7 +648,8 @@ func (i *indexAliasImpl) Swap(in, out []Index) {
 }
 
 // createChildSearchRequest creates a separate
-// request from the original
+// request from the original, asking each index for
+// enough hits to fill the requested page once merged.
 // For now, avoid data race on req structure.
 // TODO disable highligh/field load on child
 // requests, and add code to do this only on
@@ -365,12 +659,34 @@ func (i *indexAliasImpl) Swap(in, out []Index) {
 func createChildSearchRequest(req *SearchRequest) *SearchRequest {
 	rv := SearchRequest{
 		Query:     req.Query,
-		Size:      req.Size,
+		Size:      req.From + req.Size,
 		From:      0,
 		Highlight: req.Highlight,
 		Fields:    req.Fields,
 		Facets:    req.Facets,
 		Explain:   req.Explain,
+		IDsOnly:   req.IDsOnly,
+
+		IncludeLocations: req.IncludeLocations,
+
+		Aggregations: req.Aggregations,
+
+		CollapseField:          req.CollapseField,
+		CollapseSize:           req.CollapseSize,
+		CollapseExcludeMissing: req.CollapseExcludeMissing,
+
+		Sort:        req.Sort,
+		SearchAfter: req.SearchAfter,
+
+		ScoreNormalization: req.ScoreNormalization,
+
+		PostFilters: req.PostFilters,
+		Parallelism: req.Parallelism,
+
+		MinScore: req.MinScore,
+
+		MaxClauseCount:  req.MaxClauseCount,
+		TruncateClauses: req.TruncateClauses,
 	}
 	return &rv
 }
@@ -378,67 +694,87 @@ func createChildSearchRequest(req *SearchRequest) *SearchRequest {
 // MultiSearch executes a SearchRequest across multiple
 // Index objects, then merges the results.
 func MultiSearch(req *SearchRequest, indexes ...Index) (*SearchResult, error) {
-	results := make(chan *SearchResult)
-	errs := make(chan error)
+	return MultiSearchInContext(context.Background(), req, indexes...)
+}
 
-	// run search on each index in separate go routine
-	var waitGroup sync.WaitGroup
+// MultiSearchInContext executes a SearchRequest across
+// multiple Index objects, each collecting matches until
+// the context is done, then merges the results.
+func MultiSearchInContext(ctx context.Context, req *SearchRequest, indexes ...Index) (*SearchResult, error) {
+	return multiSearch(ctx, req, false, indexes...)
+}
 
-	var searchChildIndex = func(waitGroup *sync.WaitGroup, in Index, results chan *SearchResult, errs chan error) {
-		go func() {
-			defer waitGroup.Done()
-			childReq := createChildSearchRequest(req)
-			searchResult, err := in.Search(childReq)
-			if err != nil {
-				errs <- err
-			} else {
-				results <- searchResult
-			}
-		}()
+// childSearchResult is the outcome of searching one index
+type childSearchResult struct {
+	sr  *SearchResult
+	err error
+}
+
+// multiSearch searches each index concurrently and merges
+// the results.  Unless partial, the first error fails the
+// search, otherwise the errors are kept in the result and
+// only returned when all of the indexes fail.
+func multiSearch(ctx context.Context, req *SearchRequest, partial bool, indexes ...Index) (*SearchResult, error) {
+	if len(indexes) < 1 {
+		return nil, ErrorAliasEmpty
 	}
 
+	// buffered, so searches still running when one fails
+	// do not block forever
+	results := make(chan childSearchResult, len(indexes))
 	for _, in := range indexes {
-		waitGroup.Add(1)
-		searchChildIndex(&waitGroup, in, results, errs)
+		go func(in Index) {
+			childReq := createChildSearchRequest(req)
+			searchResult, err := in.SearchInContext(ctx, childReq)
+			results <- childSearchResult{sr: searchResult, err: err}
+		}(in)
 	}
 
-	// on another go routine, close after finished
-	go func() {
-		waitGroup.Wait()
-		close(results)
-		close(errs)
-	}()
-
 	var sr *SearchResult
-	var err error
-	var result *SearchResult
-	ok := true
-	for ok {
-		select {
-		case result, ok = <-results:
-			if ok {
-				if sr == nil {
-					// first result
-					sr = result
-				} else {
-					// merge with previous
-					sr.Merge(result)
-				}
-			}
-		case err, ok = <-errs:
-			// for now stop on any error
-			// FIXME offer other behaviors
-			if err != nil {
-				return nil, err
+	var errs []error
+	for range indexes {
+		result := <-results
+		if result.err != nil {
+			if !partial {
+				return nil, result.err
 			}
+			errs = append(errs, result.err)
+			continue
+		}
+		if sr == nil {
+			// first result
+			sr = result.sr
+		} else {
+			// merge with previous
+			sr.Merge(result.sr)
+		}
+	}
+	if sr == nil {
+		return nil, errs[0]
+	}
+	sr.Errors = append(sr.Errors, errs...)
+
+	// the children normalized by their own best match
+	sr.normalizeScores(req.ScoreNormalization)
+
+	if req.CollapseField != "" {
+		fixupCollapsedResult(req, sr)
+		for name, fr := range req.Facets {
+			sr.Facets.Fixup(name, fr.Size)
+			sr.Facets.OrderTerms(name, fr.Order)
 		}
+		return sr, nil
 	}
 
 	// merge just concatenated all the hits
 	// now lets clean it up
 
-	// first sort it by score
-	sort.Sort(sr.Hits)
+	// first sort it by score, or the requested order
+	if len(req.Sort) > 0 {
+		sort.Stable(&sortedHits{hits: sr.Hits, order: req.Sort})
+	} else {
+		sort.Sort(sr.Hits)
+	}
 
 	// now skip over the correct From
 	if req.From > 0 && len(sr.Hits) > req.From {
@@ -455,7 +791,50 @@ func MultiSearch(req *SearchRequest, indexes ...Index) (*SearchResult, error) {
 	// fix up facets
 	for name, fr := range req.Facets {
 		sr.Facets.Fixup(name, fr.Size)
+		sr.Facets.OrderTerms(name, fr.Order)
 	}
 
 	return sr, nil
 }
+
+// sortedHits sorts hits by their computed sort values
+type sortedHits struct {
+	hits  search.DocumentMatchCollection
+	order search.SortOrder
+}
+
+func (s *sortedHits) Len() int      { return len(s.hits) }
+func (s *sortedHits) Swap(i, j int) { s.hits[i], s.hits[j] = s.hits[j], s.hits[i] }
+func (s *sortedHits) Less(i, j int) bool {
+	return s.order.Compare(s.hits[i], s.hits[j]) < 0
+}
+
+// fixupCollapsedResult re-applies the per group size and
+// the paging over groups after merging collapsed results
+func fixupCollapsedResult(req *SearchRequest, sr *SearchResult) {
+	groupSize := req.CollapseSize
+	if groupSize < 1 {
+		groupSize = 1
+	}
+	for _, group := range sr.Groups {
+		sort.Stable(group.Hits)
+		if len(group.Hits) > groupSize {
+			group.Hits = group.Hits[0:groupSize]
+		}
+	}
+	sort.Stable(sr.Groups)
+
+	if req.From > 0 && len(sr.Groups) > req.From {
+		sr.Groups = sr.Groups[req.From:]
+	} else if req.From > 0 {
+		sr.Groups = search.DocumentMatchGroupCollection{}
+	}
+	if req.Size > 0 && len(sr.Groups) > req.Size {
+		sr.Groups = sr.Groups[0:req.Size]
+	}
+
+	sr.Hits = make(search.DocumentMatchCollection, 0)
+	for _, group := range sr.Groups {
+		sr.Hits = append(sr.Hits, group.Hits...)
+	}
+}
@@ -1,8 +1,11 @@
 package bleve
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -281,6 +284,26 @@ func TestIndexAliasClosed(t *testing.T) {
 		t.Errorf("expected %v, got %v", ErrorIndexClosed, err)
 	}
 
+	_, err = alias.DeleteByQuery(NewMatchAllQuery())
+	if err != ErrorIndexClosed {
+		t.Errorf("expected %v, got %v", ErrorIndexClosed, err)
+	}
+
+	err = alias.Update("a", map[string]interface{}{"a": "a"})
+	if err != ErrorIndexClosed {
+		t.Errorf("expected %v, got %v", ErrorIndexClosed, err)
+	}
+
+	_, err = alias.UpdateByQuery(NewMatchAllQuery(), map[string]interface{}{"a": "a"})
+	if err != ErrorIndexClosed {
+		t.Errorf("expected %v, got %v", ErrorIndexClosed, err)
+	}
+
+	_, err = alias.SearchIter(NewSearchRequest(NewMatchAllQuery()))
+	if err != ErrorIndexClosed {
+		t.Errorf("expected %v, got %v", ErrorIndexClosed, err)
+	}
+
 	err = alias.Batch(NewBatch())
 	if err != ErrorIndexClosed {
 		t.Errorf("expected %v, got %v", ErrorIndexClosed, err)
@@ -291,6 +314,11 @@ func TestIndexAliasClosed(t *testing.T) {
 		t.Errorf("expected %v, got %v", ErrorIndexClosed, err)
 	}
 
+	_, err = alias.Documents([]string{"a"})
+	if err != ErrorIndexClosed {
+		t.Errorf("expected %v, got %v", ErrorIndexClosed, err)
+	}
+
 	_, err = alias.Fields()
 	if err != ErrorIndexClosed {
 		t.Errorf("expected %v, got %v", ErrorIndexClosed, err)
@@ -362,6 +390,26 @@ func TestIndexAliasEmpty(t *testing.T) {
 		t.Errorf("expected %v, got %v", ErrorAliasEmpty, err)
 	}
 
+	_, err = alias.DeleteByQuery(NewMatchAllQuery())
+	if err != ErrorAliasEmpty {
+		t.Errorf("expected %v, got %v", ErrorAliasEmpty, err)
+	}
+
+	err = alias.Update("a", map[string]interface{}{"a": "a"})
+	if err != ErrorAliasEmpty {
+		t.Errorf("expected %v, got %v", ErrorAliasEmpty, err)
+	}
+
+	_, err = alias.UpdateByQuery(NewMatchAllQuery(), map[string]interface{}{"a": "a"})
+	if err != ErrorAliasEmpty {
+		t.Errorf("expected %v, got %v", ErrorAliasEmpty, err)
+	}
+
+	_, err = alias.SearchIter(NewSearchRequest(NewMatchAllQuery()))
+	if err != ErrorAliasEmpty {
+		t.Errorf("expected %v, got %v", ErrorAliasEmpty, err)
+	}
+
 	err = alias.Batch(NewBatch())
 	if err != ErrorAliasEmpty {
 		t.Errorf("expected %v, got %v", ErrorAliasEmpty, err)
@@ -372,6 +420,11 @@ func TestIndexAliasEmpty(t *testing.T) {
 		t.Errorf("expected %v, got %v", ErrorAliasEmpty, err)
 	}
 
+	_, err = alias.Documents([]string{"a"})
+	if err != ErrorAliasEmpty {
+		t.Errorf("expected %v, got %v", ErrorAliasEmpty, err)
+	}
+
 	_, err = alias.Fields()
 	if err != ErrorAliasEmpty {
 		t.Errorf("expected %v, got %v", ErrorAliasEmpty, err)
@@ -611,6 +664,207 @@ func TestMultiSearchNoError(t *testing.T) {
 	}
 }
 
+func TestMultiSearchCollapse(t *testing.T) {
+	a := &search.DocumentMatch{ID: "a", Score: 1.0}
+	b := &search.DocumentMatch{ID: "b", Score: 3.0}
+	c := &search.DocumentMatch{ID: "c", Score: 2.0}
+	d := &search.DocumentMatch{ID: "d", Score: 0.5}
+	ei1 := &stubIndex{err: nil, searchResult: &SearchResult{
+		Total: 2,
+		Hits:  search.DocumentMatchCollection{a, d},
+		Groups: search.DocumentMatchGroupCollection{
+			&search.DocumentMatchGroup{Value: "x", Total: 1, Hits: search.DocumentMatchCollection{a}},
+			&search.DocumentMatchGroup{Value: "y", Total: 1, Hits: search.DocumentMatchCollection{d}},
+		},
+		TotalGroups: 2,
+		MaxScore:    1.0,
+	}}
+	ei2 := &stubIndex{err: nil, searchResult: &SearchResult{
+		Total: 2,
+		Hits:  search.DocumentMatchCollection{b, c},
+		Groups: search.DocumentMatchGroupCollection{
+			&search.DocumentMatchGroup{Value: "x", Total: 1, Hits: search.DocumentMatchCollection{b}},
+			&search.DocumentMatchGroup{Value: "z", Total: 1, Hits: search.DocumentMatchCollection{c}},
+		},
+		TotalGroups: 2,
+		MaxScore:    3.0,
+	}}
+
+	sr := NewSearchRequestOptions(NewTermQuery("test"), 2, 0, false)
+	sr.SetCollapse("domain", 1)
+	results, err := MultiSearch(sr, ei1, ei2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results.Total != 4 {
+		t.Errorf("expected total 4, got %d", results.Total)
+	}
+	if results.TotalGroups != 3 {
+		t.Errorf("expected 3 total groups, got %d", results.TotalGroups)
+	}
+	expectedHits := search.DocumentMatchCollection{b, c}
+	if !reflect.DeepEqual(results.Hits, expectedHits) {
+		t.Errorf("expected hits %v, got %v", expectedHits, results.Hits)
+	}
+	if len(results.Groups) != 2 {
+		t.Fatalf("expected 2 groups, got %d", len(results.Groups))
+	}
+	if results.Groups[0].Value != "x" || results.Groups[0].Total != 2 {
+		t.Errorf("expected merged group x with total 2, got %s with %d", results.Groups[0].Value, results.Groups[0].Total)
+	}
+	if results.Groups[1].Value != "z" {
+		t.Errorf("expected second group z, got %s", results.Groups[1].Value)
+	}
+}
+
+func TestIndexAliasMultiSortedPage(t *testing.T) {
+	index1, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index1.Close()
+	index2, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index2.Close()
+
+	// the best ratings are all in the first index
+	docs1 := map[string]map[string]interface{}{
+		"a": {"rating": 9.0, "tag": "red"},
+		"b": {"rating": 8.0, "tag": "blue"},
+		"c": {"rating": 7.0, "tag": "red"},
+		"d": {"rating": 6.0, "tag": "green"},
+	}
+	docs2 := map[string]map[string]interface{}{
+		"e": {"rating": 2.0, "tag": "red"},
+		"f": {"rating": 1.0, "tag": "blue"},
+	}
+	for idx, docs := range map[Index]map[string]map[string]interface{}{index1: docs1, index2: docs2} {
+		for id, doc := range docs {
+			err = idx.Index(id, doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	alias := NewIndexAlias(index1, index2)
+	req := NewSearchRequestOptions(NewMatchAllQuery(), 2, 2, false)
+	req.SortBy([]string{"-rating"})
+	req.AddFacet("tags", NewFacetRequest("tag", 10))
+	res, err := alias.Search(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 6 {
+		t.Errorf("expected 6 total hits, got %d", res.Total)
+	}
+	ids := []string{}
+	for _, hit := range res.Hits {
+		ids = append(ids, hit.ID)
+	}
+	if !reflect.DeepEqual(ids, []string{"c", "d"}) {
+		t.Errorf("expected page [c d], got %v", ids)
+	}
+
+	counts := map[string]int{}
+	for _, tf := range res.Facets["tags"].Terms {
+		counts[tf.Term] = tf.Count
+	}
+	expectedCounts := map[string]int{"red": 3, "blue": 2, "green": 1}
+	if !reflect.DeepEqual(counts, expectedCounts) {
+		t.Errorf("expected facet counts %v, got %v", expectedCounts, counts)
+	}
+	if res.Facets["tags"].Total != 6 {
+		t.Errorf("expected facet total 6, got %d", res.Facets["tags"].Total)
+	}
+}
+
+func TestIndexAliasMinScore(t *testing.T) {
+	index1, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index1.Close()
+	index2, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index2.Close()
+
+	err = index1.Index("a", map[string]interface{}{"desc": "fox"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = index2.Index("b", map[string]interface{}{"desc": "fox " + strings.Repeat("other words ", 10)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	alias := NewIndexAlias(index1, index2)
+	req := NewSearchRequest(NewMatchQuery("fox").SetField("desc"))
+	res, err := alias.Search(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Hits) != 2 || res.Hits[0].ID != "a" {
+		t.Fatalf("expected hits [a b], got %v", res.Hits)
+	}
+
+	// each index drops its matches scoring below the threshold
+	req.MinScore = (res.Hits[0].Score + res.Hits[1].Score) / 2
+	res, err = alias.Search(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 1 || len(res.Hits) != 1 || res.Hits[0].ID != "a" {
+		t.Errorf("expected only hit a, got %v", res.Hits)
+	}
+}
+
+func TestIndexAliasPartialResults(t *testing.T) {
+	ei1 := &stubIndex{err: nil, searchResult: &SearchResult{
+		Total: 1,
+		Hits: search.DocumentMatchCollection{
+			&search.DocumentMatch{
+				ID:    "a",
+				Score: 1.0,
+			},
+		},
+		MaxScore: 1.0,
+	}}
+	deliberate := fmt.Errorf("deliberate error")
+	ei2 := &stubIndex{err: deliberate}
+
+	alias := NewIndexAlias(ei1, ei2)
+	sr := NewSearchRequest(NewTermQuery("test"))
+	_, err := alias.Search(sr)
+	if err != deliberate {
+		t.Errorf("expected %v, got %v", deliberate, err)
+	}
+
+	alias.SetPartialResults(true)
+	res, err := alias.Search(sr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Hits) != 1 || res.Hits[0].ID != "a" {
+		t.Errorf("expected hit a, got %v", res.Hits)
+	}
+	if !reflect.DeepEqual(res.Errors, []error{deliberate}) {
+		t.Errorf("expected errors [%v], got %v", deliberate, res.Errors)
+	}
+
+	// the search still fails when every index does
+	alias = NewIndexAlias(ei2, &stubIndex{err: deliberate})
+	alias.SetPartialResults(true)
+	_, err = alias.Search(sr)
+	if err != deliberate {
+		t.Errorf("expected %v, got %v", deliberate, err)
+	}
+}
+
 // TestMultiSearchSomeError
 func TestMultiSearchSomeError(t *testing.T) {
 	ei1 := &stubIndex{err: nil, searchResult: &SearchResult{
@@ -659,10 +913,26 @@ func (i *stubIndex) Index(id string, data interface{}) error {
 	return i.err
 }
 
+func (i *stubIndex) IndexWithTTL(id string, data interface{}, ttl time.Duration) error {
+	return i.err
+}
+
 func (i *stubIndex) Delete(id string) error {
 	return i.err
 }
 
+func (i *stubIndex) DeleteByQuery(q Query) (uint64, error) {
+	return 0, i.err
+}
+
+func (i *stubIndex) Update(id string, fields map[string]interface{}) error {
+	return i.err
+}
+
+func (i *stubIndex) UpdateByQuery(q Query, fields map[string]interface{}) (uint64, error) {
+	return 0, i.err
+}
+
 func (i *stubIndex) Batch(b *Batch) error {
 	return i.err
 }
@@ -674,6 +944,22 @@ func (i *stubIndex) Document(id string) (*document.Document, error) {
 	return nil, i.err
 }
 
+func (i *stubIndex) Backup(w io.Writer) error {
+	return i.err
+}
+
+func (i *stubIndex) Warmup(ctx context.Context, maxBytes uint64) error {
+	return i.err
+}
+
+func (i *stubIndex) Documents(ids []string) (map[string]*document.Document, error) {
+	return nil, i.err
+}
+
+func (i *stubIndex) DocumentField(id, field string) (interface{}, error) {
+	return nil, i.err
+}
+
 func (i *stubIndex) DocCount() (uint64, error) {
 	if i.docCountResult != nil {
 		return *i.docCountResult, nil
@@ -688,6 +974,30 @@ func (i *stubIndex) Search(req *SearchRequest) (*SearchResult, error) {
 	return nil, i.err
 }
 
+func (i *stubIndex) AnalyzeQuery(q Query) (QueryAnalysis, error) {
+	return QueryAnalysis{}, i.err
+}
+
+func (i *stubIndex) Explain(q Query, id string) (*search.Explanation, error) {
+	return nil, i.err
+}
+
+func (i *stubIndex) SuggestTerms(field, term string, maxEdits, maxSuggestions int) ([]Suggestion, error) {
+	return nil, i.err
+}
+
+func (i *stubIndex) StatsMap() map[string]interface{} {
+	return nil
+}
+
+func (i *stubIndex) SearchInContext(ctx context.Context, req *SearchRequest) (*SearchResult, error) {
+	return i.Search(req)
+}
+
+func (i *stubIndex) SearchIter(req *SearchRequest) (ResultIterator, error) {
+	return nil, i.err
+}
+
 func (i *stubIndex) Fields() ([]string, error) {
 	return nil, i.err
 }
@@ -731,3 +1041,6 @@ func (i *stubIndex) DeleteInternal(key []byte) error {
 func (i *stubIndex) Advanced() (index.Index, store.KVStore, error) {
 	return nil, nil, nil
 }
+
+func (i *stubIndex) RegisterListener(l IndexListener) {
+}
@@ -0,0 +1,134 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package bleve
+
+import (
+	"encoding/json"
+)
+
+// DefaultAutoBatchSize is the number of operations an
+// AutoBatcher accumulates when created with no maximum.
+const DefaultAutoBatchSize = 1000
+
+// An AutoBatcher accumulates index and delete operations
+// in a Batch, executing it on the index and starting a
+// new one each time the batch holds maxOps operations,
+// or values of about maxBytes bytes when SetMaxBytes was
+// called, so memory stays bounded while streaming many
+// documents in.  Flush must be called once done, to
+// execute the operations left.  An AutoBatcher is not
+// safe for concurrent use.
+type AutoBatcher struct {
+	index    Index
+	maxOps   int
+	maxBytes int
+
+	batch   *Batch
+	bytes   int
+	flushes int
+}
+
+// AutoBatch returns an AutoBatcher for the index, flushing
+// every maxOps operations, DefaultAutoBatchSize if maxOps
+// is not positive.
+func AutoBatch(index Index, maxOps int) *AutoBatcher {
+	if maxOps <= 0 {
+		maxOps = DefaultAutoBatchSize
+	}
+	return &AutoBatcher{
+		index:  index,
+		maxOps: maxOps,
+		batch:  NewBatch(),
+	}
+}
+
+// SetMaxBytes also flushes the batch once the estimated
+// size of the values it holds reaches maxBytes.  A value
+// of 0 disables the limit.
+func (a *AutoBatcher) SetMaxBytes(maxBytes int) *AutoBatcher {
+	a.maxBytes = maxBytes
+	return a
+}
+
+// Index adds the document to the batch, flushing it when
+// full.
+func (a *AutoBatcher) Index(id string, data interface{}) error {
+	a.batch.Index(id, data)
+	a.bytes += len(id) + estimatedSize(data)
+	return a.flushIfFull()
+}
+
+// Delete adds the deletion of the document to the batch,
+// flushing it when full.
+func (a *AutoBatcher) Delete(id string) error {
+	a.batch.Delete(id)
+	a.bytes += len(id)
+	return a.flushIfFull()
+}
+
+func (a *AutoBatcher) flushIfFull() error {
+	if a.batch.Size() >= a.maxOps || (a.maxBytes > 0 && a.bytes >= a.maxBytes) {
+		return a.Flush()
+	}
+	return nil
+}
+
+// Flush executes the operations in the batch, if any, and
+// starts a new one.  The operations of a batch which fails
+// are dropped.
+func (a *AutoBatcher) Flush() error {
+	if a.batch.Size() == 0 {
+		return nil
+	}
+	batch := a.batch
+	a.batch = NewBatch()
+	a.bytes = 0
+	a.flushes++
+	return a.index.Batch(batch)
+}
+
+// Flushes returns the number of batches executed.
+func (a *AutoBatcher) Flushes() int {
+	return a.flushes
+}
+
+// estimatedSize approximates the memory taken by the
+// value of a document, counting the bytes of its strings,
+// and of the JSON for values other than the types
+// documents decoded from JSON are made of.
+func estimatedSize(data interface{}) int {
+	switch data := data.(type) {
+	case nil:
+		return 0
+	case string:
+		return len(data)
+	case []byte:
+		return len(data)
+	case float64, bool:
+		return 8
+	case map[string]interface{}:
+		rv := 0
+		for k, v := range data {
+			rv += len(k) + estimatedSize(v)
+		}
+		return rv
+	case []interface{}:
+		rv := 0
+		for _, v := range data {
+			rv += estimatedSize(v)
+		}
+		return rv
+	}
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return 0
+	}
+	return len(encoded)
+}
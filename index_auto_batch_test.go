@@ -0,0 +1,111 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package bleve
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestAutoBatch(t *testing.T) {
+	index, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	batcher := AutoBatch(index, 10)
+	for n := 0; n < 25; n++ {
+		err = batcher.Index(fmt.Sprintf("%02d", n), map[string]interface{}{"name": "beer"})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if batcher.Flushes() != 2 {
+		t.Errorf("expected 2 flushes before the last, got %d", batcher.Flushes())
+	}
+	count, err := index.DocCount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 20 {
+		t.Errorf("expected the 20 flushed documents indexed, got %d", count)
+	}
+	err = batcher.Flush()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if batcher.Flushes() != 3 {
+		t.Errorf("expected 3 flushes, got %d", batcher.Flushes())
+	}
+	// flushing an empty batch does nothing
+	err = batcher.Flush()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if batcher.Flushes() != 3 {
+		t.Errorf("expected still 3 flushes, got %d", batcher.Flushes())
+	}
+
+	res, err := index.Search(NewSearchRequest(NewTermQuery("beer").SetField("name")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 25 {
+		t.Errorf("expected 25 documents searchable, got %d", res.Total)
+	}
+
+	// deletes count as operations too
+	for n := 0; n < 10; n++ {
+		err = batcher.Delete(fmt.Sprintf("%02d", n))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if batcher.Flushes() != 4 {
+		t.Errorf("expected 4 flushes, got %d", batcher.Flushes())
+	}
+	count, err = index.DocCount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 15 {
+		t.Errorf("expected 15 documents left, got %d", count)
+	}
+}
+
+func TestAutoBatchMaxBytes(t *testing.T) {
+	index, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	// each document holds about 100 bytes
+	text := strings.Repeat("beer ", 19)
+	batcher := AutoBatch(index, 1000).SetMaxBytes(250)
+	for n := 0; n < 9; n++ {
+		err = batcher.Index(fmt.Sprintf("%02d", n), map[string]interface{}{"text": text})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if batcher.Flushes() != 3 {
+		t.Errorf("expected a flush every 3 documents, got %d flushes", batcher.Flushes())
+	}
+	count, err := index.DocCount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 9 {
+		t.Errorf("expected 9 documents indexed, got %d", count)
+	}
+}
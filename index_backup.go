@@ -0,0 +1,351 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package bleve
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"hash"
+	"hash/crc32"
+	"io"
+	"os"
+
+	"github.com/blevesearch/bleve/index/store"
+	"github.com/blevesearch/bleve/registry"
+)
+
+// A backup stream starts with backupMagic followed by the
+// format version as a uvarint.  Each key/value pair of the
+// store follows as a backupRecordPair frame, holding the
+// uvarint length and bytes of the key, then of the value.
+// The stream ends with a backupRecordEnd frame holding the
+// uvarint number of pairs, then the big endian CRC-32 of
+// everything before it.
+var backupMagic = []byte("bleve-backup")
+
+const backupVersion = 1
+
+const (
+	backupRecordPair byte = 'p'
+	backupRecordEnd  byte = 'e'
+)
+
+// keys and values longer than this are taken as a sign
+// of corruption rather than allocated
+const maxBackupRecordLength = 1 << 30
+
+// restoreBatchSize is the number of pairs written to the
+// store per batch while restoring
+var restoreBatchSize = 1000
+
+// Backup writes a copy of every key/value pair in the
+// store of the index to w.  The pairs are read from a
+// single store reader, so the copy is consistent even
+// while the index is being written to.  A backup can be
+// restored with RestoreIndex, into any kind of store.
+func (i *indexImpl) Backup(w io.Writer) error {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	if !i.open {
+		return ErrorIndexClosed
+	}
+
+	kvreader, err := i.s.Reader()
+	if err != nil {
+		return err
+	}
+	defer kvreader.Close()
+
+	crc := crc32.NewIEEE()
+	bw := bufio.NewWriter(io.MultiWriter(w, crc))
+	_, err = bw.Write(backupMagic)
+	if err != nil {
+		return err
+	}
+	err = writeUvarint(bw, backupVersion)
+	if err != nil {
+		return err
+	}
+
+	var count uint64
+	it := kvreader.Iterator([]byte{})
+	defer it.Close()
+	for k, v, valid := it.Current(); valid; k, v, valid = it.Current() {
+		err = bw.WriteByte(backupRecordPair)
+		if err != nil {
+			return err
+		}
+		err = writeBytes(bw, k)
+		if err != nil {
+			return err
+		}
+		err = writeBytes(bw, v)
+		if err != nil {
+			return err
+		}
+		count++
+		it.Next()
+	}
+
+	err = bw.WriteByte(backupRecordEnd)
+	if err != nil {
+		return err
+	}
+	err = writeUvarint(bw, count)
+	if err != nil {
+		return err
+	}
+	// the checksum covers everything written so far
+	err = bw.Flush()
+	if err != nil {
+		return err
+	}
+	sum := make([]byte, 4)
+	binary.BigEndian.PutUint32(sum, crc.Sum32())
+	_, err = w.Write(sum)
+	return err
+}
+
+func writeUvarint(w io.Writer, x uint64) error {
+	buf := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(buf, x)
+	_, err := w.Write(buf[:n])
+	return err
+}
+
+func writeBytes(w io.Writer, b []byte) error {
+	err := writeUvarint(w, uint64(len(b)))
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// RestoreIndex creates a new index at the specified path,
+// which must not already exist, from a stream written by
+// Backup.  The default kvstore is used, an empty path
+// restores into an in-memory index.  The mapping of the
+// backed up index is restored with it.
+//
+// Nothing is left at path if the stream cannot be
+// restored, or the restored index cannot be opened, as
+// when its mapping is invalid.  A stream which is cut short, or does not
+// match its checksum, fails with ErrorBackupCorrupt.
+func RestoreIndex(path string, r io.Reader) (Index, error) {
+	return RestoreIndexUsing(path, r, Config.DefaultKVStore, nil)
+}
+
+// RestoreIndexUsing is like RestoreIndex, but the
+// specified kvstore implemenation is used and the
+// provided kvconfig is passed to its constructor.
+func RestoreIndexUsing(path string, r io.Reader, kvstore string, kvconfig map[string]interface{}) (rv Index, err error) {
+	if path == "" {
+		kvstore = "mem"
+		kvconfig = nil
+	}
+	if kvconfig == nil {
+		kvconfig = map[string]interface{}{}
+	}
+
+	i := &indexImpl{
+		path:  path,
+		meta:  newIndexMeta(kvstore, kvconfig),
+		stats: &IndexStat{},
+	}
+	storeConstructor := registry.KVStoreConstructorByName(i.meta.Storage)
+	if storeConstructor == nil {
+		return nil, ErrorUnknownStorageType
+	}
+	if path != "" {
+		err = i.meta.Save(path)
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			if err != nil && rv == nil {
+				os.RemoveAll(path)
+			}
+		}()
+		kvconfig["create_if_missing"] = true
+		kvconfig["error_if_exists"] = true
+		kvconfig["path"] = indexStorePath(path)
+	}
+
+	i.s, err = storeConstructor(kvconfig)
+	if err != nil {
+		return nil, err
+	}
+	err = restoreStore(i.s, r)
+	if err != nil {
+		i.s.Close()
+		return nil, err
+	}
+
+	opened, err := openIndexWithStore(i, kvconfig)
+	if err != nil {
+		if opened != nil {
+			opened.Close()
+		} else {
+			i.s.Close()
+		}
+		return nil, err
+	}
+	return opened, nil
+}
+
+func restoreStore(s store.KVStore, r io.Reader) error {
+	br := &backupReader{
+		r:   bufio.NewReader(r),
+		crc: crc32.NewIEEE(),
+	}
+
+	magic := make([]byte, len(backupMagic))
+	err := br.readFull(magic)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(magic, backupMagic) {
+		return ErrorBackupCorrupt
+	}
+	version, err := br.readUvarint()
+	if err != nil {
+		return err
+	}
+	if version != backupVersion {
+		return ErrorBackupVersion
+	}
+
+	kvwriter, err := s.Writer()
+	if err != nil {
+		return err
+	}
+	defer kvwriter.Close()
+
+	var count uint64
+	batch := kvwriter.NewBatch()
+	pending := 0
+	for {
+		record, err := br.ReadByte()
+		if err != nil {
+			batch.Close()
+			return backupReadError(err)
+		}
+		if record == backupRecordEnd {
+			break
+		}
+		if record != backupRecordPair {
+			batch.Close()
+			return ErrorBackupCorrupt
+		}
+		key, err := br.readBytes()
+		if err != nil {
+			batch.Close()
+			return err
+		}
+		val, err := br.readBytes()
+		if err != nil {
+			batch.Close()
+			return err
+		}
+		batch.Set(key, val)
+		count++
+		pending++
+		if pending >= restoreBatchSize {
+			err = batch.Execute()
+			batch.Close()
+			if err != nil {
+				return err
+			}
+			batch = kvwriter.NewBatch()
+			pending = 0
+		}
+	}
+	err = batch.Execute()
+	batch.Close()
+	if err != nil {
+		return err
+	}
+
+	expectedCount, err := br.readUvarint()
+	if err != nil {
+		return err
+	}
+	expectedSum := br.crc.Sum32()
+	sum := make([]byte, 4)
+	_, err = io.ReadFull(br.r, sum)
+	if err != nil {
+		return backupReadError(err)
+	}
+	if expectedCount != count || binary.BigEndian.Uint32(sum) != expectedSum {
+		return ErrorBackupCorrupt
+	}
+	return nil
+}
+
+// backupReader reads a backup stream, keeping the
+// checksum of the bytes read so far
+type backupReader struct {
+	r   *bufio.Reader
+	crc hash.Hash32
+}
+
+func (br *backupReader) ReadByte() (byte, error) {
+	b, err := br.r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	br.crc.Write([]byte{b})
+	return b, nil
+}
+
+func (br *backupReader) readFull(buf []byte) error {
+	_, err := io.ReadFull(br.r, buf)
+	if err != nil {
+		return backupReadError(err)
+	}
+	br.crc.Write(buf)
+	return nil
+}
+
+func (br *backupReader) readUvarint() (uint64, error) {
+	x, err := binary.ReadUvarint(br)
+	if err != nil {
+		return 0, backupReadError(err)
+	}
+	return x, nil
+}
+
+func (br *backupReader) readBytes() ([]byte, error) {
+	n, err := br.readUvarint()
+	if err != nil {
+		return nil, err
+	}
+	if n > maxBackupRecordLength {
+		return nil, ErrorBackupCorrupt
+	}
+	buf := make([]byte, n)
+	err = br.readFull(buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// backupReadError reports a stream ending early as
+// corrupt, passing other errors through
+func backupReadError(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return ErrorBackupCorrupt
+	}
+	return err
+}
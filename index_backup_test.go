@@ -0,0 +1,185 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package bleve
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func backupTestIndex(t *testing.T) (Index, []byte) {
+	mapping := NewIndexMapping()
+	mapping.DefaultAnalyzer = "en"
+	index, err := NewUsing("testidx", mapping, "cznicb", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := NewBatch()
+	for i := 0; i < 50; i++ {
+		b.Index(fmt.Sprintf("doc%02d", i), map[string]interface{}{
+			"name": fmt.Sprintf("document number %d", i),
+			"desc": "backups running out of time",
+			"num":  float64(i),
+		})
+	}
+	err = index.Batch(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	err = index.Backup(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return index, buf.Bytes()
+}
+
+func searchSummary(t *testing.T, idx Index) []string {
+	min := 10.0
+	queries := []Query{
+		NewMatchQuery("running"),
+		NewTermQuery("number").SetField("name"),
+		NewNumericRangeQuery(&min, nil).SetField("num"),
+	}
+	var rv []string
+	for _, q := range queries {
+		req := NewSearchRequestOptions(q, 100, 0, false)
+		res, err := idx.Search(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rv = append(rv, fmt.Sprintf("total %d", res.Total))
+		for _, hit := range res.Hits {
+			rv = append(rv, fmt.Sprintf("%s %f", hit.ID, hit.Score))
+		}
+	}
+	return rv
+}
+
+func TestBackupRestore(t *testing.T) {
+	defer os.RemoveAll("testidx")
+	defer os.RemoveAll("testidx2")
+
+	index, backup := backupTestIndex(t)
+	defer index.Close()
+	expected := searchSummary(t, index)
+
+	restored, err := RestoreIndex("testidx2", bytes.NewReader(backup))
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual := searchSummary(t, restored)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+	if restored.Mapping().DefaultAnalyzer != "en" {
+		t.Errorf("expected mapping to be restored, got %v", restored.Mapping())
+	}
+
+	// the restored index is a regular index
+	err = restored.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored, err = Open("testidx2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer restored.Close()
+	docCount, err := restored.DocCount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if docCount != 50 {
+		t.Errorf("expected 50 documents, got %d", docCount)
+	}
+
+	inMem, err := RestoreIndex("", bytes.NewReader(backup))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer inMem.Close()
+	actual = searchSummary(t, inMem)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
+
+func TestRestoreInvalidMapping(t *testing.T) {
+	defer os.RemoveAll("testidx")
+	defer os.RemoveAll("testidx2")
+
+	index, _ := backupTestIndex(t)
+	defer index.Close()
+	err := index.SetInternal(mappingInternalKey, []byte(`{"default_analyzer": "missing"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	err = index.Backup(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := RestoreIndex("testidx2", &buf)
+	if err == nil {
+		t.Errorf("expected error restoring an invalid mapping")
+	}
+	if restored != nil {
+		t.Errorf("expected no index, got %v", restored)
+		restored.Close()
+	}
+	if _, err := os.Stat("testidx2"); !os.IsNotExist(err) {
+		t.Errorf("expected failed restore to leave nothing behind, got %v", err)
+	}
+}
+
+func TestRestoreCorruptBackup(t *testing.T) {
+	defer os.RemoveAll("testidx")
+	defer os.RemoveAll("testidx2")
+
+	index, backup := backupTestIndex(t)
+	defer index.Close()
+
+	flipped := make([]byte, len(backup))
+	copy(flipped, backup)
+	flipped[len(flipped)/2] ^= 0xff
+
+	badVersion := make([]byte, len(backup))
+	copy(badVersion, backup)
+	badVersion[len(backupMagic)] = backupVersion + 1
+
+	tests := []struct {
+		stream []byte
+		err    error
+	}{
+		{backup[:0], ErrorBackupCorrupt},
+		{backup[:len(backupMagic)+1], ErrorBackupCorrupt},
+		{backup[:len(backup)/2], ErrorBackupCorrupt},
+		{backup[:len(backup)-1], ErrorBackupCorrupt},
+		{flipped, ErrorBackupCorrupt},
+		{badVersion, ErrorBackupVersion},
+	}
+	for _, test := range tests {
+		_, err := RestoreIndex("testidx2", bytes.NewReader(test.stream))
+		if err != test.err {
+			t.Errorf("expected %v restoring %d bytes, got %v", test.err, len(test.stream), err)
+		}
+		if _, err := os.Stat("testidx2"); !os.IsNotExist(err) {
+			t.Errorf("expected failed restore to leave nothing behind, got %v", err)
+			os.RemoveAll("testidx2")
+		}
+	}
+}
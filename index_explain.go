@@ -0,0 +1,51 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package bleve
+
+import (
+	"github.com/blevesearch/bleve/search"
+)
+
+// Explain reports how the document with the id scores
+// for q, without running a search.  The searcher of q
+// is advanced straight to the document, and the
+// explanation of its score is returned, the one a
+// search with Explain adds to its hit.  The
+// explanation is nil when the document does not match
+// q, or does not exist.
+func (i *indexImpl) Explain(q Query, id string) (*search.Explanation, error) {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	if !i.open {
+		return nil, ErrorIndexClosed
+	}
+
+	indexReader, err := i.i.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer indexReader.Close()
+
+	searcher, err := i.requestSearcher(indexReader, &SearchRequest{Query: q, Explain: true})
+	if err != nil {
+		return nil, err
+	}
+	defer searcher.Close()
+
+	match, err := searcher.Advance(id)
+	if err != nil {
+		return nil, err
+	}
+	if match == nil || match.ID != id {
+		return nil, nil
+	}
+	return match.Expl, nil
+}
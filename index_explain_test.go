@@ -0,0 +1,100 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package bleve
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/blevesearch/bleve/search"
+)
+
+func explanationMessages(expl *search.Explanation) []string {
+	rv := []string{expl.Message}
+	for _, child := range expl.Children {
+		rv = append(rv, explanationMessages(child)...)
+	}
+	return rv
+}
+
+func hasMessagePrefix(messages []string, prefix string) bool {
+	for _, message := range messages {
+		if strings.HasPrefix(message, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestExplain(t *testing.T) {
+	index, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	docs := map[string]map[string]interface{}{
+		"a": {"desc": "beer beer beer"},
+		"b": {"desc": "wine and beer"},
+		"c": {"desc": "water"},
+	}
+	for id, doc := range docs {
+		err = index.Index(id, doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	q := NewMatchQuery("beer").SetField("desc")
+	expl, err := index.Explain(q, "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expl == nil {
+		t.Fatalf("expected explanation for b")
+	}
+	messages := explanationMessages(expl)
+	if !hasMessagePrefix(messages, "tf(") {
+		t.Errorf("expected term frequency in explanation, got %v", messages)
+	}
+	if !hasMessagePrefix(messages, "idf(") {
+		t.Errorf("expected inverse document frequency in explanation, got %v", messages)
+	}
+
+	// the explanation is the one of the hit of a search
+	req := NewSearchRequest(q)
+	req.Explain = true
+	res, err := index.Search(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, hit := range res.Hits {
+		if hit.ID == "b" && math.Abs(hit.Score-expl.Value) > 1e-9 {
+			t.Errorf("expected explanation value %f to be the score %f", expl.Value, hit.Score)
+		}
+	}
+
+	// no match
+	expl, err = index.Explain(q, "c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expl != nil {
+		t.Errorf("expected no explanation for c, got %v", expl)
+	}
+	expl, err = index.Explain(q, "missing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expl != nil {
+		t.Errorf("expected no explanation for missing document, got %v", expl)
+	}
+}
@@ -10,6 +10,7 @@
 package bleve
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -20,11 +21,14 @@ import (
 	"github.com/blevesearch/bleve/document"
 	"github.com/blevesearch/bleve/index"
 	"github.com/blevesearch/bleve/index/store"
+	"github.com/blevesearch/bleve/index/store/readonly"
 	"github.com/blevesearch/bleve/index/upside_down"
 	"github.com/blevesearch/bleve/registry"
 	"github.com/blevesearch/bleve/search"
 	"github.com/blevesearch/bleve/search/collectors"
 	"github.com/blevesearch/bleve/search/facets"
+	"github.com/blevesearch/bleve/search/highlight"
+	"github.com/blevesearch/bleve/search/searchers"
 )
 
 type indexImpl struct {
@@ -36,6 +40,19 @@ type indexImpl struct {
 	mutex sync.RWMutex
 	open  bool
 	stats *IndexStat
+
+	// readOnly fails the writes to the index
+	readOnly bool
+
+	// updateMutex is held by updates from reading the
+	// documents until their changes are written
+	updateMutex sync.Mutex
+
+	hasTTL    int32
+	sweepStop chan struct{}
+
+	listenersMutex sync.RWMutex
+	listeners      []IndexListener
 }
 
 const storePath = "store"
@@ -46,11 +63,18 @@ func indexStorePath(path string) string {
 	return path + string(os.PathSeparator) + storePath
 }
 
-func newMemIndex(mapping *IndexMapping) (*indexImpl, error) {
+func newMemIndex(mapping *IndexMapping, config map[string]interface{}) (*indexImpl, error) {
+	return newMemIndexUsing(mapping, "mem", config)
+}
+
+// newMemIndexUsing creates an index which is not persisted
+// in the kvstore, which must keep its data in memory.  The
+// config may set TTLSweepIntervalConfigKey.
+func newMemIndexUsing(mapping *IndexMapping, kvstore string, config map[string]interface{}) (*indexImpl, error) {
 	rv := indexImpl{
 		path:  "",
 		m:     mapping,
-		meta:  newIndexMeta("mem", nil),
+		meta:  newIndexMeta(kvstore, nil),
 		stats: &IndexStat{},
 	}
 
@@ -83,6 +107,11 @@ func newMemIndex(mapping *IndexMapping) (*indexImpl, error) {
 		return nil, err
 	}
 
+	err = rv.loadTTLState(config)
+	if err != nil {
+		return nil, err
+	}
+
 	// mark the index as open
 	rv.mutex.Lock()
 	defer rv.mutex.Unlock()
@@ -98,7 +127,7 @@ func newIndexUsing(path string, mapping *IndexMapping, kvstore string, kvconfig
 	}
 
 	if path == "" {
-		return newMemIndex(mapping)
+		return newMemIndex(mapping, kvconfig)
 	}
 
 	if kvconfig == nil {
@@ -148,6 +177,11 @@ func newIndexUsing(path string, mapping *IndexMapping, kvstore string, kvconfig
 		return nil, err
 	}
 
+	err = rv.loadTTLState(kvconfig)
+	if err != nil {
+		return nil, err
+	}
+
 	// mark the index as open
 	rv.mutex.Lock()
 	defer rv.mutex.Unlock()
@@ -189,10 +223,20 @@ func openIndexUsing(path string, runtimeConfig map[string]interface{}) (*indexIm
 	if err != nil {
 		return nil, err
 	}
+	if readOnly, _ := storeConfig["read_only"].(bool); readOnly {
+		rv.s = readonly.NewReadOnlyStore(rv.s)
+		rv.readOnly = true
+	}
+
+	return openIndexWithStore(&rv, storeConfig)
+}
 
+// openIndexWithStore opens the index over its already
+// populated store, loading the persisted mapping
+func openIndexWithStore(rv *indexImpl, storeConfig map[string]interface{}) (*indexImpl, error) {
 	// open the index
 	rv.i = upside_down.NewUpsideDownCouch(rv.s, Config.analysisQueue)
-	err = rv.i.Open()
+	err := rv.i.Open()
 	if err != nil {
 		return nil, err
 	}
@@ -215,6 +259,11 @@ func openIndexUsing(path string, runtimeConfig map[string]interface{}) (*indexIm
 		return nil, err
 	}
 
+	err = rv.loadTTLState(storeConfig)
+	if err != nil {
+		return nil, err
+	}
+
 	// mark the index as open
 	rv.mutex.Lock()
 	defer rv.mutex.Unlock()
@@ -225,11 +274,11 @@ func openIndexUsing(path string, runtimeConfig map[string]interface{}) (*indexIm
 	if err != nil {
 		// note even if the mapping is invalid
 		// we still return an open usable index
-		return &rv, err
+		return rv, err
 	}
 
 	rv.m = &im
-	return &rv, nil
+	return rv, nil
 }
 
 // Advanced returns implementation internals
@@ -247,19 +296,33 @@ func (i *indexImpl) Mapping() *IndexMapping {
 // Index the object with the specified identifier.
 // The IndexMapping for this index will determine
 // how the object is indexed.
-func (i *indexImpl) Index(id string, data interface{}) error {
+func (i *indexImpl) Index(id string, data interface{}) (err error) {
+	defer func() {
+		if err == nil {
+			i.notifyIndex(id)
+		}
+	}()
 	i.mutex.RLock()
 	defer i.mutex.RUnlock()
 
 	if !i.open {
 		return ErrorIndexClosed
 	}
+	if i.readOnly {
+		return ErrorIndexReadOnly
+	}
 
 	doc := document.NewDocument(id)
-	err := i.m.mapDocument(doc, data)
+	err = i.m.mapDocument(doc, data)
 	if err != nil {
 		return err
 	}
+	if i.ttlEnabled() {
+		ib := index.NewBatch()
+		ib.Update(doc)
+		ib.DeleteInternal(ttlInternalKey(id))
+		return i.i.Batch(ib)
+	}
 	err = i.i.Update(doc)
 	if err != nil {
 		return err
@@ -269,15 +332,29 @@ func (i *indexImpl) Index(id string, data interface{}) error {
 
 // Delete entries for the specified identifier from
 // the index.
-func (i *indexImpl) Delete(id string) error {
+func (i *indexImpl) Delete(id string) (err error) {
+	defer func() {
+		if err == nil {
+			i.notifyDelete(id)
+		}
+	}()
 	i.mutex.RLock()
 	defer i.mutex.RUnlock()
 
 	if !i.open {
 		return ErrorIndexClosed
 	}
+	if i.readOnly {
+		return ErrorIndexReadOnly
+	}
 
-	err := i.i.Delete(id)
+	if i.ttlEnabled() {
+		ib := index.NewBatch()
+		ib.Delete(id)
+		ib.DeleteInternal(ttlInternalKey(id))
+		return i.i.Batch(ib)
+	}
+	err = i.i.Delete(id)
 	if err != nil {
 		return err
 	}
@@ -288,13 +365,21 @@ func (i *indexImpl) Delete(id string) error {
 // operations at the same time.  There are often
 // significant performance benefits when performing
 // operations in a batch.
-func (i *indexImpl) Batch(b *Batch) error {
+func (i *indexImpl) Batch(b *Batch) (err error) {
+	defer func() {
+		if err == nil {
+			i.notifyBatch(b)
+		}
+	}()
 	i.mutex.RLock()
 	defer i.mutex.RUnlock()
 
 	if !i.open {
 		return ErrorIndexClosed
 	}
+	if i.readOnly {
+		return ErrorIndexReadOnly
+	}
 
 	ib := index.NewBatch()
 	for bk, bd := range b.indexOps {
@@ -308,6 +393,9 @@ func (i *indexImpl) Batch(b *Batch) error {
 			}
 			ib.Update(doc)
 		}
+		if i.ttlEnabled() {
+			ib.DeleteInternal(ttlInternalKey(bk))
+		}
 	}
 	for ik, iv := range b.internalOps {
 		if iv == nil {
@@ -319,6 +407,91 @@ func (i *indexImpl) Batch(b *Batch) error {
 	return i.i.Batch(ib)
 }
 
+// byQueryBatchSize is the number of matches
+// DeleteByQuery and UpdateByQuery change per batch
+var byQueryBatchSize = 1000
+
+// DeleteByQuery deletes all the documents matching the
+// query, returning the number deleted.  Matches are
+// deleted in batches, each found with a fresh reader,
+// until the query matches nothing more.  Documents
+// indexed concurrently are deleted if they match when
+// a batch is read.  If a batch fails the documents
+// deleted by the previous batches are still counted.
+func (i *indexImpl) DeleteByQuery(q Query) (uint64, error) {
+	var done []*index.Batch
+	defer func() {
+		i.notifyIndexBatches(done)
+	}()
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	if !i.open {
+		return 0, ErrorIndexClosed
+	}
+	if i.readOnly {
+		return 0, ErrorIndexReadOnly
+	}
+
+	var deleted uint64
+	for {
+		ids, err := i.matchingIDs(q, byQueryBatchSize)
+		if err != nil {
+			return deleted, err
+		}
+		if len(ids) == 0 {
+			return deleted, nil
+		}
+		ib := index.NewBatch()
+		for _, id := range ids {
+			ib.Delete(id)
+			if i.ttlEnabled() {
+				ib.DeleteInternal(ttlInternalKey(id))
+			}
+		}
+		err = i.i.Batch(ib)
+		if err != nil {
+			return deleted, err
+		}
+		done = append(done, ib)
+		deleted += uint64(len(ids))
+		if len(ids) < byQueryBatchSize {
+			return deleted, nil
+		}
+	}
+}
+
+// matchingIDs returns the identifiers of up to max
+// documents matching the query, or of all of them if
+// max is 0
+func (i *indexImpl) matchingIDs(q Query, max int) ([]string, error) {
+	indexReader, err := i.i.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer indexReader.Close()
+
+	searcher, err := q.Searcher(scoringIndexReader(indexReader, i.m), i.m, false)
+	if err != nil {
+		return nil, err
+	}
+	defer searcher.Close()
+
+	rv := make([]string, 0)
+	next, err := searcher.Next()
+	for err == nil && next != nil {
+		rv = append(rv, next.ID)
+		if max > 0 && len(rv) >= max {
+			break
+		}
+		next, err = searcher.Next()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rv, nil
+}
+
 // Document is used to find the values of all the
 // stored fields for a document in the index.  These
 // stored fields are put back into a Document object
@@ -338,6 +511,79 @@ func (i *indexImpl) Document(id string) (*document.Document, error) {
 	return indexReader.Document(id)
 }
 
+// Documents finds the stored fields of several
+// documents at once, like Document, but using a single
+// reader for all of them.  The returned map is keyed by
+// identifier, documents which do not exist are left out.
+func (i *indexImpl) Documents(ids []string) (map[string]*document.Document, error) {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	if !i.open {
+		return nil, ErrorIndexClosed
+	}
+	indexReader, err := i.i.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer indexReader.Close()
+
+	rv := make(map[string]*document.Document, len(ids))
+	for _, id := range ids {
+		doc, err := indexReader.Document(id)
+		if err != nil {
+			return nil, err
+		}
+		if doc != nil {
+			rv[id] = doc
+		}
+	}
+	return rv, nil
+}
+
+// DocumentField returns the stored value of one field
+// of a document, reading only the rows storing that field.
+// Values are converted the same way as the fields loaded
+// with search hits: text as a string, numbers as float64,
+// dates as time.Time, booleans as bool and IP addresses as
+// strings.
+// A field stored from an array returns its values as an
+// []interface{}.  A missing document or field returns nil.
+func (i *indexImpl) DocumentField(id, field string) (interface{}, error) {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	if !i.open {
+		return nil, ErrorIndexClosed
+	}
+	indexReader, err := i.i.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer indexReader.Close()
+
+	fields, err := indexReader.DocumentField(id, field)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]interface{}, 0, len(fields))
+	for _, f := range fields {
+		value := storedFieldValue(f)
+		if value != nil {
+			values = append(values, value)
+		}
+	}
+	switch len(values) {
+	case 0:
+		return nil, nil
+	case 1:
+		if len(fields[0].ArrayPositions()) == 0 {
+			return values[0], nil
+		}
+	}
+	return values, nil
+}
+
 // DocCount returns the number of documents in the
 // index.
 func (i *indexImpl) DocCount() (uint64, error) {
@@ -351,9 +597,35 @@ func (i *indexImpl) DocCount() (uint64, error) {
 	return i.i.DocCount()
 }
 
+// Warmup reads the term dictionary of the index into
+// memory, so the first searches do not wait for it to be
+// read from disk.  At most maxBytes are read, with no limit
+// if 0.  Terms changed afterwards are read from disk again.
+// When ctx is done, Warmup stops, keeping what was read,
+// and returns the error of ctx.  It is safe to call while
+// the index is used, writes wait for it to finish.
+func (i *indexImpl) Warmup(ctx context.Context, maxBytes uint64) error {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	if !i.open {
+		return ErrorIndexClosed
+	}
+
+	return i.i.Warmup(ctx, maxBytes)
+}
+
 // Search executes a search request operation.
 // Returns a SearchResult object or an error.
 func (i *indexImpl) Search(req *SearchRequest) (*SearchResult, error) {
+	return i.SearchInContext(context.Background(), req)
+}
+
+// SearchInContext executes a search request, collecting
+// matches until the context is done.  The hits found by
+// then are returned, in order, with TimedOut set on the
+// result, instead of an error.
+func (i *indexImpl) SearchInContext(ctx context.Context, req *SearchRequest) (*SearchResult, error) {
 	i.mutex.RLock()
 	defer i.mutex.RUnlock()
 
@@ -363,46 +635,97 @@ func (i *indexImpl) Search(req *SearchRequest) (*SearchResult, error) {
 		return nil, ErrorIndexClosed
 	}
 
-	collector := collectors.NewTopScorerSkipCollector(req.Size, req.From)
-
 	// open a reader for this search
-	indexReader, err := i.i.Reader()
+	indexReader, shardReaders, err := i.searchReaders(req)
 	if err != nil {
 		return nil, err
 	}
 	defer indexReader.Close()
+	for _, shardReader := range shardReaders {
+		defer shardReader.Close()
+	}
+
+	err = req.validateSearchAfter()
+	if err != nil {
+		return nil, err
+	}
+	err = req.validateScoreNormalization()
+	if err != nil {
+		return nil, err
+	}
+	err = req.validateFacets()
+	if err != nil {
+		return nil, err
+	}
+	err = req.validateAggregations()
+	if err != nil {
+		return nil, err
+	}
+	err = req.validatePostFilters()
+	if err != nil {
+		return nil, err
+	}
+
+	var collector search.Collector
+	var collapsingCollector *collectors.CollapsingCollector
+	var parallel *parallelCollector
+	if req.CollapseField != "" {
+		collapsingCollector = collectors.NewCollapsingCollector(indexReader, req.CollapseField, req.CollapseSize, req.Size, req.From, req.CollapseExcludeMissing)
+		collector = collapsingCollector
+	} else if len(req.Sort) > 0 {
+		sortingCollector := collectors.NewSortingCollector(indexReader, req.Sort, req.Size, req.From)
+		if req.SearchAfter != nil {
+			sortingCollector.SetSearchAfter(req.SearchAfter)
+		}
+		collector = sortingCollector
+	} else if len(shardReaders) > 0 {
+		parallel, err = i.newParallelCollector(ctx, indexReader, shardReaders, req)
+		if err != nil {
+			return nil, err
+		}
+		collector = parallel
+	} else {
+		collector = collectors.NewTopScorerSkipCollector(req.Size, req.From)
+	}
 
-	searcher, err := req.Query.Searcher(indexReader, i.m, req.Explain)
+	searcher, err := i.requestSearcher(indexReader, req)
 	if err != nil {
 		return nil, err
 	}
 	defer searcher.Close()
 
-	if req.Facets != nil {
-		facetsBuilder := search.NewFacetsBuilder(indexReader)
+	var filters *postFilters
+	if len(req.PostFilters) > 0 {
+		filters, err = i.newPostFilters(indexReader, req)
+		if err != nil {
+			return nil, err
+		}
+		defer filters.Close()
+		searcher = searchers.NewFilteringSearcher(searcher, filters.accept)
+	}
+
+	var contextSearcher *searchers.ContextSearcher
+	if ctx.Done() != nil {
+		contextSearcher = searchers.NewContextSearcher(ctx, searcher)
+		searcher = contextSearcher
+	}
+
+	var facetsBuilder *search.FacetsBuilder
+	if req.Facets != nil || req.Aggregations != nil {
+		facetsBuilder = search.NewFacetsBuilder(indexReader)
 		for facetName, facetRequest := range req.Facets {
-			if facetRequest.NumericRanges != nil {
-				// build numeric range facet
-				facetBuilder := facets.NewNumericFacetBuilder(facetRequest.Field, facetRequest.Size)
-				for _, nr := range facetRequest.NumericRanges {
-					facetBuilder.AddRange(nr.Name, nr.Min, nr.Max)
-				}
-				facetsBuilder.Add(facetName, facetBuilder)
-			} else if facetRequest.DateTimeRanges != nil {
-				// build date range facet
-				facetBuilder := facets.NewDateTimeFacetBuilder(facetRequest.Field, facetRequest.Size)
-				dateTimeParser := i.m.dateTimeParserNamed(i.m.DefaultDateTimeParser)
-				for _, dr := range facetRequest.DateTimeRanges {
-					dr.ParseDates(dateTimeParser)
-					facetBuilder.AddRange(dr.Name, dr.Start, dr.End)
-				}
-				facetsBuilder.Add(facetName, facetBuilder)
+			facetBuilder := i.facetBuilder(facetRequest)
+			if filters != nil && filters.filters(facetRequest.Field) {
+				// counted before the post filters on its field
+				filters.addFacet(indexReader, facetRequest.Field, facetName, facetBuilder)
 			} else {
-				// build terms facet
-				facetBuilder := facets.NewTermsFacetBuilder(facetRequest.Field, facetRequest.Size)
 				facetsBuilder.Add(facetName, facetBuilder)
 			}
 		}
+		for aggregationName, aggregation := range req.Aggregations {
+			aggregationBuilder := facets.NewMetricAggregationBuilder(aggregation.Field, aggregation.Type)
+			facetsBuilder.AddAggregation(aggregationName, aggregationBuilder)
+		}
 		collector.SetFacetsBuilder(facetsBuilder)
 	}
 
@@ -413,72 +736,34 @@ func (i *indexImpl) Search(req *SearchRequest) (*SearchResult, error) {
 
 	hits := collector.Results()
 
-	if req.Highlight != nil {
-		// get the right highlighter
-		highlighter, err := Config.Cache.HighlighterNamed(Config.DefaultHighlighter)
+	if req.IDsOnly {
+		for _, hit := range hits {
+			hit.Locations = nil
+			hit.Expl = nil
+		}
+	}
+
+	if req.Highlight != nil && !req.IDsOnly {
+		highlighter, err := requestHighlighter(req.Highlight)
 		if err != nil {
 			return nil, err
 		}
-		if req.Highlight.Style != nil {
-			highlighter, err = Config.Cache.HighlighterNamed(*req.Highlight.Style)
-			if err != nil {
-				return nil, err
-			}
-		}
-		if highlighter == nil {
-			return nil, fmt.Errorf("no highlighter named `%s` registered", *req.Highlight.Style)
-		}
-
 		for _, hit := range hits {
-			doc, err := indexReader.Document(hit.ID)
-			if err == nil {
-				highlightFields := req.Highlight.Fields
-				if highlightFields == nil {
-					// add all fields with matches
-					highlightFields = make([]string, 0, len(hit.Locations))
-					for k := range hit.Locations {
-						highlightFields = append(highlightFields, k)
-					}
-				}
-
-				for _, hf := range highlightFields {
-					highlighter.BestFragmentsInField(hit, doc, hf, 1)
-				}
-			}
+			highlightHit(indexReader, highlighter, req.Highlight, hit)
 		}
 	}
 
-	if len(req.Fields) > 0 {
+	if len(req.Fields) > 0 && !req.IDsOnly {
 		for _, hit := range hits {
 			// FIXME avoid loading doc second time
 			// if we already loaded it for highlighting
-			doc, err := indexReader.Document(hit.ID)
-			if err == nil {
-				for _, f := range req.Fields {
-					for _, docF := range doc.Fields {
-						if f == "*" || docF.Name() == f {
-							var value interface{}
-							switch docF := docF.(type) {
-							case *document.TextField:
-								value = string(docF.Value())
-							case *document.NumericField:
-								num, err := docF.Number()
-								if err == nil {
-									value = num
-								}
-							case *document.DateTimeField:
-								datetime, err := docF.DateTime()
-								if err == nil {
-									value = datetime.Format(time.RFC3339)
-								}
-							}
-							if value != nil {
-								hit.AddFieldValue(docF.Name(), value)
-							}
-						}
-					}
-				}
-			}
+			loadHitFields(indexReader, req.Fields, hit)
+		}
+	}
+
+	if !req.IncludeLocations {
+		for _, hit := range hits {
+			hit.Locations = nil
 		}
 	}
 
@@ -490,14 +775,180 @@ func (i *indexImpl) Search(req *SearchRequest) (*SearchResult, error) {
 		logger.Printf("slow search took %s - %v", searchDuration, req)
 	}
 
-	return &SearchResult{
+	rv := &SearchResult{
 		Request:  req,
 		Hits:     hits,
 		Total:    collector.Total(),
 		MaxScore: collector.MaxScore(),
 		Took:     collector.Took(),
 		Facets:   collector.FacetResults(),
-	}, nil
+	}
+	if req.Aggregations != nil {
+		rv.Aggregations = facetsBuilder.AggregationResults()
+		if parallel != nil {
+			rv.Aggregations = parallel.AggregationResults()
+		}
+	}
+	if filters != nil {
+		rv.Facets.Merge(filters.FacetResults())
+	}
+	if collapsingCollector != nil {
+		rv.Groups = collapsingCollector.Groups()
+		rv.TotalGroups = collapsingCollector.TotalGroups()
+	}
+	if contextSearcher != nil {
+		rv.TimedOut = contextSearcher.TimedOut()
+	}
+	if parallel != nil {
+		rv.TimedOut = rv.TimedOut || parallel.TimedOut()
+	}
+	rv.normalizeScores(req.ScoreNormalization)
+	return rv, nil
+}
+
+// facetBuilder builds the facet builder for the field,
+// ranges and size of the facet request
+func (i *indexImpl) facetBuilder(facetRequest *FacetRequest) search.FacetBuilder {
+	if facetRequest.NumericRanges != nil {
+		// build numeric range facet
+		facetBuilder := facets.NewNumericFacetBuilder(facetRequest.Field, facetRequest.Size)
+		for _, nr := range facetRequest.NumericRanges {
+			facetBuilder.AddRange(nr.Name, nr.Min, nr.Max)
+		}
+		return facetBuilder
+	} else if facetRequest.DateTimeRanges != nil {
+		// build date range facet
+		facetBuilder := facets.NewDateTimeFacetBuilder(facetRequest.Field, facetRequest.Size)
+		dateTimeParser := i.m.dateTimeParserNamed(i.m.DefaultDateTimeParser)
+		for _, dr := range facetRequest.DateTimeRanges {
+			dr.ParseDates(dateTimeParser)
+			facetBuilder.AddRange(dr.Name, dr.Start, dr.End)
+		}
+		return facetBuilder
+	} else if facetRequest.DateHistogram != nil {
+		// build date histogram facet, its time zone
+		// was checked with the request
+		dh := facetRequest.DateHistogram
+		loc, _ := time.LoadLocation(dh.TimeZone)
+		return facets.NewDateHistogramFacetBuilder(facetRequest.Field, dh.Interval, loc, dh.EmptyBuckets)
+	}
+	// build terms facet
+	facetBuilder := facets.NewTermsFacetBuilder(facetRequest.Field, facetRequest.Size)
+	facetBuilder.SetOrder(facetRequest.Order)
+	return facetBuilder
+}
+
+// requestSearcher builds the searcher for the query of
+// the request, with the boosts of compound queries pushed
+// into their leaves, hiding expired documents and the
+// matches scoring less than MinScore
+func (i *indexImpl) requestSearcher(indexReader index.IndexReader, req *SearchRequest) (search.Searcher, error) {
+	q, err := pushBoosts(req.Query, i.m)
+	if err != nil {
+		return nil, err
+	}
+	searcher, err := q.Searcher(requestScoringIndexReader(indexReader, i.m, req), i.m, req.Explain)
+	if err != nil {
+		return nil, err
+	}
+	if i.ttlEnabled() {
+		searcher = searchers.NewFilteringSearcher(searcher, expiredFilter(indexReader))
+	}
+	if req.MinScore > 0 {
+		searcher = searchers.NewMinScoreSearcher(searcher, req.MinScore)
+	}
+	return searcher, nil
+}
+
+// requestHighlighter returns the highlighter for the
+// style and fragment size of the highlight request
+func requestHighlighter(req *HighlightRequest) (highlight.Highlighter, error) {
+	// get the right highlighter
+	highlighter, err := Config.Cache.HighlighterNamed(Config.DefaultHighlighter)
+	if err != nil {
+		return nil, err
+	}
+	if req.Style != nil {
+		highlighter, err = Config.Cache.HighlighterNamed(*req.Style)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if highlighter == nil {
+		return nil, fmt.Errorf("no highlighter named `%s` registered", *req.Style)
+	}
+	if req.FragmentSize > 0 {
+		if resizable, ok := highlighter.(highlight.ResizableHighlighter); ok {
+			highlighter = resizable.WithFragmentSize(req.FragmentSize)
+		}
+	}
+	return highlighter, nil
+}
+
+func highlightHit(indexReader index.IndexReader, highlighter highlight.Highlighter, req *HighlightRequest, hit *search.DocumentMatch) {
+	doc, err := indexReader.Document(hit.ID)
+	if err == nil {
+		highlightFields := req.Fields
+		if highlightFields == nil {
+			// add all fields with matches
+			highlightFields = make([]string, 0, len(hit.Locations))
+			for k := range hit.Locations {
+				highlightFields = append(highlightFields, k)
+			}
+		}
+
+		numFragments := req.numberOfFragments()
+		for _, hf := range highlightFields {
+			highlighter.BestFragmentsInField(hit, doc, hf, numFragments)
+		}
+	}
+}
+
+func loadHitFields(indexReader index.IndexReader, fields []string, hit *search.DocumentMatch) {
+	doc, err := indexReader.Document(hit.ID)
+	if err == nil {
+		for _, f := range fields {
+			for _, docF := range doc.Fields {
+				if f == "*" || docF.Name() == f {
+					value := storedFieldValue(docF)
+					if value != nil {
+						hit.AddFieldValue(docF.Name(), value)
+					}
+				}
+			}
+		}
+	}
+}
+
+// storedFieldValue converts a stored field to the value
+// returned for it, keeping the type it was mapped with,
+// or nil if it cannot be decoded.
+func storedFieldValue(f document.Field) interface{} {
+	switch f := f.(type) {
+	case *document.TextField:
+		return string(f.Value())
+	case *document.NumericField:
+		num, err := f.Number()
+		if err == nil {
+			return num
+		}
+	case *document.DateTimeField:
+		datetime, err := f.DateTime()
+		if err == nil {
+			return datetime
+		}
+	case *document.BooleanField:
+		b, err := f.Boolean()
+		if err == nil {
+			return b
+		}
+	case *document.IPField:
+		ip, err := f.IP()
+		if err == nil {
+			return ip.String()
+		}
+	}
+	return nil
 }
 
 // Fields returns the name of all the fields this
@@ -565,6 +1016,10 @@ func (i *indexImpl) Close() error {
 	defer i.mutex.Unlock()
 
 	i.open = false
+	if i.sweepStop != nil {
+		close(i.sweepStop)
+		i.sweepStop = nil
+	}
 	return i.i.Close()
 }
 
@@ -572,6 +1027,36 @@ func (i *indexImpl) Stats() *IndexStat {
 	return i.stats
 }
 
+// StatsMap returns the search statistics of the index
+// along with its sizes: the document count, the total
+// number of distinct terms and the number for each field,
+// and the number of entries of the store when it reports
+// them.  The terms are counted from the dictionary alone,
+// so it is cheap enough to poll.  If the sizes cannot be
+// read, the error is reported under "error".
+func (i *indexImpl) StatsMap() map[string]interface{} {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	if !i.open {
+		return nil
+	}
+
+	rv := map[string]interface{}{
+		"searches":    atomic.LoadUint64(&i.stats.searches),
+		"search_time": atomic.LoadUint64(&i.stats.searchTime),
+	}
+	indexStats, err := i.i.StatsMap()
+	if err != nil {
+		rv["error"] = err.Error()
+		return rv
+	}
+	for k, v := range indexStats {
+		rv[k] = v
+	}
+	return rv
+}
+
 func (i *indexImpl) GetInternal(key []byte) ([]byte, error) {
 	i.mutex.RLock()
 	defer i.mutex.RUnlock()
@@ -589,6 +1074,10 @@ func (i *indexImpl) SetInternal(key, val []byte) error {
 	i.mutex.RLock()
 	defer i.mutex.RUnlock()
 
+	if i.readOnly {
+		return ErrorIndexReadOnly
+	}
+
 	return i.i.SetInternal(key, val)
 }
 
@@ -596,5 +1085,9 @@ func (i *indexImpl) DeleteInternal(key []byte) error {
 	i.mutex.RLock()
 	defer i.mutex.RUnlock()
 
+	if i.readOnly {
+		return ErrorIndexReadOnly
+	}
+
 	return i.i.DeleteInternal(key)
 }
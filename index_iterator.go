@@ -0,0 +1,144 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package bleve
+
+import (
+	"sync/atomic"
+
+	"github.com/blevesearch/bleve/index"
+	"github.com/blevesearch/bleve/search"
+	"github.com/blevesearch/bleve/search/highlight"
+)
+
+// SearchIter executes the query of a search request,
+// returning an iterator over all of its matches.  Hits
+// are returned in index order as the searcher advances,
+// so memory use does not grow with the number of
+// matches.  Size and From are ignored, while Explain,
+// Highlight, Fields, IDsOnly and IncludeLocations apply
+// to each hit.
+// Requests with a Sort, SearchAfter, CollapseField,
+// Facets, Aggregations or PostFilters need every match
+// to be seen first, and are rejected with
+// ErrorSearchIteratorUnsupported.
+//
+// The iterator must be closed before the index is.
+func (i *indexImpl) SearchIter(req *SearchRequest) (ResultIterator, error) {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	if !i.open {
+		return nil, ErrorIndexClosed
+	}
+
+	err := req.validateIterator()
+	if err != nil {
+		return nil, err
+	}
+
+	rv := &searchIterator{
+		req: req,
+	}
+	if req.Highlight != nil && !req.IDsOnly {
+		rv.highlighter, err = requestHighlighter(req.Highlight)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rv.indexReader, err = i.i.Reader()
+	if err != nil {
+		return nil, err
+	}
+	rv.searcher, err = i.requestSearcher(rv.indexReader, req)
+	if err != nil {
+		rv.indexReader.Close()
+		return nil, err
+	}
+
+	atomic.AddUint64(&i.stats.searches, 1)
+	return rv, nil
+}
+
+func (r *SearchRequest) validateIterator() error {
+	if len(r.Sort) > 0 || r.SearchAfter != nil || r.CollapseField != "" || len(r.Facets) > 0 || len(r.Aggregations) > 0 || len(r.PostFilters) > 0 {
+		return ErrorSearchIteratorUnsupported
+	}
+	return nil
+}
+
+type searchIterator struct {
+	req         *SearchRequest
+	indexReader index.IndexReader
+	searcher    search.Searcher
+	highlighter highlight.Highlighter
+}
+
+func (si *searchIterator) Next() (*search.DocumentMatch, error) {
+	hit, err := si.searcher.Next()
+	if err != nil || hit == nil {
+		return nil, err
+	}
+
+	if si.req.IDsOnly {
+		hit.Locations = nil
+		hit.Expl = nil
+		return hit, nil
+	}
+	if si.highlighter != nil {
+		highlightHit(si.indexReader, si.highlighter, si.req.Highlight, hit)
+	}
+	if len(si.req.Fields) > 0 {
+		loadHitFields(si.indexReader, si.req.Fields, hit)
+	}
+	if !si.req.IncludeLocations {
+		hit.Locations = nil
+	}
+	return hit, nil
+}
+
+func (si *searchIterator) Close() error {
+	si.searcher.Close()
+	si.indexReader.Close()
+	return nil
+}
+
+// multiResultIterator returns the hits of each
+// iterator in turn
+type multiResultIterator struct {
+	iterators []ResultIterator
+}
+
+func (mi *multiResultIterator) Next() (*search.DocumentMatch, error) {
+	for len(mi.iterators) > 0 {
+		hit, err := mi.iterators[0].Next()
+		if err != nil || hit != nil {
+			return hit, err
+		}
+		err = mi.iterators[0].Close()
+		mi.iterators = mi.iterators[1:]
+		if err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+func (mi *multiResultIterator) Close() error {
+	var rv error
+	for _, iterator := range mi.iterators {
+		err := iterator.Close()
+		if err != nil && rv == nil {
+			rv = err
+		}
+	}
+	mi.iterators = nil
+	return rv
+}
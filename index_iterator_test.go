@@ -0,0 +1,148 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package bleve
+
+import (
+	"fmt"
+	"testing"
+)
+
+func indexCorpus(t *testing.T, idx Index, prefix string, n int) {
+	b := NewBatch()
+	for i := 0; i < n; i++ {
+		parity := "even"
+		if i%2 == 1 {
+			parity = "odd"
+		}
+		b.Index(fmt.Sprintf("%s%05d", prefix, i), map[string]interface{}{
+			"parity": parity,
+			"num":    float64(i),
+		})
+	}
+	err := idx.Batch(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func iterateAll(t *testing.T, idx Index, req *SearchRequest) []string {
+	iterator, err := idx.SearchIter(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := iterator.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	var rv []string
+	hit, err := iterator.Next()
+	for err == nil && hit != nil {
+		rv = append(rv, hit.ID)
+		hit, err = iterator.Next()
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rv
+}
+
+func TestSearchIter(t *testing.T) {
+	index, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	indexCorpus(t, index, "doc", 2000)
+
+	query := NewTermQuery("odd").SetField("parity")
+	res, err := index.Search(NewSearchRequest(query))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 1000 {
+		t.Fatalf("expected 1000 matches, got %d", res.Total)
+	}
+
+	ids := iterateAll(t, index, NewSearchRequest(query))
+	if uint64(len(ids)) != res.Total {
+		t.Errorf("expected %d hits, got %d", res.Total, len(ids))
+	}
+	// unsorted hits come in index order
+	for n, id := range ids {
+		expected := fmt.Sprintf("doc%05d", 2*n+1)
+		if id != expected {
+			t.Errorf("expected hit %d to be %s, got %s", n, expected, id)
+			break
+		}
+	}
+
+	min := 0.0
+	req := NewSearchRequest(NewNumericRangeQuery(&min, nil).SetField("num"))
+	req.Fields = []string{"parity"}
+	iterator, err := index.SearchIter(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hit, err := iterator.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hit == nil || hit.Fields["parity"] != "even" {
+		t.Errorf("expected stored fields to be loaded, got %v", hit)
+	}
+	err = iterator.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req = NewSearchRequest(query)
+	req.SortBy([]string{"num"})
+	_, err = index.SearchIter(req)
+	if err != ErrorSearchIteratorUnsupported {
+		t.Errorf("expected %v, got %v", ErrorSearchIteratorUnsupported, err)
+	}
+	req = NewSearchRequest(query)
+	req.AddFacet("parity", NewFacetRequest("parity", 2))
+	_, err = index.SearchIter(req)
+	if err != ErrorSearchIteratorUnsupported {
+		t.Errorf("expected %v, got %v", ErrorSearchIteratorUnsupported, err)
+	}
+}
+
+func TestSearchIterAlias(t *testing.T) {
+	index1, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index1.Close()
+	index2, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index2.Close()
+
+	indexCorpus(t, index1, "a", 300)
+	indexCorpus(t, index2, "b", 200)
+
+	alias := NewIndexAlias(index1, index2)
+	query := NewTermQuery("even").SetField("parity")
+	res, err := alias.Search(NewSearchRequest(query))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := iterateAll(t, alias, NewSearchRequest(query))
+	if uint64(len(ids)) != res.Total || res.Total != 250 {
+		t.Errorf("expected %d hits, got %d", res.Total, len(ids))
+	}
+}
@@ -0,0 +1,90 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package bleve
+
+import (
+	"github.com/blevesearch/bleve/index"
+)
+
+// An IndexListener is notified of the changes made to
+// the documents of an Index, once they are persisted.
+// Changes which fail are not notified.
+//
+// OnBatch is called for each Batch executed, with the
+// batch itself, instead of OnIndex and OnDelete for its
+// operations.  Changes made by DeleteByQuery,
+// UpdateByQuery, Update and the removal of expired
+// documents are notified one document at a time.
+//
+// Listeners are called synchronously, in the goroutine
+// making the change, after the index has released its
+// locks, so they may use the index.  Until they return
+// the method making the change does not return either,
+// so they must not block for long; hand the work to
+// another goroutine if it can be slow.  A Batch must not
+// be modified while its OnBatch runs.
+type IndexListener interface {
+	OnIndex(id string)
+	OnDelete(id string)
+	OnBatch(b *Batch)
+}
+
+// RegisterListener adds a listener notified of the
+// changes made to the documents of the index from now
+// on.
+func (i *indexImpl) RegisterListener(l IndexListener) {
+	i.listenersMutex.Lock()
+	i.listeners = append(i.listeners, l)
+	i.listenersMutex.Unlock()
+}
+
+func (i *indexImpl) currentListeners() []IndexListener {
+	i.listenersMutex.RLock()
+	defer i.listenersMutex.RUnlock()
+	return i.listeners
+}
+
+func (i *indexImpl) notifyIndex(id string) {
+	for _, l := range i.currentListeners() {
+		l.OnIndex(id)
+	}
+}
+
+func (i *indexImpl) notifyDelete(id string) {
+	for _, l := range i.currentListeners() {
+		l.OnDelete(id)
+	}
+}
+
+func (i *indexImpl) notifyBatch(b *Batch) {
+	for _, l := range i.currentListeners() {
+		l.OnBatch(b)
+	}
+}
+
+// notifyIndexBatches notifies the document operations of
+// batches built internally, one document at a time.
+func (i *indexImpl) notifyIndexBatches(ibs []*index.Batch) {
+	listeners := i.currentListeners()
+	if len(listeners) == 0 {
+		return
+	}
+	for _, ib := range ibs {
+		for id, doc := range ib.IndexOps {
+			for _, l := range listeners {
+				if doc == nil {
+					l.OnDelete(id)
+				} else {
+					l.OnIndex(id)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,264 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package bleve
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/blevesearch/bleve/index"
+	"github.com/blevesearch/bleve/search"
+	"github.com/blevesearch/bleve/search/collectors"
+	"github.com/blevesearch/bleve/search/facets"
+	"github.com/blevesearch/bleve/search/searchers"
+)
+
+// parallel reports whether the matches of the request
+// can be collected in parallel: the hits are ranked by
+// score, and the aggregations merge exactly, unlike the
+// terms kept by facets.
+func (r *SearchRequest) parallel() bool {
+	return r.Parallelism > 1 && len(r.Sort) == 0 && r.CollapseField == "" &&
+		len(r.Facets) == 0 && len(r.PostFilters) == 0
+}
+
+// searchReaders opens the reader of a request, and the
+// readers of the shards of a parallel request, when the
+// index can open them at once.
+func (i *indexImpl) searchReaders(req *SearchRequest) (index.IndexReader, []index.IndexReader, error) {
+	if multiReader, ok := i.i.(index.IndexMultiReader); ok && req.parallel() {
+		readers, err := multiReader.Readers(req.Parallelism)
+		if err != nil {
+			return nil, nil, err
+		}
+		return readers[0], readers[1:], nil
+	}
+	indexReader, err := i.i.Reader()
+	return indexReader, nil, err
+}
+
+// parallelCollector collects the matches of a request in
+// shards of the document ids, one for each worker.  The
+// first shard is read from the searcher of the request,
+// the others by goroutines searching with readers of
+// their own.  The top hits of the shards are merged ranking
+// equal scores as TopScoreCollector does, so the hits are
+// those of a serial search.
+type parallelCollector struct {
+	i       *indexImpl
+	ctx     context.Context
+	req     *SearchRequest
+	readers []index.IndexReader
+	bounds  []string
+
+	facetsBuilder *search.FacetsBuilder
+	results       search.DocumentMatchCollection
+	total         uint64
+	maxScore      float64
+	took          time.Duration
+	aggregations  search.AggregationResults
+	timedOut      bool
+}
+
+// newParallelCollector splits the document ids in shards
+// of about the same size, one for indexReader and one for
+// each of the shard readers.
+func (i *indexImpl) newParallelCollector(ctx context.Context, indexReader index.IndexReader, shardReaders []index.IndexReader, req *SearchRequest) (*parallelCollector, error) {
+	rv := parallelCollector{
+		i:       i,
+		ctx:     ctx,
+		req:     req,
+		readers: shardReaders,
+	}
+	shardSize := indexReader.DocCount() / uint64(len(shardReaders)+1)
+	if shardSize == 0 {
+		return &rv, nil
+	}
+	docIDReader, err := indexReader.DocIDReader("", "")
+	if err != nil {
+		return nil, err
+	}
+	defer docIDReader.Close()
+	var count uint64
+	id, err := docIDReader.Next()
+	for err == nil && id != "" && len(rv.bounds) < len(shardReaders) {
+		if count > 0 && count%shardSize == 0 {
+			rv.bounds = append(rv.bounds, id)
+		}
+		count++
+		id, err = docIDReader.Next()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rv, nil
+}
+
+// hitsByRank orders hits by descending score, then by
+// descending id, the order TopScoreCollector ranks the
+// hits with equal scores in.
+type hitsByRank search.DocumentMatchCollection
+
+func (h hitsByRank) Len() int      { return len(h) }
+func (h hitsByRank) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h hitsByRank) Less(i, j int) bool {
+	if h[i].Score == h[j].Score {
+		return h[i].ID > h[j].ID
+	}
+	return h[i].Score > h[j].Score
+}
+
+type shardResult struct {
+	results      search.DocumentMatchCollection
+	total        uint64
+	maxScore     float64
+	aggregations search.AggregationResults
+	timedOut     bool
+	err          error
+}
+
+func (pc *parallelCollector) Collect(searcher search.Searcher) error {
+	startTime := time.Now()
+	shardResults := make([]*shardResult, len(pc.bounds)+1)
+	var wg sync.WaitGroup
+	for n := 1; n < len(shardResults); n++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			shardResults[n] = pc.searchShard(n)
+		}(n)
+	}
+	shardResults[0] = pc.collectShard(0, searcher, pc.facetsBuilder)
+	wg.Wait()
+
+	var hits search.DocumentMatchCollection
+	for _, sr := range shardResults {
+		if sr.err != nil {
+			return sr.err
+		}
+		hits = append(hits, sr.results...)
+		pc.total += sr.total
+		if sr.maxScore > pc.maxScore {
+			pc.maxScore = sr.maxScore
+		}
+		if pc.aggregations == nil {
+			pc.aggregations = sr.aggregations
+		} else {
+			pc.aggregations.Merge(sr.aggregations)
+		}
+		pc.timedOut = pc.timedOut || sr.timedOut
+	}
+	sort.Sort(hitsByRank(hits))
+	if pc.req.From < len(hits) {
+		hits = hits[pc.req.From:]
+		if pc.req.Size < len(hits) {
+			hits = hits[:pc.req.Size]
+		}
+		pc.results = hits
+	} else {
+		pc.results = search.DocumentMatchCollection{}
+	}
+	pc.took = time.Since(startTime)
+	return nil
+}
+
+// searchShard collects the shard n with its reader and a
+// searcher of its own.
+func (pc *parallelCollector) searchShard(n int) *shardResult {
+	indexReader := pc.readers[n-1]
+	searcher, err := pc.i.requestSearcher(indexReader, pc.req)
+	if err != nil {
+		return &shardResult{err: err}
+	}
+	defer searcher.Close()
+	var contextSearcher *searchers.ContextSearcher
+	if pc.ctx.Done() != nil {
+		contextSearcher = searchers.NewContextSearcher(pc.ctx, searcher)
+		searcher = contextSearcher
+	}
+	var facetsBuilder *search.FacetsBuilder
+	if pc.req.Aggregations != nil {
+		facetsBuilder = search.NewFacetsBuilder(indexReader)
+		for aggregationName, aggregation := range pc.req.Aggregations {
+			aggregationBuilder := facets.NewMetricAggregationBuilder(aggregation.Field, aggregation.Type)
+			facetsBuilder.AddAggregation(aggregationName, aggregationBuilder)
+		}
+	}
+	rv := pc.collectShard(n, searcher, facetsBuilder)
+	if contextSearcher != nil {
+		rv.timedOut = contextSearcher.TimedOut()
+	}
+	return rv
+}
+
+func (pc *parallelCollector) collectShard(n int, searcher search.Searcher, facetsBuilder *search.FacetsBuilder) *shardResult {
+	var start, end string
+	if n > 0 {
+		start = pc.bounds[n-1]
+	}
+	if n < len(pc.bounds) {
+		end = pc.bounds[n]
+	}
+	collector := collectors.NewTopScorerCollector(pc.req.Size + pc.req.From)
+	if facetsBuilder != nil {
+		collector.SetFacetsBuilder(facetsBuilder)
+	}
+	err := collector.Collect(searchers.NewIDRangeSearcher(searcher, start, end))
+	if err != nil {
+		return &shardResult{err: err}
+	}
+	rv := &shardResult{
+		results:  collector.Results(),
+		total:    collector.Total(),
+		maxScore: collector.MaxScore(),
+	}
+	if facetsBuilder != nil {
+		rv.aggregations = facetsBuilder.AggregationResults()
+	}
+	return rv
+}
+
+func (pc *parallelCollector) Results() search.DocumentMatchCollection {
+	return pc.results
+}
+
+func (pc *parallelCollector) Total() uint64 {
+	return pc.total
+}
+
+func (pc *parallelCollector) MaxScore() float64 {
+	return pc.maxScore
+}
+
+func (pc *parallelCollector) Took() time.Duration {
+	return pc.took
+}
+
+func (pc *parallelCollector) SetFacetsBuilder(facetsBuilder *search.FacetsBuilder) {
+	pc.facetsBuilder = facetsBuilder
+}
+
+func (pc *parallelCollector) FacetResults() search.FacetResults {
+	return search.FacetResults{}
+}
+
+// AggregationResults returns the aggregations merged
+// from all the shards.
+func (pc *parallelCollector) AggregationResults() search.AggregationResults {
+	return pc.aggregations
+}
+
+// TimedOut reports whether the context was done before
+// the shards searched by goroutines were collected.
+func (pc *parallelCollector) TimedOut() bool {
+	return pc.timedOut
+}
@@ -0,0 +1,157 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package bleve
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/blevesearch/bleve/search"
+)
+
+// indexParallelDocs indexes count documents, the text of
+// each repeating beer from 1 to 5 times, so many of them
+// score the same.
+func indexParallelDocs(index Index, count int) error {
+	batch := NewBatch()
+	for n := 0; n < count; n++ {
+		doc := map[string]interface{}{
+			"text": strings.Repeat("beer ", n%5+1) + "wine",
+			"num":  float64(n % 7),
+		}
+		batch.Index(fmt.Sprintf("%05d", n), doc)
+		if batch.Size() >= 1000 {
+			err := index.Batch(batch)
+			if err != nil {
+				return err
+			}
+			batch = NewBatch()
+		}
+	}
+	return index.Batch(batch)
+}
+
+func hitIDsAndScores(hits search.DocumentMatchCollection) []string {
+	rv := make([]string, len(hits))
+	for n, hit := range hits {
+		rv[n] = fmt.Sprintf("%s:%f", hit.ID, hit.Score)
+	}
+	return rv
+}
+
+func TestParallelSearchMatchesSerial(t *testing.T) {
+	defer os.RemoveAll("testidx")
+
+	index, err := New("testidx", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	err = indexParallelDocs(index, 500)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newRequest := func(parallelism, from, size int) *SearchRequest {
+		req := NewSearchRequestOptions(NewMatchQuery("beer").SetField("text"), size, from, false)
+		req.AddAggregation("sum", NewMetricAggregation(search.MetricSum, "num"))
+		req.AddAggregation("avg", NewMetricAggregation(search.MetricAvg, "num"))
+		req.AddAggregation("max", NewMetricAggregation(search.MetricMax, "num"))
+		req.Parallelism = parallelism
+		return req
+	}
+
+	pages := []struct {
+		from int
+		size int
+	}{
+		{from: 0, size: 10},
+		{from: 95, size: 20},
+		{from: 490, size: 20},
+		{from: 600, size: 10},
+	}
+	for _, page := range pages {
+		serial, err := index.Search(newRequest(0, page.from, page.size))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, parallelism := range []int{2, 3, 8} {
+			res, err := index.Search(newRequest(parallelism, page.from, page.size))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Total != serial.Total || res.MaxScore != serial.MaxScore {
+				t.Errorf("parallelism %d from %d: expected total %d max score %f, got %d %f",
+					parallelism, page.from, serial.Total, serial.MaxScore, res.Total, res.MaxScore)
+			}
+			expected := hitIDsAndScores(serial.Hits)
+			got := hitIDsAndScores(res.Hits)
+			if !reflect.DeepEqual(got, expected) {
+				t.Errorf("parallelism %d from %d: expected hits %v, got %v", parallelism, page.from, expected, got)
+			}
+			if !reflect.DeepEqual(res.Aggregations, serial.Aggregations) {
+				t.Errorf("parallelism %d from %d: expected aggregations %v, got %v",
+					parallelism, page.from, serial.Aggregations, res.Aggregations)
+			}
+		}
+	}
+
+	// requests with facets are collected serially
+	req := newRequest(4, 0, 10)
+	req.AddFacet("num", NewFacetRequest("num", 3))
+	if req.parallel() {
+		t.Errorf("expected request with facets not to be parallel")
+	}
+}
+
+func benchmarkParallelSearch(b *testing.B, parallelism int) {
+	index, err := New("", NewIndexMapping())
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer index.Close()
+
+	err = indexParallelDocs(index, 50000)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	req := NewSearchRequest(NewMatchQuery("beer").SetField("text"))
+	req.AddAggregation("sum", NewMetricAggregation(search.MetricSum, "num"))
+	req.Parallelism = parallelism
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err = index.Search(req)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParallelSearch1Workers(b *testing.B) {
+	benchmarkParallelSearch(b, 1)
+}
+
+func BenchmarkParallelSearch2Workers(b *testing.B) {
+	benchmarkParallelSearch(b, 2)
+}
+
+func BenchmarkParallelSearch4Workers(b *testing.B) {
+	benchmarkParallelSearch(b, 4)
+}
+
+func BenchmarkParallelSearch8Workers(b *testing.B) {
+	benchmarkParallelSearch(b, 8)
+}
@@ -0,0 +1,141 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package bleve
+
+import (
+	"github.com/blevesearch/bleve/index"
+	"github.com/blevesearch/bleve/search"
+)
+
+// postFilters checks the matches of a search against
+// the post filters of the request.  The facets on the
+// fields of post filters are updated here, from the
+// matches accepted by the filters on other fields,
+// before the rejected matches are dropped.
+type postFilters struct {
+	fields    []string
+	searchers []search.Searcher
+	current   []*search.DocumentMatch
+	done      []bool
+	accepted  []bool
+	facets    map[string]*search.FacetsBuilder
+}
+
+func (i *indexImpl) newPostFilters(indexReader index.IndexReader, req *SearchRequest) (*postFilters, error) {
+	rv := postFilters{
+		fields:    make([]string, len(req.PostFilters)),
+		searchers: make([]search.Searcher, 0, len(req.PostFilters)),
+		current:   make([]*search.DocumentMatch, len(req.PostFilters)),
+		done:      make([]bool, len(req.PostFilters)),
+		accepted:  make([]bool, len(req.PostFilters)),
+		facets:    make(map[string]*search.FacetsBuilder),
+	}
+	for n, filter := range req.PostFilters {
+		searcher, err := filter.Query.Searcher(requestScoringIndexReader(indexReader, i.m, req), i.m, false)
+		if err != nil {
+			rv.Close()
+			return nil, err
+		}
+		rv.fields[n] = filter.Field
+		rv.searchers = append(rv.searchers, searcher)
+	}
+	return &rv, nil
+}
+
+// filters reports whether a post filter is on field.
+func (p *postFilters) filters(field string) bool {
+	for _, f := range p.fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// addFacet counts the facet from the matches accepted
+// by the post filters on fields other than field.
+func (p *postFilters) addFacet(indexReader index.IndexReader, field, name string, facetBuilder search.FacetBuilder) {
+	facetsBuilder, ok := p.facets[field]
+	if !ok {
+		facetsBuilder = search.NewFacetsBuilder(indexReader)
+		p.facets[field] = facetsBuilder
+	}
+	facetsBuilder.Add(name, facetBuilder)
+}
+
+// matches reports whether the post filter n matches the
+// document.  Matches arrive by ascending id, so each
+// filter searcher only moves forward.
+func (p *postFilters) matches(n int, id string) (bool, error) {
+	if p.done[n] {
+		return false, nil
+	}
+	if p.current[n] == nil || p.current[n].ID < id {
+		next, err := p.searchers[n].Advance(id)
+		if err != nil {
+			return false, err
+		}
+		if next == nil {
+			p.done[n] = true
+			return false, nil
+		}
+		p.current[n] = next
+	}
+	return p.current[n].ID == id, nil
+}
+
+// accept is the filter of the searcher of the request.
+func (p *postFilters) accept(d *search.DocumentMatch) (bool, error) {
+	rv := true
+	for n := range p.searchers {
+		ok, err := p.matches(n, d.ID)
+		if err != nil {
+			return false, err
+		}
+		p.accepted[n] = ok
+		rv = rv && ok
+	}
+	for field, facetsBuilder := range p.facets {
+		if p.acceptedIgnoring(field) {
+			err := facetsBuilder.Update(d)
+			if err != nil {
+				return false, err
+			}
+		}
+	}
+	return rv, nil
+}
+
+func (p *postFilters) acceptedIgnoring(field string) bool {
+	for n, ok := range p.accepted {
+		if !ok && p.fields[n] != field {
+			return false
+		}
+	}
+	return true
+}
+
+// FacetResults returns the facets on the fields of
+// post filters.
+func (p *postFilters) FacetResults() search.FacetResults {
+	rv := make(search.FacetResults)
+	for _, facetsBuilder := range p.facets {
+		for name, facetResult := range facetsBuilder.Results() {
+			rv[name] = facetResult
+		}
+	}
+	return rv
+}
+
+func (p *postFilters) Close() {
+	for _, searcher := range p.searchers {
+		searcher.Close()
+	}
+}
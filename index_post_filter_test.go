@@ -0,0 +1,131 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package bleve
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/blevesearch/bleve/search"
+)
+
+func termCounts(facetResult *search.FacetResult) map[string]int {
+	rv := make(map[string]int)
+	for _, tf := range facetResult.Terms {
+		rv[tf.Term] = tf.Count
+	}
+	return rv
+}
+
+func TestPostFilterMultiSelectFacets(t *testing.T) {
+	index, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	docs := map[string]map[string]interface{}{
+		"a": {"color": "red", "brand": "acme"},
+		"b": {"color": "blue", "brand": "acme"},
+		"c": {"color": "green", "brand": "bolt"},
+		"d": {"color": "red", "brand": "bolt"},
+		"e": {"color": "blue", "brand": "acme"},
+	}
+	for id, doc := range docs {
+		err = index.Index(id, doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		filters map[string]string
+		hits    []string
+		colors  map[string]int
+		brands  map[string]int
+	}{
+		{
+			filters: nil,
+			hits:    []string{"a", "b", "c", "d", "e"},
+			colors:  map[string]int{"red": 2, "blue": 2, "green": 1},
+			brands:  map[string]int{"acme": 3, "bolt": 2},
+		},
+		// the color facet keeps every color
+		{
+			filters: map[string]string{"color": "red"},
+			hits:    []string{"a", "d"},
+			colors:  map[string]int{"red": 2, "blue": 2, "green": 1},
+			brands:  map[string]int{"acme": 1, "bolt": 1},
+		},
+		// each facet applies the filter on the other field
+		{
+			filters: map[string]string{"color": "red", "brand": "acme"},
+			hits:    []string{"a"},
+			colors:  map[string]int{"red": 1, "blue": 2},
+			brands:  map[string]int{"acme": 1, "bolt": 1},
+		},
+	}
+
+	for i, test := range tests {
+		req := NewSearchRequest(NewMatchAllQuery())
+		req.AddFacet("colors", NewFacetRequest("color", 10))
+		req.AddFacet("brands", NewFacetRequest("brand", 10))
+		for field, term := range test.filters {
+			req.AddPostFilter(field, NewTermQuery(term).SetField(field))
+		}
+		res, err := index.Search(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Total != uint64(len(test.hits)) {
+			t.Errorf("test %d: expected %d total, got %d", i, len(test.hits), res.Total)
+		}
+		ids := make([]string, 0, len(res.Hits))
+		for _, hit := range res.Hits {
+			ids = append(ids, hit.ID)
+		}
+		sort.Strings(ids)
+		if !reflect.DeepEqual(ids, test.hits) {
+			t.Errorf("test %d: expected hits %v, got %v", i, test.hits, ids)
+		}
+		colors := termCounts(res.Facets["colors"])
+		if !reflect.DeepEqual(colors, test.colors) {
+			t.Errorf("test %d: expected colors %v, got %v", i, test.colors, colors)
+		}
+		brands := termCounts(res.Facets["brands"])
+		if !reflect.DeepEqual(brands, test.brands) {
+			t.Errorf("test %d: expected brands %v, got %v", i, test.brands, brands)
+		}
+	}
+
+	req := NewSearchRequest(NewMatchAllQuery())
+	req.PostFilters = []*PostFilter{{Field: "color"}}
+	_, err = index.Search(req)
+	if err != ErrorPostFilterNoQuery {
+		t.Errorf("expected %v, got %v", ErrorPostFilterNoQuery, err)
+	}
+}
+
+func TestUnmarshalPostFilters(t *testing.T) {
+	var req SearchRequest
+	err := json.Unmarshal([]byte(`{
+		"query": {"match_all": {}},
+		"post_filters": [{"field": "color", "query": {"term": "red", "field": "color"}}]
+	}`), &req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []*PostFilter{NewPostFilter("color", NewTermQuery("red").SetField("color"))}
+	if !reflect.DeepEqual(req.PostFilters, expected) {
+		t.Errorf("expected %v, got %v", expected, req.PostFilters)
+	}
+}
@@ -0,0 +1,168 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package bleve
+
+import (
+	"fmt"
+
+	"github.com/blevesearch/bleve/index"
+)
+
+// A TermAnalysis reports how many documents index
+// the term of one term clause of a query in its field.
+// A DocFreq of 0 means the clause matches nothing.
+type TermAnalysis struct {
+	Field   string `json:"field"`
+	Term    string `json:"term"`
+	DocFreq uint64 `json:"doc_freq"`
+}
+
+// A QueryAnalysis holds a TermAnalysis for every term
+// clause of a query, in the order they appear in it.
+type QueryAnalysis struct {
+	Terms []*TermAnalysis `json:"terms"`
+}
+
+// Unmatched returns the term clauses which match no
+// documents.
+func (qa QueryAnalysis) Unmatched() []*TermAnalysis {
+	var rv []*TermAnalysis
+	for _, t := range qa.Terms {
+		if t.DocFreq == 0 {
+			rv = append(rv, t)
+		}
+	}
+	return rv
+}
+
+// AnalyzeQuery reports the document frequency of the
+// term clauses of q, without running the search.
+// Match queries are analyzed into their terms the same
+// way they are when searching.  Clauses which are not
+// for a single term, like prefix or range queries, are
+// not reported.
+func (i *indexImpl) AnalyzeQuery(q Query) (QueryAnalysis, error) {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	if !i.open {
+		return QueryAnalysis{}, ErrorIndexClosed
+	}
+
+	indexReader, err := i.i.Reader()
+	if err != nil {
+		return QueryAnalysis{}, err
+	}
+	defer indexReader.Close()
+
+	var rv QueryAnalysis
+	err = analyzeQuery(q, indexReader, i.m, &rv)
+	return rv, err
+}
+
+func analyzeQuery(q Query, r index.IndexReader, m *IndexMapping, rv *QueryAnalysis) error {
+	switch q := q.(type) {
+	case *termQuery:
+		return analyzeTerm(q.Term, q.FieldVal, r, m, rv)
+	case *rawTermQuery:
+		return analyzeTerm(q.RawTerm, q.rawField(m), r, m, rv)
+	case *matchQuery:
+		return analyzeText(q.Match, q.FieldVal, q.Analyzer, r, m, rv)
+	case *multiMatchQuery:
+		for _, f := range q.Fields {
+			field, _, err := parseFieldBoost(f)
+			if err != nil {
+				return err
+			}
+			err = analyzeText(q.MultiMatch, field, q.Analyzer, r, m, rv)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case *matchPhraseQuery:
+		return analyzeText(q.MatchPhrase, q.FieldVal, q.Analyzer, r, m, rv)
+	case *phraseQuery:
+		return analyzeQueries(q.TermQueries, r, m, rv)
+	case *conjunctionQuery:
+		return analyzeQueries(q.Conjuncts, r, m, rv)
+	case *disjunctionQuery:
+		return analyzeQueries(q.Disjuncts, r, m, rv)
+	case *disjunctionMaxQuery:
+		return analyzeQueries(q.Disjuncts, r, m, rv)
+	case *nestedQuery:
+		return analyzeQueries(q.Clauses, r, m, rv)
+	case *booleanQuery:
+		return analyzeQueries([]Query{q.Must, q.Should, q.MustNot, q.Filter}, r, m, rv)
+	case *constantScoreQuery:
+		return analyzeQuery(q.Filter, r, m, rv)
+	case *functionScoreQuery:
+		return analyzeQuery(q.Query, r, m, rv)
+	case *queryStringQuery:
+		parsed, err := parseQuerySyntax(q.Query, m)
+		if err != nil {
+			return err
+		}
+		return analyzeQuery(parsed, r, m, rv)
+	}
+	return nil
+}
+
+func analyzeQueries(qs []Query, r index.IndexReader, m *IndexMapping, rv *QueryAnalysis) error {
+	for _, q := range qs {
+		if q == nil {
+			continue
+		}
+		err := analyzeQuery(q, r, m, rv)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func analyzeText(text, field, analyzerName string, r index.IndexReader, m *IndexMapping, rv *QueryAnalysis) error {
+	if field == "" {
+		field = m.DefaultField
+	}
+	if analyzerName == "" {
+		analyzerName = m.analyzerNameForPath(field)
+	}
+	analyzer := m.analyzerNamed(analyzerName)
+	if analyzer == nil {
+		return fmt.Errorf("no analyzer named '%s' registered", analyzerName)
+	}
+
+	for _, token := range m.analyzeQueryText(analyzerName, analyzer, text) {
+		err := analyzeTerm(string(token.Term), field, r, m, rv)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func analyzeTerm(term, field string, r index.IndexReader, m *IndexMapping, rv *QueryAnalysis) error {
+	if field == "" {
+		field = m.DefaultField
+	}
+	tfr, err := r.TermFieldReader([]byte(term), field)
+	if err != nil {
+		return err
+	}
+	defer tfr.Close()
+
+	rv.Terms = append(rv.Terms, &TermAnalysis{
+		Field:   field,
+		Term:    term,
+		DocFreq: tfr.Count(),
+	})
+	return nil
+}
@@ -0,0 +1,120 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package bleve
+
+import (
+	"os"
+	"testing"
+)
+
+func TestOpenReadOnly(t *testing.T) {
+	defer os.RemoveAll("testidx")
+
+	index, err := New("testidx", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = index.Index("a", map[string]interface{}{"name": "marty"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = index.SetInternal([]byte("k"), []byte("v"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = index.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// several readers can have the index open at once
+	readers := make([]Index, 2)
+	for n := range readers {
+		readers[n], err = OpenReadOnly("testidx")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer readers[n].Close()
+	}
+
+	for _, index := range readers {
+		count, err := index.DocCount()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 1 {
+			t.Errorf("expected 1 document, got %d", count)
+		}
+		doc, err := index.Document("a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if doc == nil {
+			t.Errorf("expected document a")
+		}
+		res, err := index.Search(NewSearchRequest(NewTermQuery("marty").SetField("name")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Total != 1 {
+			t.Errorf("expected 1 hit, got %d", res.Total)
+		}
+		val, err := index.GetInternal([]byte("k"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(val) != "v" {
+			t.Errorf("expected internal value v, got %q", val)
+		}
+	}
+
+	index = readers[0]
+	batch := NewBatch()
+	batch.Delete("a")
+	writes := map[string]error{
+		"index":  index.Index("b", map[string]interface{}{"name": "doc"}),
+		"delete": index.Delete("a"),
+		"batch":  index.Batch(batch),
+		"update": index.Update("a", map[string]interface{}{"name": "emmett"}),
+		"set":    index.SetInternal([]byte("k"), []byte("w")),
+		"unset":  index.DeleteInternal([]byte("k")),
+	}
+	_, writes["delete by query"] = index.DeleteByQuery(NewMatchAllQuery())
+	_, writes["update by query"] = index.UpdateByQuery(NewMatchAllQuery(), map[string]interface{}{"name": "emmett"})
+	writes["index with ttl"] = index.IndexWithTTL("c", map[string]interface{}{"name": "doc"}, 0)
+	for write, err := range writes {
+		if err != ErrorIndexReadOnly {
+			t.Errorf("%s: expected %v, got %v", write, ErrorIndexReadOnly, err)
+		}
+	}
+
+	// the store itself refuses writes too
+	_, kvstore, err := index.Advanced()
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer, err := kvstore.Writer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = writer.Set([]byte("k"), []byte("w"))
+	if err == nil {
+		t.Errorf("expected error writing to read-only store")
+	}
+	writer.Close()
+
+	count, err := index.DocCount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("expected 1 document after the failed writes, got %d", count)
+	}
+}
@@ -0,0 +1,138 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package bleve
+
+import (
+	"fmt"
+
+	"github.com/blevesearch/bleve/document"
+)
+
+// reindexBatchSize is the number of documents
+// ReindexInto indexes into the destination per batch
+var reindexBatchSize = 1000
+
+// ReindexError is returned by ReindexInto when some
+// documents could not be indexed with the mapping of the
+// destination, Failures holds the error of each of them
+// by document identifier.  The other documents were
+// reindexed.
+type ReindexError struct {
+	Failures map[string]error
+}
+
+func (e *ReindexError) Error() string {
+	return fmt.Sprintf("%d documents could not be reindexed", len(e.Failures))
+}
+
+// ReindexInto indexes every document of src into dst,
+// using the mapping of dst.  Documents are rebuilt from
+// their stored fields, as by Update, so fields mapped
+// with store disabled are not carried over, nor is any
+// expiry set by IndexWithTTL.  Documents which fail to
+// map do not stop the migration, they are reported in
+// a *ReindexError once all the others are reindexed.
+// Any other error stops the migration, the documents of
+// the batches already executed stay in dst.
+func ReindexInto(src, dst Index) error {
+	return ReindexIntoProgress(src, dst, nil)
+}
+
+// ReindexIntoProgress is like ReindexInto, calling
+// progress, if not nil, for each document once it is
+// reindexed, with a nil error, or with the error it
+// failed to map with.
+func ReindexIntoProgress(src, dst Index, progress func(id string, err error)) error {
+	req := NewSearchRequest(NewMatchAllQuery())
+	req.IDsOnly = true
+	iter, err := src.SearchIter(req)
+	if err != nil {
+		return err
+	}
+	defer iter.Close()
+
+	failures := make(map[string]error)
+	ids := make([]string, 0, reindexBatchSize)
+	for {
+		hit, err := iter.Next()
+		if err != nil {
+			return err
+		}
+		if hit != nil {
+			ids = append(ids, hit.ID)
+		}
+		if len(ids) == reindexBatchSize || (hit == nil && len(ids) > 0) {
+			err = reindexBatch(src, dst, ids, failures, progress)
+			if err != nil {
+				return err
+			}
+			ids = ids[:0]
+		}
+		if hit == nil {
+			break
+		}
+	}
+
+	if len(failures) > 0 {
+		return &ReindexError{Failures: failures}
+	}
+	return nil
+}
+
+// reindexBatch indexes the documents into dst in one
+// batch, leaving out those failing to map, and the
+// documents deleted from src since they were found
+func reindexBatch(src, dst Index, ids []string, failures map[string]error, progress func(id string, err error)) error {
+	srcIndex, _, err := src.Advanced()
+	if err != nil {
+		return err
+	}
+	indexReader, err := srcIndex.Reader()
+	if err != nil {
+		return err
+	}
+	defer indexReader.Close()
+
+	m := dst.Mapping()
+	b := NewBatch()
+	batched := make([]string, 0, len(ids))
+	for _, id := range ids {
+		source, err := storedSource(indexReader, id)
+		if err != nil {
+			return err
+		}
+		if source == nil {
+			continue
+		}
+		// map the document first to find out whether
+		// it fails, which would fail the whole batch
+		err = m.mapDocument(document.NewDocument(id), source)
+		if err != nil {
+			failures[id] = err
+			if progress != nil {
+				progress(id, err)
+			}
+			continue
+		}
+		b.Index(id, source)
+		batched = append(batched, id)
+	}
+
+	err = dst.Batch(b)
+	if err != nil {
+		return err
+	}
+	if progress != nil {
+		for _, id := range batched {
+			progress(id, nil)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package bleve
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestReindexInto(t *testing.T) {
+	defer func(size int) {
+		reindexBatchSize = size
+	}(reindexBatchSize)
+	// reindex in several batches
+	reindexBatchSize = 2
+
+	src, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+
+	docs := map[string]map[string]interface{}{
+		"a": {"name": "marty", "addr": "10.0.0.1"},
+		"b": {"name": "steve", "addr": "10.0.0.2"},
+		"c": {"name": "dustin", "addr": "192.168.1.7"},
+		"d": {"name": "gary", "addr": "unknown"},
+		"e": {"name": "sarah", "addr": "10.1.0.9"},
+	}
+	for id, doc := range docs {
+		err = src.Index(id, doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the address, indexed as text in src, becomes an IP
+	dstMapping := NewIndexMapping()
+	dstMapping.DefaultMapping.AddFieldMappingsAt("addr", NewIPFieldMapping())
+	dst, err := New("", dstMapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dst.Close()
+
+	progress := make(map[string]error)
+	err = ReindexIntoProgress(src, dst, func(id string, err error) {
+		progress[id] = err
+	})
+	reindexErr, ok := err.(*ReindexError)
+	if !ok {
+		t.Fatalf("expected *ReindexError, got %v", err)
+	}
+	if len(reindexErr.Failures) != 1 || reindexErr.Failures["d"] == nil {
+		t.Errorf("expected only d to fail, got %v", reindexErr.Failures)
+	}
+	if len(progress) != len(docs) {
+		t.Errorf("expected progress for %d documents, got %d", len(docs), len(progress))
+	}
+	for id, err := range progress {
+		if (err != nil) != (id == "d") {
+			t.Errorf("unexpected progress error %v for %s", err, id)
+		}
+	}
+
+	count, err := dst.DocCount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 4 {
+		t.Errorf("expected 4 documents reindexed, got %d", count)
+	}
+
+	res, err := dst.Search(NewSearchRequest(NewCIDRQuery("10.0.0.0/8").SetField("addr")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := []string{}
+	for _, hit := range res.Hits {
+		ids = append(ids, hit.ID)
+	}
+	sort.Strings(ids)
+	expected := []string{"a", "b", "e"}
+	if !reflect.DeepEqual(ids, expected) {
+		t.Errorf("expected %v, got %v", expected, ids)
+	}
+
+	// the other fields are carried over unchanged
+	name, err := dst.DocumentField("c", "name")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "dustin" {
+		t.Errorf("expected name dustin, got %v", name)
+	}
+
+	// reindexing an index mapping all its documents succeeds
+	again, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer again.Close()
+	err = ReindexInto(dst, again)
+	if err != nil {
+		t.Fatal(err)
+	}
+	count, err = again.DocCount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 4 {
+		t.Errorf("expected 4 documents reindexed, got %d", count)
+	}
+}
+
+func TestReindexIntoNested(t *testing.T) {
+	var mapping IndexMapping
+	err := json.Unmarshal([]byte(`{
+		"default_mapping": {
+			"properties": {
+				"items": {"nested": true}
+			}
+		}
+	}`), &mapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := New("", &mapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+	dst, err := New("", &mapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dst.Close()
+
+	items := []interface{}{
+		map[string]interface{}{"color": "red"},
+		map[string]interface{}{"color": "blue", "size": "large"},
+	}
+	err = src.Index("a", map[string]interface{}{"items": items})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = ReindexInto(src, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]interface{}{"items": items}
+	actual := indexedSource(t, dst, "a")
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+
+	// the values of each item must stay together
+	res, err := dst.Search(NewSearchRequest(NewNestedQuery("items", []Query{
+		NewMatchQuery("red").SetField("items.color"),
+		NewMatchQuery("large").SetField("items.size"),
+	})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 0 {
+		t.Errorf("expected no hits, got %v", res.Hits)
+	}
+	res, err = dst.Search(NewSearchRequest(NewNestedQuery("items", []Query{
+		NewMatchQuery("blue").SetField("items.color"),
+		NewMatchQuery("large").SetField("items.size"),
+	})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 1 {
+		t.Errorf("expected 1 hit, got %v", res.Hits)
+	}
+}
@@ -0,0 +1,74 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package bleve
+
+import (
+	"github.com/blevesearch/bleve/index"
+)
+
+// mappingIndexReader passes the field boosts of the
+// mapping to the term searchers, and the maximum clause
+// count to the searchers expanding terms
+type mappingIndexReader struct {
+	index.IndexReader
+	m *IndexMapping
+
+	maxClauseCount  int
+	truncateClauses bool
+}
+
+func (r *mappingIndexReader) FieldBoost(field string) float64 {
+	return r.m.fieldBoost(field)
+}
+
+func (r *mappingIndexReader) MaxClauseCount() (int, bool) {
+	return r.maxClauseCount, r.truncateClauses
+}
+
+// bm25IndexReader also passes the BM25 parameters
+type bm25IndexReader struct {
+	*mappingIndexReader
+}
+
+func (r *bm25IndexReader) BM25Params() (float64, float64) {
+	return r.m.BM25K1, r.m.BM25B
+}
+
+// scoringIndexReader wraps the reader used for searching
+// to score according to the mapping, expanding up to
+// Config.MaxClauseCount terms
+func scoringIndexReader(indexReader index.IndexReader, m *IndexMapping) index.IndexReader {
+	return limitedScoringIndexReader(indexReader, m, Config.MaxClauseCount, false)
+}
+
+// requestScoringIndexReader is scoringIndexReader with
+// the maximum clause count of the request, when it sets one
+func requestScoringIndexReader(indexReader index.IndexReader, m *IndexMapping, req *SearchRequest) index.IndexReader {
+	maxClauseCount := Config.MaxClauseCount
+	if req.MaxClauseCount > 0 {
+		maxClauseCount = req.MaxClauseCount
+	}
+	return limitedScoringIndexReader(indexReader, m, maxClauseCount, req.TruncateClauses)
+}
+
+// limitedScoringIndexReader is scoringIndexReader with
+// the given maximum clause count
+func limitedScoringIndexReader(indexReader index.IndexReader, m *IndexMapping, maxClauseCount int, truncateClauses bool) index.IndexReader {
+	rv := &mappingIndexReader{
+		IndexReader:     indexReader,
+		m:               m,
+		maxClauseCount:  maxClauseCount,
+		truncateClauses: truncateClauses,
+	}
+	if m.ScoringModel == ScoringModelBM25 {
+		return &bm25IndexReader{rv}
+	}
+	return rv
+}
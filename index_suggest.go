@@ -0,0 +1,99 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package bleve
+
+import (
+	"sort"
+
+	"github.com/blevesearch/bleve/search"
+)
+
+// A Suggestion is a term of the index close to the
+// term a suggestion was asked for.
+type Suggestion struct {
+	Term     string `json:"term"`
+	Distance int    `json:"distance"`
+	DocFreq  uint64 `json:"doc_freq"`
+}
+
+// SuggestTerms returns the terms indexed in field which
+// are within maxEdits of term, as measured by the
+// Levenshtein distance used by fuzzy queries.  The closest
+// terms come first, and among those the ones found in the
+// most documents, so a term which exists is always the
+// first suggestion.  At most maxSuggestions are returned,
+// all of them if it is 0.
+func (i *indexImpl) SuggestTerms(field, term string, maxEdits, maxSuggestions int) ([]Suggestion, error) {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	if !i.open {
+		return nil, ErrorIndexClosed
+	}
+
+	if field == "" {
+		field = i.m.DefaultField
+	}
+
+	indexReader, err := i.i.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer indexReader.Close()
+
+	fieldReader, err := indexReader.FieldReader(field, []byte{}, []byte{})
+	if err != nil {
+		return nil, err
+	}
+	defer fieldReader.Close()
+
+	rv := make([]Suggestion, 0)
+	tfd, err := fieldReader.Next()
+	for err == nil && tfd != nil {
+		ld, exceeded := search.LevenshteinDistanceMax(&term, &tfd.Term, maxEdits)
+		if !exceeded && ld <= maxEdits {
+			rv = append(rv, Suggestion{
+				Term:     tfd.Term,
+				Distance: ld,
+				DocFreq:  tfd.Freq,
+			})
+		}
+		tfd, err = fieldReader.Next()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return sortSuggestions(rv, maxSuggestions), nil
+}
+
+// sortSuggestions orders suggestions closest first, then
+// most frequent first, keeping at most max of them.
+func sortSuggestions(suggestions []Suggestion, max int) []Suggestion {
+	sort.Sort(suggestionsByRank(suggestions))
+	if max > 0 && len(suggestions) > max {
+		suggestions = suggestions[:max]
+	}
+	return suggestions
+}
+
+type suggestionsByRank []Suggestion
+
+func (s suggestionsByRank) Len() int      { return len(s) }
+func (s suggestionsByRank) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s suggestionsByRank) Less(i, j int) bool {
+	if s[i].Distance != s[j].Distance {
+		return s[i].Distance < s[j].Distance
+	}
+	if s[i].DocFreq != s[j].DocFreq {
+		return s[i].DocFreq > s[j].DocFreq
+	}
+	return s[i].Term < s[j].Term
+}
@@ -10,11 +10,25 @@
 package bleve
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"log"
+	"math"
 	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/blevesearch/bleve/document"
+	"github.com/blevesearch/bleve/geo"
+	"github.com/blevesearch/bleve/search"
+	"github.com/blevesearch/bleve/search/highlight"
+	"github.com/blevesearch/bleve/search/searchers"
 )
 
 func TestCrud(t *testing.T) {
@@ -266,6 +280,16 @@ func TestClosedIndex(t *testing.T) {
 		t.Errorf("expected error index closed, got %v", err)
 	}
 
+	_, err = index.Documents([]string{"test"})
+	if err != ErrorIndexClosed {
+		t.Errorf("expected error index closed, got %v", err)
+	}
+
+	err = index.Backup(ioutil.Discard)
+	if err != ErrorIndexClosed {
+		t.Errorf("expected error index closed, got %v", err)
+	}
+
 	_, err = index.DocCount()
 	if err != ErrorIndexClosed {
 		t.Errorf("expected error index closed, got %v", err)
@@ -325,3 +349,4059 @@ func (s *sawDataWriter) Write(p []byte) (n int, err error) {
 	s.sawData = true
 	return len(p), nil
 }
+
+func TestConstantScoreQuery(t *testing.T) {
+	index, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	docs := map[string]string{
+		"a": "beer",
+		"b": "beer beer beer light beer",
+		"c": "a very long description mentioning beer only once",
+		"d": "water",
+	}
+	for id, desc := range docs {
+		err = index.Index(id, map[string]interface{}{"desc": desc})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	query := NewConstantScoreQuery(NewMatchQuery("beer").SetField("desc")).SetBoost(3.0)
+	res, err := index.Search(NewSearchRequest(query))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 3 {
+		t.Fatalf("expected 3 hits, got %d", res.Total)
+	}
+	for _, hit := range res.Hits {
+		if hit.ID == "d" {
+			t.Errorf("expected non-matching doc 'd' to be excluded")
+		}
+		if hit.Score != res.Hits[0].Score {
+			t.Errorf("expected all hits to score %f, got %f for %s", res.Hits[0].Score, hit.Score, hit.ID)
+		}
+	}
+
+	// as a filter, only the other clause affects relative scoring
+	boolQuery := NewBooleanQueryMinShould(
+		[]Query{query},
+		[]Query{NewMatchQuery("light").SetField("desc")},
+		nil, 0)
+	res, err = index.Search(NewSearchRequest(boolQuery))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 3 {
+		t.Fatalf("expected 3 hits, got %d", res.Total)
+	}
+	if res.Hits[0].ID != "b" {
+		t.Errorf("expected 'b' to score highest, got %s", res.Hits[0].ID)
+	}
+	if res.Hits[1].Score != res.Hits[2].Score {
+		t.Errorf("expected remaining hits to score equally, got %f and %f", res.Hits[1].Score, res.Hits[2].Score)
+	}
+}
+
+func TestCollapseSearch(t *testing.T) {
+	index, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	docs := map[string]map[string]interface{}{
+		"a1": {"domain": "alpha", "body": "beer beer beer"},
+		"a2": {"domain": "alpha", "body": "beer beer and some other words"},
+		"a3": {"domain": "alpha", "body": "beer and lots of other words too"},
+		"b1": {"domain": "beta", "body": "beer beer with more words"},
+		"b2": {"domain": "beta", "body": "beer with many other words too"},
+		"c1": {"domain": "gamma", "body": "water"},
+		"m1": {"body": "beer beer beer words"},
+		"m2": {"body": "beer and words"},
+	}
+	for id, doc := range docs {
+		err = index.Index(id, doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		size           int
+		from           int
+		collapseSize   int
+		excludeMissing bool
+		total          uint64
+		totalGroups    uint64
+		groups         [][]string
+	}{
+		{
+			size:        10,
+			total:       7,
+			totalGroups: 4,
+			groups:      [][]string{{"a1"}, {"m1"}, {"b1"}, {"m2"}},
+		},
+		{
+			size:           10,
+			excludeMissing: true,
+			total:          5,
+			totalGroups:    2,
+			groups:         [][]string{{"a1"}, {"b1"}},
+		},
+		{
+			size:           10,
+			collapseSize:   2,
+			excludeMissing: true,
+			total:          5,
+			totalGroups:    2,
+			groups:         [][]string{{"a1", "a2"}, {"b1", "b2"}},
+		},
+		{
+			size:           1,
+			from:           1,
+			collapseSize:   5,
+			excludeMissing: true,
+			total:          5,
+			totalGroups:    2,
+			groups:         [][]string{{"b1", "b2"}},
+		},
+	}
+
+	for testIndex, test := range tests {
+		req := NewSearchRequestOptions(NewMatchQuery("beer").SetField("body"), test.size, test.from, false)
+		req.SetCollapse("domain", test.collapseSize)
+		req.CollapseExcludeMissing = test.excludeMissing
+		res, err := index.Search(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Total != test.total {
+			t.Errorf("expected total %d, got %d for test %d", test.total, res.Total, testIndex)
+		}
+		if res.TotalGroups != test.totalGroups {
+			t.Errorf("expected total groups %d, got %d for test %d", test.totalGroups, res.TotalGroups, testIndex)
+		}
+		if len(res.Groups) != len(test.groups) {
+			t.Errorf("expected %d groups, got %d for test %d", len(test.groups), len(res.Groups), testIndex)
+			continue
+		}
+		hitCount := 0
+		for gi, group := range res.Groups {
+			if len(group.Hits) != len(test.groups[gi]) {
+				t.Errorf("expected group %d to have %d hits, got %d for test %d", gi, len(test.groups[gi]), len(group.Hits), testIndex)
+				continue
+			}
+			for hi, hit := range group.Hits {
+				if hit.ID != test.groups[gi][hi] {
+					t.Errorf("expected group %d hit %d to be %s, got %s for test %d", gi, hi, test.groups[gi][hi], hit.ID, testIndex)
+				}
+				if res.Hits[hitCount] != hit {
+					t.Errorf("expected hits to follow group order for test %d", testIndex)
+				}
+				hitCount++
+			}
+		}
+		if len(res.Hits) != hitCount {
+			t.Errorf("expected %d hits, got %d for test %d", hitCount, len(res.Hits), testIndex)
+		}
+	}
+}
+
+func TestRegexpQueryCaseInsensitive(t *testing.T) {
+	keywordMapping := NewTextFieldMapping()
+	keywordMapping.Analyzer = "keyword"
+	mapping := NewIndexMapping()
+	mapping.DefaultMapping.AddFieldMappingsAt("name", keywordMapping)
+
+	index, err := New("", mapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	names := map[string]string{
+		"a": "Marty",
+		"b": "marty",
+		"c": "MARTY",
+		"d": "steve",
+	}
+	for id, name := range names {
+		err = index.Index(id, map[string]interface{}{"name": name})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		regexp          string
+		caseInsensitive bool
+		ids             []string
+	}{
+		{regexp: "mar.*", ids: []string{"b"}},
+		{regexp: "Mar.*", ids: []string{"a"}},
+		{regexp: "mar.*", caseInsensitive: true, ids: []string{"a", "b", "c"}},
+		{regexp: "marty", ids: []string{"b"}},
+		{regexp: "marty", caseInsensitive: true, ids: []string{"a", "b", "c"}},
+		{regexp: "ar", caseInsensitive: true, ids: []string{}},
+		{regexp: "S.*E", caseInsensitive: true, ids: []string{"d"}},
+	}
+
+	for _, test := range tests {
+		query := NewRegexpQuery(test.regexp).SetField("name").(*regexpQuery).SetCaseInsensitive(test.caseInsensitive)
+		res, err := index.Search(NewSearchRequest(query))
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual := make(map[string]bool, len(res.Hits))
+		for _, hit := range res.Hits {
+			actual[hit.ID] = true
+		}
+		if len(actual) != len(test.ids) {
+			t.Errorf("expected %v for %s case insensitive %t, got %v", test.ids, test.regexp, test.caseInsensitive, actual)
+			continue
+		}
+		for _, id := range test.ids {
+			if !actual[id] {
+				t.Errorf("expected %v for %s case insensitive %t, got %v", test.ids, test.regexp, test.caseInsensitive, actual)
+				break
+			}
+		}
+	}
+}
+
+func TestGeoBoundingPolygonQuery(t *testing.T) {
+	index, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	locations := map[string][2]float64{
+		"inside":       {5, 5},
+		"edge":         {5, 0},
+		"vertex":       {10, 10},
+		"notch":        {7, 5},
+		"outside":      {15, 5},
+		"fiji":         {179, -17},
+		"fiji-west":    {-179, -17},
+		"fiji-outside": {170, -17},
+	}
+	for id, lonLat := range locations {
+		err = index.Index(id, map[string]interface{}{
+			"location": map[string]interface{}{
+				"lon": lonLat[0],
+				"lat": lonLat[1],
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = index.Index("nolocation", map[string]interface{}{"name": "marty"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		points []geo.Point
+		ids    []string
+	}{
+		// concave polygon with a notch cut out of its east side
+		{
+			points: []geo.Point{
+				{Lon: 0, Lat: 0}, {Lon: 10, Lat: 0}, {Lon: 10, Lat: 3}, {Lon: 6, Lat: 3},
+				{Lon: 6, Lat: 7}, {Lon: 10, Lat: 7}, {Lon: 10, Lat: 10}, {Lon: 0, Lat: 10},
+			},
+			ids: []string{"inside", "edge", "vertex"},
+		},
+		// closed polygon across the antimeridian
+		{
+			points: []geo.Point{
+				{Lon: 175, Lat: -15}, {Lon: -175, Lat: -15}, {Lon: -175, Lat: -20},
+				{Lon: 175, Lat: -20}, {Lon: 175, Lat: -15},
+			},
+			ids: []string{"fiji", "fiji-west"},
+		},
+	}
+
+	for testIndex, test := range tests {
+		query := NewGeoBoundingPolygonQuery(test.points).SetField("location")
+		res, err := index.Search(NewSearchRequest(query))
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual := make(map[string]bool, len(res.Hits))
+		for _, hit := range res.Hits {
+			actual[hit.ID] = true
+		}
+		if len(actual) != len(test.ids) || res.Total != uint64(len(test.ids)) {
+			t.Errorf("expected %v for test %d, got %v", test.ids, testIndex, actual)
+			continue
+		}
+		for _, id := range test.ids {
+			if !actual[id] {
+				t.Errorf("expected %v for test %d, got %v", test.ids, testIndex, actual)
+				break
+			}
+		}
+	}
+}
+
+func TestSearchIDsOnly(t *testing.T) {
+	index, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	for _, id := range []string{"a", "b", "c"} {
+		err = index.Index(id, map[string]interface{}{"name": "marty", "desc": "beer " + id})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	req := NewSearchRequest(NewMatchQuery("marty").SetField("name"))
+	req.Fields = []string{"*"}
+	req.Highlight = NewHighlight()
+	res, err := index.Search(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Hits) != 3 {
+		t.Fatalf("expected 3 hits, got %d", len(res.Hits))
+	}
+	for _, hit := range res.Hits {
+		if len(hit.Fields) == 0 {
+			t.Errorf("expected fields for %s without ids only", hit.ID)
+		}
+	}
+
+	req.IDsOnly = true
+	res, err = index.Search(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Hits) != 3 {
+		t.Fatalf("expected 3 hits, got %d", len(res.Hits))
+	}
+	for _, hit := range res.Hits {
+		if hit.ID == "" || hit.Score <= 0 {
+			t.Errorf("expected id and score, got %#v", hit)
+		}
+		if len(hit.Fields) != 0 {
+			t.Errorf("expected no fields for %s, got %v", hit.ID, hit.Fields)
+		}
+		if len(hit.Fragments) != 0 {
+			t.Errorf("expected no fragments for %s, got %v", hit.ID, hit.Fragments)
+		}
+		if len(hit.Locations) != 0 {
+			t.Errorf("expected no locations for %s, got %v", hit.ID, hit.Locations)
+		}
+	}
+}
+
+func benchmarkSearchFields(b *testing.B, idsOnly bool) {
+	index, err := New("", NewIndexMapping())
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer index.Close()
+
+	batch := NewBatch()
+	for i := 0; i < 1000; i++ {
+		batch.Index(strconv.Itoa(i), map[string]interface{}{
+			"name":   "marty",
+			"desc":   "a description of document " + strconv.Itoa(i),
+			"rating": float64(i % 10),
+		})
+	}
+	err = index.Batch(batch)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	req := NewSearchRequestOptions(NewMatchQuery("marty").SetField("name"), 1000, 0, false)
+	req.Fields = []string{"*"}
+	req.IDsOnly = idsOnly
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := index.Search(req)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSearchIDsOnly(b *testing.B) {
+	benchmarkSearchFields(b, true)
+}
+
+func BenchmarkSearchAllFields(b *testing.B) {
+	benchmarkSearchFields(b, false)
+}
+
+func TestFuzzyQueryPrefixAndTranspositions(t *testing.T) {
+	index, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	names := map[string]string{
+		"a": "marty",
+		"b": "amrty",
+		"c": "marti",
+		"d": "bmarty",
+	}
+	for id, name := range names {
+		err = index.Index(id, map[string]interface{}{"name": name})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		prefix         int
+		transpositions bool
+		ids            []string
+	}{
+		{prefix: 0, ids: []string{"a", "c", "d"}},
+		{prefix: 0, transpositions: true, ids: []string{"a", "b", "c", "d"}},
+		// amrty and bmarty are within distance but lack the prefix
+		{prefix: 1, transpositions: true, ids: []string{"a", "c"}},
+		{prefix: 5, transpositions: true, ids: []string{"a"}},
+	}
+
+	for _, test := range tests {
+		query := NewFuzzyQuery("marty").
+			SetPrefix(test.prefix).(*fuzzyQuery).
+			SetTranspositions(test.transpositions).
+			SetField("name")
+		res, err := index.Search(NewSearchRequest(query))
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual := make(map[string]bool, len(res.Hits))
+		for _, hit := range res.Hits {
+			actual[hit.ID] = true
+		}
+		if len(actual) != len(test.ids) {
+			t.Errorf("expected %v for prefix %d transpositions %t, got %v", test.ids, test.prefix, test.transpositions, actual)
+			continue
+		}
+		for _, id := range test.ids {
+			if !actual[id] {
+				t.Errorf("expected %v for prefix %d transpositions %t, got %v", test.ids, test.prefix, test.transpositions, actual)
+				break
+			}
+		}
+	}
+}
+
+func TestSynonymQueryAnalyzer(t *testing.T) {
+	mapping := NewIndexMapping()
+	err := mapping.AddCustomTokenFilter("tv_synonyms", map[string]interface{}{
+		"type":     "synonym",
+		"synonyms": []interface{}{"tv, television"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = mapping.AddCustomAnalyzer("with_synonyms", map[string]interface{}{
+		"type":          "custom",
+		"tokenizer":     "unicode",
+		"token_filters": []interface{}{"to_lower", "tv_synonyms"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	index, err := New("", mapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	err = index.Index("a", map[string]interface{}{"desc": "a new television set"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = index.Index("b", map[string]interface{}{"desc": "a new radio set"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// without synonyms tv matches nothing
+	res, err := index.Search(NewSearchRequest(NewMatchQuery("tv").SetField("desc")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 0 {
+		t.Errorf("expected no hits without synonyms, got %d", res.Total)
+	}
+
+	query := NewMatchQuery("TV").SetField("desc")
+	query.(*matchQuery).Analyzer = "with_synonyms"
+	res, err = index.Search(NewSearchRequest(query))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 1 || res.Hits[0].ID != "a" {
+		t.Errorf("expected tv to match 'a', got %v", res.Hits)
+	}
+
+	// synonyms share the position, so phrases still match
+	phrase := NewMatchPhraseQuery("new tv set").SetField("desc")
+	phrase.(*matchPhraseQuery).Analyzer = "with_synonyms"
+	res, err = index.Search(NewSearchRequest(phrase))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 1 || res.Hits[0].ID != "a" {
+		t.Errorf("expected phrase to match 'a', got %v", res.Hits)
+	}
+}
+
+func TestEdgeNgramField(t *testing.T) {
+	mapping := NewIndexMapping()
+	err := mapping.AddCustomTokenFilter("prefix_grams", map[string]interface{}{
+		"type": "edge_ngram",
+		"min":  2.0,
+		"max":  4.0,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = mapping.AddCustomAnalyzer("autocomplete", map[string]interface{}{
+		"type":          "custom",
+		"tokenizer":     "unicode",
+		"token_filters": []interface{}{"to_lower", "prefix_grams"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	nameMapping := NewTextFieldMapping()
+	nameMapping.Analyzer = "autocomplete"
+	mapping.DefaultMapping.AddFieldMappingsAt("name", nameMapping)
+
+	index, err := New("", mapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	err = index.Index("a", map[string]interface{}{"name": "Search"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = index.Index("b", map[string]interface{}{"name": "seal"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		prefix string
+		ids    []string
+	}{
+		{prefix: "s", ids: []string{}},
+		{prefix: "se", ids: []string{"a", "b"}},
+		{prefix: "sea", ids: []string{"a", "b"}},
+		{prefix: "sear", ids: []string{"a"}},
+		{prefix: "searc", ids: []string{}},
+	}
+
+	for _, test := range tests {
+		req := NewSearchRequest(NewTermQuery(test.prefix).SetField("name"))
+		res, err := index.Search(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual := make(map[string]bool, len(res.Hits))
+		for _, hit := range res.Hits {
+			actual[hit.ID] = true
+		}
+		if len(actual) != len(test.ids) {
+			t.Errorf("expected %v for %s, got %v", test.ids, test.prefix, actual)
+			continue
+		}
+		for _, id := range test.ids {
+			if !actual[id] {
+				t.Errorf("expected %v for %s, got %v", test.ids, test.prefix, actual)
+				break
+			}
+		}
+	}
+}
+
+// fixedHighlighter highlights every field with the same
+// fragment, and cannot be resized
+type fixedHighlighter struct {
+	highlight.Highlighter
+}
+
+func (h *fixedHighlighter) BestFragmentsInField(dm *search.DocumentMatch, doc *document.Document, field string, num int) []string {
+	if dm.Fragments == nil {
+		dm.Fragments = make(search.FieldFragmentMap)
+	}
+	dm.Fragments[field] = []string{"fixed"}
+	return dm.Fragments[field]
+}
+
+func TestHighlightFragmentSizeKeepsStyle(t *testing.T) {
+	Config.Cache.Highlighters["test_fixed"] = &fixedHighlighter{}
+	defer delete(Config.Cache.Highlighters, "test_fixed")
+
+	index, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	err = index.Index("a", map[string]interface{}{"desc": "cold beer"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := NewSearchRequest(NewTermQuery("beer").SetField("desc"))
+	req.Highlight = NewHighlightWithStyle("test_fixed")
+	req.Highlight.FragmentSize = 4
+	res, err := index.Search(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Hits) != 1 || !reflect.DeepEqual(res.Hits[0].Fragments["desc"], []string{"fixed"}) {
+		t.Errorf("expected the style highlighter to be used, got %v", res.Hits)
+	}
+}
+
+func TestHighlightFragmentSizeAndCount(t *testing.T) {
+	index, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	filler := strings.Repeat("lorem ipsum ", 10)
+	paragraph := "beer " + filler + "more beer and wine " + filler + "last beer"
+	err = index.Index("long", map[string]interface{}{"desc": paragraph})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = index.Index("short", map[string]interface{}{"desc": "cold beer"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	search := func(size, count int) map[string][]string {
+		req := NewSearchRequest(NewDisjunctionQuery([]Query{
+			NewTermQuery("beer").SetField("desc"),
+			NewTermQuery("wine").SetField("desc"),
+		}))
+		req.Highlight = NewHighlight()
+		req.Highlight.FragmentSize = size
+		req.Highlight.NumberOfFragments = count
+		res, err := index.Search(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rv := make(map[string][]string, len(res.Hits))
+		for _, hit := range res.Hits {
+			rv[hit.ID] = hit.Fragments["desc"]
+		}
+		return rv
+	}
+
+	beer := `<span class="highlight">beer</span>`
+	wine := `<span class="highlight">wine</span>`
+
+	// fields shorter than the fragment size are returned whole
+	fragments := search(40, 3)
+	if !reflect.DeepEqual(fragments["short"], []string{"cold " + beer}) {
+		t.Errorf("expected whole short field, got %q", fragments["short"])
+	}
+
+	// all non-overlapping fragments up to the requested count
+	if len(fragments["long"]) != 3 {
+		t.Fatalf("expected 3 fragments, got %q", fragments["long"])
+	}
+	for _, fragment := range fragments["long"] {
+		text := strings.Replace(fragment, `<span class="highlight">`, "", -1)
+		text = strings.Replace(text, "</span>", "", -1)
+		text = strings.Trim(text, "…")
+		if len(text) > 40 {
+			t.Errorf("expected fragment of at most 40 bytes, got %d: %q", len(text), fragment)
+		}
+		if !strings.Contains(fragment, beer) {
+			t.Errorf("expected fragment to contain a match, got %q", fragment)
+		}
+	}
+	// the fragment matching both terms scores best
+	if !strings.Contains(fragments["long"][0], beer) || !strings.Contains(fragments["long"][0], wine) {
+		t.Errorf("expected best fragment to match both terms, got %q", fragments["long"][0])
+	}
+	// matches at the start and end of the field produce
+	// fragments without a separator on that side
+	var atStart, atEnd bool
+	for _, fragment := range fragments["long"] {
+		if strings.HasPrefix(fragment, beer) {
+			atStart = true
+		}
+		if strings.HasSuffix(fragment, "last "+beer) {
+			atEnd = true
+		}
+	}
+	if !atStart || !atEnd {
+		t.Errorf("expected fragments at the start and end of the field, got %q", fragments["long"])
+	}
+
+	// count limit
+	fragments = search(40, 1)
+	if len(fragments["long"]) != 1 {
+		t.Errorf("expected 1 fragment, got %q", fragments["long"])
+	}
+
+	// defaults to a single fragment of the default size
+	fragments = search(0, 0)
+	if len(fragments["long"]) != 1 {
+		t.Errorf("expected 1 fragment, got %q", fragments["long"])
+	}
+}
+
+func TestPlainHighlightStyle(t *testing.T) {
+	index, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	err = index.Index("a", map[string]interface{}{"desc": "cold beer and warm beer"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := NewSearchRequest(NewTermQuery("beer").SetField("desc"))
+	req.Highlight = NewHighlightWithStyle("plain")
+	res, err := index.Search(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Hits) != 1 {
+		t.Fatalf("expected 1 hit, got %d", len(res.Hits))
+	}
+	expected := []string{"cold *beer* and warm *beer*"}
+	if !reflect.DeepEqual(res.Hits[0].Fragments["desc"], expected) {
+		t.Errorf("expected %q, got %q", expected, res.Hits[0].Fragments["desc"])
+	}
+}
+
+func TestSortGeoDistance(t *testing.T) {
+	index, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	locations := map[string][2]float64{
+		"near":    {0.5, 0},
+		"middle":  {-1, 0},
+		"far":     {0, 2},
+		"farther": {3, 0},
+	}
+	for id, lonLat := range locations {
+		err = index.Index(id, map[string]interface{}{
+			"type": "place",
+			"location": map[string]interface{}{
+				"lon": lonLat[0],
+				"lat": lonLat[1],
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = index.Index("nolocation", map[string]interface{}{"type": "place"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	byDistance := func(unit string, desc bool) *search.SortGeoDistance {
+		rv, err := search.NewSortGeoDistance("location", 0, 0, unit, desc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rv
+	}
+	hitIDs := func(req *SearchRequest) ([]string, search.DocumentMatchCollection) {
+		res, err := index.Search(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var rv []string
+		for _, hit := range res.Hits {
+			rv = append(rv, hit.ID)
+		}
+		return rv, res.Hits
+	}
+
+	req := NewSearchRequest(NewTermQuery("place"))
+	req.SortByCustom(search.SortOrder{byDistance("km", false)})
+	ids, hits := hitIDs(req)
+	expected := []string{"near", "middle", "far", "farther", "nolocation"}
+	if !reflect.DeepEqual(ids, expected) {
+		t.Errorf("expected %v, got %v", expected, ids)
+	}
+	if len(hits) == len(expected) {
+		km := hits[1].Sort[0].(float64)
+		if km < 111.1 || km > 111.3 {
+			t.Errorf("expected about 111.2km for a degree of longitude, got %f", km)
+		}
+		if hits[4].Sort[0] != nil {
+			t.Errorf("expected no sort value for missing location, got %v", hits[4].Sort[0])
+		}
+	}
+
+	req.SortByCustom(search.SortOrder{byDistance("mi", true)})
+	ids, hits = hitIDs(req)
+	expected = []string{"farther", "far", "middle", "near", "nolocation"}
+	if !reflect.DeepEqual(ids, expected) {
+		t.Errorf("expected %v, got %v", expected, ids)
+	}
+	if len(hits) == len(expected) {
+		mi := hits[2].Sort[0].(float64)
+		if mi < 69.0 || mi > 69.2 {
+			t.Errorf("expected about 69.1mi for a degree of longitude, got %f", mi)
+		}
+	}
+
+	// paging applies to the sorted results
+	req.SortByCustom(search.SortOrder{byDistance("", false)})
+	req.Size = 2
+	req.From = 1
+	ids, _ = hitIDs(req)
+	expected = []string{"middle", "far"}
+	if !reflect.DeepEqual(ids, expected) {
+		t.Errorf("expected %v, got %v", expected, ids)
+	}
+
+	var jsonReq SearchRequest
+	err = json.Unmarshal([]byte(`{
+		"query": {"term": "place"},
+		"size": 10,
+		"sort": [{"by": "geo_distance", "field": "location", "location": {"lon": 0, "lat": 0}, "unit": "km", "desc": true}, "_id"]
+	}`), &jsonReq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids, _ = hitIDs(&jsonReq)
+	expected = []string{"farther", "far", "middle", "near", "nolocation"}
+	if !reflect.DeepEqual(ids, expected) {
+		t.Errorf("expected %v, got %v", expected, ids)
+	}
+}
+
+func TestSortFieldMissing(t *testing.T) {
+	index, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	docs := map[string]map[string]interface{}{
+		"a": {"type": "beer", "style": "ale", "abv": 5.0},
+		"b": {"type": "beer", "style": "ale"},
+		"c": {"type": "beer", "style": "ale", "abv": 7.0},
+		"d": {"type": "beer", "style": "lager", "abv": 4.0},
+		"e": {"type": "beer", "style": "lager"},
+		"f": {"type": "beer"},
+	}
+	for id, doc := range docs {
+		err = index.Index(id, doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		missing  interface{}
+		desc     bool
+		expected []string
+	}{
+		{nil, false, []string{"a", "c", "b", "d", "e", "f"}},
+		{search.SortFieldMissingLast, true, []string{"c", "a", "b", "d", "e", "f"}},
+		{search.SortFieldMissingFirst, false, []string{"b", "a", "c", "e", "d", "f"}},
+		{search.SortFieldMissingFirst, true, []string{"b", "c", "a", "e", "d", "f"}},
+		{6.0, false, []string{"a", "b", "c", "d", "e", "f"}},
+		{6.0, true, []string{"c", "b", "a", "e", "d", "f"}},
+	}
+
+	for _, test := range tests {
+		req := NewSearchRequest(NewTermQuery("beer"))
+		// ties on both fields fall back to the id, so the order is stable
+		req.SortByCustom(search.SortOrder{
+			&search.SortField{Field: "style"},
+			&search.SortField{Field: "abv", Desc: test.desc, Missing: test.missing},
+			&search.SortDocID{},
+		})
+		res, err := index.Search(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []string
+		for _, hit := range res.Hits {
+			ids = append(ids, hit.ID)
+		}
+		if !reflect.DeepEqual(ids, test.expected) {
+			t.Errorf("expected %v, got %v for missing %v desc %t", test.expected, ids, test.missing, test.desc)
+		}
+	}
+
+	// the missing mode is parsed from the json sort
+	var req SearchRequest
+	err = json.Unmarshal([]byte(`{
+		"query": {"term": "beer"},
+		"size": 10,
+		"sort": ["style", {"by": "field", "field": "abv", "missing": "first"}]
+	}`), &req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := index.Search(&req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, hit := range res.Hits {
+		ids = append(ids, hit.ID)
+	}
+	expected := []string{"b", "a", "c", "e", "d", "f"}
+	if !reflect.DeepEqual(ids, expected) {
+		t.Errorf("expected %v, got %v", expected, ids)
+	}
+}
+
+func TestSearchAfter(t *testing.T) {
+	index, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	// several docs share a rating, so the id decides their order
+	for i := 0; i < 25; i++ {
+		err = index.Index(fmt.Sprintf("doc%02d", i), map[string]interface{}{
+			"type":   "beer",
+			"rating": float64(i % 4),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var after []interface{}
+	seen := make(map[string]bool)
+	var lastRating float64
+	pages := 0
+	for {
+		req := NewSearchRequest(NewTermQuery("beer"))
+		req.Size = 7
+		req.SortBy([]string{"-rating", "_id"})
+		req.SetSearchAfter(after)
+
+		// send each cursor through json, like a client would
+		reqBytes, err := json.Marshal(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var jsonReq SearchRequest
+		err = json.Unmarshal(reqBytes, &jsonReq)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		res, err := index.Search(&jsonReq)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Total != 25 {
+			t.Errorf("expected total 25, got %d", res.Total)
+		}
+		if len(res.Hits) == 0 {
+			break
+		}
+		pages++
+		for _, hit := range res.Hits {
+			if seen[hit.ID] {
+				t.Errorf("expected %s only once", hit.ID)
+			}
+			seen[hit.ID] = true
+			rating := hit.Sort[0].(float64)
+			if len(seen) > 1 && rating > lastRating {
+				t.Errorf("expected descending ratings, got %f after %f", rating, lastRating)
+			}
+			lastRating = rating
+		}
+		resBytes, err := json.Marshal(res.Hits[len(res.Hits)-1].Sort)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = json.Unmarshal(resBytes, &after)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if pages != 4 {
+		t.Errorf("expected 4 pages, got %d", pages)
+	}
+	if len(seen) != 25 {
+		t.Errorf("expected all 25 docs, got %d", len(seen))
+	}
+
+	invalid := []struct {
+		req *SearchRequest
+		err error
+	}{
+		{
+			req: &SearchRequest{Query: NewTermQuery("beer"), Size: 10, SearchAfter: []interface{}{"doc01"}},
+			err: ErrorSearchAfterNeedsSort,
+		},
+		{
+			req: &SearchRequest{Query: NewTermQuery("beer"), Size: 10, SearchAfter: []interface{}{"doc01"},
+				Sort: search.SortOrder{&search.SortField{Field: "rating"}, &search.SortDocID{}}},
+			err: ErrorSearchAfterSortMismatch,
+		},
+		{
+			req: &SearchRequest{Query: NewTermQuery("beer"), Size: 10, SearchAfter: []interface{}{1.0},
+				Sort: search.SortOrder{&search.SortField{Field: "rating"}}},
+			err: ErrorSearchAfterNoTiebreaker,
+		},
+	}
+	for _, test := range invalid {
+		_, err := index.Search(test.req)
+		if err != test.err {
+			t.Errorf("expected %v, got %v", test.err, err)
+		}
+	}
+}
+
+func TestBM25ScoringModel(t *testing.T) {
+	docs := map[string]string{
+		"short":  "beer",
+		"repeat": "beer beer beer wine",
+		"other":  "a long description of cheese which does not mention the drink at all and goes on for twenty words",
+	}
+
+	ranking := func(model string) []string {
+		mapping := NewIndexMapping()
+		mapping.ScoringModel = model
+		index, err := New("", mapping)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer index.Close()
+		for id, desc := range docs {
+			err = index.Index(id, map[string]interface{}{"desc": desc})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		res, err := index.Search(NewSearchRequest(NewTermQuery("beer").SetField("desc")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []string
+		for _, hit := range res.Hits {
+			ids = append(ids, hit.ID)
+		}
+		return ids
+	}
+
+	// tf-idf favors the field made of only the term, while bm25
+	// favors repeated occurrences in a field of below average length
+	expected := []string{"short", "repeat"}
+	actual := ranking(ScoringModelTFIDF)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v with tf-idf, got %v", expected, actual)
+	}
+	if actual := ranking(""); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v by default, got %v", expected, actual)
+	}
+	expected = []string{"repeat", "short"}
+	actual = ranking(ScoringModelBM25)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v with bm25, got %v", expected, actual)
+	}
+
+	mapping := NewIndexMapping()
+	mapping.ScoringModel = "bogus"
+	_, err := New("", mapping)
+	if err != ErrorUnknownScoringModel {
+		t.Errorf("expected unknown scoring model error, got %v", err)
+	}
+}
+
+func TestQueryAndFieldBoosts(t *testing.T) {
+	nameMapping := NewTextFieldMapping()
+	nameMapping.Boost = 3.0
+	mapping := NewIndexMapping()
+	mapping.DefaultMapping.AddFieldMappingsAt("name", nameMapping)
+	index, err := New("", mapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	err = index.Index("a", map[string]interface{}{"name": "stout", "desc": "stout"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = index.Index("b", map[string]interface{}{"name": "lager", "desc": "pale"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the clause scores relative to each other are unaffected by the query norm
+	clauses := func(nameBoost float64) (float64, float64, *search.Explanation) {
+		query := NewDisjunctionQuery([]Query{
+			NewTermQuery("stout").SetField("name").SetBoost(nameBoost),
+			NewTermQuery("stout").SetField("desc"),
+		})
+		req := NewSearchRequest(query)
+		req.Explain = true
+		res, err := index.Search(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res.Hits) != 1 {
+			t.Fatalf("expected 1 hit, got %d", len(res.Hits))
+		}
+		expl := res.Hits[0].Expl
+		// coord(2/2) * sum of the two clauses
+		sum := expl.Children[0]
+		return sum.Children[0].Value, sum.Children[1].Value, sum.Children[0]
+	}
+
+	name, desc, nameExpl := clauses(1.0)
+	// the field boost applies to name alone
+	if math.Abs(name/desc-3.0) > 1e-9 {
+		t.Errorf("expected name to weigh 3 times desc, got %f", name/desc)
+	}
+	name, desc, _ = clauses(2.0)
+	if math.Abs(name/desc-6.0) > 1e-9 {
+		t.Errorf("expected name to weigh 6 times desc with query boost 2, got %f", name/desc)
+	}
+
+	var boosts []string
+	for _, child := range nameExpl.Children[0].Children {
+		boosts = append(boosts, child.Message)
+	}
+	expected := []string{"boost", "fieldBoost(field=name)", "idf(docFreq=1, maxDocs=2)", "queryNorm"}
+	if !reflect.DeepEqual(boosts, expected) {
+		t.Errorf("expected query weight explained by %v, got %v", expected, boosts)
+	}
+}
+
+func TestDeleteByQuery(t *testing.T) {
+	defer func(size int) {
+		byQueryBatchSize = size
+	}(byQueryBatchSize)
+	// force several batches
+	byQueryBatchSize = 2
+
+	index, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	for i := 0; i < 5; i++ {
+		err = index.Index(fmt.Sprintf("old%d", i), map[string]interface{}{"status": "old"})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		err = index.Index(fmt.Sprintf("new%d", i), map[string]interface{}{"status": "new"})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deleted, err := index.DeleteByQuery(NewTermQuery("old").SetField("status"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 5 {
+		t.Errorf("expected 5 documents deleted, got %d", deleted)
+	}
+	docCount, err := index.DocCount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if docCount != 3 {
+		t.Errorf("expected doc count 3, got %d", docCount)
+	}
+	hits := searchHitIDs(t, index, "new")
+	if len(hits) != 3 {
+		t.Errorf("expected the new documents to remain, got %v", hits)
+	}
+
+	deleted, err = index.DeleteByQuery(NewTermQuery("old").SetField("status"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 0 {
+		t.Errorf("expected nothing deleted, got %d", deleted)
+	}
+
+	err = index.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = index.DeleteByQuery(NewMatchAllQuery())
+	if err != ErrorIndexClosed {
+		t.Errorf("expected %v, got %v", ErrorIndexClosed, err)
+	}
+}
+
+func TestDocuments(t *testing.T) {
+	index, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	for _, id := range []string{"a", "b", "c"} {
+		err = index.Index(id, map[string]interface{}{"name": "doc " + id})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	docs, err := index.Documents([]string{"c", "missing", "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 2 {
+		t.Fatalf("expected 2 documents, got %d", len(docs))
+	}
+	if _, ok := docs["missing"]; ok {
+		t.Errorf("expected missing document to be absent")
+	}
+	for _, id := range []string{"a", "c"} {
+		doc := docs[id]
+		if doc == nil {
+			t.Errorf("expected document '%s'", id)
+			continue
+		}
+		if doc.ID != id {
+			t.Errorf("expected id '%s', got '%s'", id, doc.ID)
+		}
+		if len(doc.Fields) != 1 || string(doc.Fields[0].Value()) != "doc "+id {
+			t.Errorf("expected stored name 'doc %s', got %v", id, doc.Fields)
+		}
+	}
+
+	docs, err = index.Documents(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 0 {
+		t.Errorf("expected no documents, got %v", docs)
+	}
+}
+
+func benchmarkDocumentsIndex(b *testing.B) (Index, []string) {
+	index, err := New("", NewIndexMapping())
+	if err != nil {
+		b.Fatal(err)
+	}
+	batch := NewBatch()
+	ids := make([]string, 100)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("doc%d", i)
+		batch.Index(ids[i], map[string]interface{}{"name": ids[i], "desc": "an example document"})
+	}
+	err = index.Batch(batch)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return index, ids
+}
+
+func BenchmarkDocumentLoop(b *testing.B) {
+	index, ids := benchmarkDocumentsIndex(b)
+	defer index.Close()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, id := range ids {
+			_, err := index.Document(id)
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkDocuments(b *testing.B) {
+	index, ids := benchmarkDocumentsIndex(b)
+	defer index.Close()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := index.Documents(ids)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestIndexDocumentField(t *testing.T) {
+	index, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	err = index.Index("a", map[string]interface{}{
+		"name": "marty",
+		"age":  19.0,
+		"tags": []interface{}{"red", "blue"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		id       string
+		field    string
+		expected interface{}
+	}{
+		{id: "a", field: "name", expected: "marty"},
+		{id: "a", field: "age", expected: 19.0},
+		{id: "a", field: "tags", expected: []interface{}{"red", "blue"}},
+		{id: "a", field: "unknown", expected: nil},
+		{id: "b", field: "name", expected: nil},
+	}
+	for _, test := range tests {
+		value, err := index.DocumentField(test.id, test.field)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(value, test.expected) {
+			t.Errorf("expected %s of %s to be %#v, got %#v", test.field, test.id, test.expected, value)
+		}
+	}
+}
+
+func benchmarkWideDocumentIndex(b *testing.B) Index {
+	index, err := New("", NewIndexMapping())
+	if err != nil {
+		b.Fatal(err)
+	}
+	doc := map[string]interface{}{}
+	for i := 0; i < 100; i++ {
+		doc[fmt.Sprintf("field%d", i)] = fmt.Sprintf("value of field %d", i)
+	}
+	err = index.Index("wide", doc)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return index
+}
+
+func BenchmarkDocumentFieldWide(b *testing.B) {
+	index := benchmarkWideDocumentIndex(b)
+	defer index.Close()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := index.DocumentField("wide", "field50")
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDocumentWide(b *testing.B) {
+	index := benchmarkWideDocumentIndex(b)
+	defer index.Close()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		doc, err := index.Document("wide")
+		if err != nil {
+			b.Fatal(err)
+		}
+		for _, f := range doc.Fields {
+			if f.Name() == "field50" {
+				break
+			}
+		}
+	}
+}
+
+func TestFieldExistsQuery(t *testing.T) {
+	index, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	docs := map[string]interface{}{
+		"a": map[string]interface{}{"name": "marty", "nickname": "doc"},
+		"b": map[string]interface{}{"name": "emmett"},
+		"c": map[string]interface{}{"name": "biff", "nickname": ""},
+		"d": map[string]interface{}{"name": "lorraine", "nickname": []interface{}{"lori", "baines"}},
+		"e": map[string]interface{}{"name": "george", "address": map[string]interface{}{"nickname": "hill"}},
+	}
+	for id, doc := range docs {
+		err = index.Index(id, doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	hitIDs := func(q Query) []string {
+		res, err := index.Search(NewSearchRequest(q))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rv := make([]string, 0, len(res.Hits))
+		for _, hit := range res.Hits {
+			if hit.Score != 1 {
+				t.Errorf("expected constant score, got %f for %s", hit.Score, hit.ID)
+			}
+			rv = append(rv, hit.ID)
+		}
+		sort.Strings(rv)
+		return rv
+	}
+
+	// an empty string produces no terms
+	exists := hitIDs(NewFieldExistsQuery("nickname"))
+	if !reflect.DeepEqual(exists, []string{"a", "d"}) {
+		t.Errorf("expected a and d to have a nickname, got %v", exists)
+	}
+	missing := hitIDs(NewFieldMissingQuery("nickname"))
+	if !reflect.DeepEqual(missing, []string{"b", "c", "e"}) {
+		t.Errorf("expected b, c and e to miss a nickname, got %v", missing)
+	}
+	nested := hitIDs(NewFieldExistsQuery("address.nickname"))
+	if !reflect.DeepEqual(nested, []string{"e"}) {
+		t.Errorf("expected e to have an address nickname, got %v", nested)
+	}
+
+	// removing the field moves the document over
+	err = index.Index("a", map[string]interface{}{"name": "marty"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exists = hitIDs(NewFieldExistsQuery("nickname"))
+	if !reflect.DeepEqual(exists, []string{"d"}) {
+		t.Errorf("expected only d to have a nickname, got %v", exists)
+	}
+	missing = hitIDs(NewFieldMissingQuery("nickname"))
+	if !reflect.DeepEqual(missing, []string{"a", "b", "c", "e"}) {
+		t.Errorf("expected a, b, c and e to miss a nickname, got %v", missing)
+	}
+
+	// the field names are not reported as a field
+	fields, err := index.Fields()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range fields {
+		if field == "_field_names" {
+			t.Errorf("expected field names to be hidden, got %v", fields)
+		}
+	}
+}
+
+func TestWildcardQuery(t *testing.T) {
+	keywordMapping := NewTextFieldMapping()
+	keywordMapping.Analyzer = "keyword"
+	mapping := NewIndexMapping()
+	mapping.DefaultMapping.AddFieldMappingsAt("code", keywordMapping)
+
+	index, err := New("", mapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	codes := map[string]string{
+		"a": "a.b",
+		"b": "axb",
+		"c": "c++",
+		"d": "cc",
+		"e": "what?",
+		"f": "whats",
+		"g": "2*3",
+		"h": `c:\dir`,
+	}
+	for id, code := range codes {
+		err = index.Index(id, map[string]interface{}{"code": code})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	search := func(q Query) []string {
+		res, err := index.Search(NewSearchRequest(q))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rv := make([]string, 0, len(res.Hits))
+		for _, hit := range res.Hits {
+			rv = append(rv, hit.ID)
+		}
+		sort.Strings(rv)
+		return rv
+	}
+
+	tests := []struct {
+		wildcard string
+		ids      []string
+	}{
+		// regexp metacharacters match literally
+		{wildcard: "a.b", ids: []string{"a"}},
+		{wildcard: "c++", ids: []string{"c"}},
+		{wildcard: "c+*", ids: []string{"c"}},
+		{wildcard: "a?b", ids: []string{"a", "b"}},
+		{wildcard: "c*", ids: []string{"c", "d", "h"}},
+		{wildcard: "c?", ids: []string{"d"}},
+		{wildcard: "*b", ids: []string{"a", "b"}},
+		{wildcard: "what?", ids: []string{"e", "f"}},
+		// escaped wildcards match literally
+		{wildcard: `what\?`, ids: []string{"e"}},
+		{wildcard: `2\*3`, ids: []string{"g"}},
+		{wildcard: `c:\\*`, ids: []string{"h"}},
+		{wildcard: "x*", ids: []string{}},
+	}
+	for _, test := range tests {
+		actual := search(NewWildcardQuery(test.wildcard).SetField("code"))
+		if !reflect.DeepEqual(actual, test.ids) {
+			t.Errorf("expected %v for wildcard '%s', got %v", test.ids, test.wildcard, actual)
+		}
+	}
+
+	// the same patterns as regular expressions
+	actual := search(NewRegexpQuery("a.b").SetField("code"))
+	if !reflect.DeepEqual(actual, []string{"a", "b"}) {
+		t.Errorf("expected regexp '.' to match any character, got %v", actual)
+	}
+	actual = search(NewRegexpQuery("c+").SetField("code"))
+	if !reflect.DeepEqual(actual, []string{"d"}) {
+		t.Errorf("expected regexp '+' to repeat, got %v", actual)
+	}
+	_, err = index.Search(NewSearchRequest(NewRegexpQuery("c++").SetField("code")))
+	if err == nil {
+		t.Errorf("expected regexp 'c++' to be invalid")
+	}
+}
+
+func TestNestedQuery(t *testing.T) {
+	var mapping IndexMapping
+	err := json.Unmarshal([]byte(`{
+		"default_mapping": {
+			"properties": {
+				"authors": {"nested": true}
+			}
+		}
+	}`), &mapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	index, err := New("", &mapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	docs := map[string][]interface{}{
+		"a": {
+			map[string]interface{}{"name": "marty mcfly", "age": 17.0},
+			map[string]interface{}{"name": "emmett brown", "age": 65.0},
+		},
+		"b": {
+			map[string]interface{}{"name": "marty mcfly", "age": 65.0},
+			map[string]interface{}{"name": "emmett brown", "age": 17.0},
+		},
+		// the same term in several objects
+		"c": {
+			map[string]interface{}{"name": "marty mcfly", "age": 17.0},
+			map[string]interface{}{"name": "marty junior", "age": 47.0},
+		},
+	}
+	for id, authors := range docs {
+		err = index.Index(id, map[string]interface{}{
+			"title":   "back to the future",
+			"authors": authors,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	search := func(q Query) []string {
+		res, err := index.Search(NewSearchRequest(q))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rv := make([]string, 0, len(res.Hits))
+		for _, hit := range res.Hits {
+			rv = append(rv, hit.ID)
+		}
+		sort.Strings(rv)
+		return rv
+	}
+
+	old := 40.0
+	clauses := []Query{
+		NewMatchQuery("marty").SetField("authors.name"),
+		NewNumericRangeQuery(&old, nil).SetField("authors.age"),
+	}
+	// the flattened fields match across objects
+	actual := search(NewConjunctionQuery(clauses))
+	expected := []string{"a", "b", "c"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+	actual = search(NewNestedQuery("authors", clauses))
+	expected = []string{"b", "c"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+
+	actual = search(NewNestedQuery("authors", []Query{
+		NewMatchPhraseQuery("emmett brown").SetField("authors.name"),
+		NewNumericRangeQuery(nil, &old).SetField("authors.age"),
+	}))
+	expected = []string{"b"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+
+	// clauses only match in the objects
+	actual = search(NewNestedQuery("authors", []Query{
+		NewMatchQuery("marty").SetField("authors.name"),
+		NewMatchQuery("future").SetField("title"),
+	}))
+	if len(actual) != 0 {
+		t.Errorf("expected no hits, got %v", actual)
+	}
+
+	// only the locations in the matching object are kept
+	req := NewSearchRequest(NewNestedQuery("authors", []Query{
+		NewMatchQuery("marty").SetField("authors.name"),
+		NewMatchQuery("junior").SetField("authors.name"),
+	}))
+	req.IncludeLocations = true
+	res, err := index.Search(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Hits) != 1 || res.Hits[0].ID != "c" {
+		t.Fatalf("expected hit c, got %v", res.Hits)
+	}
+	locations := res.Hits[0].Locations["authors.name"]["marty"]
+	if len(locations) != 1 || !reflect.DeepEqual(locations[0].ArrayPositions, []uint64{1}) {
+		t.Errorf("expected one location in object 1, got %v", locations)
+	}
+
+	_, err = index.Search(NewSearchRequest(NewNestedQuery("title", clauses)))
+	if err != ErrorNestedQueryPathNotNested {
+		t.Errorf("expected %v, got %v", ErrorNestedQueryPathNotNested, err)
+	}
+}
+
+func TestNestedQueryInsideArray(t *testing.T) {
+	var mapping IndexMapping
+	err := json.Unmarshal([]byte(`{
+		"default_mapping": {
+			"properties": {
+				"groups": {
+					"properties": {
+						"authors": {"nested": true}
+					}
+				}
+			}
+		}
+	}`), &mapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	index, err := New("", &mapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	err = index.Index("a", map[string]interface{}{
+		"groups": []interface{}{
+			map[string]interface{}{
+				"authors": []interface{}{
+					map[string]interface{}{"name": "marty mcfly", "age": 17.0},
+					map[string]interface{}{"name": "emmett brown", "age": 65.0},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	old := 40.0
+	// the plain groups array must not merge the authors
+	res, err := index.Search(NewSearchRequest(NewNestedQuery("groups.authors", []Query{
+		NewMatchQuery("marty").SetField("groups.authors.name"),
+		NewNumericRangeQuery(&old, nil).SetField("groups.authors.age"),
+	})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 0 {
+		t.Errorf("expected no hits, got %v", res.Hits)
+	}
+
+	res, err = index.Search(NewSearchRequest(NewNestedQuery("groups.authors", []Query{
+		NewMatchQuery("emmett").SetField("groups.authors.name"),
+		NewNumericRangeQuery(&old, nil).SetField("groups.authors.age"),
+	})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 1 {
+		t.Errorf("expected 1 hit, got %v", res.Hits)
+	}
+}
+
+func TestNestedQueryInsideObject(t *testing.T) {
+	var mapping IndexMapping
+	err := json.Unmarshal([]byte(`{
+		"default_mapping": {
+			"properties": {
+				"groups": {
+					"properties": {
+						"authors": {"nested": true}
+					}
+				}
+			}
+		}
+	}`), &mapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	index, err := New("", &mapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	// groups is a single object, it adds no array position
+	err = index.Index("a", map[string]interface{}{
+		"groups": map[string]interface{}{
+			"authors": []interface{}{
+				map[string]interface{}{"name": "marty", "tags": []interface{}{"x", "y"}},
+				map[string]interface{}{"name": "emmett", "tags": []interface{}{"z"}},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := index.Search(NewSearchRequest(NewNestedQuery("groups.authors", []Query{
+		NewMatchQuery("marty").SetField("groups.authors.name"),
+		NewMatchQuery("y").SetField("groups.authors.tags"),
+	})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 1 {
+		t.Errorf("expected 1 hit, got %v", res.Hits)
+	}
+
+	res, err = index.Search(NewSearchRequest(NewNestedQuery("groups.authors", []Query{
+		NewMatchQuery("marty").SetField("groups.authors.name"),
+		NewMatchQuery("z").SetField("groups.authors.tags"),
+	})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 0 {
+		t.Errorf("expected no hits, got %v", res.Hits)
+	}
+}
+
+func TestSearchScoreNormalization(t *testing.T) {
+	index, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	docs := map[string]string{
+		"a": "water",
+		"b": "water water",
+		"c": "water water water and other things",
+		"d": "beer",
+	}
+	for id, desc := range docs {
+		err = index.Index(id, map[string]interface{}{"desc": desc})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	query := NewMatchQuery("water")
+	raw, err := index.Search(NewSearchRequest(query))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(raw.Hits) != 3 {
+		t.Fatalf("expected 3 hits, got %d", len(raw.Hits))
+	}
+	for _, hit := range raw.Hits {
+		if hit.NormalizedScore != 0 {
+			t.Errorf("expected scores not to be normalized by default, got %f", hit.NormalizedScore)
+		}
+	}
+
+	for _, idx := range []Index{index, NewIndexAlias(index)} {
+		req := NewSearchRequest(query)
+		req.ScoreNormalization = ScoreNormalizationMax
+		res, err := idx.Search(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res.Hits) != len(raw.Hits) {
+			t.Fatalf("expected %d hits, got %d", len(raw.Hits), len(res.Hits))
+		}
+		if res.Hits[0].NormalizedScore != 1.0 {
+			t.Errorf("expected top hit to read 1.0, got %f", res.Hits[0].NormalizedScore)
+		}
+		for i, hit := range res.Hits {
+			if hit.ID != raw.Hits[i].ID || hit.Score != raw.Hits[i].Score {
+				t.Errorf("expected hit %d to be %s (%f), got %s (%f)", i, raw.Hits[i].ID, raw.Hits[i].Score, hit.ID, hit.Score)
+			}
+			expected := raw.Hits[i].Score / raw.MaxScore
+			if math.Abs(hit.NormalizedScore-expected) > 1e-9 {
+				t.Errorf("expected hit %s to read %f, got %f", hit.ID, expected, hit.NormalizedScore)
+			}
+			if i > 0 && hit.NormalizedScore >= 1.0 {
+				t.Errorf("expected hit %s to read below 1.0, got %f", hit.ID, hit.NormalizedScore)
+			}
+		}
+	}
+
+	req := NewSearchRequest(query)
+	req.ScoreNormalization = "min"
+	_, err = index.Search(req)
+	if err != ErrorUnknownScoreNormalization {
+		t.Errorf("expected %v, got %v", ErrorUnknownScoreNormalization, err)
+	}
+}
+
+func TestIndexFieldValidator(t *testing.T) {
+	errNegative := fmt.Errorf("must not be negative")
+	rejectNegative := func(field string, value interface{}) error {
+		if f, ok := value.(float64); ok && f < 0 {
+			return errNegative
+		}
+		return nil
+	}
+
+	// a validator on the index mapping checks every field
+	mapping := NewIndexMapping()
+	mapping.Validator = rejectNegative
+
+	// a validator on a field mapping checks only that field
+	ageMapping := NewNumericFieldMapping()
+	ageMapping.Validator = func(field string, value interface{}) error {
+		if value.(float64) > 150 {
+			return fmt.Errorf("too old")
+		}
+		return nil
+	}
+	mapping.DefaultMapping.AddFieldMappingsAt("age", ageMapping)
+
+	index, err := New("", mapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	err = index.Index("a", map[string]interface{}{
+		"name":    "marty",
+		"balance": 12.5,
+		"age":     17.0,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	invalid := map[string]map[string]interface{}{
+		"b": {
+			"name":    "biff",
+			"balance": -3.0,
+		},
+		"c": {
+			"name": "emmett",
+			"age":  -65.0,
+		},
+		"d": {
+			"name": "lorraine",
+			"age":  190.0,
+		},
+		"e": {
+			"name": "george",
+			"accounts": []interface{}{
+				map[string]interface{}{"balance": 7.0},
+				map[string]interface{}{"balance": -7.0},
+			},
+		},
+	}
+	for id, doc := range invalid {
+		err = index.Index(id, doc)
+		if err == nil {
+			t.Errorf("expected indexing %s to fail", id)
+		} else if !strings.HasPrefix(err.Error(), "invalid value for field '") {
+			t.Errorf("expected field error indexing %s, got %v", id, err)
+		}
+		stored, err := index.Document(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stored != nil {
+			t.Errorf("expected %s not to be indexed", id)
+		}
+	}
+
+	err = index.Index("b", invalid["b"])
+	if err == nil || err.Error() != "invalid value for field 'balance': must not be negative" {
+		t.Errorf("expected balance error, got %v", err)
+	}
+
+	// a batch with an invalid document is not executed
+	b := NewBatch()
+	b.Index("f", map[string]interface{}{"name": "jennifer"})
+	b.Index("g", invalid["c"])
+	err = index.Batch(b)
+	if err == nil {
+		t.Errorf("expected batch to fail")
+	}
+
+	count, err := index.DocCount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("expected doc count 1, got %d", count)
+	}
+}
+
+func TestPhraseQuerySlop(t *testing.T) {
+	index, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	docs := map[string]string{
+		"exact":    "the quick fox jumps",
+		"gap":      "the quick brown fox jumps",
+		"twogaps":  "the quick brown red fox jumps",
+		"reversed": "the fox quick jumps",
+		"apart":    "the quick dog runs far from the fox",
+		"fox":      "fox",
+	}
+	for id, desc := range docs {
+		err = index.Index(id, map[string]interface{}{
+			"desc": desc,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	search := func(q Query) []string {
+		res, err := index.Search(NewSearchRequest(q))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var rv []string
+		for _, hit := range res.Hits {
+			rv = append(rv, hit.ID)
+		}
+		sort.Strings(rv)
+		return rv
+	}
+
+	tests := []struct {
+		terms    []string
+		slop     int
+		expected []string
+	}{
+		{
+			terms:    []string{"quick", "fox"},
+			slop:     0,
+			expected: []string{"exact"},
+		},
+		{
+			terms:    []string{"quick", "fox"},
+			slop:     1,
+			expected: []string{"exact", "gap"},
+		},
+		{
+			terms:    []string{"quick", "fox"},
+			slop:     2,
+			expected: []string{"exact", "gap", "reversed", "twogaps"},
+		},
+		{
+			terms:    []string{"fox", "quick"},
+			slop:     1,
+			expected: []string{"reversed"},
+		},
+		{
+			terms:    []string{"fox", "quick"},
+			slop:     2,
+			expected: []string{"exact", "reversed"},
+		},
+		{
+			terms:    []string{"fox", "quick"},
+			slop:     3,
+			expected: []string{"exact", "gap", "reversed"},
+		},
+		// the same location is not used for both terms
+		{
+			terms:    []string{"fox", "fox"},
+			slop:     3,
+			expected: nil,
+		},
+	}
+	for _, test := range tests {
+		q := NewPhraseQuery(test.terms, "desc").SetSlop(test.slop)
+		actual := search(q)
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("expected %v for %v with slop %d, got %v", test.expected, test.terms, test.slop, actual)
+		}
+	}
+
+	// closer matches score higher, exact ones are unchanged
+	res, err := index.Search(NewSearchRequest(NewPhraseQuery([]string{"quick", "fox"}, "desc").SetSlop(2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exact, err := index.Search(NewSearchRequest(NewPhraseQuery([]string{"quick", "fox"}, "desc")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Hits) != 4 || len(exact.Hits) != 1 {
+		t.Fatalf("expected 4 sloppy hits and 1 exact hit, got %d and %d", len(res.Hits), len(exact.Hits))
+	}
+	scores := make(map[string]float64)
+	for _, hit := range res.Hits {
+		scores[hit.ID] = hit.Score
+	}
+	if scores["exact"] != exact.Hits[0].Score {
+		t.Errorf("expected exact match to score %f, got %f", exact.Hits[0].Score, scores["exact"])
+	}
+	if !(scores["exact"] > scores["gap"] && scores["gap"] > scores["twogaps"]) {
+		t.Errorf("expected scores to decrease with distance, got %v", scores)
+	}
+}
+
+func TestMoreLikeThisQuery(t *testing.T) {
+	index, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	docs := map[string]map[string]interface{}{
+		"go1": {
+			"title": "goroutines and channels",
+			"body":  "goroutines communicate over channels, channels synchronize goroutines",
+		},
+		"go2": {
+			"title": "channels in practice",
+			"body":  "buffered channels let goroutines communicate without blocking",
+		},
+		"pasta1": {
+			"title": "fresh pasta",
+			"body":  "knead the dough, roll the pasta thin and boil the pasta with tomatoes",
+		},
+		"pasta2": {
+			"title": "pasta sauce",
+			"body":  "simmer tomatoes for the sauce while the pasta dough rests",
+		},
+		"misc": {
+			"title": "gardening",
+			"body":  "water the tomatoes in the morning",
+			"count": 3.0,
+		},
+	}
+	for id, doc := range docs {
+		err = index.Index(id, doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	search := func(q Query) []string {
+		res, err := index.Search(NewSearchRequest(q))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var rv []string
+		for _, hit := range res.Hits {
+			rv = append(rv, hit.ID)
+		}
+		return rv
+	}
+
+	tests := []struct {
+		query    Query
+		expected []string
+	}{
+		// the seed is excluded by default
+		{
+			query:    NewMoreLikeThisQuery("go1"),
+			expected: []string{"go2"},
+		},
+		{
+			query:    NewMoreLikeThisQuery("go1").SetIncludeSeed(true),
+			expected: []string{"go1", "go2"},
+		},
+		// the closest match first
+		{
+			query:    NewMoreLikeThisQuery("pasta1"),
+			expected: []string{"pasta2", "misc"},
+		},
+		// only terms occurring twice, pasta
+		{
+			query:    NewMoreLikeThisQuery("pasta1").SetMinTermFreq(2),
+			expected: []string{"pasta2"},
+		},
+		// the other terms are in no other document
+		{
+			query:    NewMoreLikeThisQuery("misc").SetFields([]string{"body"}),
+			expected: []string{"pasta2", "pasta1"},
+		},
+		// only a single term
+		{
+			query:    NewMoreLikeThisQuery("go2").SetFields([]string{"body"}).SetMaxQueryTerms(1),
+			expected: []string{"go1"},
+		},
+		{
+			query:    NewMoreLikeThisTextQuery("how do goroutines use channels"),
+			expected: []string{"go1", "go2"},
+		},
+		{
+			query:    NewMoreLikeThisQuery("missing"),
+			expected: nil,
+		},
+	}
+	for i, test := range tests {
+		actual := search(test.query)
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("test %d: expected %v, got %v", i, test.expected, actual)
+		}
+	}
+}
+
+func TestSearchAggregations(t *testing.T) {
+	index, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	index2, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index2.Close()
+
+	orders := []struct {
+		index Index
+		id    string
+		doc   map[string]interface{}
+	}{
+		{index, "a", map[string]interface{}{"status": "shipped", "revenue": 12.5}},
+		{index, "b", map[string]interface{}{"status": "shipped", "revenue": 40.0}},
+		{index, "c", map[string]interface{}{"status": "shipped"}},
+		{index, "d", map[string]interface{}{"status": "cancelled", "revenue": 1000.0}},
+		{index2, "e", map[string]interface{}{"status": "shipped", "revenue": 7.5}},
+	}
+	for _, order := range orders {
+		err = order.index.Index(order.id, order.doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	req := NewSearchRequest(NewTermQuery("shipped").SetField("status"))
+	req.AddAggregation("total", NewMetricAggregation(search.MetricSum, "revenue"))
+	req.AddAggregation("average", NewMetricAggregation(search.MetricAvg, "revenue"))
+	req.AddAggregation("smallest", NewMetricAggregation(search.MetricMin, "revenue"))
+	req.AddAggregation("largest", NewMetricAggregation(search.MetricMax, "revenue"))
+	req.AddAggregation("orders", NewMetricAggregation(search.MetricCount, "revenue"))
+
+	check := func(res *SearchResult, expected map[string]float64, missing int) {
+		if len(res.Aggregations) != len(expected) {
+			t.Errorf("expected %d aggregations, got %d", len(expected), len(res.Aggregations))
+		}
+		for name, value := range expected {
+			aggregation := res.Aggregations[name]
+			if aggregation == nil {
+				t.Errorf("expected aggregation %s", name)
+				continue
+			}
+			if aggregation.Value != value {
+				t.Errorf("expected %s %f, got %f", name, value, aggregation.Value)
+			}
+			if aggregation.Missing != missing {
+				t.Errorf("expected %s missing %d, got %d", name, missing, aggregation.Missing)
+			}
+		}
+	}
+
+	// the cancelled order does not match, and c has no revenue
+	res, err := index.Search(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 3 || len(res.Hits) != 3 {
+		t.Errorf("expected 3 hits collected, got %d of %d", len(res.Hits), res.Total)
+	}
+	check(res, map[string]float64{
+		"total":    52.5,
+		"average":  26.25,
+		"smallest": 12.5,
+		"largest":  40,
+		"orders":   2,
+	}, 1)
+
+	// merged across the indexes of an alias
+	alias := NewIndexAlias(index, index2)
+	res, err = alias.Search(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(res, map[string]float64{
+		"total":    60,
+		"average":  20,
+		"smallest": 7.5,
+		"largest":  40,
+		"orders":   3,
+	}, 1)
+
+	// no aggregations unless requested
+	res, err = index.Search(NewSearchRequest(NewTermQuery("shipped").SetField("status")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Aggregations != nil {
+		t.Errorf("expected no aggregations, got %v", res.Aggregations)
+	}
+
+	req = NewSearchRequest(NewMatchAllQuery())
+	req.AddAggregation("median", NewMetricAggregation("median", "revenue"))
+	_, err = index.Search(req)
+	if err != ErrorUnknownAggregationType {
+		t.Errorf("expected unknown aggregation type error, got %v", err)
+	}
+}
+
+func TestCIDRQuery(t *testing.T) {
+	mapping := NewIndexMapping()
+	mapping.DefaultMapping.AddFieldMappingsAt("client", NewIPFieldMapping())
+	index, err := New("", mapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	addrs := map[string]string{
+		"a": "192.168.1.1",
+		"b": "192.168.1.254",
+		"c": "192.168.2.1",
+		"d": "10.0.0.1",
+		"e": "2001:db8::1",
+		"f": "2001:db8:ffff::7",
+		"g": "2001:db9::1",
+	}
+	for id, addr := range addrs {
+		err = index.Index(id, map[string]interface{}{"client": addr})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = index.Index("x", map[string]interface{}{"client": "192.168.1.300"})
+	if err == nil {
+		t.Errorf("expected invalid address to fail indexing")
+	}
+
+	tests := []struct {
+		cidr string
+		ids  []string
+	}{
+		{
+			cidr: "192.168.1.0/24",
+			ids:  []string{"a", "b"},
+		},
+		{
+			cidr: "192.168.0.0/16",
+			ids:  []string{"a", "b", "c"},
+		},
+		{
+			cidr: "192.168.1.254",
+			ids:  []string{"b"},
+		},
+		{
+			cidr: "2001:db8::/32",
+			ids:  []string{"e", "f"},
+		},
+		{
+			cidr: "2001:db8::/48",
+			ids:  []string{"e"},
+		},
+		{
+			cidr: "::ffff:0:0/96",
+			ids:  []string{"a", "b", "c", "d"},
+		},
+	}
+	for _, test := range tests {
+		req := NewSearchRequest(NewCIDRQuery(test.cidr).SetField("client"))
+		req.Fields = []string{"client"}
+		res, err := index.Search(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []string
+		for _, hit := range res.Hits {
+			ids = append(ids, hit.ID)
+			if hit.Fields["client"] != addrs[hit.ID] {
+				t.Errorf("expected stored address %s, got %v", addrs[hit.ID], hit.Fields["client"])
+			}
+		}
+		sort.Strings(ids)
+		if !reflect.DeepEqual(ids, test.ids) {
+			t.Errorf("%s: expected %v, got %v", test.cidr, test.ids, ids)
+		}
+	}
+}
+
+func TestIndexWarmup(t *testing.T) {
+	index, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = index.Index("a", map[string]interface{}{"name": "marty"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = index.Warmup(context.Background(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = index.Index("b", map[string]interface{}{"name": "marty"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := index.Search(NewSearchRequest(NewTermQuery("marty").SetField("name")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 2 {
+		t.Errorf("expected 2 hits, got %d", res.Total)
+	}
+
+	err = index.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = index.Warmup(context.Background(), 0)
+	if err != ErrorIndexClosed {
+		t.Errorf("expected index closed error, got %v", err)
+	}
+}
+
+func TestHighlightFromTermVectors(t *testing.T) {
+	mapping := NewIndexMapping()
+	withoutVectors := NewTextFieldMapping()
+	withoutVectors.IncludeTermVectors = false
+	mapping.DefaultMapping.AddFieldMappingsAt("notes", withoutVectors)
+	index, err := New("", mapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	err = index.Index("a", map[string]interface{}{
+		"desc":  "the Quick brown fox, quick!",
+		"notes": "a quick note",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := NewSearchRequest(NewDisjunctionQuery([]Query{
+		NewTermQuery("quick").SetField("desc"),
+		NewTermQuery("quick").SetField("notes"),
+	}))
+	req.Highlight = NewHighlight()
+	req.Highlight.AddField("desc")
+	req.Highlight.AddField("notes")
+	req.IncludeLocations = true
+	res, err := index.Search(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Hits) != 1 {
+		t.Fatalf("expected 1 hit, got %d", len(res.Hits))
+	}
+	hit := res.Hits[0]
+
+	// the locations are those of the tokens at index time,
+	// lower cased tokens at their original offsets
+	expectedLocations := search.Locations{
+		&search.Location{Pos: 2, Start: 4, End: 9},
+		&search.Location{Pos: 5, Start: 21, End: 26},
+	}
+	if !reflect.DeepEqual(hit.Locations["desc"]["quick"], expectedLocations) {
+		t.Errorf("expected locations %v, got %v", expectedLocations, hit.Locations["desc"]["quick"])
+	}
+	quick := `<span class="highlight">Quick</span>`
+	lowerQuick := `<span class="highlight">quick</span>`
+	expectedFragments := []string{"the " + quick + " brown fox, " + lowerQuick + "!"}
+	if !reflect.DeepEqual(hit.Fragments["desc"], expectedFragments) {
+		t.Errorf("expected fragments %q, got %q", expectedFragments, hit.Fragments["desc"])
+	}
+
+	// without term vectors there is nothing to highlight,
+	// the start of the field is returned as is
+	if _, ok := hit.Locations["notes"]; ok {
+		t.Errorf("expected no locations for field without term vectors")
+	}
+	if !reflect.DeepEqual(hit.Fragments["notes"], []string{"a quick note"}) {
+		t.Errorf("expected plain fragment, got %q", hit.Fragments["notes"])
+	}
+}
+
+func TestIndexAnalyzeQuery(t *testing.T) {
+	index, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	err = index.Index("a", map[string]interface{}{"name": "marty"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = index.Index("b", map[string]interface{}{"name": "marty schoch"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q := NewBooleanQuery(
+		[]Query{NewTermQuery("marty").SetField("name")},
+		[]Query{NewMatchQuery("Schoch Shcoch").SetField("name")},
+		nil)
+	qa, err := index.AnalyzeQuery(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []*TermAnalysis{
+		{Field: "name", Term: "marty", DocFreq: 2},
+		{Field: "name", Term: "schoch", DocFreq: 1},
+		{Field: "name", Term: "shcoch", DocFreq: 0},
+	}
+	if !reflect.DeepEqual(qa.Terms, expected) {
+		t.Errorf("expected %v, got %v", expected, qa.Terms)
+	}
+	unmatched := qa.Unmatched()
+	if len(unmatched) != 1 || unmatched[0].Term != "shcoch" {
+		t.Errorf("expected only shcoch unmatched, got %v", unmatched)
+	}
+}
+
+func TestMatchQueryAnalyzerOverride(t *testing.T) {
+	index, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	err = index.Index("a", map[string]interface{}{"desc": "Running shoes"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		query    Query
+		expected []*TermAnalysis
+		hits     uint64
+	}{
+		{
+			query: NewMatchQuery("RUNNING").SetField("desc"),
+			expected: []*TermAnalysis{
+				{Field: "desc", Term: "running", DocFreq: 1},
+			},
+			hits: 1,
+		},
+		{
+			query: NewMatchQuery("RUNNING").SetField("desc").(*matchQuery).SetAnalyzer("simple"),
+			expected: []*TermAnalysis{
+				{Field: "desc", Term: "running", DocFreq: 1},
+			},
+			hits: 1,
+		},
+		{
+			query: NewMatchQuery("running").SetField("desc").(*matchQuery).SetAnalyzer("en"),
+			expected: []*TermAnalysis{
+				{Field: "desc", Term: "run", DocFreq: 0},
+			},
+			hits: 0,
+		},
+		{
+			query: NewMatchPhraseQuery("Running shoes").SetField("desc").(*matchPhraseQuery).SetAnalyzer("keyword"),
+			expected: []*TermAnalysis{
+				{Field: "desc", Term: "Running shoes", DocFreq: 0},
+			},
+			hits: 0,
+		},
+	}
+
+	for i, test := range tests {
+		qa, err := index.AnalyzeQuery(test.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(qa.Terms, test.expected) {
+			t.Errorf("test %d: expected terms %v, got %v", i, test.expected, qa.Terms)
+		}
+		res, err := index.Search(NewSearchRequest(test.query))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Total != test.hits {
+			t.Errorf("test %d: expected %d hits, got %d", i, test.hits, res.Total)
+		}
+	}
+}
+
+func TestIndexSuggestTerms(t *testing.T) {
+	index, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	err = index.Index("a", map[string]interface{}{"desc": "the quick brown fox"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = index.Index("b", map[string]interface{}{"desc": "quick quack"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		term     string
+		maxEdits int
+		max      int
+		expected []Suggestion
+	}{
+		{
+			term:     "quik",
+			maxEdits: 1,
+			expected: []Suggestion{
+				{Term: "quick", Distance: 1, DocFreq: 2},
+			},
+		},
+		{
+			term:     "quick",
+			maxEdits: 2,
+			expected: []Suggestion{
+				{Term: "quick", Distance: 0, DocFreq: 2},
+				{Term: "quack", Distance: 1, DocFreq: 1},
+			},
+		},
+		{
+			term:     "quick",
+			maxEdits: 2,
+			max:      1,
+			expected: []Suggestion{
+				{Term: "quick", Distance: 0, DocFreq: 2},
+			},
+		},
+		{
+			term:     "zzzzzzzz",
+			maxEdits: 2,
+			expected: []Suggestion{},
+		},
+	}
+
+	for _, test := range tests {
+		suggestions, err := index.SuggestTerms("desc", test.term, test.maxEdits, test.max)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(suggestions, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.term, test.expected, suggestions)
+		}
+	}
+}
+
+func TestIndexStatsMap(t *testing.T) {
+	index, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	docs := map[string]interface{}{
+		"a": map[string]interface{}{"name": "marty"},
+		"b": map[string]interface{}{"name": "marty schoch"},
+		"c": map[string]interface{}{"desc": "quick"},
+	}
+	for id, doc := range docs {
+		err = index.Index(id, doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stats := index.StatsMap()
+	if stats["doc_count"] != uint64(3) {
+		t.Errorf("expected doc count 3, got %v", stats["doc_count"])
+	}
+	expectedCardinality := map[string]uint64{
+		"name": 2,
+		"desc": 1,
+		"_all": 3,
+	}
+	if !reflect.DeepEqual(stats["field_cardinality"], expectedCardinality) {
+		t.Errorf("expected field cardinality %v, got %v", expectedCardinality, stats["field_cardinality"])
+	}
+	if stats["term_count"] != uint64(6) {
+		t.Errorf("expected term count 6, got %v", stats["term_count"])
+	}
+	if entries, ok := stats["store_entries"].(int); !ok || entries == 0 {
+		t.Errorf("expected store entries, got %v", stats["store_entries"])
+	}
+
+	err = index.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if index.StatsMap() != nil {
+		t.Errorf("expected no stats for closed index")
+	}
+}
+
+func TestNumericRangeQueryBounds(t *testing.T) {
+	index, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	for _, num := range []float64{-1, 0, 1, 2, 3} {
+		err = index.Index(fmt.Sprintf("%g", num), map[string]interface{}{"num": num})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	one := 1.0
+	three := 3.0
+	yes := true
+	no := false
+	tests := []struct {
+		min, max                   *float64
+		inclusiveMin, inclusiveMax *bool
+		expected                   []string
+	}{
+		{min: &one, max: &three, inclusiveMin: &yes, inclusiveMax: &yes, expected: []string{"1", "2", "3"}},
+		{min: &one, max: &three, inclusiveMin: &yes, inclusiveMax: &no, expected: []string{"1", "2"}},
+		{min: &one, max: &three, inclusiveMin: &no, inclusiveMax: &yes, expected: []string{"2", "3"}},
+		{min: &one, max: &three, inclusiveMin: &no, inclusiveMax: &no, expected: []string{"2"}},
+		// defaults are inclusive min and exclusive max
+		{min: &one, max: &three, expected: []string{"1", "2"}},
+		{min: &one, inclusiveMin: &no, expected: []string{"2", "3"}},
+		{min: &one, expected: []string{"1", "2", "3"}},
+		{max: &one, inclusiveMax: &yes, expected: []string{"-1", "0", "1"}},
+		{max: &one, expected: []string{"-1", "0"}},
+		{min: &three, max: &one, inclusiveMin: &yes, inclusiveMax: &yes, expected: []string{}},
+	}
+
+	for i, test := range tests {
+		q := NewNumericRangeInclusiveQuery(test.min, test.max, test.inclusiveMin, test.inclusiveMax).SetField("num")
+		res, err := index.Search(NewSearchRequest(q))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := []string{}
+		for _, hit := range res.Hits {
+			ids = append(ids, hit.ID)
+		}
+		sort.Strings(ids)
+		if !reflect.DeepEqual(ids, test.expected) {
+			t.Errorf("test %d: expected %v, got %v", i, test.expected, ids)
+		}
+	}
+}
+
+func TestPositionIncrementGap(t *testing.T) {
+	mapping := NewIndexMapping()
+	noGap := NewTextFieldMapping()
+	noGap.PositionIncrementGap = -1
+	mapping.DefaultMapping.AddFieldMappingsAt("nogap", noGap)
+	index, err := New("", mapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	err = index.Index("a", map[string]interface{}{
+		"tags":  []string{"quick brown", "fox jumps"},
+		"nogap": []string{"quick brown", "fox jumps"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		phrase string
+		slop   int
+		hits   uint64
+	}{
+		{phrase: "quick brown", hits: 1},
+		{phrase: "fox jumps", hits: 1},
+		{phrase: "brown fox", hits: 0},
+		{phrase: "brown fox", slop: 10, hits: 0},
+	}
+	for _, test := range tests {
+		q := NewPhraseQuery(strings.Split(test.phrase, " "), "tags").SetSlop(test.slop)
+		res, err := index.Search(NewSearchRequest(q))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Total != test.hits {
+			t.Errorf("%s with slop %d: expected %d hits, got %d", test.phrase, test.slop, test.hits, res.Total)
+		}
+	}
+
+	// the second value follows the first past the gap
+	expectedPositions := map[string]float64{
+		"tags":  2 + DefaultPositionIncrementGap + 1,
+		"nogap": 1,
+	}
+	for field, expected := range expectedPositions {
+		req := NewSearchRequest(NewTermQuery("fox").SetField(field))
+		req.IncludeLocations = true
+		res, err := index.Search(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Total != 1 {
+			t.Fatalf("%s: expected 1 hit, got %d", field, res.Total)
+		}
+		locations := res.Hits[0].Locations[field]["fox"]
+		if len(locations) != 1 || locations[0].Pos != expected {
+			t.Errorf("%s: expected fox at position %v, got %v", field, expected, locations)
+		}
+	}
+}
+
+func TestQueryMatchNoneShortCircuit(t *testing.T) {
+	idx, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+
+	err = idx.Index("a", map[string]interface{}{"desc": "quick brown fox"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = idx.Index("b", map[string]interface{}{"desc": "lazy dog"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	i, _, err := idx.Advanced()
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader, err := i.Reader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	quick := NewTermQuery("quick").SetField("desc")
+	lazy := NewTermQuery("lazy").SetField("desc")
+	missing := NewTermQuery("zebra").SetField("desc")
+	tests := []struct {
+		query     Query
+		matchNone bool
+		expected  []string
+	}{
+		{query: NewConjunctionQuery([]Query{quick, missing}), matchNone: true, expected: []string{}},
+		{query: NewConjunctionQuery([]Query{missing, NewMatchAllQuery()}), matchNone: true, expected: []string{}},
+		{query: NewBooleanQuery([]Query{quick, missing}, []Query{lazy}, nil), matchNone: true, expected: []string{}},
+		{query: NewBooleanQuery([]Query{quick}, []Query{missing}, nil), matchNone: true, expected: []string{}},
+		{query: NewBooleanQueryMinShould([]Query{quick}, []Query{missing}, nil, 0), expected: []string{"a"}},
+		{query: NewDisjunctionQuery([]Query{missing, NewTermQuery("unicorn").SetField("desc")}), matchNone: true, expected: []string{}},
+		{query: NewDisjunctionQueryMin([]Query{quick, missing}, 2), matchNone: true, expected: []string{}},
+		{query: NewDisjunctionQuery([]Query{quick, missing, lazy}), expected: []string{"a", "b"}},
+		{query: NewPhraseQuery([]string{"quick", "zebra"}, "desc"), matchNone: true, expected: []string{}},
+		{query: NewPhraseQuery([]string{"quick", "brown"}, "desc"), expected: []string{"a"}},
+	}
+
+	for i, test := range tests {
+		s, err := test.query.Searcher(reader, idx.Mapping(), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, isMatchNone := s.(*searchers.MatchNoneSearcher)
+		s.Close()
+		if isMatchNone != test.matchNone {
+			t.Errorf("test %d: expected match none searcher %t, got %T", i, test.matchNone, s)
+		}
+
+		res, err := idx.Search(NewSearchRequest(test.query))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := []string{}
+		for _, hit := range res.Hits {
+			ids = append(ids, hit.ID)
+		}
+		sort.Strings(ids)
+		if !reflect.DeepEqual(ids, test.expected) {
+			t.Errorf("test %d: expected %v, got %v", i, test.expected, ids)
+		}
+	}
+}
+
+func TestDocIDQuery(t *testing.T) {
+	index, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	for _, id := range []string{"a", "b", "c", "d"} {
+		err = index.Index(id, map[string]interface{}{"name": "doc " + id})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		query    Query
+		expected []string
+	}{
+		{query: NewDocIDQuery([]string{"d", "b", "x"}), expected: []string{"b", "d"}},
+		{query: NewDocIDQuery([]string{"x", "y"}), expected: []string{}},
+		{
+			query:    NewConjunctionQuery([]Query{NewMatchQuery("doc"), NewDocIDQuery([]string{"a", "c"})}),
+			expected: []string{"a", "c"},
+		},
+	}
+	for i, test := range tests {
+		res, err := index.Search(NewSearchRequest(test.query))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := []string{}
+		for _, hit := range res.Hits {
+			ids = append(ids, hit.ID)
+		}
+		sort.Strings(ids)
+		if !reflect.DeepEqual(ids, test.expected) {
+			t.Errorf("test %d: expected %v, got %v", i, test.expected, ids)
+		}
+	}
+}
+
+func TestDisjunctionMaxQuery(t *testing.T) {
+	index, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	filler := strings.Repeat("other words ", 10)
+	docs := map[string]map[string]interface{}{
+		// matches the title strongly
+		"strong": {"title": "fox", "desc": "lazy dog", "tags": "animal"},
+		// matches all the fields weakly, in long values
+		"weak": {"title": "fox " + filler, "desc": "fox " + filler, "tags": "fox " + filler},
+		"x":    {"title": "cat", "desc": "lazy cat", "tags": "animal"},
+		"y":    {"title": "dog", "desc": "lazy dog", "tags": "animal"},
+		"z":    {"title": "cow", "desc": "lazy cow", "tags": "animal"},
+	}
+	for id, doc := range docs {
+		err = index.Index(id, doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	clauses := func() []Query {
+		return []Query{
+			NewTermQuery("fox").SetField("title"),
+			NewTermQuery("fox").SetField("desc"),
+			NewTermQuery("fox").SetField("tags"),
+		}
+	}
+	tests := []struct {
+		query    Query
+		expected []string
+	}{
+		{query: NewDisjunctionQuery(clauses()), expected: []string{"weak", "strong"}},
+		{query: NewDisjunctionMaxQuery(clauses()), expected: []string{"strong", "weak"}},
+		{query: NewDisjunctionMaxQuery(clauses()).SetTieBreaker(0.1), expected: []string{"strong", "weak"}},
+	}
+	for i, test := range tests {
+		res, err := index.Search(NewSearchRequest(test.query))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := []string{}
+		for _, hit := range res.Hits {
+			ids = append(ids, hit.ID)
+		}
+		if !reflect.DeepEqual(ids, test.expected) {
+			t.Errorf("test %d: expected %v, got %v", i, test.expected, ids)
+		}
+	}
+
+	// with a tie breaker of 0 the score is the best clause score
+	req := NewSearchRequest(NewDisjunctionMaxQuery(clauses()))
+	req.Explain = true
+	res, err := index.Search(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, hit := range res.Hits {
+		best := 0.0
+		for _, child := range hit.Expl.Children {
+			if child.Value > best {
+				best = child.Value
+			}
+		}
+		if math.Abs(hit.Score-best) > 1e-9 {
+			t.Errorf("expected score of %s to be %f, got %f", hit.ID, best, hit.Score)
+		}
+	}
+}
+
+type recordingListener struct {
+	index  Index
+	events []string
+}
+
+func (l *recordingListener) OnIndex(id string) {
+	// listeners are called outside the index locks
+	_, err := l.index.DocCount()
+	if err != nil {
+		l.events = append(l.events, "error:"+err.Error())
+	}
+	l.events = append(l.events, "index:"+id)
+}
+
+func (l *recordingListener) OnDelete(id string) {
+	l.events = append(l.events, "delete:"+id)
+}
+
+func (l *recordingListener) OnBatch(b *Batch) {
+	l.events = append(l.events, fmt.Sprintf("batch:%d", b.Size()))
+}
+
+func TestIndexListener(t *testing.T) {
+	mapping := NewIndexMapping()
+	mapping.Validator = func(field string, value interface{}) error {
+		if f, ok := value.(float64); ok && f < 0 {
+			return fmt.Errorf("must not be negative")
+		}
+		return nil
+	}
+	index, err := New("", mapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener := &recordingListener{index: index}
+	index.RegisterListener(listener)
+
+	err = index.Index("a", map[string]interface{}{"name": "marty", "age": 17.0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = index.Index("b", map[string]interface{}{"name": "biff", "age": -1.0})
+	if err == nil {
+		t.Errorf("expected error indexing b")
+	}
+	err = index.Delete("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	batch := NewBatch()
+	batch.Index("c", map[string]interface{}{"name": "emmett"})
+	batch.Index("d", map[string]interface{}{"name": "lorraine"})
+	err = index.Batch(batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch = NewBatch()
+	batch.Index("e", map[string]interface{}{"name": "george"})
+	batch.Index("f", map[string]interface{}{"age": -2.0})
+	err = index.Batch(batch)
+	if err == nil {
+		t.Errorf("expected error executing batch")
+	}
+
+	err = index.Update("c", map[string]interface{}{"age": 65.0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = index.Update("x", map[string]interface{}{"age": 1.0})
+	if err != ErrorDocumentNotFound {
+		t.Errorf("expected %v, got %v", ErrorDocumentNotFound, err)
+	}
+	_, err = index.DeleteByQuery(NewDocIDQuery([]string{"d"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = index.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = index.Delete("c")
+	if err != ErrorIndexClosed {
+		t.Errorf("expected %v, got %v", ErrorIndexClosed, err)
+	}
+
+	expected := []string{"index:a", "delete:a", "batch:2", "index:c", "delete:d"}
+	if !reflect.DeepEqual(listener.events, expected) {
+		t.Errorf("expected events %v, got %v", expected, listener.events)
+	}
+}
+
+func TestFacetOrder(t *testing.T) {
+	index, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	tags := map[string][]interface{}{
+		"a": {"red", "blue"},
+		"b": {"red", "green"},
+		"c": {"blue"},
+	}
+	for id, tag := range tags {
+		err = index.Index(id, map[string]interface{}{"tags": tag})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	req := NewSearchRequest(NewMatchAllQuery())
+	facet := NewFacetRequest("tags", 10)
+	facet.Order = "term_desc"
+	req.AddFacet("tags", facet)
+	res, err := index.Search(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual := []string{}
+	for _, tf := range res.Facets["tags"].Terms {
+		actual = append(actual, tf.Term)
+	}
+	expected := []string{"red", "green", "blue"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+
+	facet.Order = "alphabetical"
+	_, err = index.Search(req)
+	if err != ErrorUnknownFacetOrder {
+		t.Errorf("expected %v, got %v", ErrorUnknownFacetOrder, err)
+	}
+}
+
+func TestTokenTypeQuery(t *testing.T) {
+	bodyMapping := NewTextFieldMapping()
+	bodyMapping.Analyzer = "url_email"
+	mapping := NewIndexMapping()
+	mapping.DefaultMapping.AddFieldMappingsAt("body", bodyMapping)
+
+	index, err := New("", mapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	docs := map[string]string{
+		"email": "write to Bob@Example.com for details",
+		"url":   "see http://example.com/bob for details",
+		"words": "bob works at example dot com",
+		"num":   "room 42 on floor 3",
+	}
+	for id, body := range docs {
+		err = index.Index(id, map[string]interface{}{"body": body})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		query    Query
+		expected []string
+	}{
+		{query: NewTokenTypeQuery("EMAIL", "").SetField("body"), expected: []string{"email"}},
+		{query: NewTokenTypeQuery("URL", "").SetField("body"), expected: []string{"url"}},
+		{query: NewTokenTypeQuery("NUM", "").SetField("body"), expected: []string{"num"}},
+		{query: NewTokenTypeQuery("EMAIL", "bob@example.com").SetField("body"), expected: []string{"email"}},
+		{query: NewTokenTypeQuery("EMAIL", "alice@example.com").SetField("body"), expected: []string{}},
+		{query: NewTokenTypeQuery("URL", "bob@example.com").SetField("body"), expected: []string{}},
+		{query: NewTokenTypeQuery("NUM", "42").SetField("body"), expected: []string{"num"}},
+		{query: NewMatchQuery("bob@example.com").SetField("body"), expected: []string{"email"}},
+		{query: NewMatchQuery("details").SetField("body"), expected: []string{"email", "url"}},
+	}
+	for i, test := range tests {
+		res, err := index.Search(NewSearchRequest(test.query))
+		if err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+		ids := []string{}
+		for _, hit := range res.Hits {
+			ids = append(ids, hit.ID)
+		}
+		sort.Strings(ids)
+		if !reflect.DeepEqual(ids, test.expected) {
+			t.Errorf("test %d: expected %v, got %v", i, test.expected, ids)
+		}
+	}
+
+	_, err = index.Search(NewSearchRequest(NewTokenTypeQuery("PHONE", "").SetField("body")))
+	if err != ErrorUnknownTokenType {
+		t.Errorf("expected %v, got %v", ErrorUnknownTokenType, err)
+	}
+}
+
+// expiringContext is done once its Done method has been
+// called more than checks times, stopping a search after a
+// known number of matches
+type expiringContext struct {
+	context.Context
+	checks int
+	calls  int
+	done   chan struct{}
+}
+
+func newExpiringContext(checks int) *expiringContext {
+	return &expiringContext{
+		Context: context.Background(),
+		checks:  checks,
+		done:    make(chan struct{}),
+	}
+}
+
+func (c *expiringContext) Done() <-chan struct{} {
+	c.calls++
+	if c.calls > c.checks {
+		select {
+		case <-c.done:
+		default:
+			close(c.done)
+		}
+	}
+	return c.done
+}
+
+func (c *expiringContext) Err() error {
+	select {
+	case <-c.done:
+		return context.DeadlineExceeded
+	default:
+		return nil
+	}
+}
+
+func TestSearchInContextTimeout(t *testing.T) {
+	index, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	for n := 0; n < 20; n++ {
+		// repeat the term a varying number of times, so the
+		// hits have different scores
+		body := strings.Repeat("beer ", n%5+1) + strings.Repeat("water ", 5)
+		err = index.Index(fmt.Sprintf("doc%02d", n), map[string]interface{}{"body": body})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	req := NewSearchRequest(NewTermQuery("beer").SetField("body"))
+	req.Size = 20
+
+	res, err := index.SearchInContext(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.TimedOut {
+		t.Errorf("expected search without deadline not to time out")
+	}
+	if res.Total != 20 {
+		t.Fatalf("expected 20 hits, got %d", res.Total)
+	}
+
+	// one check when the search starts, then one per match
+	res, err = index.SearchInContext(newExpiringContext(8), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.TimedOut {
+		t.Errorf("expected search to time out")
+	}
+	if res.Total != 7 || len(res.Hits) != 7 {
+		t.Fatalf("expected 7 partial hits, got total %d and %d hits", res.Total, len(res.Hits))
+	}
+	for i := 1; i < len(res.Hits); i++ {
+		if res.Hits[i].Score > res.Hits[i-1].Score {
+			t.Errorf("expected partial hits ordered by score, got %v", res.Hits)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	time.Sleep(time.Millisecond)
+	res, err = index.SearchInContext(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.TimedOut {
+		t.Errorf("expected search past its deadline to time out")
+	}
+	if len(res.Hits) != 0 {
+		t.Errorf("expected no hits past the deadline, got %d", len(res.Hits))
+	}
+}
+
+func TestDefaultDynamicStringAnalyzer(t *testing.T) {
+	textMapping := NewTextFieldMapping()
+	mapping := NewIndexMapping()
+	mapping.DefaultDynamicStringAnalyzer = "keyword"
+	mapping.DefaultMapping.AddFieldMappingsAt("title", textMapping)
+
+	index, err := New("", mapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	err = index.Index("a", map[string]interface{}{
+		"status": "Not Found",
+		"title":  "Page Not Found",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = index.Index("b", map[string]interface{}{
+		"status": "Found",
+		"title":  "Found It",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		query    Query
+		expected []string
+	}{
+		// the unmapped field is indexed as whole values
+		{query: NewTermQuery("Not Found").SetField("status"), expected: []string{"a"}},
+		{query: NewTermQuery("found").SetField("status"), expected: []string{}},
+		{query: NewTermQuery("Found").SetField("status"), expected: []string{"b"}},
+		// and queried with the same analyzer
+		{query: NewMatchQuery("Not Found").SetField("status"), expected: []string{"a"}},
+		// the mapped field keeps the default analyzer
+		{query: NewTermQuery("found").SetField("title"), expected: []string{"a", "b"}},
+		{query: NewMatchQuery("page").SetField("title"), expected: []string{"a"}},
+	}
+	for i, test := range tests {
+		res, err := index.Search(NewSearchRequest(test.query))
+		if err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+		ids := []string{}
+		for _, hit := range res.Hits {
+			ids = append(ids, hit.ID)
+		}
+		sort.Strings(ids)
+		if !reflect.DeepEqual(ids, test.expected) {
+			t.Errorf("test %d: expected %v, got %v", i, test.expected, ids)
+		}
+	}
+
+	mapping = NewIndexMapping()
+	mapping.DefaultDynamicStringAnalyzer = "missing"
+	_, err = New("", mapping)
+	if err == nil {
+		t.Errorf("expected error for unknown dynamic string analyzer")
+	}
+}
+
+func TestPhoneticNameSearch(t *testing.T) {
+	mapping := NewIndexMapping()
+	err := mapping.AddCustomTokenFilter("name_metaphone", map[string]interface{}{
+		"type":      "phonetic",
+		"algorithm": "double_metaphone",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = mapping.AddCustomAnalyzer("phonetic_name", map[string]interface{}{
+		"type":          "custom",
+		"tokenizer":     "unicode",
+		"token_filters": []interface{}{"to_lower", "name_metaphone"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	nameMapping := NewTextFieldMapping()
+	nameMapping.Analyzer = "phonetic_name"
+	mapping.DefaultMapping.AddFieldMappingsAt("name", nameMapping)
+
+	index, err := New("", mapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	names := map[string]string{
+		"a": "John Smith",
+		"b": "Jon Smyth",
+		"c": "Katherine Jones",
+		"d": "Catherine Schmidt",
+		"e": "Mary Black",
+	}
+	for id, name := range names {
+		err = index.Index(id, map[string]interface{}{"name": name})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		match    string
+		expected []string
+	}{
+		{match: "smith", expected: []string{"a", "b", "d"}},
+		{match: "Smythe", expected: []string{"a", "b", "d"}},
+		{match: "kathryn", expected: []string{"c", "d"}},
+		{match: "blak", expected: []string{"e"}},
+		{match: "green", expected: []string{}},
+	}
+	for _, test := range tests {
+		res, err := index.Search(NewSearchRequest(NewMatchQuery(test.match).SetField("name")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := []string{}
+		for _, hit := range res.Hits {
+			ids = append(ids, hit.ID)
+		}
+		sort.Strings(ids)
+		if !reflect.DeepEqual(ids, test.expected) {
+			t.Errorf("expected %v for %s, got %v", test.expected, test.match, ids)
+		}
+	}
+
+	// the query text goes through the analyzer of the field
+	qa, err := index.AnalyzeQuery(NewMatchQuery("Smythe").SetField("name"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	terms := []string{}
+	for _, term := range qa.Terms {
+		terms = append(terms, term.Term)
+	}
+	sort.Strings(terms)
+	if !reflect.DeepEqual(terms, []string{"SM0", "XMT"}) {
+		t.Errorf("expected the query to search for [SM0 XMT], got %v", terms)
+	}
+}
+
+func TestMultiMatchQuery(t *testing.T) {
+	index, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	filler := strings.Repeat("other words ", 10)
+	docs := map[string]map[string]interface{}{
+		// matches the title strongly
+		"strong": {"title": "fox", "desc": "lazy dog", "tags": "animal"},
+		// matches all the fields weakly, in long values
+		"weak": {"title": "fox " + filler, "desc": "fox " + filler, "tags": "fox " + filler},
+		"x":    {"title": "cat", "desc": "lazy cat", "tags": "animal"},
+		"y":    {"title": "dog", "desc": "lazy dog", "tags": "animal"},
+		"z":    {"title": "cow", "desc": "lazy cow", "tags": "animal"},
+	}
+	for id, doc := range docs {
+		err = index.Index(id, doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fields := []string{"title", "desc", "tags"}
+	tests := []struct {
+		query    Query
+		expected []string
+	}{
+		{query: NewMultiMatchQuery("fox", fields), expected: []string{"strong", "weak"}},
+		{query: NewMultiMatchQuery("fox", fields).SetType(MultiMatchBestFields), expected: []string{"strong", "weak"}},
+		{query: NewMultiMatchQuery("fox", fields).SetType(MultiMatchMostFields), expected: []string{"weak", "strong"}},
+		// boosting the description favours the only document with fox in it
+		{query: NewMultiMatchQuery("fox", []string{"title", "desc^10", "tags"}), expected: []string{"weak", "strong"}},
+		{query: NewMultiMatchQuery("fox", []string{"desc"}), expected: []string{"weak"}},
+	}
+	for i, test := range tests {
+		res, err := index.Search(NewSearchRequest(test.query))
+		if err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+		ids := []string{}
+		for _, hit := range res.Hits {
+			ids = append(ids, hit.ID)
+		}
+		if !reflect.DeepEqual(ids, test.expected) {
+			t.Errorf("test %d: expected %v, got %v", i, test.expected, ids)
+		}
+	}
+
+	// cross fields finds both terms of the text in the
+	// strong document, fox in its title and lazy in its
+	// description
+	res, err := index.Search(NewSearchRequest(NewMultiMatchQuery("lazy fox", fields).SetType(MultiMatchCrossFields)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 5 {
+		t.Errorf("expected 5 hits, got %d", res.Total)
+	}
+	if len(res.Hits) == 0 || res.Hits[0].ID != "strong" {
+		t.Errorf("expected strong to rank first, got %v", res.Hits)
+	}
+
+	_, err = index.Search(NewSearchRequest(NewMultiMatchQuery("fox", []string{"title^high"})))
+	if err == nil {
+		t.Errorf("expected error for invalid field boost")
+	}
+}
+
+func TestSearchIncludeLocations(t *testing.T) {
+	index, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	err = index.Index("a", map[string]interface{}{
+		"title": "quick fox",
+		"desc":  "the quick brown fox jumps over the quick dog",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query := NewDisjunctionQuery([]Query{
+		NewMatchQuery("quick").SetField("desc"),
+		NewMatchQuery("fox").SetField("title"),
+	})
+
+	// locations are left out by default
+	res, err := index.Search(NewSearchRequest(query))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Hits) != 1 {
+		t.Fatalf("expected 1 hit, got %d", len(res.Hits))
+	}
+	if res.Hits[0].Locations != nil {
+		t.Errorf("expected no locations by default, got %v", res.Hits[0].Locations)
+	}
+
+	req := NewSearchRequest(query)
+	req.IncludeLocations = true
+	res, err = index.Search(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Hits) != 1 {
+		t.Fatalf("expected 1 hit, got %d", len(res.Hits))
+	}
+	expected := search.FieldTermLocationMap{
+		"desc": search.TermLocationMap{
+			"quick": search.Locations{
+				&search.Location{Pos: 2, Start: 4, End: 9},
+				&search.Location{Pos: 8, Start: 35, End: 40},
+			},
+		},
+		"title": search.TermLocationMap{
+			"fox": search.Locations{
+				&search.Location{Pos: 2, Start: 6, End: 9},
+			},
+		},
+	}
+	if !reflect.DeepEqual(res.Hits[0].Locations, expected) {
+		t.Errorf("expected locations %v, got %v", expected, res.Hits[0].Locations)
+	}
+
+	// the iterator follows the request too
+	iter, err := index.SearchIter(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer iter.Close()
+	hit, err := iter.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hit == nil || !reflect.DeepEqual(hit.Locations, expected) {
+		t.Errorf("expected iterator hit with locations %v, got %v", expected, hit)
+	}
+}
+
+func TestRawTermQuery(t *testing.T) {
+	nameMapping := NewTextFieldMapping()
+	nameMapping.IncludeRaw = true
+	mapping := NewIndexMapping()
+	mapping.DefaultMapping.AddFieldMappingsAt("name", nameMapping)
+
+	index, err := New("", mapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	docs := map[string]string{
+		"upper":  "Apple",
+		"lower":  "apple",
+		"phrase": "Apple Pie",
+	}
+	for id, name := range docs {
+		err = index.Index(id, map[string]interface{}{"name": name})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		query    Query
+		expected []string
+	}{
+		// the analyzed field does not tell the cases apart
+		{query: NewTermQuery("apple").SetField("name"), expected: []string{"lower", "phrase", "upper"}},
+		{query: NewMatchQuery("Apple").SetField("name"), expected: []string{"lower", "phrase", "upper"}},
+		{query: NewRawTermQuery("Apple").SetField("name"), expected: []string{"upper"}},
+		{query: NewRawTermQuery("apple").SetField("name"), expected: []string{"lower"}},
+		{query: NewRawTermQuery("Apple Pie").SetField("name"), expected: []string{"phrase"}},
+		{query: NewRawTermQuery("APPLE").SetField("name"), expected: []string{}},
+		// the raw sub-field is left out of _all
+		{query: NewTermQuery("Apple"), expected: []string{}},
+	}
+	for i, test := range tests {
+		res, err := index.Search(NewSearchRequest(test.query))
+		if err != nil {
+			t.Fatalf("test %d: %v", i, err)
+		}
+		ids := []string{}
+		for _, hit := range res.Hits {
+			ids = append(ids, hit.ID)
+		}
+		sort.Strings(ids)
+		if !reflect.DeepEqual(ids, test.expected) {
+			t.Errorf("test %d: expected %v, got %v", i, test.expected, ids)
+		}
+	}
+
+	// the raw sub-field is not stored
+	doc, err := index.Document("upper")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range doc.Fields {
+		if field.Name() == "name"+RawFieldSuffix {
+			t.Errorf("expected raw sub-field not to be stored")
+		}
+	}
+}
+
+func TestDateHistogramFacet(t *testing.T) {
+	index, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	days := map[string]time.Time{
+		"a": time.Date(2015, 6, 1, 8, 0, 0, 0, time.UTC),
+		"b": time.Date(2015, 6, 1, 20, 0, 0, 0, time.UTC),
+		"c": time.Date(2015, 6, 3, 9, 0, 0, 0, time.UTC),
+		"d": time.Date(2015, 6, 4, 23, 59, 0, 0, time.UTC),
+	}
+	for id, when := range days {
+		err = index.Index(id, map[string]interface{}{"when": when})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	req := NewSearchRequest(NewMatchAllQuery())
+	facet := NewFacetRequest("when", 1)
+	facet.SetDateHistogram("1d", "", true)
+	req.AddFacet("days", facet)
+	res, err := index.Search(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := search.DateHistogramFacets{
+		{Start: "2015-06-01T00:00:00Z", Count: 2},
+		{Start: "2015-06-02T00:00:00Z", Count: 0},
+		{Start: "2015-06-03T00:00:00Z", Count: 1},
+		{Start: "2015-06-04T00:00:00Z", Count: 1},
+	}
+	if !reflect.DeepEqual(res.Facets["days"].DateHistogram, expected) {
+		t.Errorf("expected %v, got %v", expected, res.Facets["days"].DateHistogram)
+	}
+
+	facet.SetDateHistogram("1 day", "", false)
+	_, err = index.Search(req)
+	if err != ErrorUnknownDateHistogramInterval {
+		t.Errorf("expected %v, got %v", ErrorUnknownDateHistogramInterval, err)
+	}
+	facet.SetDateHistogram("1d", "Mars/Olympus_Mons", false)
+	_, err = index.Search(req)
+	if err != ErrorUnknownTimeZone {
+		t.Errorf("expected %v, got %v", ErrorUnknownTimeZone, err)
+	}
+}
+
+func TestFunctionScoreQuery(t *testing.T) {
+	index, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	docs := map[string]map[string]interface{}{
+		"a": {"name": "beer", "popularity": 5.0},
+		"b": {"name": "beer", "popularity": 50.0},
+		"c": {"name": "beer", "popularity": 0.5},
+		"d": {"name": "beer"},
+		"e": {"name": "wine", "popularity": 500.0},
+	}
+	for id, doc := range docs {
+		err = index.Index(id, doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	popularity := func(d *search.DocumentMatch, fields map[string]interface{}) float64 {
+		if p, ok := fields["popularity"].(float64); ok {
+			return p
+		}
+		return 1
+	}
+	tests := []struct {
+		mode     string
+		fn       searchers.ScoreFunc
+		expected []string
+	}{
+		{
+			mode:     FunctionScoreMultiply,
+			fn:       popularity,
+			expected: []string{"b", "a", "d", "c"},
+		},
+		{
+			mode:     FunctionScoreSum,
+			fn:       popularity,
+			expected: []string{"b", "a", "d", "c"},
+		},
+		// the least popular first
+		{
+			mode: FunctionScoreReplace,
+			fn: func(d *search.DocumentMatch, fields map[string]interface{}) float64 {
+				return 1 / popularity(d, fields)
+			},
+			expected: []string{"c", "d", "a", "b"},
+		},
+	}
+	for _, test := range tests {
+		q := NewFunctionScoreQuery(NewMatchQuery("beer").SetField("name"), []string{"popularity"}, test.fn).
+			SetMode(test.mode)
+		res, err := index.Search(NewSearchRequest(q))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := []string{}
+		for _, hit := range res.Hits {
+			ids = append(ids, hit.ID)
+		}
+		if !reflect.DeepEqual(ids, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.mode, test.expected, ids)
+		}
+	}
+
+	q := NewFunctionScoreQuery(NewMatchQuery("beer").SetField("name"), nil, popularity).SetMode("max")
+	_, err = index.Search(NewSearchRequest(q))
+	if err != ErrorUnknownFunctionScoreMode {
+		t.Errorf("expected %v, got %v", ErrorUnknownFunctionScoreMode, err)
+	}
+}
+
+func TestStoredFieldsTyped(t *testing.T) {
+	index, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	born := time.Date(1968, time.June, 12, 10, 30, 0, 0, time.UTC)
+	err = index.Index("a", map[string]interface{}{
+		"name":   "marty",
+		"age":    17.0,
+		"born":   born,
+		"active": true,
+		"flags":  []interface{}{true, false},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := NewSearchRequest(NewTermQuery("marty").SetField("name"))
+	req.Fields = []string{"*"}
+	res, err := index.Search(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Hits) != 1 {
+		t.Fatalf("expected 1 hit, got %d", len(res.Hits))
+	}
+	fields := res.Hits[0].Fields
+	if name, ok := fields["name"].(string); !ok || name != "marty" {
+		t.Errorf("expected name string marty, got %#v", fields["name"])
+	}
+	if age, ok := fields["age"].(float64); !ok || age != 17 {
+		t.Errorf("expected age float64 17, got %#v", fields["age"])
+	}
+	if stored, ok := fields["born"].(time.Time); !ok || !stored.Equal(born) {
+		t.Errorf("expected born time.Time %v, got %#v", born, fields["born"])
+	}
+	if active, ok := fields["active"].(bool); !ok || !active {
+		t.Errorf("expected active bool true, got %#v", fields["active"])
+	}
+	if !reflect.DeepEqual(fields["flags"], []interface{}{true, false}) {
+		t.Errorf("expected flags [true false], got %#v", fields["flags"])
+	}
+
+	value, err := index.DocumentField("a", "active")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != true {
+		t.Errorf("expected document field active true, got %#v", value)
+	}
+
+	// booleans are indexed as the terms T and F
+	res, err = index.Search(NewSearchRequest(NewTermQuery("T").SetField("active")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 1 {
+		t.Errorf("expected 1 hit for active true, got %d", res.Total)
+	}
+}
+
+func TestSearchMinScore(t *testing.T) {
+	index, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	for n := 1; n <= 6; n++ {
+		err = index.Index(strconv.Itoa(n), map[string]interface{}{
+			"text": strings.Repeat("beer ", n) + "wine",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	req := NewSearchRequest(NewMatchQuery("beer").SetField("text"))
+	all, err := index.Search(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if all.Total != 6 {
+		t.Fatalf("expected 6 matches, got %d", all.Total)
+	}
+	minScore := all.Hits[2].Score
+	expected := make([]string, 0)
+	for _, hit := range all.Hits {
+		if hit.Score >= minScore {
+			expected = append(expected, hit.ID)
+		}
+	}
+	if len(expected) == len(all.Hits) {
+		t.Fatalf("expected some hits to score below %f", minScore)
+	}
+
+	var minScoreReq SearchRequest
+	err = json.Unmarshal([]byte(fmt.Sprintf(`{"query": {"match": "beer", "field": "text"}, "size": 10, "min_score": %v}`, minScore)), &minScoreReq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if minScoreReq.MinScore != minScore {
+		t.Fatalf("expected min score %f, got %f", minScore, minScoreReq.MinScore)
+	}
+	res, err := index.Search(&minScoreReq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != uint64(len(expected)) {
+		t.Errorf("expected total %d, got %d", len(expected), res.Total)
+	}
+	got := make([]string, 0, len(res.Hits))
+	for _, hit := range res.Hits {
+		if hit.Score < minScore {
+			t.Errorf("expected hit %s scoring %f to be dropped", hit.ID, hit.Score)
+		}
+		got = append(got, hit.ID)
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected hits %v, got %v", expected, got)
+	}
+}
+
+func TestBooleanQueryFilter(t *testing.T) {
+	index, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	docs := map[string]map[string]interface{}{
+		"a": {"desc": "beer", "color": "red"},
+		"b": {"desc": "beer beer beer", "color": "red"},
+		"c": {"desc": "beer beer", "color": "blue"},
+		"d": {"desc": "wine", "color": "red"},
+		"e": {"desc": "beer wine", "color": "red"},
+	}
+	for id, doc := range docs {
+		err = index.Index(id, doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	hitScores := func(q Query) map[string]float64 {
+		res, err := index.Search(NewSearchRequest(q))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rv := make(map[string]float64, len(res.Hits))
+		for _, hit := range res.Hits {
+			rv[hit.ID] = hit.Score
+		}
+		return rv
+	}
+	hitIDs := func(scores map[string]float64) []string {
+		rv := make([]string, 0, len(scores))
+		for id := range scores {
+			rv = append(rv, id)
+		}
+		sort.Strings(rv)
+		return rv
+	}
+
+	beer := NewMatchQuery("beer").SetField("desc")
+	red := NewTermQuery("red").SetField("color")
+
+	must := hitScores(NewBooleanQuery([]Query{beer, red}, nil, nil))
+	filtered := NewBooleanQuery([]Query{beer}, nil, nil)
+	filtered.AddFilter(red)
+	filter := hitScores(filtered)
+	expected := []string{"a", "b", "e"}
+	if !reflect.DeepEqual(hitIDs(must), expected) {
+		t.Errorf("expected must hits %v, got %v", expected, hitIDs(must))
+	}
+	if !reflect.DeepEqual(hitIDs(filter), expected) {
+		t.Errorf("expected filter hits %v, got %v", expected, hitIDs(filter))
+	}
+
+	// the filter does not change the scores of the matches
+	unfiltered := hitScores(NewBooleanQuery([]Query{beer}, nil, nil))
+	for id, score := range filter {
+		if score != unfiltered[id] {
+			t.Errorf("expected %s to score %f as without the filter, got %f", id, unfiltered[id], score)
+		}
+	}
+
+	// with only filters, every match has the same score
+	onlyFilter := NewBooleanQuery(nil, nil, []Query{NewTermQuery("wine").SetField("desc")})
+	onlyFilter.AddFilter(red)
+	scores := hitScores(onlyFilter)
+	if !reflect.DeepEqual(hitIDs(scores), []string{"a", "b"}) {
+		t.Errorf("expected filter only hits [a b], got %v", hitIDs(scores))
+	}
+	if scores["a"] != scores["b"] {
+		t.Errorf("expected filter only hits to score the same, got %v", scores)
+	}
+
+	// filters parse from JSON
+	q, err := ParseQuery([]byte(`{
+		"must": {"conjuncts": [{"match": "beer", "field": "desc"}]},
+		"filter": {"conjuncts": [{"term": "red", "field": "color"}]}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed := hitScores(q)
+	if !reflect.DeepEqual(parsed, filter) {
+		t.Errorf("expected parsed query hits %v, got %v", filter, parsed)
+	}
+}
+
+func TestMaxClauseCount(t *testing.T) {
+	index, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	for n, word := range []string{"bean", "bear", "beat", "beer", "bees"} {
+		err = index.Index(strconv.Itoa(n), map[string]interface{}{"word": word})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	search := func(q Query, maxClauseCount int, truncate bool) ([]string, error) {
+		req := NewSearchRequest(q)
+		req.MaxClauseCount = maxClauseCount
+		req.TruncateClauses = truncate
+		res, err := index.Search(req)
+		if err != nil {
+			return nil, err
+		}
+		ids := make([]string, 0, len(res.Hits))
+		for _, hit := range res.Hits {
+			ids = append(ids, hit.ID)
+		}
+		sort.Strings(ids)
+		return ids, nil
+	}
+
+	overExpanding := []Query{
+		NewPrefixQuery("be").SetField("word"),
+		NewWildcardQuery("be*").SetField("word"),
+		NewRegexpQuery("be.*").SetField("word"),
+		NewFuzzyQuery("beer").SetFuzziness(2).SetField("word"),
+	}
+	for _, q := range overExpanding {
+		_, err = search(q, 3, false)
+		if err != searchers.ErrTooManyClauses {
+			t.Errorf("%#v: expected %v, got %v", q, searchers.ErrTooManyClauses, err)
+		}
+		// truncating keeps the first terms of the dictionary
+		ids, err := search(q, 3, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(ids, []string{"0", "1", "2"}) {
+			t.Errorf("%#v: expected truncated hits [0 1 2], got %v", q, ids)
+		}
+	}
+
+	// queries expanding up to the limit are unaffected
+	ids, err := search(NewPrefixQuery("bee").SetField("word"), 2, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ids, []string{"3", "4"}) {
+		t.Errorf("expected hits [3 4], got %v", ids)
+	}
+
+	// the global limit applies to requests without one
+	defer func(maxClauseCount int) {
+		Config.MaxClauseCount = maxClauseCount
+	}(Config.MaxClauseCount)
+	Config.MaxClauseCount = 4
+	_, err = search(NewPrefixQuery("be").SetField("word"), 0, false)
+	if err != searchers.ErrTooManyClauses {
+		t.Errorf("expected %v, got %v", searchers.ErrTooManyClauses, err)
+	}
+	ids, err = search(NewPrefixQuery("be").SetField("word"), 5, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 5 {
+		t.Errorf("expected the request limit to allow 5 hits, got %v", ids)
+	}
+
+	// and to deleting by query
+	_, err = index.DeleteByQuery(NewPrefixQuery("be").SetField("word"))
+	if err != searchers.ErrTooManyClauses {
+		t.Errorf("expected %v, got %v", searchers.ErrTooManyClauses, err)
+	}
+	Config.MaxClauseCount = 0
+
+	// the request limit applies through an alias
+	other, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	req := NewSearchRequest(NewPrefixQuery("be").SetField("word"))
+	req.MaxClauseCount = 3
+	_, err = NewIndexAlias(index, other).Search(req)
+	if err != searchers.ErrTooManyClauses {
+		t.Errorf("expected %v through an alias, got %v", searchers.ErrTooManyClauses, err)
+	}
+}
@@ -0,0 +1,284 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package bleve
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/blevesearch/bleve/document"
+	"github.com/blevesearch/bleve/index"
+	"github.com/blevesearch/bleve/index/store"
+	"github.com/blevesearch/bleve/index/upside_down"
+	"github.com/blevesearch/bleve/search"
+	"github.com/blevesearch/bleve/search/searchers"
+)
+
+// TTLSweepIntervalConfigKey is the index config key enabling the
+// background sweeper which purges expired documents.  The value is
+// either a duration string ("30s") or a number of seconds.
+const TTLSweepIntervalConfigKey = "ttl_sweep_interval"
+
+// expiry timestamps are stored as internal values under this prefix,
+// followed by the document id
+var ttlInternalPrefix = []byte("_ttl:")
+
+// timeNow is replaced in tests to simulate the passage of time
+var timeNow = time.Now
+
+func ttlInternalKey(id string) []byte {
+	rv := make([]byte, len(ttlInternalPrefix)+len(id))
+	copy(rv, ttlInternalPrefix)
+	copy(rv[len(ttlInternalPrefix):], id)
+	return rv
+}
+
+func encodeExpiry(t time.Time) []byte {
+	rv := make([]byte, 8)
+	binary.BigEndian.PutUint64(rv, uint64(t.UnixNano()))
+	return rv
+}
+
+func decodeExpiry(b []byte) (time.Time, error) {
+	if len(b) != 8 {
+		return time.Time{}, fmt.Errorf("invalid expiry value of length %d", len(b))
+	}
+	return time.Unix(0, int64(binary.BigEndian.Uint64(b))), nil
+}
+
+// IndexWithTTL indexes the object with the specified identifier,
+// like Index, but the document expires once ttl has elapsed.
+// Expired documents are excluded from search results, and are
+// removed from the index by the sweeper when one is configured.
+// Re-indexing the document with Index or deleting it clears the
+// expiry.
+func (i *indexImpl) IndexWithTTL(id string, data interface{}, ttl time.Duration) (err error) {
+	defer func() {
+		if err == nil {
+			i.notifyIndex(id)
+		}
+	}()
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	if !i.open {
+		return ErrorIndexClosed
+	}
+	if i.readOnly {
+		return ErrorIndexReadOnly
+	}
+
+	doc := document.NewDocument(id)
+	err = i.m.mapDocument(doc, data)
+	if err != nil {
+		return err
+	}
+	ib := index.NewBatch()
+	ib.Update(doc)
+	ib.SetInternal(ttlInternalKey(id), encodeExpiry(timeNow().Add(ttl)))
+	err = i.i.Batch(ib)
+	if err != nil {
+		return err
+	}
+	atomic.StoreInt32(&i.hasTTL, 1)
+	return nil
+}
+
+func (i *indexImpl) ttlEnabled() bool {
+	return atomic.LoadInt32(&i.hasTTL) != 0
+}
+
+// loadTTLState checks whether any expiry metadata was persisted
+// by a previous session, and starts the sweeper if configured
+func (i *indexImpl) loadTTLState(config map[string]interface{}) error {
+	kvreader, err := i.s.Reader()
+	if err != nil {
+		return err
+	}
+	it := store.PrefixIterator(kvreader, upside_down.NewInternalRow(ttlInternalPrefix, nil).Key())
+	_, _, valid := it.Current()
+	it.Close()
+	err = kvreader.Close()
+	if err != nil {
+		return err
+	}
+	if valid {
+		atomic.StoreInt32(&i.hasTTL, 1)
+	}
+
+	interval, err := ttlSweepInterval(config)
+	if err != nil {
+		return err
+	}
+	// a read-only index leaves the sweeping to the
+	// process writing to it
+	if interval > 0 && !i.readOnly {
+		i.sweepStop = make(chan struct{})
+		go i.runSweeper(interval, i.sweepStop)
+	}
+	return nil
+}
+
+func ttlSweepInterval(config map[string]interface{}) (time.Duration, error) {
+	v, ok := config[TTLSweepIntervalConfigKey]
+	if !ok {
+		return 0, nil
+	}
+	switch v := v.(type) {
+	case string:
+		return time.ParseDuration(v)
+	case float64:
+		return time.Duration(v * float64(time.Second)), nil
+	case int:
+		return time.Duration(v) * time.Second, nil
+	case time.Duration:
+		return v, nil
+	}
+	return 0, fmt.Errorf("invalid %s: %v", TTLSweepIntervalConfigKey, v)
+}
+
+func (i *indexImpl) runSweeper(interval time.Duration, stop chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			_, err := i.sweepExpired()
+			if err != nil && err != ErrorIndexClosed {
+				logger.Printf("ttl sweep failed: %v", err)
+			}
+		}
+	}
+}
+
+// sweepExpired deletes all documents whose expiry has passed,
+// returning the number of documents removed
+func (i *indexImpl) sweepExpired() (int, error) {
+	var done []*index.Batch
+	defer func() {
+		i.notifyIndexBatches(done)
+	}()
+
+	now := timeNow()
+	ids, err := i.expiredIDs(now)
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+
+	// the documents may have been re-indexed since, so their
+	// expiry is checked again while holding off all writes
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	if !i.open {
+		return 0, ErrorIndexClosed
+	}
+
+	ids, err = i.stillExpired(ids, now)
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+	ib := index.NewBatch()
+	for _, id := range ids {
+		ib.Delete(id)
+		ib.DeleteInternal(ttlInternalKey(id))
+	}
+	err = i.i.Batch(ib)
+	if err != nil {
+		return 0, err
+	}
+	done = append(done, ib)
+	return len(ids), nil
+}
+
+// stillExpired returns the identifiers among ids of the
+// documents whose expiry is still not after now
+func (i *indexImpl) stillExpired(ids []string, now time.Time) ([]string, error) {
+	kvreader, err := i.s.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer kvreader.Close()
+
+	var rv []string
+	for _, id := range ids {
+		v, err := kvreader.Get(upside_down.NewInternalRow(ttlInternalKey(id), nil).Key())
+		if err != nil {
+			return nil, err
+		}
+		if v == nil {
+			continue
+		}
+		expiry, err := decodeExpiry(v)
+		if err == nil && !expiry.After(now) {
+			rv = append(rv, id)
+		}
+	}
+	return rv, nil
+}
+
+// expiredIDs returns the identifiers of the documents whose
+// expiry is not after now
+func (i *indexImpl) expiredIDs(now time.Time) ([]string, error) {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	if !i.open {
+		return nil, ErrorIndexClosed
+	}
+	if !i.ttlEnabled() {
+		return nil, nil
+	}
+
+	kvreader, err := i.s.Reader()
+	if err != nil {
+		return nil, err
+	}
+	keyPrefix := upside_down.NewInternalRow(ttlInternalPrefix, nil).Key()
+	var rv []string
+	it := store.PrefixIterator(kvreader, keyPrefix)
+	for k, v, valid := it.Current(); valid; k, v, valid = it.Current() {
+		expiry, err := decodeExpiry(v)
+		if err == nil && !expiry.After(now) {
+			rv = append(rv, string(k[len(keyPrefix):]))
+		}
+		it.Next()
+	}
+	it.Close()
+	err = kvreader.Close()
+	if err != nil {
+		return nil, err
+	}
+	return rv, nil
+}
+
+// expiredFilter builds a search filter rejecting documents whose
+// expiry, as seen by the provided reader, has passed
+func expiredFilter(indexReader index.IndexReader) searchers.FilterFunc {
+	now := timeNow()
+	return func(d *search.DocumentMatch) (bool, error) {
+		val, err := indexReader.GetInternal(ttlInternalKey(d.ID))
+		if err != nil {
+			return false, err
+		}
+		if val == nil {
+			return true, nil
+		}
+		expiry, err := decodeExpiry(val)
+		if err != nil {
+			return true, nil
+		}
+		return expiry.After(now), nil
+	}
+}
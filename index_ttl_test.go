@@ -0,0 +1,300 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package bleve
+
+import (
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	m   sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func useFakeClock() (*fakeClock, func()) {
+	clock := &fakeClock{now: time.Unix(1420070400, 0)}
+	timeNow = clock.Now
+	return clock, func() {
+		timeNow = time.Now
+	}
+}
+
+func searchHitIDs(t *testing.T, idx Index, term string) map[string]bool {
+	req := NewSearchRequest(NewTermQuery(term))
+	res, err := idx.Search(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rv := make(map[string]bool, len(res.Hits))
+	for _, hit := range res.Hits {
+		rv[hit.ID] = true
+	}
+	if uint64(len(rv)) != res.Total {
+		t.Errorf("expected total %d to match hits %d", res.Total, len(rv))
+	}
+	return rv
+}
+
+func TestIndexWithTTL(t *testing.T) {
+	defer os.RemoveAll("testidx")
+	clock, restore := useFakeClock()
+	defer restore()
+
+	index, err := New("testidx", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = index.Index("forever", map[string]interface{}{"name": "marty"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = index.IndexWithTTL("short", map[string]interface{}{"name": "marty"}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = index.IndexWithTTL("long", map[string]interface{}{"name": "marty"}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// re-indexing without a ttl clears the expiry
+	err = index.IndexWithTTL("cleared", map[string]interface{}{"name": "marty"}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = index.Index("cleared", map[string]interface{}{"name": "marty"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hits := searchHitIDs(t, index, "marty")
+	if len(hits) != 4 {
+		t.Errorf("expected 4 hits before expiry, got %v", hits)
+	}
+
+	clock.Advance(2 * time.Minute)
+	hits = searchHitIDs(t, index, "marty")
+	if len(hits) != 3 || hits["short"] {
+		t.Errorf("expected 'short' to be filtered, got %v", hits)
+	}
+
+	// expiry metadata survives reopen
+	err = index.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	index, err = Open("testidx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	hits = searchHitIDs(t, index, "marty")
+	if len(hits) != 3 || hits["short"] {
+		t.Errorf("expected 'short' to be filtered after reopen, got %v", hits)
+	}
+
+	// expired docs are still present until swept
+	docCount, err := index.DocCount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if docCount != 4 {
+		t.Errorf("expected doc count 4 before sweep, got %d", docCount)
+	}
+
+	clock.Advance(time.Hour)
+	swept, err := index.(*indexImpl).sweepExpired()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if swept != 2 {
+		t.Errorf("expected 2 docs swept, got %d", swept)
+	}
+	docCount, err = index.DocCount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if docCount != 2 {
+		t.Errorf("expected doc count 2 after sweep, got %d", docCount)
+	}
+	hits = searchHitIDs(t, index, "marty")
+	if len(hits) != 2 || !hits["forever"] || !hits["cleared"] {
+		t.Errorf("expected 'forever' and 'cleared', got %v", hits)
+	}
+	val, err := index.GetInternal(ttlInternalKey("short"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val != nil {
+		t.Errorf("expected expiry metadata to be removed, got %v", val)
+	}
+}
+
+func TestIndexWithTTLSweeper(t *testing.T) {
+	defer os.RemoveAll("testidx")
+	clock, restore := useFakeClock()
+	defer restore()
+
+	index, err := NewUsing("testidx", NewIndexMapping(), Config.DefaultKVStore, map[string]interface{}{
+		TTLSweepIntervalConfigKey: "5ms",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	err = index.IndexWithTTL("a", map[string]interface{}{"name": "marty"}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(2 * time.Minute)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		docCount, err := index.DocCount()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if docCount == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected sweeper to purge expired doc, doc count still %d", docCount)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestMemIndexWithTTLSweeper(t *testing.T) {
+	clock, restore := useFakeClock()
+	defer restore()
+
+	index, err := NewUsing("", NewIndexMapping(), Config.DefaultKVStore, map[string]interface{}{
+		TTLSweepIntervalConfigKey: "5ms",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	err = index.IndexWithTTL("a", map[string]interface{}{"name": "marty"}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(2 * time.Minute)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		docCount, err := index.DocCount()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if docCount == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected sweeper to purge expired doc, doc count still %d", docCount)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestSweepExpiredKeepsReindexed(t *testing.T) {
+	clock, restore := useFakeClock()
+	defer restore()
+
+	idx, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+	index := idx.(*indexImpl)
+
+	for _, id := range []string{"a", "b"} {
+		err = index.IndexWithTTL(id, map[string]interface{}{"name": "marty"}, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	clock.Advance(2 * time.Minute)
+
+	ids, err := index.expiredIDs(clock.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 2 {
+		t.Fatalf("expected 2 expired documents, got %v", ids)
+	}
+
+	// b is re-indexed once found expired, before the sweep deletes
+	err = index.Index("b", map[string]interface{}{"name": "marty"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids, err = index.stillExpired(ids, clock.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 1 || ids[0] != "a" {
+		t.Errorf("expected only a to still be expired, got %v", ids)
+	}
+
+	count, err := index.sweepExpired()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("expected 1 document swept, got %d", count)
+	}
+	hits := searchHitIDs(t, index, "marty")
+	if len(hits) != 1 || !hits["b"] {
+		t.Errorf("expected only b, got %v", hits)
+	}
+}
+
+func TestTTLSweepIntervalConfig(t *testing.T) {
+	tests := []struct {
+		config map[string]interface{}
+		result time.Duration
+		err    bool
+	}{
+		{map[string]interface{}{}, 0, false},
+		{map[string]interface{}{TTLSweepIntervalConfigKey: "1m"}, time.Minute, false},
+		{map[string]interface{}{TTLSweepIntervalConfigKey: 1.5}, 1500 * time.Millisecond, false},
+		{map[string]interface{}{TTLSweepIntervalConfigKey: "bogus"}, 0, true},
+		{map[string]interface{}{TTLSweepIntervalConfigKey: true}, 0, true},
+	}
+
+	for _, test := range tests {
+		actual, err := ttlSweepInterval(test.config)
+		if (err != nil) != test.err {
+			t.Errorf("expected error %t, got %v for %v", test.err, err, test.config)
+		}
+		if actual != test.result {
+			t.Errorf("expected %v, got %v for %v", test.result, actual, test.config)
+		}
+	}
+}
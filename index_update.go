@@ -0,0 +1,302 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package bleve
+
+import (
+	"github.com/blevesearch/bleve/document"
+	"github.com/blevesearch/bleve/index"
+)
+
+// Update changes some fields of an indexed document
+// without re-supplying the whole document.  The current
+// document is rebuilt from its stored fields, the
+// provided fields are merged in, and the result is
+// re-indexed.  Field names are paths, so "a.b" sets
+// b inside the object a.  Setting a field to nil
+// removes it.
+//
+// Only stored fields survive an update, fields mapped
+// with store disabled are dropped from the document.
+// Any expiry set by IndexWithTTL is kept.
+//
+// Updates are atomic with respect to each other, the
+// document is read and written while holding a lock taken
+// by every update, so concurrent updates of a document all
+// apply.  Index, Delete and Batch do not take the lock: a
+// write of the document concurrent with an update may be
+// lost.
+//
+// Returns ErrorDocumentNotFound if there is no document
+// with the specified identifier.
+func (i *indexImpl) Update(id string, fields map[string]interface{}) (err error) {
+	defer func() {
+		if err == nil {
+			i.notifyIndex(id)
+		}
+	}()
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	if !i.open {
+		return ErrorIndexClosed
+	}
+	if i.readOnly {
+		return ErrorIndexReadOnly
+	}
+
+	i.updateMutex.Lock()
+	defer i.updateMutex.Unlock()
+
+	ib, err := i.updateBatch([]string{id}, fields)
+	if err != nil {
+		return err
+	}
+	if len(ib.IndexOps) == 0 {
+		return ErrorDocumentNotFound
+	}
+	return i.i.Batch(ib)
+}
+
+// UpdateByQuery applies Update to all the documents
+// matching the query, returning the number updated.
+// The matches are found before any document is
+// changed, so updating a document such that it still
+// matches does not update it again.  Documents are
+// updated in batches, if a batch fails the documents
+// updated by the previous batches are still counted.
+func (i *indexImpl) UpdateByQuery(q Query, fields map[string]interface{}) (uint64, error) {
+	var done []*index.Batch
+	defer func() {
+		i.notifyIndexBatches(done)
+	}()
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+
+	if !i.open {
+		return 0, ErrorIndexClosed
+	}
+	if i.readOnly {
+		return 0, ErrorIndexReadOnly
+	}
+
+	i.updateMutex.Lock()
+	defer i.updateMutex.Unlock()
+
+	ids, err := i.matchingIDs(q, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	var updated uint64
+	for len(ids) > 0 {
+		batchIDs := ids
+		if len(batchIDs) > byQueryBatchSize {
+			batchIDs = batchIDs[:byQueryBatchSize]
+		}
+		ids = ids[len(batchIDs):]
+
+		ib, err := i.updateBatch(batchIDs, fields)
+		if err != nil {
+			return updated, err
+		}
+		err = i.i.Batch(ib)
+		if err != nil {
+			return updated, err
+		}
+		done = append(done, ib)
+		updated += uint64(len(ib.IndexOps))
+	}
+	return updated, nil
+}
+
+// updateBatch builds a batch updating the documents,
+// skipping those deleted since they were matched
+func (i *indexImpl) updateBatch(ids []string, fields map[string]interface{}) (*index.Batch, error) {
+	indexReader, err := i.i.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer indexReader.Close()
+
+	ib := index.NewBatch()
+	for _, id := range ids {
+		doc, err := i.updatedDocument(indexReader, id, fields)
+		if err != nil {
+			return nil, err
+		}
+		if doc != nil {
+			ib.Update(doc)
+		}
+	}
+	return ib, nil
+}
+
+// updatedDocument maps the stored document merged with
+// the fields, or returns nil if the document does not exist
+func (i *indexImpl) updatedDocument(indexReader index.IndexReader, id string, fields map[string]interface{}) (*document.Document, error) {
+	source, err := storedSource(indexReader, id)
+	if err != nil {
+		return nil, err
+	}
+	if source == nil {
+		return nil, nil
+	}
+	mergeFields(source, fields)
+
+	doc := document.NewDocument(id)
+	err = i.m.mapDocument(doc, source)
+	if err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// storedSource rebuilds the object a document was indexed
+// from, or returns nil if the document does not exist
+func storedSource(indexReader index.IndexReader, id string) (map[string]interface{}, error) {
+	stored, err := indexReader.Document(id)
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil {
+		return nil, nil
+	}
+	arrayPaths, err := indexReader.DocumentField(id, index.ArrayPathsField)
+	if err != nil {
+		return nil, err
+	}
+	return documentSource(stored, arrayPaths), nil
+}
+
+// documentSource rebuilds the object a document was
+// indexed from out of its stored fields, placing the
+// values of the arrays at their array positions, using
+// the index.ArrayPathsField fields to tell which paths
+// hold arrays
+func documentSource(doc *document.Document, arrayPaths []document.Field) map[string]interface{} {
+	arrays := make(map[string]int)
+	for _, field := range arrayPaths {
+		arrays[string(field.Value())]++
+	}
+
+	var rv interface{} = make(map[string]interface{})
+	for _, field := range doc.Fields {
+		var val interface{}
+		switch field := field.(type) {
+		case *document.TextField:
+			val = string(field.Value())
+		case *document.NumericField:
+			n, err := field.Number()
+			if err == nil {
+				val = n
+			}
+		case *document.DateTimeField:
+			d, err := field.DateTime()
+			if err == nil {
+				val = d
+			}
+		case *document.IPField:
+			ip, err := field.IP()
+			if err == nil {
+				val = ip.String()
+			}
+		case *document.BooleanField:
+			b, err := field.Boolean()
+			if err == nil {
+				val = b
+			}
+		}
+		if val == nil {
+			continue
+		}
+		rv = placeValue(rv, decodePath(field.Name()), 0, 0, field.ArrayPositions(), arrays, val)
+	}
+	return compactArrays(rv).(map[string]interface{})
+}
+
+// placeValue returns node, the value at path[:depth], with
+// val placed at the rest of path.  levels is the number of
+// arrays at path[:depth] still to descend into, each one
+// taking the next of the arrayPositions.  Positions left
+// over at the end of path, from documents indexed without
+// their array paths, append val to an array.
+func placeValue(node interface{}, path []string, depth, levels int, arrayPositions []uint64, arrays map[string]int, val interface{}) interface{} {
+	if levels > 0 && len(arrayPositions) > 0 {
+		array, _ := node.([]interface{})
+		arrayPosition := int(arrayPositions[0])
+		for len(array) <= arrayPosition {
+			array = append(array, nil)
+		}
+		array[arrayPosition] = placeValue(array[arrayPosition], path, depth, levels-1, arrayPositions[1:], arrays, val)
+		return array
+	}
+	if depth == len(path) {
+		if len(arrayPositions) > 0 {
+			array, _ := node.([]interface{})
+			return append(array, val)
+		}
+		return val
+	}
+	obj, ok := node.(map[string]interface{})
+	if !ok {
+		obj = make(map[string]interface{})
+	}
+	name := path[depth]
+	obj[name] = placeValue(obj[name], path, depth+1, arrays[encodePath(path[:depth+1])], arrayPositions, arrays, val)
+	return obj
+}
+
+// compactArrays removes from the arrays below node the
+// elements no stored field was placed at
+func compactArrays(node interface{}) interface{} {
+	switch node := node.(type) {
+	case map[string]interface{}:
+		for name, val := range node {
+			node[name] = compactArrays(val)
+		}
+	case []interface{}:
+		rv := node[:0]
+		for _, val := range node {
+			if val != nil {
+				rv = append(rv, compactArrays(val))
+			}
+		}
+		return rv
+	}
+	return node
+}
+
+// mergeFields sets each field path of source to its
+// value, removing the fields whose value is nil
+func mergeFields(source map[string]interface{}, fields map[string]interface{}) {
+	for name, val := range fields {
+		path := decodePath(name)
+		parent := objectForPath(source, path[:len(path)-1])
+		if val == nil {
+			delete(parent, path[len(path)-1])
+		} else {
+			parent[path[len(path)-1]] = val
+		}
+	}
+}
+
+// objectForPath returns the object at path inside obj,
+// creating it, or replacing any other value, as needed
+func objectForPath(obj map[string]interface{}, path []string) map[string]interface{} {
+	for _, name := range path {
+		child, ok := obj[name].(map[string]interface{})
+		if !ok {
+			child = make(map[string]interface{})
+			obj[name] = child
+		}
+		obj = child
+	}
+	return obj
+}
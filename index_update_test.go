@@ -0,0 +1,288 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package bleve
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func indexedSource(t *testing.T, idx Index, id string) map[string]interface{} {
+	i, _, err := idx.Advanced()
+	if err != nil {
+		t.Fatal(err)
+	}
+	indexReader, err := i.Reader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer indexReader.Close()
+	source, err := storedSource(indexReader, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if source == nil {
+		t.Fatalf("expected document '%s' to exist", id)
+	}
+	return source
+}
+
+func TestIndexUpdate(t *testing.T) {
+	defer os.RemoveAll("testidx")
+
+	index, err := New("testidx", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = index.Index("a", map[string]interface{}{
+		"name":   "marty",
+		"status": "active",
+		"age":    19.0,
+		"tags":   []interface{}{"gopher"},
+		"address": map[string]interface{}{
+			"city": "hill valley",
+			"zip":  "95420",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	min := 0.0
+	ageQuery := NewNumericRangeQuery(&min, nil).SetField("age")
+	res, err := index.Search(NewSearchRequest(ageQuery))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 1 {
+		t.Fatalf("expected age to be indexed, got %d hits", res.Total)
+	}
+
+	err = index.Update("a", map[string]interface{}{
+		"status":       "retired",
+		"address.city": "twin pines",
+		"age":          nil,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = index.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	index, err = Open("testidx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	expected := map[string]interface{}{
+		"name":   "marty",
+		"status": "retired",
+		"tags":   []interface{}{"gopher"},
+		"address": map[string]interface{}{
+			"city": "twin pines",
+			"zip":  "95420",
+		},
+	}
+	actual := indexedSource(t, index, "a")
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+
+	hits := searchHitIDs(t, index, "retired")
+	if !hits["a"] {
+		t.Errorf("expected updated field to be searchable, got %v", hits)
+	}
+	hits = searchHitIDs(t, index, "active")
+	if len(hits) != 0 {
+		t.Errorf("expected old value to be gone, got %v", hits)
+	}
+	hits = searchHitIDs(t, index, "marty")
+	if !hits["a"] {
+		t.Errorf("expected untouched field to be searchable, got %v", hits)
+	}
+	res, err = index.Search(NewSearchRequest(ageQuery))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 0 {
+		t.Errorf("expected removed field to be gone, got %d hits", res.Total)
+	}
+
+	err = index.Update("missing", map[string]interface{}{"status": "retired"})
+	if err != ErrorDocumentNotFound {
+		t.Errorf("expected %v, got %v", ErrorDocumentNotFound, err)
+	}
+	docCount, err := index.DocCount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if docCount != 1 {
+		t.Errorf("expected doc count 1, got %d", docCount)
+	}
+}
+
+func TestIndexUpdateByQuery(t *testing.T) {
+	defer func(size int) {
+		byQueryBatchSize = size
+	}(byQueryBatchSize)
+	// force several batches
+	byQueryBatchSize = 2
+
+	index, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	for _, id := range []string{"a", "b", "c", "d", "e"} {
+		err = index.Index(id, map[string]interface{}{"name": id, "status": "active"})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = index.Index("f", map[string]interface{}{"name": "f", "status": "retired"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the updated documents still match the query
+	query := NewMatchAllQuery()
+	updated, err := index.UpdateByQuery(query, map[string]interface{}{"reviewed": "yes"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated != 6 {
+		t.Errorf("expected 6 documents updated, got %d", updated)
+	}
+
+	updated, err = index.UpdateByQuery(NewTermQuery("active").SetField("status"),
+		map[string]interface{}{"status": "retired"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated != 5 {
+		t.Errorf("expected 5 documents updated, got %d", updated)
+	}
+
+	hits := searchHitIDs(t, index, "retired")
+	if len(hits) != 6 {
+		t.Errorf("expected all documents retired, got %v", hits)
+	}
+	hits = searchHitIDs(t, index, "yes")
+	if len(hits) != 6 {
+		t.Errorf("expected earlier update to be kept, got %v", hits)
+	}
+	actual := indexedSource(t, index, "c")
+	expected := map[string]interface{}{"name": "c", "status": "retired", "reviewed": "yes"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
+
+func TestIndexUpdateConcurrent(t *testing.T) {
+	index, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	err = index.Index("a", map[string]interface{}{"name": "marty"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// each update sets its own field, none must be lost
+	var wg sync.WaitGroup
+	expected := map[string]interface{}{"name": "marty"}
+	for n := 0; n < 10; n++ {
+		field := "f" + strconv.Itoa(n)
+		expected[field] = "set"
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := index.Update("a", map[string]interface{}{field: "set"})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	actual := indexedSource(t, index, "a")
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
+
+func TestIndexUpdateNested(t *testing.T) {
+	var mapping IndexMapping
+	err := json.Unmarshal([]byte(`{
+		"default_mapping": {
+			"properties": {
+				"items": {"nested": true}
+			}
+		}
+	}`), &mapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	index, err := New("", &mapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	items := []interface{}{
+		map[string]interface{}{"color": "red"},
+		map[string]interface{}{"color": "blue", "size": "large"},
+	}
+	err = index.Index("a", map[string]interface{}{
+		"name":  "shirt",
+		"items": items,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = index.Update("a", map[string]interface{}{"name": "t-shirt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]interface{}{
+		"name":  "t-shirt",
+		"items": items,
+	}
+	actual := indexedSource(t, index, "a")
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+
+	// the values of each item must stay together
+	res, err := index.Search(NewSearchRequest(NewNestedQuery("items", []Query{
+		NewMatchQuery("red").SetField("items.color"),
+		NewMatchQuery("large").SetField("items.size"),
+	})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 0 {
+		t.Errorf("expected no hits, got %v", res.Hits)
+	}
+}
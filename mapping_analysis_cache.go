@@ -0,0 +1,103 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package bleve
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/blevesearch/bleve/analysis"
+)
+
+// analysisCache keeps the token streams of the query text
+// most recently analyzed, by analyzer name and text, the
+// least recently used dropped once there are more than the
+// QueryAnalysisCacheSize of the mapping.  Streams are copied
+// going in and out, so the tokens of a cached stream are
+// never shared with a caller.
+type analysisCache struct {
+	mutex   sync.Mutex
+	entries map[analysisCacheKey]*list.Element
+	lru     *list.List
+}
+
+type analysisCacheKey struct {
+	analyzer string
+	text     string
+}
+
+type analysisCacheEntry struct {
+	key    analysisCacheKey
+	tokens analysis.TokenStream
+}
+
+func newAnalysisCache() *analysisCache {
+	return &analysisCache{
+		entries: make(map[analysisCacheKey]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+func (c *analysisCache) get(key analysisCacheKey) (analysis.TokenStream, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.lru.MoveToFront(e)
+	return copyTokenStream(e.Value.(*analysisCacheEntry).tokens), true
+}
+
+func (c *analysisCache) add(key analysisCacheKey, tokens analysis.TokenStream, size int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if e, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(e)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&analysisCacheEntry{
+		key:    key,
+		tokens: copyTokenStream(tokens),
+	})
+	for c.lru.Len() > size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*analysisCacheEntry).key)
+	}
+}
+
+func copyTokenStream(tokens analysis.TokenStream) analysis.TokenStream {
+	rv := make(analysis.TokenStream, len(tokens))
+	for i, token := range tokens {
+		t := *token
+		t.Term = append([]byte(nil), token.Term...)
+		rv[i] = &t
+	}
+	return rv
+}
+
+// analyzeQueryText analyzes the text of a query with the
+// analyzer, which the mapping knows as analyzerName,
+// through the cache when QueryAnalysisCacheSize is set.
+func (im *IndexMapping) analyzeQueryText(analyzerName string, analyzer *analysis.Analyzer, text string) analysis.TokenStream {
+	size := im.QueryAnalysisCacheSize
+	if size <= 0 || im.analysisCache == nil {
+		return analyzer.Analyze([]byte(text))
+	}
+	key := analysisCacheKey{analyzer: analyzerName, text: text}
+	tokens, ok := im.analysisCache.get(key)
+	if ok {
+		return tokens
+	}
+	tokens = analyzer.Analyze([]byte(text))
+	im.analysisCache.add(key, tokens, size)
+	return tokens
+}
@@ -0,0 +1,104 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package bleve
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestQueryAnalysisCache(t *testing.T) {
+	m := NewIndexMapping()
+	m.QueryAnalysisCacheSize = 2
+	analyzer := m.analyzerNamed("standard")
+
+	texts := []string{"The Quick Brown Fox", "jumps over", "the lazy dog"}
+	for _, text := range texts {
+		for n := 0; n < 2; n++ {
+			expected := analyzer.Analyze([]byte(text))
+			got := m.analyzeQueryText("standard", analyzer, text)
+			if !reflect.DeepEqual(got, expected) {
+				t.Errorf("analysis %d of %q: expected %v, got %v", n, text, expected, got)
+			}
+		}
+	}
+	if m.analysisCache.lru.Len() != 2 {
+		t.Errorf("expected 2 cached analyses, got %d", m.analysisCache.lru.Len())
+	}
+
+	// the tokens returned are the caller's to change
+	tokens := m.analyzeQueryText("standard", analyzer, "the lazy dog")
+	tokens[0].Term[0] = 'X'
+	tokens[0].Position = 42
+	tokens = tokens[:1]
+	expected := analyzer.Analyze([]byte("the lazy dog"))
+	got := m.analyzeQueryText("standard", analyzer, "the lazy dog")
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected cached analysis %v, got %v", expected, got)
+	}
+
+	// the same text analyzed by another analyzer is cached apart
+	keyword := m.analyzerNamed("keyword")
+	got = m.analyzeQueryText("keyword", keyword, "the lazy dog")
+	if len(got) != 1 || string(got[0].Term) != "the lazy dog" {
+		t.Errorf("expected keyword analysis, got %v", got)
+	}
+
+	// without a size nothing is cached
+	m = NewIndexMapping()
+	m.analyzeQueryText("standard", analyzer, "the lazy dog")
+	if m.analysisCache.lru.Len() != 0 {
+		t.Errorf("expected no cached analyses, got %d", m.analysisCache.lru.Len())
+	}
+}
+
+func TestSearchQueryAnalysisCache(t *testing.T) {
+	m := NewIndexMapping()
+	m.QueryAnalysisCacheSize = 10
+	index, err := New("", m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	err = index.Index("a", map[string]interface{}{"name": "marty mcfly"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for n := 0; n < 3; n++ {
+		res, err := index.Search(NewSearchRequest(NewMatchQuery("Marty").SetField("name")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Total != 1 {
+			t.Errorf("search %d: expected 1 hit, got %d", n, res.Total)
+		}
+	}
+}
+
+func benchmarkQueryAnalysis(b *testing.B, cacheSize int) {
+	m := NewIndexMapping()
+	m.QueryAnalysisCacheSize = cacheSize
+	analyzer := m.analyzerNamed("standard")
+	text := "The Running Brewers were brewing their strongest ales, in the old breweries of the town"
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.analyzeQueryText("standard", analyzer, text)
+	}
+}
+
+func BenchmarkQueryAnalysisUncached(b *testing.B) {
+	benchmarkQueryAnalysis(b, 0)
+}
+
+func BenchmarkQueryAnalysisCached(b *testing.B) {
+	benchmarkQueryAnalysis(b, 100)
+}
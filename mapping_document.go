@@ -31,9 +31,14 @@ import (
 // If not explicitly mapped, default mapping operations
 // are used.  To disable this automatic handling, set
 // Dynamic to false.
+//
+// A sub-section holding an array of objects can be
+// marked Nested, so that NestedQuery can require its
+// clauses to match within the same object of the array.
 type DocumentMapping struct {
 	Enabled         bool                        `json:"enabled"`
 	Dynamic         bool                        `json:"dynamic"`
+	Nested          bool                        `json:"nested,omitempty"`
 	Properties      map[string]*DocumentMapping `json:"properties,omitempty"`
 	Fields          []*FieldMapping             `json:"fields,omitempty"`
 	DefaultAnalyzer string                      `json:"default_analyzer"`
@@ -67,7 +72,7 @@ func (dm *DocumentMapping) validate(cache *registry.Cache) error {
 			}
 		}
 		switch field.Type {
-		case "text", "datetime", "number":
+		case "text", "datetime", "number", "ip", "boolean":
 		default:
 			return fmt.Errorf("unknown field type: '%s'", field.Type)
 		}
@@ -88,6 +93,36 @@ func (dm *DocumentMapping) documentMappingForPath(path string) *DocumentMapping
 	return current
 }
 
+// fieldMappingForPath returns a field mapping producing the
+// field at path, either for the property at path or, when
+// renamed, for one of its siblings
+func (dm *DocumentMapping) fieldMappingForPath(path string) *FieldMapping {
+	pathElements := decodePath(path)
+	parent := dm
+	if len(pathElements) > 1 {
+		parent = dm.documentMappingForPath(encodePath(pathElements[:len(pathElements)-1]))
+		if parent == nil {
+			return nil
+		}
+	}
+	name := pathElements[len(pathElements)-1]
+	if property, ok := parent.Properties[name]; ok {
+		for _, fm := range property.Fields {
+			if fm.Name == "" || fm.Name == name {
+				return fm
+			}
+		}
+	}
+	for _, property := range parent.Properties {
+		for _, fm := range property.Fields {
+			if fm.Name == name {
+				return fm
+			}
+		}
+	}
+	return nil
+}
+
 // NewDocumentMapping returns a new document mapping
 // with all the default values.
 func NewDocumentMapping() *DocumentMapping {
@@ -159,6 +194,7 @@ func (dm *DocumentMapping) UnmarshalJSON(data []byte) error {
 	var tmp struct {
 		Enabled         *bool                       `json:"enabled"`
 		Dynamic         *bool                       `json:"dynamic"`
+		Nested          bool                        `json:"nested"`
 		Properties      map[string]*DocumentMapping `json:"properties"`
 		Fields          []*FieldMapping             `json:"fields"`
 		DefaultAnalyzer string                      `json:"default_analyzer"`
@@ -178,6 +214,7 @@ func (dm *DocumentMapping) UnmarshalJSON(data []byte) error {
 		dm.Dynamic = *tmp.Dynamic
 	}
 
+	dm.Nested = tmp.Nested
 	dm.DefaultAnalyzer = tmp.DefaultAnalyzer
 
 	if tmp.Properties != nil {
@@ -195,6 +232,20 @@ func (dm *DocumentMapping) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// isNested returns true if the sub-section at path is
+// nested
+func (dm *DocumentMapping) isNested(path []string) bool {
+	current := dm
+	for _, pathElement := range path {
+		var ok bool
+		current, ok = current.Properties[pathElement]
+		if !ok {
+			return false
+		}
+	}
+	return current.Nested
+}
+
 func (dm *DocumentMapping) defaultAnalyzerName(path []string) string {
 	rv := ""
 	current := dm
@@ -216,6 +267,12 @@ func (dm *DocumentMapping) walkDocument(data interface{}, path []string, indexes
 	typ := val.Type()
 	switch typ.Kind() {
 	case reflect.Map:
+		// the values of an object start new paths
+		arrayLevels := context.arrayLevels
+		context.arrayLevels = 0
+		defer func() {
+			context.arrayLevels = arrayLevels
+		}()
 		// FIXME can add support for other map keys in the future
 		if typ.Key().Kind() == reflect.String {
 			for _, key := range val.MapKeys() {
@@ -225,6 +282,11 @@ func (dm *DocumentMapping) walkDocument(data interface{}, path []string, indexes
 			}
 		}
 	case reflect.Struct:
+		arrayLevels := context.arrayLevels
+		context.arrayLevels = 0
+		defer func() {
+			context.arrayLevels = arrayLevels
+		}()
 		for i := 0; i < val.NumField(); i++ {
 			field := typ.Field(i)
 			fieldName := field.Name
@@ -242,6 +304,16 @@ func (dm *DocumentMapping) walkDocument(data interface{}, path []string, indexes
 			}
 		}
 	case reflect.Slice, reflect.Array:
+		if len(path) > 0 && val.Len() > 0 {
+			pathString := encodePath(path)
+			context.arrayLevels++
+			defer func() {
+				context.arrayLevels--
+			}()
+			if context.arrayLevels > context.arrayPaths[pathString] {
+				context.arrayPaths[pathString] = context.arrayLevels
+			}
+		}
 		for i := 0; i < val.Len(); i++ {
 			if val.Index(i).CanInterface() {
 				fieldVal := val.Index(i).Interface()
@@ -257,6 +329,9 @@ func (dm *DocumentMapping) walkDocument(data interface{}, path []string, indexes
 }
 
 func (dm *DocumentMapping) processProperty(property interface{}, path []string, indexes []uint64, context *walkContext) {
+	if context.err != nil {
+		return
+	}
 	pathString := encodePath(path)
 	// look to see if there is a mapping for this field
 	subDocMapping := dm.documentMappingForPath(pathString)
@@ -265,6 +340,12 @@ func (dm *DocumentMapping) processProperty(property interface{}, path []string,
 	if subDocMapping != nil && !subDocMapping.Enabled {
 		return
 	}
+	if subDocMapping != nil && subDocMapping.Nested {
+		context.nested++
+		defer func() {
+			context.nested--
+		}()
+	}
 
 	propertyValue := reflect.ValueOf(property)
 	propertyType := propertyValue.Type()
@@ -286,6 +367,9 @@ func (dm *DocumentMapping) processProperty(property interface{}, path []string,
 				if err != nil {
 					// index as text
 					fieldMapping := NewTextFieldMapping()
+					if context.im.DefaultDynamicStringAnalyzer != "" && context.dm.defaultAnalyzerName(path) == "" {
+						fieldMapping.Analyzer = context.im.DefaultDynamicStringAnalyzer
+					}
 					fieldMapping.processString(propertyValueString, pathString, path, indexes, context)
 				} else {
 					// index as datetime
@@ -306,6 +390,18 @@ func (dm *DocumentMapping) processProperty(property interface{}, path []string,
 			fieldMapping := NewNumericFieldMapping()
 			fieldMapping.processFloat64(propertyValFloat, pathString, path, indexes, context)
 		}
+	case reflect.Bool:
+		propertyValBool := propertyValue.Bool()
+		if subDocMapping != nil {
+			// index by explicit mapping
+			for _, fieldMapping := range subDocMapping.Fields {
+				fieldMapping.processBoolean(propertyValBool, pathString, path, indexes, context)
+			}
+		} else {
+			// automatic indexing behavior
+			fieldMapping := NewBooleanFieldMapping()
+			fieldMapping.processBoolean(propertyValBool, pathString, path, indexes, context)
+		}
 	case reflect.Struct:
 		switch property := property.(type) {
 		case time.Time:
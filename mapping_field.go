@@ -10,25 +10,79 @@
 package bleve
 
 import (
+	"fmt"
+	"net"
 	"time"
 
 	"github.com/blevesearch/bleve/analysis"
+	"github.com/blevesearch/bleve/analysis/analyzers/keyword_analyzer"
 	"github.com/blevesearch/bleve/document"
 )
 
 // A FieldMapping describes how a specific item
 // should be put into the index.
+//
+// Boost multiplies the weight of the terms of the
+// field when searching, together with the boost of
+// the query searching it, so a field boost of 2 and
+// a query boost of 3 weigh the terms by 6.  The
+// default of 0 means no boost.
+//
+// PositionIncrementGap separates the token positions of
+// consecutive values of a text field indexed from an
+// array, so phrases with slop smaller than the gap never
+// span two values.  The default of 0 leaves a gap of
+// DefaultPositionIncrementGap.  A negative gap disables it,
+// the positions of each value then start over.
+//
+// Compressed stores the value of a stored field compressed,
+// trading some CPU when indexing and loading documents for
+// less space taken by large values.  It does not change how
+// the field is indexed.
+//
+// IncludeRaw, for a text field, also indexes each value
+// whole and unchanged, in a sub-field named after the
+// field with RawFieldSuffix, for exact case-sensitive
+// matches with NewRawTermQuery.  The sub-field is not
+// stored nor included in the composite field _all.
+//
+// Validator, if set, is called with each value of the
+// field before it is indexed.  An error aborts indexing
+// the document.  Validators are not part of the JSON
+// representation, so they must be set again on the
+// mapping of an index which is reopened.
 type FieldMapping struct {
-	Name               string `json:"name,omitempty"`
-	Type               string `json:"type,omitempty"`
-	Analyzer           string `json:"analyzer,omitempty"`
-	Store              bool   `json:"store,omitempty"`
-	Index              bool   `json:"index,omitempty"`
-	IncludeTermVectors bool   `json:"include_term_vectors,omitempty"`
-	IncludeInAll       bool   `json:"include_in_all,omitempty"`
-	DateFormat         string `json:"date_format,omitempty"`
+	Name                 string         `json:"name,omitempty"`
+	Type                 string         `json:"type,omitempty"`
+	Analyzer             string         `json:"analyzer,omitempty"`
+	Store                bool           `json:"store,omitempty"`
+	Index                bool           `json:"index,omitempty"`
+	IncludeTermVectors   bool           `json:"include_term_vectors,omitempty"`
+	IncludeInAll         bool           `json:"include_in_all,omitempty"`
+	Compressed           bool           `json:"compressed,omitempty"`
+	DateFormat           string         `json:"date_format,omitempty"`
+	Boost                float64        `json:"boost,omitempty"`
+	PositionIncrementGap int            `json:"position_increment_gap,omitempty"`
+	IncludeRaw           bool           `json:"include_raw,omitempty"`
+	Validator            FieldValidator `json:"-"`
 }
 
+// DefaultPositionIncrementGap is the gap between the token
+// positions of the values of a text field indexed from an
+// array, when its mapping does not set one.
+const DefaultPositionIncrementGap = 100
+
+// A FieldValidator checks the value of a field before it
+// is indexed, returning an error to reject the document.
+// Text values are passed as strings, numbers as float64,
+// dates as time.Time, IP addresses as net.IP and
+// booleans as bool.
+type FieldValidator func(field string, value interface{}) error
+
+// RawFieldSuffix ends the name of the raw sub-field of a
+// text field mapped with IncludeRaw.
+const RawFieldSuffix = ".raw"
+
 // NewTextFieldMapping returns a default field mapping for text
 func NewTextFieldMapping() *FieldMapping {
 	return &FieldMapping{
@@ -60,6 +114,28 @@ func NewDateTimeFieldMapping() *FieldMapping {
 	}
 }
 
+// NewIPFieldMapping returns a default field mapping for
+// IPv4 and IPv6 addresses.  A document with a value which
+// is not an address fails to be indexed.
+func NewIPFieldMapping() *FieldMapping {
+	return &FieldMapping{
+		Type:         "ip",
+		Store:        true,
+		Index:        true,
+		IncludeInAll: true,
+	}
+}
+
+// NewBooleanFieldMapping returns a default field mapping for booleans
+func NewBooleanFieldMapping() *FieldMapping {
+	return &FieldMapping{
+		Type:         "boolean",
+		Store:        true,
+		Index:        true,
+		IncludeInAll: true,
+	}
+}
+
 // Options returns the indexing options for this field.
 func (fm *FieldMapping) Options() document.IndexingOptions {
 	var rv document.IndexingOptions
@@ -72,20 +148,34 @@ func (fm *FieldMapping) Options() document.IndexingOptions {
 	if fm.IncludeTermVectors {
 		rv |= document.IncludeTermVectors
 	}
+	if fm.Compressed {
+		rv |= document.CompressStoredField
+	}
 	return rv
 }
 
 func (fm *FieldMapping) processString(propertyValueString string, pathString string, path []string, indexes []uint64, context *walkContext) {
 	fieldName := getFieldName(pathString, path, fm)
-	options := fm.Options()
+	options := fm.walkOptions(context)
 	if fm.Type == "text" {
+		if !fm.validate(fieldName, propertyValueString, context) {
+			return
+		}
 		analyzer := fm.analyzerForField(path, context)
 		field := document.NewTextFieldCustom(fieldName, indexes, []byte(propertyValueString), options, analyzer)
+		field.SetPositionIncrementGap(fm.positionIncrementGap())
 		context.doc.AddField(field)
 
 		if !fm.IncludeInAll {
 			context.excludedFromAll = append(context.excludedFromAll, fieldName)
 		}
+		if fm.IncludeRaw {
+			rawFieldName := fieldName + RawFieldSuffix
+			rawAnalyzer := context.im.analyzerNamed(keyword_analyzer.Name)
+			rawField := document.NewTextFieldCustom(rawFieldName, indexes, []byte(propertyValueString), document.IndexField, rawAnalyzer)
+			context.doc.AddField(rawField)
+			context.excludedFromAll = append(context.excludedFromAll, rawFieldName)
+		}
 	} else if fm.Type == "datetime" {
 		dateTimeFormat := context.im.DefaultDateTimeParser
 		if fm.DateFormat != "" {
@@ -98,13 +188,37 @@ func (fm *FieldMapping) processString(propertyValueString string, pathString str
 				fm.processTime(parsedDateTime, pathString, path, indexes, context)
 			}
 		}
+	} else if fm.Type == "ip" {
+		ip := net.ParseIP(propertyValueString)
+		if ip == nil {
+			if context.err == nil {
+				context.err = fmt.Errorf("invalid IP address '%s' for field '%s'", propertyValueString, fieldName)
+			}
+			return
+		}
+		if !fm.validate(fieldName, ip, context) {
+			return
+		}
+		field, err := document.NewIPFieldWithIndexingOptions(fieldName, indexes, ip, options)
+		if err != nil {
+			context.err = err
+			return
+		}
+		context.doc.AddField(field)
+
+		if !fm.IncludeInAll {
+			context.excludedFromAll = append(context.excludedFromAll, fieldName)
+		}
 	}
 }
 
 func (fm *FieldMapping) processFloat64(propertyValFloat float64, pathString string, path []string, indexes []uint64, context *walkContext) {
 	fieldName := getFieldName(pathString, path, fm)
 	if fm.Type == "number" {
-		options := fm.Options()
+		if !fm.validate(fieldName, propertyValFloat, context) {
+			return
+		}
+		options := fm.walkOptions(context)
 		field := document.NewNumericFieldWithIndexingOptions(fieldName, indexes, propertyValFloat, options)
 		context.doc.AddField(field)
 
@@ -117,7 +231,10 @@ func (fm *FieldMapping) processFloat64(propertyValFloat float64, pathString stri
 func (fm *FieldMapping) processTime(propertyValueTime time.Time, pathString string, path []string, indexes []uint64, context *walkContext) {
 	fieldName := getFieldName(pathString, path, fm)
 	if fm.Type == "datetime" {
-		options := fm.Options()
+		if !fm.validate(fieldName, propertyValueTime, context) {
+			return
+		}
+		options := fm.walkOptions(context)
 		field, err := document.NewDateTimeFieldWithIndexingOptions(fieldName, indexes, propertyValueTime, options)
 		if err == nil {
 			context.doc.AddField(field)
@@ -131,6 +248,66 @@ func (fm *FieldMapping) processTime(propertyValueTime time.Time, pathString stri
 	}
 }
 
+func (fm *FieldMapping) processBoolean(propertyValueBool bool, pathString string, path []string, indexes []uint64, context *walkContext) {
+	fieldName := getFieldName(pathString, path, fm)
+	if fm.Type == "boolean" {
+		if !fm.validate(fieldName, propertyValueBool, context) {
+			return
+		}
+		options := fm.walkOptions(context)
+		field := document.NewBooleanFieldWithIndexingOptions(fieldName, indexes, propertyValueBool, options)
+		context.doc.AddField(field)
+
+		if !fm.IncludeInAll {
+			context.excludedFromAll = append(context.excludedFromAll, fieldName)
+		}
+	}
+}
+
+// positionIncrementGap returns the gap between values.
+func (fm *FieldMapping) positionIncrementGap() int {
+	if fm.PositionIncrementGap == 0 {
+		return DefaultPositionIncrementGap
+	}
+	if fm.PositionIncrementGap < 0 {
+		return 0
+	}
+	return fm.PositionIncrementGap
+}
+
+// walkOptions returns the indexing options for a value
+// found while walking a document.  Values inside nested
+// objects keep their term vectors, which NestedQuery
+// needs to tell the objects apart.
+func (fm *FieldMapping) walkOptions(context *walkContext) document.IndexingOptions {
+	rv := fm.Options()
+	if context.nested > 0 {
+		rv |= document.IncludeTermVectors
+	}
+	return rv
+}
+
+// validate runs the validators of the index mapping and
+// of the field mapping on a value, recording the first
+// failure in the context.  It reports whether the value
+// should be indexed.
+func (fm *FieldMapping) validate(fieldName string, value interface{}, context *walkContext) bool {
+	if context.err != nil {
+		return false
+	}
+	for _, validator := range []FieldValidator{context.im.Validator, fm.Validator} {
+		if validator == nil {
+			continue
+		}
+		err := validator(fieldName, value)
+		if err != nil {
+			context.err = fmt.Errorf("invalid value for field '%s': %v", fieldName, err)
+			return false
+		}
+	}
+	return true
+}
+
 func (fm *FieldMapping) analyzerForField(path []string, context *walkContext) *analysis.Analyzer {
 	analyzerName := context.dm.defaultAnalyzerName(path)
 	if analyzerName == "" {
@@ -11,10 +11,13 @@ package bleve
 
 import (
 	"encoding/json"
+	"sort"
 
 	"github.com/blevesearch/bleve/analysis"
 	"github.com/blevesearch/bleve/document"
+	"github.com/blevesearch/bleve/index"
 	"github.com/blevesearch/bleve/registry"
+	"github.com/blevesearch/bleve/search/scorers"
 )
 
 const defaultTypeField = "_type"
@@ -24,6 +27,12 @@ const defaultAnalyzer = "standard"
 const defaultDateTimeParser = "dateTimeOptional"
 const defaultByteArrayConverter = "json"
 
+// Scoring models of an IndexMapping
+const (
+	ScoringModelTFIDF = "tfidf"
+	ScoringModelBM25  = "bm25"
+)
+
 type customAnalysis struct {
 	CharFilters     map[string]map[string]interface{} `json:"char_filters,omitempty"`
 	Tokenizers      map[string]map[string]interface{} `json:"tokenizers,omitempty"`
@@ -92,6 +101,29 @@ func newCustomAnalysis() *customAnalysis {
 // DocumentMapping is selected by the type.
 // If no mapping was determined for that type,
 // a DefaultMapping will be used.
+//
+// ScoringModel selects how matches are scored, TF-IDF by
+// default, or BM25 with the parameters BM25K1 and BM25B.
+// Like the rest of the mapping it is fixed when the index
+// is created, so changing it requires reindexing into a new
+// index.  BM25 also relies on the field lengths recorded at
+// indexing time, which indexes built by earlier versions
+// lack until their documents are reindexed.
+//
+// Validator, if set, is called with the value of every
+// field of a document before it is indexed, in addition
+// to the Validator of the field mapping.
+//
+// DefaultDynamicStringAnalyzer, if set, is used in place of
+// DefaultAnalyzer for string fields without a mapping, for
+// example "keyword" to index each of their values as a
+// single term.  The default analyzers of document mappings
+// still apply to the fields below them.
+//
+// QueryAnalysisCacheSize, if above 0, keeps the tokens of
+// up to this many query texts most recently analyzed, so
+// hot queries skip analyzing the same text again.  The
+// text of documents being indexed is never cached.
 type IndexMapping struct {
 	TypeMapping           map[string]*DocumentMapping `json:"types,omitempty"`
 	DefaultMapping        *DocumentMapping            `json:"default_mapping"`
@@ -102,7 +134,17 @@ type IndexMapping struct {
 	DefaultField          string                      `json:"default_field"`
 	ByteArrayConverter    string                      `json:"byte_array_converter"`
 	CustomAnalysis        *customAnalysis             `json:"analysis,omitempty"`
-	cache                 *registry.Cache
+	ScoringModel          string                      `json:"scoring_model,omitempty"`
+	BM25K1                float64                     `json:"bm25_k1"`
+	BM25B                 float64                     `json:"bm25_b"`
+	Validator             FieldValidator              `json:"-"`
+
+	DefaultDynamicStringAnalyzer string `json:"default_dynamic_string_analyzer,omitempty"`
+
+	QueryAnalysisCacheSize int `json:"query_analysis_cache_size,omitempty"`
+
+	cache         *registry.Cache
+	analysisCache *analysisCache
 }
 
 // AddCustomCharFilter defines a custom char filter for use in this mapping
@@ -177,7 +219,10 @@ func NewIndexMapping() *IndexMapping {
 		DefaultField:          defaultField,
 		ByteArrayConverter:    defaultByteArrayConverter,
 		CustomAnalysis:        newCustomAnalysis(),
+		BM25K1:                scorers.DefaultBM25K1,
+		BM25B:                 scorers.DefaultBM25B,
 		cache:                 registry.NewCache(),
+		analysisCache:         newAnalysisCache(),
 	}
 }
 
@@ -188,6 +233,12 @@ func (im *IndexMapping) validate() error {
 	if err != nil {
 		return err
 	}
+	if im.DefaultDynamicStringAnalyzer != "" {
+		_, err = im.cache.AnalyzerNamed(im.DefaultDynamicStringAnalyzer)
+		if err != nil {
+			return err
+		}
+	}
 	_, err = im.cache.DateTimeParserNamed(im.DefaultDateTimeParser)
 	if err != nil {
 		return err
@@ -202,6 +253,11 @@ func (im *IndexMapping) validate() error {
 			return err
 		}
 	}
+	switch im.ScoringModel {
+	case "", ScoringModelTFIDF, ScoringModelBM25:
+	default:
+		return ErrorUnknownScoringModel
+	}
 	return nil
 }
 
@@ -230,6 +286,13 @@ func (im *IndexMapping) UnmarshalJSON(data []byte) error {
 		DefaultField          string                      `json:"default_field"`
 		ByteArrayConverter    string                      `json:"byte_array_converter"`
 		CustomAnalysis        *customAnalysis             `json:"analysis"`
+		ScoringModel          string                      `json:"scoring_model"`
+		BM25K1                *float64                    `json:"bm25_k1"`
+		BM25B                 *float64                    `json:"bm25_b"`
+
+		DefaultDynamicStringAnalyzer string `json:"default_dynamic_string_analyzer"`
+
+		QueryAnalysisCacheSize int `json:"query_analysis_cache_size"`
 	}
 	err := json.Unmarshal(data, &tmp)
 	if err != nil {
@@ -237,6 +300,8 @@ func (im *IndexMapping) UnmarshalJSON(data []byte) error {
 	}
 
 	im.cache = registry.NewCache()
+	im.analysisCache = newAnalysisCache()
+	im.QueryAnalysisCacheSize = tmp.QueryAnalysisCacheSize
 
 	im.CustomAnalysis = newCustomAnalysis()
 	if tmp.CustomAnalysis != nil {
@@ -290,6 +355,20 @@ func (im *IndexMapping) UnmarshalJSON(data []byte) error {
 		im.ByteArrayConverter = tmp.ByteArrayConverter
 	}
 
+	im.ScoringModel = tmp.ScoringModel
+
+	im.DefaultDynamicStringAnalyzer = tmp.DefaultDynamicStringAnalyzer
+
+	im.BM25K1 = scorers.DefaultBM25K1
+	if tmp.BM25K1 != nil {
+		im.BM25K1 = *tmp.BM25K1
+	}
+
+	im.BM25B = scorers.DefaultBM25B
+	if tmp.BM25B != nil {
+		im.BM25B = *tmp.BM25B
+	}
+
 	im.DefaultMapping = NewDocumentMapping()
 	if tmp.DefaultMapping != nil {
 		im.DefaultMapping = tmp.DefaultMapping
@@ -349,6 +428,10 @@ func (im *IndexMapping) mapDocument(doc *document.Document, data interface{}) er
 	docMapping := im.mappingForType(docType)
 	walkContext := im.newWalkContext(doc, docMapping)
 	docMapping.walkDocument(data, []string{}, []uint64{}, walkContext)
+	if walkContext.err != nil {
+		return walkContext.err
+	}
+	walkContext.addArrayPaths()
 
 	// see if the _all field was disabled
 	allMapping := docMapping.documentMappingForPath("_all")
@@ -365,6 +448,34 @@ type walkContext struct {
 	im              *IndexMapping
 	dm              *DocumentMapping
 	excludedFromAll []string
+	// nested is the number of nested sub-sections
+	// containing the current value
+	nested int
+	// arrayPaths is the number of levels of arrays
+	// found at each path, arrayLevels the number of
+	// them holding the current value
+	arrayPaths  map[string]int
+	arrayLevels int
+	// err is the first value rejected by a validator
+	err error
+}
+
+// addArrayPaths stores the paths of the arrays found in
+// the document under index.ArrayPathsField
+func (context *walkContext) addArrayPaths() {
+	paths := make([]string, 0, len(context.arrayPaths))
+	for path := range context.arrayPaths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	var arrayPosition uint64
+	for _, path := range paths {
+		for i := 0; i < context.arrayPaths[path]; i++ {
+			field := document.NewTextFieldWithIndexingOptions(index.ArrayPathsField, []uint64{arrayPosition}, []byte(path), document.StoreField)
+			context.doc.AddField(field)
+			arrayPosition++
+		}
+	}
 }
 
 func (im *IndexMapping) newWalkContext(doc *document.Document, dm *DocumentMapping) *walkContext {
@@ -373,6 +484,7 @@ func (im *IndexMapping) newWalkContext(doc *document.Document, dm *DocumentMappi
 		im:              im,
 		dm:              dm,
 		excludedFromAll: []string{},
+		arrayPaths:      make(map[string]int),
 	}
 }
 
@@ -382,8 +494,13 @@ func (im *IndexMapping) newWalkContext(doc *document.Document, dm *DocumentMappi
 // that is returned
 // nil should be an acceptable return value meaning we don't know
 func (im *IndexMapping) analyzerNameForPath(path string) string {
-	// first we look for explicit mapping on the field
-	for _, docMapping := range im.TypeMapping {
+	// first we look for explicit mapping on the field, in
+	// the type mappings and then the default mapping
+	docMappings := im.orderedDocumentMappings()
+	for _, docMapping := range docMappings {
+		if docMapping == nil {
+			continue
+		}
 		pathMapping := docMapping.documentMappingForPath(path)
 		if pathMapping != nil {
 			if len(pathMapping.Fields) > 0 {
@@ -396,16 +513,81 @@ func (im *IndexMapping) analyzerNameForPath(path string) string {
 
 	// next we will try default analyzers for the path
 	pathDecoded := decodePath(path)
-	for _, docMapping := range im.TypeMapping {
+	for _, docMapping := range docMappings {
+		if docMapping == nil {
+			continue
+		}
 		rv := docMapping.defaultAnalyzerName(pathDecoded)
 		if rv != "" {
 			return rv
 		}
 	}
 
+	// strings in fields without a mapping were indexed with
+	// the dynamic string analyzer
+	if im.DefaultDynamicStringAnalyzer != "" && path != im.DefaultField && !im.pathMapped(path) {
+		return im.DefaultDynamicStringAnalyzer
+	}
+
 	return im.DefaultAnalyzer
 }
 
+// pathMapped reports whether a document mapping has
+// explicit field mappings for the path
+func (im *IndexMapping) pathMapped(path string) bool {
+	for _, docMapping := range im.orderedDocumentMappings() {
+		pathMapping := docMapping.documentMappingForPath(path)
+		if pathMapping != nil && len(pathMapping.Fields) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// orderedDocumentMappings returns the type mappings, in
+// order of type name, followed by the default mapping
+func (im *IndexMapping) orderedDocumentMappings() []*DocumentMapping {
+	typeNames := make([]string, 0, len(im.TypeMapping))
+	for typeName := range im.TypeMapping {
+		typeNames = append(typeNames, typeName)
+	}
+	sort.Strings(typeNames)
+	docMappings := make([]*DocumentMapping, 0, len(typeNames)+1)
+	for _, typeName := range typeNames {
+		docMappings = append(docMappings, im.TypeMapping[typeName])
+	}
+	docMappings = append(docMappings, im.DefaultMapping)
+	return docMappings
+}
+
+// fieldBoost returns the boost of the field at path, from
+// the first type mapping setting one, in order of type name,
+// or the default mapping, 1 if none does
+func (im *IndexMapping) fieldBoost(path string) float64 {
+	for _, docMapping := range im.orderedDocumentMappings() {
+		if docMapping == nil {
+			continue
+		}
+		fm := docMapping.fieldMappingForPath(path)
+		if fm != nil && fm.Boost > 0 {
+			return fm.Boost
+		}
+	}
+	return 1.0
+}
+
+// isNested returns true if a document mapping marks the
+// sub-section at path nested
+func (im *IndexMapping) isNested(path string) bool {
+	pathDecoded := decodePath(path)
+	for _, docMapping := range im.orderedDocumentMappings() {
+		if docMapping != nil && docMapping.isNested(pathDecoded) {
+			return true
+		}
+	}
+	return false
+}
+
 func (im *IndexMapping) analyzerNamed(name string) *analysis.Analyzer {
 	analyzer, err := im.cache.AnalyzerNamed(name)
 	if err != nil {
@@ -426,8 +608,13 @@ func (im *IndexMapping) dateTimeParserNamed(name string) analysis.DateTimeParser
 
 func (im *IndexMapping) datetimeParserNameForPath(path string) string {
 
-	// first we look for explicit mapping on the field
-	for _, docMapping := range im.TypeMapping {
+	// first we look for explicit mapping on the field, in
+	// the type mappings and then the default mapping
+	docMappings := im.orderedDocumentMappings()
+	for _, docMapping := range docMappings {
+		if docMapping == nil {
+			continue
+		}
 		pathMapping := docMapping.documentMappingForPath(path)
 		if pathMapping != nil {
 			if len(pathMapping.Fields) > 0 {
@@ -441,6 +628,10 @@ func (im *IndexMapping) datetimeParserNameForPath(path string) string {
 	return im.DefaultDateTimeParser
 }
 
+// AnalyzeText returns the tokens the named analyzer, one
+// registered or defined in this mapping, produces for the
+// text, with their positions and offsets, as they would
+// be indexed.
 func (im *IndexMapping) AnalyzeText(analyzerName string, text []byte) (analysis.TokenStream, error) {
 	analyzer, err := im.cache.AnalyzerNamed(analyzerName)
 	if err != nil {
@@ -12,7 +12,10 @@ package bleve
 import (
 	"encoding/json"
 	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/blevesearch/bleve/analysis"
 )
 
 var mappingSource = []byte(`{
@@ -70,3 +73,93 @@ func TestUnmarshalMappingJSON(t *testing.T) {
 		t.Errorf("expected %#v,\n got %#v", mapping, &indexMapping)
 	}
 }
+
+func TestUnmarshalMappingScoringModel(t *testing.T) {
+	var indexMapping IndexMapping
+	err := json.Unmarshal([]byte(`{"scoring_model": "bm25", "bm25_b": 0}`), &indexMapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if indexMapping.ScoringModel != ScoringModelBM25 {
+		t.Errorf("expected scoring model bm25, got %s", indexMapping.ScoringModel)
+	}
+	// an explicit zero is kept, a missing parameter gets its default
+	if indexMapping.BM25B != 0 {
+		t.Errorf("expected b 0, got %f", indexMapping.BM25B)
+	}
+	if indexMapping.BM25K1 != 1.2 {
+		t.Errorf("expected default k1 1.2, got %f", indexMapping.BM25K1)
+	}
+}
+
+func TestUnmarshalMappingDynamicStringAnalyzer(t *testing.T) {
+	var indexMapping IndexMapping
+	err := json.Unmarshal([]byte(`{"default_dynamic_string_analyzer": "keyword"}`), &indexMapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if indexMapping.DefaultDynamicStringAnalyzer != "keyword" {
+		t.Errorf("expected dynamic string analyzer keyword, got %s", indexMapping.DefaultDynamicStringAnalyzer)
+	}
+	if indexMapping.analyzerNameForPath("status") != "keyword" {
+		t.Errorf("expected unmapped field to use keyword, got %s", indexMapping.analyzerNameForPath("status"))
+	}
+	if indexMapping.analyzerNameForPath(indexMapping.DefaultField) != defaultAnalyzer {
+		t.Errorf("expected default field to use %s, got %s", defaultAnalyzer, indexMapping.analyzerNameForPath(indexMapping.DefaultField))
+	}
+}
+
+func TestMappingAnalyzeText(t *testing.T) {
+	mapping := NewIndexMapping()
+	err := mapping.AddCustomAnalyzer("stemmed", map[string]interface{}{
+		"type":      "custom",
+		"tokenizer": "unicode",
+		"token_filters": []interface{}{
+			"to_lower",
+			"stop_en",
+			"stemmer_porter",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tokens, err := mapping.AnalyzeText("stemmed", []byte("The Quick FOXES jumped"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedTokens := analysis.TokenStream{
+		&analysis.Token{
+			Term:     []byte("quick"),
+			Start:    4,
+			End:      9,
+			Position: 2,
+			Type:     analysis.AlphaNumeric,
+		},
+		&analysis.Token{
+			Term:     []byte("fox"),
+			Start:    10,
+			End:      15,
+			Position: 3,
+			Type:     analysis.AlphaNumeric,
+		},
+		&analysis.Token{
+			Term:     []byte("jump"),
+			Start:    16,
+			End:      22,
+			Position: 4,
+			Type:     analysis.AlphaNumeric,
+		},
+	}
+	if !reflect.DeepEqual(tokens, expectedTokens) {
+		t.Errorf("expected %v, got %v", expectedTokens, tokens)
+	}
+
+	_, err = mapping.AnalyzeText("missing", []byte("text"))
+	if err == nil {
+		t.Fatalf("expected error for unknown analyzer")
+	}
+	if !strings.Contains(err.Error(), "'missing'") {
+		t.Errorf("expected error naming the analyzer, got %v", err)
+	}
+}
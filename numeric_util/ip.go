@@ -0,0 +1,92 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package numeric_util
+
+import (
+	"fmt"
+	"net"
+)
+
+const ShiftStartIP byte = 0x60
+
+// IPPrecisionStep is the number of bits IP addresses are
+// shifted by between their prefix coded terms.
+const IPPrecisionStep uint = 8
+
+// NewPrefixCodedIP encodes the 128-bit IPv6 form of an IP
+// address shifted by a multiple of IPPrecisionStep bits.
+// IPv4 addresses are encoded as IPv4-mapped IPv6
+// addresses, so the two kinds sort together.
+func NewPrefixCodedIP(ip net.IP, shift uint) (PrefixCoded, error) {
+	ip16 := ip.To16()
+	if ip16 == nil {
+		return nil, fmt.Errorf("invalid IP address %v", ip)
+	}
+	if shift%IPPrecisionStep != 0 || shift >= 128 {
+		return nil, fmt.Errorf("cannot shift %d, must be a multiple of %d below 128", shift, IPPrecisionStep)
+	}
+	prefix := ip16[:16-shift/8]
+
+	nChars := (len(prefix)*8 + 6) / 7
+	rv := make(PrefixCoded, nChars+1)
+	rv[0] = ShiftStartIP + byte(shift/IPPrecisionStep)
+
+	// store 7 bits per byte from the lowest bits up, as for
+	// int64 values
+	var bits uint
+	var nBits uint
+	next := len(prefix) - 1
+	for nChars > 0 {
+		if nBits < 7 && next >= 0 {
+			bits |= uint(prefix[next]) << nBits
+			nBits += 8
+			next--
+		}
+		rv[nChars] = byte(bits & 0x7f)
+		nChars--
+		bits >>= 7
+		if nBits > 7 {
+			nBits -= 7
+		} else {
+			nBits = 0
+		}
+	}
+	return rv, nil
+}
+
+func MustNewPrefixCodedIP(ip net.IP, shift uint) PrefixCoded {
+	rv, err := NewPrefixCodedIP(ip, shift)
+	if err != nil {
+		panic(err)
+	}
+	return rv
+}
+
+// IP decodes an IP address encoded without shift.
+func (p PrefixCoded) IP() (net.IP, error) {
+	if len(p) != 20 || p[0] != ShiftStartIP {
+		return nil, fmt.Errorf("invalid prefix coded IP address")
+	}
+	rv := make(net.IP, 16)
+	var bits uint
+	var nBits uint
+	next := 15
+	for i := len(p) - 1; i > 0 && next >= 0; i-- {
+		bits |= uint(p[i]) << nBits
+		nBits += 7
+		if nBits >= 8 {
+			rv[next] = byte(bits)
+			next--
+			bits >>= 8
+			nBits -= 8
+		}
+	}
+	return rv, nil
+}
@@ -0,0 +1,87 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package numeric_util
+
+import (
+	"bytes"
+	"net"
+	"testing"
+)
+
+func TestPrefixCodedIP(t *testing.T) {
+	tests := []string{
+		"0.0.0.0",
+		"10.0.0.1",
+		"192.168.1.255",
+		"255.255.255.255",
+		"::",
+		"::1",
+		"2001:db8::ff00:42:8329",
+		"ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff",
+	}
+	for _, test := range tests {
+		ip := net.ParseIP(test)
+		encoded, err := NewPrefixCodedIP(ip, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, b := range encoded[1:] {
+			if b > 0x7f {
+				t.Errorf("%s: expected 7 bit characters, got %x", test, encoded)
+			}
+		}
+		decoded, err := encoded.IP()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !decoded.Equal(ip) {
+			t.Errorf("expected %s, got %s", ip, decoded)
+		}
+	}
+}
+
+func TestPrefixCodedIPOrder(t *testing.T) {
+	ips := []string{
+		"::",
+		"::1",
+		"::ff",
+		"::100",
+		"10.0.0.1",
+		"10.0.1.0",
+		"192.168.1.1",
+		"2001:db8::1",
+		"ffff::",
+	}
+	for shift := uint(0); shift < 128; shift += IPPrecisionStep {
+		var prev PrefixCoded
+		for _, ip := range ips {
+			encoded := MustNewPrefixCodedIP(net.ParseIP(ip), shift)
+			if bytes.Compare(prev, encoded) > 0 {
+				t.Errorf("shift %d: expected %s to sort after the previous address", shift, ip)
+			}
+			prev = encoded
+		}
+	}
+}
+
+func TestPrefixCodedIPInvalid(t *testing.T) {
+	_, err := NewPrefixCodedIP(net.IP{1, 2, 3}, 0)
+	if err == nil {
+		t.Errorf("expected error for invalid address")
+	}
+	_, err = NewPrefixCodedIP(net.ParseIP("::1"), 4)
+	if err == nil {
+		t.Errorf("expected error for shift not a multiple of the precision step")
+	}
+	_, err = PrefixCoded{ShiftStartInt64, 0x1}.IP()
+	if err == nil {
+		t.Errorf("expected error decoding a number")
+	}
+}
@@ -0,0 +1,107 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package bleve
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/blevesearch/bleve/index/store/cznicb"
+)
+
+// percolatedDocID is the id the document matched by a
+// Percolator is indexed with.
+const percolatedDocID = "_percolated"
+
+// A Percolator searches in reverse: it keeps queries, and
+// reports which of them match a document.  Each document
+// is indexed on its own, in an in-memory index built for
+// it, and every query is searched in that index.  A
+// Percolator is safe for concurrent use.
+type Percolator struct {
+	m *IndexMapping
+
+	mutex   sync.RWMutex
+	queries map[string]Query
+}
+
+// NewPercolator creates a Percolator indexing the
+// documents it matches with the mapping.
+func NewPercolator(mapping *IndexMapping) (*Percolator, error) {
+	err := mapping.validate()
+	if err != nil {
+		return nil, err
+	}
+	return &Percolator{
+		m:       mapping,
+		queries: make(map[string]Query),
+	}, nil
+}
+
+// Register keeps the query under id, replacing any query
+// registered with the same id.  A query which is not
+// valid is not registered.
+func (p *Percolator) Register(id string, q Query) error {
+	err := q.Validate()
+	if err != nil {
+		return err
+	}
+	p.mutex.Lock()
+	p.queries[id] = q
+	p.mutex.Unlock()
+	return nil
+}
+
+// Unregister drops the query registered under id.
+func (p *Percolator) Unregister(id string) {
+	p.mutex.Lock()
+	delete(p.queries, id)
+	p.mutex.Unlock()
+}
+
+// Match returns the ids of the registered queries which
+// match the document, in order.
+func (p *Percolator) Match(doc interface{}) ([]string, error) {
+	i, err := newMemIndexUsing(p.m, cznicb.Name, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer i.Close()
+
+	err = i.Index(percolatedDocID, doc)
+	if err != nil {
+		return nil, err
+	}
+	indexReader, err := i.i.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer indexReader.Close()
+
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	rv := make([]string, 0)
+	for id, q := range p.queries {
+		searcher, err := i.requestSearcher(indexReader, &SearchRequest{Query: q})
+		if err != nil {
+			return nil, err
+		}
+		match, err := searcher.Next()
+		searcher.Close()
+		if err != nil {
+			return nil, err
+		}
+		if match != nil {
+			rv = append(rv, id)
+		}
+	}
+	sort.Strings(rv)
+	return rv, nil
+}
@@ -0,0 +1,82 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package bleve
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPercolator(t *testing.T) {
+	p, err := NewPercolator(NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	five := 5.0
+	queries := map[string]Query{
+		"beer":          NewMatchQuery("beer").SetField("desc"),
+		"wine":          NewMatchQuery("wine").SetField("desc"),
+		"strong":        NewNumericRangeQuery(&five, nil).SetField("abv"),
+		"beer not wine": NewBooleanQuery([]Query{NewMatchQuery("beer").SetField("desc")}, nil, []Query{NewMatchQuery("wine").SetField("desc")}),
+		"ale":           NewQueryStringQuery("+desc:ale"),
+	}
+	for id, q := range queries {
+		err = p.Register(id, q)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		doc      map[string]interface{}
+		expected []string
+	}{
+		{
+			doc:      map[string]interface{}{"desc": "a strong beer", "abv": 7.0},
+			expected: []string{"beer", "beer not wine", "strong"},
+		},
+		{
+			doc:      map[string]interface{}{"desc": "beer or wine", "abv": 4.0},
+			expected: []string{"beer", "wine"},
+		},
+		{
+			doc:      map[string]interface{}{"desc": "pale ale", "abv": 5.0},
+			expected: []string{"ale", "strong"},
+		},
+		{
+			doc:      map[string]interface{}{"desc": "water"},
+			expected: []string{},
+		},
+	}
+	for i, test := range tests {
+		got, err := p.Match(test.doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("test %d: expected %v, got %v", i, test.expected, got)
+		}
+	}
+
+	p.Unregister("beer")
+	got, err := p.Match(tests[0].doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, []string{"beer not wine", "strong"}) {
+		t.Errorf("expected [beer not wine strong] once beer is unregistered, got %v", got)
+	}
+
+	err = p.Register("invalid", NewBooleanQuery(nil, nil, []Query{NewMatchQuery("beer")}))
+	if err != ErrorBooleanQueryNeedsMustOrShould {
+		t.Errorf("expected %v, got %v", ErrorBooleanQueryNeedsMustOrShould, err)
+	}
+}
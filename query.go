@@ -48,6 +48,39 @@ func ParseQuery(input []byte) (Query, error) {
 		}
 		return &rv, nil
 	}
+	_, isConstantScoreQuery := tmp["constant_score"]
+	if isConstantScoreQuery {
+		var rv constantScoreQuery
+		err := json.Unmarshal(input, &rv)
+		if err != nil {
+			return nil, err
+		}
+		return &rv, nil
+	}
+	_, isFieldExistsQuery := tmp["field_exists"]
+	if isFieldExistsQuery {
+		var rv fieldExistsQuery
+		err := json.Unmarshal(input, &rv)
+		if err != nil {
+			return nil, err
+		}
+		if rv.Boost() == 0 {
+			rv.SetBoost(1)
+		}
+		return &rv, nil
+	}
+	_, isFieldMissingQuery := tmp["field_missing"]
+	if isFieldMissingQuery {
+		var rv fieldMissingQuery
+		err := json.Unmarshal(input, &rv)
+		if err != nil {
+			return nil, err
+		}
+		if rv.Boost() == 0 {
+			rv.SetBoost(1)
+		}
+		return &rv, nil
+	}
 	_, isTermQuery := tmp["term"]
 	if isTermQuery {
 		var rv termQuery
@@ -71,6 +104,18 @@ func ParseQuery(input []byte) (Query, error) {
 		}
 		return &rv, nil
 	}
+	_, isMatchPhrasePrefixQuery := tmp["match_phrase_prefix"]
+	if isMatchPhrasePrefixQuery {
+		var rv matchPhrasePrefixQuery
+		err := json.Unmarshal(input, &rv)
+		if err != nil {
+			return nil, err
+		}
+		if rv.Boost() == 0 {
+			rv.SetBoost(1)
+		}
+		return &rv, nil
+	}
 	_, isMatchPhraseQuery := tmp["match_phrase"]
 	if isMatchPhraseQuery {
 		var rv matchPhraseQuery
@@ -86,7 +131,8 @@ func ParseQuery(input []byte) (Query, error) {
 	_, hasMust := tmp["must"]
 	_, hasShould := tmp["should"]
 	_, hasMustNot := tmp["must_not"]
-	if hasMust || hasShould || hasMustNot {
+	_, hasFilter := tmp["filter"]
+	if hasMust || hasShould || hasMustNot || hasFilter {
 		var rv booleanQuery
 		err := json.Unmarshal(input, &rv)
 		if err != nil {
@@ -139,6 +185,19 @@ func ParseQuery(input []byte) (Query, error) {
 		return &rv, nil
 	}
 
+	_, hasDisMax := tmp["dis_max"]
+	if hasDisMax {
+		var rv disjunctionMaxQuery
+		err := json.Unmarshal(input, &rv)
+		if err != nil {
+			return nil, err
+		}
+		if rv.Boost() == 0 {
+			rv.SetBoost(1)
+		}
+		return &rv, nil
+	}
+
 	_, hasSyntaxQuery := tmp["query"]
 	if hasSyntaxQuery {
 		var rv queryStringQuery
@@ -177,6 +236,124 @@ func ParseQuery(input []byte) (Query, error) {
 		}
 		return &rv, nil
 	}
+	_, hasPolygonPoints := tmp["polygon_points"]
+	if hasPolygonPoints {
+		var rv geoBoundingPolygonQuery
+		err := json.Unmarshal(input, &rv)
+		if err != nil {
+			return nil, err
+		}
+		if rv.Boost() == 0 {
+			rv.SetBoost(1)
+		}
+		return &rv, nil
+	}
+	_, hasRegexp := tmp["regexp"]
+	if hasRegexp {
+		var rv regexpQuery
+		err := json.Unmarshal(input, &rv)
+		if err != nil {
+			return nil, err
+		}
+		if rv.Boost() == 0 {
+			rv.SetBoost(1)
+		}
+		return &rv, nil
+	}
+	_, hasNested := tmp["nested"]
+	if hasNested {
+		var rv nestedQuery
+		err := json.Unmarshal(input, &rv)
+		if err != nil {
+			return nil, err
+		}
+		return &rv, nil
+	}
+	_, hasLikeID := tmp["like_id"]
+	_, hasLikeText := tmp["like_text"]
+	if hasLikeID || hasLikeText {
+		var rv moreLikeThisQuery
+		err := json.Unmarshal(input, &rv)
+		if err != nil {
+			return nil, err
+		}
+		if rv.Boost() == 0 {
+			rv.SetBoost(1)
+		}
+		return &rv, nil
+	}
+	_, hasCIDR := tmp["cidr"]
+	if hasCIDR {
+		var rv cidrQuery
+		err := json.Unmarshal(input, &rv)
+		if err != nil {
+			return nil, err
+		}
+		if rv.Boost() == 0 {
+			rv.SetBoost(1)
+		}
+		return &rv, nil
+	}
+	_, hasWildcard := tmp["wildcard"]
+	if hasWildcard {
+		var rv wildcardQuery
+		err := json.Unmarshal(input, &rv)
+		if err != nil {
+			return nil, err
+		}
+		if rv.Boost() == 0 {
+			rv.SetBoost(1)
+		}
+		return &rv, nil
+	}
+	_, hasIDs := tmp["ids"]
+	if hasIDs {
+		var rv docIDQuery
+		err := json.Unmarshal(input, &rv)
+		if err != nil {
+			return nil, err
+		}
+		if rv.Boost() == 0 {
+			rv.SetBoost(1)
+		}
+		return &rv, nil
+	}
+	_, hasMultiMatch := tmp["multi_match"]
+	if hasMultiMatch {
+		var rv multiMatchQuery
+		err := json.Unmarshal(input, &rv)
+		if err != nil {
+			return nil, err
+		}
+		if rv.Boost() == 0 {
+			rv.SetBoost(1)
+		}
+		return &rv, nil
+	}
+	_, hasTokenType := tmp["token_type"]
+	if hasTokenType {
+		var rv tokenTypeQuery
+		err := json.Unmarshal(input, &rv)
+		if err != nil {
+			return nil, err
+		}
+		if rv.Boost() == 0 {
+			rv.SetBoost(1)
+		}
+		return &rv, nil
+	}
+	_, hasRawTerm := tmp["raw_term"]
+	if hasRawTerm {
+		var rv rawTermQuery
+		err := json.Unmarshal(input, &rv)
+		if err != nil {
+			return nil, err
+		}
+		if rv.Boost() == 0 {
+			rv.SetBoost(1)
+		}
+		return &rv, nil
+	}
 	_, hasPrefix := tmp["prefix"]
 	if hasPrefix {
 		var rv prefixQuery
@@ -189,5 +366,29 @@ func ParseQuery(input []byte) (Query, error) {
 		}
 		return &rv, nil
 	}
+	_, hasMatchAll := tmp["match_all"]
+	if hasMatchAll {
+		var rv matchAllQuery
+		err := json.Unmarshal(input, &rv)
+		if err != nil {
+			return nil, err
+		}
+		if rv.Boost() == 0 {
+			rv.SetBoost(1)
+		}
+		return &rv, nil
+	}
+	_, hasMatchNone := tmp["match_none"]
+	if hasMatchNone {
+		var rv matchNoneQuery
+		err := json.Unmarshal(input, &rv)
+		if err != nil {
+			return nil, err
+		}
+		if rv.Boost() == 0 {
+			rv.SetBoost(1)
+		}
+		return &rv, nil
+	}
 	return nil, ErrorUnknownQueryType
 }
@@ -22,6 +22,7 @@ type booleanQuery struct {
 	Must     Query   `json:"must,omitempty"`
 	Should   Query   `json:"should,omitempty"`
 	MustNot  Query   `json:"must_not,omitempty"`
+	Filter   Query   `json:"filter,omitempty"`
 	BoostVal float64 `json:"boost,omitempty"`
 }
 
@@ -85,6 +86,18 @@ func (q *booleanQuery) AddMustNot(m Query) {
 	q.MustNot.(*disjunctionQuery).AddQuery(m)
 }
 
+// AddFilter adds a clause result documents must satisfy,
+// like a must clause, but which takes no part in their
+// scores.  Filter clauses are searched without scoring,
+// so they are cheaper than must clauses.  A query with
+// only filter clauses scores all its matches the same.
+func (q *booleanQuery) AddFilter(m Query) {
+	if q.Filter == nil {
+		q.Filter = NewConjunctionQuery([]Query{})
+	}
+	q.Filter.(*conjunctionQuery).AddQuery(m)
+}
+
 func (q *booleanQuery) Boost() float64 {
 	return q.BoostVal
 }
@@ -97,12 +110,55 @@ func (q *booleanQuery) SetBoost(b float64) Query {
 func (q *booleanQuery) Searcher(i index.IndexReader, m *IndexMapping, explain bool) (search.Searcher, error) {
 	var err error
 
-	var mustSearcher search.Searcher
+	var filterSearcher search.Searcher
+	if q.Filter != nil {
+		filterSearcher, err = q.Filter.Searcher(i, m, false)
+		if err != nil {
+			return nil, err
+		}
+		if matchesNone(filterSearcher) {
+			return matchNoneSearcher(i, []search.Searcher{filterSearcher})
+		}
+		if q.Must == nil && q.Should == nil {
+			mustSearcher, err := searchers.NewConstantScoreSearcher(i, filterSearcher, q.BoostVal, explain)
+			if err != nil {
+				return nil, err
+			}
+			return q.searcherWithMust(i, m, mustSearcher, explain)
+		}
+	}
+
+	searcher, err := q.searcherWithMust(i, m, nil, explain)
+	if err != nil {
+		if filterSearcher != nil {
+			filterSearcher.Close()
+		}
+		return nil, err
+	}
+	if filterSearcher == nil {
+		return searcher, nil
+	}
+	if matchesNone(searcher) {
+		filterSearcher.Close()
+		return searcher, nil
+	}
+	return searchers.NewMatchFilterSearcher(searcher, filterSearcher), nil
+}
+
+// searcherWithMust builds the searcher of the must, should
+// and must not clauses, or uses mustSearcher for the must
+// clauses, when the query only has filter clauses.
+func (q *booleanQuery) searcherWithMust(i index.IndexReader, m *IndexMapping, mustSearcher search.Searcher, explain bool) (search.Searcher, error) {
+	var err error
+
 	if q.Must != nil {
 		mustSearcher, err = q.Must.Searcher(i, m, explain)
 		if err != nil {
 			return nil, err
 		}
+		if matchesNone(mustSearcher) {
+			return matchNoneSearcher(i, []search.Searcher{mustSearcher})
+		}
 	}
 
 	var shouldSearcher search.Searcher
@@ -111,6 +167,11 @@ func (q *booleanQuery) Searcher(i index.IndexReader, m *IndexMapping, explain bo
 		if err != nil {
 			return nil, err
 		}
+		// without must clauses, or with a minimum, some should
+		// clause has to match
+		if matchesNone(shouldSearcher) && (mustSearcher == nil || q.shouldRequired()) {
+			return matchNoneSearcher(i, []search.Searcher{mustSearcher, shouldSearcher})
+		}
 	}
 
 	var mustNotSearcher search.Searcher
@@ -124,6 +185,13 @@ func (q *booleanQuery) Searcher(i index.IndexReader, m *IndexMapping, explain bo
 	return searchers.NewBooleanSearcher(i, mustSearcher, shouldSearcher, mustNotSearcher, explain)
 }
 
+// shouldRequired reports whether documents matching the
+// must clauses also have to match some should clause.
+func (q *booleanQuery) shouldRequired() bool {
+	d, ok := q.Should.(*disjunctionQuery)
+	return ok && d.MinVal > 0
+}
+
 func (q *booleanQuery) Validate() error {
 	if q.Must != nil {
 		err := q.Must.Validate()
@@ -143,7 +211,13 @@ func (q *booleanQuery) Validate() error {
 			return err
 		}
 	}
-	if q.Must == nil && q.Should == nil {
+	if q.Filter != nil {
+		err := q.Filter.Validate()
+		if err != nil {
+			return err
+		}
+	}
+	if q.Must == nil && q.Should == nil && q.Filter == nil {
 		return ErrorBooleanQueryNeedsMustOrShould
 	}
 	return nil
@@ -154,6 +228,7 @@ func (q *booleanQuery) UnmarshalJSON(data []byte) error {
 		Must     json.RawMessage `json:"must,omitempty"`
 		Should   json.RawMessage `json:"should,omitempty"`
 		MustNot  json.RawMessage `json:"must_not,omitempty"`
+		Filter   json.RawMessage `json:"filter,omitempty"`
 		BoostVal float64         `json:"boost,omitempty"`
 	}{}
 	err := json.Unmarshal(data, &tmp)
@@ -194,6 +269,17 @@ func (q *booleanQuery) UnmarshalJSON(data []byte) error {
 		}
 	}
 
+	if tmp.Filter != nil {
+		q.Filter, err = ParseQuery(tmp.Filter)
+		if err != nil {
+			return err
+		}
+		_, isConjunctionQuery := q.Filter.(*conjunctionQuery)
+		if !isConjunctionQuery {
+			return fmt.Errorf("filter clause must be conjunction")
+		}
+	}
+
 	q.BoostVal = tmp.BoostVal
 	if q.BoostVal == 0 {
 		q.BoostVal = 1
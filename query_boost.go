@@ -0,0 +1,131 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package bleve
+
+import (
+	"reflect"
+)
+
+// pushBoosts rewrites q for searching, moving the boosts
+// of compound queries, which their searchers do not use,
+// down into the leaf queries.  Boosts combine by
+// multiplication: a leaf is scored with its own boost
+// times the boosts of all the compound queries it is
+// nested in, so a term boosted 2 in a conjunction boosted
+// 3 is searched with boost 6.  The queries of q are not
+// modified, the rewritten ones are copies.
+func pushBoosts(q Query, m *IndexMapping) (Query, error) {
+	return pushBoost(q, 1, m)
+}
+
+func pushBoost(q Query, boost float64, m *IndexMapping) (Query, error) {
+	var err error
+	switch q := q.(type) {
+	case nil:
+		return nil, nil
+	case *booleanQuery:
+		rv := *q
+		boost *= q.BoostVal
+		rv.Must, err = pushBoost(q.Must, boost, m)
+		if err != nil {
+			return nil, err
+		}
+		rv.Should, err = pushBoost(q.Should, boost, m)
+		if err != nil {
+			return nil, err
+		}
+		rv.MustNot, err = pushBoost(q.MustNot, boost, m)
+		if err != nil {
+			return nil, err
+		}
+		if q.Must == nil && q.Should == nil && q.Filter != nil {
+			// the boost weighs the constant score of the
+			// matches of the filter clauses
+			rv.BoostVal = boost
+			return &rv, nil
+		}
+		rv.BoostVal = 1
+		return &rv, nil
+	case *conjunctionQuery:
+		rv := *q
+		rv.Conjuncts, err = pushBoostAll(q.Conjuncts, boost*q.BoostVal, m)
+		rv.BoostVal = 1
+		return &rv, err
+	case *disjunctionQuery:
+		rv := *q
+		rv.Disjuncts, err = pushBoostAll(q.Disjuncts, boost*q.BoostVal, m)
+		rv.BoostVal = 1
+		return &rv, err
+	case *disjunctionMaxQuery:
+		rv := *q
+		rv.Disjuncts, err = pushBoostAll(q.Disjuncts, boost*q.BoostVal, m)
+		rv.BoostVal = 1
+		return &rv, err
+	case *nestedQuery:
+		rv := *q
+		rv.Clauses, err = pushBoostAll(q.Clauses, boost*q.BoostVal, m)
+		rv.BoostVal = 1
+		return &rv, err
+	case *phraseQuery:
+		rv := *q
+		rv.TermQueries, err = pushBoostAll(q.TermQueries, boost*q.BoostVal, m)
+		rv.BoostVal = 1
+		return &rv, err
+	case *functionScoreQuery:
+		// the boost applies to the score combined with
+		// the function, the boosts of the wrapped query
+		// are pushed on their own
+		rv := *q
+		rv.Query, err = pushBoost(q.Query, 1, m)
+		rv.BoostVal = q.BoostVal * boost
+		return &rv, err
+	case *queryStringQuery:
+		// the boosts of the syntax are already on the
+		// leaves, only a boost of the query itself has
+		// to be pushed into the parsed query
+		boost *= q.BoostVal
+		if boost == 1 {
+			return q, nil
+		}
+		parsed, err := parseQuerySyntax(q.Query, m)
+		if err != nil {
+			return nil, err
+		}
+		return pushBoost(parsed, boost, m)
+	}
+	if boost == 1 {
+		return q, nil
+	}
+	return copyQuery(q).SetBoost(q.Boost() * boost), nil
+}
+
+func pushBoostAll(qs []Query, boost float64, m *IndexMapping) ([]Query, error) {
+	rv := make([]Query, len(qs))
+	for n, q := range qs {
+		var err error
+		rv[n], err = pushBoost(q, boost, m)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return rv, nil
+}
+
+// copyQuery returns a shallow copy of the leaf query q,
+// to set the boost of without modifying q.
+func copyQuery(q Query) Query {
+	v := reflect.ValueOf(q)
+	if v.Kind() != reflect.Ptr {
+		return q
+	}
+	rv := reflect.New(v.Elem().Type())
+	rv.Elem().Set(v.Elem())
+	return rv.Interface().(Query)
+}
@@ -0,0 +1,96 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package bleve
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPushBoosts(t *testing.T) {
+	beer := NewTermQuery("beer").SetBoost(2)
+	wine := NewTermQuery("wine")
+	conjunction := NewConjunctionQuery([]Query{beer, wine}).SetBoost(3)
+	q := NewBooleanQuery([]Query{conjunction}, nil, nil).SetBoost(0.5)
+
+	pushed, err := pushBoosts(q, NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	must := pushed.(*booleanQuery).Must.(*conjunctionQuery)
+	if len(must.Conjuncts) != 1 {
+		t.Fatalf("expected 1 conjunct, got %d", len(must.Conjuncts))
+	}
+	leaves := must.Conjuncts[0].(*conjunctionQuery).Conjuncts
+	expected := []float64{3, 1.5}
+	for n, leaf := range leaves {
+		if leaf.Boost() != expected[n] {
+			t.Errorf("expected leaf %d boost %f, got %f", n, expected[n], leaf.Boost())
+		}
+	}
+	if pushed.Boost() != 1 || must.Boost() != 1 {
+		t.Errorf("expected compound boosts of 1, got %f and %f", pushed.Boost(), must.Boost())
+	}
+
+	// the queries of the request are left as they were
+	if beer.Boost() != 2 || wine.Boost() != 1 || conjunction.Boost() != 3 || q.Boost() != 0.5 {
+		t.Errorf("expected original boosts unchanged, got %f %f %f %f",
+			beer.Boost(), wine.Boost(), conjunction.Boost(), q.Boost())
+	}
+}
+
+func TestIntermediateBoostScores(t *testing.T) {
+	index, err := New("", NewIndexMapping())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer index.Close()
+
+	docs := map[string]string{
+		"both": "beer wine",
+		"beer": "beer",
+		"wine": "wine",
+	}
+	for id, text := range docs {
+		err = index.Index(id, map[string]interface{}{"text": text})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// beer and wine have the same idf, the single term
+	// documents match one clause each, so the ratio of
+	// their scores is that of the boosts of their terms
+	scoreRatio := func(boost float64) float64 {
+		q := NewDisjunctionQuery([]Query{
+			NewConjunctionQuery([]Query{NewTermQuery("beer").SetField("text")}).SetBoost(boost),
+			NewTermQuery("wine").SetField("text"),
+		})
+		res, err := index.Search(NewSearchRequest(q))
+		if err != nil {
+			t.Fatal(err)
+		}
+		scores := make(map[string]float64)
+		for _, hit := range res.Hits {
+			scores[hit.ID] = hit.Score
+		}
+		if len(scores) != 3 {
+			t.Fatalf("expected 3 hits, got %v", scores)
+		}
+		return scores["beer"] / scores["wine"]
+	}
+
+	if ratio := scoreRatio(1); math.Abs(ratio-1) > 1e-9 {
+		t.Errorf("expected equal scores without boost, got ratio %f", ratio)
+	}
+	if ratio := scoreRatio(3); math.Abs(ratio-3) > 1e-9 {
+		t.Errorf("expected the conjunction boost to triple the beer score, got ratio %f", ratio)
+	}
+}
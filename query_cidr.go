@@ -0,0 +1,108 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package bleve
+
+import (
+	"net"
+	"strings"
+
+	"github.com/blevesearch/bleve/index"
+	"github.com/blevesearch/bleve/search"
+	"github.com/blevesearch/bleve/search/searchers"
+)
+
+type cidrQuery struct {
+	CIDR     string  `json:"cidr"`
+	FieldVal string  `json:"field,omitempty"`
+	BoostVal float64 `json:"boost,omitempty"`
+}
+
+// NewCIDRQuery creates a new Query for finding IP
+// addresses in a subnet, such as "192.168.1.0/24" or
+// "2001:db8::/32", or equal to a single address.  IPv4
+// addresses are indexed as IPv4-mapped IPv6 addresses, so
+// they are also matched by an IPv6 subnet of
+// "::ffff:0:0/96" or wider.
+func NewCIDRQuery(cidr string) *cidrQuery {
+	return &cidrQuery{
+		CIDR:     cidr,
+		BoostVal: 1.0,
+	}
+}
+
+func (q *cidrQuery) Boost() float64 {
+	return q.BoostVal
+}
+
+func (q *cidrQuery) SetBoost(b float64) Query {
+	q.BoostVal = b
+	return q
+}
+
+func (q *cidrQuery) Field() string {
+	return q.FieldVal
+}
+
+func (q *cidrQuery) SetField(f string) Query {
+	q.FieldVal = f
+	return q
+}
+
+func (q *cidrQuery) Searcher(i index.IndexReader, m *IndexMapping, explain bool) (search.Searcher, error) {
+	field := q.FieldVal
+	if q.FieldVal == "" {
+		field = m.DefaultField
+	}
+	start, end, err := parseCIDRRange(q.CIDR)
+	if err != nil {
+		return nil, err
+	}
+	return searchers.NewIPRangeSearcher(i, start, end, field, q.BoostVal, explain)
+}
+
+func (q *cidrQuery) Validate() error {
+	_, _, err := parseCIDRRange(q.CIDR)
+	return err
+}
+
+// parseCIDRRange returns the first and last IPv6 form
+// addresses of a subnet, or the address itself if there is
+// no prefix length
+func parseCIDRRange(cidr string) (net.IP, net.IP, error) {
+	if !strings.Contains(cidr, "/") {
+		ip := net.ParseIP(cidr).To16()
+		if ip == nil {
+			return nil, nil, ErrorCIDRQueryInvalid
+		}
+		return ip, ip, nil
+	}
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, nil, ErrorCIDRQueryInvalid
+	}
+	ones, bits := ipNet.Mask.Size()
+	if bits == 32 {
+		ones += 96
+	}
+	start := ipNet.IP.To16()
+	end := make(net.IP, 16)
+	for i := range end {
+		end[i] = start[i]
+		if ones < 8*(i+1) {
+			// set the host bits of this byte
+			hostBits := uint(8*(i+1) - ones)
+			if hostBits > 8 {
+				hostBits = 8
+			}
+			end[i] |= byte(1<<hostBits - 1)
+		}
+	}
+	return start, end, nil
+}
@@ -53,6 +53,11 @@ func (q *conjunctionQuery) Searcher(i index.IndexReader, m *IndexMapping, explai
 		if err != nil {
 			return nil, err
 		}
+		// one clause matching nothing means the conjunction
+		// matches nothing, so skip building the rest
+		if matchesNone(ss[in]) {
+			return matchNoneSearcher(i, ss[:in+1])
+		}
 	}
 	return searchers.NewConjunctionSearcher(i, ss, explain)
 }
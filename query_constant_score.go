@@ -0,0 +1,88 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package bleve
+
+import (
+	"encoding/json"
+
+	"github.com/blevesearch/bleve/index"
+	"github.com/blevesearch/bleve/search"
+	"github.com/blevesearch/bleve/search/searchers"
+)
+
+type constantScoreQuery struct {
+	Filter   Query   `json:"constant_score"`
+	BoostVal float64 `json:"boost,omitempty"`
+}
+
+// NewConstantScoreQuery creates a new Query which
+// matches the same documents as the filter Query,
+// but scores them all equally.  Only the boost of
+// this Query contributes to the score, which is
+// useful when the filter is only meant to restrict
+// the results of an enclosing compound Query.
+func NewConstantScoreQuery(filter Query) *constantScoreQuery {
+	return &constantScoreQuery{
+		Filter:   filter,
+		BoostVal: 1.0,
+	}
+}
+
+func (q *constantScoreQuery) Boost() float64 {
+	return q.BoostVal
+}
+
+func (q *constantScoreQuery) SetBoost(b float64) Query {
+	q.BoostVal = b
+	return q
+}
+
+func (q *constantScoreQuery) Field() string {
+	return ""
+}
+
+func (q *constantScoreQuery) SetField(f string) Query {
+	return q
+}
+
+func (q *constantScoreQuery) Searcher(i index.IndexReader, m *IndexMapping, explain bool) (search.Searcher, error) {
+	filterSearcher, err := q.Filter.Searcher(i, m, false)
+	if err != nil {
+		return nil, err
+	}
+	return searchers.NewConstantScoreSearcher(i, filterSearcher, q.BoostVal, explain)
+}
+
+func (q *constantScoreQuery) Validate() error {
+	if q.Filter == nil {
+		return ErrorConstantScoreQueryNoFilter
+	}
+	return q.Filter.Validate()
+}
+
+func (q *constantScoreQuery) UnmarshalJSON(data []byte) error {
+	tmp := struct {
+		Filter   json.RawMessage `json:"constant_score"`
+		BoostVal float64         `json:"boost,omitempty"`
+	}{}
+	err := json.Unmarshal(data, &tmp)
+	if err != nil {
+		return err
+	}
+	q.Filter, err = ParseQuery(tmp.Filter)
+	if err != nil {
+		return err
+	}
+	q.BoostVal = tmp.BoostVal
+	if q.BoostVal == 0 {
+		q.BoostVal = 1
+	}
+	return nil
+}
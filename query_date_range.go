@@ -12,6 +12,7 @@ package bleve
 import (
 	"fmt"
 	"math"
+	"time"
 
 	"github.com/blevesearch/bleve/index"
 	"github.com/blevesearch/bleve/numeric_util"
@@ -27,6 +28,7 @@ type dateRangeQuery struct {
 	FieldVal       string  `json:"field,omitempty"`
 	BoostVal       float64 `json:"boost,omitempty"`
 	DateTimeParser *string `json:"datetime_parser,omitempty"`
+	TimeZone       *string `json:"time_zone,omitempty"`
 }
 
 // NewDateRangeQuery creates a new Query for ranges
@@ -70,6 +72,25 @@ func (q *dateRangeQuery) SetField(f string) Query {
 	return q
 }
 
+// SetTimeZone sets the name of the time zone, as known to
+// time.LoadLocation, in which start and end date math
+// expressions like "now/d" are evaluated.  The default is UTC.
+func (q *dateRangeQuery) SetTimeZone(tz string) *dateRangeQuery {
+	q.TimeZone = &tz
+	return q
+}
+
+func (q *dateRangeQuery) location() (*time.Location, error) {
+	if q.TimeZone == nil {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(*q.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("date range query has unknown time zone '%s'", *q.TimeZone)
+	}
+	return loc, nil
+}
+
 func (q *dateRangeQuery) Searcher(i index.IndexReader, m *IndexMapping, explain bool) (search.Searcher, error) {
 
 	dateTimeParserName := ""
@@ -88,18 +109,34 @@ func (q *dateRangeQuery) Searcher(i index.IndexReader, m *IndexMapping, explain
 		field = m.DefaultField
 	}
 
-	// now parse the endpoints
+	loc, err := q.location()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+
+	// now parse the endpoints, date math relative to now
 	min := math.Inf(-1)
 	max := math.Inf(1)
 	if q.Start != nil && *q.Start != "" {
-		startTime, err := dateTimeParser.ParseDateTime(*q.Start)
+		var startTime time.Time
+		if isDateMath(*q.Start) {
+			startTime, err = ParseDateMath(*q.Start, now, loc)
+		} else {
+			startTime, err = dateTimeParser.ParseDateTime(*q.Start)
+		}
 		if err != nil {
 			return nil, err
 		}
 		min = numeric_util.Int64ToFloat64(startTime.UnixNano())
 	}
 	if q.End != nil && *q.End != "" {
-		endTime, err := dateTimeParser.ParseDateTime(*q.End)
+		var endTime time.Time
+		if isDateMath(*q.End) {
+			endTime, err = ParseDateMath(*q.End, now, loc)
+		} else {
+			endTime, err = dateTimeParser.ParseDateTime(*q.End)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -113,5 +150,17 @@ func (q *dateRangeQuery) Validate() error {
 	if q.Start == nil && q.Start == q.End {
 		return fmt.Errorf("must specify start or end")
 	}
+	loc, err := q.location()
+	if err != nil {
+		return err
+	}
+	for _, bound := range []*string{q.Start, q.End} {
+		if bound != nil && isDateMath(*bound) {
+			_, err := ParseDateMath(*bound, time.Now(), loc)
+			if err != nil {
+				return err
+			}
+		}
+	}
 	return nil
 }
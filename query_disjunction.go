@@ -34,6 +34,9 @@ func NewDisjunctionQuery(disjuncts []Query) *disjunctionQuery {
 
 // NewDisjunctionQueryMin creates a new compound Query.
 // Result documents satisfy at least min Queries.
+// A min of 0 or 1 only requires a single Query to be
+// satisfied, while a min greater than the number of
+// Queries matches nothing.
 func NewDisjunctionQueryMin(disjuncts []Query, min float64) *disjunctionQuery {
 	return &disjunctionQuery{
 		Disjuncts: disjuncts,
@@ -60,6 +63,8 @@ func (q *disjunctionQuery) Min() float64 {
 	return q.MinVal
 }
 
+// SetMin sets the number of Queries result documents
+// must satisfy, like minimum_should_match.
 func (q *disjunctionQuery) SetMin(m float64) Query {
 	q.MinVal = m
 	return q
@@ -74,6 +79,27 @@ func (q *disjunctionQuery) Searcher(i index.IndexReader, m *IndexMapping, explai
 			return nil, err
 		}
 	}
+	// clauses for terms not in the index are replaced by
+	// MatchNoneSearchers, which still count towards the
+	// coordination factor but are never iterated
+	live := 0
+	for in, s := range ss {
+		if !matchesNone(s) {
+			live++
+			continue
+		}
+		if _, ok := s.(*searchers.MatchNoneSearcher); !ok {
+			s.Close()
+			var err error
+			ss[in], err = searchers.NewMatchNoneSearcher(i)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	if live == 0 || live < int(q.MinVal) {
+		return matchNoneSearcher(i, ss)
+	}
 	return searchers.NewDisjunctionSearcher(i, ss, q.MinVal, explain)
 }
 
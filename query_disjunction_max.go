@@ -0,0 +1,115 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package bleve
+
+import (
+	"encoding/json"
+
+	"github.com/blevesearch/bleve/index"
+	"github.com/blevesearch/bleve/search"
+	"github.com/blevesearch/bleve/search/searchers"
+)
+
+type disjunctionMaxQuery struct {
+	Disjuncts  []Query `json:"dis_max"`
+	TieBreaker float64 `json:"tie_breaker,omitempty"`
+	BoostVal   float64 `json:"boost,omitempty"`
+}
+
+// NewDisjunctionMaxQuery creates a new compound Query.
+// Result documents satisfy at least one Query, like a
+// disjunction, but they are scored with the best score of
+// the queries they satisfy rather than the sum of them, so
+// a document matching one query well ranks ahead of one
+// matching several queries poorly.  This suits searching
+// the same text in several fields.
+func NewDisjunctionMaxQuery(disjuncts []Query) *disjunctionMaxQuery {
+	return &disjunctionMaxQuery{
+		Disjuncts: disjuncts,
+		BoostVal:  1.0,
+	}
+}
+
+func (q *disjunctionMaxQuery) Boost() float64 {
+	return q.BoostVal
+}
+
+func (q *disjunctionMaxQuery) SetBoost(b float64) Query {
+	q.BoostVal = b
+	return q
+}
+
+func (q *disjunctionMaxQuery) AddQuery(aq Query) Query {
+	q.Disjuncts = append(q.Disjuncts, aq)
+	return q
+}
+
+// SetTieBreaker sets the fraction of the scores of the
+// other queries a document satisfies which is added to its
+// best score.  The default of 0 only counts the best score,
+// while 1 sums all of them like a disjunction without its
+// coordination factor.
+func (q *disjunctionMaxQuery) SetTieBreaker(t float64) *disjunctionMaxQuery {
+	q.TieBreaker = t
+	return q
+}
+
+func (q *disjunctionMaxQuery) Searcher(i index.IndexReader, m *IndexMapping, explain bool) (search.Searcher, error) {
+	ss := make([]search.Searcher, len(q.Disjuncts))
+	for in, disjunct := range q.Disjuncts {
+		var err error
+		ss[in], err = disjunct.Searcher(i, m, explain)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return searchers.NewDisjunctionMaxSearcher(i, ss, q.TieBreaker, explain)
+}
+
+func (q *disjunctionMaxQuery) Validate() error {
+	if q.TieBreaker < 0 || q.TieBreaker > 1 {
+		return ErrorDisjunctionMaxTieBreakerRange
+	}
+	return nil
+}
+
+func (q *disjunctionMaxQuery) UnmarshalJSON(data []byte) error {
+	tmp := struct {
+		Disjuncts  []json.RawMessage `json:"dis_max"`
+		TieBreaker float64           `json:"tie_breaker,omitempty"`
+		BoostVal   float64           `json:"boost,omitempty"`
+	}{}
+	err := json.Unmarshal(data, &tmp)
+	if err != nil {
+		return err
+	}
+	q.Disjuncts = make([]Query, len(tmp.Disjuncts))
+	for i, term := range tmp.Disjuncts {
+		query, err := ParseQuery(term)
+		if err != nil {
+			return err
+		}
+		q.Disjuncts[i] = query
+	}
+	q.TieBreaker = tmp.TieBreaker
+	q.BoostVal = tmp.BoostVal
+	if q.BoostVal == 0 {
+		q.BoostVal = 1
+	}
+	return nil
+}
+
+func (q *disjunctionMaxQuery) Field() string {
+	return ""
+}
+
+func (q *disjunctionMaxQuery) SetField(f string) Query {
+	return q
+}
@@ -0,0 +1,72 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package bleve
+
+import (
+	"github.com/blevesearch/bleve/index"
+	"github.com/blevesearch/bleve/search"
+)
+
+type fieldExistsQuery struct {
+	FieldExists string  `json:"field_exists"`
+	BoostVal    float64 `json:"boost,omitempty"`
+}
+
+// NewFieldExistsQuery creates a new Query for finding
+// documents which have the field, whatever its value.
+// Only fields which produced at least one term when the
+// document was indexed count, so a field holding only
+// an empty string, or which is not indexed, is missing.
+// Documents indexed before field names were recorded
+// must be reindexed to match.  All matches score the
+// same.
+func NewFieldExistsQuery(field string) *fieldExistsQuery {
+	return &fieldExistsQuery{
+		FieldExists: field,
+		BoostVal:    1.0,
+	}
+}
+
+func (q *fieldExistsQuery) Boost() float64 {
+	return q.BoostVal
+}
+
+func (q *fieldExistsQuery) SetBoost(b float64) Query {
+	q.BoostVal = b
+	return q
+}
+
+func (q *fieldExistsQuery) Field() string {
+	return q.FieldExists
+}
+
+func (q *fieldExistsQuery) SetField(f string) Query {
+	q.FieldExists = f
+	return q
+}
+
+func (q *fieldExistsQuery) Searcher(i index.IndexReader, m *IndexMapping, explain bool) (search.Searcher, error) {
+	constantScoreQuery := NewConstantScoreQuery(fieldNameQuery(q.FieldExists)).
+		SetBoost(q.BoostVal)
+	return constantScoreQuery.Searcher(i, m, explain)
+}
+
+// fieldNameQuery matches the documents in which the
+// field produced terms
+func fieldNameQuery(field string) Query {
+	return NewTermQuery(field).SetField(index.FieldNamesField)
+}
+
+func (q *fieldExistsQuery) Validate() error {
+	if q.FieldExists == "" {
+		return ErrorFieldExistsQueryNoField
+	}
+	return nil
+}
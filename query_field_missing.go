@@ -0,0 +1,65 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package bleve
+
+import (
+	"github.com/blevesearch/bleve/index"
+	"github.com/blevesearch/bleve/search"
+)
+
+type fieldMissingQuery struct {
+	FieldMissing string  `json:"field_missing"`
+	BoostVal     float64 `json:"boost,omitempty"`
+}
+
+// NewFieldMissingQuery creates a new Query for finding
+// the documents not matched by NewFieldExistsQuery
+// for the same field.  All matches score the same.
+func NewFieldMissingQuery(field string) *fieldMissingQuery {
+	return &fieldMissingQuery{
+		FieldMissing: field,
+		BoostVal:     1.0,
+	}
+}
+
+func (q *fieldMissingQuery) Boost() float64 {
+	return q.BoostVal
+}
+
+func (q *fieldMissingQuery) SetBoost(b float64) Query {
+	q.BoostVal = b
+	return q
+}
+
+func (q *fieldMissingQuery) Field() string {
+	return q.FieldMissing
+}
+
+func (q *fieldMissingQuery) SetField(f string) Query {
+	q.FieldMissing = f
+	return q
+}
+
+func (q *fieldMissingQuery) Searcher(i index.IndexReader, m *IndexMapping, explain bool) (search.Searcher, error) {
+	missingQuery := NewBooleanQuery(
+		[]Query{NewMatchAllQuery()},
+		nil,
+		[]Query{fieldNameQuery(q.FieldMissing)})
+	constantScoreQuery := NewConstantScoreQuery(missingQuery).
+		SetBoost(q.BoostVal)
+	return constantScoreQuery.Searcher(i, m, explain)
+}
+
+func (q *fieldMissingQuery) Validate() error {
+	if q.FieldMissing == "" {
+		return ErrorFieldExistsQueryNoField
+	}
+	return nil
+}
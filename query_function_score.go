@@ -0,0 +1,105 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package bleve
+
+import (
+	"github.com/blevesearch/bleve/index"
+	"github.com/blevesearch/bleve/search"
+	"github.com/blevesearch/bleve/search/searchers"
+)
+
+const (
+	// FunctionScoreMultiply multiplies the score of a
+	// match by the value of the function.
+	FunctionScoreMultiply = searchers.FunctionScoreMultiply
+	// FunctionScoreSum adds the value of the function to
+	// the score of a match.
+	FunctionScoreSum = searchers.FunctionScoreSum
+	// FunctionScoreReplace scores a match with the value
+	// of the function alone.
+	FunctionScoreReplace = searchers.FunctionScoreReplace
+)
+
+// functionScoreQuery has no JSON form, its function is
+// only given in Go, so none of its fields are marshalled.
+type functionScoreQuery struct {
+	Query    Query               `json:"-"`
+	Fields   []string            `json:"-"`
+	Func     searchers.ScoreFunc `json:"-"`
+	Mode     string              `json:"-"`
+	BoostVal float64             `json:"-"`
+}
+
+// NewFunctionScoreQuery creates a new Query which
+// matches the same documents as query, and modifies
+// their scores with the value of fn.  fn is given each
+// match, scored by query, and the numeric values of the
+// named fields of its document, a float64 for a field
+// with one value and a []float64 for a field with
+// several.  By default, the score is multiplied by the
+// value of fn, see SetMode.
+func NewFunctionScoreQuery(query Query, fields []string, fn searchers.ScoreFunc) *functionScoreQuery {
+	return &functionScoreQuery{
+		Query:    query,
+		Fields:   fields,
+		Func:     fn,
+		Mode:     FunctionScoreMultiply,
+		BoostVal: 1.0,
+	}
+}
+
+func (q *functionScoreQuery) Boost() float64 {
+	return q.BoostVal
+}
+
+func (q *functionScoreQuery) SetBoost(b float64) Query {
+	q.BoostVal = b
+	return q
+}
+
+func (q *functionScoreQuery) Field() string {
+	return ""
+}
+
+func (q *functionScoreQuery) SetField(f string) Query {
+	return q
+}
+
+// SetMode sets how the value of the function combines
+// with the score of a match, one of FunctionScoreMultiply,
+// FunctionScoreSum or FunctionScoreReplace.
+func (q *functionScoreQuery) SetMode(mode string) *functionScoreQuery {
+	q.Mode = mode
+	return q
+}
+
+func (q *functionScoreQuery) Searcher(i index.IndexReader, m *IndexMapping, explain bool) (search.Searcher, error) {
+	err := q.Validate()
+	if err != nil {
+		return nil, err
+	}
+	searcher, err := q.Query.Searcher(i, m, explain)
+	if err != nil {
+		return nil, err
+	}
+	return searchers.NewFunctionScoreSearcher(i, searcher, q.Fields, q.Func, q.Mode, q.BoostVal, explain)
+}
+
+func (q *functionScoreQuery) Validate() error {
+	if q.Query == nil || q.Func == nil {
+		return ErrorFunctionScoreQueryNoFunction
+	}
+	switch q.Mode {
+	case FunctionScoreMultiply, FunctionScoreSum, FunctionScoreReplace:
+	default:
+		return ErrorUnknownFunctionScoreMode
+	}
+	return q.Query.Validate()
+}
@@ -16,11 +16,12 @@ import (
 )
 
 type fuzzyQuery struct {
-	Term         string  `json:"term"`
-	PrefixVal    int     `json:"prefix_length"`
-	FuzzinessVal int     `json:"fuzziness"`
-	FieldVal     string  `json:"field,omitempty"`
-	BoostVal     float64 `json:"boost,omitempty"`
+	Term              string  `json:"term"`
+	PrefixVal         int     `json:"prefix_length"`
+	FuzzinessVal      int     `json:"fuzziness"`
+	TranspositionsVal bool    `json:"transpositions,omitempty"`
+	FieldVal          string  `json:"field,omitempty"`
+	BoostVal          float64 `json:"boost,omitempty"`
 }
 
 // NewPrefixQuery creates a new Query which finds
@@ -66,17 +67,31 @@ func (q *fuzzyQuery) Prefix() int {
 	return q.PrefixVal
 }
 
+// SetPrefix requires the first p characters of
+// matching terms to be identical to the query term.
 func (q *fuzzyQuery) SetPrefix(p int) Query {
 	q.PrefixVal = p
 	return q
 }
 
+func (q *fuzzyQuery) Transpositions() bool {
+	return q.TranspositionsVal
+}
+
+// SetTranspositions controls whether swapping two
+// adjacent characters counts as a single edit
+// (Damerau-Levenshtein) rather than two.
+func (q *fuzzyQuery) SetTranspositions(t bool) Query {
+	q.TranspositionsVal = t
+	return q
+}
+
 func (q *fuzzyQuery) Searcher(i index.IndexReader, m *IndexMapping, explain bool) (search.Searcher, error) {
 	field := q.FieldVal
 	if q.FieldVal == "" {
 		field = m.DefaultField
 	}
-	return searchers.NewFuzzySearcher(i, q.Term, q.PrefixVal, q.FuzzinessVal, field, q.BoostVal, explain)
+	return searchers.NewFuzzySearcherWithTranspositions(i, q.Term, q.PrefixVal, q.FuzzinessVal, q.TranspositionsVal, field, q.BoostVal, explain)
 }
 
 func (q *fuzzyQuery) Validate() error {
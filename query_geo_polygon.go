@@ -0,0 +1,119 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package bleve
+
+import (
+	"github.com/blevesearch/bleve/geo"
+	"github.com/blevesearch/bleve/index"
+	"github.com/blevesearch/bleve/search"
+	"github.com/blevesearch/bleve/search/searchers"
+)
+
+type geoBoundingPolygonQuery struct {
+	Points   []geo.Point `json:"polygon_points"`
+	FieldVal string      `json:"field,omitempty"`
+	BoostVal float64     `json:"boost,omitempty"`
+}
+
+// NewGeoBoundingPolygonQuery creates a new Query for
+// finding documents located inside the polygon with
+// the specified vertices, or on its edges.  The
+// polygon may be open or closed, and may cross the
+// antimeridian.
+// The field of the query names an object with numeric
+// "lat" and "lon" properties, both of which must be
+// indexed and stored.  For example, a document
+// {"location": {"lat": 37.4, "lon": -122.1}} is
+// matched by setting the field to "location".
+func NewGeoBoundingPolygonQuery(points []geo.Point) *geoBoundingPolygonQuery {
+	return &geoBoundingPolygonQuery{
+		Points:   points,
+		BoostVal: 1.0,
+	}
+}
+
+func (q *geoBoundingPolygonQuery) Boost() float64 {
+	return q.BoostVal
+}
+
+func (q *geoBoundingPolygonQuery) SetBoost(b float64) Query {
+	q.BoostVal = b
+	return q
+}
+
+func (q *geoBoundingPolygonQuery) Field() string {
+	return q.FieldVal
+}
+
+func (q *geoBoundingPolygonQuery) SetField(f string) Query {
+	q.FieldVal = f
+	return q
+}
+
+func (q *geoBoundingPolygonQuery) Searcher(i index.IndexReader, m *IndexMapping, explain bool) (search.Searcher, error) {
+	field := q.FieldVal
+	if q.FieldVal == "" {
+		field = m.DefaultField
+	}
+	latField := field + pathSeparator + "lat"
+	lonField := field + pathSeparator + "lon"
+
+	// first narrow down to the bounding box using the
+	// numeric index of the coordinates
+	inclusive := true
+	topLeft, bottomRight := geo.PolygonBoundingBox(q.Points)
+	latQuery := NewNumericRangeInclusiveQuery(&bottomRight.Lat, &topLeft.Lat, &inclusive, &inclusive).
+		SetField(latField).
+		SetBoost(q.BoostVal)
+	var lonQuery Query
+	if topLeft.Lon <= bottomRight.Lon {
+		lonQuery = NewNumericRangeInclusiveQuery(&topLeft.Lon, &bottomRight.Lon, &inclusive, &inclusive).
+			SetField(lonField).
+			SetBoost(q.BoostVal)
+	} else {
+		// the box wraps around the antimeridian
+		east, west := 180.0, -180.0
+		lonQuery = NewDisjunctionQueryMin([]Query{
+			NewNumericRangeInclusiveQuery(&topLeft.Lon, &east, &inclusive, &inclusive).
+				SetField(lonField).
+				SetBoost(q.BoostVal),
+			NewNumericRangeInclusiveQuery(&west, &bottomRight.Lon, &inclusive, &inclusive).
+				SetField(lonField).
+				SetBoost(q.BoostVal),
+		}, 1)
+	}
+	boxSearcher, err := NewConjunctionQuery([]Query{latQuery, lonQuery}).Searcher(i, m, explain)
+	if err != nil {
+		return nil, err
+	}
+
+	// then check the stored coordinates of each candidate
+	return searchers.NewFilteringSearcher(boxSearcher, func(d *search.DocumentMatch) (bool, error) {
+		doc, err := i.Document(d.ID)
+		if err != nil {
+			return false, err
+		}
+		if doc == nil {
+			return false, nil
+		}
+		point, ok := search.StoredGeoPoint(doc, latField, lonField)
+		if !ok {
+			return false, nil
+		}
+		return geo.PolygonContains(q.Points, point), nil
+	}), nil
+}
+
+func (q *geoBoundingPolygonQuery) Validate() error {
+	if !geo.ValidPolygon(q.Points) {
+		return ErrorGeoPolygonTooFewPoints
+	}
+	return nil
+}
@@ -56,6 +56,17 @@ func (q *matchQuery) SetField(f string) Query {
 	return q
 }
 
+// SetAnalyzer sets the analyzer used to analyze the
+// text of the query, in place of the analyzer of the
+// field.  The terms it produces are still matched
+// against the terms indexed by the analyzer of the
+// field, so an analyzer producing different terms
+// for the same text will miss documents.
+func (q *matchQuery) SetAnalyzer(a string) Query {
+	q.Analyzer = a
+	return q
+}
+
 func (q *matchQuery) Fuzziness() int {
 	return q.FuzzinessVal
 }
@@ -93,7 +104,7 @@ func (q *matchQuery) Searcher(i index.IndexReader, m *IndexMapping, explain bool
 		return nil, fmt.Errorf("no analyzer named '%s' registered", q.Analyzer)
 	}
 
-	tokens := analyzer.Analyze([]byte(q.Match))
+	tokens := m.analyzeQueryText(analyzerName, analyzer, q.Match)
 	if len(tokens) > 0 {
 
 		tqs := make([]Query, len(tokens))
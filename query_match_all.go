@@ -10,6 +10,8 @@
 package bleve
 
 import (
+	"encoding/json"
+
 	"github.com/blevesearch/bleve/index"
 	"github.com/blevesearch/bleve/search"
 	"github.com/blevesearch/bleve/search/searchers"
@@ -51,3 +53,11 @@ func (q *matchAllQuery) Field() string {
 func (q *matchAllQuery) SetField(f string) Query {
 	return q
 }
+
+func (q *matchAllQuery) MarshalJSON() ([]byte, error) {
+	tmp := map[string]interface{}{
+		"boost":     q.BoostVal,
+		"match_all": map[string]interface{}{},
+	}
+	return json.Marshal(tmp)
+}
@@ -56,6 +56,17 @@ func (q *matchPhraseQuery) SetField(f string) Query {
 	return q
 }
 
+// SetAnalyzer sets the analyzer used to analyze the
+// text of the query, in place of the analyzer of the
+// field.  The terms it produces are still matched
+// against the terms indexed by the analyzer of the
+// field, so an analyzer producing different terms
+// for the same text will miss documents.
+func (q *matchPhraseQuery) SetAnalyzer(a string) Query {
+	q.Analyzer = a
+	return q
+}
+
 func (q *matchPhraseQuery) Searcher(i index.IndexReader, m *IndexMapping, explain bool) (search.Searcher, error) {
 	field := q.FieldVal
 	if q.FieldVal == "" {
@@ -73,7 +84,7 @@ func (q *matchPhraseQuery) Searcher(i index.IndexReader, m *IndexMapping, explai
 		return nil, fmt.Errorf("no analyzer named '%s' registered", q.Analyzer)
 	}
 
-	tokens := analyzer.Analyze([]byte(q.MatchPhrase))
+	tokens := m.analyzeQueryText(analyzerName, analyzer, q.MatchPhrase)
 	if len(tokens) > 0 {
 		phrase := tokenStreamToPhrase(tokens)
 		phraseQuery := NewPhraseQuery(phrase, field).SetBoost(q.BoostVal)
@@ -0,0 +1,153 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package bleve
+
+import (
+	"fmt"
+
+	"github.com/blevesearch/bleve/index"
+	"github.com/blevesearch/bleve/search"
+)
+
+// DefaultMaxExpansions is the number of terms the last
+// term of a match phrase prefix query expands to when
+// no MaxExpansions is specified.
+const DefaultMaxExpansions = 50
+
+type matchPhrasePrefixQuery struct {
+	MatchPhrasePrefix string  `json:"match_phrase_prefix"`
+	FieldVal          string  `json:"field,omitempty"`
+	Analyzer          string  `json:"analyzer,omitempty"`
+	BoostVal          float64 `json:"boost,omitempty"`
+	MaxExpansionsVal  int     `json:"max_expansions,omitempty"`
+}
+
+// NewMatchPhrasePrefixQuery creates a new Query object
+// for matching phrases as they are being typed.
+// Input text is analyzed like NewMatchPhraseQuery,
+// except the last resulting term is treated as a
+// prefix.  Result documents must match the phrase
+// ending in one of the terms starting with that prefix.
+// At most MaxExpansions terms are considered for the
+// prefix.
+func NewMatchPhrasePrefixQuery(matchPhrasePrefix string) *matchPhrasePrefixQuery {
+	return &matchPhrasePrefixQuery{
+		MatchPhrasePrefix: matchPhrasePrefix,
+		BoostVal:          1.0,
+	}
+}
+
+func (q *matchPhrasePrefixQuery) Boost() float64 {
+	return q.BoostVal
+}
+
+func (q *matchPhrasePrefixQuery) SetBoost(b float64) Query {
+	q.BoostVal = b
+	return q
+}
+
+func (q *matchPhrasePrefixQuery) Field() string {
+	return q.FieldVal
+}
+
+func (q *matchPhrasePrefixQuery) SetField(f string) Query {
+	q.FieldVal = f
+	return q
+}
+
+func (q *matchPhrasePrefixQuery) MaxExpansions() int {
+	if q.MaxExpansionsVal <= 0 {
+		return DefaultMaxExpansions
+	}
+	return q.MaxExpansionsVal
+}
+
+func (q *matchPhrasePrefixQuery) SetMaxExpansions(n int) Query {
+	q.MaxExpansionsVal = n
+	return q
+}
+
+func (q *matchPhrasePrefixQuery) Searcher(i index.IndexReader, m *IndexMapping, explain bool) (search.Searcher, error) {
+	field := q.FieldVal
+	if q.FieldVal == "" {
+		field = m.DefaultField
+	}
+
+	analyzerName := ""
+	if q.Analyzer != "" {
+		analyzerName = q.Analyzer
+	} else {
+		analyzerName = m.analyzerNameForPath(field)
+	}
+	analyzer := m.analyzerNamed(analyzerName)
+	if analyzer == nil {
+		return nil, fmt.Errorf("no analyzer named '%s' registered", q.Analyzer)
+	}
+
+	tokens := m.analyzeQueryText(analyzerName, analyzer, q.MatchPhrasePrefix)
+	phrase := tokenStreamToPhrase(tokens)
+	if len(phrase) < 1 {
+		noneQuery := NewMatchNoneQuery()
+		return noneQuery.Searcher(i, m, explain)
+	}
+
+	last := len(phrase) - 1
+	expansions, err := expandPrefix(i, field, phrase[last], q.MaxExpansions())
+	if err != nil {
+		return nil, err
+	}
+	if len(expansions) < 1 {
+		noneQuery := NewMatchNoneQuery()
+		return noneQuery.Searcher(i, m, explain)
+	}
+
+	qs := make([]Query, len(expansions))
+	for n, expansion := range expansions {
+		if last == 0 {
+			qs[n] = NewTermQuery(expansion).
+				SetField(field).
+				SetBoost(q.BoostVal)
+		} else {
+			terms := make([]string, len(phrase))
+			copy(terms, phrase)
+			terms[last] = expansion
+			qs[n] = NewPhraseQuery(terms, field).
+				SetBoost(q.BoostVal)
+		}
+	}
+	shouldQuery := NewDisjunctionQueryMin(qs, 1).
+		SetBoost(q.BoostVal)
+	return shouldQuery.Searcher(i, m, explain)
+}
+
+// expandPrefix returns, in term dictionary order, up to
+// max terms in the field starting with prefix
+func expandPrefix(i index.IndexReader, field, prefix string, max int) ([]string, error) {
+	fieldReader, err := i.FieldReader(field, []byte(prefix), []byte(prefix))
+	if err != nil {
+		return nil, err
+	}
+	defer fieldReader.Close()
+
+	rv := make([]string, 0)
+	tfd, err := fieldReader.Next()
+	for err == nil && tfd != nil && len(rv) < max {
+		rv = append(rv, tfd.Term)
+		tfd, err = fieldReader.Next()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rv, nil
+}
+
+func (q *matchPhrasePrefixQuery) Validate() error {
+	return nil
+}
@@ -0,0 +1,297 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package bleve
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/blevesearch/bleve/document"
+	"github.com/blevesearch/bleve/index"
+	"github.com/blevesearch/bleve/search"
+	"github.com/blevesearch/bleve/search/searchers"
+)
+
+const defaultMoreLikeThisMaxQueryTerms = 25
+
+type moreLikeThisQuery struct {
+	LikeID        string   `json:"like_id,omitempty"`
+	LikeText      string   `json:"like_text,omitempty"`
+	Fields        []string `json:"fields,omitempty"`
+	MinTermFreq   int      `json:"min_term_freq,omitempty"`
+	MinDocFreq    int      `json:"min_doc_freq,omitempty"`
+	MaxQueryTerms int      `json:"max_query_terms,omitempty"`
+	IncludeSeed   bool     `json:"include_seed,omitempty"`
+	BoostVal      float64  `json:"boost,omitempty"`
+}
+
+// NewMoreLikeThisQuery creates a new Query for finding
+// documents similar to the document with the specified
+// id.  The terms of the document with the highest TF-IDF
+// are searched for, in the stored text fields of the
+// document unless fields are set.  The document itself
+// is not matched unless SetIncludeSeed is used.
+func NewMoreLikeThisQuery(id string) *moreLikeThisQuery {
+	return &moreLikeThisQuery{
+		LikeID:   id,
+		BoostVal: 1.0,
+	}
+}
+
+// NewMoreLikeThisTextQuery creates a new Query for
+// finding documents similar to the provided text.  The
+// text is analyzed for each field, the default field
+// unless fields are set, and its terms with the highest
+// TF-IDF are searched for.
+func NewMoreLikeThisTextQuery(text string) *moreLikeThisQuery {
+	return &moreLikeThisQuery{
+		LikeText: text,
+		BoostVal: 1.0,
+	}
+}
+
+func (q *moreLikeThisQuery) Boost() float64 {
+	return q.BoostVal
+}
+
+func (q *moreLikeThisQuery) SetBoost(b float64) Query {
+	q.BoostVal = b
+	return q
+}
+
+// SetFields sets the fields terms are taken from and
+// searched in.
+func (q *moreLikeThisQuery) SetFields(fields []string) *moreLikeThisQuery {
+	q.Fields = fields
+	return q
+}
+
+// SetMinTermFreq sets how many times a term must occur in
+// the document or text to be used, 1 by default.
+func (q *moreLikeThisQuery) SetMinTermFreq(n int) *moreLikeThisQuery {
+	q.MinTermFreq = n
+	return q
+}
+
+// SetMinDocFreq sets how many documents, other than the
+// document being matched, must contain a term for it to
+// be used, 1 by default.
+func (q *moreLikeThisQuery) SetMinDocFreq(n int) *moreLikeThisQuery {
+	q.MinDocFreq = n
+	return q
+}
+
+// SetMaxQueryTerms sets how many of the terms with the
+// highest TF-IDF are searched for, 25 by default.
+func (q *moreLikeThisQuery) SetMaxQueryTerms(n int) *moreLikeThisQuery {
+	q.MaxQueryTerms = n
+	return q
+}
+
+// SetIncludeSeed sets whether the document being matched
+// is included in the results.
+func (q *moreLikeThisQuery) SetIncludeSeed(include bool) *moreLikeThisQuery {
+	q.IncludeSeed = include
+	return q
+}
+
+// a moreLikeThisTerm is a candidate term for the query
+type moreLikeThisTerm struct {
+	field string
+	term  string
+	score float64
+}
+
+type moreLikeThisTerms []*moreLikeThisTerm
+
+func (t moreLikeThisTerms) Len() int      { return len(t) }
+func (t moreLikeThisTerms) Swap(i, j int) { t[i], t[j] = t[j], t[i] }
+func (t moreLikeThisTerms) Less(i, j int) bool {
+	if t[i].score != t[j].score {
+		return t[i].score > t[j].score
+	}
+	if t[i].field != t[j].field {
+		return t[i].field < t[j].field
+	}
+	return t[i].term < t[j].term
+}
+
+func (q *moreLikeThisQuery) Searcher(i index.IndexReader, m *IndexMapping, explain bool) (search.Searcher, error) {
+	var candidates moreLikeThisTerms
+	var err error
+	if q.LikeID != "" {
+		candidates, err = q.documentTerms(i)
+	} else {
+		candidates, err = q.textTerms(i, m)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Sort(candidates)
+	maxQueryTerms := q.MaxQueryTerms
+	if maxQueryTerms <= 0 {
+		maxQueryTerms = defaultMoreLikeThisMaxQueryTerms
+	}
+	if len(candidates) > maxQueryTerms {
+		candidates = candidates[:maxQueryTerms]
+	}
+	if len(candidates) == 0 {
+		return NewMatchNoneQuery().Searcher(i, m, explain)
+	}
+
+	tqs := make([]Query, len(candidates))
+	for in, candidate := range candidates {
+		tqs[in] = NewTermQuery(candidate.term).
+			SetField(candidate.field).
+			SetBoost(q.BoostVal)
+	}
+	s, err := NewDisjunctionQueryMin(tqs, 1).
+		SetBoost(q.BoostVal).
+		Searcher(i, m, explain)
+	if err != nil {
+		return nil, err
+	}
+	if q.LikeID == "" || q.IncludeSeed {
+		return s, nil
+	}
+	return searchers.NewFilteringSearcher(s, func(d *search.DocumentMatch) (bool, error) {
+		return d.ID != q.LikeID, nil
+	}), nil
+}
+
+// documentTerms returns the candidate terms of the
+// document being matched, with their frequencies read
+// from the index
+func (q *moreLikeThisQuery) documentTerms(i index.IndexReader) (moreLikeThisTerms, error) {
+	fields := q.Fields
+	if len(fields) == 0 {
+		doc, err := i.Document(q.LikeID)
+		if err != nil || doc == nil {
+			return nil, err
+		}
+		seen := make(map[string]bool)
+		for _, field := range doc.Fields {
+			_, isText := field.(*document.TextField)
+			if isText && !seen[field.Name()] {
+				seen[field.Name()] = true
+				fields = append(fields, field.Name())
+			}
+		}
+	}
+	fieldTerms, err := i.DocumentFieldTerms(q.LikeID)
+	if err != nil {
+		return nil, err
+	}
+
+	var rv moreLikeThisTerms
+	for _, field := range fields {
+		for _, term := range fieldTerms[field] {
+			reader, err := i.TermFieldReader([]byte(term), field)
+			if err != nil {
+				return nil, err
+			}
+			tfd, err := reader.Advance(q.LikeID)
+			docFreq := reader.Count()
+			reader.Close()
+			if err != nil {
+				return nil, err
+			}
+			if tfd == nil || tfd.ID != q.LikeID {
+				continue
+			}
+			// the document being matched does not count
+			candidate := q.candidate(i, field, term, tfd.Freq, docFreq-1)
+			if candidate != nil {
+				rv = append(rv, candidate)
+			}
+		}
+	}
+	return rv, nil
+}
+
+// textTerms returns the candidate terms of the text being
+// matched, as analyzed for each field
+func (q *moreLikeThisQuery) textTerms(i index.IndexReader, m *IndexMapping) (moreLikeThisTerms, error) {
+	fields := q.Fields
+	if len(fields) == 0 {
+		fields = []string{m.DefaultField}
+	}
+
+	var rv moreLikeThisTerms
+	for _, field := range fields {
+		analyzerName := m.analyzerNameForPath(field)
+		analyzer := m.analyzerNamed(analyzerName)
+		if analyzer == nil {
+			return nil, fmt.Errorf("no analyzer named '%s' registered", analyzerName)
+		}
+		termFreqs := make(map[string]uint64)
+		var terms []string
+		for _, token := range m.analyzeQueryText(analyzerName, analyzer, q.LikeText) {
+			term := string(token.Term)
+			if termFreqs[term] == 0 {
+				terms = append(terms, term)
+			}
+			termFreqs[term]++
+		}
+		for _, term := range terms {
+			reader, err := i.TermFieldReader([]byte(term), field)
+			if err != nil {
+				return nil, err
+			}
+			docFreq := reader.Count()
+			reader.Close()
+			candidate := q.candidate(i, field, term, termFreqs[term], docFreq)
+			if candidate != nil {
+				rv = append(rv, candidate)
+			}
+		}
+	}
+	return rv, nil
+}
+
+// candidate scores a term by TF-IDF, or returns nil if it
+// is too rare
+func (q *moreLikeThisQuery) candidate(i index.IndexReader, field, term string, termFreq, docFreq uint64) *moreLikeThisTerm {
+	minTermFreq := uint64(1)
+	if q.MinTermFreq > 0 {
+		minTermFreq = uint64(q.MinTermFreq)
+	}
+	minDocFreq := uint64(1)
+	if q.MinDocFreq > 0 {
+		minDocFreq = uint64(q.MinDocFreq)
+	}
+	if termFreq < minTermFreq || docFreq < minDocFreq {
+		return nil
+	}
+	idf := 1.0 + math.Log(float64(i.DocCount())/float64(docFreq+1))
+	return &moreLikeThisTerm{
+		field: field,
+		term:  term,
+		score: float64(termFreq) * idf,
+	}
+}
+
+func (q *moreLikeThisQuery) Validate() error {
+	if (q.LikeID == "") == (q.LikeText == "") {
+		return ErrorMoreLikeThisQueryNoLike
+	}
+	return nil
+}
+
+func (q *moreLikeThisQuery) Field() string {
+	return ""
+}
+
+func (q *moreLikeThisQuery) SetField(f string) Query {
+	q.Fields = []string{f}
+	return q
+}
@@ -0,0 +1,215 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package bleve
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/blevesearch/bleve/index"
+	"github.com/blevesearch/bleve/search"
+)
+
+const (
+	// MultiMatchBestFields scores documents by the field
+	// matching the text best, like a disjunction max query
+	// of a match query per field.
+	MultiMatchBestFields = "best_fields"
+	// MultiMatchMostFields sums the scores of the fields
+	// matching the text, like a disjunction of a match
+	// query per field.
+	MultiMatchMostFields = "most_fields"
+	// MultiMatchCrossFields treats the fields as one, each
+	// term of the text is scored by the field it matches
+	// best, and the scores of the terms are summed.
+	MultiMatchCrossFields = "cross_fields"
+)
+
+type multiMatchQuery struct {
+	MultiMatch string   `json:"multi_match"`
+	Fields     []string `json:"fields"`
+	Type       string   `json:"type,omitempty"`
+	TieBreaker float64  `json:"tie_breaker,omitempty"`
+	Analyzer   string   `json:"analyzer,omitempty"`
+	BoostVal   float64  `json:"boost,omitempty"`
+}
+
+// NewMultiMatchQuery creates a Query for matching text
+// in several fields.  A field may be followed by a boost
+// for its matches, as in "title^2".  The text is analyzed
+// with the analyzer of each field, and the matches are
+// combined according to the type, one of
+// MultiMatchBestFields, the default, MultiMatchMostFields
+// or MultiMatchCrossFields.
+func NewMultiMatchQuery(match string, fields []string) *multiMatchQuery {
+	return &multiMatchQuery{
+		MultiMatch: match,
+		Fields:     fields,
+		BoostVal:   1.0,
+	}
+}
+
+func (q *multiMatchQuery) Boost() float64 {
+	return q.BoostVal
+}
+
+func (q *multiMatchQuery) SetBoost(b float64) Query {
+	q.BoostVal = b
+	return q
+}
+
+func (q *multiMatchQuery) Field() string {
+	return ""
+}
+
+func (q *multiMatchQuery) SetField(f string) Query {
+	return q
+}
+
+// SetType sets how the matches in the fields are combined.
+func (q *multiMatchQuery) SetType(t string) *multiMatchQuery {
+	q.Type = t
+	return q
+}
+
+// SetTieBreaker sets the fraction of the scores of the
+// other fields added to the best score, for the
+// MultiMatchBestFields and MultiMatchCrossFields types.
+func (q *multiMatchQuery) SetTieBreaker(t float64) *multiMatchQuery {
+	q.TieBreaker = t
+	return q
+}
+
+// SetAnalyzer sets the analyzer used to analyze the
+// text of the query in all the fields, in place of the
+// analyzer of each field.
+func (q *multiMatchQuery) SetAnalyzer(a string) *multiMatchQuery {
+	q.Analyzer = a
+	return q
+}
+
+// parseFieldBoost splits a field into its name and boost
+func parseFieldBoost(field string) (string, float64, error) {
+	pos := strings.LastIndex(field, "^")
+	if pos < 0 {
+		return field, 1.0, nil
+	}
+	boost, err := strconv.ParseFloat(field[pos+1:], 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid boost for field '%s'", field)
+	}
+	return field[:pos], boost, nil
+}
+
+func (q *multiMatchQuery) Searcher(i index.IndexReader, m *IndexMapping, explain bool) (search.Searcher, error) {
+	var query Query
+	var err error
+	switch q.Type {
+	case "", MultiMatchBestFields:
+		query, err = q.fieldQueries(func(qs []Query) Query {
+			return NewDisjunctionMaxQuery(qs).SetTieBreaker(q.TieBreaker)
+		})
+	case MultiMatchMostFields:
+		query, err = q.fieldQueries(func(qs []Query) Query {
+			return NewDisjunctionQueryMin(qs, 1)
+		})
+	case MultiMatchCrossFields:
+		query, err = q.crossFieldsQuery(m)
+	default:
+		return nil, ErrorUnknownMultiMatchType
+	}
+	if err != nil {
+		return nil, err
+	}
+	return query.Searcher(i, m, explain)
+}
+
+// fieldQueries combines a match query per field
+func (q *multiMatchQuery) fieldQueries(combine func([]Query) Query) (Query, error) {
+	qs := make([]Query, len(q.Fields))
+	for in, f := range q.Fields {
+		field, boost, err := parseFieldBoost(f)
+		if err != nil {
+			return nil, err
+		}
+		mq := NewMatchQuery(q.MultiMatch)
+		mq.SetField(field)
+		mq.SetBoost(q.BoostVal * boost)
+		if q.Analyzer != "" {
+			mq.SetAnalyzer(q.Analyzer)
+		}
+		qs[in] = mq
+	}
+	return combine(qs), nil
+}
+
+// crossFieldsQuery sums, over the positions of the
+// analyzed text, the best match of the terms at that
+// position in any of the fields
+func (q *multiMatchQuery) crossFieldsQuery(m *IndexMapping) (Query, error) {
+	positions := make(map[int][]Query)
+	for _, f := range q.Fields {
+		field, boost, err := parseFieldBoost(f)
+		if err != nil {
+			return nil, err
+		}
+		analyzerName := q.Analyzer
+		if analyzerName == "" {
+			analyzerName = m.analyzerNameForPath(field)
+		}
+		analyzer := m.analyzerNamed(analyzerName)
+		if analyzer == nil {
+			return nil, fmt.Errorf("no analyzer named '%s' registered", analyzerName)
+		}
+		for _, token := range m.analyzeQueryText(analyzerName, analyzer, q.MultiMatch) {
+			positions[token.Position] = append(positions[token.Position],
+				NewTermQuery(string(token.Term)).
+					SetField(field).
+					SetBoost(q.BoostVal*boost))
+		}
+	}
+	if len(positions) == 0 {
+		return NewMatchNoneQuery(), nil
+	}
+
+	order := make([]int, 0, len(positions))
+	for position := range positions {
+		order = append(order, position)
+	}
+	sort.Ints(order)
+	qs := make([]Query, len(order))
+	for in, position := range order {
+		qs[in] = NewDisjunctionMaxQuery(positions[position]).SetTieBreaker(q.TieBreaker)
+	}
+	return NewDisjunctionQueryMin(qs, 1), nil
+}
+
+func (q *multiMatchQuery) Validate() error {
+	if len(q.Fields) == 0 {
+		return ErrorMultiMatchQueryNoFields
+	}
+	switch q.Type {
+	case "", MultiMatchBestFields, MultiMatchMostFields, MultiMatchCrossFields:
+	default:
+		return ErrorUnknownMultiMatchType
+	}
+	if q.TieBreaker < 0 || q.TieBreaker > 1 {
+		return ErrorDisjunctionMaxTieBreakerRange
+	}
+	for _, f := range q.Fields {
+		_, _, err := parseFieldBoost(f)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package bleve
+
+import (
+	"encoding/json"
+
+	"github.com/blevesearch/bleve/index"
+	"github.com/blevesearch/bleve/search"
+	"github.com/blevesearch/bleve/search/searchers"
+)
+
+type nestedQuery struct {
+	Path     string  `json:"nested"`
+	Clauses  []Query `json:"clauses"`
+	BoostVal float64 `json:"boost,omitempty"`
+}
+
+// NewNestedQuery creates a new compound Query for
+// searching the array of objects at path, which must be
+// mapped as Nested.  Result documents have one object in
+// the array satisfying all of the clauses.  The clauses
+// search the fields of the objects, by their full path,
+// and only count matches in those fields.
+func NewNestedQuery(path string, clauses []Query) *nestedQuery {
+	return &nestedQuery{
+		Path:     path,
+		Clauses:  clauses,
+		BoostVal: 1.0,
+	}
+}
+
+func (q *nestedQuery) Boost() float64 {
+	return q.BoostVal
+}
+
+func (q *nestedQuery) SetBoost(b float64) Query {
+	q.BoostVal = b
+	return q
+}
+
+func (q *nestedQuery) AddQuery(aq Query) *nestedQuery {
+	q.Clauses = append(q.Clauses, aq)
+	return q
+}
+
+func (q *nestedQuery) Searcher(i index.IndexReader, m *IndexMapping, explain bool) (search.Searcher, error) {
+	if !m.isNested(q.Path) {
+		return nil, ErrorNestedQueryPathNotNested
+	}
+	ss := make([]search.Searcher, len(q.Clauses))
+	for in, clause := range q.Clauses {
+		var err error
+		ss[in], err = clause.Searcher(i, m, explain)
+		if err != nil {
+			for _, s := range ss[:in] {
+				s.Close()
+			}
+			return nil, err
+		}
+	}
+	return searchers.NewNestedSearcher(i, ss, q.Path, explain)
+}
+
+func (q *nestedQuery) Validate() error {
+	if q.Path == "" {
+		return ErrorNestedQueryNoPath
+	}
+	for _, clause := range q.Clauses {
+		err := clause.Validate()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (q *nestedQuery) UnmarshalJSON(data []byte) error {
+	tmp := struct {
+		Path     string            `json:"nested"`
+		Clauses  []json.RawMessage `json:"clauses"`
+		BoostVal float64           `json:"boost,omitempty"`
+	}{}
+	err := json.Unmarshal(data, &tmp)
+	if err != nil {
+		return err
+	}
+	q.Path = tmp.Path
+	q.Clauses = make([]Query, len(tmp.Clauses))
+	for i, clause := range tmp.Clauses {
+		query, err := ParseQuery(clause)
+		if err != nil {
+			return err
+		}
+		q.Clauses[i] = query
+	}
+	q.BoostVal = tmp.BoostVal
+	if q.BoostVal == 0 {
+		q.BoostVal = 1
+	}
+	return nil
+}
+
+func (q *nestedQuery) Field() string {
+	return q.Path
+}
+
+func (q *nestedQuery) SetField(f string) Query {
+	q.Path = f
+	return q
+}
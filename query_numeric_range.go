@@ -74,7 +74,7 @@ func (q *numericRangeQuery) Searcher(i index.IndexReader, m *IndexMapping, expla
 }
 
 func (q *numericRangeQuery) Validate() error {
-	if q.Min == nil && q.Min == q.Max {
+	if q.Min == nil && q.Max == nil {
 		return ErrorNumericQueryNoBounds
 	}
 	return nil
@@ -0,0 +1,43 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package bleve
+
+import (
+	"github.com/blevesearch/bleve/index"
+	"github.com/blevesearch/bleve/search"
+	"github.com/blevesearch/bleve/search/searchers"
+)
+
+// matchesNone reports whether s is known to match no
+// documents, either because it is a MatchNoneSearcher or
+// because it is for a term no document in the index has.
+// Other searchers only estimate their Count, so they are
+// never treated as matching nothing.
+func matchesNone(s search.Searcher) bool {
+	switch s := s.(type) {
+	case *searchers.MatchNoneSearcher:
+		return true
+	case *searchers.TermSearcher:
+		return s.Count() == 0
+	}
+	return false
+}
+
+// matchNoneSearcher closes the searchers ss, which are no
+// longer needed, and returns a MatchNoneSearcher in place
+// of the compound searcher they were built for.
+func matchNoneSearcher(i index.IndexReader, ss []search.Searcher) (search.Searcher, error) {
+	for _, s := range ss {
+		if s != nil {
+			s.Close()
+		}
+	}
+	return searchers.NewMatchNoneSearcher(i)
+}
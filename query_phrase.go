@@ -21,6 +21,7 @@ import (
 type phraseQuery struct {
 	TermQueries []Query `json:"terms"`
 	BoostVal    float64 `json:"boost,omitempty"`
+	Slop        int     `json:"slop,omitempty"`
 	terms       []string
 }
 
@@ -52,6 +53,16 @@ func (q *phraseQuery) SetBoost(b float64) Query {
 	return q
 }
 
+// SetSlop sets how many positions in total the terms may
+// be moved from the exact phrase and still match.  Closer
+// matches score higher.  Swapping two adjacent terms
+// takes a slop of 2.  The default of 0 only matches the
+// exact phrase.
+func (q *phraseQuery) SetSlop(s int) *phraseQuery {
+	q.Slop = s
+	return q
+}
+
 func (q *phraseQuery) Searcher(i index.IndexReader, m *IndexMapping, explain bool) (search.Searcher, error) {
 
 	conjunctionQuery := NewConjunctionQuery(q.TermQueries)
@@ -59,13 +70,21 @@ func (q *phraseQuery) Searcher(i index.IndexReader, m *IndexMapping, explain boo
 	if err != nil {
 		return nil, err
 	}
-	return searchers.NewPhraseSearcher(i, conjunctionSearcher.(*searchers.ConjunctionSearcher), q.terms)
+	cs, ok := conjunctionSearcher.(*searchers.ConjunctionSearcher)
+	if !ok {
+		// a term of the phrase is not in the index
+		return conjunctionSearcher, nil
+	}
+	return searchers.NewSloppyPhraseSearcher(i, cs, q.terms, q.Slop)
 }
 
 func (q *phraseQuery) Validate() error {
 	if len(q.TermQueries) < 1 {
 		return ErrorPhraseQueryNoTerms
 	}
+	if q.Slop < 0 {
+		return ErrorPhraseQueryNegativeSlop
+	}
 	return nil
 }
 
@@ -73,6 +92,7 @@ func (q *phraseQuery) UnmarshalJSON(data []byte) error {
 	tmp := struct {
 		Terms    []json.RawMessage `json:"terms"`
 		BoostVal float64           `json:"boost,omitempty"`
+		Slop     int               `json:"slop,omitempty"`
 	}{}
 	err := json.Unmarshal(data, &tmp)
 	if err != nil {
@@ -96,6 +116,7 @@ func (q *phraseQuery) UnmarshalJSON(data []byte) error {
 	if q.BoostVal == 0 {
 		q.BoostVal = 1
 	}
+	q.Slop = tmp.Slop
 	return nil
 }
 
@@ -0,0 +1,69 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package bleve
+
+import (
+	"github.com/blevesearch/bleve/index"
+	"github.com/blevesearch/bleve/search"
+	"github.com/blevesearch/bleve/search/searchers"
+)
+
+type rawTermQuery struct {
+	RawTerm  string  `json:"raw_term"`
+	FieldVal string  `json:"field,omitempty"`
+	BoostVal float64 `json:"boost,omitempty"`
+}
+
+// NewRawTermQuery creates a new Query for finding the
+// documents where the whole value of the field is
+// exactly term, case included.  It searches the raw
+// sub-field indexed next to text fields mapped with
+// IncludeRaw, so it matches whatever the analyzer of
+// the field does to the value.
+func NewRawTermQuery(term string) *rawTermQuery {
+	return &rawTermQuery{
+		RawTerm:  term,
+		BoostVal: 1.0,
+	}
+}
+
+func (q *rawTermQuery) Boost() float64 {
+	return q.BoostVal
+}
+
+func (q *rawTermQuery) SetBoost(b float64) Query {
+	q.BoostVal = b
+	return q
+}
+
+func (q *rawTermQuery) Field() string {
+	return q.FieldVal
+}
+
+func (q *rawTermQuery) SetField(f string) Query {
+	q.FieldVal = f
+	return q
+}
+
+func (q *rawTermQuery) rawField(m *IndexMapping) string {
+	field := q.FieldVal
+	if q.FieldVal == "" {
+		field = m.DefaultField
+	}
+	return field + RawFieldSuffix
+}
+
+func (q *rawTermQuery) Searcher(i index.IndexReader, m *IndexMapping, explain bool) (search.Searcher, error) {
+	return searchers.NewTermSearcher(i, q.RawTerm, q.rawField(m), q.BoostVal, explain)
+}
+
+func (q *rawTermQuery) Validate() error {
+	return nil
+}
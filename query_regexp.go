@@ -0,0 +1,94 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package bleve
+
+import (
+	"regexp"
+
+	"github.com/blevesearch/bleve/index"
+	"github.com/blevesearch/bleve/search"
+	"github.com/blevesearch/bleve/search/searchers"
+)
+
+type regexpQuery struct {
+	Regexp             string  `json:"regexp"`
+	FieldVal           string  `json:"field,omitempty"`
+	BoostVal           float64 `json:"boost,omitempty"`
+	CaseInsensitiveVal bool    `json:"case_insensitive,omitempty"`
+}
+
+// NewRegexpQuery creates a new Query which finds
+// documents containing terms that match the
+// specified regular expression.  The expression
+// must match the whole term.
+func NewRegexpQuery(regexp string) *regexpQuery {
+	return &regexpQuery{
+		Regexp:   regexp,
+		BoostVal: 1.0,
+	}
+}
+
+func (q *regexpQuery) Boost() float64 {
+	return q.BoostVal
+}
+
+func (q *regexpQuery) SetBoost(b float64) Query {
+	q.BoostVal = b
+	return q
+}
+
+func (q *regexpQuery) Field() string {
+	return q.FieldVal
+}
+
+func (q *regexpQuery) SetField(f string) Query {
+	q.FieldVal = f
+	return q
+}
+
+func (q *regexpQuery) CaseInsensitive() bool {
+	return q.CaseInsensitiveVal
+}
+
+// SetCaseInsensitive makes the expression ignore case.
+// The expression is matched against the indexed terms,
+// so this only makes a difference when the analyzer of
+// the field preserves case, the terms produced by a
+// lowercasing analyzer will only ever match lowercase
+// expressions.
+func (q *regexpQuery) SetCaseInsensitive(ci bool) Query {
+	q.CaseInsensitiveVal = ci
+	return q
+}
+
+func (q *regexpQuery) compile() (*regexp.Regexp, error) {
+	flags := ""
+	if q.CaseInsensitiveVal {
+		flags = "(?i)"
+	}
+	return regexp.Compile(flags + `^(?:` + q.Regexp + `)$`)
+}
+
+func (q *regexpQuery) Searcher(i index.IndexReader, m *IndexMapping, explain bool) (search.Searcher, error) {
+	field := q.FieldVal
+	if q.FieldVal == "" {
+		field = m.DefaultField
+	}
+	pattern, err := q.compile()
+	if err != nil {
+		return nil, err
+	}
+	return searchers.NewRegexpSearcher(i, pattern, field, q.BoostVal, explain)
+}
+
+func (q *regexpQuery) Validate() error {
+	_, err := q.compile()
+	return err
+}
@@ -1,6 +1,9 @@
 %{
 package bleve
-import "strconv"
+import (
+	"fmt"
+	"strconv"
+)
 
 func logDebugGrammar(format string, v ...interface{}) {
 	if debugParser {
@@ -127,6 +130,25 @@ tSTRING tCOLON tSTRING {
 	$$ = q
 }
 |
+tSTRING tCOLON tSTRING tTILDE {
+	field := $1
+	str := $3
+	logDebugGrammar("FIELD - %s FUZZY STRING - %s", field, str)
+	q := NewMatchQuery(str)
+	q.SetFuzziness(1)
+	$$ = q.SetField(field)
+}
+|
+tSTRING tCOLON tSTRING tTILDENUMBER {
+	field := $1
+	str := $3
+	fuzziness, _ := strconv.ParseFloat($4, 64)
+	logDebugGrammar("FIELD - %s FUZZY STRING - %s", field, str)
+	q := NewMatchQuery(str)
+	q.SetFuzziness(int(fuzziness))
+	$$ = q.SetField(field)
+}
+|
 tSTRING tCOLON tNUMBER {
 	field := $1
 	str := $3
@@ -182,6 +204,9 @@ tSTRING tCOLON tLESS tEQUAL tNUMBER {
 searchBoost:
 tBOOST tNUMBER {
 	boost, _ := strconv.ParseFloat($2, 64)
+	if boost < 0 {
+		yylex.Error(fmt.Sprintf("invalid boost %s, must not be negative", $2))
+	}
 	$$ = boost
 	logDebugGrammar("BOOST %f", boost)
 };
@@ -1,9 +1,13 @@
+//line query_string.y:2
 package bleve
 
 import __yyfmt__ "fmt"
 
 //line query_string.y:2
-import "strconv"
+import (
+	"fmt"
+	"strconv"
+)
 
 func logDebugGrammar(format string, v ...interface{}) {
 	if debugParser {
@@ -11,7 +15,7 @@ func logDebugGrammar(format string, v ...interface{}) {
 	}
 }
 
-//line query_string.y:12
+//line query_string.y:15
 type yySymType struct {
 	yys int
 	s   string
@@ -35,7 +39,10 @@ const tEQUAL = 57357
 const tTILDE = 57358
 const tTILDENUMBER = 57359
 
-var yyToknames = []string{
+var yyToknames = [...]string{
+	"$end",
+	"error",
+	"$unk",
 	"tSTRING",
 	"tPHRASE",
 	"tPLUS",
@@ -51,14 +58,15 @@ var yyToknames = []string{
 	"tTILDE",
 	"tTILDENUMBER",
 }
-var yyStatenames = []string{}
+
+var yyStatenames = [...]string{}
 
 const yyEofCode = 1
 const yyErrCode = 2
-const yyMaxDepth = 200
+const yyInitialStackSize = 16
 
 //line yacctab:1
-var yyExca = []int{
+var yyExca = [...]int8{
 	-1, 1,
 	1, -1,
 	-2, 0,
@@ -67,88 +75,112 @@ var yyExca = []int{
 	-2, 5,
 }
 
-const yyNprod = 24
 const yyPrivate = 57344
 
-var yyTokenNames []string
-var yyStates []string
-
-const yyLast = 30
+const yyLast = 32
 
-var yyAct = []int{
-
-	18, 27, 20, 22, 28, 30, 10, 12, 16, 17,
-	21, 23, 24, 25, 11, 29, 26, 19, 15, 6,
-	7, 2, 3, 1, 14, 8, 5, 4, 13, 9,
+var yyAct = [...]int8{
+	18, 25, 26, 20, 22, 32, 31, 29, 16, 17,
+	30, 21, 23, 24, 27, 10, 12, 28, 19, 15,
+	6, 7, 2, 11, 3, 1, 8, 14, 5, 4,
+	13, 9,
 }
-var yyPact = []int{
 
-	13, -1000, -1000, 13, 2, -1000, -1000, -1000, -1000, 9,
-	-8, -1000, -1000, -1000, -1000, 5, -1000, -1000, -2, -1000,
-	-1000, -1000, -1000, 1, -11, -1000, 3, -1000, -7, -1000,
-	-1000,
+var yyPact = [...]int16{
+	14, -32768, -32768, 14, 11, -32768, -32768, -32768, -32768, 10,
+	-8, -32768, -32768, -32768, -32768, 6, -32768, -32768, -1, -32768,
+	-15, -32768, -32768, 2, -5, -32768, -32768, -32768, -6, -32768,
+	-7, -32768, -32768,
 }
-var yyPgo = []int{
 
-	0, 29, 28, 27, 26, 24, 23, 21, 22,
+var yyPgo = [...]int8{
+	0, 31, 30, 29, 28, 27, 25, 22, 24,
 }
-var yyR1 = []int{
 
+var yyR1 = [...]int8{
 	0, 6, 7, 7, 8, 3, 3, 4, 4, 1,
 	1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
-	1, 5, 2, 2,
+	1, 1, 1, 5, 2, 2,
 }
-var yyR2 = []int{
 
+var yyR2 = [...]int8{
 	0, 1, 2, 1, 3, 0, 1, 1, 1, 1,
-	2, 2, 1, 1, 3, 3, 3, 4, 5, 4,
-	5, 2, 0, 1,
+	2, 2, 1, 1, 3, 4, 4, 3, 3, 4,
+	5, 4, 5, 2, 0, 1,
 }
-var yyChk = []int{
 
-	-1000, -6, -7, -8, -3, -4, 6, 7, -7, -1,
+var yyChk = [...]int16{
+	-32768, -6, -7, -8, -3, -4, 6, 7, -7, -1,
 	4, 12, 5, -2, -5, 9, 16, 17, 8, 12,
-	4, 12, 5, 13, 14, 12, 15, 12, 15, 12,
-	12,
+	4, 12, 5, 13, 14, 16, 17, 12, 15, 12,
+	15, 12, 12,
 }
-var yyDef = []int{
 
-	5, -2, 1, -2, 0, 6, 7, 8, 2, 22,
-	9, 12, 13, 4, 23, 0, 10, 11, 0, 21,
-	14, 15, 16, 0, 0, 17, 0, 19, 0, 18,
-	20,
+var yyDef = [...]int8{
+	5, -2, 1, -2, 0, 6, 7, 8, 2, 24,
+	9, 12, 13, 4, 25, 0, 10, 11, 0, 23,
+	14, 17, 18, 0, 0, 15, 16, 19, 0, 21,
+	0, 20, 22,
 }
-var yyTok1 = []int{
 
+var yyTok1 = [...]int8{
 	1,
 }
-var yyTok2 = []int{
 
+var yyTok2 = [...]int8{
 	2, 3, 4, 5, 6, 7, 8, 9, 10, 11,
 	12, 13, 14, 15, 16, 17,
 }
-var yyTok3 = []int{
+
+var yyTok3 = [...]int8{
 	0,
 }
 
+var yyErrorMessages = [...]struct {
+	state int
+	token int
+	msg   string
+}{}
+
 //line yaccpar:1
 
 /*	parser for yacc output	*/
 
-var yyDebug = 0
+var (
+	yyDebug        = 0
+	yyErrorVerbose = false
+)
 
 type yyLexer interface {
 	Lex(lval *yySymType) int
 	Error(s string)
 }
 
-const yyFlag = -1000
+type yyParser interface {
+	Parse(yyLexer) int
+	Lookahead() int
+}
+
+type yyParserImpl struct {
+	lval  yySymType
+	stack [yyInitialStackSize]yySymType
+	char  int
+}
+
+func (p *yyParserImpl) Lookahead() int {
+	return p.char
+}
+
+func yyNewParser() yyParser {
+	return &yyParserImpl{}
+}
+
+const yyFlag = -32768
 
 func yyTokname(c int) string {
-	// 4 is TOKSTART above
-	if c >= 4 && c-4 < len(yyToknames) {
-		if yyToknames[c-4] != "" {
-			return yyToknames[c-4]
+	if c >= 1 && c-1 < len(yyToknames) {
+		if yyToknames[c-1] != "" {
+			return yyToknames[c-1]
 		}
 	}
 	return __yyfmt__.Sprintf("tok-%v", c)
@@ -163,51 +195,127 @@ func yyStatname(s int) string {
 	return __yyfmt__.Sprintf("state-%v", s)
 }
 
-func yylex1(lex yyLexer, lval *yySymType) int {
-	c := 0
-	char := lex.Lex(lval)
+func yyErrorMessage(state, lookAhead int) string {
+	const TOKSTART = 4
+
+	if !yyErrorVerbose {
+		return "syntax error"
+	}
+
+	for _, e := range yyErrorMessages {
+		if e.state == state && e.token == lookAhead {
+			return "syntax error: " + e.msg
+		}
+	}
+
+	res := "syntax error: unexpected " + yyTokname(lookAhead)
+
+	// To match Bison, suggest at most four expected tokens.
+	expected := make([]int, 0, 4)
+
+	// Look for shiftable tokens.
+	base := int(yyPact[state])
+	for tok := TOKSTART; tok-1 < len(yyToknames); tok++ {
+		if n := base + tok; n >= 0 && n < yyLast && int(yyChk[int(yyAct[n])]) == tok {
+			if len(expected) == cap(expected) {
+				return res
+			}
+			expected = append(expected, tok)
+		}
+	}
+
+	if yyDef[state] == -2 {
+		i := 0
+		for yyExca[i] != -1 || int(yyExca[i+1]) != state {
+			i += 2
+		}
+
+		// Look for tokens that we accept or reduce.
+		for i += 2; yyExca[i] >= 0; i += 2 {
+			tok := int(yyExca[i])
+			if tok < TOKSTART || yyExca[i+1] == 0 {
+				continue
+			}
+			if len(expected) == cap(expected) {
+				return res
+			}
+			expected = append(expected, tok)
+		}
+
+		// If the default action is to accept or reduce, give up.
+		if yyExca[i+1] != 0 {
+			return res
+		}
+	}
+
+	for i, tok := range expected {
+		if i == 0 {
+			res += ", expecting "
+		} else {
+			res += " or "
+		}
+		res += yyTokname(tok)
+	}
+	return res
+}
+
+func yylex1(lex yyLexer, lval *yySymType) (char, token int) {
+	token = 0
+	char = lex.Lex(lval)
 	if char <= 0 {
-		c = yyTok1[0]
+		token = int(yyTok1[0])
 		goto out
 	}
 	if char < len(yyTok1) {
-		c = yyTok1[char]
+		token = int(yyTok1[char])
 		goto out
 	}
 	if char >= yyPrivate {
 		if char < yyPrivate+len(yyTok2) {
-			c = yyTok2[char-yyPrivate]
+			token = int(yyTok2[char-yyPrivate])
 			goto out
 		}
 	}
 	for i := 0; i < len(yyTok3); i += 2 {
-		c = yyTok3[i+0]
-		if c == char {
-			c = yyTok3[i+1]
+		token = int(yyTok3[i+0])
+		if token == char {
+			token = int(yyTok3[i+1])
 			goto out
 		}
 	}
 
 out:
-	if c == 0 {
-		c = yyTok2[1] /* unknown char */
+	if token == 0 {
+		token = int(yyTok2[1]) /* unknown char */
 	}
 	if yyDebug >= 3 {
-		__yyfmt__.Printf("lex %s(%d)\n", yyTokname(c), uint(char))
+		__yyfmt__.Printf("lex %s(%d)\n", yyTokname(token), uint(char))
 	}
-	return c
+	return char, token
 }
 
 func yyParse(yylex yyLexer) int {
+	return yyNewParser().Parse(yylex)
+}
+
+func (yyrcvr *yyParserImpl) Parse(yylex yyLexer) int {
 	var yyn int
-	var yylval yySymType
 	var yyVAL yySymType
-	yyS := make([]yySymType, yyMaxDepth)
+	var yyDollar []yySymType
+	_ = yyDollar // silence set and not used
+	yyS := yyrcvr.stack[:]
 
 	Nerrs := 0   /* number of errors */
 	Errflag := 0 /* error recovery flag */
 	yystate := 0
-	yychar := -1
+	yyrcvr.char = -1
+	yytoken := -1 // yyrcvr.char translated into internal numbering
+	defer func() {
+		// Make sure we report no lookahead when not parsing.
+		yystate = -1
+		yyrcvr.char = -1
+		yytoken = -1
+	}()
 	yyp := -1
 	goto yystack
 
@@ -220,7 +328,7 @@ ret1:
 yystack:
 	/* put a state and value onto the stack */
 	if yyDebug >= 4 {
-		__yyfmt__.Printf("char %v in %v\n", yyTokname(yychar), yyStatname(yystate))
+		__yyfmt__.Printf("char %v in %v\n", yyTokname(yytoken), yyStatname(yystate))
 	}
 
 	yyp++
@@ -233,21 +341,22 @@ yystack:
 	yyS[yyp].yys = yystate
 
 yynewstate:
-	yyn = yyPact[yystate]
+	yyn = int(yyPact[yystate])
 	if yyn <= yyFlag {
 		goto yydefault /* simple state */
 	}
-	if yychar < 0 {
-		yychar = yylex1(yylex, &yylval)
+	if yyrcvr.char < 0 {
+		yyrcvr.char, yytoken = yylex1(yylex, &yyrcvr.lval)
 	}
-	yyn += yychar
+	yyn += yytoken
 	if yyn < 0 || yyn >= yyLast {
 		goto yydefault
 	}
-	yyn = yyAct[yyn]
-	if yyChk[yyn] == yychar { /* valid shift */
-		yychar = -1
-		yyVAL = yylval
+	yyn = int(yyAct[yyn])
+	if int(yyChk[yyn]) == yytoken { /* valid shift */
+		yyrcvr.char = -1
+		yytoken = -1
+		yyVAL = yyrcvr.lval
 		yystate = yyn
 		if Errflag > 0 {
 			Errflag--
@@ -257,27 +366,27 @@ yynewstate:
 
 yydefault:
 	/* default state action */
-	yyn = yyDef[yystate]
+	yyn = int(yyDef[yystate])
 	if yyn == -2 {
-		if yychar < 0 {
-			yychar = yylex1(yylex, &yylval)
+		if yyrcvr.char < 0 {
+			yyrcvr.char, yytoken = yylex1(yylex, &yyrcvr.lval)
 		}
 
 		/* look through exception table */
 		xi := 0
 		for {
-			if yyExca[xi+0] == -1 && yyExca[xi+1] == yystate {
+			if yyExca[xi+0] == -1 && int(yyExca[xi+1]) == yystate {
 				break
 			}
 			xi += 2
 		}
 		for xi += 2; ; xi += 2 {
-			yyn = yyExca[xi+0]
-			if yyn < 0 || yyn == yychar {
+			yyn = int(yyExca[xi+0])
+			if yyn < 0 || yyn == yytoken {
 				break
 			}
 		}
-		yyn = yyExca[xi+1]
+		yyn = int(yyExca[xi+1])
 		if yyn < 0 {
 			goto ret0
 		}
@@ -286,11 +395,11 @@ yydefault:
 		/* error ... attempt to resume parsing */
 		switch Errflag {
 		case 0: /* brand new error */
-			yylex.Error("syntax error")
+			yylex.Error(yyErrorMessage(yystate, yytoken))
 			Nerrs++
 			if yyDebug >= 1 {
 				__yyfmt__.Printf("%s", yyStatname(yystate))
-				__yyfmt__.Printf(" saw %s\n", yyTokname(yychar))
+				__yyfmt__.Printf(" saw %s\n", yyTokname(yytoken))
 			}
 			fallthrough
 
@@ -299,10 +408,10 @@ yydefault:
 
 			/* find a state where "error" is a legal shift action */
 			for yyp >= 0 {
-				yyn = yyPact[yyS[yyp].yys] + yyErrCode
+				yyn = int(yyPact[yyS[yyp].yys]) + yyErrCode
 				if yyn >= 0 && yyn < yyLast {
-					yystate = yyAct[yyn] /* simulate a shift of "error" */
-					if yyChk[yystate] == yyErrCode {
+					yystate = int(yyAct[yyn]) /* simulate a shift of "error" */
+					if int(yyChk[yystate]) == yyErrCode {
 						goto yystack
 					}
 				}
@@ -318,12 +427,13 @@ yydefault:
 
 		case 3: /* no shift yet; clobber input char */
 			if yyDebug >= 2 {
-				__yyfmt__.Printf("error recovery discards %s\n", yyTokname(yychar))
+				__yyfmt__.Printf("error recovery discards %s\n", yyTokname(yytoken))
 			}
-			if yychar == yyEofCode {
+			if yytoken == yyEofCode {
 				goto ret1
 			}
-			yychar = -1
+			yyrcvr.char = -1
+			yytoken = -1
 			goto yynewstate /* try again in the same state */
 		}
 	}
@@ -337,46 +447,57 @@ yydefault:
 	yypt := yyp
 	_ = yypt // guard against "declared and not used"
 
-	yyp -= yyR2[yyn]
+	yyp -= int(yyR2[yyn])
+	// yyp is now the index of $0. Perform the default action. Iff the
+	// reduced production is ε, $1 is possibly out of range.
+	if yyp+1 >= len(yyS) {
+		nyys := make([]yySymType, len(yyS)*2)
+		copy(nyys, yyS)
+		yyS = nyys
+	}
 	yyVAL = yyS[yyp+1]
 
 	/* consult goto table to find next state */
-	yyn = yyR1[yyn]
-	yyg := yyPgo[yyn]
+	yyn = int(yyR1[yyn])
+	yyg := int(yyPgo[yyn])
 	yyj := yyg + yyS[yyp].yys + 1
 
 	if yyj >= yyLast {
-		yystate = yyAct[yyg]
+		yystate = int(yyAct[yyg])
 	} else {
-		yystate = yyAct[yyj]
-		if yyChk[yystate] != -yyn {
-			yystate = yyAct[yyg]
+		yystate = int(yyAct[yyj])
+		if int(yyChk[yystate]) != -yyn {
+			yystate = int(yyAct[yyg])
 		}
 	}
 	// dummy call; replaced with literal code
 	switch yynt {
 
 	case 1:
-		//line query_string.y:34
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line query_string.y:37
 		{
 			logDebugGrammar("INPUT")
 		}
 	case 2:
-		//line query_string.y:39
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line query_string.y:42
 		{
 			logDebugGrammar("SEARCH PARTS")
 		}
 	case 3:
-		//line query_string.y:43
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line query_string.y:46
 		{
 			logDebugGrammar("SEARCH PART")
 		}
 	case 4:
-		//line query_string.y:48
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line query_string.y:51
 		{
-			query := yyS[yypt-1].q
-			query.SetBoost(yyS[yypt-0].f)
-			switch yyS[yypt-2].n {
+			query := yyDollar[2].q
+			query.SetBoost(yyDollar[3].f)
+			switch yyDollar[1].n {
 			case queryShould:
 				yylex.(*lexerWrapper).query.AddShould(query)
 			case queryMust:
@@ -386,151 +507,196 @@ yydefault:
 			}
 		}
 	case 5:
-		//line query_string.y:63
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line query_string.y:66
 		{
 			yyVAL.n = queryShould
 		}
 	case 6:
-		//line query_string.y:67
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line query_string.y:70
 		{
-			yyVAL.n = yyS[yypt-0].n
+			yyVAL.n = yyDollar[1].n
 		}
 	case 7:
-		//line query_string.y:73
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line query_string.y:76
 		{
 			logDebugGrammar("PLUS")
 			yyVAL.n = queryMust
 		}
 	case 8:
-		//line query_string.y:78
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line query_string.y:81
 		{
 			logDebugGrammar("MINUS")
 			yyVAL.n = queryMustNot
 		}
 	case 9:
-		//line query_string.y:84
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line query_string.y:87
 		{
-			str := yyS[yypt-0].s
+			str := yyDollar[1].s
 			logDebugGrammar("STRING - %s", str)
 			q := NewMatchQuery(str)
 			yyVAL.q = q
 		}
 	case 10:
-		//line query_string.y:91
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line query_string.y:94
 		{
-			str := yyS[yypt-1].s
+			str := yyDollar[1].s
 			logDebugGrammar("STRING - %s", str)
 			q := NewMatchQuery(str)
 			q.SetFuzziness(1)
 			yyVAL.q = q
 		}
 	case 11:
-		//line query_string.y:99
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line query_string.y:102
 		{
-			str := yyS[yypt-1].s
-			fuzziness, _ := strconv.ParseFloat(yyS[yypt-0].s, 64)
+			str := yyDollar[1].s
+			fuzziness, _ := strconv.ParseFloat(yyDollar[2].s, 64)
 			logDebugGrammar("STRING - %s", str)
 			q := NewMatchQuery(str)
 			q.SetFuzziness(int(fuzziness))
 			yyVAL.q = q
 		}
 	case 12:
-		//line query_string.y:108
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line query_string.y:111
 		{
-			str := yyS[yypt-0].s
+			str := yyDollar[1].s
 			logDebugGrammar("STRING - %s", str)
 			q := NewMatchQuery(str)
 			yyVAL.q = q
 		}
 	case 13:
-		//line query_string.y:115
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line query_string.y:118
 		{
-			phrase := yyS[yypt-0].s
+			phrase := yyDollar[1].s
 			logDebugGrammar("PHRASE - %s", phrase)
 			q := NewMatchPhraseQuery(phrase)
 			yyVAL.q = q
 		}
 	case 14:
-		//line query_string.y:122
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line query_string.y:125
 		{
-			field := yyS[yypt-2].s
-			str := yyS[yypt-0].s
+			field := yyDollar[1].s
+			str := yyDollar[3].s
 			logDebugGrammar("FIELD - %s STRING - %s", field, str)
 			q := NewMatchQuery(str).SetField(field)
 			yyVAL.q = q
 		}
 	case 15:
-		//line query_string.y:130
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line query_string.y:133
 		{
-			field := yyS[yypt-2].s
-			str := yyS[yypt-0].s
+			field := yyDollar[1].s
+			str := yyDollar[3].s
+			logDebugGrammar("FIELD - %s FUZZY STRING - %s", field, str)
+			q := NewMatchQuery(str)
+			q.SetFuzziness(1)
+			yyVAL.q = q.SetField(field)
+		}
+	case 16:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line query_string.y:142
+		{
+			field := yyDollar[1].s
+			str := yyDollar[3].s
+			fuzziness, _ := strconv.ParseFloat(yyDollar[4].s, 64)
+			logDebugGrammar("FIELD - %s FUZZY STRING - %s", field, str)
+			q := NewMatchQuery(str)
+			q.SetFuzziness(int(fuzziness))
+			yyVAL.q = q.SetField(field)
+		}
+	case 17:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line query_string.y:152
+		{
+			field := yyDollar[1].s
+			str := yyDollar[3].s
 			logDebugGrammar("FIELD - %s STRING - %s", field, str)
 			q := NewMatchQuery(str).SetField(field)
 			yyVAL.q = q
 		}
-	case 16:
-		//line query_string.y:138
+	case 18:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line query_string.y:160
 		{
-			field := yyS[yypt-2].s
-			phrase := yyS[yypt-0].s
+			field := yyDollar[1].s
+			phrase := yyDollar[3].s
 			logDebugGrammar("FIELD - %s PHRASE - %s", field, phrase)
 			q := NewMatchPhraseQuery(phrase).SetField(field)
 			yyVAL.q = q
 		}
-	case 17:
-		//line query_string.y:146
+	case 19:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line query_string.y:168
 		{
-			field := yyS[yypt-3].s
-			min, _ := strconv.ParseFloat(yyS[yypt-0].s, 64)
+			field := yyDollar[1].s
+			min, _ := strconv.ParseFloat(yyDollar[4].s, 64)
 			minInclusive := false
 			logDebugGrammar("FIELD - GREATER THAN %f", min)
 			q := NewNumericRangeInclusiveQuery(&min, nil, &minInclusive, nil).SetField(field)
 			yyVAL.q = q
 		}
-	case 18:
-		//line query_string.y:155
+	case 20:
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line query_string.y:177
 		{
-			field := yyS[yypt-4].s
-			min, _ := strconv.ParseFloat(yyS[yypt-0].s, 64)
+			field := yyDollar[1].s
+			min, _ := strconv.ParseFloat(yyDollar[5].s, 64)
 			minInclusive := true
 			logDebugGrammar("FIELD - GREATER THAN OR EQUAL %f", min)
 			q := NewNumericRangeInclusiveQuery(&min, nil, &minInclusive, nil).SetField(field)
 			yyVAL.q = q
 		}
-	case 19:
-		//line query_string.y:164
+	case 21:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line query_string.y:186
 		{
-			field := yyS[yypt-3].s
-			max, _ := strconv.ParseFloat(yyS[yypt-0].s, 64)
+			field := yyDollar[1].s
+			max, _ := strconv.ParseFloat(yyDollar[4].s, 64)
 			maxInclusive := false
 			logDebugGrammar("FIELD - LESS THAN %f", max)
 			q := NewNumericRangeInclusiveQuery(nil, &max, nil, &maxInclusive).SetField(field)
 			yyVAL.q = q
 		}
-	case 20:
-		//line query_string.y:173
+	case 22:
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line query_string.y:195
 		{
-			field := yyS[yypt-4].s
-			max, _ := strconv.ParseFloat(yyS[yypt-0].s, 64)
+			field := yyDollar[1].s
+			max, _ := strconv.ParseFloat(yyDollar[5].s, 64)
 			maxInclusive := true
 			logDebugGrammar("FIELD - LESS THAN OR EQUAL %f", max)
 			q := NewNumericRangeInclusiveQuery(nil, &max, nil, &maxInclusive).SetField(field)
 			yyVAL.q = q
 		}
-	case 21:
-		//line query_string.y:183
+	case 23:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line query_string.y:205
 		{
-			boost, _ := strconv.ParseFloat(yyS[yypt-0].s, 64)
+			boost, _ := strconv.ParseFloat(yyDollar[2].s, 64)
+			if boost < 0 {
+				yylex.Error(fmt.Sprintf("invalid boost %s, must not be negative", yyDollar[2].s))
+			}
 			yyVAL.f = boost
 			logDebugGrammar("BOOST %f", boost)
 		}
-	case 22:
-		//line query_string.y:190
+	case 24:
+		yyDollar = yyS[yypt-0 : yypt+1]
+//line query_string.y:215
 		{
 			yyVAL.f = 1.0
 		}
-	case 23:
-		//line query_string.y:194
+	case 25:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line query_string.y:219
 		{
 
 		}
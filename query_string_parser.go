@@ -12,7 +12,7 @@
 //go:generate sed -i "" -e s/Newlexer/newLexer/g query_string.nn.go
 //go:generate sed -i "" -e s/debuglexer/debugLexer/g query_string.nn.go
 //go:generate go fmt query_string.nn.go
-//go:generate go tool yacc -o query_string.y.go query_string.y
+//go:generate goyacc -o query_string.y.go query_string.y
 //go:generate sed -i "" -e 1d query_string.y.go
 
 package bleve
@@ -25,6 +25,11 @@ import (
 var debugParser bool
 var debugLexer bool
 
+func init() {
+	// report the unexpected token in syntax errors
+	yyErrorVerbose = true
+}
+
 func parseQuerySyntax(query string, mapping *IndexMapping) (rq Query, err error) {
 	lex := newLexerWrapper(newLexer(strings.NewReader(query)))
 	doParse(lex)
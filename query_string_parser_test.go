@@ -196,6 +196,60 @@ func TestQuerySyntaxParserValid(t *testing.T) {
 				},
 				nil),
 		},
+		{
+			input:   "field:watex~",
+			mapping: NewIndexMapping(),
+			result: NewBooleanQuery(
+				nil,
+				[]Query{
+					NewMatchQuery("watex").SetFuzziness(1).SetField("field"),
+				},
+				nil),
+		},
+		{
+			input:   "field:watex~2",
+			mapping: NewIndexMapping(),
+			result: NewBooleanQuery(
+				nil,
+				[]Query{
+					NewMatchQuery("watex").SetFuzziness(2).SetField("field"),
+				},
+				nil),
+		},
+		{
+			input:   "title:fox^3 quik~2",
+			mapping: NewIndexMapping(),
+			result: NewBooleanQuery(
+				nil,
+				[]Query{
+					NewMatchQuery("fox").SetField("title").SetBoost(3.0),
+					NewMatchQuery("quik").SetFuzziness(2),
+				},
+				nil),
+		},
+		{
+			input:   `test^0.5 "test phrase"^2`,
+			mapping: NewIndexMapping(),
+			result: NewBooleanQuery(
+				nil,
+				[]Query{
+					NewMatchQuery("test").SetBoost(0.5),
+					NewMatchPhraseQuery("test phrase").SetBoost(2.0),
+				},
+				nil),
+		},
+		{
+			input:   "watex~2^3 +field:watex~^1.5",
+			mapping: NewIndexMapping(),
+			result: NewBooleanQuery(
+				[]Query{
+					NewMatchQuery("watex").SetFuzziness(1).SetField("field").SetBoost(1.5),
+				},
+				[]Query{
+					NewMatchQuery("watex").SetFuzziness(2).SetBoost(3.0),
+				},
+				nil),
+		},
 	}
 
 	for _, test := range tests {
@@ -216,6 +270,14 @@ func TestQuerySyntaxParserInvalid(t *testing.T) {
 	}{
 		{"^"},
 		{"^5"},
+		{"test^"},
+		{"test^abc"},
+		{"test^-2"},
+		{"test^3^4"},
+		{"~"},
+		{"~2"},
+		{"field:~2"},
+		{"33~2"},
 	}
 
 	for _, test := range tests {
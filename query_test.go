@@ -12,12 +12,17 @@ package bleve
 import (
 	"reflect"
 	"testing"
+
+	"github.com/blevesearch/bleve/geo"
 )
 
 var minNum = 5.1
 var maxNum = 7.1
 var startDate = "2011-01-01"
 var endDate = "2012-01-01"
+var weekAgo = "now-7d/d"
+var nowDate = "now"
+var thirty = 30.0
 
 func TestParseQuery(t *testing.T) {
 	tests := []struct {
@@ -37,6 +42,18 @@ func TestParseQuery(t *testing.T) {
 			input:  []byte(`{"match_phrase":"light beer","field":"desc"}`),
 			output: NewMatchPhraseQuery("light beer").SetField("desc"),
 		},
+		{
+			input:  []byte(`{"match_phrase_prefix":"light be","field":"desc","max_expansions":10}`),
+			output: NewMatchPhrasePrefixQuery("light be").SetMaxExpansions(10).SetField("desc"),
+		},
+		{
+			input:  []byte(`{"match_all":{}}`),
+			output: NewMatchAllQuery(),
+		},
+		{
+			input:  []byte(`{"match_none":{},"boost":2}`),
+			output: NewMatchNoneQuery().SetBoost(2),
+		},
 		{
 			input: []byte(`{"must":{"conjuncts": [{"match":"beer","field":"desc"}]},"should":{"disjuncts": [{"match":"water","field":"desc"}],"min":1.0},"must_not":{"disjuncts": [{"match":"devon","field":"desc"}]}}`),
 			output: NewBooleanQuery(
@@ -48,6 +65,22 @@ func TestParseQuery(t *testing.T) {
 			input:  []byte(`{"terms":[{"term":"watered","field":"desc"},{"term":"down","field":"desc"}]}`),
 			output: NewPhraseQuery([]string{"watered", "down"}, "desc"),
 		},
+		{
+			input:  []byte(`{"terms":[{"term":"watered","field":"desc"},{"term":"down","field":"desc"}],"slop":2}`),
+			output: NewPhraseQuery([]string{"watered", "down"}, "desc").SetSlop(2),
+		},
+		{
+			input:  []byte(`{"like_id":"beer-1","fields":["desc"],"max_query_terms":10}`),
+			output: NewMoreLikeThisQuery("beer-1").SetFields([]string{"desc"}).SetMaxQueryTerms(10),
+		},
+		{
+			input:  []byte(`{"like_text":"light beer","min_term_freq":2,"min_doc_freq":3}`),
+			output: NewMoreLikeThisTextQuery("light beer").SetMinTermFreq(2).SetMinDocFreq(3),
+		},
+		{
+			input:  []byte(`{"cidr":"10.0.0.0/8","field":"client"}`),
+			output: NewCIDRQuery("10.0.0.0/8").SetField("client"),
+		},
 		{
 			input:  []byte(`{"query":"+beer \"light beer\" -devon"}`),
 			output: NewQueryStringQuery(`+beer "light beer" -devon`),
@@ -60,10 +93,73 @@ func TestParseQuery(t *testing.T) {
 			input:  []byte(`{"start":"` + startDate + `","end":"` + endDate + `","field":"desc"}`),
 			output: NewDateRangeQuery(&startDate, &endDate).SetField("desc"),
 		},
+		{
+			input:  []byte(`{"start":"now-7d/d","end":"now","field":"desc","time_zone":"Europe/Paris"}`),
+			output: NewDateRangeQuery(&weekAgo, &nowDate).SetTimeZone("Europe/Paris").SetField("desc"),
+		},
 		{
 			input:  []byte(`{"prefix":"budwei","field":"desc"}`),
 			output: NewPrefixQuery("budwei").SetField("desc"),
 		},
+		{
+			input:  []byte(`{"ids":["a","b"]}`),
+			output: NewDocIDQuery([]string{"a", "b"}),
+		},
+		{
+			input:  []byte(`{"multi_match":"beer","fields":["title^2","desc"],"type":"most_fields"}`),
+			output: NewMultiMatchQuery("beer", []string{"title^2", "desc"}).SetType(MultiMatchMostFields),
+		},
+		{
+			input:  []byte(`{"raw_term":"Apple Pie","field":"name"}`),
+			output: NewRawTermQuery("Apple Pie").SetField("name"),
+		},
+		{
+			input:  []byte(`{"token_type":"EMAIL","text":"bob@example.com","field":"desc"}`),
+			output: NewTokenTypeQuery("EMAIL", "bob@example.com").SetField("desc"),
+		},
+		{
+			input:  []byte(`{"dis_max":[{"term":"beer","field":"title"},{"term":"beer","field":"desc"}],"tie_breaker":0.3}`),
+			output: NewDisjunctionMaxQuery([]Query{NewTermQuery("beer").SetField("title"), NewTermQuery("beer").SetField("desc")}).SetTieBreaker(0.3),
+		},
+		{
+			input:  []byte(`{"constant_score":{"prefix":"budwei","field":"desc"},"boost":2.0}`),
+			output: NewConstantScoreQuery(NewPrefixQuery("budwei").SetField("desc")).SetBoost(2.0),
+		},
+		{
+			input:  []byte(`{"term":"budwiser","fuzziness":1,"prefix_length":2,"transpositions":true,"field":"desc"}`),
+			output: NewFuzzyQuery("budwiser").SetPrefix(2).(*fuzzyQuery).SetTranspositions(true).SetField("desc"),
+		},
+		{
+			input:  []byte(`{"regexp":"budw.*","field":"desc","case_insensitive":true}`),
+			output: NewRegexpQuery("budw.*").SetField("desc").(*regexpQuery).SetCaseInsensitive(true),
+		},
+		{
+			input: []byte(`{"polygon_points":[{"lon":0,"lat":0},{"lon":10,"lat":0},{"lon":10,"lat":10}],"field":"location"}`),
+			output: NewGeoBoundingPolygonQuery([]geo.Point{
+				{Lon: 0, Lat: 0},
+				{Lon: 10, Lat: 0},
+				{Lon: 10, Lat: 10},
+			}).SetField("location"),
+		},
+		{
+			input:  []byte(`{"wildcard":"bud?vi*","field":"desc"}`),
+			output: NewWildcardQuery("bud?vi*").SetField("desc"),
+		},
+		{
+			input:  []byte(`{"field_exists":"desc"}`),
+			output: NewFieldExistsQuery("desc"),
+		},
+		{
+			input:  []byte(`{"field_missing":"desc","boost":2.0}`),
+			output: NewFieldMissingQuery("desc").SetBoost(2.0),
+		},
+		{
+			input: []byte(`{"nested":"authors","clauses":[{"match":"marty","field":"authors.name"},{"min":30,"field":"authors.age"}]}`),
+			output: NewNestedQuery("authors", []Query{
+				NewMatchQuery("marty").SetField("authors.name"),
+				NewNumericRangeQuery(&thirty, nil).SetField("authors.age"),
+			}),
+		},
 		{
 			input:  []byte(`{"madeitup":"queryhere"}`),
 			output: nil,
@@ -105,6 +201,10 @@ func TestSetGetField(t *testing.T) {
 			query: NewMatchPhraseQuery("light beer").SetField("desc"),
 			field: "desc",
 		},
+		{
+			query: NewMatchPhrasePrefixQuery("light be").SetField("desc"),
+			field: "desc",
+		},
 		{
 			query: NewNumericRangeQuery(&minNum, &maxNum).SetField("desc"),
 			field: "desc",
@@ -160,6 +260,14 @@ func TestQueryValidate(t *testing.T) {
 			query: NewPrefixQuery("budwei").SetField("desc"),
 			err:   nil,
 		},
+		{
+			query: NewRegexpQuery("budw.*").SetField("desc"),
+			err:   nil,
+		},
+		{
+			query: NewGeoBoundingPolygonQuery([]geo.Point{{Lon: 0, Lat: 0}, {Lon: 1, Lat: 1}, {Lon: 0, Lat: 0}}),
+			err:   ErrorGeoPolygonTooFewPoints,
+		},
 		{
 			query: NewQueryStringQuery(`+beer "light beer" -devon`),
 			err:   nil,
@@ -172,6 +280,30 @@ func TestQueryValidate(t *testing.T) {
 			query: NewPhraseQuery([]string{}, "field"),
 			err:   ErrorPhraseQueryNoTerms,
 		},
+		{
+			query: NewPhraseQuery([]string{"watered", "down"}, "desc").SetSlop(-1),
+			err:   ErrorPhraseQueryNegativeSlop,
+		},
+		{
+			query: NewMoreLikeThisQuery("beer-1"),
+			err:   nil,
+		},
+		{
+			query: NewMoreLikeThisTextQuery(""),
+			err:   ErrorMoreLikeThisQueryNoLike,
+		},
+		{
+			query: NewCIDRQuery("2001:db8::/32"),
+			err:   nil,
+		},
+		{
+			query: NewCIDRQuery("10.0.0.1"),
+			err:   nil,
+		},
+		{
+			query: NewCIDRQuery("10.0.0.0/33"),
+			err:   ErrorCIDRQueryInvalid,
+		},
 		{
 			query: NewMatchNoneQuery().SetBoost(25),
 			err:   nil,
@@ -180,6 +312,42 @@ func TestQueryValidate(t *testing.T) {
 			query: NewMatchAllQuery().SetBoost(25),
 			err:   nil,
 		},
+		{
+			query: NewConstantScoreQuery(NewMatchQuery("beer").SetField("desc")),
+			err:   nil,
+		},
+		{
+			query: NewConstantScoreQuery(nil),
+			err:   ErrorConstantScoreQueryNoFilter,
+		},
+		{
+			query: NewFieldExistsQuery("desc"),
+			err:   nil,
+		},
+		{
+			query: NewFieldExistsQuery(""),
+			err:   ErrorFieldExistsQueryNoField,
+		},
+		{
+			query: NewFieldMissingQuery(""),
+			err:   ErrorFieldExistsQueryNoField,
+		},
+		{
+			query: NewConstantScoreQuery(NewNumericRangeQuery(nil, nil).SetField("desc")),
+			err:   ErrorNumericQueryNoBounds,
+		},
+		{
+			query: NewNestedQuery("authors", []Query{NewMatchQuery("marty").SetField("authors.name")}),
+			err:   nil,
+		},
+		{
+			query: NewNestedQuery("", []Query{NewMatchQuery("marty").SetField("authors.name")}),
+			err:   ErrorNestedQueryNoPath,
+		},
+		{
+			query: NewNestedQuery("authors", []Query{NewNumericRangeQuery(nil, nil).SetField("authors.age")}),
+			err:   ErrorNumericQueryNoBounds,
+		},
 		{
 			query: NewBooleanQuery(
 				[]Query{NewMatchQuery("beer").SetField("desc")},
@@ -209,6 +377,28 @@ func TestQueryValidate(t *testing.T) {
 				2.0),
 			err: ErrorDisjunctionFewerThanMinClauses,
 		},
+		{
+			query: NewDisjunctionMaxQuery(
+				[]Query{NewMatchQuery("beer").SetField("desc")}).
+				SetTieBreaker(1.5),
+			err: ErrorDisjunctionMaxTieBreakerRange,
+		},
+		{
+			query: NewMultiMatchQuery("beer", nil),
+			err:   ErrorMultiMatchQueryNoFields,
+		},
+		{
+			query: NewMultiMatchQuery("beer", []string{"title", "desc"}).SetType("phrase"),
+			err:   ErrorUnknownMultiMatchType,
+		},
+		{
+			query: NewMultiMatchQuery("beer", []string{"title", "desc"}).SetTieBreaker(-1),
+			err:   ErrorDisjunctionMaxTieBreakerRange,
+		},
+		{
+			query: NewTokenTypeQuery("PHONE", "555 1234"),
+			err:   ErrorUnknownTokenType,
+		},
 	}
 
 	for _, test := range tests {
@@ -0,0 +1,113 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package bleve
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/blevesearch/bleve/analysis/token_filters/token_type_filter"
+	"github.com/blevesearch/bleve/index"
+	"github.com/blevesearch/bleve/search"
+	"github.com/blevesearch/bleve/search/searchers"
+)
+
+type tokenTypeQuery struct {
+	TokenType string  `json:"token_type"`
+	Text      string  `json:"text,omitempty"`
+	FieldVal  string  `json:"field,omitempty"`
+	BoostVal  float64 `json:"boost,omitempty"`
+}
+
+// NewTokenTypeQuery creates a new Query which finds
+// documents containing tokens of the specified type,
+// one of the names of token_type_filter.TypeNames
+// such as "EMAIL", "URL" or "NUM".  The field must
+// be analyzed with the token_type filter, as the
+// url_email analyzer is.  If text is not empty, it
+// is analyzed with the analyzer of the field and
+// only tokens of the specified type match, so a
+// search for an email address does not match the
+// address where it is part of a URL.
+func NewTokenTypeQuery(tokenType, text string) *tokenTypeQuery {
+	return &tokenTypeQuery{
+		TokenType: tokenType,
+		Text:      text,
+		BoostVal:  1.0,
+	}
+}
+
+func (q *tokenTypeQuery) Boost() float64 {
+	return q.BoostVal
+}
+
+func (q *tokenTypeQuery) SetBoost(b float64) Query {
+	q.BoostVal = b
+	return q
+}
+
+func (q *tokenTypeQuery) Field() string {
+	return q.FieldVal
+}
+
+func (q *tokenTypeQuery) SetField(f string) Query {
+	q.FieldVal = f
+	return q
+}
+
+func (q *tokenTypeQuery) Searcher(i index.IndexReader, m *IndexMapping, explain bool) (search.Searcher, error) {
+	field := q.FieldVal
+	if q.FieldVal == "" {
+		field = m.DefaultField
+	}
+
+	tokenType, ok := token_type_filter.TypeNames[q.TokenType]
+	if !ok {
+		return nil, ErrorUnknownTokenType
+	}
+	prefix := token_type_filter.TypePrefix(tokenType)
+
+	if q.Text == "" {
+		return searchers.NewTermPrefixSearcher(i, string(prefix), field, q.BoostVal, explain)
+	}
+
+	analyzerName := m.analyzerNameForPath(field)
+	analyzer := m.analyzerNamed(analyzerName)
+	if analyzer == nil {
+		return nil, fmt.Errorf("no analyzer named '%s' registered", analyzerName)
+	}
+
+	tokens := m.analyzeQueryText(analyzerName, analyzer, q.Text)
+	tqs := make([]Query, 0, len(tokens))
+	for _, token := range tokens {
+		// skip the typed copies produced by the token_type filter
+		if token.Type != tokenType || bytes.HasPrefix(token.Term, prefix) {
+			continue
+		}
+		term := string(prefix) + string(token.Term)
+		tqs = append(tqs, NewTermQuery(term).
+			SetField(field).
+			SetBoost(q.BoostVal))
+	}
+	if len(tqs) > 0 {
+		shouldQuery := NewDisjunctionQueryMin(tqs, 1).
+			SetBoost(q.BoostVal)
+		return shouldQuery.Searcher(i, m, explain)
+	}
+	noneQuery := NewMatchNoneQuery()
+	return noneQuery.Searcher(i, m, explain)
+}
+
+func (q *tokenTypeQuery) Validate() error {
+	if _, ok := token_type_filter.TypeNames[q.TokenType]; !ok {
+		return ErrorUnknownTokenType
+	}
+	return nil
+}
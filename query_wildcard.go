@@ -0,0 +1,112 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package bleve
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+
+	"github.com/blevesearch/bleve/index"
+	"github.com/blevesearch/bleve/search"
+	"github.com/blevesearch/bleve/search/searchers"
+)
+
+type wildcardQuery struct {
+	Wildcard string  `json:"wildcard"`
+	FieldVal string  `json:"field,omitempty"`
+	BoostVal float64 `json:"boost,omitempty"`
+}
+
+// NewWildcardQuery creates a new Query which finds
+// documents containing terms that match the specified
+// wildcard pattern.  In the pattern * matches any
+// sequence of characters, including none, and ? matches
+// exactly one character.  Every other character only
+// matches itself, except \ which makes the character
+// following it, such as * or ?, match literally.  The
+// pattern must match the whole term.
+//
+// Only the terms starting with the characters before
+// the first wildcard are examined, so patterns starting
+// with a wildcard scan every term of the field.
+func NewWildcardQuery(wildcard string) *wildcardQuery {
+	return &wildcardQuery{
+		Wildcard: wildcard,
+		BoostVal: 1.0,
+	}
+}
+
+func (q *wildcardQuery) Boost() float64 {
+	return q.BoostVal
+}
+
+func (q *wildcardQuery) SetBoost(b float64) Query {
+	q.BoostVal = b
+	return q
+}
+
+func (q *wildcardQuery) Field() string {
+	return q.FieldVal
+}
+
+func (q *wildcardQuery) SetField(f string) Query {
+	q.FieldVal = f
+	return q
+}
+
+func (q *wildcardQuery) Searcher(i index.IndexReader, m *IndexMapping, explain bool) (search.Searcher, error) {
+	field := q.FieldVal
+	if q.FieldVal == "" {
+		field = m.DefaultField
+	}
+	if strings.HasPrefix(q.Wildcard, "*") || strings.HasPrefix(q.Wildcard, "?") {
+		logger.Printf("wildcard query '%s' starts with a wildcard, scanning all terms of field '%s'", q.Wildcard, field)
+	}
+	pattern, err := regexp.Compile(wildcardRegexp(q.Wildcard))
+	if err != nil {
+		return nil, err
+	}
+	return searchers.NewRegexpSearcher(i, pattern, field, q.BoostVal, explain)
+}
+
+// wildcardRegexp translates a wildcard pattern into
+// an anchored regular expression, a trailing escape
+// character matches itself
+func wildcardRegexp(wildcard string) string {
+	var buf bytes.Buffer
+	buf.WriteString(`(?s)^(?:`)
+	escaped := false
+	for _, r := range wildcard {
+		switch {
+		case escaped:
+			buf.WriteString(regexp.QuoteMeta(string(r)))
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '*':
+			buf.WriteString(`.*`)
+		case r == '?':
+			buf.WriteString(`.`)
+		default:
+			buf.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	if escaped {
+		buf.WriteString(`\\`)
+	}
+	buf.WriteString(`)$`)
+	return buf.String()
+}
+
+func (q *wildcardQuery) Validate() error {
+	_, err := regexp.Compile(wildcardRegexp(q.Wildcard))
+	return err
+}
@@ -16,6 +16,7 @@ import (
 
 	"github.com/blevesearch/bleve/analysis"
 	"github.com/blevesearch/bleve/search"
+	"github.com/blevesearch/bleve/search/facets"
 )
 
 type numericRange struct {
@@ -70,14 +71,34 @@ func (dr *dateTimeRange) UnmarshalJSON(input []byte) error {
 	return nil
 }
 
+// A dateHistogram buckets the dates of a field by a fixed
+// interval, see SetDateHistogram.
+type dateHistogram struct {
+	Interval     string `json:"interval"`
+	TimeZone     string `json:"time_zone,omitempty"`
+	EmptyBuckets bool   `json:"empty_buckets,omitempty"`
+}
+
 // A FacetRequest describes a facet or aggregation
 // of the result document set you would like to be
 // built.
+//
+// Order sets how the terms of a terms facet are listed,
+// one of "count_desc", the default, "count_asc",
+// "term_asc" and "term_desc".  Terms with the same count
+// are listed by ascending term.  The terms listed are
+// always the Size most frequent ones, Order only sorts
+// them.
+//
+// A date histogram facet lists all of its buckets by
+// ascending start, whatever the Size.
 type FacetRequest struct {
 	Size           int
 	Field          string
+	Order          string           `json:"order,omitempty"`
 	NumericRanges  []*numericRange  `json:"numeric_ranges,omitempty"`
 	DateTimeRanges []*dateTimeRange `json:"date_ranges,omitempty"`
+	DateHistogram  *dateHistogram   `json:"date_histogram,omitempty"`
 }
 
 // NewFacetRequest creates a facet on the specified
@@ -101,10 +122,31 @@ func (fr *FacetRequest) AddDateTimeRange(name string, start, end time.Time) {
 	fr.DateTimeRanges = append(fr.DateTimeRanges, &dateTimeRange{Name: name, Start: start, End: end})
 }
 
+// SetDateHistogram buckets a field containing date values
+// by interval, a count followed by one of the units "m"
+// for minutes, "h" for hours, "d" for days, "w" for weeks
+// starting on monday, "M" for months and "y" for years,
+// such as "1d".  The buckets start at the local times of
+// timeZone, the name of a time zone such as "Europe/Paris",
+// or UTC when empty.  Only buckets with documents are
+// listed, unless emptyBuckets, which lists the buckets
+// between the first and last ones with a count of 0.
+func (fr *FacetRequest) SetDateHistogram(interval, timeZone string, emptyBuckets bool) {
+	fr.DateHistogram = &dateHistogram{
+		Interval:     interval,
+		TimeZone:     timeZone,
+		EmptyBuckets: emptyBuckets,
+	}
+}
+
 // AddNumericRange adds a bucket to a field
 // containing numeric values.  Documents with a
 // numeric value falling into this range are
 // tabulated as part of this bucket/range.
+// The range includes min and excludes max, either
+// may be nil to leave that side unbounded.  Ranges
+// may overlap, in which case a document is counted
+// in each of them.
 func (fr *FacetRequest) AddNumericRange(name string, min, max *float64) {
 	if fr.NumericRanges == nil {
 		fr.NumericRanges = make([]*numericRange, 0, 1)
@@ -116,11 +158,43 @@ func (fr *FacetRequest) AddNumericRange(name string, min, max *float64) {
 // FacetRequest objects for a single query.
 type FacetsRequest map[string]*FacetRequest
 
+// A MetricAggregation computes a statistic over the
+// numeric values of a field in the matching documents,
+// one of the metrics search.MetricSum, MetricAvg,
+// MetricMin, MetricMax and MetricCount.  Documents
+// without the field are left out.
+type MetricAggregation struct {
+	Type  string `json:"type"`
+	Field string `json:"field"`
+}
+
+// NewMetricAggregation creates a MetricAggregation
+// computing the metric typ over field.
+func NewMetricAggregation(typ, field string) *MetricAggregation {
+	return &MetricAggregation{
+		Type:  typ,
+		Field: field,
+	}
+}
+
+// AggregationsRequest groups together all the
+// MetricAggregation objects for a single query.
+type AggregationsRequest map[string]*MetricAggregation
+
 // HighlightRequest describes how field matches
 // should be highlighted.
+// FragmentSize overrides the size, in bytes, of the
+// fragments chosen around matches, by highlighters
+// implementing highlight.ResizableHighlighter, which the
+// simple highlighter does for fragmenters implementing
+// highlight.ResizableFragmenter.  NumberOfFragments
+// is the maximum number of fragments returned per field,
+// 1 if unset.
 type HighlightRequest struct {
-	Style  *string  `json:"style"`
-	Fields []string `json:"fields"`
+	Style             *string  `json:"style"`
+	Fields            []string `json:"fields"`
+	FragmentSize      int      `json:"fragment_size,omitempty"`
+	NumberOfFragments int      `json:"number_of_fragments,omitempty"`
 }
 
 // NewHighlight creates a default
@@ -137,6 +211,13 @@ func NewHighlightWithStyle(style string) *HighlightRequest {
 	}
 }
 
+func (h *HighlightRequest) numberOfFragments() int {
+	if h.NumberOfFragments < 1 {
+		return 1
+	}
+	return h.NumberOfFragments
+}
+
 func (h *HighlightRequest) AddField(field string) {
 	if h.Fields == nil {
 		h.Fields = make([]string, 0, 1)
@@ -144,6 +225,12 @@ func (h *HighlightRequest) AddField(field string) {
 	h.Fields = append(h.Fields, field)
 }
 
+// Score normalizations of a SearchRequest
+const (
+	ScoreNormalizationNone = "none"
+	ScoreNormalizationMax  = "max"
+)
+
 // A SearchRequest describes all the parameters
 // needed to search the index.
 // Query is required.
@@ -154,6 +241,8 @@ func (h *HighlightRequest) AddField(field string) {
 // Fields describes a list of field values which
 // should be retrieved for result documents.
 // Facets describe the set of facets to be computed.
+// Aggregations describe the metrics to be computed,
+// while the hits are collected.
 // Explain triggers inclusion of additional search
 // result score explanations.
 //
@@ -166,6 +255,235 @@ type SearchRequest struct {
 	Fields    []string          `json:"fields"`
 	Facets    FacetsRequest     `json:"facets"`
 	Explain   bool              `json:"explain"`
+
+	Aggregations AggregationsRequest `json:"aggregations,omitempty"`
+
+	// IDsOnly returns hits with only their ID and score,
+	// skipping highlighting and the loading of stored
+	// fields requested by Fields.
+	IDsOnly bool `json:"ids_only,omitempty"`
+
+	// IncludeLocations returns the locations of the terms
+	// matched in each hit, by field and term, with their
+	// positions and offsets.  Locations are left out by
+	// default, they are still read to match phrases and
+	// to highlight.
+	IncludeLocations bool `json:"include_locations,omitempty"`
+
+	// CollapseField, when set, groups the results by the
+	// terms of this field, keeping the top CollapseSize
+	// (default 1) hits of each group.  Size and From then
+	// page through groups instead of hits.  Documents
+	// without the field form groups of their own unless
+	// CollapseExcludeMissing is set.
+	CollapseField          string `json:"collapse_field,omitempty"`
+	CollapseSize           int    `json:"collapse_size,omitempty"`
+	CollapseExcludeMissing bool   `json:"collapse_exclude_missing,omitempty"`
+
+	// Sort orders the hits, by descending score when
+	// empty.  It is not applied when collapsing.
+	Sort search.SortOrder `json:"sort,omitempty"`
+
+	// SearchAfter resumes the hits after the match with
+	// these sort values, one per level of Sort, as found
+	// in the Sort of the last hit of the previous page.
+	// Sort must include the document id to break ties.
+	SearchAfter []interface{} `json:"search_after,omitempty"`
+
+	// ScoreNormalization, when ScoreNormalizationMax, sets
+	// the NormalizedScore of each hit to its score divided
+	// by the MaxScore of the result, so the best match
+	// reads 1.  Scores and the order of the hits are left
+	// as they are.  When every match scores 0, so do the
+	// normalized scores.
+	ScoreNormalization string `json:"score_normalization,omitempty"`
+
+	// PostFilters restrict the hits to the documents
+	// matching all of them, see PostFilter.
+	PostFilters []*PostFilter `json:"post_filters,omitempty"`
+
+	// Parallelism, when above 1, collects the matches
+	// with up to this many goroutines, each searching a
+	// shard of the document ids with a reader of its own.
+	// It applies to requests ranking the hits by score,
+	// without Facets or PostFilters, and is ignored by
+	// others.  The hits and aggregations are those of a
+	// serial search.  Unless the store opens its readers
+	// at once, see store.KVMultiReader, the shards may not
+	// all see the writes made while searching.
+	Parallelism int `json:"parallelism,omitempty"`
+
+	// MinScore, when above 0, drops the matches scoring
+	// less, before they are counted in Total, facets and
+	// aggregations.  Every searcher returns its matches by
+	// id, not by descending score, so a match below MinScore
+	// says nothing of the ones after it: every match is
+	// still scored, MinScore filters rather than terminates
+	// the search.
+	MinScore float64 `json:"min_score,omitempty"`
+
+	// MaxClauseCount, when above 0, replaces the limit of
+	// Config.MaxClauseCount on the number of terms prefix,
+	// fuzzy, wildcard and regexp queries expand to.  Over
+	// the limit the search fails with
+	// searchers.ErrTooManyClauses, or with TruncateClauses
+	// only searches the first terms, in dictionary order,
+	// up to the limit.
+	MaxClauseCount  int  `json:"max_clause_count,omitempty"`
+	TruncateClauses bool `json:"truncate_clauses,omitempty"`
+}
+
+// A PostFilter restricts the hits of a search request
+// to the documents matching its Query, after the
+// facets on its Field are counted.  Facets on other
+// fields, aggregations and the total count only see
+// the documents matching all the post filters.  So a
+// facet on a field selected by a post filter still
+// lists every term of the field, as in multi-select
+// faceted navigation.
+type PostFilter struct {
+	Field string `json:"field"`
+	Query Query  `json:"query"`
+}
+
+// NewPostFilter creates a PostFilter for the Query,
+// left out of the counts of the facets on field.
+func NewPostFilter(field string, q Query) *PostFilter {
+	return &PostFilter{
+		Field: field,
+		Query: q,
+	}
+}
+
+// UnmarshalJSON deserializes a JSON representation of
+// a PostFilter
+func (f *PostFilter) UnmarshalJSON(input []byte) error {
+	var temp struct {
+		Field string          `json:"field"`
+		Q     json.RawMessage `json:"query"`
+	}
+	err := json.Unmarshal(input, &temp)
+	if err != nil {
+		return err
+	}
+	f.Field = temp.Field
+	if temp.Q != nil {
+		f.Query, err = ParseQuery(temp.Q)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddPostFilter restricts the hits to the documents
+// matching q, leaving it out of the counts of the
+// facets on field.
+func (r *SearchRequest) AddPostFilter(field string, q Query) {
+	r.PostFilters = append(r.PostFilters, NewPostFilter(field, q))
+}
+
+func (r *SearchRequest) validatePostFilters() error {
+	for _, filter := range r.PostFilters {
+		if filter.Query == nil {
+			return ErrorPostFilterNoQuery
+		}
+		err := filter.Query.Validate()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SortBy sets the order of the hits from a list of
+// field names, see search.ParseSortOrderStrings.
+// For example []string{"-_score", "name"} sorts by
+// descending score, then by name.
+func (r *SearchRequest) SortBy(order []string) {
+	r.Sort = search.ParseSortOrderStrings(order)
+}
+
+// SortByCustom sets the order of the hits.
+func (r *SearchRequest) SortByCustom(order search.SortOrder) {
+	r.Sort = order
+}
+
+// SetSearchAfter requests the hits following the
+// hit with the provided sort values.
+func (r *SearchRequest) SetSearchAfter(after []interface{}) {
+	r.SearchAfter = after
+}
+
+func (r *SearchRequest) validateSearchAfter() error {
+	if r.SearchAfter == nil {
+		return nil
+	}
+	if len(r.Sort) == 0 || r.CollapseField != "" {
+		return ErrorSearchAfterNeedsSort
+	}
+	if len(r.SearchAfter) != len(r.Sort) {
+		return ErrorSearchAfterSortMismatch
+	}
+	for _, s := range r.Sort {
+		if _, ok := s.(*search.SortDocID); ok {
+			return nil
+		}
+	}
+	return ErrorSearchAfterNoTiebreaker
+}
+
+func (r *SearchRequest) validateScoreNormalization() error {
+	switch r.ScoreNormalization {
+	case "", ScoreNormalizationNone, ScoreNormalizationMax:
+		return nil
+	}
+	return ErrorUnknownScoreNormalization
+}
+
+// SetCollapse collapses the results of this
+// SearchRequest on field, keeping size hits per group.
+func (r *SearchRequest) SetCollapse(field string, size int) {
+	r.CollapseField = field
+	r.CollapseSize = size
+}
+
+func (r *SearchRequest) validateFacets() error {
+	for _, facet := range r.Facets {
+		if !search.ValidTermFacetsOrder(facet.Order) {
+			return ErrorUnknownFacetOrder
+		}
+		if facet.DateHistogram != nil {
+			if !facets.ValidDateHistogramInterval(facet.DateHistogram.Interval) {
+				return ErrorUnknownDateHistogramInterval
+			}
+			_, err := time.LoadLocation(facet.DateHistogram.TimeZone)
+			if err != nil {
+				return ErrorUnknownTimeZone
+			}
+		}
+	}
+	return nil
+}
+
+func (r *SearchRequest) validateAggregations() error {
+	for _, aggregation := range r.Aggregations {
+		switch aggregation.Type {
+		case search.MetricSum, search.MetricAvg, search.MetricMin, search.MetricMax, search.MetricCount:
+		default:
+			return ErrorUnknownAggregationType
+		}
+	}
+	return nil
+}
+
+// AddAggregation adds a MetricAggregation to this
+// SearchRequest
+func (r *SearchRequest) AddAggregation(aggregationName string, a *MetricAggregation) {
+	if r.Aggregations == nil {
+		r.Aggregations = make(AggregationsRequest, 1)
+	}
+	r.Aggregations[aggregationName] = a
 }
 
 // AddFacet adds a FacetRequest to this SearchRequest
@@ -187,6 +505,29 @@ func (r *SearchRequest) UnmarshalJSON(input []byte) error {
 		Fields    []string          `json:"fields"`
 		Facets    FacetsRequest     `json:"facets"`
 		Explain   bool              `json:"explain"`
+		IDsOnly   bool              `json:"ids_only"`
+
+		IncludeLocations bool `json:"include_locations"`
+
+		Aggregations AggregationsRequest `json:"aggregations"`
+
+		CollapseField          string `json:"collapse_field"`
+		CollapseSize           int    `json:"collapse_size"`
+		CollapseExcludeMissing bool   `json:"collapse_exclude_missing"`
+
+		Sort        []json.RawMessage `json:"sort"`
+		SearchAfter []interface{}     `json:"search_after"`
+
+		ScoreNormalization string `json:"score_normalization"`
+
+		PostFilters []*PostFilter `json:"post_filters"`
+
+		Parallelism int `json:"parallelism"`
+
+		MinScore float64 `json:"min_score"`
+
+		MaxClauseCount  int  `json:"max_clause_count"`
+		TruncateClauses bool `json:"truncate_clauses"`
 	}
 
 	err := json.Unmarshal(input, &temp)
@@ -197,9 +538,28 @@ func (r *SearchRequest) UnmarshalJSON(input []byte) error {
 	r.Size = temp.Size
 	r.From = temp.From
 	r.Explain = temp.Explain
+	r.IDsOnly = temp.IDsOnly
+	r.IncludeLocations = temp.IncludeLocations
 	r.Highlight = temp.Highlight
 	r.Fields = temp.Fields
 	r.Facets = temp.Facets
+	r.Aggregations = temp.Aggregations
+	r.CollapseField = temp.CollapseField
+	r.CollapseSize = temp.CollapseSize
+	r.CollapseExcludeMissing = temp.CollapseExcludeMissing
+	r.SearchAfter = temp.SearchAfter
+	r.ScoreNormalization = temp.ScoreNormalization
+	r.PostFilters = temp.PostFilters
+	r.Parallelism = temp.Parallelism
+	r.MinScore = temp.MinScore
+	r.MaxClauseCount = temp.MaxClauseCount
+	r.TruncateClauses = temp.TruncateClauses
+	if temp.Sort != nil {
+		r.Sort, err = search.ParseSortOrderJSON(temp.Sort)
+		if err != nil {
+			return err
+		}
+	}
 	r.Query, err = ParseQuery(temp.Q)
 	if err != nil {
 		return err
@@ -244,6 +604,21 @@ type SearchResult struct {
 	MaxScore float64                        `json:"max_score"`
 	Took     time.Duration                  `json:"took"`
 	Facets   search.FacetResults            `json:"facets"`
+
+	Aggregations search.AggregationResults `json:"aggregations,omitempty"`
+
+	// Groups are only set for collapsed searches
+	Groups      search.DocumentMatchGroupCollection `json:"groups,omitempty"`
+	TotalGroups uint64                              `json:"total_groups,omitempty"`
+
+	// TimedOut is set when the context of the search was
+	// done before all the matches were collected, the hits
+	// are the best of those found by then
+	TimedOut bool `json:"timed_out,omitempty"`
+
+	// Errors are those of the indexes which failed, when
+	// an alias returns partial results
+	Errors []error `json:"-"`
 }
 
 func (sr *SearchResult) String() string {
@@ -277,4 +652,57 @@ func (sr *SearchResult) Merge(other *SearchResult) {
 		sr.MaxScore = other.MaxScore
 	}
 	sr.Facets.Merge(other.Facets)
+	if sr.Aggregations == nil {
+		sr.Aggregations = other.Aggregations
+	} else {
+		sr.Aggregations.Merge(other.Aggregations)
+	}
+	sr.mergeGroups(other)
+	sr.TimedOut = sr.TimedOut || other.TimedOut
+	sr.Errors = append(sr.Errors, other.Errors...)
+}
+
+// normalizeScores sets the NormalizedScore of the hits,
+// including those of the groups, as the normalization
+// requires
+func (sr *SearchResult) normalizeScores(normalization string) {
+	if normalization != ScoreNormalizationMax {
+		return
+	}
+	normalize := func(hits search.DocumentMatchCollection) {
+		for _, hit := range hits {
+			hit.NormalizedScore = 0
+			if sr.MaxScore > 0 {
+				hit.NormalizedScore = hit.Score / sr.MaxScore
+			}
+		}
+	}
+	normalize(sr.Hits)
+	for _, group := range sr.Groups {
+		normalize(group.Hits)
+	}
+}
+
+// mergeGroups combines groups sharing the same value,
+// TotalGroups only accounts for overlap between the
+// groups actually returned
+func (sr *SearchResult) mergeGroups(other *SearchResult) {
+	sr.TotalGroups += other.TotalGroups
+	for _, otherGroup := range other.Groups {
+		merged := false
+		if !otherGroup.Missing {
+			for _, group := range sr.Groups {
+				if !group.Missing && group.Value == otherGroup.Value {
+					group.Total += otherGroup.Total
+					group.Hits = append(group.Hits, otherGroup.Hits...)
+					sr.TotalGroups--
+					merged = true
+					break
+				}
+			}
+		}
+		if !merged {
+			sr.Groups = append(sr.Groups, otherGroup)
+		}
+	}
 }
@@ -0,0 +1,107 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package search
+
+import (
+	"github.com/blevesearch/bleve/index"
+)
+
+// Metrics computed by a metric aggregation
+const (
+	MetricSum   = "sum"
+	MetricAvg   = "avg"
+	MetricMin   = "min"
+	MetricMax   = "max"
+	MetricCount = "count"
+)
+
+// An AggregationBuilder computes a statistic over the
+// matching documents as they are collected, alongside
+// the facets of a FacetsBuilder.
+type AggregationBuilder interface {
+	Update(index.FieldTerms)
+	Result() *AggregationResult
+}
+
+// An AggregationResult holds the Value of a metric over
+// the numeric values of a field.  Count is the number of
+// values, and Missing the number of matching documents
+// without the field.  Sum, Min and Max are kept so results
+// from several indexes can be merged.
+type AggregationResult struct {
+	Field   string  `json:"field"`
+	Metric  string  `json:"metric"`
+	Value   float64 `json:"value"`
+	Count   int     `json:"count"`
+	Missing int     `json:"missing"`
+	Sum     float64 `json:"sum"`
+	Min     float64 `json:"min"`
+	Max     float64 `json:"max"`
+}
+
+// Add accounts for one more value.
+func (ar *AggregationResult) Add(value float64) {
+	if ar.Count == 0 || value < ar.Min {
+		ar.Min = value
+	}
+	if ar.Count == 0 || value > ar.Max {
+		ar.Max = value
+	}
+	ar.Sum += value
+	ar.Count++
+}
+
+func (ar *AggregationResult) Merge(other *AggregationResult) {
+	if other.Count > 0 {
+		if ar.Count == 0 || other.Min < ar.Min {
+			ar.Min = other.Min
+		}
+		if ar.Count == 0 || other.Max > ar.Max {
+			ar.Max = other.Max
+		}
+	}
+	ar.Sum += other.Sum
+	ar.Count += other.Count
+	ar.Missing += other.Missing
+	ar.Fixup()
+}
+
+// Fixup sets the Value from the statistics, it is 0
+// when there are no values.
+func (ar *AggregationResult) Fixup() {
+	switch ar.Metric {
+	case MetricSum:
+		ar.Value = ar.Sum
+	case MetricAvg:
+		ar.Value = 0
+		if ar.Count > 0 {
+			ar.Value = ar.Sum / float64(ar.Count)
+		}
+	case MetricMin:
+		ar.Value = ar.Min
+	case MetricMax:
+		ar.Value = ar.Max
+	case MetricCount:
+		ar.Value = float64(ar.Count)
+	}
+}
+
+type AggregationResults map[string]*AggregationResult
+
+func (ar AggregationResults) Merge(other AggregationResults) {
+	for name, oAggregationResult := range other {
+		aggregationResult, ok := ar[name]
+		if ok {
+			aggregationResult.Merge(oAggregationResult)
+		} else {
+			ar[name] = oAggregationResult
+		}
+	}
+}
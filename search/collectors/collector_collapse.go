@@ -0,0 +1,200 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package collectors
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/blevesearch/bleve/index"
+	"github.com/blevesearch/bleve/search"
+)
+
+// CollapsingCollector groups matches by the indexed
+// terms of a field, keeping only the top groupSize
+// matches of each group.  Groups are ranked by their
+// best match, and k groups are returned after skipping
+// the first skip.
+type CollapsingCollector struct {
+	indexReader    index.IndexReader
+	field          string
+	groupSize      int
+	k              int
+	skip           int
+	excludeMissing bool
+	groups         map[string]*search.DocumentMatchGroup
+	missing        search.DocumentMatchGroupCollection
+	sorted         search.DocumentMatchGroupCollection
+	took           time.Duration
+	maxScore       float64
+	total          uint64
+	facetsBuilder  *search.FacetsBuilder
+}
+
+// NewCollapsingCollector creates a collector collapsing
+// matches on field.  Matches without any terms in field
+// are dropped if excludeMissing is set, otherwise each
+// forms a group of its own.
+func NewCollapsingCollector(indexReader index.IndexReader, field string, groupSize, k, skip int, excludeMissing bool) *CollapsingCollector {
+	if groupSize < 1 {
+		groupSize = 1
+	}
+	return &CollapsingCollector{
+		indexReader:    indexReader,
+		field:          field,
+		groupSize:      groupSize,
+		k:              k,
+		skip:           skip,
+		excludeMissing: excludeMissing,
+		groups:         make(map[string]*search.DocumentMatchGroup),
+	}
+}
+
+// Total returns the number of matches collected,
+// which excludes matches dropped for missing the
+// collapse field.
+func (cc *CollapsingCollector) Total() uint64 {
+	return cc.total
+}
+
+// TotalGroups returns the number of distinct groups.
+func (cc *CollapsingCollector) TotalGroups() uint64 {
+	return uint64(len(cc.groups) + len(cc.missing))
+}
+
+func (cc *CollapsingCollector) MaxScore() float64 {
+	return cc.maxScore
+}
+
+func (cc *CollapsingCollector) Took() time.Duration {
+	return cc.took
+}
+
+func (cc *CollapsingCollector) Collect(searcher search.Searcher) error {
+	startTime := time.Now()
+	next, err := searcher.Next()
+	for err == nil && next != nil {
+		err = cc.collectSingle(next)
+		if err != nil {
+			break
+		}
+		next, err = searcher.Next()
+	}
+	cc.sortGroups()
+	// compute search duration
+	cc.took = time.Since(startTime)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (cc *CollapsingCollector) collectSingle(dm *search.DocumentMatch) error {
+	fieldTerms, err := cc.indexReader.DocumentFieldTerms(dm.ID)
+	if err != nil {
+		return err
+	}
+
+	var group *search.DocumentMatchGroup
+	terms := fieldTerms[cc.field]
+	if len(terms) == 0 {
+		if cc.excludeMissing {
+			return nil
+		}
+		group = &search.DocumentMatchGroup{
+			Missing: true,
+		}
+		cc.missing = append(cc.missing, group)
+	} else {
+		value := groupValue(terms)
+		group = cc.groups[value]
+		if group == nil {
+			group = &search.DocumentMatchGroup{
+				Value: value,
+			}
+			cc.groups[value] = group
+		}
+	}
+
+	cc.total++
+	if dm.Score > cc.maxScore {
+		cc.maxScore = dm.Score
+	}
+	if cc.facetsBuilder != nil {
+		cc.facetsBuilder.Update(dm)
+	}
+
+	group.Total++
+	if len(group.Hits) < cc.groupSize {
+		group.Hits = append(group.Hits, dm)
+	} else if dm.Score > group.Hits[len(group.Hits)-1].Score {
+		group.Hits[len(group.Hits)-1] = dm
+	} else {
+		return nil
+	}
+	sort.Stable(group.Hits)
+	return nil
+}
+
+// groupValue builds the group key from the terms
+// of a field, independent of their order
+func groupValue(terms []string) string {
+	if len(terms) == 1 {
+		return terms[0]
+	}
+	sorted := make([]string, len(terms))
+	copy(sorted, terms)
+	sort.Strings(sorted)
+	return strings.Join(sorted, " ")
+}
+
+func (cc *CollapsingCollector) sortGroups() {
+	cc.sorted = make(search.DocumentMatchGroupCollection, 0, len(cc.groups)+len(cc.missing))
+	for _, group := range cc.groups {
+		cc.sorted = append(cc.sorted, group)
+	}
+	cc.sorted = append(cc.sorted, cc.missing...)
+	sort.Stable(cc.sorted)
+}
+
+// Groups returns the requested page of groups,
+// best group first.
+func (cc *CollapsingCollector) Groups() search.DocumentMatchGroupCollection {
+	if len(cc.sorted) <= cc.skip {
+		return search.DocumentMatchGroupCollection{}
+	}
+	rv := cc.sorted[cc.skip:]
+	if len(rv) > cc.k {
+		rv = rv[:cc.k]
+	}
+	return rv
+}
+
+// Results returns the hits of the requested page
+// of groups, in group order.
+func (cc *CollapsingCollector) Results() search.DocumentMatchCollection {
+	rv := make(search.DocumentMatchCollection, 0)
+	for _, group := range cc.Groups() {
+		rv = append(rv, group.Hits...)
+	}
+	return rv
+}
+
+func (cc *CollapsingCollector) SetFacetsBuilder(facetsBuilder *search.FacetsBuilder) {
+	cc.facetsBuilder = facetsBuilder
+}
+
+func (cc *CollapsingCollector) FacetResults() search.FacetResults {
+	if cc.facetsBuilder != nil {
+		return cc.facetsBuilder.Results()
+	}
+	return search.FacetResults{}
+}
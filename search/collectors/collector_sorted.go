@@ -0,0 +1,174 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package collectors
+
+import (
+	"container/list"
+	"time"
+
+	"github.com/blevesearch/bleve/index"
+	"github.com/blevesearch/bleve/search"
+)
+
+// SortingCollector keeps the first k matches, after
+// skipping skip, in the order described by sort.
+// Matches which compare equal keep the order in which
+// they were collected.
+type SortingCollector struct {
+	indexReader   index.IndexReader
+	sort          search.SortOrder
+	k             int
+	skip          int
+	after         *search.DocumentMatch
+	results       *list.List
+	took          time.Duration
+	maxScore      float64
+	total         uint64
+	facetsBuilder *search.FacetsBuilder
+}
+
+func NewSortingCollector(indexReader index.IndexReader, sort search.SortOrder, k, skip int) *SortingCollector {
+	return &SortingCollector{
+		indexReader: indexReader,
+		sort:        sort,
+		k:           k,
+		skip:        skip,
+		results:     list.New(),
+	}
+}
+
+// SetSearchAfter limits the results to the matches
+// sorting strictly after the match with the provided
+// sort values.  Matches before it are still counted
+// in the total and the facets.
+func (sc *SortingCollector) SetSearchAfter(after []interface{}) {
+	sortValues := make([]interface{}, len(after))
+	for i, v := range after {
+		sortValues[i] = cursorValue(v)
+	}
+	sc.after = &search.DocumentMatch{Sort: sortValues}
+}
+
+// cursorValue converts numbers to the float64
+// used for computed sort values
+func cursorValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	}
+	return v
+}
+
+func (sc *SortingCollector) Total() uint64 {
+	return sc.total
+}
+
+func (sc *SortingCollector) MaxScore() float64 {
+	return sc.maxScore
+}
+
+func (sc *SortingCollector) Took() time.Duration {
+	return sc.took
+}
+
+func (sc *SortingCollector) Collect(searcher search.Searcher) error {
+	startTime := time.Now()
+	next, err := searcher.Next()
+	for err == nil && next != nil {
+		err = sc.collectSingle(next)
+		if err != nil {
+			break
+		}
+		if sc.facetsBuilder != nil {
+			sc.facetsBuilder.Update(next)
+		}
+		next, err = searcher.Next()
+	}
+	// compute search duration
+	sc.took = time.Since(startTime)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (sc *SortingCollector) collectSingle(dm *search.DocumentMatch) error {
+	// increment total hits
+	sc.total++
+
+	// update max score
+	if dm.Score > sc.maxScore {
+		sc.maxScore = dm.Score
+	}
+
+	err := sc.sort.Compute(sc.indexReader, dm)
+	if err != nil {
+		return err
+	}
+	if sc.after != nil && sc.sort.Compare(dm, sc.after) <= 0 {
+		return nil
+	}
+
+	// the list is kept in sorted order, walk back from
+	// the end to find where this match belongs
+	for e := sc.results.Back(); e != nil; e = e.Prev() {
+		curr := e.Value.(*search.DocumentMatch)
+		if sc.sort.Compare(dm, curr) >= 0 {
+			sc.results.InsertAfter(dm, e)
+			sc.trim()
+			return nil
+		}
+	}
+	// sorts before everything collected so far
+	sc.results.PushFront(dm)
+	sc.trim()
+	return nil
+}
+
+func (sc *SortingCollector) trim() {
+	if sc.results.Len() > (sc.k + sc.skip) {
+		sc.results.Remove(sc.results.Back())
+	}
+}
+
+func (sc *SortingCollector) Results() search.DocumentMatchCollection {
+	if sc.results.Len()-sc.skip > 0 {
+		rv := make(search.DocumentMatchCollection, sc.results.Len()-sc.skip)
+		i := 0
+		skipped := 0
+		for e := sc.results.Front(); e != nil; e = e.Next() {
+			if skipped < sc.skip {
+				skipped++
+				continue
+			}
+			rv[i] = e.Value.(*search.DocumentMatch)
+			i++
+		}
+		return rv
+	}
+	return search.DocumentMatchCollection{}
+}
+
+func (sc *SortingCollector) SetFacetsBuilder(facetsBuilder *search.FacetsBuilder) {
+	sc.facetsBuilder = facetsBuilder
+}
+
+func (sc *SortingCollector) FacetResults() search.FacetResults {
+	if sc.facetsBuilder != nil {
+		return sc.facetsBuilder.Results()
+	}
+	return search.FacetResults{}
+}
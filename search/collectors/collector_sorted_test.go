@@ -0,0 +1,112 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package collectors
+
+import (
+	"testing"
+
+	"github.com/blevesearch/bleve/search"
+)
+
+func TestSortingCollector(t *testing.T) {
+	matches := func() search.DocumentMatchCollection {
+		return search.DocumentMatchCollection{
+			&search.DocumentMatch{ID: "c", Score: 1},
+			&search.DocumentMatch{ID: "a", Score: 3},
+			&search.DocumentMatch{ID: "e", Score: 3},
+			&search.DocumentMatch{ID: "b", Score: 2},
+			&search.DocumentMatch{ID: "d", Score: 1},
+		}
+	}
+
+	tests := []struct {
+		sort search.SortOrder
+		k    int
+		skip int
+		ids  []string
+	}{
+		{
+			sort: search.SortOrder{&search.SortDocID{}},
+			k:    10,
+			ids:  []string{"a", "b", "c", "d", "e"},
+		},
+		{
+			sort: search.SortOrder{&search.SortDocID{Desc: true}},
+			k:    2,
+			ids:  []string{"e", "d"},
+		},
+		{
+			sort: search.SortOrder{&search.SortDocID{}},
+			k:    2,
+			skip: 2,
+			ids:  []string{"c", "d"},
+		},
+		// ties are broken by the next sort
+		{
+			sort: search.SortOrder{&search.SortScore{Desc: true}, &search.SortDocID{Desc: true}},
+			k:    10,
+			ids:  []string{"e", "a", "b", "d", "c"},
+		},
+		// remaining ties keep collection order
+		{
+			sort: search.SortOrder{&search.SortScore{}},
+			k:    3,
+			ids:  []string{"c", "d", "b"},
+		},
+	}
+
+	for _, test := range tests {
+		collector := NewSortingCollector(nil, test.sort, test.k, test.skip)
+		err := collector.Collect(&stubSearcher{matches: matches()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if collector.Total() != 5 {
+			t.Errorf("expected 5 total, got %d", collector.Total())
+		}
+		if collector.MaxScore() != 3 {
+			t.Errorf("expected max score 3, got %f", collector.MaxScore())
+		}
+		results := collector.Results()
+		if len(results) != len(test.ids) {
+			t.Errorf("expected %v, got %d results", test.ids, len(results))
+			continue
+		}
+		for i, id := range test.ids {
+			if results[i].ID != id {
+				t.Errorf("expected %s at %d, got %s", id, i, results[i].ID)
+			}
+		}
+	}
+}
+
+func TestSortingCollectorSearchAfter(t *testing.T) {
+	matches := search.DocumentMatchCollection{
+		&search.DocumentMatch{ID: "c", Score: 1},
+		&search.DocumentMatch{ID: "a", Score: 3},
+		&search.DocumentMatch{ID: "e", Score: 3},
+		&search.DocumentMatch{ID: "b", Score: 2},
+		&search.DocumentMatch{ID: "d", Score: 1},
+	}
+
+	collector := NewSortingCollector(nil, search.SortOrder{&search.SortScore{Desc: true}, &search.SortDocID{}}, 2, 0)
+	collector.SetSearchAfter([]interface{}{3, "e"})
+	err := collector.Collect(&stubSearcher{matches: matches})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if collector.Total() != 5 {
+		t.Errorf("expected 5 total, got %d", collector.Total())
+	}
+	results := collector.Results()
+	if len(results) != 2 || results[0].ID != "b" || results[1].ID != "c" {
+		t.Errorf("expected b and c after the cursor, got %v", results)
+	}
+}
@@ -0,0 +1,58 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package facets
+
+import (
+	"github.com/blevesearch/bleve/index"
+	"github.com/blevesearch/bleve/numeric_util"
+	"github.com/blevesearch/bleve/search"
+)
+
+// MetricAggregationBuilder computes a metric over the
+// numeric values of a field.  Every value of a document
+// counts, documents without the field are only counted
+// as missing.
+type MetricAggregationBuilder struct {
+	result search.AggregationResult
+}
+
+func NewMetricAggregationBuilder(field string, metric string) *MetricAggregationBuilder {
+	return &MetricAggregationBuilder{
+		result: search.AggregationResult{
+			Field:  field,
+			Metric: metric,
+		},
+	}
+}
+
+func (ab *MetricAggregationBuilder) Update(ft index.FieldTerms) {
+	terms, ok := ft[ab.result.Field]
+	if !ok {
+		ab.result.Missing++
+		return
+	}
+	for _, term := range terms {
+		// only consider the values which are shifted 0
+		prefixCoded := numeric_util.PrefixCoded(term)
+		shift, err := prefixCoded.Shift()
+		if err == nil && shift == 0 {
+			i64, err := prefixCoded.Int64()
+			if err == nil {
+				ab.result.Add(numeric_util.Int64ToFloat64(i64))
+			}
+		}
+	}
+}
+
+func (ab *MetricAggregationBuilder) Result() *search.AggregationResult {
+	rv := ab.result
+	rv.Fixup()
+	return &rv
+}
@@ -0,0 +1,63 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package facets
+
+import (
+	"testing"
+
+	"github.com/blevesearch/bleve/index"
+	"github.com/blevesearch/bleve/search"
+)
+
+func TestMetricAggregationBuilder(t *testing.T) {
+	docs := []index.FieldTerms{
+		numericFieldTerms("price", 4),
+		numericFieldTerms("price", -1.5),
+		// every value of a document counts
+		numericFieldTerms("price", 2, 7.5),
+		// missing the field
+		index.FieldTerms{"name": []string{"marty"}},
+	}
+
+	tests := []struct {
+		metric string
+		value  float64
+	}{
+		{search.MetricSum, 12},
+		{search.MetricAvg, 3},
+		{search.MetricMin, -1.5},
+		{search.MetricMax, 7.5},
+		{search.MetricCount, 4},
+	}
+	for _, test := range tests {
+		ab := NewMetricAggregationBuilder("price", test.metric)
+		for _, doc := range docs {
+			ab.Update(doc)
+		}
+		result := ab.Result()
+		if result.Value != test.value {
+			t.Errorf("expected %s %f, got %f", test.metric, test.value, result.Value)
+		}
+		if result.Count != 4 {
+			t.Errorf("expected count 4, got %d", result.Count)
+		}
+		if result.Missing != 1 {
+			t.Errorf("expected 1 missing, got %d", result.Missing)
+		}
+	}
+
+	// no values at all
+	ab := NewMetricAggregationBuilder("price", search.MetricAvg)
+	ab.Update(index.FieldTerms{})
+	result := ab.Result()
+	if result.Value != 0 || result.Count != 0 || result.Missing != 1 {
+		t.Errorf("expected empty avg of 0 with 1 missing, got %#v", result)
+	}
+}
@@ -0,0 +1,228 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package facets
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/blevesearch/bleve/index"
+	"github.com/blevesearch/bleve/numeric_util"
+	"github.com/blevesearch/bleve/search"
+)
+
+// the units of the intervals of a date histogram
+const (
+	dateHistogramMinute = 'm'
+	dateHistogramHour   = 'h'
+	dateHistogramDay    = 'd'
+	dateHistogramWeek   = 'w'
+	dateHistogramMonth  = 'M'
+	dateHistogramYear   = 'y'
+)
+
+type dateHistogramInterval struct {
+	n    int
+	unit byte
+}
+
+func parseDateHistogramInterval(interval string) (dateHistogramInterval, bool) {
+	if len(interval) < 2 {
+		return dateHistogramInterval{}, false
+	}
+	n, err := strconv.Atoi(interval[:len(interval)-1])
+	if err != nil || n < 1 {
+		return dateHistogramInterval{}, false
+	}
+	unit := interval[len(interval)-1]
+	switch unit {
+	case dateHistogramMinute, dateHistogramHour, dateHistogramDay,
+		dateHistogramWeek, dateHistogramMonth, dateHistogramYear:
+		return dateHistogramInterval{n: n, unit: unit}, true
+	}
+	return dateHistogramInterval{}, false
+}
+
+// ValidDateHistogramInterval reports whether interval is
+// a count followed by one of the units "m" for minutes,
+// "h" for hours, "d" for days, "w" for weeks, "M" for
+// months and "y" for years, such as "1d" or "6h".
+func ValidDateHistogramInterval(interval string) bool {
+	_, ok := parseDateHistogramInterval(interval)
+	return ok
+}
+
+// floorDiv divides rounding towards negative infinity, so
+// the buckets before the epoch have the same size.
+func floorDiv(a, b int) int {
+	if a < 0 {
+		return -((-a + b - 1) / b)
+	}
+	return a / b
+}
+
+// start returns the start of the bucket t falls into.  The
+// buckets follow the calendar of loc: days start at local
+// midnight and weeks on monday.  Intervals of several units
+// are aligned on multiples of the count, of minutes within
+// the hour, of hours within the day, and of the other units
+// since the epoch.
+func (i dateHistogramInterval) start(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	year, month, day := t.Date()
+	days := int(time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Unix() / 86400)
+	switch i.unit {
+	case dateHistogramMinute:
+		minute := t.Minute() - t.Minute()%i.n
+		return time.Date(year, month, day, t.Hour(), minute, 0, 0, loc)
+	case dateHistogramHour:
+		hour := t.Hour() - t.Hour()%i.n
+		return time.Date(year, month, day, hour, 0, 0, 0, loc)
+	case dateHistogramDay:
+		day -= days - floorDiv(days, i.n)*i.n
+		return time.Date(year, month, day, 0, 0, 0, 0, loc)
+	case dateHistogramWeek:
+		// the epoch is a thursday, weeks are counted
+		// from the monday after it
+		days -= 4
+		day -= days - floorDiv(days, 7*i.n)*7*i.n
+		return time.Date(year, month, day, 0, 0, 0, 0, loc)
+	case dateHistogramMonth:
+		months := year*12 + int(month) - 1
+		months = floorDiv(months, i.n) * i.n
+		return time.Date(months/12, time.Month(months%12+1), 1, 0, 0, 0, 0, loc)
+	}
+	year = floorDiv(year, i.n) * i.n
+	return time.Date(year, 1, 1, 0, 0, 0, 0, loc)
+}
+
+// next returns the start of the bucket after the one
+// starting at start.
+func (i dateHistogramInterval) next(start time.Time) time.Time {
+	year, month, day := start.Date()
+	switch i.unit {
+	case dateHistogramMinute:
+		return time.Date(year, month, day, start.Hour(), start.Minute()+i.n, 0, 0, start.Location())
+	case dateHistogramHour:
+		return time.Date(year, month, day, start.Hour()+i.n, 0, 0, 0, start.Location())
+	case dateHistogramDay:
+		return start.AddDate(0, 0, i.n)
+	case dateHistogramWeek:
+		return start.AddDate(0, 0, 7*i.n)
+	case dateHistogramMonth:
+		return start.AddDate(0, i.n, 0)
+	}
+	return start.AddDate(i.n, 0, 0)
+}
+
+// DateHistogramFacetBuilder counts the documents with a
+// date value in each bucket of a fixed interval.  A
+// document with several values in a bucket is counted
+// once in it.
+type DateHistogramFacetBuilder struct {
+	field        string
+	interval     dateHistogramInterval
+	loc          *time.Location
+	emptyBuckets bool
+	counts       map[int64]int
+	total        int
+	missing      int
+}
+
+// NewDateHistogramFacetBuilder buckets the dates of field
+// by interval, which ValidDateHistogramInterval must
+// accept, with the buckets bounded in the time zone loc.
+// With emptyBuckets the buckets without documents between
+// the first and the last counted ones are listed too.
+func NewDateHistogramFacetBuilder(field string, interval string, loc *time.Location, emptyBuckets bool) *DateHistogramFacetBuilder {
+	i, _ := parseDateHistogramInterval(interval)
+	if loc == nil {
+		loc = time.UTC
+	}
+	return &DateHistogramFacetBuilder{
+		field:        field,
+		interval:     i,
+		loc:          loc,
+		emptyBuckets: emptyBuckets,
+		counts:       make(map[int64]int),
+	}
+}
+
+func (fb *DateHistogramFacetBuilder) Update(ft index.FieldTerms) {
+	terms, ok := ft[fb.field]
+	if !ok {
+		fb.missing++
+		return
+	}
+	if fb.interval.n == 0 {
+		return
+	}
+	var matched map[int64]bool
+	for _, term := range terms {
+		// only consider the values which are shifted 0
+		prefixCoded := numeric_util.PrefixCoded(term)
+		shift, err := prefixCoded.Shift()
+		if err != nil || shift != 0 {
+			continue
+		}
+		i64, err := prefixCoded.Int64()
+		if err != nil {
+			continue
+		}
+		start := fb.interval.start(time.Unix(0, i64), fb.loc).UnixNano()
+		if matched[start] {
+			continue
+		}
+		if matched == nil {
+			matched = make(map[int64]bool)
+		}
+		matched[start] = true
+		fb.counts[start]++
+		fb.total++
+	}
+}
+
+type int64s []int64
+
+func (s int64s) Len() int           { return len(s) }
+func (s int64s) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s int64s) Less(i, j int) bool { return s[i] < s[j] }
+
+// Result lists the buckets by ascending start.
+func (fb *DateHistogramFacetBuilder) Result() *search.FacetResult {
+	rv := search.FacetResult{
+		Field:         fb.field,
+		Total:         fb.total,
+		Missing:       fb.missing,
+		DateHistogram: make(search.DateHistogramFacets, 0, len(fb.counts)),
+	}
+	starts := make(int64s, 0, len(fb.counts))
+	for start := range fb.counts {
+		starts = append(starts, start)
+	}
+	sort.Sort(starts)
+	for n, start := range starts {
+		t := time.Unix(0, start).In(fb.loc)
+		rv.DateHistogram = append(rv.DateHistogram, &search.DateHistogramFacet{
+			Start: t.Format(time.RFC3339Nano),
+			Count: fb.counts[start],
+		})
+		if !fb.emptyBuckets || n == len(starts)-1 {
+			continue
+		}
+		for t = fb.interval.next(t); t.UnixNano() < starts[n+1]; t = fb.interval.next(t) {
+			rv.DateHistogram = append(rv.DateHistogram, &search.DateHistogramFacet{
+				Start: t.Format(time.RFC3339Nano),
+			})
+		}
+	}
+	return &rv
+}
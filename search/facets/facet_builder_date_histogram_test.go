@@ -0,0 +1,159 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package facets
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/blevesearch/bleve/index"
+	"github.com/blevesearch/bleve/numeric_util"
+	"github.com/blevesearch/bleve/search"
+)
+
+func dateFieldTerms(field string, vals ...time.Time) index.FieldTerms {
+	terms := make([]string, 0, len(vals)*2)
+	for _, val := range vals {
+		terms = append(terms, string(numeric_util.MustNewPrefixCodedInt64(val.UnixNano(), 0)))
+		// lower precision terms are indexed too, and must be ignored
+		terms = append(terms, string(numeric_util.MustNewPrefixCodedInt64(val.UnixNano(), 4)))
+	}
+	return index.FieldTerms{field: terms}
+}
+
+func TestDateHistogramFacetBuilderByDay(t *testing.T) {
+	day := func(d, h int) time.Time {
+		return time.Date(2015, 3, d, h, 30, 0, 0, time.UTC)
+	}
+	values := [][]time.Time{
+		{day(1, 0)},
+		{day(1, 23)},
+		{day(2, 12)},
+		// two values on one day count once
+		{day(4, 1), day(4, 2)},
+		{day(5, 0), day(1, 5)},
+	}
+
+	tests := []struct {
+		emptyBuckets bool
+		expected     search.DateHistogramFacets
+	}{
+		{
+			expected: search.DateHistogramFacets{
+				{Start: "2015-03-01T00:00:00Z", Count: 3},
+				{Start: "2015-03-02T00:00:00Z", Count: 1},
+				{Start: "2015-03-04T00:00:00Z", Count: 1},
+				{Start: "2015-03-05T00:00:00Z", Count: 1},
+			},
+		},
+		{
+			emptyBuckets: true,
+			expected: search.DateHistogramFacets{
+				{Start: "2015-03-01T00:00:00Z", Count: 3},
+				{Start: "2015-03-02T00:00:00Z", Count: 1},
+				{Start: "2015-03-03T00:00:00Z", Count: 0},
+				{Start: "2015-03-04T00:00:00Z", Count: 1},
+				{Start: "2015-03-05T00:00:00Z", Count: 1},
+			},
+		},
+	}
+	for _, test := range tests {
+		fb := NewDateHistogramFacetBuilder("when", "1d", time.UTC, test.emptyBuckets)
+		for _, vals := range values {
+			fb.Update(dateFieldTerms("when", vals...))
+		}
+		// missing the field
+		fb.Update(index.FieldTerms{"name": []string{"marty"}})
+
+		result := fb.Result()
+		if !reflect.DeepEqual(result.DateHistogram, test.expected) {
+			t.Errorf("empty buckets %t: expected %v, got %v", test.emptyBuckets, test.expected, result.DateHistogram)
+		}
+		if result.Total != 6 || result.Missing != 1 {
+			t.Errorf("expected total 6 missing 1, got %d %d", result.Total, result.Missing)
+		}
+	}
+}
+
+func TestDateHistogramFacetBuilderTimeZone(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	// 03:00 UTC is still the previous day in New York
+	values := []time.Time{
+		time.Date(2015, 3, 2, 3, 0, 0, 0, time.UTC),
+		time.Date(2015, 3, 2, 6, 0, 0, 0, time.UTC),
+		// the day after the switch to daylight saving time
+		time.Date(2015, 3, 9, 3, 0, 0, 0, time.UTC),
+		time.Date(2015, 3, 9, 5, 0, 0, 0, time.UTC),
+	}
+	tests := []struct {
+		interval string
+		expected search.DateHistogramFacets
+	}{
+		{
+			interval: "1d",
+			expected: search.DateHistogramFacets{
+				{Start: "2015-03-01T00:00:00-05:00", Count: 1},
+				{Start: "2015-03-02T00:00:00-05:00", Count: 1},
+				{Start: "2015-03-08T00:00:00-05:00", Count: 1},
+				{Start: "2015-03-09T00:00:00-04:00", Count: 1},
+			},
+		},
+		{
+			interval: "1w",
+			expected: search.DateHistogramFacets{
+				{Start: "2015-02-23T00:00:00-05:00", Count: 1},
+				{Start: "2015-03-02T00:00:00-05:00", Count: 2},
+				{Start: "2015-03-09T00:00:00-04:00", Count: 1},
+			},
+		},
+		{
+			interval: "1M",
+			expected: search.DateHistogramFacets{
+				{Start: "2015-03-01T00:00:00-05:00", Count: 4},
+			},
+		},
+		{
+			interval: "12h",
+			expected: search.DateHistogramFacets{
+				{Start: "2015-03-01T12:00:00-05:00", Count: 1},
+				{Start: "2015-03-02T00:00:00-05:00", Count: 1},
+				{Start: "2015-03-08T12:00:00-04:00", Count: 1},
+				{Start: "2015-03-09T00:00:00-04:00", Count: 1},
+			},
+		},
+	}
+	for _, test := range tests {
+		fb := NewDateHistogramFacetBuilder("when", test.interval, loc, false)
+		for _, val := range values {
+			fb.Update(dateFieldTerms("when", val))
+		}
+		result := fb.Result()
+		if !reflect.DeepEqual(result.DateHistogram, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.interval, test.expected, result.DateHistogram)
+		}
+	}
+}
+
+func TestValidDateHistogramInterval(t *testing.T) {
+	for _, interval := range []string{"1m", "15m", "1h", "6h", "1d", "7d", "1w", "1M", "3M", "1y"} {
+		if !ValidDateHistogramInterval(interval) {
+			t.Errorf("expected %s valid", interval)
+		}
+	}
+	for _, interval := range []string{"", "d", "0d", "-1d", "1s", "1.5h", "day"} {
+		if ValidDateHistogramInterval(interval) {
+			t.Errorf("expected %s invalid", interval)
+		}
+	}
+}
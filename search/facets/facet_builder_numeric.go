@@ -48,9 +48,15 @@ func (fb *NumericFacetBuilder) AddRange(name string, min, max *float64) {
 	fb.ranges[name] = &r
 }
 
+// Update counts the document in each range containing
+// one of its values.  Ranges are half-open, including
+// min and excluding max, and may overlap.  A document
+// with several values in the same range only counts
+// once towards it.
 func (fb *NumericFacetBuilder) Update(ft index.FieldTerms) {
 	terms, ok := ft[fb.field]
 	if ok {
+		var matched map[string]bool
 		for _, term := range terms {
 			// only consider the values which are shifted 0
 			prefixCoded := numeric_util.PrefixCoded(term)
@@ -65,6 +71,14 @@ func (fb *NumericFacetBuilder) Update(ft index.FieldTerms) {
 
 						if (r.min == nil || f64 >= *r.min) && (r.max == nil || f64 < *r.max) {
 
+							if matched[rangeName] {
+								continue
+							}
+							if matched == nil {
+								matched = make(map[string]bool, len(fb.ranges))
+							}
+							matched[rangeName] = true
+
 							existingCount, existed := fb.termsCount[rangeName]
 							if existed {
 								fb.termsCount[rangeName] = existingCount + 1
@@ -105,7 +119,7 @@ OUTER:
 
 		for e := topN.Front(); e != nil; e = e.Next() {
 			curr := e.Value.(*search.NumericRangeFacet)
-			if tf.Count < curr.Count {
+			if tf.Count < curr.Count || (tf.Count == curr.Count && tf.Name > curr.Name) {
 
 				topN.InsertBefore(tf, e)
 				// if we just made the list too long
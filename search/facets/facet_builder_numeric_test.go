@@ -0,0 +1,107 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package facets
+
+import (
+	"testing"
+
+	"github.com/blevesearch/bleve/index"
+	"github.com/blevesearch/bleve/numeric_util"
+)
+
+func numericFieldTerms(field string, vals ...float64) index.FieldTerms {
+	terms := make([]string, 0, len(vals)*2)
+	for _, val := range vals {
+		i64 := numeric_util.Float64ToInt64(val)
+		terms = append(terms, string(numeric_util.MustNewPrefixCodedInt64(i64, 0)))
+		// lower precision terms are indexed too, and must be ignored
+		terms = append(terms, string(numeric_util.MustNewPrefixCodedInt64(i64, 4)))
+	}
+	return index.FieldTerms{field: terms}
+}
+
+func TestNumericFacetBuilder(t *testing.T) {
+	ten := 10.0
+	fifty := 50.0
+	twenty := 20.0
+
+	fb := NewNumericFacetBuilder("price", 10)
+	fb.AddRange("cheap", nil, &ten)
+	fb.AddRange("mid", &ten, &fifty)
+	fb.AddRange("expensive", &fifty, nil)
+	// overlaps with cheap and mid
+	fb.AddRange("under20", nil, &twenty)
+
+	fb.Update(numericFieldTerms("price", 5))
+	fb.Update(numericFieldTerms("price", 10))
+	fb.Update(numericFieldTerms("price", 15))
+	fb.Update(numericFieldTerms("price", 49.99))
+	fb.Update(numericFieldTerms("price", 50))
+	fb.Update(numericFieldTerms("price", 500))
+	// several values in the same range only count once
+	fb.Update(numericFieldTerms("price", 1, 2, 3))
+	// missing the field
+	fb.Update(index.FieldTerms{"name": []string{"marty"}})
+
+	result := fb.Result()
+	if result.Missing != 1 {
+		t.Errorf("expected 1 missing, got %d", result.Missing)
+	}
+
+	expected := map[string]int{
+		"cheap":     2,
+		"mid":       3,
+		"expensive": 2,
+		"under20":   4,
+	}
+	if len(result.NumericRanges) != len(expected) {
+		t.Fatalf("expected %d ranges, got %d", len(expected), len(result.NumericRanges))
+	}
+	total := 0
+	for _, nr := range result.NumericRanges {
+		if nr.Count != expected[nr.Name] {
+			t.Errorf("expected range %s to have count %d, got %d", nr.Name, expected[nr.Name], nr.Count)
+		}
+		total += nr.Count
+	}
+	if result.Total != total {
+		t.Errorf("expected total %d, got %d", total, result.Total)
+	}
+	if result.Other != 0 {
+		t.Errorf("expected other 0, got %d", result.Other)
+	}
+
+	// largest first, ties broken by name
+	expectedOrder := []string{"under20", "mid", "cheap", "expensive"}
+	for i, name := range expectedOrder {
+		if result.NumericRanges[i].Name != name {
+			t.Errorf("expected range %d to be %s, got %s", i, name, result.NumericRanges[i].Name)
+		}
+	}
+}
+
+func TestNumericFacetBuilderSize(t *testing.T) {
+	ten := 10.0
+	fb := NewNumericFacetBuilder("price", 1)
+	fb.AddRange("low", nil, &ten)
+	fb.AddRange("high", &ten, nil)
+
+	fb.Update(numericFieldTerms("price", 1))
+	fb.Update(numericFieldTerms("price", 2))
+	fb.Update(numericFieldTerms("price", 20))
+
+	result := fb.Result()
+	if len(result.NumericRanges) != 1 || result.NumericRanges[0].Name != "low" {
+		t.Fatalf("expected only the low range, got %v", result.NumericRanges)
+	}
+	if result.Other != 1 {
+		t.Errorf("expected other 1, got %d", result.Other)
+	}
+}
@@ -19,6 +19,7 @@ import (
 type TermsFacetBuilder struct {
 	size       int
 	field      string
+	order      string
 	termsCount map[string]int
 	total      int
 	missing    int
@@ -32,6 +33,13 @@ func NewTermsFacetBuilder(field string, size int) *TermsFacetBuilder {
 	}
 }
 
+// SetOrder sets the order the terms of the result are
+// listed in, one of the search.TermFacets orders.  The
+// terms kept are the most frequent ones whatever the order.
+func (fb *TermsFacetBuilder) SetOrder(order string) {
+	fb.order = order
+}
+
 func (fb *TermsFacetBuilder) Update(ft index.FieldTerms) {
 	terms, ok := ft[fb.field]
 	if ok {
@@ -70,7 +78,9 @@ func (fb *TermsFacetBuilder) Result() *search.FacetResult {
 	sort.Sort(rv.Terms)
 
 	// we now have the list of the top N facets
-	rv.Terms = rv.Terms[:fb.size]
+	if len(rv.Terms) > fb.size {
+		rv.Terms = rv.Terms[:fb.size]
+	}
 
 	notOther := 0
 	for _, tf := range rv.Terms {
@@ -78,5 +88,9 @@ func (fb *TermsFacetBuilder) Result() *search.FacetResult {
 	}
 	rv.Other = fb.total - notOther
 
+	if fb.order != "" {
+		rv.Terms.SortBy(fb.order)
+	}
+
 	return &rv
 }
@@ -2,6 +2,7 @@ package facets
 
 import (
 	"io/ioutil"
+	"reflect"
 	"regexp"
 	"testing"
 
@@ -56,3 +57,46 @@ func termsFacetN(b *testing.B, numTerms int) {
 		tfb.Result()
 	}
 }
+
+func TestTermsFacetOrder(t *testing.T) {
+	counts := map[string]int{
+		"apple":  3,
+		"banana": 1,
+		"cherry": 3,
+		"date":   2,
+		"elder":  1,
+	}
+
+	tests := []struct {
+		order    string
+		expected []string
+	}{
+		{order: "", expected: []string{"apple", "cherry", "date", "banana"}},
+		{order: "count_desc", expected: []string{"apple", "cherry", "date", "banana"}},
+		{order: "count_asc", expected: []string{"banana", "date", "apple", "cherry"}},
+		{order: "term_asc", expected: []string{"apple", "banana", "cherry", "date"}},
+		{order: "term_desc", expected: []string{"date", "cherry", "banana", "apple"}},
+	}
+
+	for _, test := range tests {
+		tfb := NewTermsFacetBuilder("test", 4)
+		tfb.SetOrder(test.order)
+		for term, count := range counts {
+			for i := 0; i < count; i++ {
+				tfb.Update(index.FieldTerms{"test": []string{term}})
+			}
+		}
+		result := tfb.Result()
+		actual := make([]string, len(result.Terms))
+		for i, tf := range result.Terms {
+			actual[i] = tf.Term
+		}
+		if !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("order %q: expected %v, got %v", test.order, test.expected, actual)
+		}
+		// the least frequent term left out is counted in other
+		if result.Other != 1 {
+			t.Errorf("order %q: expected other 1, got %d", test.order, result.Other)
+		}
+	}
+}
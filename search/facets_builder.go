@@ -11,6 +11,7 @@ package search
 
 import (
 	"sort"
+	"time"
 
 	"github.com/blevesearch/bleve/index"
 )
@@ -21,14 +22,16 @@ type FacetBuilder interface {
 }
 
 type FacetsBuilder struct {
-	indexReader index.IndexReader
-	facets      map[string]FacetBuilder
+	indexReader  index.IndexReader
+	facets       map[string]FacetBuilder
+	aggregations map[string]AggregationBuilder
 }
 
 func NewFacetsBuilder(indexReader index.IndexReader) *FacetsBuilder {
 	return &FacetsBuilder{
-		indexReader: indexReader,
-		facets:      make(map[string]FacetBuilder, 0),
+		indexReader:  indexReader,
+		facets:       make(map[string]FacetBuilder, 0),
+		aggregations: make(map[string]AggregationBuilder, 0),
 	}
 }
 
@@ -36,6 +39,12 @@ func (fb *FacetsBuilder) Add(name string, facetBuilder FacetBuilder) {
 	fb.facets[name] = facetBuilder
 }
 
+// AddAggregation adds an aggregation, which is updated
+// from the same field terms as the facets.
+func (fb *FacetsBuilder) AddAggregation(name string, aggregationBuilder AggregationBuilder) {
+	fb.aggregations[name] = aggregationBuilder
+}
+
 func (fb *FacetsBuilder) Update(docMatch *DocumentMatch) error {
 	fieldTerms, err := fb.indexReader.DocumentFieldTerms(docMatch.ID)
 	if err != nil {
@@ -44,6 +53,9 @@ func (fb *FacetsBuilder) Update(docMatch *DocumentMatch) error {
 	for _, facetBuilder := range fb.facets {
 		facetBuilder.Update(fieldTerms)
 	}
+	for _, aggregationBuilder := range fb.aggregations {
+		aggregationBuilder.Update(fieldTerms)
+	}
 	return nil
 }
 
@@ -66,9 +78,64 @@ func (tf TermFacets) Add(termFacet *TermFacet) TermFacets {
 	return tf
 }
 
-func (tf TermFacets) Len() int           { return len(tf) }
-func (tf TermFacets) Swap(i, j int)      { tf[i], tf[j] = tf[j], tf[i] }
-func (tf TermFacets) Less(i, j int) bool { return tf[i].Count > tf[j].Count }
+func (tf TermFacets) Len() int      { return len(tf) }
+func (tf TermFacets) Swap(i, j int) { tf[i], tf[j] = tf[j], tf[i] }
+func (tf TermFacets) Less(i, j int) bool {
+	if tf[i].Count == tf[j].Count {
+		return tf[i].Term < tf[j].Term
+	}
+	return tf[i].Count > tf[j].Count
+}
+
+// The orders in which the terms of a facet can be listed.
+// Terms with the same count are listed by ascending term.
+const (
+	TermFacetsCountDesc = "count_desc"
+	TermFacetsCountAsc  = "count_asc"
+	TermFacetsTermAsc   = "term_asc"
+	TermFacetsTermDesc  = "term_desc"
+)
+
+// ValidTermFacetsOrder reports whether order is one of the
+// orders of the terms of a facet, or empty for the default
+// of TermFacetsCountDesc.
+func ValidTermFacetsOrder(order string) bool {
+	switch order {
+	case "", TermFacetsCountDesc, TermFacetsCountAsc, TermFacetsTermAsc, TermFacetsTermDesc:
+		return true
+	}
+	return false
+}
+
+// SortBy sorts the terms in the order named by order, an
+// empty or unknown order sorts by descending count.
+func (tf TermFacets) SortBy(order string) {
+	switch order {
+	case TermFacetsCountAsc:
+		sort.Sort(termFacetsByCountAsc{tf})
+	case TermFacetsTermAsc:
+		sort.Sort(termFacetsByTerm{tf})
+	case TermFacetsTermDesc:
+		sort.Sort(sort.Reverse(termFacetsByTerm{tf}))
+	default:
+		sort.Sort(tf)
+	}
+}
+
+type termFacetsByCountAsc struct{ TermFacets }
+
+func (tf termFacetsByCountAsc) Less(i, j int) bool {
+	if tf.TermFacets[i].Count == tf.TermFacets[j].Count {
+		return tf.TermFacets[i].Term < tf.TermFacets[j].Term
+	}
+	return tf.TermFacets[i].Count < tf.TermFacets[j].Count
+}
+
+type termFacetsByTerm struct{ TermFacets }
+
+func (tf termFacetsByTerm) Less(i, j int) bool {
+	return tf.TermFacets[i].Term < tf.TermFacets[j].Term
+}
 
 type NumericRangeFacet struct {
 	Name  string   `json:"name"`
@@ -120,14 +187,45 @@ func (drf DateRangeFacets) Len() int           { return len(drf) }
 func (drf DateRangeFacets) Swap(i, j int)      { drf[i], drf[j] = drf[j], drf[i] }
 func (drf DateRangeFacets) Less(i, j int) bool { return drf[i].Count > drf[j].Count }
 
+// A DateHistogramFacet is a bucket of a date histogram,
+// starting at Start, formatted as RFC 3339, and lasting
+// until the start of the next bucket.
+type DateHistogramFacet struct {
+	Start string `json:"start"`
+	Count int    `json:"count"`
+}
+
+type DateHistogramFacets []*DateHistogramFacet
+
+func (dhf DateHistogramFacets) Add(dateHistogramFacet *DateHistogramFacet) DateHistogramFacets {
+	for _, existingDh := range dhf {
+		if dateHistogramFacet.Start == existingDh.Start {
+			existingDh.Count += dateHistogramFacet.Count
+			return dhf
+		}
+	}
+	// if we got here it wasn't already in the existing buckets
+	dhf = append(dhf, dateHistogramFacet)
+	return dhf
+}
+
+func (dhf DateHistogramFacets) Len() int      { return len(dhf) }
+func (dhf DateHistogramFacets) Swap(i, j int) { dhf[i], dhf[j] = dhf[j], dhf[i] }
+func (dhf DateHistogramFacets) Less(i, j int) bool {
+	ti, _ := time.Parse(time.RFC3339Nano, dhf[i].Start)
+	tj, _ := time.Parse(time.RFC3339Nano, dhf[j].Start)
+	return ti.Before(tj)
+}
+
 type FacetResult struct {
-	Field         string             `json:"field"`
-	Total         int                `json:"total"`
-	Missing       int                `json:"missing"`
-	Other         int                `json:"other"`
-	Terms         TermFacets         `json:"terms,omitempty"`
-	NumericRanges NumericRangeFacets `json:"numeric_ranges,omitempty"`
-	DateRanges    DateRangeFacets    `json:"date_ranges,omitempty"`
+	Field         string              `json:"field"`
+	Total         int                 `json:"total"`
+	Missing       int                 `json:"missing"`
+	Other         int                 `json:"other"`
+	Terms         TermFacets          `json:"terms,omitempty"`
+	NumericRanges NumericRangeFacets  `json:"numeric_ranges,omitempty"`
+	DateRanges    DateRangeFacets     `json:"date_ranges,omitempty"`
+	DateHistogram DateHistogramFacets `json:"date_histogram,omitempty"`
 }
 
 func (fr *FacetResult) Merge(other *FacetResult) {
@@ -149,6 +247,11 @@ func (fr *FacetResult) Merge(other *FacetResult) {
 			fr.DateRanges = fr.DateRanges.Add(dr)
 		}
 	}
+	if fr.DateHistogram != nil && other.DateHistogram != nil {
+		for _, dh := range other.DateHistogram {
+			fr.DateHistogram = fr.DateHistogram.Add(dh)
+		}
+	}
 }
 
 func (fr *FacetResult) Fixup(size int) {
@@ -179,6 +282,9 @@ func (fr *FacetResult) Fixup(size int) {
 			}
 			fr.DateRanges = fr.DateRanges[0:size]
 		}
+	} else if fr.DateHistogram != nil {
+		// every bucket is kept, in order
+		sort.Sort(fr.DateHistogram)
 	}
 }
 
@@ -202,6 +308,16 @@ func (fr FacetResults) Fixup(name string, size int) {
 	}
 }
 
+// OrderTerms lists the terms of the named facet in the
+// order named by order.  It only changes how the terms are
+// listed, they remain the most frequent ones.
+func (fr FacetResults) OrderTerms(name string, order string) {
+	facetResult, ok := fr[name]
+	if ok && facetResult.Terms != nil {
+		facetResult.Terms.SortBy(order)
+	}
+}
+
 func (fb *FacetsBuilder) Results() FacetResults {
 	fr := make(FacetResults)
 	for facetName, facetBuilder := range fb.facets {
@@ -210,3 +326,11 @@ func (fb *FacetsBuilder) Results() FacetResults {
 	}
 	return fr
 }
+
+func (fb *FacetsBuilder) AggregationResults() AggregationResults {
+	ar := make(AggregationResults)
+	for aggregationName, aggregationBuilder := range fb.aggregations {
+		ar[aggregationName] = aggregationBuilder.Result()
+	}
+	return ar
+}
@@ -0,0 +1,86 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package plain
+
+import (
+	"github.com/blevesearch/bleve/registry"
+	"github.com/blevesearch/bleve/search"
+	"github.com/blevesearch/bleve/search/highlight"
+)
+
+const Name = "plain"
+
+const defaultPlainHighlightBefore = "*"
+const defaultPlainHighlightAfter = "*"
+
+// FragmentFormatter wraps matches in plain text markers.
+// Overlapping and adjacent matches are wrapped as one,
+// so markers are never nested or back to back.
+type FragmentFormatter struct {
+	before string
+	after  string
+}
+
+func NewFragmentFormatter(before, after string) *FragmentFormatter {
+	return &FragmentFormatter{
+		before: before,
+		after:  after,
+	}
+}
+
+func (a *FragmentFormatter) Format(f *highlight.Fragment, tlm search.TermLocationMap) string {
+	orderedTermLocations := highlight.OrderTermLocations(tlm)
+	rv := ""
+	curr := f.Start
+	// the span of merged term locations being built
+	spanStart, spanEnd := -1, -1
+	for _, termLocation := range orderedTermLocations {
+		if termLocation.Start < f.Start || termLocation.End > f.End {
+			continue
+		}
+		if spanStart >= 0 && termLocation.Start <= spanEnd {
+			if termLocation.End > spanEnd {
+				spanEnd = termLocation.End
+			}
+			continue
+		}
+		if spanStart >= 0 {
+			rv += string(f.Orig[curr:spanStart]) + a.before + string(f.Orig[spanStart:spanEnd]) + a.after
+			curr = spanEnd
+		}
+		spanStart, spanEnd = termLocation.Start, termLocation.End
+	}
+	if spanStart >= 0 {
+		rv += string(f.Orig[curr:spanStart]) + a.before + string(f.Orig[spanStart:spanEnd]) + a.after
+		curr = spanEnd
+	}
+	// add any remaining text after the last match
+	rv += string(f.Orig[curr:f.End])
+
+	return rv
+}
+
+func Constructor(config map[string]interface{}, cache *registry.Cache) (highlight.FragmentFormatter, error) {
+	before := defaultPlainHighlightBefore
+	beforeVal, ok := config["before"].(string)
+	if ok {
+		before = beforeVal
+	}
+	after := defaultPlainHighlightAfter
+	afterVal, ok := config["after"].(string)
+	if ok {
+		after = afterVal
+	}
+	return NewFragmentFormatter(before, after), nil
+}
+
+func init() {
+	registry.RegisterFragmentFormatter(Name, Constructor)
+}
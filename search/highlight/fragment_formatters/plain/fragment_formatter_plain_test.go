@@ -0,0 +1,171 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package plain
+
+import (
+	"testing"
+
+	"github.com/blevesearch/bleve/search"
+	"github.com/blevesearch/bleve/search/highlight"
+)
+
+func TestPlainFragmentFormatter(t *testing.T) {
+	tests := []struct {
+		fragment *highlight.Fragment
+		tlm      search.TermLocationMap
+		output   string
+	}{
+		{
+			fragment: &highlight.Fragment{
+				Orig:  []byte("the quick brown fox"),
+				Start: 0,
+				End:   19,
+			},
+			tlm: search.TermLocationMap{
+				"quick": search.Locations{
+					&search.Location{
+						Pos:   2,
+						Start: 4,
+						End:   9,
+					},
+				},
+				"fox": search.Locations{
+					&search.Location{
+						Pos:   4,
+						Start: 16,
+						End:   19,
+					},
+				},
+			},
+			output: "the *quick* brown *fox*",
+		},
+		// overlapping matches are merged
+		{
+			fragment: &highlight.Fragment{
+				Orig:  []byte("the quick brown fox"),
+				Start: 0,
+				End:   19,
+			},
+			tlm: search.TermLocationMap{
+				"quick": search.Locations{
+					&search.Location{
+						Pos:   2,
+						Start: 4,
+						End:   9,
+					},
+				},
+				"qu": search.Locations{
+					&search.Location{
+						Pos:   2,
+						Start: 4,
+						End:   6,
+					},
+				},
+				"ick": search.Locations{
+					&search.Location{
+						Pos:   2,
+						Start: 6,
+						End:   9,
+					},
+				},
+			},
+			output: "the *quick* brown fox",
+		},
+		// adjacent matches are merged
+		{
+			fragment: &highlight.Fragment{
+				Orig:  []byte("play softball"),
+				Start: 0,
+				End:   13,
+			},
+			tlm: search.TermLocationMap{
+				"soft": search.Locations{
+					&search.Location{
+						Pos:   2,
+						Start: 5,
+						End:   9,
+					},
+				},
+				"ball": search.Locations{
+					&search.Location{
+						Pos:   2,
+						Start: 9,
+						End:   13,
+					},
+				},
+			},
+			output: "play *softball*",
+		},
+		// matches outside the fragment are ignored
+		{
+			fragment: &highlight.Fragment{
+				Orig:  []byte("the quick brown fox"),
+				Start: 4,
+				End:   15,
+			},
+			tlm: search.TermLocationMap{
+				"the": search.Locations{
+					&search.Location{
+						Pos:   1,
+						Start: 0,
+						End:   3,
+					},
+				},
+				"brown": search.Locations{
+					&search.Location{
+						Pos:   3,
+						Start: 10,
+						End:   15,
+					},
+				},
+				"fox": search.Locations{
+					&search.Location{
+						Pos:   4,
+						Start: 16,
+						End:   19,
+					},
+				},
+			},
+			output: "quick *brown*",
+		},
+	}
+
+	formatter := NewFragmentFormatter("*", "*")
+	for _, test := range tests {
+		result := formatter.Format(test.fragment, test.tlm)
+		if result != test.output {
+			t.Errorf("expected `%s`, got `%s`", test.output, result)
+		}
+	}
+}
+
+func TestPlainFragmentFormatterMarkers(t *testing.T) {
+	fragment := &highlight.Fragment{
+		Orig:  []byte("the quick brown fox"),
+		Start: 0,
+		End:   19,
+	}
+	tlm := search.TermLocationMap{
+		"quick": search.Locations{
+			&search.Location{
+				Pos:   2,
+				Start: 4,
+				End:   9,
+			},
+		},
+	}
+
+	formatter := NewFragmentFormatter("\x1b[43m", "\x1b[0m")
+	result := formatter.Format(fragment, tlm)
+	expected := "the \x1b[43mquick\x1b[0m brown fox"
+	if result != expected {
+		t.Errorf("expected `%q`, got `%q`", expected, result)
+	}
+}
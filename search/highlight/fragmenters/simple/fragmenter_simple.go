@@ -28,6 +28,12 @@ func NewFragmenter(fragmentSize int) *Fragmenter {
 	}
 }
 
+// WithFragmentSize returns a fragmenter choosing fragments
+// of the given size.
+func (s *Fragmenter) WithFragmentSize(size int) highlight.Fragmenter {
+	return NewFragmenter(size)
+}
+
 func (s *Fragmenter) Fragment(orig []byte, ot highlight.TermLocations) []*highlight.Fragment {
 	rv := make([]*highlight.Fragment, 0)
 
@@ -15,11 +15,12 @@ import (
 )
 
 type Fragment struct {
-	Orig  []byte
-	Start int
-	End   int
-	Score float64
-	Index int // used by heap
+	Orig           []byte
+	ArrayPositions []uint64
+	Start          int
+	End            int
+	Score          float64
+	Index          int // used by heap
 }
 
 func (f *Fragment) Overlaps(other *Fragment) bool {
@@ -35,6 +36,13 @@ type Fragmenter interface {
 	Fragment([]byte, TermLocations) []*Fragment
 }
 
+// A ResizableFragmenter returns a copy of itself choosing
+// fragments of another size, in bytes.
+type ResizableFragmenter interface {
+	Fragmenter
+	WithFragmentSize(size int) Fragmenter
+}
+
 type FragmentFormatter interface {
 	Format(f *Fragment, tlm search.TermLocationMap) string
 }
@@ -56,3 +64,11 @@ type Highlighter interface {
 	BestFragmentInField(*search.DocumentMatch, *document.Document, string) string
 	BestFragmentsInField(*search.DocumentMatch, *document.Document, string, int) []string
 }
+
+// A ResizableHighlighter returns a copy of itself choosing
+// fragments of another size, in bytes, leaving the shared
+// highlighter unchanged.
+type ResizableHighlighter interface {
+	Highlighter
+	WithFragmentSize(size int) Highlighter
+}
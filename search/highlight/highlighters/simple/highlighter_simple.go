@@ -44,6 +44,17 @@ func (s *Highlighter) SetFragmenter(f highlight.Fragmenter) {
 	s.fragmenter = f
 }
 
+// WithFragmentSize returns a copy of the highlighter with
+// its fragmenter resized, or the highlighter itself if the
+// fragmenter is not a highlight.ResizableFragmenter.
+func (s *Highlighter) WithFragmentSize(size int) highlight.Highlighter {
+	fragmenter, ok := s.fragmenter.(highlight.ResizableFragmenter)
+	if !ok {
+		return s
+	}
+	return NewHighlighter(fragmenter.WithFragmentSize(size), s.formatter, s.sep)
+}
+
 func (s *Highlighter) FragmentFormatter() highlight.FragmentFormatter {
 	return s.formatter
 }
@@ -69,10 +80,6 @@ func (s *Highlighter) BestFragmentInField(dm *search.DocumentMatch, doc *documen
 }
 
 func (s *Highlighter) BestFragmentsInField(dm *search.DocumentMatch, doc *document.Document, field string, num int) []string {
-	tlm := dm.Locations[field]
-	orderedTermLocations := highlight.OrderTermLocations(tlm)
-	scorer := NewFragmentScorer(dm.Locations[field])
-
 	// score the fragments and put them into a priority queue ordered by score
 	fq := make(FragmentQueue, 0)
 	heap.Init(&fq)
@@ -80,9 +87,14 @@ func (s *Highlighter) BestFragmentsInField(dm *search.DocumentMatch, doc *docume
 		if f.Name() == field {
 			_, ok := f.(*document.TextField)
 			if ok {
+				// only the locations in this value of the field apply
 				fieldData := f.Value()
-				fragments := s.fragmenter.Fragment(fieldData, orderedTermLocations)
+				tlm := highlight.LocationsInArrayElement(dm.Locations[field], f.ArrayPositions())
+				tlm = highlight.LocationsWithin(tlm, len(fieldData))
+				scorer := NewFragmentScorer(tlm)
+				fragments := s.fragmenter.Fragment(fieldData, highlight.OrderTermLocations(tlm))
 				for _, fragment := range fragments {
+					fragment.ArrayPositions = f.ArrayPositions()
 					scorer.Score(fragment)
 					heap.Push(&fq, fragment)
 				}
@@ -126,7 +138,8 @@ func (s *Highlighter) BestFragmentsInField(dm *search.DocumentMatch, doc *docume
 		if fragment.Start != 0 {
 			formattedFragments[i] += s.sep
 		}
-		formattedFragments[i] += s.formatter.Format(fragment, dm.Locations[field])
+		tlm := highlight.LocationsInArrayElement(dm.Locations[field], fragment.ArrayPositions)
+		formattedFragments[i] += s.formatter.Format(fragment, highlight.LocationsWithin(tlm, len(fragment.Orig)))
 		if fragment.End != len(fragment.Orig) {
 			formattedFragments[i] += s.sep
 		}
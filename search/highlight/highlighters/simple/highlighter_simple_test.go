@@ -61,6 +61,44 @@ func TestSimpleHighlighter(t *testing.T) {
 	}
 }
 
+func TestSimpleHighlighterLocationsBeyondValue(t *testing.T) {
+	fragmenter := sfrag.NewFragmenter(100)
+	formatter := ansi.NewFragmentFormatter(ansi.DefaultAnsiHighlight)
+	highlighter := NewHighlighter(fragmenter, formatter, defaultSeparator)
+
+	// locations recorded for a longer value than the one stored
+	docMatch := search.DocumentMatch{
+		ID:    "a",
+		Score: 1.0,
+		Locations: search.FieldTermLocationMap{
+			"desc": search.TermLocationMap{
+				"quick": search.Locations{
+					&search.Location{
+						Pos:   2,
+						Start: 4,
+						End:   9,
+					},
+				},
+				"dog": search.Locations{
+					&search.Location{
+						Pos:   9,
+						Start: 40,
+						End:   43,
+					},
+				},
+			},
+		},
+	}
+
+	expectedFragment := "the " + DefaultAnsiHighlight + "quick" + reset + " brown fox"
+	doc := document.NewDocument("a").AddField(document.NewTextField("desc", []uint64{}, []byte("the quick brown fox")))
+
+	fragment := highlighter.BestFragmentInField(&docMatch, doc, "desc")
+	if fragment != expectedFragment {
+		t.Errorf("expected `%s`, got `%s`", expectedFragment, fragment)
+	}
+}
+
 func TestSimpleHighlighterLonger(t *testing.T) {
 
 	fieldBytes := []byte(`Lorem ipsum dolor sit amet, consectetur adipiscing elit. Mauris sed semper nulla, sed pellentesque urna. Suspendisse potenti. Aliquam dignissim pulvinar erat vel ullamcorper. Nullam sed diam at dolor dapibus varius. Vestibulum at semper nunc. Integer ullamcorper enim ut nisi condimentum lacinia. Nulla ipsum ipsum, dictum in dapibus non, bibendum eget neque. Vestibulum malesuada erat quis malesuada dictum. Mauris luctus viverra lorem, nec hendrerit lacus lacinia ut. Donec suscipit sit amet nisi et dictum. Maecenas ultrices mollis diam, vel commodo libero lobortis nec. Nunc non dignissim dolor. Nulla non tempus risus, eget porttitor lectus. Suspendisse vitae gravida magna, a sagittis urna. Curabitur nec dui volutpat, hendrerit nisi non, adipiscing erat. Maecenas aliquet sem sit amet nibh ultrices accumsan.
@@ -44,3 +44,34 @@ func OrderTermLocations(tlm search.TermLocationMap) TermLocations {
 	sort.Sort(rv)
 	return rv
 }
+
+// LocationsWithin returns the locations of the map which
+// lie within a value of length size.  Locations are the
+// offsets recorded in the term vectors when the value was
+// indexed, any others cannot be highlighted in it.
+func LocationsWithin(tlm search.TermLocationMap, size int) search.TermLocationMap {
+	rv := make(search.TermLocationMap)
+	for term, locations := range tlm {
+		for _, location := range locations {
+			if location.Start >= 0 && location.Start <= location.End && location.End <= float64(size) {
+				rv.AddLocation(term, location)
+			}
+		}
+	}
+	return rv
+}
+
+// LocationsInArrayElement returns the locations of the
+// map which are in the value at the array positions
+func LocationsInArrayElement(tlm search.TermLocationMap, arrayPositions []uint64) search.TermLocationMap {
+	element := &search.Location{ArrayPositions: arrayPositions}
+	rv := make(search.TermLocationMap)
+	for term, locations := range tlm {
+		for _, location := range locations {
+			if location.SameArrayElement(element) {
+				rv.AddLocation(term, location)
+			}
+		}
+	}
+	return rv
+}
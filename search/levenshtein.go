@@ -89,3 +89,58 @@ func LevenshteinDistanceMax(a, b *string, max int) (int, bool) {
 	}
 	return d[la], false
 }
+
+// DamerauLevenshteinDistanceMax same as LevenshteinDistanceMax
+// but the transposition of two adjacent characters counts
+// as a single edit (optimal string alignment distance)
+func DamerauLevenshteinDistanceMax(a, b *string, max int) (int, bool) {
+	la := len(*a)
+	lb := len(*b)
+
+	ld := int(math.Abs(float64(la - lb)))
+	if ld > max {
+		return max, true
+	}
+
+	prev2 := make([]int, la+1)
+	prev := make([]int, la+1)
+	d := make([]int, la+1)
+	var temp int
+
+	for j := 1; j <= la; j++ {
+		d[j] = j
+	}
+	for i := 1; i <= lb; i++ {
+		prev2, prev, d = prev, d, prev2
+		d[0] = i
+		rowmin := max + 1
+		for j := 1; j <= la; j++ {
+			if (*a)[j-1] == (*b)[i-1] {
+				temp = 0
+			} else {
+				temp = 1
+			}
+			min := prev[j] + 1
+			if (d[j-1] + 1) < min {
+				min = d[j-1] + 1
+			}
+			if (prev[j-1] + temp) < min {
+				min = prev[j-1] + temp
+			}
+			if i > 1 && j > 1 &&
+				(*a)[j-1] == (*b)[i-2] && (*a)[j-2] == (*b)[i-1] &&
+				(prev2[j-2]+1) < min {
+				min = prev2[j-2] + 1
+			}
+			if min < rowmin {
+				rowmin = min
+			}
+			d[j] = min
+		}
+		// after each row if rowmin isnt less than max stop
+		if rowmin > max {
+			return max, true
+		}
+	}
+	return d[la], false
+}
@@ -80,6 +80,67 @@ func TestLevenshteinDistanceMax(t *testing.T) {
 	}
 }
 
+func TestDamerauLevenshteinDistanceMax(t *testing.T) {
+
+	tests := []struct {
+		a        string
+		b        string
+		max      int
+		dist     int
+		exceeded bool
+	}{
+		{
+			a:        "marty",
+			b:        "amrty",
+			max:      1,
+			dist:     1,
+			exceeded: false,
+		},
+		{
+			a:        "water",
+			b:        "wtaer",
+			max:      2,
+			dist:     1,
+			exceeded: false,
+		},
+		{
+			a:        "water",
+			b:        "atec",
+			max:      1,
+			dist:     1,
+			exceeded: true,
+		},
+		{
+			a:        "water",
+			b:        "water",
+			max:      1,
+			dist:     0,
+			exceeded: false,
+		},
+		{
+			a:        "ca",
+			b:        "abc",
+			max:      3,
+			dist:     3,
+			exceeded: false,
+		},
+	}
+
+	for _, test := range tests {
+		actual, exceeded := DamerauLevenshteinDistanceMax(&test.a, &test.b, test.max)
+		if actual != test.dist || exceeded != test.exceeded {
+			t.Errorf("expected %d %t, got %d %t for %s and %s", test.dist, test.exceeded, actual, exceeded, test.a, test.b)
+		}
+	}
+
+	// without transpositions the same pair is two edits apart
+	a, b := "marty", "amrty"
+	_, exceeded := LevenshteinDistanceMax(&a, &b, 1)
+	if !exceeded {
+		t.Errorf("expected levenshtein distance of %s and %s to exceed 1", a, b)
+	}
+}
+
 // 5 terms that are less than 2
 // 5 terms that are more than 2
 var benchmarkTerms = []string{
@@ -0,0 +1,72 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package scorers
+
+import (
+	"fmt"
+
+	"github.com/blevesearch/bleve/search"
+)
+
+// DisjunctionMaxQueryScorer scores a document with the best
+// score of the clauses it matches, plus tieBreaker times the
+// sum of the scores of the other clauses it matches.  Unlike
+// the DisjunctionQueryScorer there is no coordination factor,
+// so matching more clauses only counts through tieBreaker.
+type DisjunctionMaxQueryScorer struct {
+	tieBreaker float64
+	explain    bool
+}
+
+func NewDisjunctionMaxQueryScorer(tieBreaker float64, explain bool) *DisjunctionMaxQueryScorer {
+	return &DisjunctionMaxQueryScorer{
+		tieBreaker: tieBreaker,
+		explain:    explain,
+	}
+}
+
+func (s *DisjunctionMaxQueryScorer) Score(constituents []*search.DocumentMatch, countMatch, countTotal int) *search.DocumentMatch {
+	rv := search.DocumentMatch{
+		ID: constituents[0].ID,
+	}
+
+	var sum, max float64
+	var childrenExplanations []*search.Explanation
+	if s.explain {
+		childrenExplanations = make([]*search.Explanation, len(constituents))
+	}
+
+	locations := []search.FieldTermLocationMap{}
+	for i, docMatch := range constituents {
+		sum += docMatch.Score
+		if docMatch.Score > max {
+			max = docMatch.Score
+		}
+		if s.explain {
+			childrenExplanations[i] = docMatch.Expl
+		}
+		if docMatch.Locations != nil {
+			locations = append(locations, docMatch.Locations)
+		}
+	}
+
+	rv.Score = max + s.tieBreaker*(sum-max)
+	if s.explain {
+		rv.Expl = &search.Explanation{Value: rv.Score, Message: fmt.Sprintf("max plus %f times others of:", s.tieBreaker), Children: childrenExplanations}
+	}
+
+	if len(locations) == 1 {
+		rv.Locations = locations[0]
+	} else if len(locations) > 1 {
+		rv.Locations = search.MergeLocations(locations)
+	}
+
+	return &rv
+}
@@ -17,10 +17,21 @@ import (
 	"github.com/blevesearch/bleve/search"
 )
 
+// Default BM25 parameters
+const (
+	DefaultBM25K1 = 1.2
+	DefaultBM25B  = 0.75
+)
+
 type TermQueryScorer struct {
+	bm25                   bool
+	k1                     float64
+	b                      float64
+	avgFieldLength         float64
 	queryTerm              string
 	queryField             string
 	queryBoost             float64
+	fieldBoost             float64
 	docTerm                uint64
 	docTotal               uint64
 	idf                    float64
@@ -36,6 +47,7 @@ func NewTermQueryScorer(queryTerm string, queryField string, queryBoost float64,
 		queryTerm:   queryTerm,
 		queryField:  queryField,
 		queryBoost:  queryBoost,
+		fieldBoost:  1.0,
 		docTerm:     docTerm,
 		docTotal:    docTotal,
 		idf:         1.0 + math.Log(float64(docTotal)/float64(docTerm+1.0)),
@@ -53,28 +65,101 @@ func NewTermQueryScorer(queryTerm string, queryField string, queryBoost float64,
 	return &rv
 }
 
+// NewBM25TermQueryScorer creates a scorer using the BM25
+// model instead of TF-IDF.  k1 limits how much repeated
+// occurrences of the term add to the score, and b how much
+// the score is reduced for fields longer than
+// avgFieldLength, from 0 (not at all) to 1 (proportionally).
+func NewBM25TermQueryScorer(queryTerm string, queryField string, queryBoost float64, docTotal, docTerm uint64, avgFieldLength, k1, b float64, explain bool) *TermQueryScorer {
+	rv := TermQueryScorer{
+		bm25:           true,
+		k1:             k1,
+		b:              b,
+		avgFieldLength: avgFieldLength,
+		queryTerm:      queryTerm,
+		queryField:     queryField,
+		queryBoost:     queryBoost,
+		fieldBoost:     1.0,
+		docTerm:        docTerm,
+		docTotal:       docTotal,
+		idf:            math.Log(1.0 + (float64(docTotal)-float64(docTerm)+0.5)/(float64(docTerm)+0.5)),
+		explain:        explain,
+		queryWeight:    queryBoost,
+	}
+
+	if explain {
+		rv.idfExplanation = &search.Explanation{
+			Value:   rv.idf,
+			Message: fmt.Sprintf("idf(docFreq=%d, maxDocs=%d)", docTerm, docTotal),
+		}
+		rv.queryWeightExplanation = rv.boostExplanations()[0]
+	}
+
+	return &rv
+}
+
+// SetFieldBoost sets the boost of the field being searched,
+// from its mapping.  It multiplies with the boost of the
+// query, so that the weight of the term is proportional to
+// both, and must be set before the query norm.
+func (s *TermQueryScorer) SetFieldBoost(fieldBoost float64) {
+	s.fieldBoost = fieldBoost
+	if s.bm25 {
+		s.queryWeight = s.queryBoost * s.fieldBoost
+		if s.explain {
+			boosts := s.boostExplanations()
+			s.queryWeightExplanation = boosts[0]
+			if len(boosts) > 1 {
+				s.queryWeightExplanation = &search.Explanation{
+					Value:    s.queryWeight,
+					Message:  fmt.Sprintf("queryWeight(%s:%s^%f), product of:", s.queryField, string(s.queryTerm), s.queryBoost),
+					Children: boosts,
+				}
+			}
+		}
+	}
+}
+
+// boostExplanations explains the query boost, followed by
+// the field boost when there is one
+func (s *TermQueryScorer) boostExplanations() []*search.Explanation {
+	rv := []*search.Explanation{
+		&search.Explanation{
+			Value:   s.queryBoost,
+			Message: "boost",
+		},
+	}
+	if s.fieldBoost != 1.0 {
+		rv = append(rv, &search.Explanation{
+			Value:   s.fieldBoost,
+			Message: fmt.Sprintf("fieldBoost(field=%s)", s.queryField),
+		})
+	}
+	return rv
+}
+
 func (s *TermQueryScorer) Weight() float64 {
-	sum := s.queryBoost * s.idf
+	sum := s.queryBoost * s.fieldBoost * s.idf
 	return sum * sum
 }
 
 func (s *TermQueryScorer) SetQueryNorm(qnorm float64) {
 	s.queryNorm = qnorm
+	if s.bm25 {
+		// bm25 scores are not normalized, only boosted
+		return
+	}
 
 	// update the query weight
-	s.queryWeight = s.queryBoost * s.idf * s.queryNorm
+	s.queryWeight = s.queryBoost * s.fieldBoost * s.idf * s.queryNorm
 
 	if s.explain {
-		childrenExplanations := make([]*search.Explanation, 3)
-		childrenExplanations[0] = &search.Explanation{
-			Value:   s.queryBoost,
-			Message: "boost",
-		}
-		childrenExplanations[1] = s.idfExplanation
-		childrenExplanations[2] = &search.Explanation{
+		childrenExplanations := s.boostExplanations()
+		childrenExplanations = append(childrenExplanations, s.idfExplanation)
+		childrenExplanations = append(childrenExplanations, &search.Explanation{
 			Value:   s.queryNorm,
 			Message: "queryNorm",
-		}
+		})
 		s.queryWeightExplanation = &search.Explanation{
 			Value:    s.queryWeight,
 			Message:  fmt.Sprintf("queryWeight(%s:%s^%f), product of:", s.queryField, string(s.queryTerm), s.queryBoost),
@@ -84,33 +169,12 @@ func (s *TermQueryScorer) SetQueryNorm(qnorm float64) {
 }
 
 func (s *TermQueryScorer) Score(termMatch *index.TermFieldDoc) *search.DocumentMatch {
+	var score float64
 	var scoreExplanation *search.Explanation
-
-	// need to compute score
-	var tf float64
-	if termMatch.Freq < MaxSqrtCache {
-		tf = SqrtCache[int(termMatch.Freq)]
+	if s.bm25 {
+		score, scoreExplanation = s.bm25FieldWeight(termMatch)
 	} else {
-		tf = math.Sqrt(float64(termMatch.Freq))
-	}
-	score := tf * termMatch.Norm * s.idf
-
-	if s.explain {
-		childrenExplanations := make([]*search.Explanation, 3)
-		childrenExplanations[0] = &search.Explanation{
-			Value:   tf,
-			Message: fmt.Sprintf("tf(termFreq(%s:%s)=%d", s.queryField, string(s.queryTerm), termMatch.Freq),
-		}
-		childrenExplanations[1] = &search.Explanation{
-			Value:   termMatch.Norm,
-			Message: fmt.Sprintf("fieldNorm(field=%s, doc=%s)", s.queryField, termMatch.ID),
-		}
-		childrenExplanations[2] = s.idfExplanation
-		scoreExplanation = &search.Explanation{
-			Value:    score,
-			Message:  fmt.Sprintf("fieldWeight(%s:%s in %s), product of:", s.queryField, string(s.queryTerm), termMatch.ID),
-			Children: childrenExplanations,
-		}
+		score, scoreExplanation = s.tfidfFieldWeight(termMatch)
 	}
 
 	// if the query weight isn't 1, multiply
@@ -146,9 +210,10 @@ func (s *TermQueryScorer) Score(termMatch *index.TermFieldDoc) *search.DocumentM
 			}
 
 			loc := search.Location{
-				Pos:   float64(v.Pos),
-				Start: float64(v.Start),
-				End:   float64(v.End),
+				Pos:            float64(v.Pos),
+				Start:          float64(v.Start),
+				End:            float64(v.End),
+				ArrayPositions: v.ArrayPositions,
 			}
 
 			locations := tlm[s.queryTerm]
@@ -167,3 +232,68 @@ func (s *TermQueryScorer) Score(termMatch *index.TermFieldDoc) *search.DocumentM
 
 	return &rv
 }
+
+func (s *TermQueryScorer) tfidfFieldWeight(termMatch *index.TermFieldDoc) (float64, *search.Explanation) {
+	var scoreExplanation *search.Explanation
+
+	// need to compute score
+	var tf float64
+	if termMatch.Freq < MaxSqrtCache {
+		tf = SqrtCache[int(termMatch.Freq)]
+	} else {
+		tf = math.Sqrt(float64(termMatch.Freq))
+	}
+	score := tf * termMatch.Norm * s.idf
+
+	if s.explain {
+		childrenExplanations := make([]*search.Explanation, 3)
+		childrenExplanations[0] = &search.Explanation{
+			Value:   tf,
+			Message: fmt.Sprintf("tf(termFreq(%s:%s)=%d", s.queryField, string(s.queryTerm), termMatch.Freq),
+		}
+		childrenExplanations[1] = &search.Explanation{
+			Value:   termMatch.Norm,
+			Message: fmt.Sprintf("fieldNorm(field=%s, doc=%s)", s.queryField, termMatch.ID),
+		}
+		childrenExplanations[2] = s.idfExplanation
+		scoreExplanation = &search.Explanation{
+			Value:    score,
+			Message:  fmt.Sprintf("fieldWeight(%s:%s in %s), product of:", s.queryField, string(s.queryTerm), termMatch.ID),
+			Children: childrenExplanations,
+		}
+	}
+
+	return score, scoreExplanation
+}
+
+func (s *TermQueryScorer) bm25FieldWeight(termMatch *index.TermFieldDoc) (float64, *search.Explanation) {
+	// the norm of the field is 1/sqrt(fieldLength)
+	fieldLength := s.avgFieldLength
+	if termMatch.Norm > 0 {
+		fieldLength = math.Floor(1.0/(termMatch.Norm*termMatch.Norm) + 0.5)
+	}
+	lengthNorm := 1.0
+	if s.avgFieldLength > 0 {
+		lengthNorm = 1.0 - s.b + s.b*fieldLength/s.avgFieldLength
+	}
+	freq := float64(termMatch.Freq)
+	tf := freq * (s.k1 + 1.0) / (freq + s.k1*lengthNorm)
+	score := tf * s.idf
+
+	var scoreExplanation *search.Explanation
+	if s.explain {
+		childrenExplanations := make([]*search.Explanation, 2)
+		childrenExplanations[0] = &search.Explanation{
+			Value: tf,
+			Message: fmt.Sprintf("tf(termFreq(%s:%s)=%d, k1=%f, b=%f, fieldLength=%f, avgFieldLength=%f)",
+				s.queryField, string(s.queryTerm), termMatch.Freq, s.k1, s.b, fieldLength, s.avgFieldLength),
+		}
+		childrenExplanations[1] = s.idfExplanation
+		scoreExplanation = &search.Explanation{
+			Value:    score,
+			Message:  fmt.Sprintf("fieldWeight(%s:%s in %s), product of:", s.queryField, string(s.queryTerm), termMatch.ID),
+			Children: childrenExplanations,
+		}
+	}
+	return score, scoreExplanation
+}
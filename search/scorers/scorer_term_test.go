@@ -239,3 +239,119 @@ func TestTermScorerWithQueryNorm(t *testing.T) {
 	}
 
 }
+
+func TestBM25TermScorer(t *testing.T) {
+
+	var docTotal uint64 = 100
+	var docTerm uint64 = 9
+	var queryTerm = "beer"
+	var queryField = "desc"
+	var queryBoost = 2.0
+	var avgFieldLength = 4.0
+	scorer := NewBM25TermQueryScorer(queryTerm, queryField, queryBoost, docTotal, docTerm, avgFieldLength, DefaultBM25K1, DefaultBM25B, false)
+	idf := math.Log(1.0 + (100.0-9.0+0.5)/(9.0+0.5))
+
+	// the query norm does not apply
+	scorer.SetQueryNorm(5.0)
+
+	bm25 := func(freq, fieldLength float64) float64 {
+		lengthNorm := 1.0 - DefaultBM25B + DefaultBM25B*fieldLength/avgFieldLength
+		return idf * freq * (DefaultBM25K1 + 1) / (freq + DefaultBM25K1*lengthNorm) * queryBoost
+	}
+
+	tests := []struct {
+		termMatch *index.TermFieldDoc
+		score     float64
+	}{
+		// average length
+		{
+			termMatch: &index.TermFieldDoc{ID: "one", Freq: 1, Norm: 1.0 / math.Sqrt(4.0)},
+			score:     bm25(1, 4),
+		},
+		// shorter fields score higher
+		{
+			termMatch: &index.TermFieldDoc{ID: "two", Freq: 1, Norm: 1.0},
+			score:     bm25(1, 1),
+		},
+		// frequency saturates
+		{
+			termMatch: &index.TermFieldDoc{ID: "three", Freq: 16, Norm: 1.0 / math.Sqrt(16.0)},
+			score:     bm25(16, 16),
+		},
+		// without a norm the field is assumed average
+		{
+			termMatch: &index.TermFieldDoc{ID: "four", Freq: 1},
+			score:     bm25(1, 4),
+		},
+	}
+
+	for _, test := range tests {
+		actual := scorer.Score(test.termMatch)
+		if math.Abs(actual.Score-test.score) > 1e-9 {
+			t.Errorf("expected score %f, got %f for %s", test.score, actual.Score, test.termMatch.ID)
+		}
+	}
+
+	// the score never exceeds idf * (k1 + 1) * boost
+	actual := scorer.Score(&index.TermFieldDoc{ID: "five", Freq: 1000, Norm: 1.0 / math.Sqrt(1000.0)})
+	if actual.Score >= idf*(DefaultBM25K1+1)*queryBoost {
+		t.Errorf("expected saturated score below %f, got %f", idf*(DefaultBM25K1+1)*queryBoost, actual.Score)
+	}
+
+	// explanations add up to the score
+	scorer = NewBM25TermQueryScorer(queryTerm, queryField, queryBoost, docTotal, docTerm, avgFieldLength, DefaultBM25K1, DefaultBM25B, true)
+	actual = scorer.Score(&index.TermFieldDoc{ID: "one", Freq: 1, Norm: 0.5})
+	if actual.Expl == nil || actual.Expl.Value != actual.Score || len(actual.Expl.Children) != 2 {
+		t.Errorf("expected explanation of the score, got %#v", actual.Expl)
+	}
+}
+
+func TestTermScorerBoosts(t *testing.T) {
+
+	var docTotal uint64 = 100
+	var docTerm uint64 = 9
+	termMatch := &index.TermFieldDoc{ID: "one", Freq: 1, Norm: 1.0}
+
+	score := func(queryBoost, fieldBoost float64, bm25 bool) *search.DocumentMatch {
+		var scorer *TermQueryScorer
+		if bm25 {
+			scorer = NewBM25TermQueryScorer("beer", "desc", queryBoost, docTotal, docTerm, 1.0, DefaultBM25K1, DefaultBM25B, true)
+		} else {
+			scorer = NewTermQueryScorer("beer", "desc", queryBoost, docTotal, docTerm, true)
+		}
+		scorer.SetFieldBoost(fieldBoost)
+		scorer.SetQueryNorm(0.5)
+		return scorer.Score(termMatch)
+	}
+
+	for _, bm25 := range []bool{false, true} {
+		base := score(1.0, 1.0, bm25).Score
+		tests := []struct {
+			queryBoost float64
+			fieldBoost float64
+			factor     float64
+		}{
+			{2.0, 1.0, 2.0},
+			{1.0, 2.0, 2.0},
+			{2.0, 3.0, 6.0},
+		}
+		for _, test := range tests {
+			actual := score(test.queryBoost, test.fieldBoost, bm25)
+			if math.Abs(actual.Score-base*test.factor) > 1e-9 {
+				t.Errorf("expected %f, got %f for query boost %f and field boost %f (bm25 %t)",
+					base*test.factor, actual.Score, test.queryBoost, test.fieldBoost, bm25)
+			}
+
+			// the explanation lists both boosts
+			if test.fieldBoost == 1.0 {
+				continue
+			}
+			queryWeight := actual.Expl.Children[0]
+			if len(queryWeight.Children) < 2 ||
+				queryWeight.Children[0].Message != "boost" || queryWeight.Children[0].Value != test.queryBoost ||
+				queryWeight.Children[1].Message != "fieldBoost(field=desc)" || queryWeight.Children[1].Value != test.fieldBoost {
+				t.Errorf("expected boosts in explanation, got %#v (bm25 %t)", queryWeight, bm25)
+			}
+		}
+	}
+}
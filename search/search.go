@@ -13,6 +13,24 @@ type Location struct {
 	Pos   float64 `json:"pos"`
 	Start float64 `json:"start"`
 	End   float64 `json:"end"`
+
+	// ArrayPositions identifies the array element holding
+	// the value the location is in
+	ArrayPositions []uint64 `json:"array_positions,omitempty"`
+}
+
+// SameArrayElement returns true if the other location is
+// in the value held by the same array element.
+func (l *Location) SameArrayElement(other *Location) bool {
+	if len(l.ArrayPositions) != len(other.ArrayPositions) {
+		return false
+	}
+	for i := range l.ArrayPositions {
+		if l.ArrayPositions[i] != other.ArrayPositions[i] {
+			return false
+		}
+	}
+	return true
 }
 
 type Locations []*Location
@@ -42,6 +60,14 @@ type DocumentMatch struct {
 	Locations FieldTermLocationMap   `json:"locations,omitempty"`
 	Fragments FieldFragmentMap       `json:"fragments,omitempty"`
 	Fields    map[string]interface{} `json:"fields,omitempty"`
+
+	// Sort holds the values the match was sorted by,
+	// when the request specified a sort order
+	Sort []interface{} `json:"sort,omitempty"`
+
+	// NormalizedScore is the score relative to the best
+	// match, when the request asked for normalization
+	NormalizedScore float64 `json:"normalized_score,omitempty"`
 }
 
 func (dm *DocumentMatch) AddFieldValue(name string, value interface{}) {
@@ -70,6 +96,35 @@ func (c DocumentMatchCollection) Len() int           { return len(c) }
 func (c DocumentMatchCollection) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
 func (c DocumentMatchCollection) Less(i, j int) bool { return c[i].Score > c[j].Score }
 
+// DocumentMatchGroup holds the top matches sharing
+// the same value of a collapse field.
+type DocumentMatchGroup struct {
+	Value   string                  `json:"value"`
+	Missing bool                    `json:"missing,omitempty"`
+	Total   uint64                  `json:"total"`
+	Hits    DocumentMatchCollection `json:"hits"`
+}
+
+// MaxScore returns the score of the best match
+// in the group.
+func (g *DocumentMatchGroup) MaxScore() float64 {
+	if len(g.Hits) > 0 {
+		return g.Hits[0].Score
+	}
+	return 0
+}
+
+type DocumentMatchGroupCollection []*DocumentMatchGroup
+
+func (c DocumentMatchGroupCollection) Len() int      { return len(c) }
+func (c DocumentMatchGroupCollection) Swap(i, j int) { c[i], c[j] = c[j], c[i] }
+func (c DocumentMatchGroupCollection) Less(i, j int) bool {
+	if c[i].MaxScore() == c[j].MaxScore() {
+		return c[i].Value < c[j].Value
+	}
+	return c[i].MaxScore() > c[j].MaxScore()
+}
+
 type Searcher interface {
 	Next() (*DocumentMatch, error)
 	Advance(ID string) (*DocumentMatch, error)
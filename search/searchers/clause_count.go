@@ -0,0 +1,56 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package searchers
+
+import (
+	"fmt"
+
+	"github.com/blevesearch/bleve/index"
+)
+
+// ErrTooManyClauses is returned when a prefix, fuzzy or
+// regexp searcher expands to more terms than the maximum
+// clause count of its index reader.
+var ErrTooManyClauses = fmt.Errorf("query expands to more terms than the maximum clause count")
+
+// ClauseCountIndexReader is implemented by index readers
+// limiting the number of terms, each searched with a
+// clause of its own, a searcher expanding to the terms of
+// the dictionary may search.  A max of 0 means no limit.
+// When truncate is set, the searcher keeps the first max
+// terms, in dictionary order, instead of failing with
+// ErrTooManyClauses.
+type ClauseCountIndexReader interface {
+	index.IndexReader
+	MaxClauseCount() (max int, truncate bool)
+}
+
+// clauseCount checks the number of terms expanded so far
+// against the limit of the index reader, after each one.
+// It reports whether the expansion must stop, having
+// reached the limit when truncating, and returns
+// ErrTooManyClauses once the limit is passed otherwise.
+func clauseCount(indexReader index.IndexReader, count int) (bool, error) {
+	limitReader, ok := indexReader.(ClauseCountIndexReader)
+	if !ok {
+		return false, nil
+	}
+	max, truncate := limitReader.MaxClauseCount()
+	if max <= 0 || count < max {
+		return false, nil
+	}
+	if truncate {
+		return true, nil
+	}
+	if count > max {
+		return true, ErrTooManyClauses
+	}
+	return false, nil
+}
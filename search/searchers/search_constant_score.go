@@ -0,0 +1,78 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package searchers
+
+import (
+	"github.com/blevesearch/bleve/index"
+	"github.com/blevesearch/bleve/search"
+	"github.com/blevesearch/bleve/search/scorers"
+)
+
+// ConstantScoreSearcher matches the same documents as
+// the searcher it wraps, but ignores its scoring and
+// gives every match the same constant score.
+type ConstantScoreSearcher struct {
+	indexReader index.IndexReader
+	searcher    search.Searcher
+	scorer      *scorers.ConstantScorer
+}
+
+func NewConstantScoreSearcher(indexReader index.IndexReader, searcher search.Searcher, boost float64, explain bool) (*ConstantScoreSearcher, error) {
+	scorer := scorers.NewConstantScorer(1.0, boost, explain)
+	return &ConstantScoreSearcher{
+		indexReader: indexReader,
+		searcher:    searcher,
+		scorer:      scorer,
+	}, nil
+}
+
+func (s *ConstantScoreSearcher) Count() uint64 {
+	return s.searcher.Count()
+}
+
+func (s *ConstantScoreSearcher) Weight() float64 {
+	return s.scorer.Weight()
+}
+
+func (s *ConstantScoreSearcher) SetQueryNorm(qnorm float64) {
+	s.scorer.SetQueryNorm(qnorm)
+}
+
+func (s *ConstantScoreSearcher) Next() (*search.DocumentMatch, error) {
+	docMatch, err := s.searcher.Next()
+	if err != nil || docMatch == nil {
+		return nil, err
+	}
+	return s.rescore(docMatch), nil
+}
+
+func (s *ConstantScoreSearcher) Advance(ID string) (*search.DocumentMatch, error) {
+	docMatch, err := s.searcher.Advance(ID)
+	if err != nil || docMatch == nil {
+		return nil, err
+	}
+	return s.rescore(docMatch), nil
+}
+
+// rescore replaces the score of the wrapped searcher,
+// keeping the term locations for highlighting
+func (s *ConstantScoreSearcher) rescore(docMatch *search.DocumentMatch) *search.DocumentMatch {
+	rv := s.scorer.Score(docMatch.ID)
+	rv.Locations = docMatch.Locations
+	return rv
+}
+
+func (s *ConstantScoreSearcher) Close() {
+	s.searcher.Close()
+}
+
+func (s *ConstantScoreSearcher) Min() int {
+	return 0
+}
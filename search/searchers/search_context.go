@@ -0,0 +1,85 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package searchers
+
+import (
+	"context"
+
+	"github.com/blevesearch/bleve/search"
+)
+
+// ContextSearcher wraps a searcher, ending its matches early
+// once its context is done.  The context is checked before
+// each match, so a collector reading from it stops with the
+// matches found so far instead of failing.
+type ContextSearcher struct {
+	child    search.Searcher
+	ctx      context.Context
+	timedOut bool
+}
+
+func NewContextSearcher(ctx context.Context, s search.Searcher) *ContextSearcher {
+	return &ContextSearcher{
+		child: s,
+		ctx:   ctx,
+	}
+}
+
+// TimedOut reports whether the matches were ended early
+// because the context was done.
+func (s *ContextSearcher) TimedOut() bool {
+	return s.timedOut
+}
+
+func (s *ContextSearcher) done() bool {
+	if s.timedOut {
+		return true
+	}
+	select {
+	case <-s.ctx.Done():
+		s.timedOut = true
+	default:
+	}
+	return s.timedOut
+}
+
+func (s *ContextSearcher) Next() (*search.DocumentMatch, error) {
+	if s.done() {
+		return nil, nil
+	}
+	return s.child.Next()
+}
+
+func (s *ContextSearcher) Advance(ID string) (*search.DocumentMatch, error) {
+	if s.done() {
+		return nil, nil
+	}
+	return s.child.Advance(ID)
+}
+
+func (s *ContextSearcher) Close() {
+	s.child.Close()
+}
+
+func (s *ContextSearcher) Weight() float64 {
+	return s.child.Weight()
+}
+
+func (s *ContextSearcher) SetQueryNorm(n float64) {
+	s.child.SetQueryNorm(n)
+}
+
+func (s *ContextSearcher) Count() uint64 {
+	return s.child.Count()
+}
+
+func (s *ContextSearcher) Min() int {
+	return s.child.Min()
+}
@@ -0,0 +1,77 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package searchers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestContextSearcher(t *testing.T) {
+
+	twoDocIndexReader, err := twoDocIndex.Reader()
+	if err != nil {
+		t.Error(err)
+	}
+	defer twoDocIndexReader.Close()
+
+	allSearcher, err := NewMatchAllSearcher(twoDocIndexReader, 1.0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	searcher := NewContextSearcher(ctx, allSearcher)
+	defer searcher.Close()
+
+	got := []string{}
+	next, err := searcher.Next()
+	for err == nil && next != nil {
+		got = append(got, next.ID)
+		if len(got) == 2 {
+			cancel()
+		}
+		next, err = searcher.Next()
+	}
+	if err != nil {
+		t.Fatalf("error iterating searcher: %v", err)
+	}
+	expected := []string{"1", "2"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+	if !searcher.TimedOut() {
+		t.Errorf("expected searcher to have timed out")
+	}
+
+	// a context which is never done does not end the matches
+	allSearcher, err = NewMatchAllSearcher(twoDocIndexReader, 1.0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	searcher = NewContextSearcher(context.Background(), allSearcher)
+	defer searcher.Close()
+	count := 0
+	next, err = searcher.Next()
+	for err == nil && next != nil {
+		count++
+		next, err = searcher.Next()
+	}
+	if err != nil {
+		t.Fatalf("error iterating searcher: %v", err)
+	}
+	if uint64(count) != searcher.Count() {
+		t.Errorf("expected %d matches, got %d", searcher.Count(), count)
+	}
+	if searcher.TimedOut() {
+		t.Errorf("expected searcher not to have timed out")
+	}
+}
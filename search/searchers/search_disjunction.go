@@ -25,11 +25,29 @@ type DisjunctionSearcher struct {
 	queryNorm   float64
 	currs       []*search.DocumentMatch
 	currentID   string
-	scorer      *scorers.DisjunctionQueryScorer
+	scorer      disjunctionScorer
 	min         float64
 }
 
+// disjunctionScorer combines the matches of the searchers
+// of a DisjunctionSearcher for one document.
+type disjunctionScorer interface {
+	Score(constituents []*search.DocumentMatch, countMatch, countTotal int) *search.DocumentMatch
+}
+
 func NewDisjunctionSearcher(indexReader index.IndexReader, qsearchers []search.Searcher, min float64, explain bool) (*DisjunctionSearcher, error) {
+	return newDisjunctionSearcher(indexReader, qsearchers, min, scorers.NewDisjunctionQueryScorer(explain))
+}
+
+// NewDisjunctionMaxSearcher returns a searcher matching the
+// same documents as a DisjunctionSearcher with a min of 0, but
+// scoring them with the best score of the searchers matching
+// them, plus tieBreaker times the sum of the other scores.
+func NewDisjunctionMaxSearcher(indexReader index.IndexReader, qsearchers []search.Searcher, tieBreaker float64, explain bool) (*DisjunctionSearcher, error) {
+	return newDisjunctionSearcher(indexReader, qsearchers, 0, scorers.NewDisjunctionMaxQueryScorer(tieBreaker, explain))
+}
+
+func newDisjunctionSearcher(indexReader index.IndexReader, qsearchers []search.Searcher, min float64, scorer disjunctionScorer) (*DisjunctionSearcher, error) {
 	// build the downstream searchers
 	searchers := make(OrderedSearcherList, len(qsearchers))
 	for i, searcher := range qsearchers {
@@ -42,7 +60,7 @@ func NewDisjunctionSearcher(indexReader index.IndexReader, qsearchers []search.S
 		indexReader: indexReader,
 		searchers:   searchers,
 		currs:       make([]*search.DocumentMatch, len(searchers)),
-		scorer:      scorers.NewDisjunctionQueryScorer(explain),
+		scorer:      scorer,
 		min:         min,
 	}
 	rv.computeQueryNorm()
@@ -109,6 +127,10 @@ func (s *DisjunctionSearcher) Next() (*search.DocumentMatch, error) {
 			return nil, err
 		}
 	}
+	if int(s.min) > len(s.searchers) {
+		// no document can match enough searchers
+		return nil, nil
+	}
 	var err error
 	var rv *search.DocumentMatch
 	matching := make([]*search.DocumentMatch, 0)
@@ -152,3 +152,60 @@ func TestDisjunctionAdvance(t *testing.T) {
 		t.Errorf("expected 3, got nil")
 	}
 }
+
+func TestDisjunctionMin(t *testing.T) {
+
+	twoDocIndexReader, err := twoDocIndex.Reader()
+	if err != nil {
+		t.Error(err)
+	}
+	defer twoDocIndexReader.Close()
+
+	tests := []struct {
+		min float64
+		ids []string
+	}{
+		{min: 0, ids: []string{"1", "2", "3", "4", "5"}},
+		{min: 1, ids: []string{"1", "2", "3", "4", "5"}},
+		{min: 2, ids: []string{"1", "2", "3"}},
+		{min: 3, ids: []string{"2"}},
+		{min: 4, ids: []string{}},
+	}
+
+	for testIndex, test := range tests {
+		// beer: 1, 2, 3, 4 - mister: 2, 3, 5 - couchbase: 1, 2
+		beerTermSearcher, err := NewTermSearcher(twoDocIndexReader, "beer", "desc", 1.0, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		misterTermSearcher, err := NewTermSearcher(twoDocIndexReader, "mister", "title", 1.0, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		couchbaseTermSearcher, err := NewTermSearcher(twoDocIndexReader, "couchbase", "street", 1.0, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		searcher, err := NewDisjunctionSearcher(twoDocIndexReader, []search.Searcher{beerTermSearcher, misterTermSearcher, couchbaseTermSearcher}, test.min, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer searcher.Close()
+
+		next, err := searcher.Next()
+		i := 0
+		for err == nil && next != nil {
+			if i < len(test.ids) && next.ID != test.ids[i] {
+				t.Errorf("expected result %d to have id %s got %s for test %d", i, test.ids[i], next.ID, testIndex)
+			}
+			next, err = searcher.Next()
+			i++
+		}
+		if err != nil {
+			t.Fatalf("error iterating searcher: %v for test %d", err, testIndex)
+		}
+		if len(test.ids) != i {
+			t.Errorf("expected %d results got %d for test %d", len(test.ids), i, testIndex)
+		}
+	}
+}
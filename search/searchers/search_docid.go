@@ -0,0 +1,110 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package searchers
+
+import (
+	"sort"
+
+	"github.com/blevesearch/bleve/index"
+	"github.com/blevesearch/bleve/search"
+	"github.com/blevesearch/bleve/search/scorers"
+)
+
+// DocIDSearcher matches the documents with the given
+// identifiers, seeking each of them in the document
+// identifier index.  Identifiers of documents which do
+// not exist are skipped.
+type DocIDSearcher struct {
+	indexReader index.IndexReader
+	reader      index.DocIDReader
+	scorer      *scorers.ConstantScorer
+	ids         []string
+	pos         int
+}
+
+func NewDocIDSearcher(indexReader index.IndexReader, ids []string, boost float64, explain bool) (*DocIDSearcher, error) {
+	sorted := make([]string, len(ids))
+	copy(sorted, ids)
+	sort.Strings(sorted)
+	// drop duplicates
+	unique := sorted[:0]
+	for i, id := range sorted {
+		if i == 0 || id != sorted[i-1] {
+			unique = append(unique, id)
+		}
+	}
+
+	rv := DocIDSearcher{
+		indexReader: indexReader,
+		scorer:      scorers.NewConstantScorer(1.0, boost, explain),
+		ids:         unique,
+	}
+	if len(unique) > 0 {
+		var err error
+		rv.reader, err = indexReader.DocIDReader(unique[0], unique[len(unique)-1])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &rv, nil
+}
+
+// Count returns the number of identifiers searched, an upper
+// bound of the number of matches.
+func (s *DocIDSearcher) Count() uint64 {
+	return uint64(len(s.ids))
+}
+
+func (s *DocIDSearcher) Weight() float64 {
+	return s.scorer.Weight()
+}
+
+func (s *DocIDSearcher) SetQueryNorm(qnorm float64) {
+	s.scorer.SetQueryNorm(qnorm)
+}
+
+func (s *DocIDSearcher) Next() (*search.DocumentMatch, error) {
+	for s.pos < len(s.ids) {
+		id, err := s.reader.Advance(s.ids[s.pos])
+		if err != nil {
+			return nil, err
+		}
+		if id == "" {
+			s.pos = len(s.ids)
+			return nil, nil
+		}
+		// skip the identifiers of missing documents
+		for s.pos < len(s.ids) && s.ids[s.pos] < id {
+			s.pos++
+		}
+		if s.pos < len(s.ids) && s.ids[s.pos] == id {
+			s.pos++
+			return s.scorer.Score(id), nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *DocIDSearcher) Advance(ID string) (*search.DocumentMatch, error) {
+	for s.pos < len(s.ids) && s.ids[s.pos] < ID {
+		s.pos++
+	}
+	return s.Next()
+}
+
+func (s *DocIDSearcher) Close() {
+	if s.reader != nil {
+		s.reader.Close()
+	}
+}
+
+func (s *DocIDSearcher) Min() int {
+	return 0
+}
@@ -0,0 +1,76 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package searchers
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDocIDSearcher(t *testing.T) {
+
+	twoDocIndexReader, err := twoDocIndex.Reader()
+	if err != nil {
+		t.Error(err)
+	}
+	defer twoDocIndexReader.Close()
+
+	tests := []struct {
+		ids      []string
+		advance  string
+		expected []string
+	}{
+		{
+			ids:      []string{"3", "1", "7", "1", "5"},
+			expected: []string{"1", "3", "5"},
+		},
+		{
+			ids:      []string{"3", "1", "7", "1", "5"},
+			advance:  "2",
+			expected: []string{"3", "5"},
+		},
+		{
+			ids:      []string{"0", "45", "6"},
+			expected: []string{},
+		},
+		{
+			ids:      []string{},
+			expected: []string{},
+		},
+	}
+
+	for testIndex, test := range tests {
+		searcher, err := NewDocIDSearcher(twoDocIndexReader, test.ids, 1.0, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer searcher.Close()
+
+		got := []string{}
+		next, err := searcher.Next()
+		if test.advance != "" {
+			// the first match is "1", advance past it
+			next, err = searcher.Advance(test.advance)
+		}
+		for err == nil && next != nil {
+			got = append(got, next.ID)
+			if next.Score != 1.0 {
+				t.Errorf("expected score 1.0 for %s, got %v for test %d", next.ID, next.Score, testIndex)
+			}
+			next, err = searcher.Next()
+		}
+		if err != nil {
+			t.Fatalf("error iterating searcher: %v for test %d", err, testIndex)
+		}
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("expected %v, got %v for test %d", test.expected, got, testIndex)
+		}
+	}
+}
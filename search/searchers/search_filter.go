@@ -0,0 +1,82 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package searchers
+
+import (
+	"github.com/blevesearch/bleve/search"
+)
+
+// FilterFunc reports whether a document match should be kept.
+type FilterFunc func(d *search.DocumentMatch) (bool, error)
+
+// FilteringSearcher wraps a searcher, only returning the matches
+// accepted by its filter.
+type FilteringSearcher struct {
+	child  search.Searcher
+	accept FilterFunc
+}
+
+func NewFilteringSearcher(s search.Searcher, filter FilterFunc) *FilteringSearcher {
+	return &FilteringSearcher{
+		child:  s,
+		accept: filter,
+	}
+}
+
+func (f *FilteringSearcher) Next() (*search.DocumentMatch, error) {
+	next, err := f.child.Next()
+	for next != nil && err == nil {
+		var ok bool
+		ok, err = f.accept(next)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return next, nil
+		}
+		next, err = f.child.Next()
+	}
+	return nil, err
+}
+
+func (f *FilteringSearcher) Advance(ID string) (*search.DocumentMatch, error) {
+	adv, err := f.child.Advance(ID)
+	if err != nil || adv == nil {
+		return nil, err
+	}
+	ok, err := f.accept(adv)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return adv, nil
+	}
+	return f.Next()
+}
+
+func (f *FilteringSearcher) Close() {
+	f.child.Close()
+}
+
+func (f *FilteringSearcher) Weight() float64 {
+	return f.child.Weight()
+}
+
+func (f *FilteringSearcher) SetQueryNorm(n float64) {
+	f.child.SetQueryNorm(n)
+}
+
+func (f *FilteringSearcher) Count() uint64 {
+	return f.child.Count()
+}
+
+func (f *FilteringSearcher) Min() int {
+	return f.child.Min()
+}
@@ -0,0 +1,184 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package searchers
+
+import (
+	"fmt"
+
+	"github.com/blevesearch/bleve/index"
+	"github.com/blevesearch/bleve/numeric_util"
+	"github.com/blevesearch/bleve/search"
+)
+
+// The ways the value of the function of a
+// FunctionScoreSearcher combines with the score of the
+// match.
+const (
+	FunctionScoreMultiply = "multiply"
+	FunctionScoreSum      = "sum"
+	FunctionScoreReplace  = "replace"
+)
+
+// ScoreFunc computes a value for a match from the match,
+// as scored by the wrapped searcher, and the numeric
+// values of the fields of the document.  A field with one
+// value maps to a float64, one with several values to a
+// []float64, and fields the document lacks are absent.
+type ScoreFunc func(d *search.DocumentMatch, fields map[string]interface{}) float64
+
+// FunctionScoreSearcher matches the same documents as the
+// searcher it wraps, combining the score of each match
+// with the value of a function of the match.
+type FunctionScoreSearcher struct {
+	indexReader index.IndexReader
+	searcher    search.Searcher
+	fields      []string
+	fn          ScoreFunc
+	mode        string
+	boost       float64
+	explain     bool
+}
+
+// NewFunctionScoreSearcher wraps searcher, passing fn the
+// values of fields, and combining its value with the score
+// by mode, one of FunctionScoreMultiply, FunctionScoreSum
+// and FunctionScoreReplace.  The combined score is
+// multiplied by boost.
+func NewFunctionScoreSearcher(indexReader index.IndexReader, searcher search.Searcher, fields []string, fn ScoreFunc, mode string, boost float64, explain bool) (*FunctionScoreSearcher, error) {
+	switch mode {
+	case FunctionScoreMultiply, FunctionScoreSum, FunctionScoreReplace:
+	default:
+		return nil, fmt.Errorf("unknown function score mode '%s'", mode)
+	}
+	return &FunctionScoreSearcher{
+		indexReader: indexReader,
+		searcher:    searcher,
+		fields:      fields,
+		fn:          fn,
+		mode:        mode,
+		boost:       boost,
+		explain:     explain,
+	}, nil
+}
+
+func (s *FunctionScoreSearcher) Count() uint64 {
+	return s.searcher.Count()
+}
+
+func (s *FunctionScoreSearcher) Weight() float64 {
+	return s.searcher.Weight()
+}
+
+func (s *FunctionScoreSearcher) SetQueryNorm(qnorm float64) {
+	s.searcher.SetQueryNorm(qnorm)
+}
+
+func (s *FunctionScoreSearcher) Next() (*search.DocumentMatch, error) {
+	docMatch, err := s.searcher.Next()
+	if err != nil || docMatch == nil {
+		return nil, err
+	}
+	return s.rescore(docMatch)
+}
+
+func (s *FunctionScoreSearcher) Advance(ID string) (*search.DocumentMatch, error) {
+	docMatch, err := s.searcher.Advance(ID)
+	if err != nil || docMatch == nil {
+		return nil, err
+	}
+	return s.rescore(docMatch)
+}
+
+// fieldValues decodes the numeric values of the fields of
+// the document.
+func (s *FunctionScoreSearcher) fieldValues(id string) (map[string]interface{}, error) {
+	rv := make(map[string]interface{}, len(s.fields))
+	if len(s.fields) == 0 {
+		return rv, nil
+	}
+	fieldTerms, err := s.indexReader.DocumentFieldTerms(id)
+	if err != nil {
+		return nil, err
+	}
+	for _, field := range s.fields {
+		var vals []float64
+		for _, term := range fieldTerms[field] {
+			// only consider the values which are shifted 0
+			prefixCoded := numeric_util.PrefixCoded(term)
+			shift, err := prefixCoded.Shift()
+			if err != nil || shift != 0 {
+				continue
+			}
+			i64, err := prefixCoded.Int64()
+			if err != nil {
+				continue
+			}
+			vals = append(vals, numeric_util.Int64ToFloat64(i64))
+		}
+		switch len(vals) {
+		case 0:
+		case 1:
+			rv[field] = vals[0]
+		default:
+			rv[field] = vals
+		}
+	}
+	return rv, nil
+}
+
+func (s *FunctionScoreSearcher) rescore(docMatch *search.DocumentMatch) (*search.DocumentMatch, error) {
+	fields, err := s.fieldValues(docMatch.ID)
+	if err != nil {
+		return nil, err
+	}
+	value := s.fn(docMatch, fields)
+	score := docMatch.Score
+	switch s.mode {
+	case FunctionScoreMultiply:
+		score *= value
+	case FunctionScoreSum:
+		score += value
+	case FunctionScoreReplace:
+		score = value
+	}
+	score *= s.boost
+	if s.explain {
+		children := []*search.Explanation{
+			{
+				Value:   value,
+				Message: "function value",
+			},
+		}
+		if s.mode != FunctionScoreReplace && docMatch.Expl != nil {
+			children = append([]*search.Explanation{docMatch.Expl}, children...)
+		}
+		if s.boost != 1 {
+			children = append(children, &search.Explanation{
+				Value:   s.boost,
+				Message: "boost",
+			})
+		}
+		docMatch.Expl = &search.Explanation{
+			Value:    score,
+			Message:  fmt.Sprintf("function score, %s of:", s.mode),
+			Children: children,
+		}
+	}
+	docMatch.Score = score
+	return docMatch, nil
+}
+
+func (s *FunctionScoreSearcher) Close() {
+	s.searcher.Close()
+}
+
+func (s *FunctionScoreSearcher) Min() int {
+	return s.searcher.Min()
+}
@@ -0,0 +1,111 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package searchers
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/blevesearch/bleve/search"
+)
+
+func TestFunctionScoreSearch(t *testing.T) {
+
+	twoDocIndexReader, err := twoDocIndex.Reader()
+	if err != nil {
+		t.Error(err)
+	}
+	defer twoDocIndexReader.Close()
+
+	// scores a match by its numeric id
+	idValue := func(d *search.DocumentMatch, fields map[string]interface{}) float64 {
+		f, _ := strconv.ParseFloat(d.ID, 64)
+		return f
+	}
+
+	tests := []struct {
+		mode   string
+		boost  float64
+		scores func(termScore, id float64) float64
+	}{
+		{
+			mode:   FunctionScoreMultiply,
+			boost:  1.0,
+			scores: func(termScore, id float64) float64 { return termScore * id },
+		},
+		{
+			mode:   FunctionScoreSum,
+			boost:  1.0,
+			scores: func(termScore, id float64) float64 { return termScore + id },
+		},
+		{
+			mode:   FunctionScoreReplace,
+			boost:  2.0,
+			scores: func(termScore, id float64) float64 { return 2 * id },
+		},
+	}
+
+	for testIndex, test := range tests {
+		termSearcher, err := NewTermSearcher(twoDocIndexReader, "beer", "desc", 1.0, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		termScores := make(map[string]float64)
+		next, err := termSearcher.Next()
+		for err == nil && next != nil {
+			termScores[next.ID] = next.Score
+			next, err = termSearcher.Next()
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		termSearcher.Close()
+
+		termSearcher, err = NewTermSearcher(twoDocIndexReader, "beer", "desc", 1.0, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		searcher, err := NewFunctionScoreSearcher(twoDocIndexReader, termSearcher, nil, idValue, test.mode, test.boost, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		i := 0
+		next, err = searcher.Next()
+		for err == nil && next != nil {
+			id, _ := strconv.ParseFloat(next.ID, 64)
+			expected := test.scores(termScores[next.ID], id)
+			if !scoresCloseEnough(next.Score, expected) {
+				t.Errorf("expected result %s to have score %v got %v for test %d", next.ID, expected, next.Score, testIndex)
+			}
+			if next.Expl == nil || next.Expl.Value != next.Score {
+				t.Errorf("expected explanation of score %v for test %d, got %v", next.Score, testIndex, next.Expl)
+			}
+			next, err = searcher.Next()
+			i++
+		}
+		if err != nil {
+			t.Fatalf("error iterating searcher: %v for test %d", err, testIndex)
+		}
+		if i != len(termScores) {
+			t.Errorf("expected %d results got %d for test %d", len(termScores), i, testIndex)
+		}
+		searcher.Close()
+	}
+
+	termSearcher, err := NewTermSearcher(twoDocIndexReader, "beer", "desc", 1.0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer termSearcher.Close()
+	_, err = NewFunctionScoreSearcher(twoDocIndexReader, termSearcher, nil, idValue, "max", 1.0, false)
+	if err == nil {
+		t.Errorf("expected error for unknown mode")
+	}
+}
@@ -15,36 +15,67 @@ import (
 )
 
 type FuzzySearcher struct {
-	indexReader index.IndexReader
-	term        string
-	prefix      int
-	fuzziness   int
-	field       string
-	explain     bool
-	searcher    *DisjunctionSearcher
+	indexReader    index.IndexReader
+	term           string
+	prefix         int
+	fuzziness      int
+	transpositions bool
+	field          string
+	explain        bool
+	searcher       *DisjunctionSearcher
 }
 
+// NewFuzzySearcher creates a searcher matching the terms
+// in field within fuzziness edits of term.  Candidate terms
+// must share the first prefix characters of term exactly.
 func NewFuzzySearcher(indexReader index.IndexReader, term string, prefix, fuzziness int, field string, boost float64, explain bool) (*FuzzySearcher, error) {
+	return NewFuzzySearcherWithTranspositions(indexReader, term, prefix, fuzziness, false, field, boost, explain)
+}
+
+// NewFuzzySearcherWithTranspositions is like
+// NewFuzzySearcher, but if transpositions is set, swapping
+// two adjacent characters counts as a single edit.
+func NewFuzzySearcherWithTranspositions(indexReader index.IndexReader, term string, prefix, fuzziness int, transpositions bool, field string, boost float64, explain bool) (*FuzzySearcher, error) {
 	prefixTerm := ""
-	for i, r := range term {
-		if i < prefix {
-			prefixTerm += string(r)
+	n := 0
+	for _, r := range term {
+		if n >= prefix {
+			break
 		}
+		prefixTerm += string(r)
+		n++
+	}
+
+	distanceMax := search.LevenshteinDistanceMax
+	if transpositions {
+		distanceMax = search.DamerauLevenshteinDistanceMax
 	}
 
 	// find the terms with this prefix
 	fieldReader, err := indexReader.FieldReader(field, []byte(prefixTerm), []byte(prefixTerm))
+	if err != nil {
+		return nil, err
+	}
 
-	// enumerate terms and check levenshtein distance
+	// enumerate terms and check edit distance
 	candidateTerms := make([]string, 0)
 	tfd, err := fieldReader.Next()
 	for err == nil && tfd != nil {
-		ld, exceeded := search.LevenshteinDistanceMax(&term, &tfd.Term, fuzziness)
+		ld, exceeded := distanceMax(&term, &tfd.Term, fuzziness)
 		if !exceeded && ld <= fuzziness {
 			candidateTerms = append(candidateTerms, tfd.Term)
+			var full bool
+			full, err = clauseCount(indexReader, len(candidateTerms))
+			if full {
+				break
+			}
 		}
 		tfd, err = fieldReader.Next()
 	}
+	fieldReader.Close()
+	if err != nil {
+		return nil, err
+	}
 
 	// enumerate all the terms in the range
 	qsearchers := make([]search.Searcher, 0, 25)
@@ -64,13 +95,14 @@ func NewFuzzySearcher(indexReader index.IndexReader, term string, prefix, fuzzin
 	}
 
 	return &FuzzySearcher{
-		indexReader: indexReader,
-		term:        term,
-		prefix:      prefix,
-		fuzziness:   fuzziness,
-		field:       field,
-		explain:     explain,
-		searcher:    searcher,
+		indexReader:    indexReader,
+		term:           term,
+		prefix:         prefix,
+		fuzziness:      fuzziness,
+		transpositions: transpositions,
+		field:          field,
+		explain:        explain,
+		searcher:       searcher,
 	}, nil
 }
 func (s *FuzzySearcher) Count() uint64 {
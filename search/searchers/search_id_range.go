@@ -0,0 +1,82 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package searchers
+
+import (
+	"github.com/blevesearch/bleve/search"
+)
+
+// IDRangeSearcher wraps a searcher, only returning the
+// matches with an id from start, included, to end,
+// excluded.  An empty start or end leaves that side
+// unbounded.  The matches before start are skipped with
+// a single Advance.
+type IDRangeSearcher struct {
+	child   search.Searcher
+	start   string
+	end     string
+	started bool
+}
+
+func NewIDRangeSearcher(s search.Searcher, start, end string) *IDRangeSearcher {
+	return &IDRangeSearcher{
+		child: s,
+		start: start,
+		end:   end,
+	}
+}
+
+func (s *IDRangeSearcher) inRange(next *search.DocumentMatch, err error) (*search.DocumentMatch, error) {
+	if err != nil || next == nil {
+		return nil, err
+	}
+	if s.end != "" && next.ID >= s.end {
+		return nil, nil
+	}
+	return next, nil
+}
+
+func (s *IDRangeSearcher) Next() (*search.DocumentMatch, error) {
+	if !s.started && s.start != "" {
+		return s.Advance(s.start)
+	}
+	s.started = true
+	next, err := s.child.Next()
+	return s.inRange(next, err)
+}
+
+func (s *IDRangeSearcher) Advance(ID string) (*search.DocumentMatch, error) {
+	s.started = true
+	if ID < s.start {
+		ID = s.start
+	}
+	next, err := s.child.Advance(ID)
+	return s.inRange(next, err)
+}
+
+func (s *IDRangeSearcher) Close() {
+	s.child.Close()
+}
+
+func (s *IDRangeSearcher) Weight() float64 {
+	return s.child.Weight()
+}
+
+func (s *IDRangeSearcher) SetQueryNorm(n float64) {
+	s.child.SetQueryNorm(n)
+}
+
+func (s *IDRangeSearcher) Count() uint64 {
+	return s.child.Count()
+}
+
+func (s *IDRangeSearcher) Min() int {
+	return s.child.Min()
+}
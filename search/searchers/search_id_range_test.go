@@ -0,0 +1,100 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package searchers
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIDRangeSearcher(t *testing.T) {
+
+	twoDocIndexReader, err := twoDocIndex.Reader()
+	if err != nil {
+		t.Error(err)
+	}
+	defer twoDocIndexReader.Close()
+
+	tests := []struct {
+		start    string
+		end      string
+		expected []string
+	}{
+		{
+			start:    "",
+			end:      "",
+			expected: []string{"1", "2", "3", "4", "5"},
+		},
+		{
+			start:    "2",
+			end:      "4",
+			expected: []string{"2", "3"},
+		},
+		{
+			start:    "",
+			end:      "3",
+			expected: []string{"1", "2"},
+		},
+		{
+			start:    "4",
+			end:      "",
+			expected: []string{"4", "5"},
+		},
+		{
+			start:    "6",
+			end:      "",
+			expected: []string{},
+		},
+	}
+
+	for testIndex, test := range tests {
+		allSearcher, err := NewMatchAllSearcher(twoDocIndexReader, 1.0, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		searcher := NewIDRangeSearcher(allSearcher, test.start, test.end)
+
+		got := []string{}
+		next, err := searcher.Next()
+		for err == nil && next != nil {
+			got = append(got, next.ID)
+			next, err = searcher.Next()
+		}
+		if err != nil {
+			t.Fatalf("error iterating searcher: %v", err)
+		}
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("test %d: expected %v, got %v", testIndex, test.expected, got)
+		}
+		searcher.Close()
+	}
+
+	// advancing before the start moves to the start
+	allSearcher, err := NewMatchAllSearcher(twoDocIndexReader, 1.0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	searcher := NewIDRangeSearcher(allSearcher, "3", "5")
+	defer searcher.Close()
+	next, err := searcher.Advance("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next == nil || next.ID != "3" {
+		t.Errorf("expected advance to 3, got %v", next)
+	}
+	next, err = searcher.Advance("5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next != nil {
+		t.Errorf("expected no match past the end, got %v", next)
+	}
+}
@@ -0,0 +1,155 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package searchers
+
+import (
+	"bytes"
+	"net"
+
+	"github.com/blevesearch/bleve/index"
+	"github.com/blevesearch/bleve/numeric_util"
+	"github.com/blevesearch/bleve/search"
+)
+
+// IPRangeSearcher matches the IP addresses from start to
+// end inclusive, by searching for the fewest prefix coded
+// terms covering the range, as NumericRangeSearcher does.
+type IPRangeSearcher struct {
+	indexReader index.IndexReader
+	field       string
+	explain     bool
+	searcher    *DisjunctionSearcher
+}
+
+func NewIPRangeSearcher(indexReader index.IndexReader, start, end net.IP, field string, boost float64, explain bool) (*IPRangeSearcher, error) {
+	terms := splitIPRange(start.To16(), end.To16())
+	qsearchers := make([]search.Searcher, len(terms))
+	for i, term := range terms {
+		var err error
+		qsearchers[i], err = NewTermSearcher(indexReader, string(term), field, boost, explain)
+		if err != nil {
+			for _, s := range qsearchers[:i] {
+				s.Close()
+			}
+			return nil, err
+		}
+	}
+	searcher, err := NewDisjunctionSearcher(indexReader, qsearchers, 0, explain)
+	if err != nil {
+		return nil, err
+	}
+	return &IPRangeSearcher{
+		indexReader: indexReader,
+		field:       field,
+		explain:     explain,
+		searcher:    searcher,
+	}, nil
+}
+
+// splitIPRange returns the terms covering the addresses
+// from start to end.  Working up from the lowest byte, the
+// partial blocks at each end of the range are covered by
+// terms at that shift, the whole blocks between them are
+// left to the next shift.
+func splitIPRange(start, end net.IP) [][]byte {
+	if start == nil || end == nil || bytes.Compare(start, end) > 0 {
+		return nil
+	}
+	lo := make(net.IP, 16)
+	copy(lo, start)
+	hi := make(net.IP, 16)
+	copy(hi, end)
+
+	var rv [][]byte
+	for shift := uint(0); shift < 128; shift += numeric_util.IPPrecisionStep {
+		last := 15 - int(shift/8)
+		if last > 0 && lo[last] == 0 && hi[last] == 0xff {
+			continue
+		}
+		if last == 0 || bytes.Equal(lo[:last], hi[:last]) {
+			rv = appendIPTerms(rv, lo, hi[last], last, shift)
+			break
+		}
+		if lo[last] != 0 {
+			rv = appendIPTerms(rv, lo, 0xff, last, shift)
+			incrementIP(lo[:last])
+		}
+		if hi[last] != 0xff {
+			hiBlock := make(net.IP, 16)
+			copy(hiBlock, hi)
+			hiBlock[last] = 0
+			rv = appendIPTerms(rv, hiBlock, hi[last], last, shift)
+			decrementIP(hi[:last])
+		}
+		if bytes.Compare(lo[:last], hi[:last]) > 0 {
+			break
+		}
+	}
+	return rv
+}
+
+// appendIPTerms appends the terms at shift of the addresses
+// sharing the bytes of from before last, with byte last
+// from that of from to through
+func appendIPTerms(terms [][]byte, from net.IP, through byte, last int, shift uint) [][]byte {
+	ip := make(net.IP, 16)
+	copy(ip, from[:last])
+	for b := int(from[last]); b <= int(through); b++ {
+		ip[last] = byte(b)
+		terms = append(terms, numeric_util.MustNewPrefixCodedIP(ip, shift))
+	}
+	return terms
+}
+
+func incrementIP(ip []byte) {
+	for i := len(ip) - 1; i >= 0; i-- {
+		ip[i]++
+		if ip[i] != 0 {
+			break
+		}
+	}
+}
+
+func decrementIP(ip []byte) {
+	for i := len(ip) - 1; i >= 0; i-- {
+		ip[i]--
+		if ip[i] != 0xff {
+			break
+		}
+	}
+}
+
+func (s *IPRangeSearcher) Count() uint64 {
+	return s.searcher.Count()
+}
+
+func (s *IPRangeSearcher) Weight() float64 {
+	return s.searcher.Weight()
+}
+
+func (s *IPRangeSearcher) SetQueryNorm(qnorm float64) {
+	s.searcher.SetQueryNorm(qnorm)
+}
+
+func (s *IPRangeSearcher) Next() (*search.DocumentMatch, error) {
+	return s.searcher.Next()
+}
+
+func (s *IPRangeSearcher) Advance(ID string) (*search.DocumentMatch, error) {
+	return s.searcher.Advance(ID)
+}
+
+func (s *IPRangeSearcher) Close() {
+	s.searcher.Close()
+}
+
+func (s *IPRangeSearcher) Min() int {
+	return 0
+}
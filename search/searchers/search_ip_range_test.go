@@ -0,0 +1,90 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package searchers
+
+import (
+	"net"
+	"testing"
+
+	"github.com/blevesearch/bleve/numeric_util"
+)
+
+func TestSplitIPRange(t *testing.T) {
+	tests := []struct {
+		start string
+		end   string
+		terms int
+	}{
+		{
+			start: "10.0.0.7",
+			end:   "10.0.0.7",
+			terms: 1,
+		},
+		{
+			start: "10.0.0.0",
+			end:   "10.0.0.255",
+			terms: 1,
+		},
+		{
+			start: "10.0.0.250",
+			end:   "10.0.3.5",
+			// 250-255, 10.0.1 and 10.0.2, 0-5
+			terms: 6 + 2 + 6,
+		},
+		{
+			start: "2001:db8::",
+			end:   "2001:db8:0:ffff:ffff:ffff:ffff:ffff",
+			terms: 1,
+		},
+		{
+			start: "::",
+			end:   "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff",
+			terms: 256,
+		},
+		{
+			start: "10.0.0.9",
+			end:   "10.0.0.1",
+			terms: 0,
+		},
+	}
+
+	for _, test := range tests {
+		terms := splitIPRange(net.ParseIP(test.start).To16(), net.ParseIP(test.end).To16())
+		if len(terms) != test.terms {
+			t.Errorf("%s-%s: expected %d terms, got %d", test.start, test.end, test.terms, len(terms))
+		}
+	}
+}
+
+func TestSplitIPRangeCovers(t *testing.T) {
+	start := net.ParseIP("10.0.0.250").To16()
+	end := net.ParseIP("10.0.3.5").To16()
+	terms := make(map[string]bool)
+	for _, term := range splitIPRange(start, end) {
+		terms[string(term)] = true
+	}
+
+	ip := net.ParseIP("10.0.0.240").To16()
+	for i := 0; i < 800; i++ {
+		matched := 0
+		for shift := uint(0); shift < 128; shift += numeric_util.IPPrecisionStep {
+			if terms[string(numeric_util.MustNewPrefixCodedIP(ip, shift))] {
+				matched++
+			}
+		}
+		in := string(ip) >= string(start) && string(ip) <= string(end)
+		if in && matched != 1 {
+			t.Errorf("expected %s to match one term, matched %d", ip, matched)
+		} else if !in && matched != 0 {
+			t.Errorf("expected %s not to match, matched %d", ip, matched)
+		}
+		incrementIP(ip)
+	}
+}
@@ -0,0 +1,91 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package searchers
+
+import (
+	"github.com/blevesearch/bleve/search"
+)
+
+// MatchFilterSearcher returns the matches of a searcher
+// which the filter searcher matches too.  The filter only
+// decides which documents match: the matches keep the
+// score of the searcher, and the filter takes no part in
+// the query norm, so it should be built without explain.
+type MatchFilterSearcher struct {
+	searcher   search.Searcher
+	filter     search.Searcher
+	currFilter *search.DocumentMatch
+	filterDone bool
+}
+
+func NewMatchFilterSearcher(searcher, filter search.Searcher) *MatchFilterSearcher {
+	return &MatchFilterSearcher{
+		searcher: searcher,
+		filter:   filter,
+	}
+}
+
+func (s *MatchFilterSearcher) Next() (*search.DocumentMatch, error) {
+	next, err := s.searcher.Next()
+	return s.nextFiltered(next, err)
+}
+
+func (s *MatchFilterSearcher) Advance(ID string) (*search.DocumentMatch, error) {
+	adv, err := s.searcher.Advance(ID)
+	return s.nextFiltered(adv, err)
+}
+
+// nextFiltered returns match if the filter matches it, or
+// else the next match of the searcher the filter matches.
+// Both searchers only move forward, each advancing to the
+// document the other is on.
+func (s *MatchFilterSearcher) nextFiltered(match *search.DocumentMatch, err error) (*search.DocumentMatch, error) {
+	for match != nil && err == nil {
+		if s.filterDone {
+			return nil, nil
+		}
+		if s.currFilter == nil || s.currFilter.ID < match.ID {
+			s.currFilter, err = s.filter.Advance(match.ID)
+			if err != nil {
+				return nil, err
+			}
+			if s.currFilter == nil {
+				s.filterDone = true
+				return nil, nil
+			}
+		}
+		if s.currFilter.ID == match.ID {
+			return match, nil
+		}
+		match, err = s.searcher.Advance(s.currFilter.ID)
+	}
+	return nil, err
+}
+
+func (s *MatchFilterSearcher) Close() {
+	s.searcher.Close()
+	s.filter.Close()
+}
+
+func (s *MatchFilterSearcher) Weight() float64 {
+	return s.searcher.Weight()
+}
+
+func (s *MatchFilterSearcher) SetQueryNorm(n float64) {
+	s.searcher.SetQueryNorm(n)
+}
+
+func (s *MatchFilterSearcher) Count() uint64 {
+	return s.searcher.Count()
+}
+
+func (s *MatchFilterSearcher) Min() int {
+	return s.searcher.Min()
+}
@@ -0,0 +1,67 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package searchers
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/blevesearch/bleve/search"
+)
+
+func TestMatchFilterSearcher(t *testing.T) {
+	matches := func(ids ...string) search.DocumentMatchCollection {
+		rv := make(search.DocumentMatchCollection, len(ids))
+		for n, id := range ids {
+			rv[n] = &search.DocumentMatch{ID: id, Score: float64(n + 1)}
+		}
+		return rv
+	}
+
+	searcher := NewMatchFilterSearcher(
+		&sliceSearcher{matches: matches("a", "b", "d", "e", "g", "h")},
+		&sliceSearcher{matches: matches("b", "c", "e", "f", "g")})
+	got := []string{}
+	scores := []float64{}
+	next, err := searcher.Next()
+	for err == nil && next != nil {
+		got = append(got, next.ID)
+		scores = append(scores, next.Score)
+		next, err = searcher.Next()
+	}
+	if err != nil {
+		t.Fatalf("error iterating searcher: %v", err)
+	}
+	if !reflect.DeepEqual(got, []string{"b", "e", "g"}) {
+		t.Errorf("expected [b e g], got %v", got)
+	}
+	// the scores are those of the searcher, not the filter
+	if !reflect.DeepEqual(scores, []float64{2, 4, 5}) {
+		t.Errorf("expected scores [2 4 5], got %v", scores)
+	}
+
+	searcher = NewMatchFilterSearcher(
+		&sliceSearcher{matches: matches("a", "b", "d", "e", "g", "h")},
+		&sliceSearcher{matches: matches("b", "c", "e", "f", "g")})
+	adv, err := searcher.Advance("c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if adv == nil || adv.ID != "e" {
+		t.Errorf("expected to advance to e, got %v", adv)
+	}
+	adv, err = searcher.Advance("h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if adv != nil {
+		t.Errorf("expected no match from h, got %v", adv)
+	}
+}
@@ -0,0 +1,72 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package searchers
+
+import (
+	"github.com/blevesearch/bleve/search"
+)
+
+// MinScoreSearcher wraps a searcher, only returning the
+// matches scoring at least minScore.  Matches arrive by
+// id, with scores in no particular order, so the matches
+// below minScore are skipped and the search goes on.
+type MinScoreSearcher struct {
+	child    search.Searcher
+	minScore float64
+}
+
+func NewMinScoreSearcher(s search.Searcher, minScore float64) *MinScoreSearcher {
+	return &MinScoreSearcher{
+		child:    s,
+		minScore: minScore,
+	}
+}
+
+func (s *MinScoreSearcher) Next() (*search.DocumentMatch, error) {
+	next, err := s.child.Next()
+	return s.check(next, err)
+}
+
+func (s *MinScoreSearcher) Advance(ID string) (*search.DocumentMatch, error) {
+	adv, err := s.child.Advance(ID)
+	return s.check(adv, err)
+}
+
+// check returns match if it scores enough, or else the
+// next match which does.
+func (s *MinScoreSearcher) check(match *search.DocumentMatch, err error) (*search.DocumentMatch, error) {
+	for match != nil && err == nil {
+		if match.Score >= s.minScore {
+			return match, nil
+		}
+		match, err = s.child.Next()
+	}
+	return nil, err
+}
+
+func (s *MinScoreSearcher) Close() {
+	s.child.Close()
+}
+
+func (s *MinScoreSearcher) Weight() float64 {
+	return s.child.Weight()
+}
+
+func (s *MinScoreSearcher) SetQueryNorm(n float64) {
+	s.child.SetQueryNorm(n)
+}
+
+func (s *MinScoreSearcher) Count() uint64 {
+	return s.child.Count()
+}
+
+func (s *MinScoreSearcher) Min() int {
+	return s.child.Min()
+}
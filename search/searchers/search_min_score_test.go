@@ -0,0 +1,89 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package searchers
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/blevesearch/bleve/search"
+)
+
+// sliceSearcher returns its matches in order.
+type sliceSearcher struct {
+	matches search.DocumentMatchCollection
+}
+
+func (s *sliceSearcher) Next() (*search.DocumentMatch, error) {
+	if len(s.matches) == 0 {
+		return nil, nil
+	}
+	rv := s.matches[0]
+	s.matches = s.matches[1:]
+	return rv, nil
+}
+
+func (s *sliceSearcher) Advance(ID string) (*search.DocumentMatch, error) {
+	for len(s.matches) > 0 && s.matches[0].ID < ID {
+		s.matches = s.matches[1:]
+	}
+	return s.Next()
+}
+
+func (s *sliceSearcher) Close()               {}
+func (s *sliceSearcher) Weight() float64      { return 0 }
+func (s *sliceSearcher) SetQueryNorm(float64) {}
+func (s *sliceSearcher) Count() uint64        { return uint64(len(s.matches)) }
+func (s *sliceSearcher) Min() int             { return 0 }
+
+func TestMinScoreSearcher(t *testing.T) {
+	scores := map[string]float64{"a": 0.5, "b": 3, "c": 1, "d": 2, "e": 0.1}
+	matches := func(ids ...string) search.DocumentMatchCollection {
+		rv := make(search.DocumentMatchCollection, len(ids))
+		for n, id := range ids {
+			rv[n] = &search.DocumentMatch{ID: id, Score: scores[id]}
+		}
+		return rv
+	}
+
+	// the matches arrive by id, those below the threshold
+	// are skipped
+	searcher := NewMinScoreSearcher(&sliceSearcher{matches: matches("a", "b", "c", "d", "e")}, 1)
+	got := []string{}
+	next, err := searcher.Next()
+	for err == nil && next != nil {
+		got = append(got, next.ID)
+		next, err = searcher.Next()
+	}
+	if err != nil {
+		t.Fatalf("error iterating searcher: %v", err)
+	}
+	expected := []string{"b", "c", "d"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+
+	// advancing skips to the next match scoring enough
+	searcher = NewMinScoreSearcher(&sliceSearcher{matches: matches("a", "b", "c", "d", "e")}, 2.5)
+	adv, err := searcher.Advance("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if adv == nil || adv.ID != "b" {
+		t.Errorf("expected to advance to b, got %v", adv)
+	}
+	adv, err = searcher.Advance("c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if adv != nil {
+		t.Errorf("expected no match scoring 2.5 after c, got %v", adv)
+	}
+}
@@ -0,0 +1,302 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package searchers
+
+import (
+	"encoding/binary"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/blevesearch/bleve/index"
+	"github.com/blevesearch/bleve/search"
+	"github.com/blevesearch/bleve/search/scorers"
+)
+
+// NestedSearcher matches documents where all of the
+// searchers match within the same element of the nested
+// objects at path.  An element is identified by the
+// leading array positions of the locations in fields
+// below path, one for each array enclosing it in the
+// document, as recorded in index.ArrayPathsField, so the
+// searchers must return locations.
+type NestedSearcher struct {
+	initialized bool
+	indexReader index.IndexReader
+	searchers   OrderedSearcherList
+	prefix      string
+	explain     bool
+	queryNorm   float64
+	currs       []*search.DocumentMatch
+	currentID   string
+	scorer      *scorers.ConjunctionQueryScorer
+}
+
+func NewNestedSearcher(indexReader index.IndexReader, qsearchers []search.Searcher, path string, explain bool) (*NestedSearcher, error) {
+	// build the downstream searchers
+	searchers := make(OrderedSearcherList, len(qsearchers))
+	for i, searcher := range qsearchers {
+		searchers[i] = searcher
+	}
+	// sort the searchers
+	sort.Sort(searchers)
+	// build our searcher
+	rv := NestedSearcher{
+		indexReader: indexReader,
+		explain:     explain,
+		searchers:   searchers,
+		prefix:      path + ".",
+		currs:       make([]*search.DocumentMatch, len(searchers)),
+		scorer:      scorers.NewConjunctionQueryScorer(explain),
+	}
+	rv.computeQueryNorm()
+	return &rv, nil
+}
+
+func (s *NestedSearcher) computeQueryNorm() {
+	// first calculate sum of squared weights
+	sumOfSquaredWeights := 0.0
+	for _, searcher := range s.searchers {
+		sumOfSquaredWeights += searcher.Weight()
+	}
+	// now compute query norm from this
+	s.queryNorm = 1.0 / math.Sqrt(sumOfSquaredWeights)
+	// finally tell all the downstream searchers the norm
+	for _, searcher := range s.searchers {
+		searcher.SetQueryNorm(s.queryNorm)
+	}
+}
+
+func (s *NestedSearcher) initSearchers() error {
+	var err error
+	// get all searchers pointing at their first match
+	for i, searcher := range s.searchers {
+		s.currs[i], err = searcher.Next()
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(s.currs) > 0 {
+		if s.currs[0] != nil {
+			s.currentID = s.currs[0].ID
+		} else {
+			s.currentID = ""
+		}
+	}
+
+	s.initialized = true
+	return nil
+}
+
+func (s *NestedSearcher) Weight() float64 {
+	var rv float64
+	for _, searcher := range s.searchers {
+		rv += searcher.Weight()
+	}
+	return rv
+}
+
+func (s *NestedSearcher) SetQueryNorm(qnorm float64) {
+	for _, searcher := range s.searchers {
+		searcher.SetQueryNorm(qnorm)
+	}
+}
+
+func (s *NestedSearcher) Next() (*search.DocumentMatch, error) {
+	if !s.initialized {
+		err := s.initSearchers()
+		if err != nil {
+			return nil, err
+		}
+	}
+	var rv *search.DocumentMatch
+	var err error
+OUTER:
+	for s.currentID != "" {
+		for i, searcher := range s.searchers {
+			if s.currs[i] != nil && s.currs[i].ID != s.currentID {
+				// this reader doesn't have the currentID, try to advance
+				s.currs[i], err = searcher.Advance(s.currentID)
+				if err != nil {
+					return nil, err
+				}
+				if s.currs[i] == nil {
+					s.currentID = ""
+					continue OUTER
+				}
+				if s.currs[i].ID != s.currentID {
+					// we just advanced, so it doesn't match, it must be greater
+					// no need to call next
+					s.currentID = s.currs[i].ID
+					continue OUTER
+				}
+			} else if s.currs[i] == nil {
+				s.currentID = ""
+				continue OUTER
+			}
+		}
+		// if we get here, a doc matched all readers, it is a
+		// match if they all matched the same element
+		depth, err := s.depth(s.currentID)
+		if err != nil {
+			return nil, err
+		}
+		elements := s.commonElements(depth)
+		if len(elements) > 0 {
+			rv = s.scorer.Score(s.currs)
+			rv.Locations = s.elementLocations(rv.Locations, depth, elements)
+		}
+
+		// prepare for next entry
+		s.currs[0], err = s.searchers[0].Next()
+		if err != nil {
+			return nil, err
+		}
+		if s.currs[0] == nil {
+			s.currentID = ""
+		} else {
+			s.currentID = s.currs[0].ID
+		}
+		if rv != nil {
+			// don't continue now, wait for the next call to Next()
+			break
+		}
+	}
+	return rv, nil
+}
+
+// depth returns the number of arrays of the document
+// enclosing the nested elements, the path itself or the
+// objects above it
+func (s *NestedSearcher) depth(id string) (int, error) {
+	arrayPaths, err := s.indexReader.DocumentField(id, index.ArrayPathsField)
+	if err != nil {
+		return 0, err
+	}
+	rv := 0
+	for _, arrayPath := range arrayPaths {
+		if strings.HasPrefix(s.prefix, string(arrayPath.Value())+".") {
+			rv++
+		}
+	}
+	return rv, nil
+}
+
+// element returns the key of the nested element holding
+// the location
+func (s *NestedSearcher) element(location *search.Location, depth int) string {
+	arrayPositions := location.ArrayPositions
+	if len(arrayPositions) > depth {
+		arrayPositions = arrayPositions[:depth]
+	}
+	buf := make([]byte, binary.MaxVarintLen64*len(arrayPositions))
+	n := 0
+	for _, arrayPosition := range arrayPositions {
+		n += binary.PutUvarint(buf[n:], arrayPosition)
+	}
+	return string(buf[:n])
+}
+
+// elements returns the keys of the nested elements the
+// match has locations in
+func (s *NestedSearcher) elements(dm *search.DocumentMatch, depth int) map[string]bool {
+	rv := make(map[string]bool)
+	for field, tlm := range dm.Locations {
+		if !strings.HasPrefix(field, s.prefix) {
+			continue
+		}
+		for _, locations := range tlm {
+			for _, location := range locations {
+				rv[s.element(location, depth)] = true
+			}
+		}
+	}
+	return rv
+}
+
+// commonElements returns the keys of the nested elements
+// matched by every searcher
+func (s *NestedSearcher) commonElements(depth int) map[string]bool {
+	rv := s.elements(s.currs[0], depth)
+	for _, curr := range s.currs[1:] {
+		if len(rv) == 0 {
+			break
+		}
+		elements := s.elements(curr, depth)
+		for element := range rv {
+			if !elements[element] {
+				delete(rv, element)
+			}
+		}
+	}
+	return rv
+}
+
+// elementLocations returns the locations in the matching
+// elements
+func (s *NestedSearcher) elementLocations(ftlm search.FieldTermLocationMap, depth int, elements map[string]bool) search.FieldTermLocationMap {
+	rv := make(search.FieldTermLocationMap)
+	for field, tlm := range ftlm {
+		if !strings.HasPrefix(field, s.prefix) {
+			continue
+		}
+		for term, locations := range tlm {
+			for _, location := range locations {
+				if elements[s.element(location, depth)] {
+					rvtlm := rv[field]
+					if rvtlm == nil {
+						rvtlm = make(search.TermLocationMap)
+						rv[field] = rvtlm
+					}
+					rvtlm.AddLocation(term, location)
+				}
+			}
+		}
+	}
+	return rv
+}
+
+func (s *NestedSearcher) Advance(ID string) (*search.DocumentMatch, error) {
+	if !s.initialized {
+		err := s.initSearchers()
+		if err != nil {
+			return nil, err
+		}
+	}
+	var err error
+	for i, searcher := range s.searchers {
+		s.currs[i], err = searcher.Advance(ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	s.currentID = ID
+	return s.Next()
+}
+
+func (s *NestedSearcher) Count() uint64 {
+	// for now return a worst case
+	var sum uint64
+	for _, searcher := range s.searchers {
+		sum += searcher.Count()
+	}
+	return sum
+}
+
+func (s *NestedSearcher) Close() {
+	for _, searcher := range s.searchers {
+		searcher.Close()
+	}
+}
+
+func (s *NestedSearcher) Min() int {
+	return 0
+}
@@ -28,15 +28,6 @@ type NumericRangeSearcher struct {
 }
 
 func NewNumericRangeSearcher(indexReader index.IndexReader, min *float64, max *float64, inclusiveMin, inclusiveMax *bool, field string, boost float64, explain bool) (*NumericRangeSearcher, error) {
-	// account for unbounded edges
-	if min == nil {
-		negInf := math.Inf(-1)
-		min = &negInf
-	}
-	if max == nil {
-		Inf := math.Inf(1)
-		max = &Inf
-	}
 	if inclusiveMin == nil {
 		defaultInclusiveMin := true
 		inclusiveMin = &defaultInclusiveMin
@@ -45,14 +36,14 @@ func NewNumericRangeSearcher(indexReader index.IndexReader, min *float64, max *f
 		defaultInclusiveMax := false
 		inclusiveMax = &defaultInclusiveMax
 	}
-	// find all the ranges
-	minInt64 := numeric_util.Float64ToInt64(*min)
-	if !*inclusiveMin && minInt64 != math.MaxInt64 {
-		minInt64++
+	// find all the ranges, unbounded edges cover every value
+	minInt64 := int64(math.MinInt64)
+	if min != nil {
+		minInt64 = lowerBoundInt64(*min, *inclusiveMin)
 	}
-	maxInt64 := numeric_util.Float64ToInt64(*max)
-	if !*inclusiveMax && maxInt64 != math.MinInt64 {
-		maxInt64--
+	maxInt64 := int64(math.MaxInt64)
+	if max != nil {
+		maxInt64 = upperBoundInt64(*max, *inclusiveMax)
 	}
 	// FIXME hard-coded precision, should match field declaration
 	termRanges := splitInt64Range(minInt64, maxInt64, 4)
@@ -81,6 +72,40 @@ func NewNumericRangeSearcher(indexReader index.IndexReader, min *float64, max *f
 	}, nil
 }
 
+// lowerBoundInt64 returns the smallest sortable int64 of the
+// values above min, or at min when inclusive.  Negative and
+// positive zero are distinct sortable values but the same
+// bound, so both are included or excluded together.
+func lowerBoundInt64(min float64, inclusive bool) int64 {
+	if min == 0 {
+		if inclusive {
+			return numeric_util.Float64ToInt64(math.Copysign(0, -1))
+		}
+		return numeric_util.Float64ToInt64(0) + 1
+	}
+	rv := numeric_util.Float64ToInt64(min)
+	if !inclusive && rv != math.MaxInt64 {
+		rv++
+	}
+	return rv
+}
+
+// upperBoundInt64 returns the largest sortable int64 of the
+// values below max, or at max when inclusive.
+func upperBoundInt64(max float64, inclusive bool) int64 {
+	if max == 0 {
+		if inclusive {
+			return numeric_util.Float64ToInt64(0)
+		}
+		return numeric_util.Float64ToInt64(math.Copysign(0, -1)) - 1
+	}
+	rv := numeric_util.Float64ToInt64(max)
+	if !inclusive && rv != math.MinInt64 {
+		rv--
+	}
+	return rv
+}
+
 func (s *NumericRangeSearcher) Count() uint64 {
 	return s.searcher.Count()
 }
@@ -10,6 +10,7 @@
 package searchers
 
 import (
+	"math"
 	"reflect"
 	"testing"
 
@@ -53,3 +54,40 @@ func TestIncrementBytes(t *testing.T) {
 		}
 	}
 }
+
+func TestNumericRangeBounds(t *testing.T) {
+	negZero := math.Copysign(0, -1)
+	tests := []struct {
+		value     float64
+		bound     float64
+		inclusive bool
+		lower     bool
+		in        bool
+	}{
+		{value: 2, bound: 2, inclusive: true, lower: true, in: true},
+		{value: 2, bound: 2, inclusive: false, lower: true, in: false},
+		{value: 2, bound: 2, inclusive: true, lower: false, in: true},
+		{value: 2, bound: 2, inclusive: false, lower: false, in: false},
+		{value: 0, bound: negZero, inclusive: true, lower: true, in: true},
+		{value: negZero, bound: 0, inclusive: true, lower: true, in: true},
+		{value: negZero, bound: 0, inclusive: false, lower: true, in: false},
+		{value: 0, bound: negZero, inclusive: false, lower: true, in: false},
+		{value: negZero, bound: 0, inclusive: true, lower: false, in: true},
+		{value: 0, bound: negZero, inclusive: true, lower: false, in: true},
+		{value: negZero, bound: 0, inclusive: false, lower: false, in: false},
+		{value: 0, bound: negZero, inclusive: false, lower: false, in: false},
+	}
+
+	for i, test := range tests {
+		value := numeric_util.Float64ToInt64(test.value)
+		var in bool
+		if test.lower {
+			in = value >= lowerBoundInt64(test.bound, test.inclusive)
+		} else {
+			in = value <= upperBoundInt64(test.bound, test.inclusive)
+		}
+		if in != test.in {
+			t.Errorf("test %d: expected in range %t, got %t", i, test.in, in)
+		}
+	}
+}
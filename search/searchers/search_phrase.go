@@ -10,6 +10,7 @@
 package searchers
 
 import (
+	"fmt"
 	"math"
 
 	"github.com/blevesearch/bleve/index"
@@ -26,11 +27,25 @@ type PhraseSearcher struct {
 	terms        []string
 }
 
+// NewPhraseSearcher creates a searcher matching the terms
+// at consecutive positions in the documents matched by
+// mustSearcher.  Empty terms hold the place of terms
+// removed by the analyzer.
 func NewPhraseSearcher(indexReader index.IndexReader, mustSearcher *ConjunctionSearcher, terms []string) (*PhraseSearcher, error) {
+	return NewSloppyPhraseSearcher(indexReader, mustSearcher, terms, 0)
+}
+
+// NewSloppyPhraseSearcher is like NewPhraseSearcher, but
+// lets the terms be moved by up to slop positions in
+// total, where swapping two adjacent terms counts as 2,
+// and scales the score by 1/(1+distance) of the closest
+// match.
+func NewSloppyPhraseSearcher(indexReader index.IndexReader, mustSearcher *ConjunctionSearcher, terms []string, slop int) (*PhraseSearcher, error) {
 	// build our searcher
 	rv := PhraseSearcher{
 		indexReader:  indexReader,
 		mustSearcher: mustSearcher,
+		slop:         slop,
 		terms:        terms,
 	}
 	rv.computeQueryNorm()
@@ -102,39 +117,26 @@ func (s *PhraseSearcher) Next() (*search.DocumentMatch, error) {
 	for s.currMust != nil {
 		rvftlm := make(search.FieldTermLocationMap, 0)
 		freq := 0
+		bestDistance := 0.0
 		firstTerm := s.terms[0]
 		for field, termLocMap := range s.currMust.Locations {
 			rvtlm := make(search.TermLocationMap, 0)
 			locations, ok := termLocMap[firstTerm]
 			if ok {
-			OUTER:
 				for _, location := range locations {
-					crvtlm := make(search.TermLocationMap, 0)
-				INNER:
-					for i := 0; i < len(s.terms); i++ {
-						nextTerm := s.terms[i]
-						if nextTerm != "" {
-							// look through all these term locations
-							// to try and find the correct offsets
-							nextLocations, ok := termLocMap[nextTerm]
-							if ok {
-								for _, nextLocation := range nextLocations {
-									if nextLocation.Pos == location.Pos+float64(i) {
-										// found a location match for this term
-										crvtlm.AddLocation(nextTerm, nextLocation)
-										continue INNER
-									}
-								}
-								// if we got here we didn't find a location match for this term
-								continue OUTER
-							} else {
-								continue OUTER
-							}
-						}
+					matched, distance := s.phraseMatch(termLocMap, location)
+					if matched == nil {
+						continue
+					}
+					if freq == 0 || distance < bestDistance {
+						bestDistance = distance
 					}
-					// if we got here all the terms matched
 					freq++
-					search.MergeTermLocationMaps(rvtlm, crvtlm)
+					for i, term := range s.terms {
+						if term != "" {
+							rvtlm.AddLocation(term, matched[i])
+						}
+					}
 					rvftlm[field] = rvtlm
 				}
 			}
@@ -144,6 +146,9 @@ func (s *PhraseSearcher) Next() (*search.DocumentMatch, error) {
 			// return match
 			rv = s.currMust
 			rv.Locations = rvftlm
+			if bestDistance > 0 {
+				s.applyDistance(rv, bestDistance)
+			}
 			s.advanceNextMust()
 			return rv, nil
 		}
@@ -154,6 +159,81 @@ func (s *PhraseSearcher) Next() (*search.DocumentMatch, error) {
 	return nil, nil
 }
 
+// phraseMatch finds the locations of the terms forming the
+// closest phrase with the first term at location.  It
+// returns them by term index, along with how far they are
+// from their positions in the phrase, or nil if there is
+// no phrase within the slop.  A location is used for at
+// most one term, but different terms may share a position.
+func (s *PhraseSearcher) phraseMatch(termLocMap search.TermLocationMap, location *search.Location) ([]*search.Location, float64) {
+	var rv []*search.Location
+	var rvDistance float64
+	chosen := make([]*search.Location, len(s.terms))
+	chosen[0] = location
+	// each term is placed by its offset, the position the
+	// first term would have for it to be in place, and the
+	// distance is the spread of the offsets
+	var place func(i int, minOffset, maxOffset float64)
+	place = func(i int, minOffset, maxOffset float64) {
+		if i == len(s.terms) {
+			distance := maxOffset - minOffset
+			if rv == nil || distance < rvDistance {
+				rv = make([]*search.Location, len(chosen))
+				copy(rv, chosen)
+				rvDistance = distance
+			}
+			return
+		}
+		term := s.terms[i]
+		if term == "" {
+			place(i+1, minOffset, maxOffset)
+			return
+		}
+	NEXT:
+		for _, next := range termLocMap[term] {
+			offset := next.Pos - float64(i)
+			nextMin := math.Min(minOffset, offset)
+			nextMax := math.Max(maxOffset, offset)
+			if nextMax-nextMin > float64(s.slop) || (rv != nil && nextMax-nextMin >= rvDistance) {
+				continue
+			}
+			if !next.SameArrayElement(location) {
+				continue
+			}
+			for _, prev := range chosen[:i] {
+				if prev == next {
+					continue NEXT
+				}
+			}
+			chosen[i] = next
+			place(i+1, nextMin, nextMax)
+		}
+		chosen[i] = nil
+	}
+	place(1, location.Pos, location.Pos)
+	return rv, rvDistance
+}
+
+// applyDistance scales the score of a sloppy match by
+// how far the closest phrase was from exact
+func (s *PhraseSearcher) applyDistance(dm *search.DocumentMatch, distance float64) {
+	factor := 1.0 / (1.0 + distance)
+	dm.Score *= factor
+	if dm.Expl != nil {
+		dm.Expl = &search.Explanation{
+			Value:   dm.Score,
+			Message: "product of:",
+			Children: []*search.Explanation{
+				dm.Expl,
+				&search.Explanation{
+					Value:   factor,
+					Message: fmt.Sprintf("sloppy phrase factor, distance %v", distance),
+				},
+			},
+		}
+	}
+}
+
 func (s *PhraseSearcher) Advance(ID string) (*search.DocumentMatch, error) {
 	if !s.initialized {
 		err := s.initSearchers()
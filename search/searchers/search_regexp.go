@@ -0,0 +1,114 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package searchers
+
+import (
+	"regexp"
+
+	"github.com/blevesearch/bleve/index"
+	"github.com/blevesearch/bleve/search"
+)
+
+type RegexpSearcher struct {
+	indexReader index.IndexReader
+	pattern     *regexp.Regexp
+	field       string
+	explain     bool
+	searcher    *DisjunctionSearcher
+}
+
+// NewRegexpSearcher finds documents containing terms
+// in the field which the pattern matches in their
+// entirety.  The pattern should be anchored, as in
+// ^(?:expr)$, the searcher does not anchor it.
+func NewRegexpSearcher(indexReader index.IndexReader, pattern *regexp.Regexp, field string, boost float64, explain bool) (*RegexpSearcher, error) {
+	// only scan the terms starting with the literal prefix
+	prefixTerm, complete := pattern.LiteralPrefix()
+
+	var candidateTerms []string
+	if complete {
+		candidateTerms = []string{prefixTerm}
+	} else {
+		fieldReader, err := indexReader.FieldReader(field, []byte(prefixTerm), []byte(prefixTerm))
+		if err != nil {
+			return nil, err
+		}
+
+		// enumerate terms and check the pattern
+		candidateTerms = make([]string, 0)
+		tfd, err := fieldReader.Next()
+		for err == nil && tfd != nil {
+			if pattern.MatchString(tfd.Term) {
+				candidateTerms = append(candidateTerms, tfd.Term)
+				var full bool
+				full, err = clauseCount(indexReader, len(candidateTerms))
+				if full {
+					break
+				}
+			}
+			tfd, err = fieldReader.Next()
+		}
+		fieldReader.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	qsearchers := make([]search.Searcher, 0, len(candidateTerms))
+	for _, cterm := range candidateTerms {
+		qsearcher, err := NewTermSearcher(indexReader, cterm, field, boost, explain)
+		if err != nil {
+			return nil, err
+		}
+		qsearchers = append(qsearchers, qsearcher)
+	}
+
+	// build disjunction searcher of these terms
+	searcher, err := NewDisjunctionSearcher(indexReader, qsearchers, 0, explain)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RegexpSearcher{
+		indexReader: indexReader,
+		pattern:     pattern,
+		field:       field,
+		explain:     explain,
+		searcher:    searcher,
+	}, nil
+}
+
+func (s *RegexpSearcher) Count() uint64 {
+	return s.searcher.Count()
+}
+
+func (s *RegexpSearcher) Weight() float64 {
+	return s.searcher.Weight()
+}
+
+func (s *RegexpSearcher) SetQueryNorm(qnorm float64) {
+	s.searcher.SetQueryNorm(qnorm)
+}
+
+func (s *RegexpSearcher) Next() (*search.DocumentMatch, error) {
+	return s.searcher.Next()
+}
+
+func (s *RegexpSearcher) Advance(ID string) (*search.DocumentMatch, error) {
+	return s.searcher.Advance(ID)
+}
+
+func (s *RegexpSearcher) Close() {
+	s.searcher.Close()
+}
+
+func (s *RegexpSearcher) Min() int {
+	return 0
+}
@@ -24,12 +24,36 @@ type TermSearcher struct {
 	scorer      *scorers.TermQueryScorer
 }
 
+// BM25IndexReader is implemented by index readers whose
+// term matches are scored with BM25 instead of TF-IDF.
+type BM25IndexReader interface {
+	index.IndexReader
+	BM25Params() (k1, b float64)
+}
+
+// FieldBoostIndexReader is implemented by index readers
+// boosting the matches of some fields.
+type FieldBoostIndexReader interface {
+	index.IndexReader
+	FieldBoost(field string) float64
+}
+
 func NewTermSearcher(indexReader index.IndexReader, term string, field string, boost float64, explain bool) (*TermSearcher, error) {
 	reader, err := indexReader.TermFieldReader([]byte(term), field)
 	if err != nil {
 		return nil, err
 	}
-	scorer := scorers.NewTermQueryScorer(term, field, boost, indexReader.DocCount(), reader.Count(), explain)
+	var scorer *scorers.TermQueryScorer
+	if bm25Reader, ok := indexReader.(BM25IndexReader); ok {
+		k1, b := bm25Reader.BM25Params()
+		avgFieldLength := indexReader.FieldStats(field).AverageLength()
+		scorer = scorers.NewBM25TermQueryScorer(term, field, boost, indexReader.DocCount(), reader.Count(), avgFieldLength, k1, b, explain)
+	} else {
+		scorer = scorers.NewTermQueryScorer(term, field, boost, indexReader.DocCount(), reader.Count(), explain)
+	}
+	if boostReader, ok := indexReader.(FieldBoostIndexReader); ok {
+		scorer.SetFieldBoost(boostReader.FieldBoost(field))
+	}
 	return &TermSearcher{
 		indexReader: indexReader,
 		term:        term,
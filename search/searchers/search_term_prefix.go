@@ -25,17 +25,34 @@ type TermPrefixSearcher struct {
 func NewTermPrefixSearcher(indexReader index.IndexReader, prefix string, field string, boost float64, explain bool) (*TermPrefixSearcher, error) {
 	// find the terms with this prefix
 	fieldReader, err := indexReader.FieldReader(field, []byte(prefix), []byte(prefix))
+	if err != nil {
+		return nil, err
+	}
 
 	// enumerate all the terms in the range
-	qsearchers := make([]search.Searcher, 0, 25)
+	candidateTerms := make([]string, 0)
 	tfd, err := fieldReader.Next()
 	for err == nil && tfd != nil {
-		qsearcher, err := NewTermSearcher(indexReader, string(tfd.Term), field, 1.0, explain)
+		candidateTerms = append(candidateTerms, tfd.Term)
+		var full bool
+		full, err = clauseCount(indexReader, len(candidateTerms))
+		if full {
+			break
+		}
+		tfd, err = fieldReader.Next()
+	}
+	fieldReader.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	qsearchers := make([]search.Searcher, 0, len(candidateTerms))
+	for _, cterm := range candidateTerms {
+		qsearcher, err := NewTermSearcher(indexReader, cterm, field, 1.0, explain)
 		if err != nil {
 			return nil, err
 		}
 		qsearchers = append(qsearchers, qsearcher)
-		tfd, err = fieldReader.Next()
 	}
 	// build disjunction searcher of these ranges
 	searcher, err := NewDisjunctionSearcher(indexReader, qsearchers, 0, explain)
@@ -0,0 +1,486 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package search
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/blevesearch/bleve/document"
+	"github.com/blevesearch/bleve/geo"
+	"github.com/blevesearch/bleve/index"
+)
+
+// dateSortLayout formats date values so that they
+// sort lexicographically
+const dateSortLayout = "2006-01-02T15:04:05.000000000Z"
+
+// SearchSort is one level of the order of search results.
+type SearchSort interface {
+	// Value returns the value the match is ordered by,
+	// a float64 or a string, or nil if it has none.
+	// doc is the stored document of the match, it is
+	// only loaded if RequiresDocument is true.
+	Value(d *DocumentMatch, doc *document.Document) interface{}
+	RequiresDocument() bool
+	Descending() bool
+}
+
+// SortOrder orders search results by its first sort,
+// breaking ties with the following ones.  Matches
+// without a value for a sort are placed after those
+// with one, unless the sort is a SortField with
+// Missing set to SortFieldMissingFirst.
+type SortOrder []SearchSort
+
+func (so SortOrder) RequiresDocument() bool {
+	for _, s := range so {
+		if s.RequiresDocument() {
+			return true
+		}
+	}
+	return false
+}
+
+// Compute sets the sort values of the match.
+func (so SortOrder) Compute(indexReader index.IndexReader, d *DocumentMatch) error {
+	var doc *document.Document
+	if so.RequiresDocument() {
+		var err error
+		doc, err = indexReader.Document(d.ID)
+		if err != nil {
+			return err
+		}
+	}
+	d.Sort = make([]interface{}, len(so))
+	for i, s := range so {
+		d.Sort[i] = s.Value(d, doc)
+	}
+	return nil
+}
+
+// Compare compares the computed sort values of
+// two matches, returning a negative number if a
+// sorts before b, 0 if they are equal, and a
+// positive number otherwise.
+func (so SortOrder) Compare(a, b *DocumentMatch) int {
+	for i, s := range so {
+		c := compareSortValues(sortValue(a, i), sortValue(b, i), s.Descending(), missingFirst(s))
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func sortValue(d *DocumentMatch, i int) interface{} {
+	if i < len(d.Sort) {
+		return d.Sort[i]
+	}
+	return nil
+}
+
+func missingFirst(s SearchSort) bool {
+	sf, ok := s.(*SortField)
+	return ok && sf.Missing == SortFieldMissingFirst
+}
+
+func compareSortValues(a, b interface{}, desc, missingFirst bool) int {
+	// missing values go to the same end in either direction
+	if a == nil || b == nil {
+		c := 0
+		switch {
+		case a == nil && b == nil:
+			return 0
+		case a == nil:
+			c = 1
+		default:
+			c = -1
+		}
+		if missingFirst {
+			return -c
+		}
+		return c
+	}
+	c := compareValues(a, b)
+	if desc {
+		return -c
+	}
+	return c
+}
+
+// compareValues orders numbers before strings
+func compareValues(a, b interface{}) int {
+	switch a := a.(type) {
+	case float64:
+		switch b := b.(type) {
+		case float64:
+			switch {
+			case a < b:
+				return -1
+			case a > b:
+				return 1
+			}
+			return 0
+		case string:
+			return -1
+		}
+	case string:
+		switch b := b.(type) {
+		case float64:
+			return 1
+		case string:
+			return strings.Compare(a, b)
+		}
+	}
+	return 0
+}
+
+// SortScore orders matches by score.
+type SortScore struct {
+	Desc bool
+}
+
+func (s *SortScore) Value(d *DocumentMatch, doc *document.Document) interface{} {
+	return d.Score
+}
+
+func (s *SortScore) RequiresDocument() bool {
+	return false
+}
+
+func (s *SortScore) Descending() bool {
+	return s.Desc
+}
+
+func (s *SortScore) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"by":   "score",
+		"desc": s.Desc,
+	})
+}
+
+// SortDocID orders matches by document identifier.
+type SortDocID struct {
+	Desc bool
+}
+
+func (s *SortDocID) Value(d *DocumentMatch, doc *document.Document) interface{} {
+	return d.ID
+}
+
+func (s *SortDocID) RequiresDocument() bool {
+	return false
+}
+
+func (s *SortDocID) Descending() bool {
+	return s.Desc
+}
+
+func (s *SortDocID) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"by":   "id",
+		"desc": s.Desc,
+	})
+}
+
+// Missing modes of a SortField
+const (
+	SortFieldMissingLast  = "last"
+	SortFieldMissingFirst = "first"
+)
+
+// SortField orders matches by the stored values of a
+// field.  Of multiple values the lowest is used when
+// ascending, and the highest when descending.
+//
+// Missing controls matches without a value for the
+// field.  They are placed after all others when it is
+// nil or SortFieldMissingLast, and before all others
+// when it is SortFieldMissingFirst, regardless of Desc.
+// Any other string or number is used as their value.
+type SortField struct {
+	Field   string
+	Desc    bool
+	Missing interface{}
+}
+
+func (s *SortField) Value(d *DocumentMatch, doc *document.Document) interface{} {
+	if doc == nil {
+		return s.missingValue()
+	}
+	var rv interface{}
+	for _, field := range doc.Fields {
+		if field.Name() != s.Field {
+			continue
+		}
+		val := storedSortValue(field)
+		if val == nil {
+			continue
+		}
+		if rv == nil || compareSortValues(val, rv, s.Desc, false) < 0 {
+			rv = val
+		}
+	}
+	if rv == nil {
+		return s.missingValue()
+	}
+	return rv
+}
+
+// missingValue returns the substitute for a missing value,
+// or nil to place the match according to the Missing mode
+func (s *SortField) missingValue() interface{} {
+	switch missing := s.Missing.(type) {
+	case string:
+		if missing == SortFieldMissingFirst || missing == SortFieldMissingLast {
+			return nil
+		}
+		return missing
+	case float64:
+		return missing
+	case float32:
+		return float64(missing)
+	case int:
+		return float64(missing)
+	case int64:
+		return float64(missing)
+	}
+	return nil
+}
+
+func storedSortValue(field document.Field) interface{} {
+	switch field := field.(type) {
+	case *document.TextField:
+		return string(field.Value())
+	case *document.NumericField:
+		n, err := field.Number()
+		if err == nil {
+			return n
+		}
+	case *document.DateTimeField:
+		dt, err := field.DateTime()
+		if err == nil {
+			return dt.UTC().Format(dateSortLayout)
+		}
+	case *document.IPField:
+		// the hex digits of the IPv6 form sort in address order
+		ip, err := field.IP()
+		if err == nil {
+			return hex.EncodeToString(ip.To16())
+		}
+	case *document.BooleanField:
+		// false sorts before true, as "F" does before "T"
+		return string(field.Value())
+	}
+	return nil
+}
+
+func (s *SortField) RequiresDocument() bool {
+	return true
+}
+
+func (s *SortField) Descending() bool {
+	return s.Desc
+}
+
+func (s *SortField) MarshalJSON() ([]byte, error) {
+	rv := map[string]interface{}{
+		"by":    "field",
+		"field": s.Field,
+		"desc":  s.Desc,
+	}
+	if s.Missing != nil {
+		rv["missing"] = s.Missing
+	}
+	return json.Marshal(rv)
+}
+
+// SortGeoDistance orders matches by the distance of the
+// geo point stored in Field, as Field.lat and Field.lon,
+// from Location.  Distances are measured in Unit.
+type SortGeoDistance struct {
+	Field    string
+	Location geo.Point
+	Unit     string
+	Desc     bool
+	unitLen  float64
+}
+
+// NewSortGeoDistance creates a SortGeoDistance, unit
+// is one of m, km or mi, meters when empty.
+func NewSortGeoDistance(field string, lon, lat float64, unit string, desc bool) (*SortGeoDistance, error) {
+	unitLen, err := geo.DistanceUnit(unit)
+	if err != nil {
+		return nil, err
+	}
+	return &SortGeoDistance{
+		Field:    field,
+		Location: geo.Point{Lon: lon, Lat: lat},
+		Unit:     unit,
+		Desc:     desc,
+		unitLen:  unitLen,
+	}, nil
+}
+
+func (s *SortGeoDistance) Value(d *DocumentMatch, doc *document.Document) interface{} {
+	if doc == nil {
+		return nil
+	}
+	point, ok := StoredGeoPoint(doc, s.Field+".lat", s.Field+".lon")
+	if !ok {
+		return nil
+	}
+	unitLen := s.unitLen
+	if unitLen == 0 {
+		// not built by NewSortGeoDistance
+		unitLen, _ = geo.DistanceUnit(s.Unit)
+		if unitLen == 0 {
+			unitLen = 1
+		}
+	}
+	return geo.Haversine(s.Location, point) / unitLen
+}
+
+func (s *SortGeoDistance) RequiresDocument() bool {
+	return true
+}
+
+func (s *SortGeoDistance) Descending() bool {
+	return s.Desc
+}
+
+func (s *SortGeoDistance) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"by":       "geo_distance",
+		"field":    s.Field,
+		"location": s.Location,
+		"unit":     s.Unit,
+		"desc":     s.Desc,
+	})
+}
+
+// StoredGeoPoint reads a geo point from the stored
+// numeric fields latField and lonField of doc.
+func StoredGeoPoint(doc *document.Document, latField, lonField string) (geo.Point, bool) {
+	var rv geo.Point
+	var haveLat, haveLon bool
+	for _, field := range doc.Fields {
+		numericField, ok := field.(*document.NumericField)
+		if !ok {
+			continue
+		}
+		if !haveLat && numericField.Name() == latField {
+			lat, err := numericField.Number()
+			if err == nil {
+				rv.Lat = lat
+				haveLat = true
+			}
+		} else if !haveLon && numericField.Name() == lonField {
+			lon, err := numericField.Number()
+			if err == nil {
+				rv.Lon = lon
+				haveLon = true
+			}
+		}
+	}
+	return rv, haveLat && haveLon
+}
+
+// ParseSortOrderStrings builds a SortOrder from field
+// names, with the special names _score and _id sorting
+// by score and document identifier.  A leading - sorts
+// in descending order.
+func ParseSortOrderStrings(in []string) SortOrder {
+	rv := make(SortOrder, 0, len(in))
+	for _, s := range in {
+		rv = append(rv, parseSortString(s))
+	}
+	return rv
+}
+
+func parseSortString(s string) SearchSort {
+	desc := false
+	if strings.HasPrefix(s, "-") {
+		desc = true
+		s = s[1:]
+	}
+	switch s {
+	case "_score":
+		return &SortScore{Desc: desc}
+	case "_id":
+		return &SortDocID{Desc: desc}
+	}
+	return &SortField{Field: s, Desc: desc}
+}
+
+// ParseSortOrderJSON builds a SortOrder from JSON, each
+// sort being either a string as accepted by
+// ParseSortOrderStrings, or an object naming the kind of
+// sort in "by" (score, id, field or geo_distance).
+func ParseSortOrderJSON(in []json.RawMessage) (SortOrder, error) {
+	rv := make(SortOrder, 0, len(in))
+	for _, raw := range in {
+		var s string
+		err := json.Unmarshal(raw, &s)
+		if err == nil {
+			rv = append(rv, parseSortString(s))
+			continue
+		}
+		ss, err := parseSortJSON(raw)
+		if err != nil {
+			return nil, err
+		}
+		rv = append(rv, ss)
+	}
+	return rv, nil
+}
+
+func parseSortJSON(raw json.RawMessage) (SearchSort, error) {
+	var temp struct {
+		By       string      `json:"by"`
+		Field    string      `json:"field"`
+		Desc     bool        `json:"desc"`
+		Location *geo.Point  `json:"location"`
+		Unit     string      `json:"unit"`
+		Missing  interface{} `json:"missing"`
+	}
+	err := json.Unmarshal(raw, &temp)
+	if err != nil {
+		return nil, err
+	}
+	switch temp.By {
+	case "score":
+		return &SortScore{Desc: temp.Desc}, nil
+	case "id":
+		return &SortDocID{Desc: temp.Desc}, nil
+	case "field":
+		if temp.Field == "" {
+			return nil, fmt.Errorf("field sort must specify field")
+		}
+		switch temp.Missing.(type) {
+		case nil, string, float64:
+		default:
+			return nil, fmt.Errorf("field sort missing must be first, last, a string or a number")
+		}
+		return &SortField{Field: temp.Field, Desc: temp.Desc, Missing: temp.Missing}, nil
+	case "geo_distance":
+		if temp.Field == "" {
+			return nil, fmt.Errorf("geo distance sort must specify field")
+		}
+		if temp.Location == nil {
+			return nil, fmt.Errorf("geo distance sort must specify location")
+		}
+		return NewSortGeoDistance(temp.Field, temp.Location.Lon, temp.Location.Lat, temp.Unit, temp.Desc)
+	}
+	return nil, fmt.Errorf("unknown sort by '%s'", temp.By)
+}
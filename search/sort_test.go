@@ -0,0 +1,155 @@
+//  Copyright (c) 2015 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package search
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/blevesearch/bleve/document"
+	"github.com/blevesearch/bleve/geo"
+)
+
+func TestSortOrderCompare(t *testing.T) {
+	tests := []struct {
+		order SortOrder
+		a, b  []interface{}
+		cmp   int
+	}{
+		{SortOrder{&SortField{}}, []interface{}{1.0}, []interface{}{2.0}, -1},
+		{SortOrder{&SortField{Desc: true}}, []interface{}{1.0}, []interface{}{2.0}, 1},
+		{SortOrder{&SortField{}}, []interface{}{"a"}, []interface{}{"b"}, -1},
+		// numbers before strings
+		{SortOrder{&SortField{}}, []interface{}{"1"}, []interface{}{2.0}, 1},
+		// missing values last in both directions
+		{SortOrder{&SortField{}}, []interface{}{nil}, []interface{}{2.0}, 1},
+		{SortOrder{&SortField{Desc: true}}, []interface{}{nil}, []interface{}{2.0}, 1},
+		{SortOrder{&SortField{}}, []interface{}{nil}, []interface{}{nil}, 0},
+		{SortOrder{&SortField{Missing: SortFieldMissingLast}}, []interface{}{nil}, []interface{}{2.0}, 1},
+		{SortOrder{&SortField{Missing: SortFieldMissingFirst}}, []interface{}{nil}, []interface{}{2.0}, -1},
+		{SortOrder{&SortField{Missing: SortFieldMissingFirst, Desc: true}}, []interface{}{nil}, []interface{}{2.0}, -1},
+		// missing modes apply to each level independently
+		{SortOrder{&SortField{}, &SortField{Missing: SortFieldMissingFirst}}, []interface{}{1.0, nil}, []interface{}{1.0, "a"}, -1},
+		{SortOrder{&SortField{Missing: SortFieldMissingFirst}, &SortField{}}, []interface{}{1.0, nil}, []interface{}{1.0, "a"}, 1},
+		// ties broken by the next level
+		{SortOrder{&SortField{}, &SortField{Desc: true}}, []interface{}{1.0, "a"}, []interface{}{1.0, "b"}, 1},
+		{SortOrder{&SortField{}, &SortField{}}, []interface{}{1.0, "a"}, []interface{}{1.0, "a"}, 0},
+	}
+
+	for _, test := range tests {
+		a := &DocumentMatch{Sort: test.a}
+		b := &DocumentMatch{Sort: test.b}
+		actual := test.order.Compare(a, b)
+		if actual != test.cmp {
+			t.Errorf("expected %d, got %d comparing %v to %v", test.cmp, actual, test.a, test.b)
+		}
+	}
+}
+
+func TestSortFieldValue(t *testing.T) {
+	doc := document.NewDocument("a").
+		AddField(document.NewTextField("name", []uint64{}, []byte("marty"))).
+		AddField(document.NewNumericField("rating", []uint64{0}, 3.0)).
+		AddField(document.NewNumericField("rating", []uint64{1}, 5.0)).
+		AddField(document.NewNumericField("loc.lat", []uint64{}, 37.5)).
+		AddField(document.NewNumericField("loc.lon", []uint64{}, -122.25))
+
+	tests := []struct {
+		sort  SearchSort
+		value interface{}
+	}{
+		{&SortField{Field: "name"}, "marty"},
+		{&SortField{Field: "rating"}, 3.0},
+		{&SortField{Field: "rating", Desc: true}, 5.0},
+		{&SortField{Field: "missing"}, nil},
+		{&SortField{Field: "missing", Missing: SortFieldMissingFirst}, nil},
+		{&SortField{Field: "missing", Missing: "zzz"}, "zzz"},
+		{&SortField{Field: "missing", Missing: 4}, 4.0},
+		{&SortField{Field: "rating", Missing: 4}, 3.0},
+		{&SortGeoDistance{Field: "loc", Location: geo.Point{Lon: -122.25, Lat: 37.5}}, 0.0},
+		{&SortGeoDistance{Field: "missing"}, nil},
+	}
+
+	for _, test := range tests {
+		actual := test.sort.Value(&DocumentMatch{ID: "a"}, doc)
+		if !reflect.DeepEqual(actual, test.value) {
+			t.Errorf("expected %v, got %v for %#v", test.value, actual, test.sort)
+		}
+	}
+}
+
+func TestParseSortOrder(t *testing.T) {
+	geoSort, err := NewSortGeoDistance("loc", -122.25, 37.5, "km", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := SortOrder{
+		&SortScore{Desc: true},
+		&SortDocID{},
+		&SortField{Field: "name"},
+		&SortField{Field: "age", Desc: true},
+		geoSort,
+		&SortField{Field: "rank", Missing: SortFieldMissingFirst},
+		&SortField{Field: "rank", Missing: 7.0},
+	}
+
+	actual := ParseSortOrderStrings([]string{"-_score", "_id", "name", "-age"})
+	if !reflect.DeepEqual(actual, expected[:4]) {
+		t.Errorf("expected %v, got %v", expected[:4], actual)
+	}
+
+	var raw []json.RawMessage
+	err = json.Unmarshal([]byte(`["-_score", {"by":"id"}, {"by":"field","field":"name"}, "-age",
+		{"by":"geo_distance","field":"loc","location":{"lon":-122.25,"lat":37.5},"unit":"km"},
+		{"by":"field","field":"rank","missing":"first"}, {"by":"field","field":"rank","missing":7}]`), &raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual, err = ParseSortOrderJSON(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+
+	// sorts marshal to the object form
+	marshalled, err := json.Marshal(expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = json.Unmarshal(marshalled, &raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual, err = ParseSortOrderJSON(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v after round trip", expected, actual)
+	}
+
+	invalid := []string{
+		`{"by":"color"}`,
+		`{"by":"field"}`,
+		`{"by":"field","field":"rank","missing":true}`,
+		`{"by":"geo_distance","field":"loc"}`,
+		`{"by":"geo_distance","field":"loc","location":{"lon":0,"lat":0},"unit":"furlongs"}`,
+		`7`,
+	}
+	for _, in := range invalid {
+		_, err := ParseSortOrderJSON([]json.RawMessage{json.RawMessage(in)})
+		if err == nil {
+			t.Errorf("expected error for %s", in)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package bleve
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// placeholderOnly matches a template string made of a
+// single parameter placeholder, such as "{{.size}}".
+var placeholderOnly = regexp.MustCompile(`^\{\{\s*\.([A-Za-z_][A-Za-z0-9_]*)\s*\}\}$`)
+
+// queryStringSyntax replaces the characters with a
+// meaning in the query string syntax by spaces.
+var queryStringSyntax = strings.NewReplacer(
+	"+", " ", "-", " ", ":", " ", "^", " ", "(", " ", ")", " ",
+	">", " ", "<", " ", "=", " ", "~", " ", "\"", " ", "\\", " ")
+
+// A SearchTemplate is the JSON representation of a
+// SearchRequest with parameter placeholders, such as
+// "{{.term}}", in its string values.  Placeholders
+// follow the text/template syntax.
+//
+// The template is decoded once, the parameters are
+// substituted into the decoded values, so they cannot
+// alter the structure of the request.  A string made
+// of a single placeholder takes the value of the
+// parameter with its type, for example
+// "size": "{{.size}}" with an integer size, which must be
+// a string, a number or a boolean.  In the
+// text of a query string query, the characters of the
+// query string syntax are removed from the parameters,
+// so they are searched as plain terms.
+type SearchTemplate struct {
+	source    interface{}
+	templates map[string]*template.Template
+}
+
+// NewSearchTemplate parses a search template from
+// its JSON representation.
+func NewSearchTemplate(source []byte) (*SearchTemplate, error) {
+	rv := SearchTemplate{
+		templates: make(map[string]*template.Template),
+	}
+	decoder := json.NewDecoder(bytes.NewReader(source))
+	decoder.UseNumber()
+	err := decoder.Decode(&rv.source)
+	if err != nil {
+		return nil, err
+	}
+	err = rv.parse(rv.source)
+	if err != nil {
+		return nil, err
+	}
+	return &rv, nil
+}
+
+func (t *SearchTemplate) parse(v interface{}) error {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for _, child := range v {
+			err := t.parse(child)
+			if err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, child := range v {
+			err := t.parse(child)
+			if err != nil {
+				return err
+			}
+		}
+	case string:
+		if !strings.Contains(v, "{{") {
+			return nil
+		}
+		if _, ok := t.templates[v]; ok {
+			return nil
+		}
+		tmpl, err := template.New("").Option("missingkey=error").Parse(v)
+		if err != nil {
+			return err
+		}
+		t.templates[v] = tmpl
+	}
+	return nil
+}
+
+// Execute substitutes the parameters into the template
+// and returns the resulting SearchRequest.  Every
+// parameter referenced by the template must be set.
+func (t *SearchTemplate) Execute(params map[string]interface{}) (*SearchRequest, error) {
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+	var queryStringParams map[string]interface{}
+	if len(t.templates) > 0 {
+		queryStringParams = make(map[string]interface{}, len(params))
+		for k, v := range params {
+			queryStringParams[k] = escapeQueryStringParam(v)
+		}
+	}
+	rendered, err := t.render(t.source, params, queryStringParams, false)
+	if err != nil {
+		return nil, err
+	}
+	requestBytes, err := json.Marshal(rendered)
+	if err != nil {
+		return nil, err
+	}
+	var rv SearchRequest
+	err = json.Unmarshal(requestBytes, &rv)
+	if err != nil {
+		return nil, err
+	}
+	return &rv, nil
+}
+
+func (t *SearchTemplate) render(v interface{}, params, queryStringParams map[string]interface{}, queryString bool) (interface{}, error) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		rv := make(map[string]interface{}, len(v))
+		for k, child := range v {
+			// a string query is the text of a query string query
+			_, isString := child.(string)
+			renderedChild, err := t.render(child, params, queryStringParams, k == "query" && isString)
+			if err != nil {
+				return nil, err
+			}
+			rv[k] = renderedChild
+		}
+		return rv, nil
+	case []interface{}:
+		rv := make([]interface{}, len(v))
+		for i, child := range v {
+			renderedChild, err := t.render(child, params, queryStringParams, false)
+			if err != nil {
+				return nil, err
+			}
+			rv[i] = renderedChild
+		}
+		return rv, nil
+	case string:
+		tmpl, ok := t.templates[v]
+		if !ok {
+			return v, nil
+		}
+		if queryString {
+			params = queryStringParams
+		}
+		if match := placeholderOnly.FindStringSubmatch(v); match != nil {
+			if param, ok := params[match[1]]; ok {
+				if !isScalarParam(param) {
+					return nil, ErrorSearchTemplateParamType
+				}
+				return param, nil
+			}
+		}
+		var buf bytes.Buffer
+		err := tmpl.Execute(&buf, params)
+		if err != nil {
+			return nil, err
+		}
+		return buf.String(), nil
+	}
+	return v, nil
+}
+
+// isScalarParam returns whether the parameter can be
+// substituted with its type, without adding objects or
+// arrays to the request
+func isScalarParam(v interface{}) bool {
+	switch v.(type) {
+	case string, bool, json.Number,
+		int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64,
+		float32, float64:
+		return true
+	}
+	return false
+}
+
+func escapeQueryStringParam(v interface{}) interface{} {
+	if s, ok := v.(string); ok {
+		return queryStringSyntax.Replace(s)
+	}
+	return v
+}
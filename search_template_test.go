@@ -0,0 +1,139 @@
+//  Copyright (c) 2014 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License"); you may not use this file
+//  except in compliance with the License. You may obtain a copy of the License at
+//    http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing, software distributed under the
+//  License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+//  either express or implied. See the License for the specific language governing permissions
+//  and limitations under the License.
+
+package bleve
+
+import (
+	"testing"
+)
+
+func TestSearchTemplateExecute(t *testing.T) {
+	tmpl, err := NewSearchTemplate([]byte(`{
+		"query": {"match": "{{.term}}", "field": "name"},
+		"size": "{{.size}}",
+		"fields": ["name", "{{.field}}"]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := tmpl.Execute(map[string]interface{}{
+		"term":  "marty",
+		"size":  25,
+		"field": "desc",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Size != 25 {
+		t.Errorf("expected size 25, got %d", req.Size)
+	}
+	q, ok := req.Query.(*matchQuery)
+	if !ok {
+		t.Fatalf("expected match query, got %T", req.Query)
+	}
+	if q.Match != "marty" || q.FieldVal != "name" {
+		t.Errorf("expected match marty on name, got %s on %s", q.Match, q.FieldVal)
+	}
+	if len(req.Fields) != 2 || req.Fields[1] != "desc" {
+		t.Errorf("expected fields [name desc], got %v", req.Fields)
+	}
+
+	// the template can be executed again with other params
+	req, err = tmpl.Execute(map[string]interface{}{
+		"term":  "steve",
+		"size":  5,
+		"field": "age",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Size != 5 || req.Query.(*matchQuery).Match != "steve" {
+		t.Errorf("expected size 5 matching steve, got %d matching %s", req.Size, req.Query.(*matchQuery).Match)
+	}
+
+	_, err = tmpl.Execute(map[string]interface{}{"term": "marty"})
+	if err == nil {
+		t.Errorf("expected error for missing params")
+	}
+}
+
+func TestSearchTemplateInjection(t *testing.T) {
+	tmpl, err := NewSearchTemplate([]byte(`{
+		"query": {"match": "{{.term}}", "field": "name"},
+		"size": 10
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	term := `marty"}, "size": 1000, "explain": true, "x": {"y": "`
+	req, err := tmpl.Execute(map[string]interface{}{"term": term})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Size != 10 || req.Explain {
+		t.Errorf("expected param not to alter the request, got size %d explain %t", req.Size, req.Explain)
+	}
+	if req.Query.(*matchQuery).Match != term {
+		t.Errorf("expected match %q, got %q", term, req.Query.(*matchQuery).Match)
+	}
+
+	tmpl, err = NewSearchTemplate([]byte(`{
+		"query": {"query": "+name:{{.term}} -secret:true"},
+		"size": 10
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err = tmpl.Execute(map[string]interface{}{
+		"term": `marty ^5 -name:steve secret:"true" (x)`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, ok := req.Query.(*queryStringQuery)
+	if !ok {
+		t.Fatalf("expected query string query, got %T", req.Query)
+	}
+	expected := `+name:marty  5  name steve secret  true   x  -secret:true`
+	if q.Query != expected {
+		t.Errorf("expected query %q, got %q", expected, q.Query)
+	}
+	_, err = parseQuerySyntax(q.Query, NewIndexMapping())
+	if err != nil {
+		t.Errorf("expected escaped query to parse, got %v", err)
+	}
+
+	// a placeholder cannot be replaced by another query
+	tmpl, err = NewSearchTemplate([]byte(`{"query": "{{.q}}"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, param := range []interface{}{
+		map[string]interface{}{"match_all": map[string]interface{}{}},
+		[]interface{}{"marty"},
+		nil,
+	} {
+		_, err = tmpl.Execute(map[string]interface{}{"q": param})
+		if err != ErrorSearchTemplateParamType {
+			t.Errorf("%v: expected %v, got %v", param, ErrorSearchTemplateParamType, err)
+		}
+	}
+}
+
+func TestSearchTemplateInvalid(t *testing.T) {
+	_, err := NewSearchTemplate([]byte(`{"query": {"match": "{{.term"}}`))
+	if err == nil {
+		t.Errorf("expected error for unterminated placeholder")
+	}
+	_, err = NewSearchTemplate([]byte(`{"size": {{.size}}}`))
+	if err == nil {
+		t.Errorf("expected error for placeholder outside a string")
+	}
+}
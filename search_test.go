@@ -118,3 +118,25 @@ func TestSearchResultMerge(t *testing.T) {
 		t.Errorf("expected %#v, got %#v", expected, l)
 	}
 }
+
+func TestSearchResultNormalizeScoresZero(t *testing.T) {
+	sr := &SearchResult{
+		Total: 2,
+		Hits: search.DocumentMatchCollection{
+			&search.DocumentMatch{
+				ID: "a",
+			},
+			&search.DocumentMatch{
+				ID: "b",
+			},
+		},
+	}
+
+	sr.normalizeScores(ScoreNormalizationMax)
+
+	for _, hit := range sr.Hits {
+		if hit.NormalizedScore != 0 {
+			t.Errorf("expected normalized score 0 for %s, got %f", hit.ID, hit.NormalizedScore)
+		}
+	}
+}
@@ -134,7 +134,11 @@ func runTestDir(t *testing.T, dir string) {
 				t.Errorf("expected hit %d to have ID %s got %s", hi, hit.ID, res.Hits[hi].ID)
 			}
 			if hit.Fields != nil {
-				if !reflect.DeepEqual(hit.Fields, res.Hits[hi].Fields) {
+				fields, err := jsonFields(res.Hits[hi].Fields)
+				if err != nil {
+					t.Errorf("error converting hit %d fields: %v", hi, err)
+				}
+				if !reflect.DeepEqual(hit.Fields, fields) {
 					t.Errorf("expected hit %d to have fields %#v got %#v", hi, hit.Fields, res.Hits[hi].Fields)
 				}
 			}
@@ -151,3 +155,16 @@ func runTestDir(t *testing.T, dir string) {
 		}
 	}
 }
+
+// jsonFields returns the fields as they are read back from
+// their JSON form, like those of the expected results, so
+// stored dates compare as their RFC3339 strings
+func jsonFields(fields map[string]interface{}) (map[string]interface{}, error) {
+	fieldsBytes, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	var rv map[string]interface{}
+	err = json.Unmarshal(fieldsBytes, &rv)
+	return rv, err
+}